@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+const (
+	cefVendor        = "DKonsole"
+	cefProduct       = "DKonsole"
+	cefDeviceVersion = "2"
+)
+
+// FormatCEF formats a record as an ArcSight Common Event Format line:
+// CEF:0|DKonsole|DKonsole|2|<verb>|<verb kind>|<severity>|<extension>
+// Failed actions get severity 7, the rest 3.
+func FormatCEF(record models.AuditRecord) string {
+	severity := 3
+	if record.Status == StatusFailure {
+		severity = 7
+	}
+	name := strings.TrimSpace(record.Verb + " " + record.Kind)
+
+	ext := []struct{ key, value string }{
+		{"rt", strconv.FormatInt(record.Time.UnixMilli(), 10)},
+		{"externalId", record.ID},
+		{"suser", record.User},
+		{"src", record.IP},
+		{"act", record.Verb},
+		{"outcome", record.Status},
+		{"cs1Label", "cluster"},
+		{"cs1", record.Cluster},
+		{"cs2Label", "kind"},
+		{"cs2", record.Kind},
+		{"cs3Label", "namespace"},
+		{"cs3", record.Namespace},
+		{"cs4Label", "name"},
+		{"cs4", record.Name},
+		{"cs5Label", "diffHash"},
+		{"cs5", record.DiffHash},
+		{"msg", record.Error},
+	}
+
+	var parts []string
+	for i, field := range ext {
+		if field.value == "" {
+			continue
+		}
+		// Skip labels of empty custom strings
+		if strings.HasSuffix(field.key, "Label") && (i+1 >= len(ext) || ext[i+1].value == "") {
+			continue
+		}
+		parts = append(parts, field.key+"="+escapeCEFValue(field.value))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		escapeCEFHeader(cefVendor),
+		escapeCEFHeader(cefProduct),
+		escapeCEFHeader(cefDeviceVersion),
+		escapeCEFHeader(record.Verb),
+		escapeCEFHeader(name),
+		severity,
+		strings.Join(parts, " "),
+	)
+}
+
+// escapeCEFHeader escapes '\' and '|' in header fields
+func escapeCEFHeader(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\n", " ", "\r", " ").Replace(value)
+}
+
+// escapeCEFValue escapes '\', '=' and line breaks in extension values
+func escapeCEFValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\n", `\n`, "\r", `\r`).Replace(value)
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestFormatCEF(t *testing.T) {
+	record := models.AuditRecord{
+		ID:        "0001",
+		Time:      time.UnixMilli(1700000000123),
+		User:      "alice",
+		IP:        "10.0.0.1",
+		Cluster:   "prod",
+		Verb:      "delete",
+		Kind:      "Deployment",
+		Namespace: "shop",
+		Name:      "web",
+		Status:    StatusFailure,
+		Error:     "a=b\nc\\d",
+	}
+
+	got := FormatCEF(record)
+	wantPrefix := "CEF:0|DKonsole|DKonsole|2|delete|delete Deployment|7|"
+	if !strings.HasPrefix(got, wantPrefix) {
+		t.Fatalf("FormatCEF() = %s, want prefix %s", got, wantPrefix)
+	}
+
+	for _, want := range []string{
+		"rt=1700000000123",
+		"externalId=0001",
+		"suser=alice",
+		"src=10.0.0.1",
+		"act=delete",
+		"outcome=failure",
+		"cs1Label=cluster cs1=prod",
+		"cs2Label=kind cs2=Deployment",
+		"cs3Label=namespace cs3=shop",
+		"cs4Label=name cs4=web",
+		`msg=a\=b\nc\\d`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatCEF() = %s, missing %s", got, want)
+		}
+	}
+	if strings.Contains(got, "cs5") {
+		t.Errorf("empty custom strings should be omitted with their label: %s", got)
+	}
+}
+
+func TestFormatCEF_EscapesHeader(t *testing.T) {
+	got := FormatCEF(models.AuditRecord{Verb: `a|b\c`, Status: StatusSuccess})
+	if !strings.HasPrefix(got, `CEF:0|DKonsole|DKonsole|2|a\|b\\c|a\|b\\c|3|`) {
+		t.Errorf("FormatCEF() = %s", got)
+	}
+}
@@ -0,0 +1,153 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// StoreMemory, StoreFile and StoreConfigMap are the values of AUDIT_STORE
+	StoreMemory    = "memory"
+	StoreFile      = "file"
+	StoreConfigMap = "configmap"
+
+	defaultFilePath = "/var/lib/dkonsole/audit/audit.jsonl"
+)
+
+// Config selects and sizes the audit store
+type Config struct {
+	Store              string // StoreMemory (default), StoreFile or StoreConfigMap
+	MemorySize         int
+	FilePath           string
+	FileMaxSize        int64
+	FileMaxBackups     int
+	ConfigMapPrefix    string
+	ConfigMapMaxChunks int
+}
+
+// ConfigFromEnv reads the audit store settings from the environment:
+// AUDIT_STORE (memory, file or configmap), AUDIT_MEMORY_SIZE, AUDIT_FILE_PATH,
+// AUDIT_FILE_MAX_SIZE_MB, AUDIT_FILE_MAX_BACKUPS, AUDIT_CONFIGMAP_PREFIX and AUDIT_CONFIGMAP_MAX_CHUNKS.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Store:              strings.ToLower(strings.TrimSpace(os.Getenv("AUDIT_STORE"))),
+		MemorySize:         envInt("AUDIT_MEMORY_SIZE", defaultMemorySize),
+		FilePath:           os.Getenv("AUDIT_FILE_PATH"),
+		FileMaxSize:        int64(envInt("AUDIT_FILE_MAX_SIZE_MB", defaultFileMaxSize>>20)) << 20,
+		FileMaxBackups:     envInt("AUDIT_FILE_MAX_BACKUPS", defaultFileMaxBackups),
+		ConfigMapPrefix:    os.Getenv("AUDIT_CONFIGMAP_PREFIX"),
+		ConfigMapMaxChunks: envInt("AUDIT_CONFIGMAP_MAX_CHUNKS", defaultConfigMapMaxChunks),
+	}
+	if cfg.Store == "" {
+		cfg.Store = StoreMemory
+	}
+	if cfg.FilePath == "" {
+		cfg.FilePath = defaultFilePath
+	}
+	return cfg
+}
+
+// NewStore creates the store selected by cfg
+func NewStore(cfg Config, client kubernetes.Interface) (Store, error) {
+	switch cfg.Store {
+	case "", StoreMemory:
+		return NewMemoryStore(cfg.MemorySize), nil
+	case StoreFile:
+		return NewFileStore(cfg.FilePath, cfg.FileMaxSize, cfg.FileMaxBackups)
+	case StoreConfigMap:
+		return NewConfigMapStore(client, cfg.ConfigMapPrefix, cfg.ConfigMapMaxChunks)
+	default:
+		return nil, fmt.Errorf("unknown audit store %q (use %s, %s or %s)", cfg.Store, StoreMemory, StoreFile, StoreConfigMap)
+	}
+}
+
+// ForwardConfig configures the external destinations audit records are forwarded to
+type ForwardConfig struct {
+	WebhookURL     string
+	WebhookSecret  string
+	WebhookTimeout time.Duration
+	SyslogAddress  string // host:port; empty disables syslog
+	SyslogNetwork  string // "udp" (default) or "tcp"
+	SyslogFormat   string // SyslogFormatJSON (default) or SyslogFormatCEF
+	QueueSize      int    // per destination
+	MaxRetries     int
+}
+
+// ForwardConfigFromEnv reads the forwarding settings from the environment:
+// AUDIT_WEBHOOK_URL, AUDIT_WEBHOOK_SECRET, AUDIT_WEBHOOK_TIMEOUT (a Go duration),
+// AUDIT_SYSLOG_ADDRESS, AUDIT_SYSLOG_NETWORK, AUDIT_SYSLOG_FORMAT,
+// AUDIT_FORWARD_QUEUE_SIZE and AUDIT_FORWARD_MAX_RETRIES.
+func ForwardConfigFromEnv() ForwardConfig {
+	cfg := ForwardConfig{
+		WebhookURL:     strings.TrimSpace(os.Getenv("AUDIT_WEBHOOK_URL")),
+		WebhookSecret:  os.Getenv("AUDIT_WEBHOOK_SECRET"),
+		WebhookTimeout: defaultWebhookTimeout,
+		SyslogAddress:  strings.TrimSpace(os.Getenv("AUDIT_SYSLOG_ADDRESS")),
+		SyslogNetwork:  strings.ToLower(strings.TrimSpace(os.Getenv("AUDIT_SYSLOG_NETWORK"))),
+		SyslogFormat:   strings.ToLower(strings.TrimSpace(os.Getenv("AUDIT_SYSLOG_FORMAT"))),
+		QueueSize:      envInt("AUDIT_FORWARD_QUEUE_SIZE", defaultForwardQueueSize),
+		MaxRetries:     envInt("AUDIT_FORWARD_MAX_RETRIES", defaultForwardMaxRetries),
+	}
+
+	if timeout := os.Getenv("AUDIT_WEBHOOK_TIMEOUT"); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err == nil && d > 0 {
+			cfg.WebhookTimeout = d
+		} else {
+			utils.LogWarn("Invalid AUDIT_WEBHOOK_TIMEOUT, using default", map[string]interface{}{
+				"value":   timeout,
+				"default": defaultWebhookTimeout.String(),
+			})
+		}
+	}
+	return cfg
+}
+
+// NewForwarders creates a forwarder for each configured destination
+func NewForwarders(cfg ForwardConfig) ([]*Forwarder, error) {
+	var forwarders []*Forwarder
+
+	if cfg.WebhookURL != "" {
+		sender, err := NewWebhookSender(cfg.WebhookURL, cfg.WebhookSecret, cfg.WebhookTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.WebhookSecret == "" {
+			utils.LogWarn("AUDIT_WEBHOOK_SECRET is not set, audit webhook requests are not signed", nil)
+		}
+		forwarders = append(forwarders, NewForwarder("webhook", sender, cfg.QueueSize, cfg.MaxRetries))
+	}
+
+	if cfg.SyslogAddress != "" {
+		sender, err := NewSyslogSender(cfg.SyslogNetwork, cfg.SyslogAddress, cfg.SyslogFormat)
+		if err != nil {
+			return nil, err
+		}
+		forwarders = append(forwarders, NewForwarder("syslog", sender, cfg.QueueSize, cfg.MaxRetries))
+	}
+
+	return forwarders, nil
+}
+
+func envInt(name string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		utils.LogWarn("Invalid audit setting, using default", map[string]interface{}{
+			"name":    name,
+			"value":   value,
+			"default": fallback,
+		})
+		return fallback
+	}
+	return n
+}
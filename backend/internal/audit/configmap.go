@@ -0,0 +1,324 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	defaultConfigMapPrefix    = "dkonsole-audit"
+	defaultConfigMapMaxChunks = 20
+
+	// maxChunkBytes keeps every chunk well below the 1MB object size limit of the API server
+	maxChunkBytes = 512 << 10
+	// chunkRecordsKey is the ConfigMap entry holding the JSONL records of a chunk
+	chunkRecordsKey = "records.jsonl"
+	// chunkLabel marks the audit chunks; its value is the store prefix
+	chunkLabel = "dkonsole.io/audit-chunk"
+
+	// configMapFlushInterval is how often buffered records are written to the cluster
+	configMapFlushInterval = 5 * time.Second
+	// maxPendingRecords bounds the buffer while the API server is unreachable; the oldest records are dropped
+	maxPendingRecords = 5000
+
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+// ConfigMapStore keeps records in a rolling set of ConfigMaps ("chunks") in the DKonsole namespace,
+// named <prefix>-<first record ID> so they sort by time. Appends are buffered and written every
+// configMapFlushInterval by Run; only the newest maxChunks chunks are kept.
+type ConfigMapStore struct {
+	mu        sync.Mutex // guards client and pending
+	flushMu   sync.Mutex // serializes writes to the cluster
+	client    kubernetes.Interface
+	namespace string
+	prefix    string
+	maxChunks int
+	maxBytes  int // size of a chunk, maxChunkBytes outside of tests
+	pending   []models.AuditRecord
+}
+
+// NewConfigMapStore creates a ConfigMap-backed store in the current namespace.
+// client may be nil until the Kubernetes connection is configured; records are buffered meanwhile.
+func NewConfigMapStore(client kubernetes.Interface, prefix string, maxChunks int) (*ConfigMapStore, error) {
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current namespace: %w", err)
+	}
+	if prefix == "" {
+		prefix = defaultConfigMapPrefix
+	}
+	if err := utils.ValidateK8sName(prefix, "prefix"); err != nil {
+		return nil, err
+	}
+	if maxChunks <= 0 {
+		maxChunks = defaultConfigMapMaxChunks
+	}
+
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+		prefix:    prefix,
+		maxChunks: maxChunks,
+		maxBytes:  maxChunkBytes,
+	}, nil
+}
+
+// SetClient replaces the Kubernetes client, e.g. after the service account token changed
+func (s *ConfigMapStore) SetClient(client kubernetes.Interface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = client
+}
+
+// Append buffers a record until the next flush
+func (s *ConfigMapStore) Append(ctx context.Context, record models.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, record)
+	if dropped := len(s.pending) - maxPendingRecords; dropped > 0 {
+		s.pending = append([]models.AuditRecord(nil), s.pending[dropped:]...)
+		utils.LogWarn("Audit buffer full, dropping oldest records", map[string]interface{}{
+			"dropped": dropped,
+		})
+	}
+	return nil
+}
+
+// Run flushes the buffered records every configMapFlushInterval until ctx is cancelled,
+// then makes a last attempt
+func (s *ConfigMapStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(configMapFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flushAndLog(ctx)
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			s.flushAndLog(flushCtx)
+			cancel()
+			return
+		}
+	}
+}
+
+func (s *ConfigMapStore) flushAndLog(ctx context.Context) {
+	if err := s.Flush(ctx); err != nil {
+		utils.LogWarn("Failed to write audit records to ConfigMaps", map[string]interface{}{
+			"namespace": s.namespace,
+			"error":     err.Error(),
+		})
+	}
+}
+
+// Flush writes the buffered records to the newest chunk, starting new chunks as they fill up.
+// On failure the records stay buffered for the next attempt.
+func (s *ConfigMapStore) Flush(ctx context.Context) error {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	client := s.client
+	records := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+
+	if client == nil || len(records) == 0 {
+		s.requeue(records)
+		return nil
+	}
+
+	written, err := s.write(ctx, client, records)
+	if err != nil {
+		s.requeue(records[written:])
+		return err
+	}
+	return nil
+}
+
+// requeue puts records that could not be written back in front of the buffer
+func (s *ConfigMapStore) requeue(records []models.AuditRecord) {
+	if len(records) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(records, s.pending...)
+	if dropped := len(s.pending) - maxPendingRecords; dropped > 0 {
+		s.pending = s.pending[dropped:]
+	}
+}
+
+// Query reads all chunks plus the buffered records and returns a page of them
+func (s *ConfigMapStore) Query(ctx context.Context, q Query) (*models.PaginatedAuditRecords, error) {
+	s.mu.Lock()
+	client := s.client
+	records := append([]models.AuditRecord(nil), s.pending...)
+	s.mu.Unlock()
+
+	if client != nil {
+		chunks, err := s.listChunks(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		for i := range chunks {
+			records = append(records, parseChunk(chunks[i].Data[chunkRecordsKey])...)
+		}
+	}
+	return paginate(records, q), nil
+}
+
+// write stores records and returns how many of them were written before any error
+func (s *ConfigMapStore) write(ctx context.Context, client kubernetes.Interface, records []models.AuditRecord) (int, error) {
+	lines := make([]string, 0, len(records))
+	for i := range records {
+		line, err := json.Marshal(records[i])
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		lines = append(lines, string(line)+"\n")
+	}
+
+	configMaps := client.CoreV1().ConfigMaps(s.namespace)
+	chunks, err := s.listChunks(ctx, client)
+	if err != nil {
+		return 0, err
+	}
+
+	// Fill up the newest chunk first
+	written := 0
+	if len(chunks) > 0 {
+		latest := chunks[len(chunks)-1].Name
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			written = 0
+			cm, err := configMaps.Get(ctx, latest, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			data := cm.Data[chunkRecordsKey]
+			for written < len(lines) && len(data)+len(lines[written]) <= s.maxBytes {
+				data += lines[written]
+				written++
+			}
+			if written == 0 {
+				return nil
+			}
+			if cm.Data == nil {
+				cm.Data = make(map[string]string)
+			}
+			cm.Data[chunkRecordsKey] = data
+			_, err = configMaps.Update(ctx, cm, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return 0, fmt.Errorf("failed to update audit chunk: %w", err)
+		}
+		if err != nil {
+			written = 0
+		}
+	}
+
+	created := false
+	for written < len(lines) {
+		first, end := written, written
+		var data strings.Builder
+		for end < len(lines) && (end == first || data.Len()+len(lines[end]) <= s.maxBytes) {
+			data.WriteString(lines[end])
+			end++
+		}
+		cm := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.prefix + "-" + records[first].ID,
+				Namespace: s.namespace,
+				Labels:    map[string]string{chunkLabel: s.prefix},
+			},
+			Data: map[string]string{chunkRecordsKey: data.String()},
+		}
+		if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return written, fmt.Errorf("failed to create audit chunk: %w", err)
+		}
+		written = end
+		created = true
+	}
+
+	if created {
+		s.prune(ctx, client)
+	}
+	return written, nil
+}
+
+// prune deletes the oldest chunks beyond maxChunks
+func (s *ConfigMapStore) prune(ctx context.Context, client kubernetes.Interface) {
+	chunks, err := s.listChunks(ctx, client)
+	if err != nil || len(chunks) <= s.maxChunks {
+		return
+	}
+	for i := 0; i < len(chunks)-s.maxChunks; i++ {
+		err := client.CoreV1().ConfigMaps(s.namespace).Delete(ctx, chunks[i].Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			utils.LogWarn("Failed to delete old audit chunk", map[string]interface{}{
+				"configmap": chunks[i].Name,
+				"error":     err.Error(),
+			})
+		}
+	}
+}
+
+// listChunks returns the chunks of this store, oldest first
+func (s *ConfigMapStore) listChunks(ctx context.Context, client kubernetes.Interface) ([]corev1.ConfigMap, error) {
+	list, err := client.CoreV1().ConfigMaps(s.namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: chunkLabel + "=" + s.prefix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit chunks: %w", err)
+	}
+	chunks := list.Items
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].Name < chunks[j].Name })
+	return chunks, nil
+}
+
+func parseChunk(data string) []models.AuditRecord {
+	var records []models.AuditRecord
+	for _, line := range strings.Split(data, "\n") {
+		if line == "" {
+			continue
+		}
+		var record models.AuditRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil || record.ID == "" {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// getCurrentNamespace gets the namespace where the application is running
+func getCurrentNamespace() (string, error) {
+	if data, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		if namespace := strings.TrimSpace(string(data)); namespace != "" {
+			return namespace, nil
+		}
+	}
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace, nil
+	}
+	return "", fmt.Errorf("could not determine namespace: service account file not found and POD_NAMESPACE not set")
+}
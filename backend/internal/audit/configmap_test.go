@@ -0,0 +1,194 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestConfigMapStore(t *testing.T, maxChunks int) (*ConfigMapStore, *fake.Clientset) {
+	t.Helper()
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	client := fake.NewSimpleClientset()
+	store, err := NewConfigMapStore(client, "", maxChunks)
+	if err != nil {
+		t.Fatalf("NewConfigMapStore() error = %v", err)
+	}
+	return store, client
+}
+
+func listChunkNames(t *testing.T, client *fake.Clientset) []string {
+	t.Helper()
+	list, err := client.CoreV1().ConfigMaps("dkonsole").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var names []string
+	for _, cm := range list.Items {
+		names = append(names, cm.Name)
+	}
+	return names
+}
+
+func TestConfigMapStore_FlushAndQuery(t *testing.T) {
+	ctx := context.Background()
+	store, client := newTestConfigMapStore(t, 0)
+	records := testRecords(4)
+
+	for _, record := range records[:2] {
+		if err := store.Append(ctx, record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if names := listChunkNames(t, client); len(names) != 0 {
+		t.Fatalf("records should be buffered until flushed, got %v", names)
+	}
+
+	// Buffered records are already visible
+	page, err := store.Query(ctx, Query{})
+	if err != nil || len(page.Records) != 2 {
+		t.Fatalf("Query() = %+v, %v", page, err)
+	}
+
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	names := listChunkNames(t, client)
+	if len(names) != 1 || names[0] != "dkonsole-audit-"+records[0].ID {
+		t.Fatalf("chunks = %v", names)
+	}
+
+	// Later records are appended to the same chunk
+	for _, record := range records[2:] {
+		_ = store.Append(ctx, record)
+	}
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if names := listChunkNames(t, client); len(names) != 1 {
+		t.Fatalf("chunks = %v, want 1", names)
+	}
+
+	page, err = store.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 4 || page.Records[0].Name != "app-3" {
+		t.Errorf("records = %+v", page.Records)
+	}
+}
+
+func TestConfigMapStore_ChunksAndPruning(t *testing.T) {
+	ctx := context.Background()
+	store, client := newTestConfigMapStore(t, 3)
+	store.maxBytes = 450 // two records per chunk
+
+	records := testRecords(10)
+	for _, record := range records {
+		_ = store.Append(ctx, record)
+	}
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	names := listChunkNames(t, client)
+	if len(names) != 3 {
+		t.Fatalf("chunks = %v, want the newest 3", names)
+	}
+	for _, name := range names {
+		if !strings.HasPrefix(name, "dkonsole-audit-") {
+			t.Errorf("unexpected chunk name %s", name)
+		}
+	}
+
+	page, err := store.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 6 || page.Records[0].Name != "app-9" || page.Records[5].Name != "app-4" {
+		t.Errorf("records = %d", len(page.Records))
+	}
+}
+
+func TestConfigMapStore_IgnoresOtherConfigMaps(t *testing.T) {
+	ctx := context.Background()
+	store, client := newTestConfigMapStore(t, 0)
+	other, err := NewConfigMapStore(client, "other-audit", 0)
+	if err != nil {
+		t.Fatalf("NewConfigMapStore() error = %v", err)
+	}
+
+	records := testRecords(2)
+	_ = store.Append(ctx, records[0])
+	_ = other.Append(ctx, records[1])
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if err := other.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	page, err := store.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Name != "app-0" {
+		t.Errorf("records = %+v", page.Records)
+	}
+}
+
+func TestConfigMapStore_KeepsRecordsOnFailure(t *testing.T) {
+	ctx := context.Background()
+	store, client := newTestConfigMapStore(t, 0)
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("api server unavailable")
+	})
+
+	_ = store.Append(ctx, testRecords(1)[0])
+	if err := store.Flush(ctx); err == nil {
+		t.Fatal("expected flush error")
+	}
+	if len(store.pending) != 1 {
+		t.Errorf("pending = %d, want the record kept for the next flush", len(store.pending))
+	}
+}
+
+func TestConfigMapStore_BuffersWithoutClient(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	ctx := context.Background()
+	store, err := NewConfigMapStore(nil, "", 0)
+	if err != nil {
+		t.Fatalf("NewConfigMapStore() error = %v", err)
+	}
+
+	_ = store.Append(ctx, testRecords(1)[0])
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	page, err := store.Query(ctx, Query{})
+	if err != nil || len(page.Records) != 1 {
+		t.Fatalf("Query() = %+v, %v", page, err)
+	}
+
+	client := fake.NewSimpleClientset()
+	store.SetClient(client)
+	if err := store.Flush(ctx); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if names := listChunkNames(t, client); len(names) != 1 {
+		t.Errorf("chunks = %v, want the buffered record written", names)
+	}
+}
+
+func TestNewConfigMapStore_InvalidPrefix(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	if _, err := NewConfigMapStore(nil, "Invalid_Prefix", 0); err == nil {
+		t.Error("expected error for invalid prefix")
+	}
+}
@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	defaultFileMaxSize    = 10 << 20 // 10MB
+	defaultFileMaxBackups = 5
+
+	// maxRecordLine bounds the size of one JSONL line when reading the files back
+	maxRecordLine = 1 << 20
+)
+
+// FileStore appends records as JSON lines to a file and rotates it when it reaches maxSize,
+// keeping maxBackups old files (audit.jsonl.1 is the most recent backup).
+type FileStore struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileStore opens (or creates) the audit file at path
+func NewFileStore(path string, maxSize int64, maxBackups int) (*FileStore, error) {
+	if path == "" {
+		return nil, errors.New("audit file path is required")
+	}
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
+	}
+	if maxBackups < 0 {
+		maxBackups = defaultFileMaxBackups
+	}
+
+	s := &FileStore{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create audit directory: %w", err)
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Append writes a record as one JSON line, rotating the file first if it would grow past maxSize
+func (s *FileStore) Append(ctx context.Context, record models.AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
+
+// Query streams the current file and its backups through the page filter. The lock is only
+// held to open the files and note how much of the current one is written, so appends and
+// rotations go on while a query reads; open files keep their contents when they are renamed.
+func (s *FileStore) Query(ctx context.Context, q Query) (*models.PaginatedAuditRecords, error) {
+	files, currentSize, err := s.openSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, file := range files {
+			file.Close()
+		}
+	}()
+
+	p := newPager(q)
+	for i, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var r io.Reader = file
+		if i == 0 {
+			r = io.LimitReader(file, currentSize)
+		}
+		scanRecords(r, file.Name(), p.add)
+	}
+	return p.page(), nil
+}
+
+// openSnapshot opens the current file (first) and the existing backups under the lock and
+// returns the size of the current file at that point
+func (s *FileStore) openSnapshot() ([]*os.File, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	files := make([]*os.File, 0, s.maxBackups+1)
+	for i := 0; i <= s.maxBackups; i++ {
+		file, err := os.Open(s.backupPath(i)) // #nosec G304 -- path comes from the audit configuration
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			for _, opened := range files {
+				opened.Close()
+			}
+			return nil, 0, fmt.Errorf("failed to open audit file: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, s.size, nil
+}
+
+// Close closes the current file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *FileStore) open() error {
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit file: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// rotate shifts audit.jsonl.N-1 to audit.jsonl.N (dropping the oldest) and starts a new file
+func (s *FileStore) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit file: %w", err)
+	}
+	if s.maxBackups == 0 {
+		if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove audit file: %w", err)
+		}
+	} else {
+		for i := s.maxBackups - 1; i >= 0; i-- {
+			err := os.Rename(s.backupPath(i), s.backupPath(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to rotate audit file: %w", err)
+			}
+		}
+	}
+	return s.open()
+}
+
+// backupPath returns the path of the i-th backup; 0 is the current file
+func (s *FileStore) backupPath(i int) string {
+	if i == 0 {
+		return s.path
+	}
+	return fmt.Sprintf("%s.%d", s.path, i)
+}
+
+// scanRecords parses JSONL records and passes them to add; malformed lines are skipped
+func scanRecords(r io.Reader, path string, add func(models.AuditRecord)) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxRecordLine)
+	for scanner.Scan() {
+		var record models.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.ID == "" {
+			continue
+		}
+		add(record)
+	}
+	if err := scanner.Err(); err != nil {
+		utils.LogWarn("Failed to read part of the audit file", map[string]interface{}{
+			"path":  path,
+			"error": err.Error(),
+		})
+	}
+}
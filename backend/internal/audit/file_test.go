@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore_AppendAndQuery(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit", "audit.jsonl")
+
+	store, err := NewFileStore(path, 0, 2)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	for _, record := range testRecords(3) {
+		if err := store.Append(ctx, record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Records survive a restart
+	store, err = NewFileStore(path, 0, 2)
+	if err != nil {
+		t.Fatalf("NewFileStore() reopen error = %v", err)
+	}
+	defer store.Close()
+
+	page, err := store.Query(ctx, Query{Name: "app-1"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Name != "app-1" {
+		t.Errorf("records = %+v", page.Records)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+}
+
+func TestFileStore_Rotation(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Each record is ~200 bytes, so every file holds two records
+	store, err := NewFileStore(path, 450, 2)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+
+	for _, record := range testRecords(10) {
+		if err := store.Append(ctx, record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if _, err := os.Stat(name); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("only 2 backups should be kept")
+	}
+
+	page, err := store.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 6 {
+		t.Fatalf("len = %d, want 6 (3 files of 2 records)", len(page.Records))
+	}
+	if page.Records[0].Name != "app-9" || page.Records[5].Name != "app-4" {
+		t.Errorf("records = %s ... %s", page.Records[0].Name, page.Records[5].Name)
+	}
+}
+
+func TestFileStore_SkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.WriteFile(path, []byte("not json\n{}\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	store, err := NewFileStore(path, 0, 0)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+	if err := store.Append(context.Background(), testRecords(1)[0]); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	page, err := store.Query(context.Background(), Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 1 {
+		t.Errorf("len = %d, want 1", len(page.Records))
+	}
+}
+
+func TestNewFileStore_RequiresPath(t *testing.T) {
+	if _, err := NewFileStore("", 0, 0); err == nil {
+		t.Error("expected error for empty path")
+	}
+}
+
+func TestFileStore_QueryWhileAppending(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	store, err := NewFileStore(path, 450, 2)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	defer store.Close()
+
+	records := testRecords(40)
+	done := make(chan error, 1)
+	go func() {
+		for _, record := range records {
+			if err := store.Append(ctx, record); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	// Queries see a consistent snapshot while records are appended and files rotated
+	for i := 0; i < 20; i++ {
+		page, err := store.Query(ctx, Query{})
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		seen := make(map[string]bool, len(page.Records))
+		for j, record := range page.Records {
+			if seen[record.ID] {
+				t.Fatalf("record %s returned twice", record.Name)
+			}
+			seen[record.ID] = true
+			if j > 0 && record.ID > page.Records[j-1].ID {
+				t.Fatalf("records are not newest first: %s after %s", record.Name, page.Records[j-1].Name)
+			}
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+
+	page, err := store.Query(ctx, Query{Limit: 2})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 2 || page.Records[0].Name != "app-39" || page.Remaining != 4 {
+		t.Errorf("page = %d records (first %+v), %d remaining", len(page.Records), page.Records, page.Remaining)
+	}
+}
@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	defaultForwardQueueSize  = 1000
+	defaultForwardMaxRetries = 5
+
+	forwardInitialBackoff = 500 * time.Millisecond
+	forwardMaxBackoff     = 30 * time.Second
+)
+
+// Sender delivers one audit record to an external system (SIEM, log collector, ...)
+type Sender interface {
+	Send(ctx context.Context, record models.AuditRecord) error
+}
+
+// permanentError marks a delivery failure that retrying cannot fix (e.g. HTTP 400)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the forwarder drops the record instead of retrying it
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// Forwarder ships records to one Sender from a bounded queue, so a slow or unreachable
+// destination never blocks request handlers: when the queue is full new records are dropped.
+// Failed deliveries are retried with exponential backoff.
+type Forwarder struct {
+	name       string
+	sender     Sender
+	queue      chan models.AuditRecord
+	maxRetries int
+	backoff    time.Duration // first retry delay, doubled up to maxBackoff
+	maxBackoff time.Duration
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+// ForwarderStats counts the records handled by a forwarder
+type ForwarderStats struct {
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"` // queue full
+	Failed    uint64 `json:"failed"`  // retries exhausted or permanent error
+}
+
+// NewForwarder creates a forwarder with a queue of queueSize records and up to maxRetries
+// retries per record
+func NewForwarder(name string, sender Sender, queueSize, maxRetries int) *Forwarder {
+	if queueSize <= 0 {
+		queueSize = defaultForwardQueueSize
+	}
+	if maxRetries < 0 {
+		maxRetries = defaultForwardMaxRetries
+	}
+	return &Forwarder{
+		name:       name,
+		sender:     sender,
+		queue:      make(chan models.AuditRecord, queueSize),
+		maxRetries: maxRetries,
+		backoff:    forwardInitialBackoff,
+		maxBackoff: forwardMaxBackoff,
+	}
+}
+
+// Name returns the name used in logs
+func (f *Forwarder) Name() string {
+	return f.name
+}
+
+// Stats returns the delivery counters
+func (f *Forwarder) Stats() ForwarderStats {
+	return ForwarderStats{
+		Delivered: f.delivered.Load(),
+		Dropped:   f.dropped.Load(),
+		Failed:    f.failed.Load(),
+	}
+}
+
+// Enqueue queues a record without blocking; it returns false if the queue is full and the record was dropped
+func (f *Forwarder) Enqueue(record models.AuditRecord) bool {
+	select {
+	case f.queue <- record:
+		return true
+	default:
+		// Log the first drop and then every 100th, not every record of an outage
+		if n := f.dropped.Add(1); n == 1 || n%100 == 0 {
+			utils.LogWarn("Audit forwarding queue full, dropping records", map[string]interface{}{
+				"sink":    f.name,
+				"dropped": n,
+			})
+		}
+		return false
+	}
+}
+
+// Run delivers queued records until ctx is cancelled, then closes the sender if it is an io.Closer
+func (f *Forwarder) Run(ctx context.Context) {
+	defer func() {
+		if closer, ok := f.sender.(io.Closer); ok {
+			closer.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case record := <-f.queue:
+			f.deliver(ctx, record)
+		}
+	}
+}
+
+// deliver sends a record, retrying with exponential backoff
+func (f *Forwarder) deliver(ctx context.Context, record models.AuditRecord) {
+	backoff := f.backoff
+	for attempt := 0; ; attempt++ {
+		err := f.sender.Send(ctx, record)
+		if err == nil {
+			f.delivered.Add(1)
+			return
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= f.maxRetries || ctx.Err() != nil {
+			f.failed.Add(1)
+			utils.LogWarn("Failed to forward audit record", map[string]interface{}{
+				"sink":     f.name,
+				"id":       record.ID,
+				"attempts": attempt + 1,
+				"error":    err.Error(),
+			})
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			f.failed.Add(1)
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > f.maxBackoff {
+			backoff = f.maxBackoff
+		}
+	}
+}
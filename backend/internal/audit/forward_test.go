@@ -0,0 +1,211 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// fakeSender fails the first failures calls with err, then records what it receives
+type fakeSender struct {
+	mu       sync.Mutex
+	failures int
+	err      error
+	calls    int
+	received []models.AuditRecord
+	block    chan struct{}
+}
+
+func (s *fakeSender) Send(ctx context.Context, record models.AuditRecord) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.calls <= s.failures {
+		return s.err
+	}
+	s.received = append(s.received, record)
+	return nil
+}
+
+func (s *fakeSender) count() (calls, received int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls, len(s.received)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestForwarder_RetriesWithBackoff(t *testing.T) {
+	sender := &fakeSender{failures: 2, err: errors.New("unavailable")}
+	forwarder := NewForwarder("test", sender, 10, 3)
+	forwarder.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwarder.Run(ctx)
+
+	forwarder.Enqueue(testRecords(1)[0])
+	waitFor(t, func() bool { return forwarder.Stats().Delivered == 1 })
+
+	if calls, received := sender.count(); calls != 3 || received != 1 {
+		t.Errorf("calls = %d, received = %d", calls, received)
+	}
+}
+
+func TestForwarder_GivesUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantCalls int
+	}{
+		{"retries exhausted", errors.New("unavailable"), 3},
+		{"permanent error", Permanent(errors.New("bad request")), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &fakeSender{failures: 100, err: tt.err}
+			forwarder := NewForwarder("test", sender, 10, 2)
+			forwarder.backoff = time.Millisecond
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go forwarder.Run(ctx)
+
+			forwarder.Enqueue(testRecords(1)[0])
+			waitFor(t, func() bool { return forwarder.Stats().Failed == 1 })
+			if calls, _ := sender.count(); calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestForwarder_DropsWhenQueueFull(t *testing.T) {
+	sender := &fakeSender{block: make(chan struct{})}
+	forwarder := NewForwarder("test", sender, 2, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwarder.Run(ctx)
+
+	records := testRecords(10)
+	start := time.Now()
+	for _, record := range records {
+		forwarder.Enqueue(record)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Enqueue must not block on a slow destination")
+	}
+
+	// One record is being sent, two are queued, the rest are dropped
+	if dropped := forwarder.Stats().Dropped; dropped < 7 {
+		t.Errorf("dropped = %d, want at least 7", dropped)
+	}
+
+	close(sender.block)
+	waitFor(t, func() bool {
+		stats := forwarder.Stats()
+		return stats.Delivered+stats.Dropped == uint64(len(records))
+	})
+}
+
+func TestService_ForwardsRecords(t *testing.T) {
+	sender := &fakeSender{}
+	forwarder := NewForwarder("test", sender, 10, 0)
+	service := NewService(NewMemoryStore(10))
+	service.AddForwarder(forwarder)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx)
+		close(done)
+	}()
+
+	service.Record(utils.AuditLogEntry{User: "alice", Action: "delete", Kind: "Pod", Name: "web", Success: true})
+	waitFor(t, func() bool { return service.ForwarderStats()["test"].Delivered == 1 })
+
+	page, err := service.Query(context.Background(), Query{})
+	if err != nil || len(page.Records) != 1 {
+		t.Fatalf("Query() = %+v, %v", page, err)
+	}
+	sender.mu.Lock()
+	if sender.received[0].ID != page.Records[0].ID {
+		t.Errorf("forwarded and stored records differ: %s != %s", sender.received[0].ID, page.Records[0].ID)
+	}
+	sender.mu.Unlock()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not stop")
+	}
+}
+
+func TestNewForwarders(t *testing.T) {
+	forwarders, err := NewForwarders(ForwardConfig{
+		WebhookURL:    "https://siem.example.com/hook",
+		WebhookSecret: "s3cret",
+		SyslogAddress: "127.0.0.1:514",
+	})
+	if err != nil {
+		t.Fatalf("NewForwarders() error = %v", err)
+	}
+	if len(forwarders) != 2 || forwarders[0].Name() != "webhook" || forwarders[1].Name() != "syslog" {
+		t.Errorf("forwarders = %v", forwarders)
+	}
+
+	if forwarders, err := NewForwarders(ForwardConfig{}); err != nil || len(forwarders) != 0 {
+		t.Errorf("no destinations: %v, %v", forwarders, err)
+	}
+
+	invalid := []ForwardConfig{
+		{WebhookURL: "ftp://siem.example.com"},
+		{SyslogAddress: "no-port"},
+		{SyslogAddress: "127.0.0.1:514", SyslogNetwork: "tls"},
+		{SyslogAddress: "127.0.0.1:514", SyslogFormat: "leef"},
+	}
+	for _, cfg := range invalid {
+		if _, err := NewForwarders(cfg); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
+
+func TestForwardConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_WEBHOOK_URL", " https://siem.example.com/hook ")
+	t.Setenv("AUDIT_WEBHOOK_TIMEOUT", "3s")
+	t.Setenv("AUDIT_SYSLOG_ADDRESS", "syslog:6514")
+	t.Setenv("AUDIT_SYSLOG_NETWORK", "TCP")
+	t.Setenv("AUDIT_SYSLOG_FORMAT", "CEF")
+	t.Setenv("AUDIT_FORWARD_QUEUE_SIZE", "50")
+
+	cfg := ForwardConfigFromEnv()
+	if cfg.WebhookURL != "https://siem.example.com/hook" || cfg.WebhookTimeout != 3*time.Second {
+		t.Errorf("webhook cfg = %+v", cfg)
+	}
+	if cfg.SyslogAddress != "syslog:6514" || cfg.SyslogNetwork != "tcp" || cfg.SyslogFormat != SyslogFormatCEF {
+		t.Errorf("syslog cfg = %+v", cfg)
+	}
+	if cfg.QueueSize != 50 || cfg.MaxRetries != defaultForwardMaxRetries {
+		t.Errorf("queue cfg = %+v", cfg)
+	}
+}
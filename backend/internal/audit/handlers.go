@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// ListAuditHandler returns the stored audit records, newest first
+//
+// @Summary Registro de auditoría
+// @Description Lista las acciones auditadas (usuario, IP, cluster, verbo, recurso, estado y hash del contenido) con filtros y paginación
+// @Tags audit
+// @Security Bearer
+// @Produce json
+// @Param user query string false "Usuario"
+// @Param verb query string false "Acción (create, update, delete, ...)"
+// @Param kind query string false "Tipo de recurso"
+// @Param namespace query string false "Namespace"
+// @Param name query string false "Nombre del recurso"
+// @Param cluster query string false "Cluster"
+// @Param status query string false "success o failure"
+// @Param since query string false "Desde (RFC3339)"
+// @Param until query string false "Hasta (RFC3339)"
+// @Param limit query int false "Tamaño de página (por defecto 100, máximo 1000)"
+// @Param continue query string false "Token de continuación"
+// @Success 200 {object} models.PaginatedAuditRecords "Registros de auditoría"
+// @Failure 400 {object} map[string]string "Filtro inválido"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/audit [get]
+func (s *Service) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.Query(r.Context(), q)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to query audit log", http.StatusInternalServerError, nil)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, page)
+}
+
+// parseQuery reads the audit filters from the query string.
+// The "cluster" parameter filters records; it does not select the cluster to talk to here.
+func parseQuery(values url.Values) (Query, error) {
+	q := Query{
+		User:      values.Get("user"),
+		Verb:      values.Get("verb"),
+		Kind:      values.Get("kind"),
+		Namespace: values.Get("namespace"),
+		Name:      values.Get("name"),
+		Cluster:   values.Get("cluster"),
+		Status:    values.Get("status"),
+		Continue:  values.Get("continue"),
+	}
+
+	if q.Status != "" && q.Status != StatusSuccess && q.Status != StatusFailure {
+		return q, fmt.Errorf("status must be %q or %q", StatusSuccess, StatusFailure)
+	}
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n <= 0 {
+			return q, fmt.Errorf("limit must be a positive number")
+		}
+		q.Limit = n
+	}
+	var err error
+	if q.Since, err = parseTime(values.Get("since"), "since"); err != nil {
+		return q, err
+	}
+	if q.Until, err = parseTime(values.Get("until"), "until"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func parseTime(value, param string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+	}
+	return t, nil
+}
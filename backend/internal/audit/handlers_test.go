@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestHandlerService(t *testing.T) *Service {
+	t.Helper()
+	store := NewMemoryStore(100)
+	for _, record := range testRecords(10) {
+		if err := store.Append(context.Background(), record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	return NewService(store)
+}
+
+func TestListAuditHandler(t *testing.T) {
+	service := newTestHandlerService(t)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/audit?user=user1&limit=2", nil)
+	service.ListAuditHandler(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var page models.PaginatedAuditRecords
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// user1 made records 1, 4 and 7
+	if len(page.Records) != 2 || page.Records[0].Name != "app-7" || page.Remaining != 1 || page.Continue == "" {
+		t.Fatalf("page = %+v", page)
+	}
+
+	rr = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/api/audit?user=user1&limit=2&continue="+page.Continue, nil)
+	service.ListAuditHandler(rr, req)
+	page = models.PaginatedAuditRecords{}
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Name != "app-1" || page.Continue != "" {
+		t.Errorf("second page = %+v", page)
+	}
+}
+
+func TestListAuditHandler_TimeAndStatusFilters(t *testing.T) {
+	service := newTestHandlerService(t)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/audit?status=failure&since=2025-01-01T00:03:00Z&until=2025-01-01T00:07:00Z", nil)
+	service.ListAuditHandler(rr, req)
+
+	var page models.PaginatedAuditRecords
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// Failures are the odd records: 3, 5 and 7
+	if len(page.Records) != 3 {
+		t.Errorf("records = %+v", page.Records)
+	}
+}
+
+func TestListAuditHandler_Errors(t *testing.T) {
+	service := newTestHandlerService(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		want   int
+	}{
+		{"method", http.MethodPost, "/api/audit", http.StatusMethodNotAllowed},
+		{"status", http.MethodGet, "/api/audit?status=ok", http.StatusBadRequest},
+		{"limit", http.MethodGet, "/api/audit?limit=-1", http.StatusBadRequest},
+		{"since", http.MethodGet, "/api/audit?since=yesterday", http.StatusBadRequest},
+		{"until", http.MethodGet, "/api/audit?until=2025-01-01", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			service.ListAuditHandler(rr, httptest.NewRequest(tt.method, tt.target, nil))
+			if rr.Code != tt.want {
+				t.Errorf("status = %d, want %d", rr.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// defaultMemorySize is the number of records kept by the in-memory store
+const defaultMemorySize = 5000
+
+// MemoryStore keeps the most recent records in a ring buffer.
+// Records are lost on restart and not shared between replicas.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	records []models.AuditRecord
+	next    int  // index of the slot written next
+	full    bool // the buffer has wrapped around
+}
+
+// NewMemoryStore creates a ring buffer holding up to size records
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	return &MemoryStore{records: make([]models.AuditRecord, size)}
+}
+
+// Append stores a record, overwriting the oldest one when the buffer is full
+func (s *MemoryStore) Append(ctx context.Context, record models.AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[s.next] = record
+	s.next = (s.next + 1) % len(s.records)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+// Query returns a page of the buffered records
+func (s *MemoryStore) Query(ctx context.Context, q Query) (*models.PaginatedAuditRecords, error) {
+	s.mu.RLock()
+	count := s.next
+	if s.full {
+		count = len(s.records)
+	}
+	records := make([]models.AuditRecord, count)
+	copy(records, s.records[:count])
+	s.mu.RUnlock()
+
+	return paginate(records, q), nil
+}
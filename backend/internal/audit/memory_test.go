@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryStore_RingBuffer(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(3)
+
+	page, err := store.Query(ctx, Query{})
+	if err != nil || len(page.Records) != 0 {
+		t.Fatalf("empty store: %v, %+v", err, page)
+	}
+
+	for _, record := range testRecords(5) {
+		if err := store.Append(ctx, record); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	page, err = store.Query(ctx, Query{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 3 {
+		t.Fatalf("len = %d, want 3", len(page.Records))
+	}
+	want := []string{"app-4", "app-3", "app-2"}
+	for i, name := range want {
+		if page.Records[i].Name != name {
+			t.Errorf("record %d = %s, want %s", i, page.Records[i].Name, name)
+		}
+	}
+}
+
+func TestNewMemoryStore_DefaultSize(t *testing.T) {
+	if store := NewMemoryStore(0); len(store.records) != defaultMemorySize {
+		t.Errorf("size = %d, want %d", len(store.records), defaultMemorySize)
+	}
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// Service records action-level audit entries in a Store, forwards them to the configured
+// external destinations and serves queries over them
+type Service struct {
+	store      Store
+	forwarders []*Forwarder
+	mu         sync.Mutex
+}
+
+// NewService creates an audit service backed by store
+func NewService(store Store) *Service {
+	return &Service{store: store}
+}
+
+// NewServiceFromEnv creates an audit service with the store selected by the environment
+// (see ConfigFromEnv), falling back to the in-memory store if it cannot be created
+func NewServiceFromEnv(client kubernetes.Interface) *Service {
+	cfg := ConfigFromEnv()
+	store, err := NewStore(cfg, client)
+	if err != nil {
+		utils.LogWarn("Failed to initialize audit store, keeping audit records in memory", map[string]interface{}{
+			"store": cfg.Store,
+			"error": err.Error(),
+		})
+		store = NewMemoryStore(cfg.MemorySize)
+	}
+	service := NewService(store)
+
+	forwarders, err := NewForwarders(ForwardConfigFromEnv())
+	if err != nil {
+		utils.LogError(err, "Invalid audit forwarding configuration, audit records are not forwarded", nil)
+	}
+	for _, forwarder := range forwarders {
+		service.AddForwarder(forwarder)
+	}
+	return service
+}
+
+// AddForwarder forwards every new record to an external destination; call it before Run
+func (s *Service) AddForwarder(forwarder *Forwarder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.forwarders = append(s.forwarders, forwarder)
+	utils.LogInfo("Audit forwarding enabled", map[string]interface{}{
+		"sink": forwarder.Name(),
+	})
+}
+
+// ForwarderStats returns the delivery counters of each forwarder by name
+func (s *Service) ForwarderStats() map[string]ForwarderStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make(map[string]ForwarderStats, len(s.forwarders))
+	for _, forwarder := range s.forwarders {
+		stats[forwarder.Name()] = forwarder.Stats()
+	}
+	return stats
+}
+
+// SetClient hands a new Kubernetes client to the ConfigMap store; call it whenever the default
+// cluster client changes (e.g. after setup or a token reload). Other stores ignore it.
+func (s *Service) SetClient(client kubernetes.Interface) {
+	store, ok := s.store.(*ConfigMapStore)
+	if !ok {
+		return
+	}
+	// In setup mode the default client is a nil *Clientset
+	if clientset, isClientset := client.(*kubernetes.Clientset); client == nil || (isClientset && clientset == nil) {
+		return
+	}
+	store.SetClient(client)
+}
+
+// Store returns the underlying store
+func (s *Service) Store() Store {
+	return s.store
+}
+
+// Run performs the background work (forwarding records, flushing ConfigMap chunks) until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	s.mu.Lock()
+	forwarders := s.forwarders
+	s.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, forwarder := range forwarders {
+		wg.Add(1)
+		go func(forwarder *Forwarder) {
+			defer wg.Done()
+			forwarder.Run(ctx)
+		}(forwarder)
+	}
+
+	if store, ok := s.store.(*ConfigMapStore); ok {
+		store.Run(ctx)
+	}
+	wg.Wait()
+}
+
+// Record stores an audit log entry and queues it for forwarding; it is registered with utils.AddAuditSink.
+// It never blocks on the forwarding destinations.
+func (s *Service) Record(entry utils.AuditLogEntry) {
+	record := NewRecord(entry)
+	if err := s.store.Append(context.Background(), record); err != nil {
+		utils.LogError(err, "Failed to store audit record", map[string]interface{}{
+			"action": record.Verb,
+			"kind":   record.Kind,
+			"name":   record.Name,
+		})
+	}
+
+	s.mu.Lock()
+	forwarders := s.forwarders
+	s.mu.Unlock()
+	for _, forwarder := range forwarders {
+		forwarder.Enqueue(record)
+	}
+}
+
+// Query returns a page of the stored records matching q
+func (s *Service) Query(ctx context.Context, q Query) (*models.PaginatedAuditRecords, error) {
+	return s.store.Query(ctx, q)
+}
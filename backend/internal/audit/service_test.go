@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+func TestService_RecordAndQuery(t *testing.T) {
+	service := NewService(NewMemoryStore(10))
+
+	service.Record(utils.AuditLogEntry{User: "alice", Action: "delete", Kind: "Deployment", Name: "web", Namespace: "shop", Success: true})
+	service.Record(utils.AuditLogEntry{User: "bob", Action: "update", Kind: "ConfigMap", Name: "cfg", Namespace: "shop", Success: false})
+
+	page, err := service.Query(context.Background(), Query{User: "alice"})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(page.Records) != 1 || page.Records[0].Verb != "delete" || page.Records[0].Status != StatusSuccess {
+		t.Errorf("records = %+v", page.Records)
+	}
+}
+
+func TestService_SetClient(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	store, err := NewConfigMapStore(nil, "", 0)
+	if err != nil {
+		t.Fatalf("NewConfigMapStore() error = %v", err)
+	}
+	service := NewService(store)
+
+	// Setup mode stores a nil *Clientset, which must not be used
+	var setupClient *kubernetes.Clientset
+	service.SetClient(setupClient)
+	service.Record(utils.AuditLogEntry{Action: "create"})
+	if store.client != nil {
+		t.Fatal("nil clientset should be ignored")
+	}
+
+	service.SetClient(fake.NewSimpleClientset())
+	service.Record(utils.AuditLogEntry{Action: "create"})
+	if err := store.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	page, err := service.Query(context.Background(), Query{})
+	if err != nil || len(page.Records) != 2 {
+		t.Fatalf("Query() = %+v, %v", page, err)
+	}
+
+	// Other stores ignore the client
+	NewService(NewMemoryStore(10)).SetClient(fake.NewSimpleClientset())
+}
+
+func TestNewStore(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{"default", Config{}, "memory", false},
+		{"memory", Config{Store: StoreMemory, MemorySize: 10}, "memory", false},
+		{"file", Config{Store: StoreFile, FilePath: filepath.Join(t.TempDir(), "audit.jsonl")}, "file", false},
+		{"configmap", Config{Store: StoreConfigMap}, "configmap", false},
+		{"unknown", Config{Store: "s3"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewStore(tt.cfg, fake.NewSimpleClientset())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewStore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var got string
+			switch s := store.(type) {
+			case *MemoryStore:
+				got = "memory"
+			case *FileStore:
+				got = "file"
+				s.Close()
+			case *ConfigMapStore:
+				got = "configmap"
+			}
+			if got != tt.want {
+				t.Errorf("store = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("AUDIT_STORE", " File ")
+	t.Setenv("AUDIT_FILE_PATH", "/tmp/audit.jsonl")
+	t.Setenv("AUDIT_FILE_MAX_SIZE_MB", "2")
+	t.Setenv("AUDIT_FILE_MAX_BACKUPS", "bad")
+	t.Setenv("AUDIT_MEMORY_SIZE", "")
+
+	cfg := ConfigFromEnv()
+	if cfg.Store != StoreFile || cfg.FilePath != "/tmp/audit.jsonl" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.FileMaxSize != 2<<20 {
+		t.Errorf("FileMaxSize = %d", cfg.FileMaxSize)
+	}
+	if cfg.FileMaxBackups != defaultFileMaxBackups || cfg.MemorySize != defaultMemorySize {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+}
+
+func TestNewServiceFromEnv_FallsBackToMemory(t *testing.T) {
+	t.Setenv("AUDIT_STORE", "unknown")
+	service := NewServiceFromEnv(nil)
+	if _, ok := service.Store().(*MemoryStore); !ok {
+		t.Errorf("store = %T, want *MemoryStore", service.Store())
+	}
+}
@@ -0,0 +1,190 @@
+package audit
+
+import (
+	"container/heap"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// StatusSuccess and StatusFailure are the values of AuditRecord.Status
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+
+	// defaultQueryLimit and maxQueryLimit bound the page size of Query
+	defaultQueryLimit = 100
+	maxQueryLimit     = 1000
+)
+
+// Store persists audit records and answers filtered, paginated queries over them
+type Store interface {
+	Append(ctx context.Context, record models.AuditRecord) error
+	Query(ctx context.Context, q Query) (*models.PaginatedAuditRecords, error)
+}
+
+// Query filters audit records. Empty fields match everything.
+type Query struct {
+	User      string
+	Verb      string
+	Kind      string // case-insensitive
+	Namespace string
+	Name      string
+	Cluster   string
+	Status    string // StatusSuccess or StatusFailure
+	Since     time.Time
+	Until     time.Time
+	Limit     int    // page size; 0 = defaultQueryLimit
+	Continue  string // ID of the last record of the previous page
+}
+
+// Matches reports whether a record passes the filters (pagination fields are ignored)
+func (q Query) Matches(record models.AuditRecord) bool {
+	switch {
+	case q.User != "" && record.User != q.User,
+		q.Verb != "" && record.Verb != q.Verb,
+		q.Kind != "" && !strings.EqualFold(record.Kind, q.Kind),
+		q.Namespace != "" && record.Namespace != q.Namespace,
+		q.Name != "" && record.Name != q.Name,
+		q.Cluster != "" && record.Cluster != q.Cluster,
+		q.Status != "" && record.Status != q.Status,
+		!q.Since.IsZero() && record.Time.Before(q.Since),
+		!q.Until.IsZero() && record.Time.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// NewRecord converts an action-level audit log entry into a record with a new ID
+func NewRecord(entry utils.AuditLogEntry) models.AuditRecord {
+	now := entry.Time
+	if now.IsZero() {
+		now = time.Now().UTC()
+	}
+	status := StatusSuccess
+	if !entry.Success {
+		status = StatusFailure
+	}
+	return models.AuditRecord{
+		ID:        newRecordID(now),
+		Time:      now,
+		User:      entry.User,
+		IP:        entry.IP,
+		Cluster:   entry.Cluster,
+		Verb:      entry.Action,
+		Kind:      entry.Kind,
+		Namespace: entry.Namespace,
+		Name:      entry.Name,
+		Status:    status,
+		Error:     entry.Error,
+		DiffHash:  entry.DiffHash,
+		Details:   jsonSafeDetails(entry.Details),
+	}
+}
+
+// newRecordID returns an ID that sorts by time: the hex Unix nanoseconds plus random bytes,
+// so records written by several replicas at the same instant do not collide
+func newRecordID(t time.Time) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%016x00000000", t.UnixNano())
+	}
+	return fmt.Sprintf("%016x%s", t.UnixNano(), hex.EncodeToString(suffix))
+}
+
+// jsonSafeDetails drops the details that cannot be stored as JSON rather than losing the record
+func jsonSafeDetails(details map[string]interface{}) map[string]interface{} {
+	if len(details) == 0 {
+		return nil
+	}
+	safe := make(map[string]interface{}, len(details))
+	for key, value := range details {
+		if _, err := json.Marshal(value); err == nil {
+			safe[key] = value
+		}
+	}
+	return safe
+}
+
+// paginate filters records (in any order) and returns the requested page, newest first
+func paginate(records []models.AuditRecord, q Query) *models.PaginatedAuditRecords {
+	p := newPager(q)
+	for i := range records {
+		p.add(records[i])
+	}
+	return p.page()
+}
+
+// pager builds a page from records streamed in any order: it keeps only the newest matches
+// that fit in the page (in a min-heap by ID) and counts the others, so stores do not need to
+// hold all their records in memory to answer a query.
+type pager struct {
+	q         Query
+	limit     int
+	kept      recordHeap
+	remaining int
+}
+
+func newPager(q Query) *pager {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	return &pager{q: q, limit: limit}
+}
+
+// add offers a record to the page
+func (p *pager) add(record models.AuditRecord) {
+	if p.q.Continue != "" && record.ID >= p.q.Continue {
+		return
+	}
+	if !p.q.Matches(record) {
+		return
+	}
+	if len(p.kept) < p.limit {
+		heap.Push(&p.kept, record)
+		return
+	}
+	p.remaining++
+	if record.ID > p.kept[0].ID {
+		p.kept[0] = record
+		heap.Fix(&p.kept, 0)
+	}
+}
+
+// page returns the kept records newest first
+func (p *pager) page() *models.PaginatedAuditRecords {
+	records := append([]models.AuditRecord{}, p.kept...)
+	sort.Slice(records, func(i, j int) bool { return records[i].ID > records[j].ID })
+
+	page := &models.PaginatedAuditRecords{Records: records, Remaining: p.remaining}
+	if page.Remaining > 0 {
+		page.Continue = records[len(records)-1].ID
+	}
+	return page
+}
+
+// recordHeap is a min-heap of records by ID, so the oldest kept record is evicted first
+type recordHeap []models.AuditRecord
+
+func (h recordHeap) Len() int            { return len(h) }
+func (h recordHeap) Less(i, j int) bool  { return h[i].ID < h[j].ID }
+func (h recordHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *recordHeap) Push(x interface{}) { *h = append(*h, x.(models.AuditRecord)) }
+func (h *recordHeap) Pop() interface{} {
+	old := *h
+	record := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return record
+}
@@ -0,0 +1,163 @@
+package audit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// testRecords returns n records one minute apart, oldest first
+func testRecords(n int) []models.AuditRecord {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]models.AuditRecord, 0, n)
+	for i := 0; i < n; i++ {
+		t := base.Add(time.Duration(i) * time.Minute)
+		status := StatusSuccess
+		if i%2 == 1 {
+			status = StatusFailure
+		}
+		records = append(records, models.AuditRecord{
+			ID:        fmt.Sprintf("%016x%08x", t.UnixNano(), i),
+			Time:      t,
+			User:      fmt.Sprintf("user%d", i%3),
+			Verb:      "delete",
+			Kind:      "Deployment",
+			Namespace: "default",
+			Name:      fmt.Sprintf("app-%d", i),
+			Cluster:   "default",
+			Status:    status,
+		})
+	}
+	return records
+}
+
+func TestNewRecord(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	record := NewRecord(utils.AuditLogEntry{
+		Time:      now,
+		User:      "alice",
+		IP:        "10.0.0.1",
+		Cluster:   "prod",
+		Action:    "update",
+		Kind:      "Deployment",
+		Name:      "web",
+		Namespace: "shop",
+		Success:   false,
+		Error:     "forbidden",
+		DiffHash:  "abc",
+		Details: map[string]interface{}{
+			"force":   true,
+			"invalid": func() {},
+		},
+	})
+
+	if record.ID == "" || record.Time != now {
+		t.Fatalf("record ID/time not set: %+v", record)
+	}
+	if record.Verb != "update" || record.Status != StatusFailure || record.Error != "forbidden" {
+		t.Errorf("unexpected record: %+v", record)
+	}
+	if record.Cluster != "prod" || record.Kind != "Deployment" || record.Name != "web" || record.Namespace != "shop" || record.DiffHash != "abc" {
+		t.Errorf("resource fields not copied: %+v", record)
+	}
+	if _, ok := record.Details["invalid"]; ok {
+		t.Errorf("details that cannot be encoded should be dropped")
+	}
+	if record.Details["force"] != true {
+		t.Errorf("details = %v", record.Details)
+	}
+
+	later := NewRecord(utils.AuditLogEntry{Time: now.Add(time.Nanosecond), Success: true})
+	if later.ID <= record.ID {
+		t.Errorf("IDs should sort by time: %s <= %s", later.ID, record.ID)
+	}
+	if later.Status != StatusSuccess {
+		t.Errorf("status = %s, want success", later.Status)
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	record := testRecords(1)[0]
+
+	tests := []struct {
+		name  string
+		query Query
+		want  bool
+	}{
+		{"empty", Query{}, true},
+		{"user", Query{User: "user0"}, true},
+		{"other user", Query{User: "bob"}, false},
+		{"kind is case-insensitive", Query{Kind: "deployment"}, true},
+		{"verb", Query{Verb: "create"}, false},
+		{"namespace", Query{Namespace: "kube-system"}, false},
+		{"name", Query{Name: "app-0"}, true},
+		{"cluster", Query{Cluster: "prod"}, false},
+		{"status", Query{Status: StatusFailure}, false},
+		{"since", Query{Since: record.Time.Add(time.Second)}, false},
+		{"until", Query{Until: record.Time.Add(-time.Second)}, false},
+		{"time range", Query{Since: record.Time, Until: record.Time}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.Matches(record); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	records := testRecords(10)
+
+	page := paginate(append([]models.AuditRecord(nil), records...), Query{Limit: 4})
+	if len(page.Records) != 4 || page.Remaining != 6 {
+		t.Fatalf("page = %d records, %d remaining", len(page.Records), page.Remaining)
+	}
+	if page.Records[0].Name != "app-9" {
+		t.Errorf("first record = %s, want newest", page.Records[0].Name)
+	}
+
+	var names []string
+	q := Query{Limit: 4}
+	for {
+		page := paginate(append([]models.AuditRecord(nil), records...), q)
+		for _, record := range page.Records {
+			names = append(names, record.Name)
+		}
+		if page.Continue == "" {
+			break
+		}
+		q.Continue = page.Continue
+	}
+	if len(names) != 10 || names[9] != "app-0" {
+		t.Errorf("walked pages = %v", names)
+	}
+
+	// The page does not depend on the order the records arrive in
+	reversed := make([]models.AuditRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		reversed = append(reversed, records[i])
+	}
+	page = paginate(reversed, Query{Limit: 4, Continue: records[8].ID})
+	if len(page.Records) != 4 || page.Records[0].Name != "app-7" || page.Records[3].Name != "app-4" || page.Remaining != 4 {
+		t.Errorf("page from reversed records = %+v", page)
+	}
+
+	failures := paginate(append([]models.AuditRecord(nil), records...), Query{Status: StatusFailure, Limit: 2})
+	if len(failures.Records) != 2 || failures.Remaining != 3 {
+		t.Errorf("filtered page = %d records, %d remaining", len(failures.Records), failures.Remaining)
+	}
+	for _, record := range failures.Records {
+		if record.Status != StatusFailure {
+			t.Errorf("unexpected record %+v", record)
+		}
+	}
+
+	empty := paginate(nil, Query{})
+	if empty.Records == nil || len(empty.Records) != 0 || empty.Continue != "" {
+		t.Errorf("empty page = %+v", empty)
+	}
+}
@@ -0,0 +1,191 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+const (
+	// SyslogFormatJSON and SyslogFormatCEF select the MSG part of the syslog messages
+	SyslogFormatJSON = "json"
+	SyslogFormatCEF  = "cef"
+
+	// syslogFacility is "log audit" (13) from RFC 5424
+	syslogFacility = 13
+	// syslogSDID is the structured data ID; 32473 is the enterprise number reserved for examples (RFC 5612)
+	syslogSDID         = "dkonsole@32473"
+	syslogAppName      = "dkonsole"
+	syslogMsgID        = "audit"
+	syslogTimeFormat   = "2006-01-02T15:04:05.000000Z07:00"
+	defaultSyslogDial  = 5 * time.Second
+	syslogWriteTimeout = 5 * time.Second
+)
+
+// SyslogSender writes RFC 5424 messages to a syslog collector over UDP or TCP.
+// TCP messages use octet-counting framing (RFC 6587); the connection is re-dialed after an error.
+type SyslogSender struct {
+	mu       sync.Mutex
+	network  string
+	address  string
+	format   string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogSender creates a syslog sender; network is "udp" or "tcp", format is SyslogFormatJSON or SyslogFormatCEF
+func NewSyslogSender(network, address, format string) (*SyslogSender, error) {
+	if network == "" {
+		network = "udp"
+	}
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("unsupported syslog network %q (use udp or tcp)", network)
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %w", address, err)
+	}
+	if format == "" {
+		format = SyslogFormatJSON
+	}
+	if format != SyslogFormatJSON && format != SyslogFormatCEF {
+		return nil, fmt.Errorf("unsupported syslog format %q (use %s or %s)", format, SyslogFormatJSON, SyslogFormatCEF)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	return &SyslogSender{
+		network:  network,
+		address:  address,
+		format:   format,
+		hostname: hostname,
+	}, nil
+}
+
+// Send writes one record as a syslog message
+func (s *SyslogSender) Send(ctx context.Context, record models.AuditRecord) error {
+	msg, err := s.message(record)
+	if err != nil {
+		return Permanent(err)
+	}
+	if s.network == "tcp" {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		dialer := net.Dialer{Timeout: defaultSyslogDial}
+		conn, err := dialer.DialContext(ctx, s.network, s.address)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		s.conn = conn
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+	if _, err := s.conn.Write([]byte(msg)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return fmt.Errorf("failed to write to syslog: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection to the collector
+func (s *SyslogSender) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSender) message(record models.AuditRecord) (string, error) {
+	var body string
+	if s.format == SyslogFormatCEF {
+		body = FormatCEF(record)
+	} else {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode audit record: %w", err)
+		}
+		body = string(data)
+	}
+	return FormatRFC5424(record, s.hostname, body), nil
+}
+
+// FormatRFC5424 builds a syslog message whose structured data carries the audit fields and whose MSG is msg.
+// Failed actions are logged with severity warning, the rest as informational.
+func FormatRFC5424(record models.AuditRecord, hostname, msg string) string {
+	severity := 6
+	if record.Status == StatusFailure {
+		severity = 4
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	params := map[string]string{
+		"id":        record.ID,
+		"user":      record.User,
+		"ip":        record.IP,
+		"cluster":   record.Cluster,
+		"verb":      record.Verb,
+		"kind":      record.Kind,
+		"namespace": record.Namespace,
+		"name":      record.Name,
+		"status":    record.Status,
+		"diffHash":  record.DiffHash,
+	}
+	keys := make([]string, 0, len(params))
+	for key, value := range params {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var sd strings.Builder
+	sd.WriteString("[" + syslogSDID)
+	for _, key := range keys {
+		sd.WriteString(" " + key + `="` + escapeSDParam(params[key]) + `"`)
+	}
+	sd.WriteString("]")
+
+	return fmt.Sprintf("<%d>1 %s %s %s %d %s %s %s",
+		syslogFacility*8+severity,
+		record.Time.UTC().Format(syslogTimeFormat),
+		truncate(hostname, 255),
+		syslogAppName,
+		os.Getpid(),
+		syslogMsgID,
+		sd.String(),
+		msg,
+	)
+}
+
+// escapeSDParam escapes '"', '\' and ']' in a structured data value (RFC 5424 section 6.3.3)
+func escapeSDParam(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestFormatRFC5424(t *testing.T) {
+	record := testRecords(2)[1] // a failure
+	record.User = `ali"ce]`
+
+	msg := FormatRFC5424(record, "dkonsole-0", "hello")
+
+	// facility 13 (log audit) * 8 + severity 4 (warning)
+	if !strings.HasPrefix(msg, "<108>1 2025-01-01T00:01:00.000000Z dkonsole-0 dkonsole ") {
+		t.Errorf("unexpected header: %s", msg)
+	}
+	if !strings.Contains(msg, " audit [dkonsole@32473 ") {
+		t.Errorf("missing MSGID and SD-ID: %s", msg)
+	}
+	if !strings.Contains(msg, `user="ali\"ce\]"`) {
+		t.Errorf("SD param not escaped: %s", msg)
+	}
+	if strings.Contains(msg, "diffHash=") {
+		t.Errorf("empty params should be omitted: %s", msg)
+	}
+	if !strings.HasSuffix(msg, "] hello") {
+		t.Errorf("unexpected MSG: %s", msg)
+	}
+
+	success := FormatRFC5424(testRecords(1)[0], "", "x")
+	if !strings.HasPrefix(success, "<110>1 ") || !strings.Contains(success, " - dkonsole ") {
+		t.Errorf("unexpected message: %s", success)
+	}
+}
+
+func TestSyslogSender_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer conn.Close()
+
+	sender, err := NewSyslogSender("udp", conn.LocalAddr().String(), SyslogFormatJSON)
+	if err != nil {
+		t.Fatalf("NewSyslogSender() error = %v", err)
+	}
+	defer sender.Close()
+
+	record := testRecords(1)[0]
+	if err := sender.Send(context.Background(), record); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<110>1 ") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+
+	// The MSG part is the JSON record
+	var got models.AuditRecord
+	if err := json.Unmarshal([]byte(msg[strings.Index(msg, "] ")+2:]), &got); err != nil || got.ID != record.ID {
+		t.Errorf("MSG is not the JSON record: %v, %s", err, msg)
+	}
+}
+
+func TestSyslogSender_TCPOctetCounting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, _ := strconv.Atoi(strings.TrimSpace(length))
+			buf := make([]byte, n)
+			if _, err := reader.Read(buf); err != nil {
+				return
+			}
+			messages <- string(buf)
+		}
+	}()
+
+	sender, err := NewSyslogSender("tcp", listener.Addr().String(), SyslogFormatCEF)
+	if err != nil {
+		t.Fatalf("NewSyslogSender() error = %v", err)
+	}
+	defer sender.Close()
+
+	for _, record := range testRecords(2) {
+		if err := sender.Send(context.Background(), record); err != nil {
+			t.Fatalf("Send() error = %v", err)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-messages:
+			if !strings.Contains(msg, "] CEF:0|DKonsole|DKonsole|") || !strings.Contains(msg, fmt.Sprintf("cs4=app-%d", i)) {
+				t.Errorf("unexpected message %d: %s", i, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("message %d not received", i)
+		}
+	}
+}
+
+func TestSyslogSender_ReconnectsAfterError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	sender, err := NewSyslogSender("tcp", address, "")
+	if err != nil {
+		t.Fatalf("NewSyslogSender() error = %v", err)
+	}
+	defer sender.Close()
+
+	// Nothing is listening: the send fails and can be retried
+	if err := sender.Send(context.Background(), testRecords(1)[0]); err == nil {
+		t.Fatal("expected connection error")
+	}
+
+	listener, err = net.Listen("tcp", address)
+	if err != nil {
+		t.Skipf("could not listen on %s again: %v", address, err)
+	}
+	defer listener.Close()
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			defer conn.Close()
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+		}
+	}()
+
+	if err := sender.Send(context.Background(), testRecords(1)[0]); err != nil {
+		t.Errorf("Send() after the collector came back error = %v", err)
+	}
+}
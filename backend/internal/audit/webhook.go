@@ -0,0 +1,102 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+
+	// WebhookSignatureHeader carries "sha256=<hex HMAC-SHA256 of '<timestamp>.<body>'>"
+	WebhookSignatureHeader = "X-DKonsole-Signature"
+	// WebhookTimestampHeader carries the Unix time the request was signed at
+	WebhookTimestampHeader = "X-DKonsole-Timestamp"
+	// WebhookEventIDHeader carries the record ID, so receivers can drop retried duplicates
+	WebhookEventIDHeader = "X-DKonsole-Event-ID"
+)
+
+// WebhookSender POSTs each record as JSON to an HTTP endpoint.
+// When a secret is set, requests are signed with HMAC-SHA256 over the timestamp and body,
+// so the receiver can verify their origin and reject replays.
+type WebhookSender struct {
+	url    string
+	secret []byte
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewWebhookSender creates a webhook sender for an http(s) URL
+func NewWebhookSender(endpoint, secret string, timeout time.Duration) (*WebhookSender, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid audit webhook URL %q", endpoint)
+	}
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSender{
+		url:    endpoint,
+		secret: []byte(secret),
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}, nil
+}
+
+// Send posts a record. 4xx responses other than 408 and 429 are permanent errors.
+func (s *WebhookSender) Send(ctx context.Context, record models.AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return Permanent(fmt.Errorf("failed to encode audit record: %w", err))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return Permanent(err)
+	}
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DKonsole-Audit")
+	req.Header.Set(WebhookEventIDHeader, record.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	if len(s.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhook(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return fmt.Errorf("audit webhook returned %d", resp.StatusCode)
+	default:
+		return Permanent(fmt.Errorf("audit webhook returned %d", resp.StatusCode))
+	}
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func SignWebhook(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestWebhookSender_SignsRequests(t *testing.T) {
+	record := testRecords(1)[0]
+	var got models.AuditRecord
+	var signature, timestamp, eventID string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(WebhookSignatureHeader)
+		timestamp = r.Header.Get(WebhookTimestampHeader)
+		eventID = r.Header.Get(WebhookEventIDHeader)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if signature != "sha256="+SignWebhook([]byte("s3cret"), timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender, err := NewWebhookSender(server.URL, "s3cret", time.Second)
+	if err != nil {
+		t.Fatalf("NewWebhookSender() error = %v", err)
+	}
+	sender.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	if err := sender.Send(context.Background(), record); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.ID != record.ID || got.Name != record.Name {
+		t.Errorf("received %+v", got)
+	}
+	if timestamp != "1700000000" || eventID != record.ID {
+		t.Errorf("timestamp = %s, event ID = %s", timestamp, eventID)
+	}
+}
+
+func TestWebhookSender_Unsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(WebhookSignatureHeader) != "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sender, _ := NewWebhookSender(server.URL, "", 0)
+	if err := sender.Send(context.Background(), testRecords(1)[0]); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+}
+
+func TestWebhookSender_StatusCodes(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantErr       bool
+		wantPermanent bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusAccepted, false, false},
+		{http.StatusBadRequest, true, true},
+		{http.StatusUnauthorized, true, true},
+		{http.StatusRequestTimeout, true, false},
+		{http.StatusTooManyRequests, true, false},
+		{http.StatusBadGateway, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			sender, _ := NewWebhookSender(server.URL, "s3cret", time.Second)
+			err := sender.Send(context.Background(), testRecords(1)[0])
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var permanent *permanentError
+			if errors.As(err, &permanent) != tt.wantPermanent {
+				t.Errorf("permanent = %v, want %v", !tt.wantPermanent, tt.wantPermanent)
+			}
+		})
+	}
+}
+
+func TestWebhookForwarder_RetriesServerErrors(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	sender, _ := NewWebhookSender(server.URL, "s3cret", time.Second)
+	forwarder := NewForwarder("webhook", sender, 10, 5)
+	forwarder.backoff = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go forwarder.Run(ctx)
+
+	forwarder.Enqueue(testRecords(1)[0])
+	waitFor(t, func() bool { return forwarder.Stats().Delivered == 1 })
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
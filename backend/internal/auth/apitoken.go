@@ -0,0 +1,444 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// apiTokenPrefix marks personal API tokens so they are not mistaken for JWTs
+	apiTokenPrefix = "dkp_"
+
+	defaultAPITokenTTL = 90 * 24 * time.Hour
+	maxAPITokenTTL     = 365 * 24 * time.Hour
+	maxAPITokenName    = 64
+
+	// apiTokenReauthorizeInterval bounds how long a token keeps permissions its owner has lost
+	apiTokenReauthorizeInterval = 5 * time.Minute
+)
+
+var (
+	// ErrAPITokenNotFound is returned by an APITokenStore when the token does not exist
+	ErrAPITokenNotFound = errors.New("API token not found")
+	// ErrInvalidAPITokenRequest is returned when the name, expiry or scope of a new token is invalid
+	ErrInvalidAPITokenRequest = errors.New("invalid API token request")
+	// ErrAPITokenIDPUnsupported is returned when the owner's access cannot be re-checked while the
+	// token is used, as for OIDC users, whose identity provider is only queried at login
+	ErrAPITokenIDPUnsupported = errors.New("API tokens are only available to local and LDAP users")
+)
+
+// APIToken is a named, long-lived credential for scripts and CI.
+// Only the hash of the secret is stored; the token itself is shown once on creation.
+type APIToken struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Owner       models.Claims     `json:"owner"`                 // claims of the user who created it, re-checked on use
+	Permissions map[string]string `json:"permissions,omitempty"` // scope; empty means the owner's full access
+	SecretHash  string            `json:"secretHash"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	ExpiresAt   time.Time         `json:"expiresAt"`
+	LastUsed    time.Time         `json:"lastUsed,omitempty"`
+	LastUsedIP  string            `json:"lastUsedIp,omitempty"`
+}
+
+func (t APIToken) recordID() string        { return t.ID }
+func (t APIToken) recordExpiry() time.Time { return t.ExpiresAt }
+
+// APITokenInfo is the public view of a token returned by the token endpoints
+type APITokenInfo struct {
+	ID          string            `json:"id"`
+	Name        string            `json:"name"`
+	Username    string            `json:"username"`
+	IDP         string            `json:"idp,omitempty"`
+	Permissions map[string]string `json:"permissions,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	ExpiresAt   time.Time         `json:"expiresAt"`
+	LastUsed    *time.Time        `json:"lastUsed,omitempty"`
+	LastUsedIP  string            `json:"lastUsedIp,omitempty"`
+}
+
+// APITokenStore persists API tokens. Implementations must be safe for concurrent use.
+type APITokenStore interface {
+	// Save creates or replaces a token
+	Save(ctx context.Context, token APIToken) error
+	// Update applies fn to the stored token and saves the result as one atomic change.
+	// It returns ErrAPITokenNotFound when the token no longer exists, so a token revoked
+	// elsewhere is never written back, and returns fn's error without saving anything.
+	Update(ctx context.Context, id string, fn func(token *APIToken) error) error
+	// Get returns a token, or ErrAPITokenNotFound
+	Get(ctx context.Context, id string) (APIToken, error)
+	// Delete removes tokens; unknown IDs are ignored
+	Delete(ctx context.Context, ids ...string) error
+	// List returns all tokens that have not expired
+	List(ctx context.Context) ([]APIToken, error)
+}
+
+// newDefaultAPITokenManager builds the token manager. Tokens outlive restarts, so they are kept
+// in a Secret whenever a Kubernetes client is available, and in memory otherwise.
+func newDefaultAPITokenManager(k8sClient kubernetes.Interface) *APITokenManager {
+	var store APITokenStore = NewMemoryAPITokenStore()
+	if k8sClient == nil {
+		utils.LogWarn("No Kubernetes client, API tokens are kept in memory and lost on restart", nil)
+	} else if secretStore, err := NewK8sAPITokenStore(k8sClient, defaultAPITokenSecretName); err != nil {
+		utils.LogWarn("Failed to initialize Secret API token store, using in-memory tokens", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		store = secretStore
+	}
+	return NewAPITokenManager(store)
+}
+
+// APITokenManager creates, authenticates and revokes personal API tokens
+type APITokenManager struct {
+	store       APITokenStore
+	now         func() time.Time
+	mu          sync.Mutex
+	reauthorize func(ctx context.Context, claims models.Claims) (models.Claims, error)
+	owners      map[string]authorizedOwner // token ID -> owner claims as last re-checked
+}
+
+type authorizedOwner struct {
+	claims    models.Claims
+	checkedAt time.Time
+}
+
+// NewAPITokenManager creates a token manager backed by the given store
+func NewAPITokenManager(store APITokenStore) *APITokenManager {
+	return &APITokenManager{
+		store:  store,
+		now:    time.Now,
+		owners: make(map[string]authorizedOwner),
+	}
+}
+
+// Store returns the underlying token store
+func (m *APITokenManager) Store() APITokenStore {
+	return m.store
+}
+
+// SetReauthorizer sets the function that re-reads the owner's role and permissions,
+// so that a token never grants more than its owner currently has. An error rejects the token.
+func (m *APITokenManager) SetReauthorizer(reauthorize func(ctx context.Context, claims models.Claims) (models.Claims, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reauthorize = reauthorize
+	m.owners = make(map[string]authorizedOwner)
+}
+
+// Create issues a token for owner and returns it with the secret token string.
+// ttl 0 uses the default expiry. scope must be covered by the owner's permissions;
+// an empty scope gives the token the owner's full access.
+func (m *APITokenManager) Create(ctx context.Context, owner models.Claims, name string, ttl time.Duration, scope map[string]string) (*APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPITokenName {
+		return nil, "", fmt.Errorf("%w: name is required and must be at most %d characters", ErrInvalidAPITokenRequest, maxAPITokenName)
+	}
+	if !reauthorizableIDP(owner.IDP) {
+		return nil, "", ErrAPITokenIDPUnsupported
+	}
+	if ttl == 0 {
+		ttl = defaultAPITokenTTL
+	}
+	if ttl < 0 || ttl > maxAPITokenTTL {
+		return nil, "", fmt.Errorf("%w: expiry must be between 1 and %d days", ErrInvalidAPITokenRequest, int(maxAPITokenTTL.Hours()/24))
+	}
+	scope, err := normalizeTokenScope(owner, scope)
+	if err != nil {
+		return nil, "", err
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := m.now()
+	token := APIToken{
+		ID:          id,
+		Name:        name,
+		Owner:       owner,
+		Permissions: scope,
+		SecretHash:  hashRefreshSecret(secret),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	if err := m.store.Save(ctx, token); err != nil {
+		return nil, "", fmt.Errorf("failed to save API token: %w", err)
+	}
+
+	return &token, apiTokenPrefix + id + "_" + secret, nil
+}
+
+// Authenticate validates a token string and returns the claims it grants, marked with the token ID.
+// It returns ErrInvalidAPIToken when the token is unknown, expired or its owner lost access.
+func (m *APITokenManager) Authenticate(ctx context.Context, tokenString, clientIP string) (*AuthClaims, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(tokenString, apiTokenPrefix), "_")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrInvalidAPIToken
+	}
+
+	token, err := m.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrAPITokenNotFound) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, fmt.Errorf("failed to get API token: %w", err)
+	}
+
+	now := m.now()
+	if !hashesEqual(hashRefreshSecret(secret), token.SecretHash) || now.After(token.ExpiresAt) || !reauthorizableIDP(token.Owner.IDP) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	owner, err := m.authorizedOwner(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if now.Sub(token.LastUsed) >= sessionTouchInterval {
+		err := m.store.Update(ctx, id, func(stored *APIToken) error {
+			stored.LastUsed = now
+			stored.LastUsedIP = clientIP
+			return nil
+		})
+		if errors.Is(err, ErrAPITokenNotFound) {
+			m.forget(id)
+			return nil, ErrInvalidAPIToken
+		}
+		if err != nil {
+			utils.LogWarn("Failed to update API token last use", map[string]interface{}{
+				"token": token.ID,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	return &AuthClaims{
+		Claims:         scopeClaims(owner, token.Permissions),
+		APITokenID:     token.ID,
+		APITokenScoped: len(token.Permissions) > 0,
+	}, nil
+}
+
+// authorizedOwner returns the owner's current claims, re-checked at most every apiTokenReauthorizeInterval
+func (m *APITokenManager) authorizedOwner(ctx context.Context, token APIToken) (models.Claims, error) {
+	m.mu.Lock()
+	reauthorize := m.reauthorize
+	cached, ok := m.owners[token.ID]
+	m.mu.Unlock()
+
+	if reauthorize == nil {
+		return token.Owner, nil
+	}
+	if ok && m.now().Sub(cached.checkedAt) < apiTokenReauthorizeInterval {
+		return cached.claims, nil
+	}
+
+	claims, err := reauthorize(ctx, token.Owner)
+	if err != nil {
+		utils.LogWarn("API token owner no longer authorized", map[string]interface{}{
+			"username": token.Owner.Username,
+			"token":    token.ID,
+			"error":    err.Error(),
+		})
+		m.mu.Lock()
+		delete(m.owners, token.ID)
+		m.mu.Unlock()
+		return models.Claims{}, ErrInvalidAPIToken
+	}
+
+	m.mu.Lock()
+	m.owners[token.ID] = authorizedOwner{claims: claims, checkedAt: m.now()}
+	m.mu.Unlock()
+	return claims, nil
+}
+
+// Revoke deletes a token. When username is not empty the token must belong to that user.
+func (m *APITokenManager) Revoke(ctx context.Context, id, username string) error {
+	token, err := m.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if username != "" && token.Owner.Username != username {
+		return ErrAPITokenNotFound
+	}
+	m.forget(id)
+	return m.store.Delete(ctx, id)
+}
+
+// RevokeUser deletes every token of a user and returns how many were removed
+func (m *APITokenManager) RevokeUser(ctx context.Context, username string) (int, error) {
+	return m.RevokeIDPUser(ctx, "", username)
+}
+
+// RevokeIDPUser deletes the tokens of a user of the given identity provider ("core", "ldap"
+// or "oidc"); an empty idp matches every provider
+func (m *APITokenManager) RevokeIDPUser(ctx context.Context, idp, username string) (int, error) {
+	tokens, err := m.List(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if idp == "" || token.Owner.IDP == idp {
+			ids = append(ids, token.ID)
+			m.forget(token.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := m.store.Delete(ctx, ids...); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// List returns the active tokens of a user (all users when username is empty), newest first
+func (m *APITokenManager) List(ctx context.Context, username string) ([]APIToken, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	tokens := make([]APIToken, 0, len(all))
+	for _, token := range all {
+		if now.After(token.ExpiresAt) {
+			continue
+		}
+		if username != "" && token.Owner.Username != username {
+			continue
+		}
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreatedAt.After(tokens[j].CreatedAt)
+	})
+	return tokens, nil
+}
+
+func (m *APITokenManager) forget(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.owners, id)
+}
+
+// Info returns the public view of the token
+func (t *APIToken) Info() APITokenInfo {
+	info := APITokenInfo{
+		ID:          t.ID,
+		Name:        t.Name,
+		Username:    t.Owner.Username,
+		IDP:         t.Owner.IDP,
+		Permissions: t.Permissions,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedIP:  t.LastUsedIP,
+	}
+	if !t.LastUsed.IsZero() {
+		lastUsed := t.LastUsed
+		info.LastUsed = &lastUsed
+	}
+	return info
+}
+
+// normalizeTokenScope validates a requested scope against the owner's permissions.
+// Keys use the permission key format (namespace or cluster/namespace, "*" as wildcard).
+func normalizeTokenScope(owner models.Claims, scope map[string]string) (map[string]string, error) {
+	if len(scope) == 0 {
+		return nil, nil
+	}
+
+	normalized := make(map[string]string, len(scope))
+	for key, level := range scope {
+		if models.PermissionLevel(level) == 0 {
+			return nil, fmt.Errorf("%w: invalid permission %q for %s, must be 'view' or 'edit'", ErrInvalidAPITokenRequest, level, key)
+		}
+		cluster, namespace := models.ParsePermissionKey(key)
+		if err := validateScopeKey(cluster, namespace); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidAPITokenRequest, err)
+		}
+		if models.PermissionLevel(ownerLevel(owner, cluster, namespace)) < models.PermissionLevel(level) {
+			return nil, fmt.Errorf("%w: scope %s=%s exceeds your permissions", ErrInvalidAPITokenRequest, key, level)
+		}
+		normalized[models.PermissionKey(cluster, namespace)] = level
+	}
+	return normalized, nil
+}
+
+func validateScopeKey(cluster, namespace string) error {
+	if cluster != models.PermissionWildcard {
+		if err := utils.ValidateK8sName(cluster, "cluster"); err != nil {
+			return err
+		}
+	}
+	if namespace != models.PermissionWildcard {
+		if err := utils.ValidateNamespace(namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reauthorizableIDP reports whether the access of users of an identity provider can be re-checked
+// without them: local users are read from the auth Secret and LDAP users from the directory.
+// Tokens are limited to these providers so that they never outlive a revoked permission.
+func reauthorizableIDP(idp string) bool {
+	return idp == "core" || idp == "ldap"
+}
+
+// ownerLevel returns the highest level of the owner's grants that cover a scope key,
+// so "prod/*" covers "prod/payments" and "prod/*", but "prod/payments" does not cover "prod/*".
+// It returns "" when no valid grant covers the key.
+func ownerLevel(owner models.Claims, cluster, namespace string) string {
+	if owner.Role == "admin" {
+		return "edit"
+	}
+	level, _ := models.GrantedPermission(owner.Permissions, cluster, namespace)
+	if models.PermissionLevel(level) == 0 {
+		return ""
+	}
+	return level
+}
+
+// scopeClaims returns the claims granted by a token: the owner's claims limited to the token scope.
+// Each scoped key gets the lower of the requested level and what the owner still has.
+func scopeClaims(owner models.Claims, scope map[string]string) models.Claims {
+	if len(scope) == 0 {
+		return owner
+	}
+
+	claims := owner
+	claims.Role = "user"
+	claims.Permissions = make(map[string]string, len(scope))
+	for key, level := range scope {
+		cluster, namespace := models.ParsePermissionKey(key)
+		granted := ownerLevel(owner, cluster, namespace)
+		if granted == "" {
+			continue
+		}
+		if models.PermissionLevel(granted) < models.PermissionLevel(level) {
+			level = granted
+		}
+		claims.Permissions[key] = level
+	}
+	return claims
+}
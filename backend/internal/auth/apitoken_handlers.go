@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// maxAPITokenRequestSize limits the size of token creation payloads
+const maxAPITokenRequestSize = 64 << 10 // 64KB
+
+// APITokenRequest creates a personal API token
+type APITokenRequest struct {
+	Name          string            `json:"name"`
+	ExpiresInDays int               `json:"expiresInDays,omitempty"` // 0 = 90 days, at most 365
+	Permissions   map[string]string `json:"permissions,omitempty"`   // scope; empty = the creator's full access
+}
+
+// APITokenCreatedResponse returns a new token; the token value is not shown again
+type APITokenCreatedResponse struct {
+	APITokenInfo
+	Token string `json:"token"`
+}
+
+// ListAPITokensHandler returns the active API tokens of the current user
+//
+// @Summary Mis tokens de API
+// @Description Lista los tokens de API personales del usuario autenticado (sin el valor del token)
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {array} APITokenInfo "Tokens activos"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Router /api/auth/tokens [get]
+func (s *Service) ListAPITokensHandler(w http.ResponseWriter, r *http.Request) {
+	claims, apiTokens, ok := s.apiTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := apiTokens.List(r.Context(), claims.Username)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to list API tokens", http.StatusInternalServerError, nil)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, apiTokenInfos(list))
+}
+
+// CreateAPITokenHandler creates a personal API token for scripts and CI.
+// The token is sent as "Authorization: Bearer <token>" and never grants more than its creator has.
+// Only local and LDAP users can create tokens, since only their access is re-checked while tokens are used.
+//
+// @Summary Crear token de API
+// @Description Crea un token de API personal con nombre, expiración y un subconjunto opcional de permisos por namespace
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body APITokenRequest true "Datos del token"
+// @Success 201 {object} APITokenCreatedResponse "Token creado (el valor solo se muestra una vez)"
+// @Failure 400 {object} map[string]string "Nombre, expiración o permisos inválidos"
+// @Failure 403 {object} map[string]string "Token de API usado para gestionar tokens, o usuario OIDC"
+// @Router /api/auth/tokens [post]
+func (s *Service) CreateAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims, apiTokens, ok := s.apiTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var req APITokenRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxAPITokenRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "expiresInDays must be positive")
+		return
+	}
+
+	token, value, err := apiTokens.Create(r.Context(), claims.Claims, req.Name, time.Duration(req.ExpiresInDays)*24*time.Hour, req.Permissions)
+	if err != nil {
+		utils.AuditLog(r, "create", "APIToken", req.Name, "", false, err, nil)
+		writeAPITokenError(w, err, "Failed to create API token")
+		return
+	}
+
+	utils.AuditLog(r, "create", "APIToken", token.Name, "", true, nil, map[string]interface{}{
+		"token_id":   token.ID,
+		"expires_at": token.ExpiresAt,
+		"scoped":     len(token.Permissions) > 0,
+	})
+	utils.JSONResponse(w, http.StatusCreated, APITokenCreatedResponse{
+		APITokenInfo: token.Info(),
+		Token:        value,
+	})
+}
+
+// RevokeAPITokenHandler revokes one of the current user's API tokens
+//
+// @Summary Revocar un token de API
+// @Description Revoca un token de API del usuario autenticado
+// @Tags auth
+// @Security Bearer
+// @Param id query string true "ID del token"
+// @Produce json
+// @Success 200 {object} map[string]string "Token revocado"
+// @Failure 404 {object} map[string]string "Token no encontrado"
+// @Router /api/auth/tokens [delete]
+func (s *Service) RevokeAPITokenHandler(w http.ResponseWriter, r *http.Request) {
+	claims, apiTokens, ok := s.apiTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "id parameter is required")
+		return
+	}
+
+	err := apiTokens.Revoke(r.Context(), id, claims.Username)
+	utils.AuditLog(r, "revoke", "APIToken", id, "", err == nil, err, nil)
+	if err != nil {
+		writeAPITokenError(w, err, "Failed to revoke API token")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "API token revoked"})
+}
+
+// AdminListAPITokensHandler returns the active API tokens of all users, or of one user
+//
+// @Summary Listar tokens de API
+// @Description Lista los tokens de API de todos los usuarios (o de uno con ?username=), con su último uso
+// @Tags auth
+// @Security Bearer
+// @Param username query string false "Usuario"
+// @Produce json
+// @Success 200 {array} APITokenInfo "Tokens activos"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/tokens [get]
+func (s *Service) AdminListAPITokensHandler(w http.ResponseWriter, r *http.Request) {
+	_, apiTokens, ok := s.apiTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := apiTokens.List(r.Context(), r.URL.Query().Get("username"))
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to list API tokens", http.StatusInternalServerError, nil)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, apiTokenInfos(list))
+}
+
+// AdminRevokeAPITokensHandler revokes every API token of ?username=, or a single ?id=
+//
+// @Summary Revocar tokens de API (admin)
+// @Description Revoca todos los tokens de API de un usuario (?username=) o un token (?id=)
+// @Tags auth
+// @Security Bearer
+// @Param username query string false "Usuario"
+// @Param id query string false "ID del token"
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Tokens revocados"
+// @Failure 400 {object} map[string]string "Falta username o id"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Failure 404 {object} map[string]string "Token no encontrado"
+// @Router /api/admin/tokens [delete]
+func (s *Service) AdminRevokeAPITokensHandler(w http.ResponseWriter, r *http.Request) {
+	_, apiTokens, ok := s.apiTokenRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	username := r.URL.Query().Get("username")
+	id := r.URL.Query().Get("id")
+
+	var revoked int
+	var err error
+	switch {
+	case username != "":
+		revoked, err = apiTokens.RevokeUser(ctx, username)
+	case id != "":
+		err = apiTokens.Revoke(ctx, id, "")
+		revoked = 1
+	default:
+		utils.ErrorResponse(w, http.StatusBadRequest, "username or id parameter is required")
+		return
+	}
+
+	utils.AuditLog(r, "revoke_api_tokens", "APIToken", id, "", err == nil, err, map[string]interface{}{
+		"target_user": username,
+	})
+	if err != nil {
+		writeAPITokenError(w, err, "Failed to revoke API tokens")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "API tokens revoked",
+		"revoked": revoked,
+	})
+}
+
+// apiTokenRequest returns the caller's claims and the token manager, writing an error response
+// when tokens are unavailable or the request is not authenticated
+func (s *Service) apiTokenRequest(w http.ResponseWriter, r *http.Request) (*AuthClaims, *APITokenManager, bool) {
+	s.mu.RLock()
+	apiTokens := s.apiTokens
+	s.mu.RUnlock()
+
+	if apiTokens == nil {
+		utils.ErrorResponse(w, http.StatusNotImplemented, "API tokens are not available")
+		return nil, nil, false
+	}
+
+	claims, ok := r.Context().Value(userContextKey).(*AuthClaims)
+	if !ok || claims.Username == "" {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, nil, false
+	}
+
+	return claims, apiTokens, true
+}
+
+// writeAPITokenError maps API token errors to HTTP status codes
+func writeAPITokenError(w http.ResponseWriter, err error, userMsg string) {
+	switch {
+	case errors.Is(err, ErrInvalidAPITokenRequest):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrAPITokenIDPUnsupported):
+		utils.ErrorResponse(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, ErrAPITokenNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, userMsg, http.StatusInternalServerError, nil)
+	}
+}
+
+func apiTokenInfos(tokens []APIToken) []APITokenInfo {
+	infos := make([]APITokenInfo, 0, len(tokens))
+	for i := range tokens {
+		infos = append(infos, tokens[i].Info())
+	}
+	return infos
+}
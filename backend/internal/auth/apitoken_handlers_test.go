@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestAPITokenService(t *testing.T) *Service {
+	t.Helper()
+	s := newTestSessionService(t)
+	s.apiTokens = NewAPITokenManager(NewMemoryAPITokenStore())
+	s.apiTokens.SetReauthorizer(s.authService.reauthorizeClaims)
+	s.jwtService.SetAPITokenManager(s.apiTokens)
+	return s
+}
+
+func callWithAPIToken(s *Service, handler http.HandlerFunc, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	s.AuthMiddleware(handler)(rr, req)
+	return rr
+}
+
+func TestAPITokenHandlers_CreateUseRevoke(t *testing.T) {
+	s := newTestAPITokenService(t)
+	session, _ := loginForTest(t, s)
+
+	rr := callAuthenticatedWithBody(s, s.CreateAPITokenHandler, http.MethodPost, "/api/auth/tokens",
+		postJSON(t, APITokenRequest{Name: "ci", ExpiresInDays: 30, Permissions: map[string]string{"payments": "view"}}), session)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("CreateAPITokenHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var created APITokenCreatedResponse
+	_ = json.NewDecoder(rr.Body).Decode(&created)
+	if created.Token == "" || created.ID == "" || created.Username != "admin" {
+		t.Fatalf("unexpected response: %+v", created)
+	}
+
+	// The token authenticates through the Authorization header with its scope
+	rr = callWithAPIToken(s, s.MeHandler, http.MethodGet, "/api/me", created.Token)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("MeHandler() with API token status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var me map[string]interface{}
+	_ = json.NewDecoder(rr.Body).Decode(&me)
+	if me["role"] != "user" {
+		t.Errorf("scoped admin token role = %v, want user", me["role"])
+	}
+
+	// ...but cannot mint new tokens
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/tokens", postJSON(t, APITokenRequest{Name: "more"}))
+	req.Header.Set("Authorization", "Bearer "+created.Token)
+	rr = httptest.NewRecorder()
+	s.AuthMiddleware(InteractiveOnly(s.CreateAPITokenHandler))(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("create with API token status = %d, want 403", rr.Code)
+	}
+
+	rr = callAuthenticated(s, s.ListAPITokensHandler, http.MethodGet, "/api/auth/tokens", session)
+	var list []APITokenInfo
+	_ = json.NewDecoder(rr.Body).Decode(&list)
+	if len(list) != 1 || list[0].LastUsed == nil {
+		t.Fatalf("ListAPITokensHandler() = %+v, want one used token", list)
+	}
+
+	rr = callAuthenticated(s, s.RevokeAPITokenHandler, http.MethodDelete, "/api/auth/tokens?id=unknown", session)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("revoke unknown token status = %d, want 404", rr.Code)
+	}
+	rr = callAuthenticated(s, s.RevokeAPITokenHandler, http.MethodDelete, "/api/auth/tokens?id="+created.ID, session)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("RevokeAPITokenHandler() status = %d", rr.Code)
+	}
+	if rr := callWithAPIToken(s, s.MeHandler, http.MethodGet, "/api/me", created.Token); rr.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token status = %d, want 401", rr.Code)
+	}
+}
+
+func TestAPITokenHandlers_Admin(t *testing.T) {
+	s := newTestAPITokenService(t)
+	session, _ := loginForTest(t, s)
+
+	rr := callAuthenticatedWithBody(s, s.CreateAPITokenHandler, http.MethodPost, "/api/auth/tokens", postJSON(t, APITokenRequest{Name: ""}), session)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("create without name status = %d, want 400", rr.Code)
+	}
+	rr = callAuthenticatedWithBody(s, s.CreateAPITokenHandler, http.MethodPost, "/api/auth/tokens", postJSON(t, APITokenRequest{Name: "ci", ExpiresInDays: -1}), session)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("create with negative expiry status = %d, want 400", rr.Code)
+	}
+
+	for _, name := range []string{"one", "two"} {
+		rr = callAuthenticatedWithBody(s, s.CreateAPITokenHandler, http.MethodPost, "/api/auth/tokens", postJSON(t, APITokenRequest{Name: name}), session)
+		if rr.Code != http.StatusCreated {
+			t.Fatalf("CreateAPITokenHandler() status = %d", rr.Code)
+		}
+	}
+
+	rr = callAuthenticated(s, s.AdminListAPITokensHandler, http.MethodGet, "/api/admin/tokens?username=admin", session)
+	var list []APITokenInfo
+	_ = json.NewDecoder(rr.Body).Decode(&list)
+	if len(list) != 2 {
+		t.Fatalf("AdminListAPITokensHandler() = %d tokens, want 2", len(list))
+	}
+
+	if rr := callAuthenticated(s, s.AdminRevokeAPITokensHandler, http.MethodDelete, "/api/admin/tokens", session); rr.Code != http.StatusBadRequest {
+		t.Errorf("revoke without parameters status = %d, want 400", rr.Code)
+	}
+	rr = callAuthenticated(s, s.AdminRevokeAPITokensHandler, http.MethodDelete, "/api/admin/tokens?username=admin", session)
+	var result map[string]interface{}
+	_ = json.NewDecoder(rr.Body).Decode(&result)
+	if rr.Code != http.StatusOK || result["revoked"] != float64(2) {
+		t.Errorf("AdminRevokeAPITokensHandler() = %d %v, want 2 revoked", rr.Code, result)
+	}
+}
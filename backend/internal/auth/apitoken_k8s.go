@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultAPITokenSecretName = "dkonsole-api-tokens"
+
+// K8sAPITokenStore persists API tokens in a Kubernetes Secret shared by all replicas,
+// one key per token ID (see secretRecordStore)
+type K8sAPITokenStore struct {
+	*secretRecordStore[APIToken]
+}
+
+// NewK8sAPITokenStore creates a Secret-backed token store in the current namespace
+func NewK8sAPITokenStore(client kubernetes.Interface, secretName string) (*K8sAPITokenStore, error) {
+	store, err := newSecretRecordStore[APIToken](client, secretName, "API tokens", ErrAPITokenNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &K8sAPITokenStore{secretRecordStore: store}, nil
+}
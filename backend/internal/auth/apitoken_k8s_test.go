@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestK8sAPITokenStore(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	client := k8sfake.NewSimpleClientset()
+	store, err := NewK8sAPITokenStore(client, defaultAPITokenSecretName)
+	if err != nil {
+		t.Fatalf("NewK8sAPITokenStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	active := APIToken{ID: "a1", Name: "ci", Owner: models.Claims{Username: "alice"}, ExpiresAt: time.Now().Add(time.Hour)}
+	expired := APIToken{ID: "b2", Name: "old", Owner: models.Claims{Username: "alice"}, ExpiresAt: time.Now().Add(-time.Hour)}
+	if err := store.Save(ctx, active); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, expired); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("dkonsole").Get(ctx, defaultAPITokenSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("API tokens secret not created: %v", err)
+	}
+	if _, ok := secret.Data["a1"]; !ok {
+		t.Errorf("token not stored in secret: %v", secret.Data)
+	}
+	if _, ok := secret.Data["b2"]; ok {
+		t.Error("expired tokens must be pruned on write")
+	}
+
+	// A second replica reads the same Secret
+	other, _ := NewK8sAPITokenStore(client, defaultAPITokenSecretName)
+	if got, err := other.Get(ctx, "a1"); err != nil || got.Name != "ci" {
+		t.Fatalf("Get() from another store = %+v, %v", got, err)
+	}
+
+	if err := store.Delete(ctx, "a1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "a1"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrAPITokenNotFound", err)
+	}
+	if list, _ := store.List(ctx); len(list) != 0 {
+		t.Errorf("List() after delete = %+v", list)
+	}
+}
+
+func TestK8sAPITokenStore_LastUseDoesNotRestoreRevokedToken(t *testing.T) {
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	client := k8sfake.NewSimpleClientset()
+	storeA, _ := NewK8sAPITokenStore(client, defaultAPITokenSecretName)
+	storeB, _ := NewK8sAPITokenStore(client, defaultAPITokenSecretName)
+	replicaA := NewAPITokenManager(storeA)
+	replicaB := NewAPITokenManager(storeB)
+	ctx := context.Background()
+
+	token, secret, err := replicaA.Create(ctx, models.Claims{Username: "alice", Role: "admin", IDP: "core"}, "ci", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := replicaB.Authenticate(ctx, secret, "10.0.0.1"); err != nil {
+		t.Fatalf("Authenticate() on replica B error = %v", err)
+	}
+
+	// Revoked on replica A while replica B still has the token cached and wants to record its use
+	if err := replicaA.Revoke(ctx, token.ID, ""); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	replicaB.now = func() time.Time { return time.Now().Add(2 * sessionTouchInterval) }
+	if _, err := replicaB.Authenticate(ctx, secret, "10.0.0.1"); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Authenticate() with a revoked token error = %v, want ErrInvalidAPIToken", err)
+	}
+
+	stored, _ := client.CoreV1().Secrets("dkonsole").Get(ctx, defaultAPITokenSecretName, metav1.GetOptions{})
+	if _, ok := stored.Data[token.ID]; ok {
+		t.Error("revoked token was written back to the secret")
+	}
+}
@@ -0,0 +1,12 @@
+package auth
+
+// MemoryAPITokenStore keeps API tokens in process memory.
+// Tokens are lost on restart and are not shared between replicas.
+type MemoryAPITokenStore struct {
+	*memoryRecordStore[APIToken]
+}
+
+// NewMemoryAPITokenStore creates an empty in-memory token store
+func NewMemoryAPITokenStore() *MemoryAPITokenStore {
+	return &MemoryAPITokenStore{memoryRecordStore: newMemoryRecordStore[APIToken](ErrAPITokenNotFound)}
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestAPITokenManager_CreateValidation(t *testing.T) {
+	m := NewAPITokenManager(NewMemoryAPITokenStore())
+	ctx := context.Background()
+	user := models.Claims{Username: "alice", Role: "user", IDP: "ldap", Permissions: map[string]string{"payments": "edit", "prod/*": "view"}}
+	admin := models.Claims{Username: "admin", Role: "admin", IDP: "core"}
+
+	tests := []struct {
+		name    string
+		owner   models.Claims
+		token   string
+		ttl     time.Duration
+		scope   map[string]string
+		wantErr bool
+	}{
+		{name: "full access", owner: user, token: "ci"},
+		{name: "subset", owner: user, token: "ci", scope: map[string]string{"payments": "view", "prod/orders": "view"}},
+		{name: "wildcard covered by wildcard", owner: user, token: "ci", scope: map[string]string{"prod/*": "view"}},
+		{name: "admin any scope", owner: admin, token: "ci", scope: map[string]string{"*/*": "edit"}},
+		{name: "missing name", owner: user, wantErr: true},
+		{name: "expiry too long", owner: user, token: "ci", ttl: 400 * 24 * time.Hour, wantErr: true},
+		{name: "higher level", owner: user, token: "ci", scope: map[string]string{"prod/orders": "edit"}, wantErr: true},
+		{name: "not granted", owner: user, token: "ci", scope: map[string]string{"billing": "view"}, wantErr: true},
+		{name: "wildcard wider than grant", owner: user, token: "ci", scope: map[string]string{"*/*": "view"}, wantErr: true},
+		{name: "invalid level", owner: user, token: "ci", scope: map[string]string{"payments": "admin"}, wantErr: true},
+		{name: "invalid namespace", owner: admin, token: "ci", scope: map[string]string{"Bad_NS": "view"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, value, err := m.Create(ctx, tt.owner, tt.token, tt.ttl, tt.scope)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidAPITokenRequest) {
+					t.Errorf("Create() error = %v, want ErrInvalidAPITokenRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if !strings.HasPrefix(value, apiTokenPrefix) {
+				t.Errorf("token %q does not have the %s prefix", value, apiTokenPrefix)
+			}
+		})
+	}
+}
+
+func TestAPITokenManager_OIDCOwner(t *testing.T) {
+	m := NewAPITokenManager(NewMemoryAPITokenStore())
+	ctx := context.Background()
+	oidcUser := models.Claims{Username: "carol", Role: "user", IDP: "oidc", Permissions: map[string]string{"payments": "edit"}}
+
+	// OIDC permissions cannot be re-checked without the user, so tokens would keep them for months
+	if _, _, err := m.Create(ctx, oidcUser, "ci", 0, nil); !errors.Is(err, ErrAPITokenIDPUnsupported) {
+		t.Errorf("Create() for an OIDC user error = %v, want ErrAPITokenIDPUnsupported", err)
+	}
+
+	// A token stored before the restriction no longer authenticates
+	if err := m.Store().Save(ctx, APIToken{
+		ID:         "legacy",
+		Name:       "ci",
+		Owner:      oidcUser,
+		SecretHash: hashRefreshSecret("secret"),
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := m.Authenticate(ctx, apiTokenPrefix+"legacy_secret", ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("Authenticate() of an OIDC token error = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+func TestAPITokenManager_Authenticate(t *testing.T) {
+	m := NewAPITokenManager(NewMemoryAPITokenStore())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+	owner := models.Claims{Username: "alice", Role: "user", IDP: "core", Permissions: map[string]string{"payments": "edit", "orders": "view"}}
+
+	token, value, err := m.Create(ctx, owner, "deploy", 24*time.Hour, map[string]string{"payments": "edit"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	stored, _ := m.Store().Get(ctx, token.ID)
+	if strings.Contains(value, stored.SecretHash) || stored.SecretHash == "" {
+		t.Error("only the hash of the secret must be stored")
+	}
+
+	claims, err := m.Authenticate(ctx, value, "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if claims.APITokenID != token.ID || !claims.APITokenScoped || claims.Username != "alice" || claims.Role != "user" {
+		t.Errorf("Authenticate() = %+v", claims)
+	}
+	if len(claims.Permissions) != 1 || claims.Permissions["payments"] != "edit" {
+		t.Errorf("token must be limited to its scope, got %v", claims.Permissions)
+	}
+	if stored, _ := m.Store().Get(ctx, token.ID); !stored.LastUsed.Equal(now) || stored.LastUsedIP != "10.0.0.1" {
+		t.Errorf("last use not recorded: %+v", stored)
+	}
+
+	if _, err := m.Authenticate(ctx, value+"x", ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("wrong secret error = %v, want ErrInvalidAPIToken", err)
+	}
+	if _, err := m.Authenticate(ctx, apiTokenPrefix+"unknown_secret", ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("unknown token error = %v, want ErrInvalidAPIToken", err)
+	}
+
+	now = now.Add(25 * time.Hour)
+	if _, err := m.Authenticate(ctx, value, ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("expired token error = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+func TestAPITokenManager_OwnerReauthorization(t *testing.T) {
+	m := NewAPITokenManager(NewMemoryAPITokenStore())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+	owner := models.Claims{Username: "alice", Role: "user", IDP: "core", Permissions: map[string]string{"payments": "edit"}}
+
+	current := owner
+	var deleted bool
+	calls := 0
+	m.SetReauthorizer(func(ctx context.Context, claims models.Claims) (models.Claims, error) {
+		calls++
+		if deleted {
+			return claims, errors.New("user deleted")
+		}
+		return current, nil
+	})
+
+	_, scoped, _ := m.Create(ctx, owner, "scoped", 0, map[string]string{"payments": "edit"})
+	_, full, _ := m.Create(ctx, owner, "full", 0, nil)
+
+	if _, err := m.Authenticate(ctx, scoped, ""); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if _, err := m.Authenticate(ctx, scoped, ""); err != nil || calls != 1 {
+		t.Fatalf("owner must be re-checked once per interval, calls = %d, err = %v", calls, err)
+	}
+
+	// The owner is downgraded: the token follows on the next re-check
+	current = models.Claims{Username: "alice", Role: "user", IDP: "core", Permissions: map[string]string{"payments": "view"}}
+	now = now.Add(apiTokenReauthorizeInterval)
+	claims, err := m.Authenticate(ctx, scoped, "")
+	if err != nil || claims.Permissions["payments"] != "view" {
+		t.Errorf("scoped token after downgrade = %v, %v; want payments=view", claims.Permissions, err)
+	}
+	claims, _ = m.Authenticate(ctx, full, "")
+	if claims.Permissions["payments"] != "view" || claims.APITokenScoped {
+		t.Errorf("full token after downgrade = %+v, want unscoped with payments=view", claims)
+	}
+
+	deleted = true
+	now = now.Add(apiTokenReauthorizeInterval)
+	if _, err := m.Authenticate(ctx, scoped, ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("token of a deleted owner error = %v, want ErrInvalidAPIToken", err)
+	}
+}
+
+func TestAPITokenManager_ListAndRevoke(t *testing.T) {
+	m := NewAPITokenManager(NewMemoryAPITokenStore())
+	ctx := context.Background()
+	alice := models.Claims{Username: "alice", Role: "admin", IDP: "core"}
+	bob := models.Claims{Username: "bob", Role: "admin", IDP: "core"}
+
+	first, _, _ := m.Create(ctx, alice, "first", 0, nil)
+	_, second, _ := m.Create(ctx, alice, "second", 0, nil)
+	bobToken, _, _ := m.Create(ctx, bob, "bob", 0, nil)
+
+	if list, _ := m.List(ctx, "alice"); len(list) != 2 {
+		t.Errorf("List(alice) = %d tokens, want 2", len(list))
+	}
+	if list, _ := m.List(ctx, ""); len(list) != 3 {
+		t.Errorf("List() = %d tokens, want 3", len(list))
+	}
+
+	if err := m.Revoke(ctx, bobToken.ID, "alice"); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Errorf("revoking another user's token error = %v, want ErrAPITokenNotFound", err)
+	}
+	if err := m.Revoke(ctx, first.ID, "alice"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	if n, err := m.RevokeUser(ctx, "alice"); err != nil || n != 1 {
+		t.Errorf("RevokeUser() = %d, %v; want 1", n, err)
+	}
+	if _, err := m.Authenticate(ctx, second, ""); !errors.Is(err, ErrInvalidAPIToken) {
+		t.Errorf("revoked token error = %v, want ErrInvalidAPIToken", err)
+	}
+	if list, _ := m.List(ctx, ""); len(list) != 1 || list[0].ID != bobToken.ID {
+		t.Errorf("remaining tokens = %+v, want only bob's", list)
+	}
+}
+
+func TestScopeClaims(t *testing.T) {
+	owner := models.Claims{Username: "alice", Role: "user", Permissions: map[string]string{"prod/*": "edit", "payments": "view"}}
+	claims := scopeClaims(owner, map[string]string{"prod/orders": "edit", "payments": "edit", "billing": "view"})
+
+	want := map[string]string{"prod/orders": "edit", "payments": "view"}
+	if len(claims.Permissions) != len(want) {
+		t.Fatalf("scopeClaims() = %v, want %v", claims.Permissions, want)
+	}
+	for key, level := range want {
+		if claims.Permissions[key] != level {
+			t.Errorf("scopeClaims()[%s] = %q, want %q", key, claims.Permissions[key], level)
+		}
+	}
+
+	admin := scopeClaims(models.Claims{Username: "root", Role: "admin"}, map[string]string{"payments": "view"})
+	if admin.Role != "user" || admin.Permissions["payments"] != "view" {
+		t.Errorf("a scoped admin token must not keep the admin role: %+v", admin)
+	}
+}
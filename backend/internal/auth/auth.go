@@ -28,6 +28,10 @@ type Service struct {
 	mu            sync.RWMutex       // Mutex for thread-safe reload
 	k8sClient     kubernetes.Interface
 	secretName    string
+	oidcAuth      OIDCAuthenticator                                // kept so it survives an auth service reload
+	localUsers    LocalUserAuthenticator                           // kept so it survives an auth service reload
+	sessions      *SessionManager                                  // server-side sessions, shared across reloads
+	apiTokens     *APITokenManager                                 // personal API tokens, shared across reloads
 	ClientFactory func(token string) (kubernetes.Interface, error) // Factory for creating K8s clients
 	OnReload      func(token string)                               // Callback to notify about reload (e.g. to update global clients)
 }
@@ -50,6 +54,8 @@ func newSetupModeService(k8sClient kubernetes.Interface, secretName string, k8sR
 		setupMode:   true,
 		k8sClient:   k8sClient,
 		secretName:  secretName,
+		sessions:    newSessionManagerFromEnv(k8sClient),
+		apiTokens:   newDefaultAPITokenManager(k8sClient),
 	}
 }
 
@@ -62,6 +68,53 @@ func (s *Service) SetLDAPAuthenticator(ldapAuth LDAPAuthenticator) {
 	}
 }
 
+// SetOIDCAuthenticator sets the OIDC authenticator for the auth service
+func (s *Service) SetOIDCAuthenticator(oidcAuth OIDCAuthenticator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oidcAuth = oidcAuth
+	if s.authService != nil {
+		s.authService.SetOIDCAuthenticator(oidcAuth)
+	}
+}
+
+// SetLocalUserAuthenticator sets the local users authenticator for the auth service
+func (s *Service) SetLocalUserAuthenticator(localUsers LocalUserAuthenticator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.localUsers = localUsers
+	if s.authService != nil {
+		s.authService.SetLocalUserAuthenticator(localUsers)
+	}
+}
+
+// ResetTOTP removes the TOTP enrollment of a core user, e.g. when the user is deleted.
+// It is a no-op when two-factor authentication is not available.
+func (s *Service) ResetTOTP(ctx context.Context, username string) error {
+	s.mu.RLock()
+	authService := s.authService
+	s.mu.RUnlock()
+
+	if authService == nil {
+		return nil
+	}
+	return authService.ResetTOTP(ctx, username)
+}
+
+// Sessions returns the server-side session manager (nil when sessions are disabled)
+func (s *Service) Sessions() *SessionManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sessions
+}
+
+// APITokens returns the personal API token manager
+func (s *Service) APITokens() *APITokenManager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.apiTokens
+}
+
 // IsSetupMode reports whether the service is waiting for initial setup (secret missing)
 func (s *Service) IsSetupMode() bool {
 	s.mu.RLock()
@@ -153,7 +206,16 @@ func NewService(k8sClient kubernetes.Interface, secretName string) (*Service, er
 
 	// Initialize services (only if not in setup mode)
 	authService := NewAuthService(userRepo, jwtSecret)
+	if store, ok := userRepo.(TOTPStore); ok {
+		authService.SetTOTPStore(store)
+	}
 	jwtService := NewJWTService(jwtSecret)
+	sessions := newSessionManagerFromEnv(k8sClient)
+	authService.SetSessionManager(sessions)
+	jwtService.SetSessionManager(sessions)
+	apiTokens := newDefaultAPITokenManager(k8sClient)
+	apiTokens.SetReauthorizer(authService.reauthorizeClaims)
+	jwtService.SetAPITokenManager(apiTokens)
 
 	return &Service{
 		authService:   authService,
@@ -162,6 +224,8 @@ func NewService(k8sClient kubernetes.Interface, secretName string) (*Service, er
 		setupMode:     setupMode,
 		k8sClient:     k8sClient,
 		secretName:    secretName,
+		sessions:      sessions,
+		apiTokens:     apiTokens,
 		ClientFactory: createEphemeralClient,
 	}, nil
 }
@@ -209,6 +273,16 @@ func (s *Service) Reload(ctx context.Context, tokenOverride string) (bool, error
 		repo.ClientFactory = s.ClientFactory
 
 		s.k8sRepo = repo
+		if s.sessions != nil {
+			if store, ok := s.sessions.Store().(*K8sSessionStore); ok {
+				store.SetClient(newClient)
+			}
+		}
+		if s.apiTokens != nil {
+			if store, ok := s.apiTokens.Store().(*K8sAPITokenStore); ok {
+				store.SetClient(newClient)
+			}
+		}
 	}
 
 	// Check if secret now exists
@@ -246,7 +320,22 @@ func (s *Service) Reload(ctx context.Context, tokenOverride string) (bool, error
 
 	// Initialize services with new credentials
 	authService := NewAuthService(userRepo, jwtSecretBytes)
+	authService.SetTOTPStore(userRepo)
 	jwtService := NewJWTService(jwtSecretBytes)
+	if s.oidcAuth != nil {
+		authService.SetOIDCAuthenticator(s.oidcAuth)
+	}
+	if s.localUsers != nil {
+		authService.SetLocalUserAuthenticator(s.localUsers)
+	}
+	if s.sessions != nil {
+		authService.SetSessionManager(s.sessions)
+		jwtService.SetSessionManager(s.sessions)
+	}
+	if s.apiTokens != nil {
+		s.apiTokens.SetReauthorizer(authService.reauthorizeClaims)
+		jwtService.SetAPITokenManager(s.apiTokens)
+	}
 
 	// Update service state
 	s.authService = authService
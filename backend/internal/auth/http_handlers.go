@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"time"
@@ -31,6 +32,9 @@ import (
 // Example response:
 //
 //	{"role": "admin"}
+//
+// When the account has two-factor authentication enabled the response is
+// {"mfaRequired": true, "mfaToken": "..."} and the login continues at /api/login/mfa.
 func (s *Service) LoginHandler(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
 	setupMode := s.setupMode
@@ -57,6 +61,7 @@ func (s *Service) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		Username: creds.Username,
 		Password: creds.Password,
 		IDP:      creds.IDP, // "core", "ldap", or "" for auto-detect
+		Client:   clientMeta(r),
 	}
 
 	// Call service (business logic layer)
@@ -78,30 +83,53 @@ func (s *Service) LoginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set cookie (HTTP layer)
-	// Use SameSite=None to allow cross-origin WebSocket handshakes after CORS restrictions.
+	// Core accounts with TOTP continue at /api/login/mfa; no cookies are set yet
+	if result.Response.MFARequired {
+		utils.JSONResponse(w, http.StatusOK, result.Response)
+		return
+	}
+
+	// Set cookies (HTTP layer)
+	setSessionCookies(w, result)
+
+	// Write JSON response (HTTP layer) - Do not return token in body
+	utils.JSONResponse(w, http.StatusOK, result.Response)
+}
+
+// setTokenCookie stores the session JWT in an HTTP-only cookie.
+// Use SameSite=None to allow cross-origin WebSocket handshakes after CORS restrictions.
+func setTokenCookie(w http.ResponseWriter, token string, expires time.Time) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "token",
-		Value:    result.Token,
-		Expires:  result.Expires,
+		Value:    token,
+		Expires:  expires,
 		HttpOnly: true,
 		Secure:   true, // Required for SameSite=None
 		SameSite: http.SameSiteNoneMode,
 		Path:     "/",
 	})
-
-	// Write JSON response (HTTP layer) - Do not return token in body
-	utils.JSONResponse(w, http.StatusOK, result.Response)
 }
 
-// LogoutHandler handles HTTP requests to log out the current user.
-// It clears the authentication cookie by setting it to expire immediately.
-// Returns a JSON response with a success message.
-func (s *Service) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
+// setSessionCookies stores the tokens of a login or refresh result.
+// The refresh cookie is only replaced when the result carries a new refresh token.
+func setSessionCookies(w http.ResponseWriter, result *LoginResult) {
+	setTokenCookie(w, result.Token, result.Expires)
+	if result.RefreshToken != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     refreshCookieName,
+			Value:    result.RefreshToken,
+			Expires:  result.RefreshExpires,
+			HttpOnly: true,
+			Secure:   true,
+			// Strict: the refresh endpoint is only ever called by the application itself
+			SameSite: http.SameSiteStrictMode,
+			Path:     refreshCookiePath,
+		})
 	}
+}
+
+// clearSessionCookies expires the token and refresh cookies
+func clearSessionCookies(w http.ResponseWriter) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     "token",
 		Value:    "",
@@ -112,6 +140,49 @@ func (s *Service) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 		Path:     "/",
 		MaxAge:   -1,
 	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+		Path:     refreshCookiePath,
+		MaxAge:   -1,
+	})
+}
+
+// clientMeta describes the client of a request for the session record
+func clientMeta(r *http.Request) SessionMeta {
+	return SessionMeta{
+		IP:        utils.GetClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// LogoutHandler handles HTTP requests to log out the current user.
+// It revokes the server-side session (so copies of the token stop working) and
+// clears the authentication cookies by setting them to expire immediately.
+// Returns a JSON response with a success message.
+func (s *Service) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	sessions := s.sessions
+	s.mu.RUnlock()
+
+	if claims, ok := r.Context().Value(userContextKey).(*AuthClaims); ok && sessions != nil && claims.RegisteredClaims.ID != "" {
+		if err := sessions.Revoke(r.Context(), claims.RegisteredClaims.ID); err != nil {
+			utils.LogError(err, "Failed to revoke session on logout", map[string]interface{}{
+				"username": claims.Username,
+			})
+		}
+	}
+
+	clearSessionCookies(w)
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Logged out"})
 }
 
@@ -190,12 +261,17 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"newPassword"`
 }
 
-// ChangePasswordHandler handles password change requests
+// ChangePasswordHandler handles password change requests of local ("core") accounts: the setup
+// admin's password lives in the auth secret, the other local users' in the users store.
+// On success the caller's other sessions and its API tokens are revoked.
 func (s *Service) ChangePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	s.mu.RLock()
 	setupMode := s.setupMode
 	authService := s.authService
 	k8sRepo := s.k8sRepo
+	localUsers := s.localUsers
+	sessions := s.sessions
+	apiTokens := s.apiTokens
 	s.mu.RUnlock()
 
 	// Check if in setup mode
@@ -231,45 +307,52 @@ func (s *Service) ChangePasswordHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Get current username from context
-	claims, err := authService.GetCurrentUser(ctx)
-	if err != nil {
+	// Get current user from context
+	claims, ok := ctx.Value(userContextKey).(*AuthClaims)
+	if !ok {
 		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
+	if claims.IDP != "" && claims.IDP != "core" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Password change is only available for local accounts")
+		return
+	}
 
-	// Verify current password
-	loginReq := LoginRequest{
-		Username: claims.Username,
-		Password: req.CurrentPassword,
+	var match bool
+	var err error
+	if adminUser, adminErr := authService.userRepo.GetAdminUser(); (adminErr != nil || adminUser != claims.Username) && localUsers != nil {
+		// Local user other than the setup admin (verification happens without opening a new session)
+		match, err = localUsers.ChangeLocalUserPassword(ctx, claims.Username, req.CurrentPassword, req.NewPassword)
+	} else {
+		match, err = changeAdminPassword(ctx, authService, k8sRepo, claims.Username, req.CurrentPassword, req.NewPassword)
 	}
-	_, err = authService.Login(ctx, loginReq)
 	if err != nil {
-		if err == ErrInvalidCredentials {
-			utils.ErrorResponse(w, http.StatusUnauthorized, "Current password is incorrect")
-			return
-		}
 		utils.HandleErrorJSON(w, err, "Failed to change password", http.StatusInternalServerError, map[string]interface{}{
 			"username": claims.Username,
 		})
 		return
 	}
-
-	// Hash new password
-	newPasswordHash, err := HashPassword(req.NewPassword)
-	if err != nil {
-		utils.HandleErrorJSON(w, err, "Failed to change password", http.StatusInternalServerError, map[string]interface{}{
-			"username": claims.Username,
-		})
+	if !match {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Current password is incorrect")
 		return
 	}
 
-	// Update password in secret
-	if err := k8sRepo.UpdatePassword(ctx, newPasswordHash); err != nil {
-		utils.HandleErrorJSON(w, err, "Failed to change password", http.StatusInternalServerError, map[string]interface{}{
-			"username": claims.Username,
-		})
-		return
+	// Whoever held the old password is logged out everywhere but here
+	if sessions != nil {
+		if _, err := sessions.RevokeOtherIDPSessions(ctx, "core", claims.Username, claims.RegisteredClaims.ID); err != nil {
+			utils.LogWarn("Failed to revoke sessions after password change", map[string]interface{}{
+				"username": claims.Username,
+				"error":    err.Error(),
+			})
+		}
+	}
+	if apiTokens != nil {
+		if _, err := apiTokens.RevokeIDPUser(ctx, "core", claims.Username); err != nil {
+			utils.LogWarn("Failed to revoke API tokens after password change", map[string]interface{}{
+				"username": claims.Username,
+				"error":    err.Error(),
+			})
+		}
 	}
 
 	utils.LogInfo("Password changed successfully", map[string]interface{}{
@@ -280,3 +363,20 @@ func (s *Service) ChangePasswordHandler(w http.ResponseWriter, r *http.Request)
 		"message": "Password changed successfully",
 	})
 }
+
+// changeAdminPassword verifies the setup admin's current password and stores the new hash in the auth secret
+func changeAdminPassword(ctx context.Context, authService *AuthService, k8sRepo *K8sUserRepository, username, currentPassword, newPassword string) (bool, error) {
+	match, err := authService.checkAdminCredentials(username, currentPassword)
+	if err != nil || !match {
+		return false, err
+	}
+
+	newPasswordHash, err := HashPassword(newPassword)
+	if err != nil {
+		return false, err
+	}
+	if err := k8sRepo.UpdatePassword(ctx, newPasswordHash); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -158,3 +158,74 @@ func TestChangePasswordHandler_WithK8s(t *testing.T) {
 		})
 	}
 }
+
+func TestChangePasswordHandler_LocalUser(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	if ns, err := getCurrentNamespace(); err != nil || ns == "" {
+		os.Setenv("POD_NAMESPACE", "default")
+		defer os.Unsetenv("POD_NAMESPACE")
+	}
+	k8sRepo, err := NewK8sUserRepository(client, "dkonsole-auth")
+	if err != nil {
+		t.Fatalf("failed to create k8s repo: %v", err)
+	}
+
+	ctx := context.Background()
+	adminHash, _ := HashPassword("adminpass123")
+	local := &mockLocalUserAuthenticator{users: map[string]string{"alice": "oldpassword123"}}
+	sessions := NewSessionManager(NewMemorySessionStore(), SessionConfig{})
+	apiTokens := NewAPITokenManager(NewMemoryAPITokenStore())
+	service := &Service{
+		authService: NewAuthService(&mockUserRepository{adminUser: "admin", adminPassword: adminHash}, []byte("secret-key-must-be-32-bytes-long-123")),
+		k8sRepo:     k8sRepo,
+		localUsers:  local,
+		sessions:    sessions,
+		apiTokens:   apiTokens,
+	}
+
+	alice := models.Claims{Username: "alice", Role: "user", IDP: "core"}
+	current, _, err := sessions.Create(ctx, alice, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	other, _, err := sessions.Create(ctx, alice, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, _, err := apiTokens.Create(ctx, alice, "ci", 0, nil); err != nil {
+		t.Fatalf("Create() token error = %v", err)
+	}
+
+	changePassword := func(claims models.Claims, currentPassword string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(ChangePasswordRequest{CurrentPassword: currentPassword, NewPassword: "newpassword123"})
+		req := httptest.NewRequest("POST", "/api/change-password", bytes.NewBuffer(body))
+		authClaims := &AuthClaims{Claims: claims, RegisteredClaims: jwt.RegisteredClaims{ID: current.ID}}
+		req = req.WithContext(context.WithValue(req.Context(), UserContextKey(), authClaims))
+		rr := httptest.NewRecorder()
+		service.ChangePasswordHandler(rr, req)
+		return rr
+	}
+
+	if rr := changePassword(alice, "wrongpassword"); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong current password: expected 401, got %v", rr.Code)
+	}
+	if rr := changePassword(models.Claims{Username: "alice", Role: "user", IDP: "ldap"}, "oldpassword123"); rr.Code != http.StatusBadRequest {
+		t.Fatalf("LDAP user: expected 400, got %v", rr.Code)
+	}
+	if rr := changePassword(alice, "oldpassword123"); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %v. Body: %s", rr.Code, rr.Body.String())
+	}
+	if local.users["alice"] != "newpassword123" {
+		t.Errorf("password was not changed in the local user store")
+	}
+
+	if _, err := sessions.Validate(ctx, current.ID, "alice"); err != nil {
+		t.Errorf("current session should survive the password change: %v", err)
+	}
+	if _, err := sessions.Validate(ctx, other.ID, "alice"); err == nil {
+		t.Error("other sessions should be revoked after the password change")
+	}
+	if tokens, err := apiTokens.List(ctx, "alice"); err != nil || len(tokens) != 0 {
+		t.Errorf("API tokens should be revoked after the password change, got %d (err %v)", len(tokens), err)
+	}
+}
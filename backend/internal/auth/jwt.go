@@ -8,11 +8,14 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
 // JWTService provides JWT token operations including extraction, validation, and authentication.
 type JWTService struct {
-	jwtSecret []byte // Secret key used for signing and verifying JWT tokens
+	jwtSecret []byte           // Secret key used for signing and verifying JWT tokens
+	sessions  *SessionManager  // Optional; when set, tokens must belong to an active session
+	apiTokens *APITokenManager // Optional; when set, personal API tokens are accepted too
 }
 
 // NewJWTService creates a new JWTService with the provided JWT secret.
@@ -23,11 +26,28 @@ func NewJWTService(jwtSecret []byte) *JWTService {
 	}
 }
 
+// SetSessionManager makes AuthenticateRequest reject tokens whose session was revoked or expired.
+func (s *JWTService) SetSessionManager(sessions *SessionManager) {
+	s.sessions = sessions
+}
+
+// SetAPITokenManager makes AuthenticateRequest accept personal API tokens ("dkp_" prefix).
+func (s *JWTService) SetAPITokenManager(apiTokens *APITokenManager) {
+	s.apiTokens = apiTokens
+}
+
 // AuthClaims extends models.Claims with JWT registered claims.
 // It contains user information (username, role) and standard JWT claims (expiration, etc.).
 type AuthClaims struct {
 	models.Claims
 	jwt.RegisteredClaims
+	APITokenID     string `json:"-"` // set when the request was authenticated with a personal API token
+	APITokenScoped bool   `json:"-"` // set when that token is limited to part of its owner's permissions
+}
+
+// AuditUser returns the username recorded in audit entries (implements utils.AuditIdentity)
+func (c *AuthClaims) AuditUser() string {
+	return c.Username
 }
 
 // ExtractToken extracts JWT token from HTTP request.
@@ -86,6 +106,8 @@ func (s *JWTService) ValidateToken(tokenString string) (*AuthClaims, error) {
 
 // AuthenticateRequest extracts and validates JWT from HTTP request.
 // This is a convenience method that combines ExtractToken and ValidateToken.
+// With server-side sessions enabled, the token's jti must also refer to an active session.
+// Personal API tokens are accepted in the Authorization header when an APITokenManager is set.
 //
 // Returns the authenticated claims if successful, or an error if authentication fails.
 func (s *JWTService) AuthenticateRequest(r *http.Request) (*AuthClaims, error) {
@@ -94,5 +116,26 @@ func (s *JWTService) AuthenticateRequest(r *http.Request) (*AuthClaims, error) {
 		return nil, err
 	}
 
-	return s.ValidateToken(tokenString)
+	if strings.HasPrefix(tokenString, apiTokenPrefix) {
+		if s.apiTokens == nil || r.Header.Get("Authorization") == "" {
+			return nil, fmt.Errorf("invalid token")
+		}
+		return s.apiTokens.Authenticate(r.Context(), tokenString, utils.GetClientIP(r))
+	}
+
+	claims, err := s.ValidateToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.sessions != nil {
+		if claims.RegisteredClaims.ID == "" {
+			return nil, ErrSessionRevoked
+		}
+		if _, err := s.sessions.Validate(r.Context(), claims.RegisteredClaims.ID, claims.Username); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
 }
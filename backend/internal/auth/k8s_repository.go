@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -12,6 +13,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
@@ -398,3 +400,84 @@ func (r *K8sUserRepository) CreateOrUpdateSecret(ctx context.Context, username,
 
 	return nil
 }
+
+// totpKey is the auth Secret entry holding the TOTP enrollments of core users, by username
+const totpKey = "totp"
+
+// GetTOTP returns the TOTP enrollment of a core user, or nil if the user has none
+func (r *K8sUserRepository) GetTOTP(ctx context.Context, username string) (*TOTPConfig, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("K8s repository not initialized")
+	}
+
+	secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	configs, err := decodeTOTPConfigs(secret)
+	if err != nil {
+		return nil, err
+	}
+	return configs[username], nil
+}
+
+// UpdateTOTP applies fn to the TOTP enrollment of a core user and saves it, retrying on conflicts.
+// A nil result from fn removes the enrollment.
+func (r *K8sUserRepository) UpdateTOTP(ctx context.Context, username string, fn func(current *TOTPConfig) (*TOTPConfig, error)) error {
+	if r.client == nil {
+		return fmt.Errorf("K8s repository not initialized")
+	}
+
+	secrets := r.client.CoreV1().Secrets(r.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, r.secretName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+
+		configs, err := decodeTOTPConfigs(secret)
+		if err != nil {
+			return err
+		}
+		updated, err := fn(configs[username])
+		if err != nil {
+			return err
+		}
+		if updated == nil {
+			if _, ok := configs[username]; !ok {
+				return nil
+			}
+			delete(configs, username)
+		} else {
+			configs[username] = updated
+		}
+
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		if len(configs) == 0 {
+			delete(secret.Data, totpKey)
+		} else {
+			data, err := json.Marshal(configs)
+			if err != nil {
+				return fmt.Errorf("failed to marshal %s: %w", totpKey, err)
+			}
+			secret.Data[totpKey] = data
+		}
+		secret.StringData = nil
+
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+func decodeTOTPConfigs(secret *corev1.Secret) (map[string]*TOTPConfig, error) {
+	configs := make(map[string]*TOTPConfig)
+	if data := secret.Data[totpKey]; len(data) > 0 {
+		if err := json.Unmarshal(data, &configs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal %s: %w", totpKey, err)
+		}
+	}
+	return configs, nil
+}
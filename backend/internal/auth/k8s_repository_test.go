@@ -228,3 +228,54 @@ func TestEnvUserRepository(t *testing.T) {
 		t.Fatalf("env repo returned %q/%q", user, pass)
 	}
 }
+
+func TestK8sUserRepository_TOTP(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	repo := &K8sUserRepository{client: client, namespace: "testns", secretName: "dkonsole-auth"}
+	ctx := context.Background()
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dkonsole-auth", Namespace: "testns"},
+		Data: map[string][]byte{
+			"admin-username":      []byte("admin"),
+			"admin-password-hash": []byte("hash"),
+		},
+	}
+	if _, err := client.CoreV1().Secrets("testns").Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("failed to seed secret: %v", err)
+	}
+
+	if config, err := repo.GetTOTP(ctx, "admin"); err != nil || config != nil {
+		t.Fatalf("GetTOTP() without enrollment = %+v, %v", config, err)
+	}
+
+	err := repo.UpdateTOTP(ctx, "admin", func(current *TOTPConfig) (*TOTPConfig, error) {
+		return &TOTPConfig{Secret: "JBSWY3DPEHPK3PXP", Enabled: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateTOTP() error = %v", err)
+	}
+	config, err := repo.GetTOTP(ctx, "admin")
+	if err != nil || config == nil || !config.Enabled || config.Secret != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("GetTOTP() = %+v, %v", config, err)
+	}
+
+	stored, _ := client.CoreV1().Secrets("testns").Get(ctx, "dkonsole-auth", metav1.GetOptions{})
+	if string(stored.Data["admin-password-hash"]) != "hash" {
+		t.Error("UpdateTOTP() must keep the password hash")
+	}
+
+	// A nil result removes the enrollment and the key once empty
+	if err := repo.UpdateTOTP(ctx, "admin", func(current *TOTPConfig) (*TOTPConfig, error) { return nil, nil }); err != nil {
+		t.Fatalf("UpdateTOTP() delete error = %v", err)
+	}
+	stored, _ = client.CoreV1().Secrets("testns").Get(ctx, "dkonsole-auth", metav1.GetOptions{})
+	if _, ok := stored.Data[totpKey]; ok {
+		t.Error("empty TOTP map should be removed from the secret")
+	}
+
+	want := errors.New("boom")
+	if err := repo.UpdateTOTP(ctx, "admin", func(current *TOTPConfig) (*TOTPConfig, error) { return nil, want }); !errors.Is(err, want) {
+		t.Errorf("UpdateTOTP() error = %v, want %v", err, want)
+	}
+}
@@ -3,15 +3,18 @@ package auth
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/flaucha/DKonsole/backend/internal/models"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
 // Login authenticates a user and generates a JWT token.
 // It first tries admin authentication, then falls back to LDAP if enabled.
 // If IDP is specified ("core" or "ldap"), only that method is tried.
-// Returns a JWT token valid for 24 hours if authentication succeeds.
+// Returns a JWT token valid for 24 hours if authentication succeeds, or a two-factor
+// challenge (Response.MFARequired) for core accounts with TOTP enabled; see LoginWithTOTP.
 //
 // Returns ErrInvalidCredentials if username or password is incorrect.
 func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult, error) {
@@ -38,6 +41,15 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult
 		if authenticated {
 			role = "admin"
 			req.IDP = "core"
+		} else if s.localUsers != nil {
+			// Not the setup admin: try the local users stored next to it
+			claims, err := s.localUsers.AuthenticateLocalUser(ctx, req.Username, req.Password)
+			if err != nil {
+				utils.LogError(err, "Failed to check local user credentials", map[string]interface{}{"username": req.Username})
+			} else if claims != nil {
+				claims.IDP = "core"
+				return s.finishLogin(ctx, *claims, req.Client)
+			}
 		}
 	}
 
@@ -82,30 +94,9 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult
 						if perms == nil {
 							role = "admin"
 							// We don't need to check groups if perms say admin
-						} else {
+						} else if groups, err := s.ldapAuth.GetUserGroups(ctx, req.Username); err == nil && s.inLDAPAdminGroup(ctx, req.Username, groups) {
 							// Check if user is in admin group via config
-							groups, err := s.ldapAuth.GetUserGroups(ctx, req.Username)
-							if err == nil {
-								// Need config for AdminGroups check
-								config, _ := s.ldapAuth.GetConfig(ctx)
-								if config != nil {
-									for _, adminGroup := range config.AdminGroups {
-										for _, group := range groups {
-											if group == adminGroup {
-												role = "admin"
-												utils.LogInfo("User promoted to admin via LDAP group", map[string]interface{}{
-													"username": req.Username,
-													"group":    group,
-												})
-												break
-											}
-										}
-										if role == "admin" {
-											break
-										}
-									}
-								}
-							}
+							role = "admin"
 						}
 					}
 				}
@@ -122,9 +113,6 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult
 		return nil, ErrInvalidCredentials
 	}
 
-	// Calculate expiration
-	expirationTime := time.Now().Add(24 * time.Hour)
-
 	// Get permissions if not admin (and we haven't already fetched them)
 	permissions := make(map[string]string)
 	if role != "admin" && req.IDP == "ldap" && s.ldapAuth != nil {
@@ -142,28 +130,199 @@ func (s *AuthService) Login(ctx context.Context, req LoginRequest) (*LoginResult
 		}
 	}
 
-	// Generate JWT token
-	token, err := s.generateToken(req.Username, role, req.IDP, permissions, expirationTime)
+	// LDAP groups travel in the token so Kubernetes requests can impersonate them
+	var groups []string
+	if req.IDP == "ldap" && s.ldapAuth != nil {
+		if g, err := s.ldapAuth.GetUserGroups(ctx, req.Username); err == nil {
+			groups = g
+		} else {
+			utils.LogWarn("Failed to get user groups", map[string]interface{}{
+				"username": req.Username,
+				"error":    err.Error(),
+			})
+		}
+	}
+
+	return s.finishLogin(ctx, models.Claims{
+		Username:    req.Username,
+		Role:        role,
+		IDP:         req.IDP,
+		Permissions: permissions,
+		Groups:      groups,
+	}, req.Client)
+}
+
+// issueTokens completes a successful login. With server-side sessions it opens a session and
+// returns a short-lived access token plus a refresh token; otherwise it returns a 24h token.
+func (s *AuthService) issueTokens(ctx context.Context, claims models.Claims, client SessionMeta) (*LoginResult, error) {
+	result := &LoginResult{
+		Response: LoginResponse{
+			Role: claims.Role,
+		},
+	}
+
+	tokenID := ""
+	expirationTime := time.Now().Add(24 * time.Hour)
+	if s.sessions != nil {
+		session, refreshToken, err := s.sessions.Create(ctx, claims, client)
+		if err != nil {
+			utils.LogError(err, "Failed to create session", map[string]interface{}{"username": claims.Username})
+			return nil, err
+		}
+		tokenID = session.ID
+		expirationTime = s.sessions.AccessTokenExpiry(session)
+		result.RefreshToken = refreshToken
+		result.RefreshExpires = session.ExpiresAt
+	}
+
+	token, err := s.generateToken(claims, tokenID, expirationTime)
 	if err != nil {
-		utils.LogError(err, "Failed to generate token", map[string]interface{}{"username": req.Username})
+		utils.LogError(err, "Failed to generate token", map[string]interface{}{"username": claims.Username})
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
+	result.Token = token
+	result.Expires = expirationTime
 
 	utils.LogInfo("Login successful", map[string]interface{}{
-		"username": req.Username,
-		"role":     role,
-		"idp":      req.IDP,
+		"username": claims.Username,
+		"role":     claims.Role,
+		"idp":      claims.IDP,
+	})
+
+	return result, nil
+}
+
+// RefreshSession exchanges a refresh token for a new access token.
+// LDAP users are re-authorized against the directory so group and permission changes take
+// effect without waiting for the session to expire.
+//
+// Returns ErrSessionsDisabled without server-side sessions and ErrSessionRevoked when the
+// refresh token is invalid, the session was revoked, or the user is no longer authorized.
+func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string) (*LoginResult, error) {
+	if s.sessions == nil {
+		return nil, ErrSessionsDisabled
+	}
+
+	session, newRefreshToken, err := s.sessions.Refresh(ctx, refreshToken, func(claims models.Claims) (models.Claims, error) {
+		return s.reauthorizeClaims(ctx, claims)
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	expirationTime := s.sessions.AccessTokenExpiry(session)
+	token, err := s.generateToken(session.Claims, session.ID, expirationTime)
+	if err != nil {
+		utils.LogError(err, "Failed to generate token", map[string]interface{}{"username": session.Claims.Username})
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
 
 	return &LoginResult{
 		Response: LoginResponse{
-			Role: role,
+			Role: session.Claims.Role,
 		},
-		Token:   token,
-		Expires: expirationTime,
+		Token:          token,
+		Expires:        expirationTime,
+		RefreshToken:   newRefreshToken,
+		RefreshExpires: session.ExpiresAt,
 	}, nil
 }
 
+// reauthorizeClaims re-reads the role and permissions of local users, and the permissions
+// and groups of LDAP users. Setup admin and OIDC claims are kept as issued, since they cannot
+// change or their identity provider cannot be queried without the user; for that reason OIDC
+// users cannot create API tokens.
+// A temporary LDAP error keeps the previous permissions.
+func (s *AuthService) reauthorizeClaims(ctx context.Context, claims models.Claims) (models.Claims, error) {
+	if claims.IDP == "core" {
+		return s.reauthorizeLocalUser(ctx, claims)
+	}
+	if claims.IDP != "ldap" || s.ldapAuth == nil {
+		return claims, nil
+	}
+
+	if err := s.ldapAuth.ValidateUserGroup(ctx, claims.Username); err != nil {
+		return claims, err
+	}
+
+	perms, err := s.ldapAuth.GetUserPermissions(ctx, claims.Username)
+	if err != nil {
+		utils.LogWarn("Failed to refresh user permissions, keeping previous ones", map[string]interface{}{
+			"username": claims.Username,
+			"error":    err.Error(),
+		})
+		return claims, nil
+	}
+
+	groups, err := s.ldapAuth.GetUserGroups(ctx, claims.Username)
+	if err != nil {
+		utils.LogWarn("Failed to refresh user groups, keeping previous ones", map[string]interface{}{
+			"username": claims.Username,
+			"error":    err.Error(),
+		})
+		groups = claims.Groups
+	}
+
+	refreshed := claims
+	refreshed.Groups = groups
+	refreshed.Role = "user"
+	refreshed.Permissions = perms
+	if perms == nil || s.inLDAPAdminGroup(ctx, claims.Username, groups) {
+		refreshed.Role = "admin"
+		refreshed.Permissions = make(map[string]string)
+	}
+	return refreshed, nil
+}
+
+// reauthorizeLocalUser reloads a local user's role and permissions; a deleted user loses its session.
+// A temporary storage error keeps the previous permissions.
+func (s *AuthService) reauthorizeLocalUser(ctx context.Context, claims models.Claims) (models.Claims, error) {
+	if s.localUsers == nil {
+		return claims, nil
+	}
+	if adminUser, err := s.userRepo.GetAdminUser(); err == nil && adminUser == claims.Username {
+		return claims, nil
+	}
+
+	current, err := s.localUsers.LocalUserClaims(ctx, claims.Username)
+	if err != nil {
+		utils.LogWarn("Failed to refresh local user permissions, keeping previous ones", map[string]interface{}{
+			"username": claims.Username,
+			"error":    err.Error(),
+		})
+		return claims, nil
+	}
+	if current == nil {
+		return claims, fmt.Errorf("local user %s no longer exists", claims.Username)
+	}
+
+	refreshed := claims
+	refreshed.Role = current.Role
+	refreshed.Permissions = current.Permissions
+	return refreshed, nil
+}
+
+// inLDAPAdminGroup reports whether one of the user's groups is a configured LDAP admin group.
+// Group names are compared case-insensitively, like in group permission mappings.
+func (s *AuthService) inLDAPAdminGroup(ctx context.Context, username string, groups []string) bool {
+	config, _ := s.ldapAuth.GetConfig(ctx)
+	if config == nil {
+		return false
+	}
+	for _, adminGroup := range config.AdminGroups {
+		for _, group := range groups {
+			if strings.EqualFold(group, adminGroup) {
+				utils.LogInfo("User promoted to admin via LDAP group", map[string]interface{}{
+					"username": username,
+					"group":    group,
+				})
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *AuthService) checkAdminCredentials(username, password string) (bool, error) {
 	// Get admin username from repo
 	adminUser, err := s.userRepo.GetAdminUser()
@@ -189,3 +348,38 @@ func (s *AuthService) checkAdminCredentials(username, password string) (bool, er
 	}
 	return match, nil
 }
+
+// StartOIDCLogin begins an OIDC login and returns the provider URL and the expected state.
+//
+// Returns ErrOIDCNotConfigured if no OIDC authenticator is set.
+func (s *AuthService) StartOIDCLogin(ctx context.Context) (authURL, state string, err error) {
+	if s.oidcAuth == nil {
+		return "", "", ErrOIDCNotConfigured
+	}
+	return s.oidcAuth.BeginLogin(ctx)
+}
+
+// LoginWithOIDC completes an OIDC login and issues the same tokens as a password login.
+// The identity provider has already authenticated the user; this only maps the
+// verified identity to a DKonsole session.
+//
+// Returns ErrOIDCNotConfigured if no OIDC authenticator is set.
+func (s *AuthService) LoginWithOIDC(ctx context.Context, state, code string, client SessionMeta) (*LoginResult, error) {
+	if s.oidcAuth == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	claims, err := s.oidcAuth.CompleteLogin(ctx, state, code)
+	if err != nil {
+		return nil, err
+	}
+	claims.IDP = "oidc"
+	if claims.Role == "" {
+		claims.Role = "user"
+	}
+	if claims.Role != "admin" && claims.Permissions == nil {
+		claims.Permissions = make(map[string]string)
+	}
+
+	return s.issueTokens(ctx, *claims, client)
+}
@@ -7,10 +7,11 @@ import (
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
-// contextKey is a custom type for context keys to avoid collisions
-type contextKey string
+// contextKey is a custom type for context keys to avoid collisions.
+// It is shared with utils so the audit log can read the user without importing auth.
+type contextKey = utils.ContextKey
 
-const userContextKey contextKey = "user"
+const userContextKey = utils.UserContextKey
 
 // UserContextKey returns the context key for user information
 // This is exported so other packages can access user information from context
@@ -62,3 +63,16 @@ func (s *Service) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r.WithContext(ctx))
 	}
 }
+
+// InteractiveOnly rejects requests authenticated with a personal API token.
+// Account and credential management routes are wrapped with it so that a leaked token
+// cannot be turned into a persistent account takeover.
+func InteractiveOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if claims, ok := r.Context().Value(userContextKey).(*AuthClaims); ok && claims.APITokenID != "" {
+			utils.ErrorResponse(w, http.StatusForbidden, "API tokens cannot be used to manage accounts or credentials")
+			return
+		}
+		next(w, r)
+	}
+}
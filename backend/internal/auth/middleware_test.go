@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -136,3 +137,32 @@ func TestAuthMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestInteractiveOnly(t *testing.T) {
+	handler := InteractiveOnly(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		claims         *AuthClaims
+		expectedStatus int
+	}{
+		{"session", &AuthClaims{Claims: models.Claims{Username: "admin"}}, http.StatusOK},
+		{"API token", &AuthClaims{Claims: models.Claims{Username: "admin"}, APITokenID: "abc"}, http.StatusForbidden},
+		{"no claims", nil, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/users", nil)
+			if tt.claims != nil {
+				req = req.WithContext(context.WithValue(req.Context(), userContextKey, tt.claims))
+			}
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.expectedStatus)
+			}
+		})
+	}
+}
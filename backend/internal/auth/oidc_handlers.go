@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// oidcStateCookie binds the OIDC callback to the browser that started the login
+	oidcStateCookie = "oidc_state"
+	oidcCookiePath  = "/api/auth/oidc"
+	oidcStateMaxAge = 600 // seconds, matches the login state lifetime
+
+	// oidcLoginPage is where the browser is sent when an OIDC login fails
+	oidcLoginPage = "/login"
+)
+
+// OIDCLoginHandler starts an OIDC authorization-code + PKCE login.
+// It stores the state in a short-lived cookie and redirects the browser to the identity provider.
+//
+// @Summary Iniciar login OIDC
+// @Description Redirige al proveedor OIDC (authorization code + PKCE)
+// @Tags auth
+// @Success 302 "Redirección al proveedor OIDC"
+// @Router /api/auth/oidc/login [get]
+func (s *Service) OIDCLoginHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	setupMode := s.setupMode
+	authService := s.authService
+	s.mu.RUnlock()
+
+	if setupMode || authService == nil {
+		redirectOIDCError(w, r, "oidc_unavailable")
+		return
+	}
+
+	authURL, state, err := authService.StartOIDCLogin(r.Context())
+	if err != nil {
+		utils.LogError(err, "Failed to start OIDC login", nil)
+		redirectOIDCError(w, r, "oidc_unavailable")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		MaxAge:   oidcStateMaxAge,
+		HttpOnly: true,
+		Secure:   true,
+		// Lax so the cookie is sent on the top-level redirect back from the provider
+		SameSite: http.SameSiteLaxMode,
+		Path:     oidcCookiePath,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallbackHandler completes an OIDC login.
+// On success it sets the same token cookie as LoginHandler and redirects to the application root.
+//
+// @Summary Callback de login OIDC
+// @Description Intercambia el código de autorización y crea la sesión de DKonsole
+// @Tags auth
+// @Param code query string true "Código de autorización"
+// @Param state query string true "Estado del login"
+// @Success 302 "Redirección a la aplicación"
+// @Router /api/auth/oidc/callback [get]
+func (s *Service) OIDCCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	authService := s.authService
+	s.mu.RUnlock()
+
+	// The state cookie is single use
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+		Path:     oidcCookiePath,
+	})
+
+	query := r.URL.Query()
+	if providerErr := query.Get("error"); providerErr != "" {
+		utils.LogWarn("OIDC provider returned an error", map[string]interface{}{
+			"error":       providerErr,
+			"description": query.Get("error_description"),
+		})
+		redirectOIDCError(w, r, "oidc_denied")
+		return
+	}
+
+	state := query.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		utils.LogWarn("OIDC callback state mismatch", nil)
+		redirectOIDCError(w, r, "oidc_failed")
+		return
+	}
+
+	if authService == nil {
+		redirectOIDCError(w, r, "oidc_unavailable")
+		return
+	}
+
+	result, err := authService.LoginWithOIDC(r.Context(), state, query.Get("code"), clientMeta(r))
+	if err != nil {
+		utils.LogError(err, "OIDC login failed", nil)
+		code := "oidc_failed"
+		if errors.Is(err, ErrOIDCAccessDenied) {
+			code = "oidc_denied"
+		}
+		redirectOIDCError(w, r, code)
+		return
+	}
+
+	setSessionCookies(w, result)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
+
+// redirectOIDCError sends the browser back to the login page with an error code
+func redirectOIDCError(w http.ResponseWriter, r *http.Request, code string) {
+	http.Redirect(w, r, oidcLoginPage+"?error="+url.QueryEscape(code), http.StatusFound)
+}
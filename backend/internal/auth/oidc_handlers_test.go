@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// mockOIDCAuthenticator is a mock implementation of OIDCAuthenticator for testing
+type mockOIDCAuthenticator struct {
+	beginErr    error
+	completeErr error
+	claims      *models.Claims
+	gotState    string
+	gotCode     string
+}
+
+func (m *mockOIDCAuthenticator) BeginLogin(ctx context.Context) (string, string, error) {
+	if m.beginErr != nil {
+		return "", "", m.beginErr
+	}
+	return "https://idp.example.com/authorize?state=state-123", "state-123", nil
+}
+
+func (m *mockOIDCAuthenticator) CompleteLogin(ctx context.Context, state, code string) (*models.Claims, error) {
+	m.gotState = state
+	m.gotCode = code
+	if m.completeErr != nil {
+		return nil, m.completeErr
+	}
+	return m.claims, nil
+}
+
+func newOIDCTestService(oidcAuth OIDCAuthenticator) *Service {
+	service := &Service{authService: NewAuthService(&mockUserRepository{}, []byte("test-secret-key-must-be-at-least-32-characters-long"))}
+	service.SetOIDCAuthenticator(oidcAuth)
+	return service
+}
+
+func callbackRequest(query, cookieState string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/oidc/callback?"+query, nil)
+	if cookieState != "" {
+		req.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: cookieState})
+	}
+	return req
+}
+
+func findCookie(rr *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestOIDCLoginHandler(t *testing.T) {
+	service := newOIDCTestService(&mockOIDCAuthenticator{})
+
+	rr := httptest.NewRecorder()
+	service.OIDCLoginHandler(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+
+	if rr.Code != http.StatusFound || !strings.HasPrefix(rr.Header().Get("Location"), "https://idp.example.com/authorize") {
+		t.Fatalf("expected redirect to provider, got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	cookie := findCookie(rr, oidcStateCookie)
+	if cookie == nil || cookie.Value != "state-123" || !cookie.HttpOnly || cookie.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("expected HttpOnly Lax state cookie, got %+v", cookie)
+	}
+
+	// Provider unavailable
+	service = newOIDCTestService(&mockOIDCAuthenticator{beginErr: errors.New("disabled")})
+	rr = httptest.NewRecorder()
+	service.OIDCLoginHandler(rr, httptest.NewRequest(http.MethodGet, "/api/auth/oidc/login", nil))
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/login?error=oidc_unavailable" {
+		t.Fatalf("expected redirect to login page, got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+}
+
+func TestOIDCCallbackHandler_Success(t *testing.T) {
+	oidcAuth := &mockOIDCAuthenticator{claims: &models.Claims{
+		Username: "alice",
+		Role:     "user",
+		Groups:   []string{"devs"},
+	}}
+	service := newOIDCTestService(oidcAuth)
+
+	rr := httptest.NewRecorder()
+	service.OIDCCallbackHandler(rr, callbackRequest("state=state-123&code=abc", "state-123"))
+
+	if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/" {
+		t.Fatalf("expected redirect to app, got %d %s", rr.Code, rr.Header().Get("Location"))
+	}
+	if oidcAuth.gotState != "state-123" || oidcAuth.gotCode != "abc" {
+		t.Fatalf("unexpected state/code passed to authenticator: %q %q", oidcAuth.gotState, oidcAuth.gotCode)
+	}
+
+	tokenCookie := findCookie(rr, "token")
+	if tokenCookie == nil || tokenCookie.Value == "" {
+		t.Fatalf("expected token cookie to be set")
+	}
+	claims := &AuthClaims{}
+	if _, err := jwt.ParseWithClaims(tokenCookie.Value, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte("test-secret-key-must-be-at-least-32-characters-long"), nil
+	}); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims.Username != "alice" || claims.IDP != "oidc" || claims.Role != "user" || len(claims.Groups) != 1 {
+		t.Fatalf("unexpected token claims: %+v", claims.Claims)
+	}
+
+	if cookie := findCookie(rr, oidcStateCookie); cookie == nil || cookie.MaxAge >= 0 {
+		t.Fatalf("expected state cookie to be cleared, got %+v", cookie)
+	}
+}
+
+func TestOIDCCallbackHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		req         *http.Request
+		completeErr error
+		want        string
+	}{
+		{name: "missing state cookie", req: callbackRequest("state=state-123&code=abc", ""), want: "oidc_failed"},
+		{name: "state mismatch", req: callbackRequest("state=state-123&code=abc", "other"), want: "oidc_failed"},
+		{name: "provider error", req: callbackRequest("error=access_denied&state=state-123", "state-123"), want: "oidc_denied"},
+		{name: "access denied", req: callbackRequest("state=state-123&code=abc", "state-123"), completeErr: ErrOIDCAccessDenied, want: "oidc_denied"},
+		{name: "exchange failure", req: callbackRequest("state=state-123&code=abc", "state-123"), completeErr: errors.New("invalid_grant"), want: "oidc_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newOIDCTestService(&mockOIDCAuthenticator{completeErr: tt.completeErr, claims: &models.Claims{Username: "alice"}})
+			rr := httptest.NewRecorder()
+			service.OIDCCallbackHandler(rr, tt.req)
+
+			if rr.Code != http.StatusFound || rr.Header().Get("Location") != "/login?error="+tt.want {
+				t.Fatalf("expected redirect with %s, got %d %s", tt.want, rr.Code, rr.Header().Get("Location"))
+			}
+			if findCookie(rr, "token") != nil {
+				t.Fatalf("token cookie must not be set on failure")
+			}
+		})
+	}
+}
@@ -19,12 +19,36 @@ type LDAPAuthenticator interface {
 	GetConfig(ctx context.Context) (*models.LDAPConfig, error)
 }
 
+// OIDCAuthenticator defines the interface for OpenID Connect login
+type OIDCAuthenticator interface {
+	// BeginLogin returns the provider authorization URL and the state expected on the callback
+	BeginLogin(ctx context.Context) (authURL, state string, err error)
+	// CompleteLogin exchanges the authorization code and returns the user's claims
+	CompleteLogin(ctx context.Context, state, code string) (*models.Claims, error)
+}
+
+// LocalUserAuthenticator defines the interface for local ("core") users other than the setup admin
+type LocalUserAuthenticator interface {
+	// AuthenticateLocalUser returns the user's claims, or nil if the username or password is wrong
+	AuthenticateLocalUser(ctx context.Context, username, password string) (*models.Claims, error)
+	// LocalUserClaims returns the current claims of a user, or nil if it no longer exists
+	LocalUserClaims(ctx context.Context, username string) (*models.Claims, error)
+	// ChangeLocalUserPassword sets a new password, returning false if the user is missing or the current password is wrong
+	ChangeLocalUserPassword(ctx context.Context, username, currentPassword, newPassword string) (bool, error)
+}
+
 // AuthService provides business logic for authentication operations.
 // It handles user authentication, password verification, and JWT token generation.
 type AuthService struct {
-	userRepo  UserRepository
-	jwtSecret []byte
-	ldapAuth  LDAPAuthenticator // Optional LDAP authenticator
+	userRepo   UserRepository
+	jwtSecret  []byte
+	ldapAuth   LDAPAuthenticator      // Optional LDAP authenticator
+	oidcAuth   OIDCAuthenticator      // Optional OIDC authenticator
+	localUsers LocalUserAuthenticator // Optional local users besides the setup admin
+	sessions   *SessionManager        // Optional server-side sessions; nil issues stateless 24h tokens
+	totp       TOTPStore              // Optional TOTP enrollments of core users; nil disables two-factor
+	mfa        *mfaAttempts           // Failed second-step attempts per login challenge
+	now        func() time.Time
 }
 
 // NewAuthService creates a new AuthService with the provided user repository and JWT secret.
@@ -34,6 +58,8 @@ func NewAuthService(userRepo UserRepository, jwtSecret []byte) *AuthService {
 		userRepo:  userRepo,
 		jwtSecret: jwtSecret,
 		ldapAuth:  nil,
+		mfa:       newMFAAttempts(),
+		now:       time.Now,
 	}
 }
 
@@ -42,23 +68,49 @@ func (s *AuthService) SetLDAPAuthenticator(ldapAuth LDAPAuthenticator) {
 	s.ldapAuth = ldapAuth
 }
 
+// SetOIDCAuthenticator sets the OIDC authenticator for the service
+func (s *AuthService) SetOIDCAuthenticator(oidcAuth OIDCAuthenticator) {
+	s.oidcAuth = oidcAuth
+}
+
+// SetLocalUserAuthenticator sets the local users authenticator for the service
+func (s *AuthService) SetLocalUserAuthenticator(localUsers LocalUserAuthenticator) {
+	s.localUsers = localUsers
+}
+
+// SetTOTPStore enables TOTP two-factor authentication for core accounts
+func (s *AuthService) SetTOTPStore(store TOTPStore) {
+	s.totp = store
+}
+
+// SetSessionManager enables server-side sessions: logins issue short-lived access tokens
+// bound to a revocable session plus a refresh token.
+func (s *AuthService) SetSessionManager(sessions *SessionManager) {
+	s.sessions = sessions
+}
+
 // LoginRequest represents login credentials provided by the user.
 type LoginRequest struct {
-	Username string // Username for authentication
-	Password string // Password for authentication
-	IDP      string // Identity Provider: "core" for admin, "ldap" for LDAP, "" for auto-detect
+	Username string      // Username for authentication
+	Password string      // Password for authentication
+	IDP      string      // Identity Provider: "core" for admin, "ldap" for LDAP, "" for auto-detect
+	Client   SessionMeta // Client that is logging in, recorded on the session
 }
 
 // LoginResponse represents the response after successful login.
 type LoginResponse struct {
-	Role string `json:"role"` // User role (typically "admin")
+	Role        string `json:"role"`                  // User role (typically "admin")
+	MFARequired bool   `json:"mfaRequired,omitempty"` // Password accepted, a TOTP or recovery code is required
+	MFAToken    string `json:"mfaToken,omitempty"`    // Challenge to send back with the code to /api/login/mfa
 }
 
 // LoginResult represents the complete login result including JWT token and expiration.
 type LoginResult struct {
-	Response LoginResponse // Login response with user role
-	Token    string        // JWT token for subsequent authenticated requests
-	Expires  time.Time     // Expiration time of the JWT token
+	Response       LoginResponse // Login response with user role
+	Token          string        // JWT token for subsequent authenticated requests
+	Expires        time.Time     // Expiration time of the JWT token
+	RefreshToken   string        // Refresh token (empty when sessions are disabled or the token was not rotated)
+	RefreshExpires time.Time     // Expiration time of the refresh token (end of the session)
 }
 
 // GetCurrentUser extracts user information from the request context.
@@ -73,10 +125,12 @@ func (s *AuthService) GetCurrentUser(ctx context.Context) (*models.Claims, error
 
 	var username, role string
 	var permissions map[string]string
+	var groups []string
 	if claims, ok := userVal.(*AuthClaims); ok {
 		username = claims.Username
 		role = claims.Role
 		permissions = claims.Permissions
+		groups = claims.Groups
 
 		utils.LogInfo("GetCurrentUser: extracted from AuthClaims", map[string]interface{}{
 			"username":    username,
@@ -100,6 +154,7 @@ func (s *AuthService) GetCurrentUser(ctx context.Context) (*models.Claims, error
 		Username:    username,
 		Role:        role,
 		Permissions: permissions,
+		Groups:      groups,
 	}
 
 	utils.LogInfo("GetCurrentUser: returning claims", map[string]interface{}{
@@ -115,18 +170,25 @@ func (s *AuthService) GetCurrentUser(ctx context.Context) (*models.Claims, error
 var (
 	ErrInvalidCredentials = &AuthError{Message: "Invalid credentials"}
 	ErrUnauthorized       = &AuthError{Message: "Unauthorized"}
+	ErrOIDCNotConfigured  = &AuthError{Message: "OIDC login is not configured"}
+	ErrOIDCAccessDenied   = &AuthError{Message: "User is not allowed to access DKonsole"}
+	ErrSessionRevoked     = &AuthError{Message: "Session expired or revoked"}
+	ErrSessionsDisabled   = &AuthError{Message: "Server-side sessions are not enabled"}
+	ErrInvalidMFACode     = &AuthError{Message: "Invalid verification code"}
+	ErrMFAChallenge       = &AuthError{Message: "Two-factor challenge expired or invalid, please log in again"}
+	ErrTOTPUnavailable    = &AuthError{Message: "Two-factor authentication is not available"}
+	ErrTOTPEnabled        = &AuthError{Message: "Two-factor authentication is already enabled"}
+	ErrTOTPNotEnrolled    = &AuthError{Message: "Two-factor authentication is not enrolled"}
+	ErrInvalidAPIToken    = &AuthError{Message: "Invalid or expired API token"}
 )
 
-// generateToken creates a new JWT token for the user
-func (s *AuthService) generateToken(username, role, idp string, permissions map[string]string, expiration time.Time) (string, error) {
+// generateToken creates a new JWT token for the user.
+// tokenID is stored as the jti claim and links the token to its server-side session.
+func (s *AuthService) generateToken(user models.Claims, tokenID string, expiration time.Time) (string, error) {
 	claims := &AuthClaims{
-		Claims: models.Claims{
-			Username:    username,
-			Role:        role,
-			IDP:         idp,
-			Permissions: permissions,
-		},
+		Claims: user,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expiration),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
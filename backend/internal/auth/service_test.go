@@ -2,11 +2,14 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
+
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
@@ -438,3 +441,115 @@ func TestAuthService_LoginWithLDAP(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthService_LoginWithLDAP_IncludesGroups(t *testing.T) {
+	jwtSecret := []byte("test-secret-key-must-be-at-least-32-characters-long")
+	service := NewAuthService(&mockUserRepository{}, jwtSecret)
+	service.SetLDAPAuthenticator(&mockLDAPAuthenticator{
+		getUserPermissionsFunc: func(ctx context.Context, username string) (map[string]string, error) {
+			return map[string]string{"namespace1": "view"}, nil
+		},
+		getUserGroupsFunc: func(ctx context.Context, username string) ([]string, error) {
+			return []string{"devs", "ops"}, nil
+		},
+	})
+
+	result, err := service.Login(context.Background(), LoginRequest{Username: "ldapuser", Password: "ldappass", IDP: "ldap"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	claims := &AuthClaims{}
+	if _, err := jwt.ParseWithClaims(result.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims.IDP != "ldap" || len(claims.Groups) != 2 || claims.Groups[0] != "devs" || claims.Groups[1] != "ops" {
+		t.Errorf("expected LDAP groups in token, got idp=%q groups=%v", claims.IDP, claims.Groups)
+	}
+}
+
+type mockLocalUserAuthenticator struct {
+	users map[string]string // username -> password
+	perms map[string]string
+}
+
+func (m *mockLocalUserAuthenticator) AuthenticateLocalUser(ctx context.Context, username, password string) (*models.Claims, error) {
+	if pass, ok := m.users[username]; !ok || pass != password {
+		return nil, nil
+	}
+	return m.LocalUserClaims(ctx, username)
+}
+
+func (m *mockLocalUserAuthenticator) LocalUserClaims(ctx context.Context, username string) (*models.Claims, error) {
+	if _, ok := m.users[username]; !ok {
+		return nil, nil
+	}
+	return &models.Claims{Username: username, Role: "user", IDP: "core", Permissions: m.perms}, nil
+}
+
+func (m *mockLocalUserAuthenticator) ChangeLocalUserPassword(ctx context.Context, username, currentPassword, newPassword string) (bool, error) {
+	if pass, ok := m.users[username]; !ok || pass != currentPassword {
+		return false, nil
+	}
+	m.users[username] = newPassword
+	return true, nil
+}
+
+func TestAuthService_LoginWithLocalUser(t *testing.T) {
+	hash, err := HashPassword("adminpass")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	jwtSecret := []byte("test-secret-key-must-be-at-least-32-characters-long")
+	local := &mockLocalUserAuthenticator{
+		users: map[string]string{"alice": "alicepass"},
+		perms: map[string]string{"payments": "edit"},
+	}
+	service := NewAuthService(&mockUserRepository{adminUser: "admin", adminPassword: hash}, jwtSecret)
+	service.SetLocalUserAuthenticator(local)
+	service.SetSessionManager(NewSessionManager(NewMemorySessionStore(), SessionConfig{}))
+	ctx := context.Background()
+
+	if _, err := service.Login(ctx, LoginRequest{Username: "alice", Password: "wrong", IDP: "core"}); err != ErrInvalidCredentials {
+		t.Fatalf("Login() with wrong password error = %v, want ErrInvalidCredentials", err)
+	}
+
+	result, err := service.Login(ctx, LoginRequest{Username: "alice", Password: "alicepass", IDP: "core"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if result.Response.Role != "user" {
+		t.Errorf("role = %q, want user", result.Response.Role)
+	}
+	claims := &AuthClaims{}
+	if _, err := jwt.ParseWithClaims(result.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	}); err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if claims.IDP != "core" || claims.Permissions["payments"] != "edit" {
+		t.Errorf("unexpected claims: idp=%q permissions=%v", claims.IDP, claims.Permissions)
+	}
+
+	// Permission changes are picked up on refresh
+	local.perms = map[string]string{"payments": "view"}
+	refreshed, err := service.RefreshSession(ctx, result.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshSession() error = %v", err)
+	}
+	claims = &AuthClaims{}
+	_, _ = jwt.ParseWithClaims(refreshed.Token, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+	if claims.Permissions["payments"] != "view" {
+		t.Errorf("permissions after refresh = %v, want payments:view", claims.Permissions)
+	}
+
+	// A deleted user cannot refresh its session
+	delete(local.users, "alice")
+	if _, err := service.RefreshSession(ctx, refreshed.RefreshToken); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("RefreshSession() for deleted user error = %v, want ErrSessionRevoked", err)
+	}
+}
@@ -0,0 +1,484 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	defaultAccessTokenTTL = 15 * time.Minute
+	defaultSessionTTL     = 24 * time.Hour
+
+	// sessionTouchInterval throttles last-used updates so the store is not written on every request
+	sessionTouchInterval = time.Minute
+	// refreshReuseGrace lets concurrent refreshes (e.g. two tabs) present the previous refresh token
+	refreshReuseGrace = 30 * time.Second
+
+	// SessionStoreMemory keeps sessions in process memory (lost on restart, single replica only)
+	SessionStoreMemory = "memory"
+	// SessionStoreSecret persists sessions in a Kubernetes Secret shared by all replicas
+	SessionStoreSecret = "secret"
+)
+
+// ErrSessionNotFound is returned by a SessionStore when the session does not exist
+var ErrSessionNotFound = errors.New("session not found")
+
+var (
+	// errRefreshTokenReused is returned while refreshing when the presented refresh token is
+	// the previous one after refreshReuseGrace
+	errRefreshTokenReused = errors.New("refresh token reused")
+	// errRefreshTokenInvalid is returned while refreshing when the presented refresh token is
+	// neither the current nor the previous one of the session
+	errRefreshTokenInvalid = errors.New("invalid refresh token")
+)
+
+// Session is the server-side record of a login. The access token carries its ID as jti,
+// so deleting the record invalidates the token immediately.
+type Session struct {
+	ID        string        `json:"id"`
+	Claims    models.Claims `json:"claims"`
+	IP        string        `json:"ip,omitempty"`
+	UserAgent string        `json:"userAgent,omitempty"`
+	CreatedAt time.Time     `json:"createdAt"`
+	LastUsed  time.Time     `json:"lastUsed"`
+	ExpiresAt time.Time     `json:"expiresAt"`
+
+	RefreshHash         string    `json:"refreshHash"`
+	PreviousRefreshHash string    `json:"previousRefreshHash,omitempty"`
+	RotatedAt           time.Time `json:"rotatedAt,omitempty"`
+}
+
+func (s Session) recordID() string        { return s.ID }
+func (s Session) recordExpiry() time.Time { return s.ExpiresAt }
+
+// SessionInfo is the public view of a session returned by the sessions endpoints
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	IDP       string    `json:"idp,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	LastUsed  time.Time `json:"lastUsed"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Current   bool      `json:"current"`
+}
+
+// SessionMeta describes the client that opened a session
+type SessionMeta struct {
+	IP        string
+	UserAgent string
+}
+
+// SessionStore persists sessions. Implementations must be safe for concurrent use.
+type SessionStore interface {
+	// Save creates or replaces a session
+	Save(ctx context.Context, session Session) error
+	// Update applies fn to the stored session and saves the result as one atomic change.
+	// It returns ErrSessionNotFound when the session no longer exists, so a session revoked
+	// elsewhere is never written back, and returns fn's error without saving anything.
+	// fn may be called more than once.
+	Update(ctx context.Context, id string, fn func(session *Session) error) error
+	// Get returns a session, or ErrSessionNotFound
+	Get(ctx context.Context, id string) (Session, error)
+	// Delete removes sessions; unknown IDs are ignored
+	Delete(ctx context.Context, ids ...string) error
+	// List returns all sessions that have not expired
+	List(ctx context.Context) ([]Session, error)
+}
+
+// SessionConfig controls token lifetimes and where sessions are stored
+type SessionConfig struct {
+	AccessTokenTTL time.Duration // lifetime of the JWT sent on every request
+	SessionTTL     time.Duration // absolute lifetime of a session (and its refresh token)
+	Store          string        // SessionStoreMemory or SessionStoreSecret
+}
+
+// SessionConfigFromEnv reads the session settings:
+//   - SESSION_ACCESS_TOKEN_TTL: access token lifetime (default 15m)
+//   - SESSION_TTL: session lifetime before a new login is required (default 24h)
+//   - SESSION_STORE: "memory" (default) or "secret"
+func SessionConfigFromEnv() SessionConfig {
+	config := SessionConfig{
+		AccessTokenTTL: defaultAccessTokenTTL,
+		SessionTTL:     defaultSessionTTL,
+		Store:          SessionStoreMemory,
+	}
+
+	if ttl := os.Getenv("SESSION_ACCESS_TOKEN_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil && d > 0 {
+			config.AccessTokenTTL = d
+		} else {
+			utils.LogWarn("Invalid SESSION_ACCESS_TOKEN_TTL, using default", map[string]interface{}{
+				"value":   ttl,
+				"default": defaultAccessTokenTTL.String(),
+			})
+		}
+	}
+	if ttl := os.Getenv("SESSION_TTL"); ttl != "" {
+		if d, err := time.ParseDuration(ttl); err == nil && d > 0 {
+			config.SessionTTL = d
+		} else {
+			utils.LogWarn("Invalid SESSION_TTL, using default", map[string]interface{}{
+				"value":   ttl,
+				"default": defaultSessionTTL.String(),
+			})
+		}
+	}
+	if config.AccessTokenTTL > config.SessionTTL {
+		config.AccessTokenTTL = config.SessionTTL
+	}
+	if store := strings.ToLower(os.Getenv("SESSION_STORE")); store == SessionStoreSecret {
+		config.Store = SessionStoreSecret
+	}
+
+	return config
+}
+
+// newSessionManagerFromEnv builds the session manager configured by the environment.
+// The Secret store falls back to memory when no Kubernetes client or namespace is available.
+func newSessionManagerFromEnv(k8sClient kubernetes.Interface) *SessionManager {
+	config := SessionConfigFromEnv()
+
+	var store SessionStore = NewMemorySessionStore()
+	if config.Store == SessionStoreSecret {
+		if k8sClient == nil {
+			utils.LogWarn("SESSION_STORE=secret requires a Kubernetes client, using in-memory sessions", nil)
+		} else if secretStore, err := NewK8sSessionStore(k8sClient, defaultSessionSecretName); err != nil {
+			utils.LogWarn("Failed to initialize Secret session store, using in-memory sessions", map[string]interface{}{
+				"error": err.Error(),
+			})
+		} else {
+			store = secretStore
+		}
+	}
+
+	return NewSessionManager(store, config)
+}
+
+// SessionManager issues, validates, refreshes and revokes sessions
+type SessionManager struct {
+	store  SessionStore
+	config SessionConfig
+	now    func() time.Time
+}
+
+// NewSessionManager creates a session manager backed by the given store
+func NewSessionManager(store SessionStore, config SessionConfig) *SessionManager {
+	if config.AccessTokenTTL <= 0 {
+		config.AccessTokenTTL = defaultAccessTokenTTL
+	}
+	if config.SessionTTL <= 0 {
+		config.SessionTTL = defaultSessionTTL
+	}
+	return &SessionManager{
+		store:  store,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Store returns the underlying session store
+func (m *SessionManager) Store() SessionStore {
+	return m.store
+}
+
+// AccessTokenExpiry returns when an access token issued now for the session must expire.
+// It never outlives the session itself.
+func (m *SessionManager) AccessTokenExpiry(session *Session) time.Time {
+	expiry := m.now().Add(m.config.AccessTokenTTL)
+	if expiry.After(session.ExpiresAt) {
+		return session.ExpiresAt
+	}
+	return expiry
+}
+
+// Create opens a session for the user and returns it with its refresh token
+func (m *SessionManager) Create(ctx context.Context, claims models.Claims, meta SessionMeta) (*Session, string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := m.now()
+	session := Session{
+		ID:          id,
+		Claims:      claims,
+		IP:          meta.IP,
+		UserAgent:   meta.UserAgent,
+		CreatedAt:   now,
+		LastUsed:    now,
+		ExpiresAt:   now.Add(m.config.SessionTTL),
+		RefreshHash: hashRefreshSecret(secret),
+	}
+	if err := m.store.Save(ctx, session); err != nil {
+		return nil, "", fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return &session, id + "." + secret, nil
+}
+
+// Validate checks that the session behind an access token is still active.
+// It returns ErrSessionRevoked when the session is gone, expired, or belongs to another user.
+func (m *SessionManager) Validate(ctx context.Context, id, username string) (*Session, error) {
+	session, err := m.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	now := m.now()
+	if now.After(session.ExpiresAt) || session.Claims.Username != username {
+		return nil, ErrSessionRevoked
+	}
+
+	if now.Sub(session.LastUsed) >= sessionTouchInterval {
+		err := m.store.Update(ctx, id, func(stored *Session) error {
+			stored.LastUsed = now
+			return nil
+		})
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, ErrSessionRevoked
+		}
+		if err != nil {
+			utils.LogWarn("Failed to update session last use", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+		session.LastUsed = now
+	}
+
+	return &session, nil
+}
+
+// Refresh exchanges a refresh token for an updated session.
+// reauthorize receives the stored claims and returns the claims to use from now on, so that
+// permission changes at the identity provider take effect; an error revokes the session.
+// The refresh token is rotated: the returned token replaces the presented one. Within a short
+// grace period the previous token is still accepted and the returned token is empty, meaning
+// the client should keep the one it already received.
+// Presenting the previous token after the grace period revokes the session, since it indicates
+// the token was stolen. Any other secret is rejected without touching the session, so that
+// knowing a session ID is not enough to log its user out.
+//
+// The token is checked and rotated in one atomic store update, against the stored session
+// rather than a cached copy, so concurrent refreshes with the same token are resolved by the
+// grace period instead of being mistaken for reuse.
+func (m *SessionManager) Refresh(ctx context.Context, refreshToken string, reauthorize func(models.Claims) (models.Claims, error)) (*Session, string, error) {
+	id, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, "", ErrSessionRevoked
+	}
+
+	session, err := m.store.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			return nil, "", ErrSessionRevoked
+		}
+		return nil, "", fmt.Errorf("failed to get session: %w", err)
+	}
+
+	now := m.now()
+	if now.After(session.ExpiresAt) {
+		m.revokeQuietly(ctx, session.ID)
+		return nil, "", ErrSessionRevoked
+	}
+
+	// Check the secret before re-checking the user, so that unauthenticated requests never
+	// reach the identity provider. The cached copy may be stale; the update below decides.
+	presented := hashRefreshSecret(secret)
+	if !hashesEqual(presented, session.RefreshHash) && !hashesEqual(presented, session.PreviousRefreshHash) {
+		return nil, "", ErrSessionRevoked
+	}
+
+	claims := session.Claims
+	if reauthorize != nil {
+		claims, err = reauthorize(session.Claims)
+		if err != nil {
+			utils.LogWarn("Session no longer authorized, revoking", map[string]interface{}{
+				"username": session.Claims.Username,
+				"error":    err.Error(),
+			})
+			m.revokeQuietly(ctx, session.ID)
+			return nil, "", ErrSessionRevoked
+		}
+	}
+
+	newSecret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	newToken := ""
+	err = m.store.Update(ctx, id, func(stored *Session) error {
+		newToken = ""
+		switch {
+		case now.After(stored.ExpiresAt):
+			return ErrSessionNotFound
+		case hashesEqual(presented, stored.RefreshHash):
+			stored.PreviousRefreshHash = stored.RefreshHash
+			stored.RefreshHash = hashRefreshSecret(newSecret)
+			stored.RotatedAt = now
+			newToken = stored.ID + "." + newSecret
+		case hashesEqual(presented, stored.PreviousRefreshHash):
+			if now.Sub(stored.RotatedAt) > refreshReuseGrace {
+				return errRefreshTokenReused
+			}
+			// A concurrent refresh already rotated the token; the client keeps that one
+		default:
+			return errRefreshTokenInvalid
+		}
+		stored.Claims = claims
+		stored.LastUsed = now
+		session = *stored
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		m.revokeQuietly(ctx, id)
+		return nil, "", ErrSessionRevoked
+	case errors.Is(err, errRefreshTokenInvalid):
+		return nil, "", ErrSessionRevoked
+	case errors.Is(err, errRefreshTokenReused):
+		utils.LogWarn("Refresh token reuse detected, revoking session", map[string]interface{}{
+			"username": session.Claims.Username,
+			"session":  session.ID,
+		})
+		m.revokeQuietly(ctx, id)
+		return nil, "", ErrSessionRevoked
+	case err != nil:
+		return nil, "", fmt.Errorf("failed to save session: %w", err)
+	}
+
+	return &session, newToken, nil
+}
+
+// Revoke deletes a single session
+func (m *SessionManager) Revoke(ctx context.Context, id string) error {
+	return m.store.Delete(ctx, id)
+}
+
+// RevokeUser deletes every session of a user and returns how many were removed
+func (m *SessionManager) RevokeUser(ctx context.Context, username string) (int, error) {
+	return m.RevokeIDPUser(ctx, "", username)
+}
+
+// RevokeIDPUser deletes the sessions of a user that signed in through the given identity
+// provider ("core", "ldap" or "oidc"), so that a local user does not log out an LDAP or OIDC
+// user with the same name. An empty idp matches every provider.
+func (m *SessionManager) RevokeIDPUser(ctx context.Context, idp, username string) (int, error) {
+	return m.RevokeOtherIDPSessions(ctx, idp, username, "")
+}
+
+// RevokeOtherIDPSessions works like RevokeIDPUser but keeps the session keepID, so a user
+// changing credentials stays signed in on the current device only.
+func (m *SessionManager) RevokeOtherIDPSessions(ctx context.Context, idp, username, keepID string) (int, error) {
+	sessions, err := m.List(ctx, username)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		if session.ID == keepID {
+			continue
+		}
+		if idp == "" || session.Claims.IDP == idp {
+			ids = append(ids, session.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	if err := m.store.Delete(ctx, ids...); err != nil {
+		return 0, err
+	}
+	return len(ids), nil
+}
+
+// List returns the active sessions of a user (all users when username is empty), newest first
+func (m *SessionManager) List(ctx context.Context, username string) ([]Session, error) {
+	all, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now()
+	sessions := make([]Session, 0, len(all))
+	for _, session := range all {
+		if now.After(session.ExpiresAt) {
+			continue
+		}
+		if username != "" && session.Claims.Username != username {
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (m *SessionManager) revokeQuietly(ctx context.Context, id string) {
+	if err := m.store.Delete(ctx, id); err != nil {
+		utils.LogWarn("Failed to revoke session", map[string]interface{}{
+			"session": id,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// Info returns the public view of the session
+func (s *Session) Info(currentID string) SessionInfo {
+	return SessionInfo{
+		ID:        s.ID,
+		Username:  s.Claims.Username,
+		IDP:       s.Claims.IDP,
+		IP:        s.IP,
+		UserAgent: s.UserAgent,
+		CreatedAt: s.CreatedAt,
+		LastUsed:  s.LastUsed,
+		ExpiresAt: s.ExpiresAt,
+		Current:   s.ID == currentID,
+	}
+}
+
+func hashRefreshSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func hashesEqual(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// refreshCookieName holds the refresh token; it is only sent to the refresh endpoint
+	refreshCookieName = "refresh_token"
+	refreshCookiePath = "/api/auth/refresh"
+)
+
+// RefreshHandler exchanges the refresh cookie for a new access token.
+// The refresh token is rotated on every use; LDAP permissions are re-read from the directory.
+//
+// @Summary Renovar sesión
+// @Description Emite un nuevo access token a partir del refresh token (cookie) y lo rota
+// @Tags auth
+// @Produce json
+// @Success 200 {object} LoginResponse "Sesión renovada"
+// @Failure 401 {object} map[string]string "Sesión expirada o revocada"
+// @Router /api/auth/refresh [post]
+func (s *Service) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	setupMode := s.setupMode
+	authService := s.authService
+	s.mu.RUnlock()
+
+	if setupMode {
+		utils.ErrorResponse(w, http.StatusPreconditionFailed, "Setup required. Please complete the initial setup first.")
+		return
+	}
+	if authService == nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Authentication service not initialized")
+		return
+	}
+
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil || cookie.Value == "" {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Refresh token required")
+		return
+	}
+
+	result, err := authService.RefreshSession(r.Context(), cookie.Value)
+	if err != nil {
+		if errors.Is(err, ErrSessionRevoked) || errors.Is(err, ErrSessionsDisabled) {
+			clearSessionCookies(w)
+			utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		utils.HandleErrorJSON(w, err, "Failed to refresh session", http.StatusInternalServerError, nil)
+		return
+	}
+
+	setSessionCookies(w, result)
+	utils.JSONResponse(w, http.StatusOK, result.Response)
+}
+
+// ListSessionsHandler returns the active sessions of the current user
+//
+// @Summary Mis sesiones activas
+// @Description Lista las sesiones activas del usuario autenticado
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {array} SessionInfo "Sesiones activas"
+// @Failure 401 {object} map[string]string "No autenticado"
+// @Router /api/auth/sessions [get]
+func (s *Service) ListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, sessions, ok := s.sessionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := sessions.List(r.Context(), claims.Username)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to list sessions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, sessionInfos(list, claims.RegisteredClaims.ID))
+}
+
+// RevokeSessionHandler revokes one of the current user's sessions (e.g. a lost device)
+//
+// @Summary Cerrar una de mis sesiones
+// @Description Revoca una sesión del usuario autenticado
+// @Tags auth
+// @Security Bearer
+// @Param id query string true "ID de la sesión"
+// @Produce json
+// @Success 200 {object} map[string]string "Sesión revocada"
+// @Failure 404 {object} map[string]string "Sesión no encontrada"
+// @Router /api/auth/sessions [delete]
+func (s *Service) RevokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	claims, sessions, ok := s.sessionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "id parameter is required")
+		return
+	}
+
+	ctx := r.Context()
+	session, err := sessions.Store().Get(ctx, id)
+	if err != nil || session.Claims.Username != claims.Username {
+		utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	if err := sessions.Revoke(ctx, id); err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to revoke session", http.StatusInternalServerError, nil)
+		return
+	}
+	if id == claims.RegisteredClaims.ID {
+		clearSessionCookies(w)
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Session revoked"})
+}
+
+// AdminListSessionsHandler returns the active sessions of all users, or of one user
+//
+// @Summary Listar sesiones
+// @Description Lista las sesiones activas de todos los usuarios (o de uno con ?username=)
+// @Tags auth
+// @Security Bearer
+// @Param username query string false "Usuario"
+// @Produce json
+// @Success 200 {array} SessionInfo "Sesiones activas"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/sessions [get]
+func (s *Service) AdminListSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, sessions, ok := s.sessionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := sessions.List(r.Context(), r.URL.Query().Get("username"))
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to list sessions", http.StatusInternalServerError, nil)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, sessionInfos(list, claims.RegisteredClaims.ID))
+}
+
+// AdminRevokeSessionsHandler forces logout: it revokes every session of ?username=, or a single ?id=.
+// Revoked access tokens stop working on their next request.
+//
+// @Summary Forzar cierre de sesión
+// @Description Revoca todas las sesiones de un usuario (?username=) o una sesión (?id=)
+// @Tags auth
+// @Security Bearer
+// @Param username query string false "Usuario"
+// @Param id query string false "ID de la sesión"
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Sesiones revocadas"
+// @Failure 400 {object} map[string]string "Falta username o id"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/sessions [delete]
+func (s *Service) AdminRevokeSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	_, sessions, ok := s.sessionRequest(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	username := r.URL.Query().Get("username")
+	id := r.URL.Query().Get("id")
+
+	var revoked int
+	var err error
+	switch {
+	case username != "":
+		revoked, err = sessions.RevokeUser(ctx, username)
+	case id != "":
+		if _, getErr := sessions.Store().Get(ctx, id); getErr != nil {
+			utils.ErrorResponse(w, http.StatusNotFound, "Session not found")
+			return
+		}
+		err = sessions.Revoke(ctx, id)
+		revoked = 1
+	default:
+		utils.ErrorResponse(w, http.StatusBadRequest, "username or id parameter is required")
+		return
+	}
+
+	utils.AuditLog(r, "revoke_sessions", "Session", id, "", err == nil, err, map[string]interface{}{
+		"target_user": username,
+		"revoked":     revoked,
+	})
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to revoke sessions", http.StatusInternalServerError, map[string]interface{}{
+			"target_user": username,
+		})
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"message": "Sessions revoked",
+		"revoked": revoked,
+	})
+}
+
+// sessionRequest returns the caller's claims and the session manager, writing an error response
+// when sessions are unavailable or the request is not authenticated
+func (s *Service) sessionRequest(w http.ResponseWriter, r *http.Request) (*AuthClaims, *SessionManager, bool) {
+	s.mu.RLock()
+	sessions := s.sessions
+	s.mu.RUnlock()
+
+	if sessions == nil {
+		utils.ErrorResponse(w, http.StatusNotImplemented, ErrSessionsDisabled.Error())
+		return nil, nil, false
+	}
+
+	claims, ok := r.Context().Value(userContextKey).(*AuthClaims)
+	if !ok || claims.Username == "" {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, nil, false
+	}
+
+	return claims, sessions, true
+}
+
+func sessionInfos(sessions []Session, currentID string) []SessionInfo {
+	infos := make([]SessionInfo, 0, len(sessions))
+	for i := range sessions {
+		infos = append(infos, sessions[i].Info(currentID))
+	}
+	return infos
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+const testSessionSecret = "secret-key-must-be-32-bytes-long-123"
+
+func newTestSessionService(t *testing.T) *Service {
+	t.Helper()
+	hash, err := HashPassword("password123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	sessions := NewSessionManager(NewMemorySessionStore(), SessionConfig{})
+	authService := NewAuthService(&mockUserRepository{adminUser: "admin", adminPassword: hash}, []byte(testSessionSecret))
+	authService.SetSessionManager(sessions)
+	jwtService := NewJWTService([]byte(testSessionSecret))
+	jwtService.SetSessionManager(sessions)
+
+	return &Service{
+		authService: authService,
+		jwtService:  jwtService,
+		sessions:    sessions,
+	}
+}
+
+// loginForTest performs a password login and returns the token and refresh cookies
+func loginForTest(t *testing.T, s *Service) (*http.Cookie, *http.Cookie) {
+	t.Helper()
+	body, _ := json.Marshal(models.Credentials{Username: "admin", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/login", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	s.LoginHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("LoginHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	token := findCookie(rr, "token")
+	refresh := findCookie(rr, refreshCookieName)
+	if token == nil || refresh == nil {
+		t.Fatalf("login must set token and refresh cookies, got %v", rr.Result().Cookies())
+	}
+	if refresh.Path != refreshCookiePath || !refresh.HttpOnly || refresh.SameSite != http.SameSiteStrictMode {
+		t.Errorf("unexpected refresh cookie attributes: %+v", refresh)
+	}
+	return token, refresh
+}
+
+func callAuthenticated(s *Service, handler http.HandlerFunc, method, target string, token *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.AddCookie(token)
+	rr := httptest.NewRecorder()
+	s.AuthMiddleware(handler)(rr, req)
+	return rr
+}
+
+func TestSessionHandlers_LoginRefreshLogout(t *testing.T) {
+	s := newTestSessionService(t)
+	token, refresh := loginForTest(t, s)
+
+	if rr := callAuthenticated(s, s.MeHandler, http.MethodGet, "/api/me", token); rr.Code != http.StatusOK {
+		t.Fatalf("MeHandler() status = %d", rr.Code)
+	}
+
+	// Refresh issues a new access token and rotates the refresh token
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+	req.AddCookie(refresh)
+	rr := httptest.NewRecorder()
+	s.RefreshHandler(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("RefreshHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	newToken := findCookie(rr, "token")
+	newRefresh := findCookie(rr, refreshCookieName)
+	if newToken == nil || newRefresh == nil || newRefresh.Value == refresh.Value {
+		t.Fatalf("refresh must set a new token and rotate the refresh cookie")
+	}
+
+	// Logout revokes the session: every token of the session stops working
+	if rr := callAuthenticated(s, s.LogoutHandler, http.MethodPost, "/api/logout", newToken); rr.Code != http.StatusOK {
+		t.Fatalf("LogoutHandler() status = %d", rr.Code)
+	}
+	if rr := callAuthenticated(s, s.MeHandler, http.MethodGet, "/api/me", token); rr.Code != http.StatusUnauthorized {
+		t.Errorf("token after logout status = %d, want 401", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+	req.AddCookie(newRefresh)
+	rr = httptest.NewRecorder()
+	s.RefreshHandler(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after logout status = %d, want 401", rr.Code)
+	}
+}
+
+func TestRefreshHandler_MissingCookie(t *testing.T) {
+	s := newTestSessionService(t)
+	rr := httptest.NewRecorder()
+	s.RefreshHandler(rr, httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", rr.Code)
+	}
+}
+
+func TestSessionHandlers_ListAndRevokeOwn(t *testing.T) {
+	s := newTestSessionService(t)
+	first, _ := loginForTest(t, s)
+	second, _ := loginForTest(t, s)
+
+	// Another user's session must not be visible or revocable
+	other, _, err := s.sessions.Create(context.Background(), models.Claims{Username: "bob"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	rr := callAuthenticated(s, s.ListSessionsHandler, http.MethodGet, "/api/auth/sessions", first)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("ListSessionsHandler() status = %d", rr.Code)
+	}
+	var infos []SessionInfo
+	if err := json.NewDecoder(rr.Body).Decode(&infos); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(infos))
+	}
+	current := 0
+	var secondID string
+	for _, info := range infos {
+		if info.Username != "admin" {
+			t.Errorf("unexpected session of %q", info.Username)
+		}
+		if info.Current {
+			current++
+		} else {
+			secondID = info.ID
+		}
+	}
+	if current != 1 {
+		t.Errorf("exactly one session must be marked current, got %d", current)
+	}
+
+	if rr := callAuthenticated(s, s.RevokeSessionHandler, http.MethodDelete, "/api/auth/sessions?id="+other.ID, first); rr.Code != http.StatusNotFound {
+		t.Errorf("revoking another user's session status = %d, want 404", rr.Code)
+	}
+	if rr := callAuthenticated(s, s.RevokeSessionHandler, http.MethodDelete, "/api/auth/sessions?id="+secondID, first); rr.Code != http.StatusOK {
+		t.Fatalf("RevokeSessionHandler() status = %d", rr.Code)
+	}
+	if rr := callAuthenticated(s, s.MeHandler, http.MethodGet, "/api/me", second); rr.Code != http.StatusUnauthorized {
+		t.Errorf("revoked session status = %d, want 401", rr.Code)
+	}
+	if rr := callAuthenticated(s, s.MeHandler, http.MethodGet, "/api/me", first); rr.Code != http.StatusOK {
+		t.Errorf("current session status = %d, want 200", rr.Code)
+	}
+}
+
+func TestAdminRevokeSessionsHandler_ForcesLogout(t *testing.T) {
+	s := newTestSessionService(t)
+	admin, _ := loginForTest(t, s)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.sessions.Create(ctx, models.Claims{Username: "bob", IDP: "ldap"}, SessionMeta{}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	if rr := callAuthenticated(s, s.AdminRevokeSessionsHandler, http.MethodDelete, "/api/admin/sessions", admin); rr.Code != http.StatusBadRequest {
+		t.Errorf("missing parameters status = %d, want 400", rr.Code)
+	}
+
+	rr := callAuthenticated(s, s.AdminRevokeSessionsHandler, http.MethodDelete, "/api/admin/sessions?username=bob", admin)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("AdminRevokeSessionsHandler() status = %d", rr.Code)
+	}
+	var resp map[string]interface{}
+	_ = json.NewDecoder(rr.Body).Decode(&resp)
+	if resp["revoked"] != float64(2) {
+		t.Errorf("revoked = %v, want 2", resp["revoked"])
+	}
+
+	rr = callAuthenticated(s, s.AdminListSessionsHandler, http.MethodGet, "/api/admin/sessions", admin)
+	var infos []SessionInfo
+	_ = json.NewDecoder(rr.Body).Decode(&infos)
+	if len(infos) != 1 || infos[0].Username != "admin" {
+		t.Errorf("remaining sessions = %+v", infos)
+	}
+}
+
+func TestSessionHandlers_Disabled(t *testing.T) {
+	s := &Service{}
+	req := httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil)
+	rr := httptest.NewRecorder()
+	s.ListSessionsHandler(rr, req)
+	if rr.Code != http.StatusNotImplemented {
+		t.Errorf("status = %d, want 501", rr.Code)
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"k8s.io/client-go/kubernetes"
+)
+
+const defaultSessionSecretName = "dkonsole-sessions"
+
+// K8sSessionStore persists sessions in a Kubernetes Secret shared by all replicas,
+// one key per session ID (see secretRecordStore)
+type K8sSessionStore struct {
+	*secretRecordStore[Session]
+}
+
+// NewK8sSessionStore creates a Secret-backed session store in the current namespace
+func NewK8sSessionStore(client kubernetes.Interface, secretName string) (*K8sSessionStore, error) {
+	store, err := newSecretRecordStore[Session](client, secretName, "sessions", ErrSessionNotFound)
+	if err != nil {
+		return nil, err
+	}
+	return &K8sSessionStore{secretRecordStore: store}, nil
+}
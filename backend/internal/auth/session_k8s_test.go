@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestK8sSessionStore(t *testing.T, client *k8sfake.Clientset) *K8sSessionStore {
+	t.Helper()
+	t.Setenv("POD_NAMESPACE", "dkonsole")
+	store, err := NewK8sSessionStore(client, defaultSessionSecretName)
+	if err != nil {
+		t.Fatalf("NewK8sSessionStore() error = %v", err)
+	}
+	return store
+}
+
+func TestK8sSessionStore_SaveGetDelete(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	store := newTestK8sSessionStore(t, client)
+	ctx := context.Background()
+
+	session := Session{
+		ID:        "abc123",
+		Claims:    models.Claims{Username: "alice", Role: "user"},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	if err := store.Save(ctx, session); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets(store.namespace).Get(ctx, defaultSessionSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("sessions secret not created: %v", err)
+	}
+	if _, ok := secret.Data["abc123"]; !ok {
+		t.Errorf("session not stored in secret: %v", secret.Data)
+	}
+
+	got, err := store.Get(ctx, "abc123")
+	if err != nil || got.Claims.Username != "alice" {
+		t.Fatalf("Get() = %+v, %v", got, err)
+	}
+
+	if err := store.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Get(ctx, "abc123"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Get() after delete error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestK8sSessionStore_PrunesExpired(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	store := newTestK8sSessionStore(t, client)
+	ctx := context.Background()
+
+	if err := store.Save(ctx, Session{ID: "old", ExpiresAt: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	store.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if err := store.Save(ctx, Session{ID: "new", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	secret, _ := client.CoreV1().Secrets(store.namespace).Get(ctx, defaultSessionSecretName, metav1.GetOptions{})
+	if _, ok := secret.Data["old"]; ok {
+		t.Error("expired session was not pruned")
+	}
+	if _, ok := secret.Data["new"]; !ok {
+		t.Error("new session missing")
+	}
+}
+
+func TestK8sSessionStore_SharedBetweenReplicas(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	replicaA := newTestK8sSessionStore(t, client)
+	replicaB := newTestK8sSessionStore(t, client)
+	ctx := context.Background()
+
+	if err := replicaA.Save(ctx, Session{ID: "s1", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := replicaB.Get(ctx, "s1"); err != nil {
+		t.Fatalf("replica B should see session: %v", err)
+	}
+
+	// A revocation on replica A is seen by replica B after its cache expires
+	if err := replicaA.Delete(ctx, "s1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := replicaB.Get(ctx, "s1"); err != nil {
+		t.Fatalf("replica B cache should still hold the session: %v", err)
+	}
+	replicaB.now = func() time.Time { return time.Now().Add(secretStoreResyncInterval) }
+	if _, err := replicaB.Get(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("replica B Get() after resync error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestK8sSessionStore_UpdateDoesNotRestoreRevokedSession(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	replicaA := NewSessionManager(newTestK8sSessionStore(t, client), SessionConfig{})
+	replicaB := NewSessionManager(newTestK8sSessionStore(t, client), SessionConfig{})
+	ctx := context.Background()
+
+	session, _, err := replicaA.Create(ctx, models.Claims{Username: "alice"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := replicaB.Validate(ctx, session.ID, "alice"); err != nil {
+		t.Fatalf("Validate() on replica B error = %v", err)
+	}
+
+	// Logout on replica A while replica B still has the session cached and wants to
+	// record its last use
+	if err := replicaA.Revoke(ctx, session.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	replicaB.now = func() time.Time { return time.Now().Add(2 * sessionTouchInterval) }
+	if _, err := replicaB.Validate(ctx, session.ID, "alice"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("Validate() of a revoked session error = %v, want ErrSessionRevoked", err)
+	}
+
+	secret, _ := client.CoreV1().Secrets("dkonsole").Get(ctx, defaultSessionSecretName, metav1.GetOptions{})
+	if _, ok := secret.Data[session.ID]; ok {
+		t.Error("revoked session was written back to the secret")
+	}
+}
+
+func TestK8sSessionStore_ConcurrentRefreshAcrossReplicas(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	replicaA := NewSessionManager(newTestK8sSessionStore(t, client), SessionConfig{})
+	replicaB := NewSessionManager(newTestK8sSessionStore(t, client), SessionConfig{})
+	ctx := context.Background()
+
+	session, token, err := replicaA.Create(ctx, models.Claims{Username: "alice"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := replicaB.Validate(ctx, session.ID, "alice"); err != nil {
+		t.Fatalf("Validate() on replica B error = %v", err)
+	}
+
+	// Two tabs refresh with the same token on different replicas
+	_, rotated, err := replicaA.Refresh(ctx, token, nil)
+	if err != nil || rotated == "" {
+		t.Fatalf("Refresh() on replica A = %q, %v", rotated, err)
+	}
+	if _, again, err := replicaB.Refresh(ctx, token, nil); err != nil || again != "" {
+		t.Fatalf("concurrent Refresh() on replica B = %q, %v; want the grace period to apply", again, err)
+	}
+
+	// Replica B's cache still has the old token, but the rotated one is accepted
+	if _, next, err := replicaB.Refresh(ctx, rotated, nil); err != nil || next == "" {
+		t.Fatalf("Refresh() with the rotated token on replica B = %q, %v", next, err)
+	}
+}
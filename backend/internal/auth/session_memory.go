@@ -0,0 +1,12 @@
+package auth
+
+// MemorySessionStore keeps sessions in process memory.
+// Sessions are lost on restart and are not shared between replicas.
+type MemorySessionStore struct {
+	*memoryRecordStore[Session]
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{memoryRecordStore: newMemoryRecordStore[Session](ErrSessionNotFound)}
+}
@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestSessionManager() (*SessionManager, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore()
+	store.now = func() time.Time { return now }
+	m := NewSessionManager(store, SessionConfig{AccessTokenTTL: 15 * time.Minute, SessionTTL: time.Hour})
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestSessionManager_CreateAndValidate(t *testing.T) {
+	m, now := newTestSessionManager()
+	ctx := context.Background()
+
+	session, refreshToken, err := m.Create(ctx, models.Claims{Username: "alice", Role: "user", IDP: "ldap"}, SessionMeta{IP: "10.0.0.1", UserAgent: "test"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(refreshToken, session.ID+".") {
+		t.Errorf("refresh token %q does not reference session %q", refreshToken, session.ID)
+	}
+	if session.RefreshHash == "" || strings.Contains(refreshToken, session.RefreshHash) {
+		t.Error("refresh token must be stored hashed")
+	}
+	if got := m.AccessTokenExpiry(session); !got.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("AccessTokenExpiry() = %v", got)
+	}
+
+	if _, err := m.Validate(ctx, session.ID, "alice"); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if _, err := m.Validate(ctx, session.ID, "mallory"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("Validate() with another username error = %v, want ErrSessionRevoked", err)
+	}
+	if _, err := m.Validate(ctx, "unknown", "alice"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("Validate() unknown session error = %v, want ErrSessionRevoked", err)
+	}
+
+	*now = now.Add(2 * time.Hour)
+	if _, err := m.Validate(ctx, session.ID, "alice"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("Validate() expired session error = %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestSessionManager_AccessTokenNeverOutlivesSession(t *testing.T) {
+	m, now := newTestSessionManager()
+	session, _, err := m.Create(context.Background(), models.Claims{Username: "alice"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	*now = session.ExpiresAt.Add(-5 * time.Minute)
+	if got := m.AccessTokenExpiry(session); !got.Equal(session.ExpiresAt) {
+		t.Errorf("AccessTokenExpiry() = %v, want session expiry %v", got, session.ExpiresAt)
+	}
+}
+
+func TestSessionManager_RefreshRotates(t *testing.T) {
+	m, now := newTestSessionManager()
+	ctx := context.Background()
+
+	session, first, err := m.Create(ctx, models.Claims{Username: "alice"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	*now = now.Add(10 * time.Minute)
+	refreshed, second, err := m.Refresh(ctx, first, nil)
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if second == "" || second == first {
+		t.Fatalf("Refresh() did not rotate the token: %q", second)
+	}
+	if refreshed.ID != session.ID || !refreshed.ExpiresAt.Equal(session.ExpiresAt) {
+		t.Error("Refresh() must keep the session ID and absolute expiry")
+	}
+
+	// Concurrent refresh with the previous token within the grace period: accepted, not rotated
+	*now = now.Add(10 * time.Second)
+	if _, token, err := m.Refresh(ctx, first, nil); err != nil || token != "" {
+		t.Fatalf("Refresh() within grace = (%q, %v), want (\"\", nil)", token, err)
+	}
+
+	// The current token keeps working
+	third := second
+	if _, token, err := m.Refresh(ctx, second, nil); err != nil {
+		t.Fatalf("Refresh() with current token error = %v", err)
+	} else {
+		third = token
+	}
+
+	// A secret that was never the previous one is rejected without revoking the session:
+	// knowing the session ID is not enough to log the user out
+	*now = now.Add(time.Minute)
+	for _, token := range []string{first, session.ID + ".garbage"} {
+		if _, _, err := m.Refresh(ctx, token, nil); !errors.Is(err, ErrSessionRevoked) {
+			t.Fatalf("Refresh(%q) error = %v, want ErrSessionRevoked", token, err)
+		}
+	}
+	if _, err := m.Validate(ctx, session.ID, "alice"); err != nil {
+		t.Fatalf("session revoked by an invalid refresh token: %v", err)
+	}
+
+	// The previous token after the grace period means it leaked: the session is revoked
+	if _, _, err := m.Refresh(ctx, second, nil); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("Refresh() with reused token error = %v, want ErrSessionRevoked", err)
+	}
+	if _, _, err := m.Refresh(ctx, third, nil); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("session should be revoked after reuse, got %v", err)
+	}
+}
+
+func TestSessionManager_RefreshChecksSecretBeforeReauthorizing(t *testing.T) {
+	m, _ := newTestSessionManager()
+	ctx := context.Background()
+
+	session, _, err := m.Create(ctx, models.Claims{Username: "alice"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	calls := 0
+	_, _, err = m.Refresh(ctx, session.ID+".garbage", func(claims models.Claims) (models.Claims, error) {
+		calls++
+		return claims, nil
+	})
+	if !errors.Is(err, ErrSessionRevoked) || calls != 0 {
+		t.Errorf("Refresh() with a wrong secret = %v after %d reauthorizations, want ErrSessionRevoked and none", err, calls)
+	}
+}
+
+func TestSessionManager_RefreshReauthorizes(t *testing.T) {
+	m, _ := newTestSessionManager()
+	ctx := context.Background()
+
+	_, token, err := m.Create(ctx, models.Claims{Username: "alice", Permissions: map[string]string{"prod": "edit"}}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	session, token, err := m.Refresh(ctx, token, func(claims models.Claims) (models.Claims, error) {
+		claims.Permissions = map[string]string{"prod": "view"}
+		return claims, nil
+	})
+	if err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+	if session.Claims.Permissions["prod"] != "view" {
+		t.Errorf("permissions not updated: %v", session.Claims.Permissions)
+	}
+
+	_, _, err = m.Refresh(ctx, token, func(claims models.Claims) (models.Claims, error) {
+		return claims, errors.New("user removed from group")
+	})
+	if !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("Refresh() error = %v, want ErrSessionRevoked", err)
+	}
+	if _, err := m.Validate(ctx, session.ID, "alice"); !errors.Is(err, ErrSessionRevoked) {
+		t.Error("session should be revoked when reauthorization fails")
+	}
+}
+
+func TestSessionManager_RefreshInvalidToken(t *testing.T) {
+	m, _ := newTestSessionManager()
+	for _, token := range []string{"", "no-dot", ".secret", "id.", "unknown.secret"} {
+		if _, _, err := m.Refresh(context.Background(), token, nil); !errors.Is(err, ErrSessionRevoked) {
+			t.Errorf("Refresh(%q) error = %v, want ErrSessionRevoked", token, err)
+		}
+	}
+}
+
+func TestSessionManager_ListAndRevokeUser(t *testing.T) {
+	m, now := newTestSessionManager()
+	ctx := context.Background()
+
+	for _, user := range []string{"alice", "alice", "bob"} {
+		if _, _, err := m.Create(ctx, models.Claims{Username: user}, SessionMeta{}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		*now = now.Add(time.Second)
+	}
+
+	all, err := m.List(ctx, "")
+	if err != nil || len(all) != 3 {
+		t.Fatalf("List() = %d sessions, %v; want 3", len(all), err)
+	}
+	if !all[0].CreatedAt.After(all[2].CreatedAt) {
+		t.Error("List() should return newest sessions first")
+	}
+
+	revoked, err := m.RevokeUser(ctx, "alice")
+	if err != nil || revoked != 2 {
+		t.Fatalf("RevokeUser() = %d, %v; want 2", revoked, err)
+	}
+	remaining, _ := m.List(ctx, "")
+	if len(remaining) != 1 || remaining[0].Claims.Username != "bob" {
+		t.Errorf("remaining sessions = %+v", remaining)
+	}
+}
+
+func TestSessionManager_RevokeIDPUser(t *testing.T) {
+	m, _ := newTestSessionManager()
+	ctx := context.Background()
+
+	for _, idp := range []string{"core", "ldap", "oidc"} {
+		if _, _, err := m.Create(ctx, models.Claims{Username: "alice", IDP: idp}, SessionMeta{}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// Deleting the local user "alice" must not log out the LDAP or OIDC user "alice"
+	revoked, err := m.RevokeIDPUser(ctx, "core", "alice")
+	if err != nil || revoked != 1 {
+		t.Fatalf("RevokeIDPUser() = %d, %v; want 1", revoked, err)
+	}
+	remaining, _ := m.List(ctx, "alice")
+	if len(remaining) != 2 {
+		t.Fatalf("remaining sessions = %+v", remaining)
+	}
+	for _, session := range remaining {
+		if session.Claims.IDP == "core" {
+			t.Errorf("core session not revoked: %+v", session)
+		}
+	}
+}
+
+func TestSessionConfigFromEnv(t *testing.T) {
+	t.Setenv("SESSION_ACCESS_TOKEN_TTL", "5m")
+	t.Setenv("SESSION_TTL", "8h")
+	t.Setenv("SESSION_STORE", "Secret")
+
+	config := SessionConfigFromEnv()
+	if config.AccessTokenTTL != 5*time.Minute || config.SessionTTL != 8*time.Hour || config.Store != SessionStoreSecret {
+		t.Errorf("SessionConfigFromEnv() = %+v", config)
+	}
+
+	t.Setenv("SESSION_ACCESS_TOKEN_TTL", "invalid")
+	t.Setenv("SESSION_TTL", "")
+	t.Setenv("SESSION_STORE", "")
+	config = SessionConfigFromEnv()
+	if config.AccessTokenTTL != defaultAccessTokenTTL || config.SessionTTL != defaultSessionTTL || config.Store != SessionStoreMemory {
+		t.Errorf("SessionConfigFromEnv() defaults = %+v", config)
+	}
+}
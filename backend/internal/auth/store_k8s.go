@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// secretStoreResyncInterval bounds how stale the cached records can be, i.e. how long a
+// revocation made by another replica can take to be seen by this one
+const secretStoreResyncInterval = 15 * time.Second
+
+// storedRecord is a record kept by the session and API token stores
+type storedRecord interface {
+	recordID() string
+	recordExpiry() time.Time
+}
+
+// secretRecordStore persists records in a Kubernetes Secret, one key per record ID.
+// Records are cached in memory and re-read at most every secretStoreResyncInterval, so all
+// replicas see new records and revocations without hitting the API server on every request.
+// Every write is a read-modify-write of the Secret itself, never of the cache.
+type secretRecordStore[T storedRecord] struct {
+	mu         sync.Mutex
+	client     kubernetes.Interface
+	namespace  string
+	secretName string
+	kind       string // what the Secret holds, for error messages
+	notFound   error
+	cache      map[string]T
+	syncedAt   time.Time
+	now        func() time.Time
+}
+
+func newSecretRecordStore[T storedRecord](client kubernetes.Interface, secretName, kind string, notFound error) (*secretRecordStore[T], error) {
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current namespace: %w", err)
+	}
+
+	return &secretRecordStore[T]{
+		client:     client,
+		namespace:  namespace,
+		secretName: secretName,
+		kind:       kind,
+		notFound:   notFound,
+		now:        time.Now,
+	}, nil
+}
+
+// SetClient replaces the Kubernetes client, e.g. after the service account token changed
+func (s *secretRecordStore[T]) SetClient(client kubernetes.Interface) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.client = client
+}
+
+// Save creates or replaces a record
+func (s *secretRecordStore[T]) Save(ctx context.Context, record T) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s entry: %w", s.kind, err)
+	}
+	return s.mutate(ctx, func(entries map[string][]byte) error {
+		entries[record.recordID()] = data
+		return nil
+	})
+}
+
+// Update applies fn to the record as stored in the Secret, not to the cached copy, and
+// writes it back in the same conflict-checked update
+func (s *secretRecordStore[T]) Update(ctx context.Context, id string, fn func(record *T) error) error {
+	return s.mutate(ctx, func(entries map[string][]byte) error {
+		data, ok := entries[id]
+		if !ok {
+			return s.notFound
+		}
+		var record T
+		if err := json.Unmarshal(data, &record); err != nil {
+			return s.notFound
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+		updated, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s entry: %w", s.kind, err)
+		}
+		entries[id] = updated
+		return nil
+	})
+}
+
+// Get returns a record from the cache
+func (s *secretRecordStore[T]) Get(ctx context.Context, id string) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var zero T
+	if err := s.syncLocked(ctx); err != nil {
+		return zero, err
+	}
+	record, ok := s.cache[id]
+	if !ok {
+		return zero, s.notFound
+	}
+	return record, nil
+}
+
+// Delete removes records; unknown IDs are ignored
+func (s *secretRecordStore[T]) Delete(ctx context.Context, ids ...string) error {
+	return s.mutate(ctx, func(entries map[string][]byte) error {
+		for _, id := range ids {
+			delete(entries, id)
+		}
+		return nil
+	})
+}
+
+// List returns all records that have not expired
+func (s *secretRecordStore[T]) List(ctx context.Context) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.syncLocked(ctx); err != nil {
+		return nil, err
+	}
+	now := s.now()
+	records := make([]T, 0, len(s.cache))
+	for _, record := range s.cache {
+		if !now.After(record.recordExpiry()) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+// syncLocked reloads the cache from the Secret when it is older than secretStoreResyncInterval
+func (s *secretRecordStore[T]) syncLocked(ctx context.Context) error {
+	if s.cache != nil && s.now().Sub(s.syncedAt) < secretStoreResyncInterval {
+		return nil
+	}
+
+	secret, err := s.client.CoreV1().Secrets(s.namespace).Get(ctx, s.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			s.setCacheLocked(nil)
+			return nil
+		}
+		return fmt.Errorf("failed to get %s secret: %w", s.kind, err)
+	}
+	s.setCacheLocked(secret.Data)
+	return nil
+}
+
+// mutate applies fn to the stored records and writes the Secret back, retrying on conflicts.
+// Nothing is written when fn returns an error. Expired records are pruned on every write.
+func (s *secretRecordStore[T]) mutate(ctx context.Context, fn func(entries map[string][]byte) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets := s.client.CoreV1().Secrets(s.namespace)
+	var written map[string][]byte
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, s.secretName, metav1.GetOptions{})
+		if err != nil {
+			if !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to get %s secret: %w", s.kind, err)
+			}
+			entries := make(map[string][]byte)
+			if err := fn(entries); err != nil {
+				return err
+			}
+			s.pruneExpired(entries)
+			newSecret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      s.secretName,
+					Namespace: s.namespace,
+					Labels: map[string]string{
+						"app.kubernetes.io/managed-by": "dkonsole",
+					},
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: entries,
+			}
+			if _, err := secrets.Create(ctx, newSecret, metav1.CreateOptions{}); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					// Another replica created it first; retry as an update
+					return apierrors.NewConflict(corev1.Resource("secrets"), s.secretName, err)
+				}
+				return fmt.Errorf("failed to create %s secret: %w", s.kind, err)
+			}
+			written = entries
+			return nil
+		}
+
+		entries := make(map[string][]byte, len(secret.Data)+1)
+		for key, value := range secret.Data {
+			entries[key] = value
+		}
+		if err := fn(entries); err != nil {
+			return err
+		}
+		s.pruneExpired(entries)
+		secret.Data = entries
+		if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		written = entries
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.setCacheLocked(written)
+	return nil
+}
+
+// pruneExpired drops expired and undecodable entries so the Secret does not grow without bound
+func (s *secretRecordStore[T]) pruneExpired(entries map[string][]byte) {
+	now := s.now()
+	for id, data := range entries {
+		var record T
+		if err := json.Unmarshal(data, &record); err != nil || now.After(record.recordExpiry()) {
+			delete(entries, id)
+		}
+	}
+}
+
+func (s *secretRecordStore[T]) setCacheLocked(entries map[string][]byte) {
+	cache := make(map[string]T, len(entries))
+	for id, data := range entries {
+		var record T
+		if err := json.Unmarshal(data, &record); err != nil {
+			utils.LogWarn("Skipping invalid entry in secret", map[string]interface{}{
+				"secret": s.secretName,
+				"id":     id,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		cache[id] = record
+	}
+	s.cache = cache
+	s.syncedAt = s.now()
+}
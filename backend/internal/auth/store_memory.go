@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryRecordStore keeps records in process memory.
+// Records are lost on restart and are not shared between replicas.
+type memoryRecordStore[T storedRecord] struct {
+	mu       sync.RWMutex
+	records  map[string]T
+	notFound error
+	now      func() time.Time
+}
+
+func newMemoryRecordStore[T storedRecord](notFound error) *memoryRecordStore[T] {
+	return &memoryRecordStore[T]{
+		records:  make(map[string]T),
+		notFound: notFound,
+		now:      time.Now,
+	}
+}
+
+// Save creates or replaces a record, dropping expired ones
+func (s *memoryRecordStore[T]) Save(ctx context.Context, record T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, existing := range s.records {
+		if now.After(existing.recordExpiry()) {
+			delete(s.records, id)
+		}
+	}
+	s.records[record.recordID()] = record
+	return nil
+}
+
+// Update applies fn to a stored record under the store lock
+func (s *memoryRecordStore[T]) Update(ctx context.Context, id string, fn func(record *T) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		return s.notFound
+	}
+	if err := fn(&record); err != nil {
+		return err
+	}
+	s.records[id] = record
+	return nil
+}
+
+// Get returns a record
+func (s *memoryRecordStore[T]) Get(ctx context.Context, id string) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, ok := s.records[id]
+	if !ok {
+		var zero T
+		return zero, s.notFound
+	}
+	return record, nil
+}
+
+// Delete removes records; unknown IDs are ignored
+func (s *memoryRecordStore[T]) Delete(ctx context.Context, ids ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		delete(s.records, id)
+	}
+	return nil
+}
+
+// List returns all records that have not expired
+func (s *memoryRecordStore[T]) List(ctx context.Context) ([]T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := s.now()
+	records := make([]T, 0, len(s.records))
+	for _, record := range s.records {
+		if !now.After(record.recordExpiry()) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
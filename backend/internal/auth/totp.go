@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	totpIssuer     = "DKonsole"
+	totpDigits     = 6
+	totpPeriod     = 30 // seconds
+	totpSkew       = 1  // accepted steps before and after the current one (clock drift)
+	totpSecretSize = 20 // bytes, the RFC 4226 recommended HMAC-SHA1 key size
+
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// TOTPConfig is the TOTP enrollment of a core user, stored in the auth Secret next to the password hash
+type TOTPConfig struct {
+	Secret        string    `json:"secret"`                  // Base32 shared secret
+	Enabled       bool      `json:"enabled"`                 // false until the first code is confirmed
+	RecoveryCodes []string  `json:"recoveryCodes,omitempty"` // SHA-256 hashes of the unused recovery codes
+	LastStep      int64     `json:"lastStep,omitempty"`      // last accepted time step, so a code cannot be replayed
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// TOTPStore persists TOTP enrollments by username
+type TOTPStore interface {
+	// GetTOTP returns the user's enrollment, or nil if the user has none
+	GetTOTP(ctx context.Context, username string) (*TOTPConfig, error)
+	// UpdateTOTP applies fn to the user's enrollment (nil if none) and saves the result atomically.
+	// Returning nil from fn removes the enrollment.
+	UpdateTOTP(ctx context.Context, username string, fn func(current *TOTPConfig) (*TOTPConfig, error)) error
+}
+
+// TOTPStatus describes the two-factor state of the current user
+type TOTPStatus struct {
+	Enabled                bool `json:"enabled"`
+	Pending                bool `json:"pending"` // enrollment started but not confirmed yet
+	RecoveryCodesRemaining int  `json:"recoveryCodesRemaining"`
+}
+
+// TOTPEnrollment holds the provisioning data shown to the user once, usually as a QR code
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// provisioning URI
+}
+
+// newTOTPSecret generates a random Base32 shared secret
+func newTOTPSecret() (string, error) {
+	key := make([]byte, totpSecretSize)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(key), nil
+}
+
+// totpURI builds the otpauth:// provisioning URI understood by authenticator apps
+func totpURI(username, secret string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(totpIssuer + ":" + username)
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpStep returns the RFC 6238 time step of t
+func totpStep(t time.Time) int64 {
+	return t.Unix() / totpPeriod
+}
+
+// totpCode computes the RFC 6238 code of a time step (HMAC-SHA1, dynamic truncation)
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// matchCode returns the time step a code belongs to, accepting the configured clock skew.
+// Steps up to the last accepted one are rejected so a code cannot be used twice.
+func (c *TOTPConfig) matchCode(code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= c.LastStep {
+			continue
+		}
+		expected, err := totpCode(c.Secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// consumeRecoveryCode removes a matching recovery code and reports whether one was found
+func (c *TOTPConfig) consumeRecoveryCode(code string) bool {
+	hash := hashRecoveryCode(code)
+	for i, stored := range c.RecoveryCodes {
+		if hashesEqual(stored, hash) {
+			c.RecoveryCodes = append(c.RecoveryCodes[:i], c.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// newRecoveryCodes generates single-use recovery codes and their hashes
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode hashes a recovery code, ignoring case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashRefreshSecret(normalized)
+}
@@ -0,0 +1,339 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// mfaChallengeTTL is how long the second login step may take
+	mfaChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts is the number of wrong codes accepted per challenge before a new login is required
+	maxMFAAttempts       = 5
+	mfaChallengeAudience = "dkonsole-mfa"
+)
+
+// mfaChallengeClaims is the signed state carried between the password and the code step
+type mfaChallengeClaims struct {
+	User models.Claims `json:"user"`
+	jwt.RegisteredClaims
+}
+
+// mfaAttempts counts failed codes per challenge
+type mfaAttempts struct {
+	mu       sync.Mutex
+	failures map[string]mfaFailure
+}
+
+type mfaFailure struct {
+	count   int
+	expires time.Time
+}
+
+func newMFAAttempts() *mfaAttempts {
+	return &mfaAttempts{failures: make(map[string]mfaFailure)}
+}
+
+// exhausted reports whether the challenge has no attempts left
+func (a *mfaAttempts) exhausted(id string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.failures[id].count >= maxMFAAttempts
+}
+
+// fail records a wrong code; expired entries are pruned on the way
+func (a *mfaAttempts) fail(id string, expires, now time.Time) {
+	a.add(id, 1, expires, now)
+}
+
+// burn uses up a challenge after a successful login
+func (a *mfaAttempts) burn(id string, expires, now time.Time) {
+	a.add(id, maxMFAAttempts, expires, now)
+}
+
+func (a *mfaAttempts) add(id string, n int, expires, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for key, failure := range a.failures {
+		if now.After(failure.expires) {
+			delete(a.failures, key)
+		}
+	}
+	failure := a.failures[id]
+	failure.count += n
+	failure.expires = expires
+	a.failures[id] = failure
+}
+
+// finishLogin issues the tokens of an authenticated user, or a two-factor challenge
+// when the user is a core account with TOTP enabled
+func (s *AuthService) finishLogin(ctx context.Context, claims models.Claims, client SessionMeta) (*LoginResult, error) {
+	if claims.IDP == "core" && s.totp != nil {
+		config, err := s.totp.GetTOTP(ctx, claims.Username)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read two-factor configuration: %w", err)
+		}
+		if config != nil && config.Enabled {
+			challenge, err := s.newMFAChallenge(claims)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create two-factor challenge: %w", err)
+			}
+			utils.LogInfo("Password accepted, two-factor code required", map[string]interface{}{
+				"username": claims.Username,
+			})
+			return &LoginResult{Response: LoginResponse{MFARequired: true, MFAToken: challenge}}, nil
+		}
+	}
+	return s.issueTokens(ctx, claims, client)
+}
+
+// LoginWithTOTP completes a login that requires a second factor.
+// code is either the current TOTP code or one of the user's recovery codes.
+//
+// Returns ErrMFAChallenge if the challenge is invalid, expired or out of attempts,
+// and ErrInvalidMFACode if the code is wrong.
+func (s *AuthService) LoginWithTOTP(ctx context.Context, challenge, code string, client SessionMeta) (*LoginResult, error) {
+	if s.totp == nil {
+		return nil, ErrTOTPUnavailable
+	}
+
+	parsed, err := s.parseMFAChallenge(challenge)
+	if err != nil {
+		return nil, ErrMFAChallenge
+	}
+	id := parsed.RegisteredClaims.ID
+	expires := parsed.RegisteredClaims.ExpiresAt.Time
+	if s.mfa.exhausted(id) {
+		return nil, ErrMFAChallenge
+	}
+
+	usedRecovery, err := s.verifySecondFactor(ctx, parsed.User.Username, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			s.mfa.fail(id, expires, s.now())
+			utils.LogWarn("Invalid two-factor code", map[string]interface{}{
+				"username": parsed.User.Username,
+			})
+		}
+		if errors.Is(err, ErrTOTPNotEnrolled) {
+			// Two-factor was reset while the challenge was pending: start over
+			return nil, ErrMFAChallenge
+		}
+		return nil, err
+	}
+	s.mfa.burn(id, expires, s.now())
+
+	if usedRecovery {
+		utils.LogWarn("Recovery code used to log in", map[string]interface{}{
+			"username": parsed.User.Username,
+		})
+	}
+	return s.issueTokens(ctx, parsed.User, client)
+}
+
+// verifySecondFactor checks a TOTP or recovery code and records its use,
+// so neither can be replayed
+func (s *AuthService) verifySecondFactor(ctx context.Context, username, code string) (usedRecovery bool, err error) {
+	err = s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		if current == nil || !current.Enabled {
+			return nil, ErrTOTPNotEnrolled
+		}
+		if step, ok := current.matchCode(code, s.now()); ok {
+			current.LastStep = step
+			return current, nil
+		}
+		if current.consumeRecoveryCode(code) {
+			usedRecovery = true
+			return current, nil
+		}
+		return nil, ErrInvalidMFACode
+	})
+	return usedRecovery, err
+}
+
+// TOTPStatus returns the two-factor state of a core user
+func (s *AuthService) TOTPStatus(ctx context.Context, username string) (*TOTPStatus, error) {
+	if s.totp == nil {
+		return nil, ErrTOTPUnavailable
+	}
+	config, err := s.totp.GetTOTP(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TOTPStatus{}
+	if config != nil {
+		status.Enabled = config.Enabled
+		status.Pending = !config.Enabled
+		status.RecoveryCodesRemaining = len(config.RecoveryCodes)
+	}
+	return status, nil
+}
+
+// BeginTOTPEnrollment creates a new unconfirmed TOTP secret for a core user,
+// replacing any previous unconfirmed one.
+//
+// Returns ErrTOTPEnabled if two-factor authentication is already active.
+func (s *AuthService) BeginTOTPEnrollment(ctx context.Context, username string) (*TOTPEnrollment, error) {
+	if s.totp == nil {
+		return nil, ErrTOTPUnavailable
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		if current != nil && current.Enabled {
+			return nil, ErrTOTPEnabled
+		}
+		return &TOTPConfig{Secret: secret, CreatedAt: s.now()}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(username, secret)}, nil
+}
+
+// ConfirmTOTPEnrollment activates a pending enrollment with a first valid code
+// and returns the recovery codes, which are only shown this once.
+func (s *AuthService) ConfirmTOTPEnrollment(ctx context.Context, username, code string) ([]string, error) {
+	if s.totp == nil {
+		return nil, ErrTOTPUnavailable
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		if current == nil {
+			return nil, ErrTOTPNotEnrolled
+		}
+		if current.Enabled {
+			return nil, ErrTOTPEnabled
+		}
+		step, ok := current.matchCode(code, s.now())
+		if !ok {
+			return nil, ErrInvalidMFACode
+		}
+		current.Enabled = true
+		current.LastStep = step
+		current.RecoveryCodes = hashes
+		return current, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of a user; it requires a current TOTP code
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, username, code string) ([]string, error) {
+	if s.totp == nil {
+		return nil, ErrTOTPUnavailable
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		if current == nil || !current.Enabled {
+			return nil, ErrTOTPNotEnrolled
+		}
+		step, ok := current.matchCode(code, s.now())
+		if !ok {
+			return nil, ErrInvalidMFACode
+		}
+		current.LastStep = step
+		current.RecoveryCodes = hashes
+		return current, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns off two-factor authentication after checking a TOTP or recovery code
+func (s *AuthService) DisableTOTP(ctx context.Context, username, code string) error {
+	if s.totp == nil {
+		return ErrTOTPUnavailable
+	}
+	return s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		if current == nil || !current.Enabled {
+			return nil, ErrTOTPNotEnrolled
+		}
+		if _, ok := current.matchCode(code, s.now()); !ok && !current.consumeRecoveryCode(code) {
+			return nil, ErrInvalidMFACode
+		}
+		return nil, nil
+	})
+}
+
+// ResetTOTP removes a user's enrollment without a code (admin recovery, deleted users)
+func (s *AuthService) ResetTOTP(ctx context.Context, username string) error {
+	if s.totp == nil {
+		return nil
+	}
+	return s.totp.UpdateTOTP(ctx, username, func(current *TOTPConfig) (*TOTPConfig, error) {
+		return nil, nil
+	})
+}
+
+// newMFAChallenge signs the already authenticated claims for the second login step.
+// It uses a key derived from the JWT secret, so a challenge is never accepted as an access token.
+func (s *AuthService) newMFAChallenge(user models.Claims) (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	now := s.now()
+	claims := &mfaChallengeClaims{
+		User: user,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(mfaChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.mfaKey())
+}
+
+func (s *AuthService) parseMFAChallenge(challenge string) (*mfaChallengeClaims, error) {
+	claims := &mfaChallengeClaims{}
+	_, err := jwt.ParseWithClaims(challenge, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.mfaKey(), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithAudience(mfaChallengeAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.RegisteredClaims.ID == "" || claims.User.Username == "" {
+		return nil, errors.New("incomplete challenge")
+	}
+	return claims, nil
+}
+
+func (s *AuthService) mfaKey() []byte {
+	mac := hmac.New(sha256.New, s.jwtSecret)
+	mac.Write([]byte(mfaChallengeAudience))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// newTestTOTPAuthService returns an auth service for admin/password123 with sessions,
+// a TOTP store and a fixed clock
+func newTestTOTPAuthService(t *testing.T) (*AuthService, *memoryTOTPStore, *time.Time) {
+	t.Helper()
+	hash, err := HashPassword("password123")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+	store := newMemoryTOTPStore()
+	s := NewAuthService(&mockUserRepository{adminUser: "admin", adminPassword: hash}, []byte(testSessionSecret))
+	s.SetSessionManager(NewSessionManager(NewMemorySessionStore(), SessionConfig{}))
+	s.SetTOTPStore(store)
+	s.now = func() time.Time { return now }
+	return s, store, &now
+}
+
+// enrollForTest enables TOTP for a user and returns the secret and recovery codes
+func enrollForTest(t *testing.T, s *AuthService, username string) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	enrollment, err := s.BeginTOTPEnrollment(ctx, username)
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment() error = %v", err)
+	}
+	code, _ := totpCode(enrollment.Secret, totpStep(s.now()))
+	codes, err := s.ConfirmTOTPEnrollment(ctx, username, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTPEnrollment() error = %v", err)
+	}
+	return enrollment.Secret, codes
+}
+
+func TestTOTPEnrollment(t *testing.T) {
+	s, _, now := newTestTOTPAuthService(t)
+	ctx := context.Background()
+
+	enrollment, err := s.BeginTOTPEnrollment(ctx, "admin")
+	if err != nil {
+		t.Fatalf("BeginTOTPEnrollment() error = %v", err)
+	}
+	if status, _ := s.TOTPStatus(ctx, "admin"); status.Enabled || !status.Pending {
+		t.Errorf("status after enroll = %+v, want pending", status)
+	}
+
+	// A pending enrollment does not affect login
+	result, err := s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	if err != nil || result.Response.MFARequired || result.Token == "" {
+		t.Fatalf("Login() with pending enrollment = %+v, %v", result, err)
+	}
+
+	if _, err := s.ConfirmTOTPEnrollment(ctx, "admin", "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("ConfirmTOTPEnrollment() with wrong code error = %v", err)
+	}
+	code, _ := totpCode(enrollment.Secret, totpStep(*now))
+	codes, err := s.ConfirmTOTPEnrollment(ctx, "admin", code)
+	if err != nil || len(codes) != recoveryCodeCount {
+		t.Fatalf("ConfirmTOTPEnrollment() = %v, %v", codes, err)
+	}
+
+	status, _ := s.TOTPStatus(ctx, "admin")
+	if !status.Enabled || status.RecoveryCodesRemaining != recoveryCodeCount {
+		t.Errorf("status after confirm = %+v", status)
+	}
+	if _, err := s.BeginTOTPEnrollment(ctx, "admin"); !errors.Is(err, ErrTOTPEnabled) {
+		t.Errorf("BeginTOTPEnrollment() when enabled error = %v, want ErrTOTPEnabled", err)
+	}
+}
+
+func TestLoginWithTOTP(t *testing.T) {
+	s, _, now := newTestTOTPAuthService(t)
+	ctx := context.Background()
+	secret, recovery := enrollForTest(t, s, "admin")
+	*now = now.Add(time.Minute)
+
+	result, err := s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !result.Response.MFARequired || result.Response.MFAToken == "" || result.Token != "" || result.RefreshToken != "" {
+		t.Fatalf("Login() must return only a challenge, got %+v", result)
+	}
+	challenge := result.Response.MFAToken
+
+	// The challenge is not an access token
+	jwtService := NewJWTService([]byte(testSessionSecret))
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+challenge)
+	if _, err := jwtService.AuthenticateRequest(req); err == nil {
+		t.Fatal("MFA challenge must not be accepted as an access token")
+	}
+
+	if _, err := s.LoginWithTOTP(ctx, challenge, "000000", SessionMeta{}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("LoginWithTOTP() with wrong code error = %v", err)
+	}
+
+	code, _ := totpCode(secret, totpStep(*now))
+	result, err = s.LoginWithTOTP(ctx, challenge, code, SessionMeta{})
+	if err != nil || result.Token == "" || result.RefreshToken == "" || result.Response.Role != "admin" {
+		t.Fatalf("LoginWithTOTP() = %+v, %v", result, err)
+	}
+
+	// The challenge is single use and the code cannot be replayed
+	if _, err := s.LoginWithTOTP(ctx, challenge, code, SessionMeta{}); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("reused challenge error = %v, want ErrMFAChallenge", err)
+	}
+	result, _ = s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	if _, err := s.LoginWithTOTP(ctx, result.Response.MFAToken, code, SessionMeta{}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("replayed code error = %v, want ErrInvalidMFACode", err)
+	}
+
+	// A recovery code works once
+	if _, err := s.LoginWithTOTP(ctx, result.Response.MFAToken, recovery[0], SessionMeta{}); err != nil {
+		t.Fatalf("LoginWithTOTP() with recovery code error = %v", err)
+	}
+	result, _ = s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	if _, err := s.LoginWithTOTP(ctx, result.Response.MFAToken, recovery[0], SessionMeta{}); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("reused recovery code error = %v, want ErrInvalidMFACode", err)
+	}
+	if status, _ := s.TOTPStatus(ctx, "admin"); status.RecoveryCodesRemaining != recoveryCodeCount-1 {
+		t.Errorf("recovery codes remaining = %d", status.RecoveryCodesRemaining)
+	}
+}
+
+func TestLoginWithTOTP_ChallengeLimits(t *testing.T) {
+	s, _, now := newTestTOTPAuthService(t)
+	ctx := context.Background()
+	secret, _ := enrollForTest(t, s, "admin")
+	*now = now.Add(time.Minute)
+
+	result, _ := s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	challenge := result.Response.MFAToken
+	for i := 0; i < maxMFAAttempts; i++ {
+		if _, err := s.LoginWithTOTP(ctx, challenge, "000000", SessionMeta{}); !errors.Is(err, ErrInvalidMFACode) {
+			t.Fatalf("attempt %d error = %v", i, err)
+		}
+	}
+	code, _ := totpCode(secret, totpStep(*now))
+	if _, err := s.LoginWithTOTP(ctx, challenge, code, SessionMeta{}); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("challenge out of attempts error = %v, want ErrMFAChallenge", err)
+	}
+
+	// Expired and forged challenges are rejected
+	result, _ = s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	*now = now.Add(mfaChallengeTTL + time.Second)
+	code, _ = totpCode(secret, totpStep(*now))
+	if _, err := s.LoginWithTOTP(ctx, result.Response.MFAToken, code, SessionMeta{}); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("expired challenge error = %v, want ErrMFAChallenge", err)
+	}
+	token, _ := s.generateToken(models.Claims{Username: "admin", Role: "admin", IDP: "core"}, "id", now.Add(time.Hour))
+	if _, err := s.LoginWithTOTP(ctx, token, code, SessionMeta{}); !errors.Is(err, ErrMFAChallenge) {
+		t.Errorf("access token used as challenge error = %v, want ErrMFAChallenge", err)
+	}
+}
+
+func TestDisableAndResetTOTP(t *testing.T) {
+	s, _, now := newTestTOTPAuthService(t)
+	ctx := context.Background()
+	secret, recovery := enrollForTest(t, s, "admin")
+	*now = now.Add(time.Minute)
+
+	if err := s.DisableTOTP(ctx, "admin", "000000"); !errors.Is(err, ErrInvalidMFACode) {
+		t.Fatalf("DisableTOTP() with wrong code error = %v", err)
+	}
+	code, _ := totpCode(secret, totpStep(*now))
+	newCodes, err := s.RegenerateRecoveryCodes(ctx, "admin", code)
+	if err != nil || len(newCodes) != recoveryCodeCount {
+		t.Fatalf("RegenerateRecoveryCodes() = %v, %v", newCodes, err)
+	}
+	if err := s.DisableTOTP(ctx, "admin", recovery[0]); !errors.Is(err, ErrInvalidMFACode) {
+		t.Errorf("old recovery code should be invalid after regeneration, got %v", err)
+	}
+	if err := s.DisableTOTP(ctx, "admin", newCodes[0]); err != nil {
+		t.Fatalf("DisableTOTP() error = %v", err)
+	}
+	if status, _ := s.TOTPStatus(ctx, "admin"); status.Enabled || status.Pending {
+		t.Errorf("status after disable = %+v", status)
+	}
+
+	enrollForTest(t, s, "admin")
+	if err := s.ResetTOTP(ctx, "admin"); err != nil {
+		t.Fatalf("ResetTOTP() error = %v", err)
+	}
+	result, err := s.Login(ctx, LoginRequest{Username: "admin", Password: "password123", IDP: "core"})
+	if err != nil || result.Response.MFARequired {
+		t.Errorf("Login() after reset = %+v, %v", result, err)
+	}
+}
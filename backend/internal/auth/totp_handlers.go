@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// MFALoginRequest is the second login step of an account with two-factor authentication
+type MFALoginRequest struct {
+	MFAToken string `json:"mfaToken"` // Challenge returned by /api/login
+	Code     string `json:"code"`     // TOTP code or recovery code
+}
+
+// TOTPCodeRequest carries a TOTP (or, where accepted, recovery) code
+type TOTPCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodesResponse returns freshly generated recovery codes; they are not shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// LoginMFAHandler completes a login with a TOTP or recovery code.
+//
+// @Summary Segundo factor de login
+// @Description Completa el login de una cuenta core con TOTP habilitado usando un código TOTP o de recuperación
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body MFALoginRequest true "Desafío y código"
+// @Success 200 {object} LoginResponse "Autenticación exitosa"
+// @Failure 400 {object} map[string]string "Cuerpo de solicitud inválido"
+// @Failure 401 {object} map[string]string "Código o desafío inválido"
+// @Router /api/login/mfa [post]
+func (s *Service) LoginMFAHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	s.mu.RLock()
+	setupMode := s.setupMode
+	authService := s.authService
+	s.mu.RUnlock()
+
+	if setupMode {
+		utils.ErrorResponse(w, http.StatusPreconditionFailed, "Setup required. Please complete the initial setup first.")
+		return
+	}
+	if authService == nil {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Authentication service not initialized")
+		return
+	}
+
+	var req MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "mfaToken and code are required")
+		return
+	}
+
+	result, err := authService.LoginWithTOTP(r.Context(), req.MFAToken, req.Code, clientMeta(r))
+	if err != nil {
+		if errors.Is(err, ErrInvalidMFACode) || errors.Is(err, ErrMFAChallenge) {
+			utils.ErrorResponse(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+		writeTOTPError(w, err, "Two-factor authentication failed")
+		return
+	}
+
+	setSessionCookies(w, result)
+	utils.JSONResponse(w, http.StatusOK, result.Response)
+}
+
+// TOTPStatusHandler returns the two-factor state of the current core user
+//
+// @Summary Estado de 2FA
+// @Description Indica si el usuario core autenticado tiene TOTP habilitado y cuántos códigos de recuperación le quedan
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} TOTPStatus "Estado de 2FA"
+// @Failure 400 {object} map[string]string "Solo disponible para cuentas core"
+// @Router /api/auth/totp [get]
+func (s *Service) TOTPStatusHandler(w http.ResponseWriter, r *http.Request) {
+	claims, authService, ok := s.totpRequest(w, r, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	status, err := authService.TOTPStatus(r.Context(), claims.Username)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to get two-factor status")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, status)
+}
+
+// TOTPEnrollHandler starts a TOTP enrollment and returns the secret and provisioning URI
+//
+// @Summary Iniciar enrolamiento TOTP
+// @Description Genera un secreto TOTP y la URI otpauth:// para el código QR; se activa al confirmar un código
+// @Tags auth
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} TOTPEnrollment "Secreto y URI de aprovisionamiento"
+// @Failure 409 {object} map[string]string "2FA ya habilitado"
+// @Router /api/auth/totp/enroll [post]
+func (s *Service) TOTPEnrollHandler(w http.ResponseWriter, r *http.Request) {
+	claims, authService, ok := s.totpRequest(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	enrollment, err := authService.BeginTOTPEnrollment(r.Context(), claims.Username)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to start two-factor enrollment")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, enrollment)
+}
+
+// TOTPConfirmHandler activates a pending enrollment and returns the recovery codes
+//
+// @Summary Confirmar enrolamiento TOTP
+// @Description Activa 2FA con un primer código válido y devuelve los códigos de recuperación (solo se muestran una vez)
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body TOTPCodeRequest true "Código TOTP"
+// @Success 200 {object} RecoveryCodesResponse "Códigos de recuperación"
+// @Failure 400 {object} map[string]string "Código inválido"
+// @Router /api/auth/totp/confirm [post]
+func (s *Service) TOTPConfirmHandler(w http.ResponseWriter, r *http.Request) {
+	claims, authService, ok := s.totpRequest(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+	code, ok := decodeTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := authService.ConfirmTOTPEnrollment(r.Context(), claims.Username, code)
+	utils.AuditLog(r, "enable_2fa", "User", claims.Username, "", err == nil, err, nil)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to confirm two-factor enrollment")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// TOTPRecoveryCodesHandler replaces the recovery codes of the current user
+//
+// @Summary Regenerar códigos de recuperación
+// @Description Reemplaza los códigos de recuperación; requiere un código TOTP actual
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body TOTPCodeRequest true "Código TOTP"
+// @Success 200 {object} RecoveryCodesResponse "Nuevos códigos de recuperación"
+// @Failure 400 {object} map[string]string "Código inválido"
+// @Router /api/auth/totp/recovery-codes [post]
+func (s *Service) TOTPRecoveryCodesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, authService, ok := s.totpRequest(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+	code, ok := decodeTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := authService.RegenerateRecoveryCodes(r.Context(), claims.Username, code)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to regenerate recovery codes")
+		return
+	}
+	utils.AuditLog(r, "regenerate_recovery_codes", "User", claims.Username, "", true, nil, nil)
+	utils.JSONResponse(w, http.StatusOK, RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// TOTPDisableHandler turns off two-factor authentication for the current user
+//
+// @Summary Deshabilitar 2FA
+// @Description Deshabilita TOTP tras verificar un código TOTP o de recuperación
+// @Tags auth
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body TOTPCodeRequest true "Código TOTP o de recuperación"
+// @Success 200 {object} map[string]string "2FA deshabilitado"
+// @Failure 400 {object} map[string]string "Código inválido"
+// @Router /api/auth/totp/disable [post]
+func (s *Service) TOTPDisableHandler(w http.ResponseWriter, r *http.Request) {
+	claims, authService, ok := s.totpRequest(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+	code, ok := decodeTOTPCode(w, r)
+	if !ok {
+		return
+	}
+
+	err := authService.DisableTOTP(r.Context(), claims.Username, code)
+	utils.AuditLog(r, "disable_2fa", "User", claims.Username, "", err == nil, err, nil)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to disable two-factor authentication")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// AdminResetTOTPHandler removes the TOTP enrollment of a user who lost the device and recovery codes
+//
+// @Summary Restablecer 2FA de un usuario (admin)
+// @Description Elimina el enrolamiento TOTP de un usuario core sin requerir código
+// @Tags auth
+// @Security Bearer
+// @Param username query string true "Usuario"
+// @Produce json
+// @Success 200 {object} map[string]string "2FA restablecido"
+// @Failure 400 {object} map[string]string "Falta username"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/totp [delete]
+func (s *Service) AdminResetTOTPHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "username parameter is required")
+		return
+	}
+
+	err := s.ResetTOTP(r.Context(), username)
+	utils.AuditLog(r, "reset_2fa", "User", username, "", err == nil, err, nil)
+	if err != nil {
+		writeTOTPError(w, err, "Failed to reset two-factor authentication")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Two-factor authentication reset",
+	})
+}
+
+// totpRequest checks the method and resolves the current core user and the auth service
+// for the TOTP endpoints
+func (s *Service) totpRequest(w http.ResponseWriter, r *http.Request, method string) (*AuthClaims, *AuthService, bool) {
+	if r.Method != method {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return nil, nil, false
+	}
+
+	s.mu.RLock()
+	authService := s.authService
+	s.mu.RUnlock()
+
+	if authService == nil || authService.totp == nil {
+		utils.ErrorResponse(w, http.StatusNotImplemented, ErrTOTPUnavailable.Error())
+		return nil, nil, false
+	}
+
+	claims, ok := r.Context().Value(userContextKey).(*AuthClaims)
+	if !ok || claims.Username == "" {
+		utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, nil, false
+	}
+	if claims.IDP != "core" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Two-factor authentication is only available for core accounts")
+		return nil, nil, false
+	}
+
+	return claims, authService, true
+}
+
+func decodeTOTPCode(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var req TOTPCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "code is required")
+		return "", false
+	}
+	return req.Code, true
+}
+
+// writeTOTPError maps two-factor errors to HTTP status codes
+func writeTOTPError(w http.ResponseWriter, err error, userMsg string) {
+	switch {
+	case errors.Is(err, ErrTOTPUnavailable):
+		utils.ErrorResponse(w, http.StatusNotImplemented, err.Error())
+	case errors.Is(err, ErrInvalidMFACode):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrTOTPEnabled):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrTOTPNotEnrolled):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, userMsg, http.StatusInternalServerError, nil)
+	}
+}
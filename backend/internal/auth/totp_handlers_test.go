@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func postJSON(t *testing.T, v interface{}) *bytes.Reader {
+	t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal body: %v", err)
+	}
+	return bytes.NewReader(body)
+}
+
+func callAuthenticatedWithBody(s *Service, handler http.HandlerFunc, method, target string, body *bytes.Reader, token *http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.AddCookie(token)
+	rr := httptest.NewRecorder()
+	s.AuthMiddleware(handler)(rr, req)
+	return rr
+}
+
+func TestTOTPHandlers_EnrollAndLogin(t *testing.T) {
+	s := newTestSessionService(t)
+	s.authService.SetTOTPStore(newMemoryTOTPStore())
+	now := time.Now()
+	s.authService.now = func() time.Time { return now }
+	token, _ := loginForTest(t, s)
+
+	// Enroll: get the secret and URI, then confirm with a code
+	rr := callAuthenticated(s, s.TOTPEnrollHandler, http.MethodPost, "/api/auth/totp/enroll", token)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("TOTPEnrollHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var enrollment TOTPEnrollment
+	_ = json.NewDecoder(rr.Body).Decode(&enrollment)
+	if enrollment.Secret == "" || enrollment.URI == "" {
+		t.Fatalf("unexpected enrollment: %+v", enrollment)
+	}
+
+	rr = callAuthenticatedWithBody(s, s.TOTPConfirmHandler, http.MethodPost, "/api/auth/totp/confirm", postJSON(t, TOTPCodeRequest{Code: "000000"}), token)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("confirm with wrong code status = %d, want 400", rr.Code)
+	}
+	code, _ := totpCode(enrollment.Secret, totpStep(now))
+	rr = callAuthenticatedWithBody(s, s.TOTPConfirmHandler, http.MethodPost, "/api/auth/totp/confirm", postJSON(t, TOTPCodeRequest{Code: code}), token)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("TOTPConfirmHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var recovery RecoveryCodesResponse
+	_ = json.NewDecoder(rr.Body).Decode(&recovery)
+	if len(recovery.RecoveryCodes) != recoveryCodeCount {
+		t.Fatalf("expected %d recovery codes, got %d", recoveryCodeCount, len(recovery.RecoveryCodes))
+	}
+
+	rr = callAuthenticated(s, s.TOTPStatusHandler, http.MethodGet, "/api/auth/totp", token)
+	var status TOTPStatus
+	_ = json.NewDecoder(rr.Body).Decode(&status)
+	if !status.Enabled {
+		t.Errorf("status = %+v, want enabled", status)
+	}
+
+	// The password step now returns a challenge and sets no cookies
+	rr = httptest.NewRecorder()
+	s.LoginHandler(rr, httptest.NewRequest(http.MethodPost, "/api/login", postJSON(t, models.Credentials{Username: "admin", Password: "password123"})))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("LoginHandler() status = %d", rr.Code)
+	}
+	if len(rr.Result().Cookies()) != 0 {
+		t.Errorf("no cookies must be set before the second factor, got %v", rr.Result().Cookies())
+	}
+	var login LoginResponse
+	_ = json.NewDecoder(rr.Body).Decode(&login)
+	if !login.MFARequired || login.MFAToken == "" {
+		t.Fatalf("LoginHandler() response = %+v, want MFA challenge", login)
+	}
+
+	rr = httptest.NewRecorder()
+	s.LoginMFAHandler(rr, httptest.NewRequest(http.MethodPost, "/api/login/mfa", postJSON(t, MFALoginRequest{MFAToken: login.MFAToken, Code: "000000"})))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("LoginMFAHandler() with wrong code status = %d, want 401", rr.Code)
+	}
+
+	now = now.Add(totpPeriod * time.Second)
+	code, _ = totpCode(enrollment.Secret, totpStep(now))
+	rr = httptest.NewRecorder()
+	s.LoginMFAHandler(rr, httptest.NewRequest(http.MethodPost, "/api/login/mfa", postJSON(t, MFALoginRequest{MFAToken: login.MFAToken, Code: code})))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("LoginMFAHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	newToken := findCookie(rr, "token")
+	if newToken == nil || findCookie(rr, refreshCookieName) == nil {
+		t.Fatal("LoginMFAHandler() must set the session cookies")
+	}
+	if rr := callAuthenticated(s, s.MeHandler, http.MethodGet, "/api/me", newToken); rr.Code != http.StatusOK {
+		t.Errorf("MeHandler() after MFA login status = %d", rr.Code)
+	}
+
+	// Disabling requires a valid code
+	rr = callAuthenticatedWithBody(s, s.TOTPDisableHandler, http.MethodPost, "/api/auth/totp/disable", postJSON(t, TOTPCodeRequest{Code: recovery.RecoveryCodes[0]}), newToken)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("TOTPDisableHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTOTPHandlers_Errors(t *testing.T) {
+	s := newTestSessionService(t)
+	token, _ := loginForTest(t, s)
+
+	// No TOTP store: two-factor is unavailable
+	if rr := callAuthenticated(s, s.TOTPStatusHandler, http.MethodGet, "/api/auth/totp", token); rr.Code != http.StatusNotImplemented {
+		t.Errorf("status without store = %d, want 501", rr.Code)
+	}
+
+	s.authService.SetTOTPStore(newMemoryTOTPStore())
+	if rr := callAuthenticated(s, s.TOTPEnrollHandler, http.MethodGet, "/api/auth/totp/enroll", token); rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET enroll status = %d, want 405", rr.Code)
+	}
+	if rr := callAuthenticated(s, s.TOTPConfirmHandler, http.MethodPost, "/api/auth/totp/confirm", token); rr.Code != http.StatusBadRequest {
+		t.Errorf("confirm without code status = %d, want 400", rr.Code)
+	}
+	rr := callAuthenticatedWithBody(s, s.TOTPDisableHandler, http.MethodPost, "/api/auth/totp/disable", postJSON(t, TOTPCodeRequest{Code: "123456"}), token)
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("disable when not enrolled status = %d, want 404", rr.Code)
+	}
+
+	// Only core accounts can enroll
+	ldapToken, _, err := s.sessions.Create(t.Context(), models.Claims{Username: "bob", IDP: "ldap"}, SessionMeta{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	jwt, err := s.authService.generateToken(ldapToken.Claims, ldapToken.ID, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("generateToken() error = %v", err)
+	}
+	if rr := callAuthenticated(s, s.TOTPEnrollHandler, http.MethodPost, "/api/auth/totp/enroll", &http.Cookie{Name: "token", Value: jwt}); rr.Code != http.StatusBadRequest {
+		t.Errorf("LDAP user enroll status = %d, want 400", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	s.LoginMFAHandler(rr, httptest.NewRequest(http.MethodPost, "/api/login/mfa", postJSON(t, MFALoginRequest{MFAToken: "invalid", Code: "123456"})))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("invalid challenge status = %d, want 401", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	s.AdminResetTOTPHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/admin/totp", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("reset without username status = %d, want 400", rr.Code)
+	}
+}
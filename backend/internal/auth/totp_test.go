@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"encoding/base32"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryTOTPStore is an in-memory TOTPStore for tests
+type memoryTOTPStore struct {
+	mu      sync.Mutex
+	configs map[string]*TOTPConfig
+}
+
+func newMemoryTOTPStore() *memoryTOTPStore {
+	return &memoryTOTPStore{configs: make(map[string]*TOTPConfig)}
+}
+
+func (m *memoryTOTPStore) GetTOTP(ctx context.Context, username string) (*TOTPConfig, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if config, ok := m.configs[username]; ok {
+		copied := *config
+		copied.RecoveryCodes = append([]string(nil), config.RecoveryCodes...)
+		return &copied, nil
+	}
+	return nil, nil
+}
+
+func (m *memoryTOTPStore) UpdateTOTP(ctx context.Context, username string, fn func(current *TOTPConfig) (*TOTPConfig, error)) error {
+	current, _ := m.GetTOTP(ctx, username)
+	updated, err := fn(current)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if updated == nil {
+		delete(m.configs, username)
+	} else {
+		m.configs[username] = updated
+	}
+	return nil
+}
+
+// RFC 6238 appendix B test secret for HMAC-SHA1
+var rfcTestSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	// The RFC lists 8-digit codes; 6-digit codes are their last six digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := totpCode(rfcTestSecret, totpStep(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("totpCode() error = %v", err)
+		}
+		if got != tt.code {
+			t.Errorf("totpCode(t=%d) = %s, want %s", tt.unix, got, tt.code)
+		}
+	}
+
+	if _, err := totpCode("not base32!", 1); err == nil {
+		t.Error("expected error for an invalid secret")
+	}
+}
+
+func TestTOTPConfig_MatchCodeSkewAndReplay(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	config := &TOTPConfig{Secret: rfcTestSecret}
+
+	previous, _ := totpCode(rfcTestSecret, totpStep(now)-1)
+	step, ok := config.matchCode(previous, now)
+	if !ok || step != totpStep(now)-1 {
+		t.Fatalf("code of the previous step should be accepted, got step=%d ok=%v", step, ok)
+	}
+
+	tooOld, _ := totpCode(rfcTestSecret, totpStep(now)-2)
+	if _, ok := config.matchCode(tooOld, now); ok {
+		t.Error("code outside the skew window should be rejected")
+	}
+
+	// Once a step was used, it and older steps are rejected
+	config.LastStep = step
+	if _, ok := config.matchCode(previous, now); ok {
+		t.Error("replayed code should be rejected")
+	}
+	current, _ := totpCode(rfcTestSecret, totpStep(now))
+	if _, ok := config.matchCode(" "+current+" ", now); !ok {
+		t.Error("current code should be accepted")
+	}
+	for _, code := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := config.matchCode(code, now); ok {
+			t.Errorf("matchCode(%q) should fail", code)
+		}
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatalf("newRecoveryCodes() error = %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d/%d", recoveryCodeCount, len(codes), len(hashes))
+	}
+	for i, code := range codes {
+		if strings.Contains(hashes[i], code) {
+			t.Fatal("recovery codes must be stored hashed")
+		}
+	}
+
+	config := &TOTPConfig{RecoveryCodes: hashes}
+	// Case, spaces and dashes are ignored
+	if !config.consumeRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))) {
+		t.Fatal("recovery code should be accepted")
+	}
+	if config.consumeRecoveryCode(codes[3]) {
+		t.Error("recovery code should only be usable once")
+	}
+	if len(config.RecoveryCodes) != recoveryCodeCount-1 {
+		t.Errorf("remaining codes = %d", len(config.RecoveryCodes))
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := newTOTPSecret()
+	if err != nil {
+		t.Fatalf("newTOTPSecret() error = %v", err)
+	}
+	if key, err := totpEncoding.DecodeString(secret); err != nil || len(key) != totpSecretSize {
+		t.Fatalf("secret %q is not %d bytes of base32: %v", secret, totpSecretSize, err)
+	}
+
+	uri, err := url.Parse(totpURI("alice@example.com", secret))
+	if err != nil {
+		t.Fatalf("invalid URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/DKonsole:alice@example.com" {
+		t.Errorf("unexpected URI: %s", uri)
+	}
+	query := uri.Query()
+	if query.Get("secret") != secret || query.Get("issuer") != "DKonsole" || query.Get("digits") != "6" || query.Get("period") != "30" {
+		t.Errorf("unexpected URI parameters: %v", query)
+	}
+}
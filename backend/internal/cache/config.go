@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	defaultIdleTimeout = 10 * time.Minute
+	defaultSyncTimeout = 30 * time.Second
+)
+
+// Config controls the shared informer cache
+type Config struct {
+	Enabled     bool
+	Kinds       []string      // kinds served from the cache; empty means every supported kind
+	IdleTimeout time.Duration // informers unused for this long are stopped
+	SyncTimeout time.Duration // how long a first request waits for an informer to fill
+}
+
+// ConfigFromEnv reads the cache settings from the environment:
+// RESOURCE_CACHE_ENABLED ("true" to enable), RESOURCE_CACHE_KINDS (comma-separated kinds),
+// RESOURCE_CACHE_IDLE_TIMEOUT and RESOURCE_CACHE_SYNC_TIMEOUT (Go durations).
+func ConfigFromEnv() Config {
+	var kinds []string
+	for _, kind := range strings.Split(os.Getenv("RESOURCE_CACHE_KINDS"), ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+	return Config{
+		Enabled:     strings.EqualFold(strings.TrimSpace(os.Getenv("RESOURCE_CACHE_ENABLED")), "true"),
+		Kinds:       kinds,
+		IdleTimeout: envDuration("RESOURCE_CACHE_IDLE_TIMEOUT", defaultIdleTimeout),
+		SyncTimeout: envDuration("RESOURCE_CACHE_SYNC_TIMEOUT", defaultSyncTimeout),
+	}
+}
+
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		utils.LogWarn("Invalid cache setting, using default", map[string]interface{}{
+			"name":    name,
+			"value":   value,
+			"default": fallback.String(),
+		})
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,39 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("RESOURCE_CACHE_ENABLED", "TRUE")
+	t.Setenv("RESOURCE_CACHE_KINDS", "Pod, Deployment,,")
+	t.Setenv("RESOURCE_CACHE_IDLE_TIMEOUT", "5m")
+	t.Setenv("RESOURCE_CACHE_SYNC_TIMEOUT", "nonsense")
+
+	cfg := ConfigFromEnv()
+	if !cfg.Enabled {
+		t.Error("cache not enabled")
+	}
+	if len(cfg.Kinds) != 2 || cfg.Kinds[0] != "Pod" || cfg.Kinds[1] != "Deployment" {
+		t.Errorf("kinds = %q", cfg.Kinds)
+	}
+	if cfg.IdleTimeout != 5*time.Minute {
+		t.Errorf("idle timeout = %s", cfg.IdleTimeout)
+	}
+	if cfg.SyncTimeout != defaultSyncTimeout {
+		t.Errorf("invalid sync timeout = %s, want default", cfg.SyncTimeout)
+	}
+}
+
+func TestConfigFromEnv_Defaults(t *testing.T) {
+	t.Setenv("RESOURCE_CACHE_ENABLED", "")
+	t.Setenv("RESOURCE_CACHE_KINDS", "")
+	t.Setenv("RESOURCE_CACHE_IDLE_TIMEOUT", "")
+	t.Setenv("RESOURCE_CACHE_SYNC_TIMEOUT", "")
+
+	cfg := ConfigFromEnv()
+	if cfg.Enabled || cfg.Kinds != nil || cfg.IdleTimeout != defaultIdleTimeout || cfg.SyncTimeout != defaultSyncTimeout {
+		t.Errorf("defaults = %+v", cfg)
+	}
+}
@@ -0,0 +1,302 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	toolscache "k8s.io/client-go/tools/cache"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const syncPollInterval = 50 * time.Millisecond
+
+var (
+	// ErrClusterNotFound is returned for clusters without a registered client
+	ErrClusterNotFound = errors.New("cluster not found")
+	// ErrNotSynced is returned when an informer could not fill its cache in time
+	ErrNotSynced = errors.New("resource cache not synced")
+)
+
+// ClientSource returns the shared (service account) dynamic client of a cluster
+type ClientSource func(cluster string) (dynamic.Interface, bool)
+
+type informerKey struct {
+	cluster string
+	gvr     schema.GroupVersionResource
+}
+
+// informerEntry is a running informer for one resource type on one cluster
+type informerEntry struct {
+	key      informerKey
+	client   dynamic.Interface // the informer is restarted when the cluster's client changes
+	informer toolscache.SharedIndexInformer
+	stop     chan struct{}
+	started  time.Time
+	lastUsed time.Time
+	watchers map[*cacheWatcher]struct{}
+
+	errMu   sync.Mutex
+	lastErr error // last list/watch failure reported by the reflector
+}
+
+func (e *informerEntry) setError(err error) {
+	e.errMu.Lock()
+	defer e.errMu.Unlock()
+	e.lastErr = err
+}
+
+func (e *informerEntry) err() error {
+	e.errMu.Lock()
+	defer e.errMu.Unlock()
+	return e.lastErr
+}
+
+// Manager keeps shared informers per cluster and resource type so list and watch requests are
+// served from memory. Informers start on first use and stop after Config.IdleTimeout without use.
+type Manager struct {
+	cfg     Config
+	clients ClientSource
+	now     func() time.Time
+
+	mu      sync.Mutex
+	entries map[informerKey]*informerEntry
+}
+
+// NewManager creates a cache manager; clients resolves the dynamic client of each cluster
+func NewManager(cfg Config, clients ClientSource) *Manager {
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = defaultIdleTimeout
+	}
+	if cfg.SyncTimeout <= 0 {
+		cfg.SyncTimeout = defaultSyncTimeout
+	}
+	return &Manager{
+		cfg:     cfg,
+		clients: clients,
+		now:     time.Now,
+		entries: make(map[informerKey]*informerEntry),
+	}
+}
+
+// Enabled reports whether list and watch requests should use the cache
+func (m *Manager) Enabled() bool {
+	return m.cfg.Enabled
+}
+
+// CachesKind reports whether requests for kind should use the cache
+func (m *Manager) CachesKind(kind string) bool {
+	if !m.cfg.Enabled {
+		return false
+	}
+	if len(m.cfg.Kinds) == 0 {
+		return true
+	}
+	for _, k := range m.cfg.Kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the cached objects of a resource, restricted to a namespace ("" for all) and an
+// optional label selector, sorted by namespace and name, along with the resourceVersion the cache is at.
+// The first call for a resource starts its informer and waits for it to sync.
+// Returned objects are shared with the cache and must not be modified.
+func (m *Manager) List(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, string, error) {
+	entry, err := m.acquire(cluster, gvr)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := m.waitForSync(ctx, entry); err != nil {
+		return nil, "", err
+	}
+
+	var items []interface{}
+	if namespace == "" {
+		items = entry.informer.GetStore().List()
+	} else {
+		items, err = entry.informer.GetIndexer().ByIndex(toolscache.NamespaceIndex, namespace)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	objects := make([]*unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+		if selector != nil && !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].GetNamespace() != objects[j].GetNamespace() {
+			return objects[i].GetNamespace() < objects[j].GetNamespace()
+		}
+		return objects[i].GetName() < objects[j].GetName()
+	})
+
+	return objects, entry.informer.LastSyncResourceVersion(), nil
+}
+
+// acquire returns the informer for a resource, starting it if needed, and marks it as used
+func (m *Manager) acquire(cluster string, gvr schema.GroupVersionResource) (*informerEntry, error) {
+	client, ok := m.clients(cluster)
+	if !ok || client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, cluster)
+	}
+
+	key := informerKey{cluster: cluster, gvr: gvr}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if ok && entry.client != client {
+		// The cluster was re-registered with new credentials
+		m.stopLocked(entry, "client changed")
+		ok = false
+	}
+	if !ok {
+		entry = m.startLocked(key, client)
+	}
+	entry.lastUsed = m.now()
+	return entry, nil
+}
+
+func (m *Manager) startLocked(key informerKey, client dynamic.Interface) *informerEntry {
+	informer := dynamicinformer.NewFilteredDynamicInformer(client, key.gvr, metav1.NamespaceAll, 0,
+		toolscache.Indexers{toolscache.NamespaceIndex: toolscache.MetaNamespaceIndexFunc}, nil).Informer()
+
+	entry := &informerEntry{
+		key:      key,
+		client:   client,
+		informer: informer,
+		stop:     make(chan struct{}),
+		started:  m.now(),
+		watchers: make(map[*cacheWatcher]struct{}),
+	}
+	_ = informer.SetWatchErrorHandlerWithContext(func(ctx context.Context, r *toolscache.Reflector, err error) {
+		entry.setError(err)
+		toolscache.DefaultWatchErrorHandler(ctx, r, err)
+	})
+	m.entries[key] = entry
+	go informer.Run(entry.stop)
+
+	utils.LogInfo("Started resource informer", map[string]interface{}{
+		"cluster":  key.cluster,
+		"resource": key.gvr.String(),
+	})
+	return entry
+}
+
+// stopLocked stops an informer and closes its watches; m.mu must be held
+func (m *Manager) stopLocked(entry *informerEntry, reason string) {
+	if m.entries[entry.key] == entry {
+		delete(m.entries, entry.key)
+	}
+	select {
+	case <-entry.stop:
+		return
+	default:
+		close(entry.stop)
+	}
+	for w := range entry.watchers {
+		w.close()
+	}
+	entry.watchers = nil
+
+	utils.LogInfo("Stopped resource informer", map[string]interface{}{
+		"cluster":  entry.key.cluster,
+		"resource": entry.key.gvr.String(),
+		"reason":   reason,
+	})
+}
+
+// waitForSync waits until the informer has listed its resource. An informer that fails to
+// list (e.g. forbidden) is stopped so a later request can retry, and the caller falls back.
+func (m *Manager) waitForSync(ctx context.Context, entry *informerEntry) error {
+	if entry.informer.HasSynced() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.SyncTimeout)
+	defer cancel()
+	ticker := time.NewTicker(syncPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if entry.informer.HasSynced() {
+			return nil
+		}
+		if err := entry.err(); err != nil {
+			m.mu.Lock()
+			m.stopLocked(entry, "sync failed")
+			m.mu.Unlock()
+			return fmt.Errorf("%w: %v", ErrNotSynced, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w: %s on cluster %s", ErrNotSynced, entry.key.gvr.String(), entry.key.cluster)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run stops idle informers until ctx is done, then stops all of them
+func (m *Manager) Run(ctx context.Context) {
+	interval := m.cfg.IdleTimeout / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.mu.Lock()
+			for _, entry := range m.entries {
+				m.stopLocked(entry, "shutdown")
+			}
+			m.mu.Unlock()
+			return
+		case <-ticker.C:
+			m.pruneIdle()
+		}
+	}
+}
+
+// pruneIdle stops informers nobody watches or listed within the idle timeout,
+// and informers of clusters that were removed or re-registered
+func (m *Manager) pruneIdle() {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, entry := range m.entries {
+		if client, ok := m.clients(entry.key.cluster); !ok || client != entry.client {
+			m.stopLocked(entry, "cluster changed")
+			continue
+		}
+		if len(entry.watchers) == 0 && now.Sub(entry.lastUsed) >= m.cfg.IdleTimeout {
+			m.stopLocked(entry, "idle")
+		}
+	}
+}
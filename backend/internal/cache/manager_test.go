@@ -0,0 +1,218 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+func testPod(name, namespace string, podLabels map[string]string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+	}}
+	pod.SetName(name)
+	pod.SetNamespace(namespace)
+	pod.SetLabels(podLabels)
+	return pod
+}
+
+func newFakeClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{podsGVR: "PodList"}, objects...)
+}
+
+// testClients is a ClientSource whose clusters can be changed by a test
+type testClients struct {
+	mu      sync.Mutex
+	clients map[string]dynamic.Interface
+}
+
+func (c *testClients) get(cluster string) (dynamic.Interface, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	client, ok := c.clients[cluster]
+	return client, ok
+}
+
+func (c *testClients) set(cluster string, client dynamic.Interface) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client == nil {
+		delete(c.clients, cluster)
+		return
+	}
+	c.clients[cluster] = client
+}
+
+func newTestManager(t *testing.T, client dynamic.Interface) (*Manager, *testClients) {
+	t.Helper()
+	clients := &testClients{clients: map[string]dynamic.Interface{"default": client}}
+	m := NewManager(Config{Enabled: true, IdleTimeout: time.Minute, SyncTimeout: 5 * time.Second}, clients.get)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return m, clients
+}
+
+func names(objects []*unstructured.Unstructured) []string {
+	result := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		result = append(result, obj.GetNamespace()+"/"+obj.GetName())
+	}
+	return result
+}
+
+func TestManager_ListFiltersNamespaceAndSelector(t *testing.T) {
+	client := newFakeClient(
+		testPod("web", "shop", map[string]string{"app": "web"}),
+		testPod("worker", "shop", map[string]string{"app": "worker"}),
+		testPod("api", "payments", map[string]string{"app": "web"}),
+	)
+	m, _ := newTestManager(t, client)
+	ctx := context.Background()
+
+	all, _, err := m.List(ctx, "default", podsGVR, "", nil)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := names(all); len(got) != 3 || got[0] != "payments/api" || got[2] != "shop/worker" {
+		t.Errorf("all pods = %v, want sorted by namespace and name", got)
+	}
+
+	shop, _, err := m.List(ctx, "default", podsGVR, "shop", labels.SelectorFromSet(labels.Set{"app": "web"}))
+	if err != nil {
+		t.Fatalf("List(shop) error = %v", err)
+	}
+	if got := names(shop); len(got) != 1 || got[0] != "shop/web" {
+		t.Errorf("shop pods = %v", got)
+	}
+
+	// Both lists share one informer
+	if stats := m.Stats(); len(stats.Informers) != 1 || stats.Objects != 3 || !stats.Informers[0].Synced {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestManager_ListUnknownCluster(t *testing.T) {
+	m, _ := newTestManager(t, newFakeClient())
+
+	if _, _, err := m.List(context.Background(), "missing", podsGVR, "", nil); !errors.Is(err, ErrClusterNotFound) {
+		t.Errorf("List() error = %v, want ErrClusterNotFound", err)
+	}
+}
+
+func TestManager_ListFailsWhenForbidden(t *testing.T) {
+	client := newFakeClient()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(podsGVR.GroupResource(), "", errors.New("no access"))
+	})
+	m, _ := newTestManager(t, client)
+
+	if _, _, err := m.List(context.Background(), "default", podsGVR, "", nil); !errors.Is(err, ErrNotSynced) {
+		t.Fatalf("List() error = %v, want ErrNotSynced", err)
+	}
+	// The failed informer is dropped so a later request can retry
+	if stats := m.Stats(); len(stats.Informers) != 0 {
+		t.Errorf("informers after failure = %+v", stats.Informers)
+	}
+}
+
+func TestManager_PruneIdle(t *testing.T) {
+	m, clients := newTestManager(t, newFakeClient(testPod("web", "shop", nil)))
+	now := time.Now()
+	m.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if _, _, err := m.List(ctx, "default", podsGVR, "", nil); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	w, err := m.Watch(ctx, "default", podsGVR, "")
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	// Informers with watchers stay up
+	now = now.Add(2 * time.Minute)
+	m.pruneIdle()
+	if len(m.Stats().Informers) != 1 {
+		t.Fatal("informer with a watcher was pruned")
+	}
+
+	w.Stop()
+	now = now.Add(30 * time.Second)
+	m.pruneIdle()
+	if len(m.Stats().Informers) != 1 {
+		t.Fatal("informer pruned before the idle timeout")
+	}
+	now = now.Add(time.Minute)
+	m.pruneIdle()
+	if len(m.Stats().Informers) != 0 {
+		t.Fatal("idle informer was not pruned")
+	}
+
+	// Informers of removed clusters are stopped even while in use
+	if _, _, err := m.List(ctx, "default", podsGVR, "", nil); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	clients.set("default", nil)
+	m.pruneIdle()
+	if len(m.Stats().Informers) != 0 {
+		t.Error("informer of a removed cluster was not stopped")
+	}
+}
+
+func TestManager_RestartsWhenClientChanges(t *testing.T) {
+	m, clients := newTestManager(t, newFakeClient(testPod("old", "shop", nil)))
+	ctx := context.Background()
+
+	if _, _, err := m.List(ctx, "default", podsGVR, "", nil); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	clients.set("default", newFakeClient(testPod("new", "shop", nil)))
+
+	objects, _, err := m.List(ctx, "default", podsGVR, "", nil)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := names(objects); len(got) != 1 || got[0] != "shop/new" {
+		t.Errorf("pods after client change = %v", got)
+	}
+}
+
+func TestManager_CachesKind(t *testing.T) {
+	all := NewManager(Config{Enabled: true}, nil)
+	if !all.CachesKind("Pod") {
+		t.Error("enabled cache without kinds should cache every kind")
+	}
+
+	some := NewManager(Config{Enabled: true, Kinds: []string{"pod", "Deployment"}}, nil)
+	if !some.CachesKind("Pod") || !some.CachesKind("Deployment") || some.CachesKind("Secret") {
+		t.Error("CachesKind does not follow Config.Kinds")
+	}
+
+	if NewManager(Config{Kinds: []string{"Pod"}}, nil).CachesKind("Pod") {
+		t.Error("disabled cache should not cache any kind")
+	}
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// InformerStats describes one running informer
+type InformerStats struct {
+	Cluster         string    `json:"cluster"`
+	Resource        string    `json:"resource"` // group/version, Resource=name
+	Synced          bool      `json:"synced"`
+	Objects         int       `json:"objects"`
+	ApproxBytes     int64     `json:"approxBytes"`
+	Watchers        int       `json:"watchers"`
+	ResourceVersion string    `json:"resourceVersion,omitempty"`
+	StartedAt       time.Time `json:"startedAt"`
+	LastUsed        time.Time `json:"lastUsed"`
+}
+
+// Stats summarizes the cache and its memory use
+type Stats struct {
+	Enabled     bool            `json:"enabled"`
+	Kinds       []string        `json:"kinds,omitempty"`
+	IdleTimeout string          `json:"idleTimeout"`
+	Informers   []InformerStats `json:"informers"`
+	Objects     int             `json:"objects"`
+	ApproxBytes int64           `json:"approxBytes"`    // estimated size of the cached objects
+	HeapBytes   uint64          `json:"heapAllocBytes"` // process heap, for comparison
+}
+
+// Stats returns per-informer object counts and an estimate of the memory they hold
+func (m *Manager) Stats() Stats {
+	m.mu.Lock()
+	entries := make([]*informerEntry, 0, len(m.entries))
+	informers := make([]InformerStats, 0, len(m.entries))
+	for _, entry := range m.entries {
+		entries = append(entries, entry)
+		informers = append(informers, InformerStats{
+			Cluster:   entry.key.cluster,
+			Resource:  entry.key.gvr.String(),
+			Watchers:  len(entry.watchers),
+			StartedAt: entry.started,
+			LastUsed:  entry.lastUsed,
+		})
+	}
+	m.mu.Unlock()
+
+	stats := Stats{
+		Enabled:     m.cfg.Enabled,
+		Kinds:       m.cfg.Kinds,
+		IdleTimeout: m.cfg.IdleTimeout.String(),
+		Informers:   informers,
+	}
+	// Walk the stores outside the lock, the informers keep running meanwhile
+	for i, entry := range entries {
+		info := &stats.Informers[i]
+		info.Synced = entry.informer.HasSynced()
+		info.ResourceVersion = entry.informer.LastSyncResourceVersion()
+		for _, item := range entry.informer.GetStore().List() {
+			info.Objects++
+			if obj, ok := item.(*unstructured.Unstructured); ok {
+				info.ApproxBytes += approxSize(obj.Object)
+			}
+		}
+		stats.Objects += info.Objects
+		stats.ApproxBytes += info.ApproxBytes
+	}
+	sort.Slice(stats.Informers, func(i, j int) bool {
+		if stats.Informers[i].Cluster != stats.Informers[j].Cluster {
+			return stats.Informers[i].Cluster < stats.Informers[j].Cluster
+		}
+		return stats.Informers[i].Resource < stats.Informers[j].Resource
+	})
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats.HeapBytes = mem.HeapAlloc
+	return stats
+}
+
+// approxSize estimates the memory held by an unstructured value: string bytes plus
+// rough per-value overhead for headers, map entries and interface boxing
+func approxSize(value interface{}) int64 {
+	const overhead = 16
+	switch v := value.(type) {
+	case map[string]interface{}:
+		size := int64(48)
+		for key, item := range v {
+			size += int64(len(key)) + overhead + approxSize(item)
+		}
+		return size
+	case []interface{}:
+		size := int64(24)
+		for _, item := range v {
+			size += approxSize(item)
+		}
+		return size
+	case string:
+		return int64(len(v)) + overhead
+	default:
+		return overhead
+	}
+}
+
+// StatsHandler returns the cache statistics
+//
+// @Summary Estado de la caché de recursos
+// @Description Informers activos por cluster y recurso, cantidad de objetos y memoria estimada
+// @Tags cache
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} cache.Stats "Estadísticas de la caché"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/cache [get]
+func (m *Manager) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, m.Stats())
+}
@@ -0,0 +1,44 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApproxSize(t *testing.T) {
+	small := approxSize(testPod("a", "ns", nil).Object)
+	large := approxSize(testPod("a-much-longer-pod-name", "ns", map[string]string{"app": "web", "tier": "frontend"}).Object)
+	if small <= 0 || large <= small {
+		t.Errorf("approxSize small = %d, large = %d", small, large)
+	}
+}
+
+func TestStatsHandler(t *testing.T) {
+	m, _ := newTestManager(t, newFakeClient(testPod("web", "shop", nil)))
+	if _, _, err := m.List(context.Background(), "default", podsGVR, "", nil); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	m.StatsHandler(rec, httptest.NewRequest(http.MethodGet, "/api/admin/cache", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var stats Stats
+	if err := json.NewDecoder(rec.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if !stats.Enabled || len(stats.Informers) != 1 || stats.Informers[0].Resource != podsGVR.String() ||
+		stats.Objects != 1 || stats.ApproxBytes <= 0 || stats.HeapBytes == 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	rec = httptest.NewRecorder()
+	m.StatsHandler(rec, httptest.NewRequest(http.MethodPost, "/api/admin/cache", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d", rec.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// watchBufferSize bounds the events queued for a slow client; on overflow the watch is closed
+// and the client resumes from a fresh list
+const watchBufferSize = 256
+
+// Watch returns a watch fed by the shared informer of a resource. It first delivers an ADDED event
+// for every cached object, then the changes. Events are limited to namespace unless it is empty.
+// The result channel is closed when the consumer falls too far behind or the informer stops.
+func (m *Manager) Watch(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string) (watch.Interface, error) {
+	entry, err := m.acquire(cluster, gvr)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.waitForSync(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	// Room for the replay of every cached object on top of the regular buffer
+	w := &cacheWatcher{
+		namespace: namespace,
+		result:    make(chan watch.Event, len(entry.informer.GetStore().ListKeys())+watchBufferSize),
+	}
+
+	m.mu.Lock()
+	if entry.watchers == nil {
+		// Stopped while we waited for it to sync
+		m.mu.Unlock()
+		return nil, ErrNotSynced
+	}
+	entry.watchers[w] = struct{}{}
+	m.mu.Unlock()
+
+	registration, err := entry.informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			w.send(watch.Added, obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			w.send(watch.Modified, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			w.send(watch.Deleted, obj)
+		},
+	})
+	if err != nil {
+		m.release(entry, w)
+		return nil, err
+	}
+
+	w.stopFn = func() {
+		_ = entry.informer.RemoveEventHandler(registration)
+		m.release(entry, w)
+	}
+	return w, nil
+}
+
+// release forgets a watcher and counts the end of the watch as a use of the informer
+func (m *Manager) release(entry *informerEntry, w *cacheWatcher) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(entry.watchers, w)
+	entry.lastUsed = m.now()
+}
+
+// cacheWatcher implements watch.Interface on top of an informer event handler
+type cacheWatcher struct {
+	namespace string
+	result    chan watch.Event
+	stopFn    func()
+
+	mu       sync.Mutex
+	closed   bool
+	stopOnce sync.Once
+}
+
+// ResultChan implements watch.Interface
+func (w *cacheWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface
+func (w *cacheWatcher) Stop() {
+	w.close()
+	w.stopOnce.Do(func() {
+		if w.stopFn != nil {
+			w.stopFn()
+		}
+	})
+}
+
+func (w *cacheWatcher) send(eventType watch.EventType, obj interface{}) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok || (w.namespace != "" && u.GetNamespace() != w.namespace) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.result <- watch.Event{Type: eventType, Object: u}:
+	default:
+		// The client cannot keep up; closing makes it resync instead of silently missing events
+		w.closed = true
+		close(w.result)
+	}
+}
+
+func (w *cacheWatcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		w.closed = true
+		close(w.result)
+	}
+}
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func nextEvent(t *testing.T, w watch.Interface) watch.Event {
+	t.Helper()
+	select {
+	case event, ok := <-w.ResultChan():
+		if !ok {
+			t.Fatal("watch closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a watch event")
+	}
+	return watch.Event{}
+}
+
+func eventName(event watch.Event) string {
+	return event.Object.(*unstructured.Unstructured).GetName()
+}
+
+func TestManager_WatchReplaysAndStreams(t *testing.T) {
+	client := newFakeClient(testPod("web", "shop", nil), testPod("api", "payments", nil))
+	m, _ := newTestManager(t, client)
+	ctx := context.Background()
+
+	w, err := m.Watch(ctx, "default", podsGVR, "shop")
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	defer w.Stop()
+
+	if event := nextEvent(t, w); event.Type != watch.Added || eventName(event) != "web" {
+		t.Fatalf("replayed event = %s %s", event.Type, eventName(event))
+	}
+
+	pods := client.Resource(podsGVR)
+	if _, err := pods.Namespace("payments").Create(ctx, testPod("hidden", "payments", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pods.Namespace("shop").Create(ctx, testPod("worker", "shop", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pods.Namespace("shop").Delete(ctx, "web", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Events of other namespaces are skipped
+	if event := nextEvent(t, w); event.Type != watch.Added || eventName(event) != "worker" {
+		t.Errorf("event = %s %s, want ADDED worker", event.Type, eventName(event))
+	}
+	if event := nextEvent(t, w); event.Type != watch.Deleted || eventName(event) != "web" {
+		t.Errorf("event = %s %s, want DELETED web", event.Type, eventName(event))
+	}
+
+	if stats := m.Stats(); len(stats.Informers) != 1 || stats.Informers[0].Watchers != 1 {
+		t.Errorf("stats = %+v", stats.Informers)
+	}
+	w.Stop()
+	if stats := m.Stats(); stats.Informers[0].Watchers != 0 {
+		t.Errorf("watchers after Stop = %d", stats.Informers[0].Watchers)
+	}
+}
+
+func TestCacheWatcher_ClosesOnOverflow(t *testing.T) {
+	w := &cacheWatcher{result: make(chan watch.Event, 1)}
+
+	w.send(watch.Added, testPod("first", "shop", nil))
+	w.send(watch.Added, testPod("second", "shop", nil))
+	w.send(watch.Added, testPod("third", "shop", nil))
+
+	if event := <-w.ResultChan(); eventName(event) != "first" {
+		t.Errorf("first event = %s", eventName(event))
+	}
+	// A consumer that cannot keep up sees the watch end instead of missing events
+	if _, ok := <-w.ResultChan(); ok {
+		t.Error("watch still open after overflow")
+	}
+	w.Stop()
+}
+
+func TestManager_StopClosesWatches(t *testing.T) {
+	m, _ := newTestManager(t, newFakeClient())
+
+	w, err := m.Watch(context.Background(), "default", podsGVR, "")
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	m.mu.Lock()
+	for _, entry := range m.entries {
+		m.stopLocked(entry, "test")
+	}
+	m.mu.Unlock()
+
+	select {
+	case _, ok := <-w.ResultChan():
+		if ok {
+			t.Error("unexpected event after stop")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch not closed when its informer stopped")
+	}
+	w.Stop()
+}
@@ -3,6 +3,7 @@ package cluster
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
@@ -14,21 +15,46 @@ import (
 
 // Service provides cluster management operations
 type Service struct {
-	handlers *models.Handlers
+	handlers      *models.Handlers
+	repo          Repository
+	clientBuilder ClientBuilder
+
+	// registry state for clusters added through the admin API
+	mu         sync.RWMutex
+	registered map[string]models.ClusterConfig
+	loadErrors map[string]string
+
+	// per-user clients used when Kubernetes impersonation is enabled
+	userMu        sync.Mutex
+	impersonation ImpersonationConfig
+	userClients   map[string]*userClientEntry
 }
 
 // NewService creates a new cluster service
 func NewService(h *models.Handlers) *Service {
-	return &Service{handlers: h}
+	return &Service{
+		handlers:      h,
+		clientBuilder: NewClients,
+		registered:    make(map[string]models.ClusterConfig),
+		loadErrors:    make(map[string]string),
+		userClients:   make(map[string]*userClientEntry),
+	}
 }
 
-// GetClient returns the Kubernetes client for the specified cluster
+// GetClient returns the Kubernetes client for the specified cluster.
+// With impersonation enabled, the client acts as the logged-in user.
 func (s *Service) GetClient(r *http.Request) (kubernetes.Interface, error) {
 	cluster := r.URL.Query().Get("cluster")
 	if cluster == "" {
 		cluster = "default"
 	}
 
+	if clients, err := s.userClientsFor(r, cluster); err != nil {
+		return nil, err
+	} else if clients != nil {
+		return clients.Client, nil
+	}
+
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
 
@@ -39,13 +65,20 @@ func (s *Service) GetClient(r *http.Request) (kubernetes.Interface, error) {
 	return client, nil
 }
 
-// GetDynamicClient returns the dynamic client for the specified cluster
+// GetDynamicClient returns the dynamic client for the specified cluster.
+// With impersonation enabled, the client acts as the logged-in user.
 func (s *Service) GetDynamicClient(r *http.Request) (dynamic.Interface, error) {
 	cluster := r.URL.Query().Get("cluster")
 	if cluster == "" {
 		cluster = "default"
 	}
 
+	if clients, err := s.userClientsFor(r, cluster); err != nil {
+		return nil, err
+	} else if clients != nil {
+		return clients.Dynamic, nil
+	}
+
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
 
@@ -56,13 +89,20 @@ func (s *Service) GetDynamicClient(r *http.Request) (dynamic.Interface, error) {
 	return client, nil
 }
 
-// GetMetricsClient returns the metrics client for the specified cluster
+// GetMetricsClient returns the metrics client for the specified cluster.
+// With impersonation enabled, the client acts as the logged-in user.
 func (s *Service) GetMetricsClient(r *http.Request) *metricsv.Clientset {
 	cluster := r.URL.Query().Get("cluster")
 	if cluster == "" {
 		cluster = "default"
 	}
 
+	if clients, err := s.userClientsFor(r, cluster); err != nil {
+		return nil
+	} else if clients != nil {
+		return clients.Metrics
+	}
+
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
 
@@ -73,13 +113,20 @@ func (s *Service) GetMetricsClient(r *http.Request) *metricsv.Clientset {
 	return client
 }
 
-// GetRESTConfig returns the REST config for the specified cluster
+// GetRESTConfig returns the REST config for the specified cluster.
+// With impersonation enabled, the config carries the logged-in user's impersonation headers.
 func (s *Service) GetRESTConfig(r *http.Request) (*rest.Config, error) {
 	cluster := r.URL.Query().Get("cluster")
 	if cluster == "" {
 		cluster = "default"
 	}
 
+	if clients, err := s.userClientsFor(r, cluster); err != nil {
+		return nil, err
+	} else if clients != nil {
+		return clients.Config, nil
+	}
+
 	s.handlers.RLock()
 	defer s.handlers.RUnlock()
 
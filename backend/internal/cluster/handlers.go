@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// maxClusterRequestSize limits the size of cluster registration payloads (kubeconfigs included)
+const maxClusterRequestSize = 1 << 20 // 1MB
+
+// RegisterClusterRequest represents a request to register or test a cluster.
+// Either Kubeconfig (optionally with Context) or Host+Token(+CAData) must be provided.
+type RegisterClusterRequest struct {
+	models.ClusterConfig
+	SkipTest bool `json:"skipTest,omitempty"`
+}
+
+// ListClustersHandler returns the known clusters without credentials.
+// Non-admin users only see the clusters they have at least one permission in.
+// @Summary Listar clusters
+// @Description Devuelve los clusters registrados (sin credenciales) a los que el usuario tiene acceso
+// @Tags clusters
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.ClusterInfo
+// @Router /api/clusters [get]
+func (s *Service) ListClustersHandler(w http.ResponseWriter, r *http.Request) {
+	clusters := s.ListClusters()
+
+	visible := make([]models.ClusterInfo, 0, len(clusters))
+	for _, info := range clusters {
+		hasAccess, err := permissions.HasClusterAccess(r.Context(), info.Name)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		if hasAccess {
+			visible = append(visible, info)
+		}
+	}
+
+	utils.JSONResponse(w, http.StatusOK, visible)
+}
+
+// RegisterClusterHandler registers a new cluster from JSON (host+token+CA or kubeconfig)
+// or from a multipart kubeconfig upload (form fields: kubeconfig file, name, context, skipTest)
+// @Summary Registrar cluster
+// @Description Registra un cluster mediante kubeconfig o host+token+CA y lo guarda en un Secret
+// @Tags clusters
+// @Accept json
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param request body RegisterClusterRequest true "Configuración del cluster"
+// @Success 201 {object} models.ClusterInfo
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/clusters [post]
+func (s *Service) RegisterClusterHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := parseClusterRequest(w, r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	info, err := s.RegisterCluster(r.Context(), &req.ClusterConfig, req.SkipTest)
+	if err != nil {
+		utils.AuditLog(r, "register", "Cluster", req.Name, "", false, err, nil)
+		writeClusterError(w, err, "Failed to register cluster", req.Name)
+		return
+	}
+
+	utils.AuditLog(r, "register", "Cluster", req.Name, "", true, nil, map[string]interface{}{
+		"host": info.Host,
+	})
+	utils.JSONResponse(w, http.StatusCreated, info)
+}
+
+// TestClusterHandler checks connectivity for a submitted configuration,
+// or for an already registered cluster when ?name= is provided
+// @Summary Probar conexión a cluster
+// @Description Verifica la conectividad con un cluster (registrado o por configuración enviada)
+// @Tags clusters
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param name query string false "Nombre de un cluster ya registrado"
+// @Param request body RegisterClusterRequest false "Configuración del cluster"
+// @Success 200 {object} TestResult
+// @Failure 400 {object} map[string]string
+// @Failure 502 {object} map[string]string
+// @Router /api/clusters/test [post]
+func (s *Service) TestClusterHandler(w http.ResponseWriter, r *http.Request) {
+	if name := r.URL.Query().Get("name"); name != "" {
+		result, err := s.TestRegisteredCluster(name)
+		if err != nil {
+			writeClusterError(w, err, "Cluster connection test failed", name)
+			return
+		}
+		utils.JSONResponse(w, http.StatusOK, result)
+		return
+	}
+
+	req, err := parseClusterRequest(w, r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := s.TestCluster(&req.ClusterConfig)
+	if err != nil {
+		writeClusterError(w, err, "Cluster connection test failed", req.Name)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, result)
+}
+
+// DeleteClusterHandler removes a registered cluster
+// @Summary Eliminar cluster
+// @Description Elimina un cluster del registro y descarta sus clientes
+// @Tags clusters
+// @Produce json
+// @Security Bearer
+// @Param name query string true "Nombre del cluster"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/clusters [delete]
+func (s *Service) DeleteClusterHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	if err := s.RemoveCluster(r.Context(), name); err != nil {
+		utils.AuditLog(r, "delete", "Cluster", name, "", false, err, nil)
+		writeClusterError(w, err, "Failed to remove cluster", name)
+		return
+	}
+
+	utils.AuditLog(r, "delete", "Cluster", name, "", true, nil, nil)
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "Cluster removed successfully",
+	})
+}
+
+// parseClusterRequest decodes a cluster request from JSON or from a multipart kubeconfig upload
+func parseClusterRequest(w http.ResponseWriter, r *http.Request) (*RegisterClusterRequest, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxClusterRequestSize)
+
+	var req RegisterClusterRequest
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(maxClusterRequestSize); err != nil {
+			return nil, errors.New("error parsing form")
+		}
+		file, _, err := r.FormFile("kubeconfig")
+		if err != nil {
+			return nil, errors.New("kubeconfig file is required")
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return nil, errors.New("error reading kubeconfig file")
+		}
+		req.Name = r.FormValue("name")
+		req.Context = r.FormValue("context")
+		req.Kubeconfig = string(data)
+		req.SkipTest, _ = strconv.ParseBool(r.FormValue("skipTest"))
+		return &req, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	return &req, nil
+}
+
+// writeClusterError maps registry errors to HTTP status codes
+func writeClusterError(w http.ResponseWriter, err error, userMsg, name string) {
+	fields := map[string]interface{}{"cluster": name}
+	switch {
+	case errors.Is(err, ErrInvalidClusterConfig), errors.Is(err, ErrReservedClusterName):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrClusterExists):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrClusterNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrRegistryUnavailable):
+		utils.HandleErrorJSON(w, err, userMsg, http.StatusServiceUnavailable, fields)
+	case errors.Is(err, ErrClusterUnreachable):
+		utils.ErrorResponse(w, http.StatusBadGateway, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, userMsg, http.StatusInternalServerError, fields)
+	}
+}
@@ -0,0 +1,214 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestRegistryService(serverErr error) (*Service, *memoryRepository) {
+	var built []*rest.Config
+	repo := newMemoryRepository()
+	service := NewService(newEmptyHandlers())
+	service.SetRepository(repo)
+	service.clientBuilder = fakeBuilder(&built, serverErr)
+	return service, repo
+}
+
+func requestAs(method, target string, claims *models.Claims) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(context.WithValue(req.Context(), auth.UserContextKey(), claims))
+}
+
+func TestRegisterClusterHandler_JSON(t *testing.T) {
+	service, repo := newTestRegistryService(nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":  "staging",
+		"host":  "https://staging:6443",
+		"token": "tok",
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	service.RegisterClusterHandler(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var info models.ClusterInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if info.Name != "staging" || info.Host != "https://staging:6443" {
+		t.Fatalf("unexpected response: %+v", info)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte("tok")) {
+		t.Fatalf("response must not contain the token")
+	}
+	if _, ok := repo.clusters["staging"]; !ok {
+		t.Fatalf("expected cluster to be saved")
+	}
+}
+
+func TestRegisterClusterHandler_KubeconfigUpload(t *testing.T) {
+	service, repo := newTestRegistryService(nil)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	_ = writer.WriteField("name", "uploaded")
+	_ = writer.WriteField("context", "other")
+	part, _ := writer.CreateFormFile("kubeconfig", "config")
+	_, _ = part.Write([]byte(testKubeconfig))
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/clusters", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+
+	service.RegisterClusterHandler(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	saved := repo.clusters["uploaded"]
+	if saved.Context != "other" || saved.Kubeconfig == "" {
+		t.Fatalf("expected kubeconfig and context to be saved, got %+v", saved)
+	}
+}
+
+func TestRegisterClusterHandler_StatusCodes(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		serverErr error
+		want      int
+	}{
+		{name: "invalid json", body: "{", want: http.StatusBadRequest},
+		{name: "reserved", body: `{"name":"default","host":"https://x","token":"t"}`, want: http.StatusBadRequest},
+		{name: "missing credentials", body: `{"name":"c1"}`, want: http.StatusBadRequest},
+		{name: "unreachable", body: `{"name":"c1","host":"https://x","token":"t"}`, serverErr: errors.New("refused"), want: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _ := newTestRegistryService(tt.serverErr)
+			req := httptest.NewRequest(http.MethodPost, "/api/clusters", bytes.NewBufferString(tt.body))
+			rr := httptest.NewRecorder()
+
+			service.RegisterClusterHandler(rr, req)
+
+			if rr.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestListAndDeleteClusterHandlers(t *testing.T) {
+	service, _ := newTestRegistryService(nil)
+	config := models.ClusterConfig{Name: "east", Host: "https://east", Token: "t"}
+	if _, err := service.RegisterCluster(context.Background(), &config, false); err != nil {
+		t.Fatalf("RegisterCluster returned error: %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	service.ListClustersHandler(rr, requestAs(http.MethodGet, "/api/clusters", &models.Claims{Username: "admin", Role: "admin"}))
+	var infos []models.ClusterInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("invalid list response: %v", err)
+	}
+	if len(infos) != 2 || infos[0].Name != "default" || infos[1].Name != "east" {
+		t.Fatalf("unexpected cluster list: %+v", infos)
+	}
+
+	rr = httptest.NewRecorder()
+	service.DeleteClusterHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/clusters", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without name, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	service.DeleteClusterHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/clusters?name=default", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for default cluster, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	service.DeleteClusterHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/clusters?name=east", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	service.DeleteClusterHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/clusters?name=east", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for removed cluster, got %d", rr.Code)
+	}
+}
+
+func TestListClustersHandler_FiltersByClusterPermissions(t *testing.T) {
+	service, _ := newTestRegistryService(nil)
+	for _, name := range []string{"east", "west"} {
+		config := models.ClusterConfig{Name: name, Host: "https://" + name, Token: "t"}
+		if _, err := service.RegisterCluster(context.Background(), &config, false); err != nil {
+			t.Fatalf("RegisterCluster returned error: %v", err)
+		}
+	}
+
+	user := &models.Claims{Username: "dev", Role: "user", Permissions: map[string]string{"west/payments": "edit"}}
+	rr := httptest.NewRecorder()
+	service.ListClustersHandler(rr, requestAs(http.MethodGet, "/api/clusters", user))
+
+	var infos []models.ClusterInfo
+	if err := json.Unmarshal(rr.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("invalid list response: %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "west" {
+		t.Fatalf("expected only west to be visible, got %+v", infos)
+	}
+
+	rr = httptest.NewRecorder()
+	service.ListClustersHandler(rr, httptest.NewRequest(http.MethodGet, "/api/clusters", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without user, got %d", rr.Code)
+	}
+}
+
+func TestTestClusterHandler(t *testing.T) {
+	service, _ := newTestRegistryService(nil)
+
+	rr := httptest.NewRecorder()
+	service.TestClusterHandler(rr, httptest.NewRequest(http.MethodPost, "/api/clusters/test?name=default", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for default cluster, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	service.TestClusterHandler(rr, httptest.NewRequest(http.MethodPost, "/api/clusters/test?name=missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing cluster, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	body := bytes.NewBufferString(`{"host":"https://new","token":"t"}`)
+	service.TestClusterHandler(rr, httptest.NewRequest(http.MethodPost, "/api/clusters/test", body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for submitted config, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result TestResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil || result.Version != "v1.30.1" {
+		t.Fatalf("unexpected test result: %+v (err=%v)", result, err)
+	}
+}
@@ -0,0 +1,178 @@
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// defaultImpersonationCacheTTL is how long per-user clients are reused before being rebuilt
+const defaultImpersonationCacheTTL = 10 * time.Minute
+
+// ErrImpersonationNoUser is returned when impersonation is enabled but the request has no authenticated user
+var ErrImpersonationNoUser = errors.New("impersonation requires an authenticated user")
+
+// ImpersonationConfig controls whether Kubernetes requests run as the logged-in user.
+// When enabled, users from external identity providers are impersonated (Impersonate-User /
+// Impersonate-Group) so cluster RBAC decides what they can do. Core accounts keep using
+// the service account token, since they have no identity in the cluster.
+type ImpersonationConfig struct {
+	Enabled     bool
+	UserPrefix  string        // Prepended to the username, e.g. "ldap:"
+	GroupPrefix string        // Prepended to every group name
+	CacheTTL    time.Duration // Lifetime of cached per-user clients
+}
+
+// ImpersonationConfigFromEnv reads the impersonation settings from the environment:
+// K8S_IMPERSONATION, K8S_IMPERSONATION_USER_PREFIX, K8S_IMPERSONATION_GROUP_PREFIX
+// and K8S_IMPERSONATION_CACHE_TTL (a Go duration).
+func ImpersonationConfigFromEnv() ImpersonationConfig {
+	cfg := ImpersonationConfig{
+		Enabled:     strings.EqualFold(strings.TrimSpace(os.Getenv("K8S_IMPERSONATION")), "true"),
+		UserPrefix:  os.Getenv("K8S_IMPERSONATION_USER_PREFIX"),
+		GroupPrefix: os.Getenv("K8S_IMPERSONATION_GROUP_PREFIX"),
+		CacheTTL:    defaultImpersonationCacheTTL,
+	}
+
+	if ttlStr := os.Getenv("K8S_IMPERSONATION_CACHE_TTL"); ttlStr != "" {
+		if ttl, err := time.ParseDuration(ttlStr); err == nil && ttl > 0 {
+			cfg.CacheTTL = ttl
+		} else {
+			utils.LogWarn("Invalid K8S_IMPERSONATION_CACHE_TTL, using default", map[string]interface{}{
+				"value":   ttlStr,
+				"default": defaultImpersonationCacheTTL.String(),
+			})
+		}
+	}
+
+	return cfg
+}
+
+// userClientEntry is a cached set of clients impersonating one user on one cluster
+type userClientEntry struct {
+	base    *rest.Config // cluster config the clients were derived from
+	clients *Clients
+	expires time.Time
+}
+
+// SetImpersonation enables or disables per-user clients and clears the client cache
+func (s *Service) SetImpersonation(cfg ImpersonationConfig) {
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultImpersonationCacheTTL
+	}
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+	s.impersonation = cfg
+	s.userClients = make(map[string]*userClientEntry)
+}
+
+// ImpersonationEnabled reports whether Kubernetes requests impersonate the logged-in user
+func (s *Service) ImpersonationEnabled() bool {
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+	return s.impersonation.Enabled
+}
+
+// shouldImpersonate reports whether requests from this user must run under their own identity
+func shouldImpersonate(claims *models.Claims) bool {
+	return claims.IDP != "" && claims.IDP != "core"
+}
+
+// impersonationKey identifies a cached client set by cluster, user and group membership
+func impersonationKey(cluster string, impersonate rest.ImpersonationConfig) string {
+	return cluster + "\x00" + impersonate.UserName + "\x00" + strings.Join(impersonate.Groups, "\x00")
+}
+
+// impersonationFor builds the impersonation settings for a user
+func (cfg ImpersonationConfig) impersonationFor(claims *models.Claims) rest.ImpersonationConfig {
+	groups := make([]string, 0, len(claims.Groups))
+	for _, group := range claims.Groups {
+		if group == "" {
+			continue
+		}
+		groups = append(groups, cfg.GroupPrefix+group)
+	}
+	sort.Strings(groups)
+
+	return rest.ImpersonationConfig{
+		UserName: cfg.UserPrefix + claims.Username,
+		Groups:   groups,
+	}
+}
+
+// userClientsFor returns the clients impersonating the request's user on a cluster.
+// It returns nil without error when the shared service-account clients should be used:
+// impersonation is disabled or the user is a core account.
+func (s *Service) userClientsFor(r *http.Request, cluster string) (*Clients, error) {
+	s.userMu.Lock()
+	cfg := s.impersonation
+	s.userMu.Unlock()
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	claims, err := permissions.GetUserFromContext(r.Context())
+	if err != nil || claims.Username == "" {
+		return nil, ErrImpersonationNoUser
+	}
+	if !shouldImpersonate(claims) {
+		return nil, nil
+	}
+
+	s.handlers.RLock()
+	base, ok := s.handlers.RESTConfigs[cluster]
+	s.handlers.RUnlock()
+	if !ok || base == nil {
+		return nil, fmt.Errorf("cluster not found: %s", cluster)
+	}
+
+	impersonate := cfg.impersonationFor(claims)
+	key := impersonationKey(cluster, impersonate)
+	now := time.Now()
+
+	s.userMu.Lock()
+	defer s.userMu.Unlock()
+
+	// A different base config means the cluster token was reloaded or the cluster re-registered
+	if entry, ok := s.userClients[key]; ok && entry.base == base && now.Before(entry.expires) {
+		return entry.clients, nil
+	}
+
+	config := rest.CopyConfig(base)
+	config.Impersonate = impersonate
+	clients, err := s.clientBuilder(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonated clients: %w", err)
+	}
+
+	s.pruneUserClients(now)
+	s.userClients[key] = &userClientEntry{base: base, clients: clients, expires: now.Add(cfg.CacheTTL)}
+
+	utils.LogDebug("Created impersonated Kubernetes clients", map[string]interface{}{
+		"cluster": cluster,
+		"user":    impersonate.UserName,
+		"groups":  impersonate.Groups,
+	})
+
+	return clients, nil
+}
+
+// pruneUserClients drops expired cache entries. Callers must hold userMu.
+func (s *Service) pruneUserClients(now time.Time) {
+	for key, entry := range s.userClients {
+		if !now.Before(entry.expires) {
+			delete(s.userClients, key)
+		}
+	}
+}
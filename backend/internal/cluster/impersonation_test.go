@@ -0,0 +1,171 @@
+package cluster
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newImpersonationService(cfg ImpersonationConfig) (*Service, *[]*rest.Config) {
+	var built []*rest.Config
+	handlers := newEmptyHandlers()
+	handlers.RESTConfigs["default"] = &rest.Config{Host: "https://default:6443", BearerToken: "sa-token"}
+
+	service := NewService(handlers)
+	service.clientBuilder = fakeBuilder(&built, nil)
+	service.SetImpersonation(cfg)
+	return service, &built
+}
+
+func ldapUser(username string, groups ...string) *models.Claims {
+	return &models.Claims{Username: username, Role: "user", IDP: "ldap", Groups: groups}
+}
+
+func TestGetClient_ImpersonatesExternalUsers(t *testing.T) {
+	service, built := newImpersonationService(ImpersonationConfig{
+		Enabled:     true,
+		UserPrefix:  "ldap:",
+		GroupPrefix: "ldap:",
+	})
+
+	client, err := service.GetClient(requestAs(http.MethodGet, "/", ldapUser("alice", "devs", "ops")))
+	if err != nil {
+		t.Fatalf("GetClient returned error: %v", err)
+	}
+	if client == service.handlers.Clients["default"] {
+		t.Fatalf("expected an impersonated client, got the shared one")
+	}
+	if len(*built) != 1 {
+		t.Fatalf("expected one client set to be built, got %d", len(*built))
+	}
+
+	config := (*built)[0]
+	if config.Impersonate.UserName != "ldap:alice" {
+		t.Errorf("expected impersonated user ldap:alice, got %q", config.Impersonate.UserName)
+	}
+	if want := []string{"ldap:devs", "ldap:ops"}; !reflect.DeepEqual(config.Impersonate.Groups, want) {
+		t.Errorf("expected groups %v, got %v", want, config.Impersonate.Groups)
+	}
+	if config.BearerToken != "sa-token" || config.Host != "https://default:6443" {
+		t.Errorf("expected base credentials to be kept, got %+v", config)
+	}
+	if service.handlers.RESTConfigs["default"].Impersonate.UserName != "" {
+		t.Errorf("base config must not be modified")
+	}
+
+	restConfig, err := service.GetRESTConfig(requestAs(http.MethodGet, "/", ldapUser("alice", "ops", "devs")))
+	if err != nil {
+		t.Fatalf("GetRESTConfig returned error: %v", err)
+	}
+	if restConfig.Impersonate.UserName != "ldap:alice" {
+		t.Errorf("expected impersonated REST config, got %+v", restConfig.Impersonate)
+	}
+	if len(*built) != 1 {
+		t.Errorf("expected cached clients to be reused regardless of group order, built %d", len(*built))
+	}
+}
+
+func TestGetClient_ImpersonationCache(t *testing.T) {
+	service, built := newImpersonationService(ImpersonationConfig{Enabled: true, CacheTTL: time.Hour})
+
+	alice := requestAs(http.MethodGet, "/", ldapUser("alice", "devs"))
+	first, _ := service.GetDynamicClient(alice)
+	second, _ := service.GetDynamicClient(alice)
+	if first != second || len(*built) != 1 {
+		t.Fatalf("expected the same cached client, built %d", len(*built))
+	}
+
+	// Different group membership gets its own clients
+	if _, err := service.GetClient(requestAs(http.MethodGet, "/", ldapUser("alice", "admins"))); err != nil {
+		t.Fatalf("GetClient returned error: %v", err)
+	}
+	if len(*built) != 2 {
+		t.Fatalf("expected a new client set for different groups, built %d", len(*built))
+	}
+
+	// A reloaded cluster config invalidates cached clients
+	service.handlers.RESTConfigs["default"] = &rest.Config{Host: "https://default:6443", BearerToken: "new-token"}
+	if _, err := service.GetClient(alice); err != nil {
+		t.Fatalf("GetClient returned error: %v", err)
+	}
+	if len(*built) != 3 || (*built)[2].BearerToken != "new-token" {
+		t.Fatalf("expected clients to be rebuilt from the new config")
+	}
+
+	// Expired entries are rebuilt
+	for _, entry := range service.userClients {
+		entry.expires = time.Now().Add(-time.Second)
+	}
+	if _, err := service.GetClient(alice); err != nil {
+		t.Fatalf("GetClient returned error: %v", err)
+	}
+	if len(*built) != 4 {
+		t.Fatalf("expected expired clients to be rebuilt, built %d", len(*built))
+	}
+	if len(service.userClients) != 1 {
+		t.Fatalf("expected expired entries to be pruned, got %d", len(service.userClients))
+	}
+}
+
+func TestGetClient_ImpersonationFallbacks(t *testing.T) {
+	service, built := newImpersonationService(ImpersonationConfig{Enabled: true})
+
+	// Core accounts keep the service-account client
+	admin := &models.Claims{Username: "admin", Role: "admin", IDP: "core"}
+	client, err := service.GetClient(requestAs(http.MethodGet, "/", admin))
+	if err != nil || client != service.handlers.Clients["default"] {
+		t.Fatalf("expected shared client for core admin, got err=%v", err)
+	}
+
+	// Requests without a user fail closed
+	if _, err := service.GetClient(newRequest("")); !errors.Is(err, ErrImpersonationNoUser) {
+		t.Fatalf("expected ErrImpersonationNoUser, got %v", err)
+	}
+
+	// Unknown clusters are reported before any client is built
+	if _, err := service.GetClient(requestAs(http.MethodGet, "/?cluster=missing", ldapUser("alice"))); err == nil {
+		t.Fatalf("expected error for missing cluster")
+	}
+	if len(*built) != 0 {
+		t.Fatalf("expected no impersonated clients, built %d", len(*built))
+	}
+
+	// Disabled impersonation uses the shared client for everyone
+	service.SetImpersonation(ImpersonationConfig{})
+	client, err = service.GetClient(requestAs(http.MethodGet, "/", ldapUser("alice")))
+	if err != nil || client != service.handlers.Clients["default"] {
+		t.Fatalf("expected shared client when impersonation is disabled, got err=%v", err)
+	}
+}
+
+func TestImpersonationConfigFromEnv(t *testing.T) {
+	defer func() {
+		os.Unsetenv("K8S_IMPERSONATION")
+		os.Unsetenv("K8S_IMPERSONATION_USER_PREFIX")
+		os.Unsetenv("K8S_IMPERSONATION_CACHE_TTL")
+	}()
+
+	if cfg := ImpersonationConfigFromEnv(); cfg.Enabled || cfg.CacheTTL != defaultImpersonationCacheTTL {
+		t.Fatalf("expected impersonation disabled by default, got %+v", cfg)
+	}
+
+	os.Setenv("K8S_IMPERSONATION", "TRUE")
+	os.Setenv("K8S_IMPERSONATION_USER_PREFIX", "oidc:")
+	os.Setenv("K8S_IMPERSONATION_CACHE_TTL", "30s")
+	cfg := ImpersonationConfigFromEnv()
+	if !cfg.Enabled || cfg.UserPrefix != "oidc:" || cfg.CacheTTL != 30*time.Second {
+		t.Fatalf("unexpected config: %+v", cfg)
+	}
+
+	os.Setenv("K8S_IMPERSONATION_CACHE_TTL", "soon")
+	if cfg := ImpersonationConfigFromEnv(); cfg.CacheTTL != defaultImpersonationCacheTTL {
+		t.Fatalf("expected default TTL for invalid value, got %v", cfg.CacheTTL)
+	}
+}
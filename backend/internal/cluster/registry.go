@@ -0,0 +1,526 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// DefaultClusterName is the name of the cluster DKonsole runs against at startup
+const DefaultClusterName = "default"
+
+// connectionTestTimeout bounds how long a connectivity check may take
+const connectionTestTimeout = 10 * time.Second
+
+var (
+	// ErrClusterNotFound is returned when a cluster is not registered
+	ErrClusterNotFound = errors.New("cluster not found")
+	// ErrClusterExists is returned when registering a cluster name that is already in use
+	ErrClusterExists = errors.New("cluster already registered")
+	// ErrReservedClusterName is returned when trying to register or remove the startup cluster
+	ErrReservedClusterName = errors.New("cluster name \"default\" is reserved")
+	// ErrInvalidClusterConfig is returned when the submitted cluster configuration is incomplete or unsafe
+	ErrInvalidClusterConfig = errors.New("invalid cluster configuration")
+	// ErrRegistryUnavailable is returned when no repository has been configured
+	ErrRegistryUnavailable = errors.New("cluster registry not available")
+	// ErrClusterUnreachable is returned when the API server cannot be reached with the given credentials
+	ErrClusterUnreachable = errors.New("failed to connect to cluster")
+)
+
+// Clients bundles the Kubernetes clients built for a single cluster
+type Clients struct {
+	Client  kubernetes.Interface
+	Dynamic dynamic.Interface
+	Metrics *metricsv.Clientset
+	Config  *rest.Config
+}
+
+// ClientBuilder creates the clients for a cluster from its REST config
+type ClientBuilder func(config *rest.Config) (*Clients, error)
+
+// TestResult describes the outcome of a cluster connectivity test
+type TestResult struct {
+	Name    string `json:"name,omitempty"`
+	Host    string `json:"host"`
+	Version string `json:"version"`
+}
+
+// NewClients builds the typed, dynamic and metrics clients for a REST config.
+// A metrics client failure is not fatal: the cluster is registered without metrics.
+func NewClients(config *rest.Config) (*Clients, error) {
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	metricsClient, err := metricsv.NewForConfig(config)
+	if err != nil {
+		utils.LogWarn("Failed to create metrics client for cluster", map[string]interface{}{
+			"host":  config.Host,
+			"error": err.Error(),
+		})
+		metricsClient = nil
+	}
+
+	return &Clients{
+		Client:  client,
+		Dynamic: dynamicClient,
+		Metrics: metricsClient,
+		Config:  config,
+	}, nil
+}
+
+// SetRepository wires the persistent store used by the cluster registry
+func (s *Service) SetRepository(repo Repository) {
+	s.repo = repo
+}
+
+// refreshRepoClient keeps the registry repository pointed at the current default client,
+// which may change after a token reload or once setup mode completes.
+func (s *Service) refreshRepoClient() {
+	repo, ok := s.repo.(*K8sRepository)
+	if !ok || s.handlers == nil {
+		return
+	}
+
+	s.handlers.RLock()
+	client := s.handlers.Clients[DefaultClusterName]
+	s.handlers.RUnlock()
+
+	if client != nil {
+		repo.SetClient(client)
+	}
+}
+
+// LoadClusters reads every registered cluster from the repository and installs its clients.
+// Clusters that fail to load are kept in the registry and reported by ListClusters.
+func (s *Service) LoadClusters(ctx context.Context) error {
+	if s.repo == nil {
+		return ErrRegistryUnavailable
+	}
+	s.refreshRepoClient()
+
+	configs, err := s.repo.ListClusters(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list registered clusters: %w", err)
+	}
+
+	loaded := 0
+	for _, config := range configs {
+		s.mu.Lock()
+		s.registered[config.Name] = config
+		s.mu.Unlock()
+
+		if config.Name == DefaultClusterName {
+			s.setLoadError(config.Name, ErrReservedClusterName)
+			continue
+		}
+
+		restConfig, err := BuildRESTConfig(&config)
+		if err != nil {
+			s.setLoadError(config.Name, err)
+			continue
+		}
+		clients, err := s.clientBuilder(restConfig)
+		if err != nil {
+			s.setLoadError(config.Name, err)
+			continue
+		}
+		s.installClients(config.Name, clients)
+		loaded++
+	}
+
+	utils.LogInfo("Cluster registry loaded", map[string]interface{}{
+		"registered": len(configs),
+		"loaded":     loaded,
+	})
+	return nil
+}
+
+// RegisterCluster validates, optionally tests, persists and installs a new cluster
+func (s *Service) RegisterCluster(ctx context.Context, config *models.ClusterConfig, skipTest bool) (*models.ClusterInfo, error) {
+	if s.repo == nil {
+		return nil, ErrRegistryUnavailable
+	}
+	if err := ValidateClusterConfig(config); err != nil {
+		return nil, err
+	}
+	if s.clusterExists(config.Name) {
+		return nil, fmt.Errorf("%w: %s", ErrClusterExists, config.Name)
+	}
+
+	restConfig, err := BuildRESTConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := s.clientBuilder(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	if !skipTest {
+		if _, err := testConnection(clients); err != nil {
+			return nil, err
+		}
+	}
+
+	s.refreshRepoClient()
+	if err := s.repo.SaveCluster(ctx, config); err != nil {
+		return nil, fmt.Errorf("failed to save cluster: %w", err)
+	}
+
+	s.mu.Lock()
+	s.registered[config.Name] = *config
+	s.mu.Unlock()
+	s.installClients(config.Name, clients)
+
+	utils.LogInfo("Cluster registered", map[string]interface{}{
+		"cluster": config.Name,
+		"host":    restConfig.Host,
+	})
+
+	info := s.clusterInfo(config.Name)
+	return &info, nil
+}
+
+// RemoveCluster deletes a registered cluster from the repository and drops its clients
+func (s *Service) RemoveCluster(ctx context.Context, name string) error {
+	if name == DefaultClusterName {
+		return ErrReservedClusterName
+	}
+	if s.repo == nil {
+		return ErrRegistryUnavailable
+	}
+
+	s.refreshRepoClient()
+	if err := s.repo.DeleteCluster(ctx, name); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.registered, name)
+	delete(s.loadErrors, name)
+	s.mu.Unlock()
+
+	s.handlers.Lock()
+	delete(s.handlers.Clients, name)
+	delete(s.handlers.Dynamics, name)
+	delete(s.handlers.Metrics, name)
+	delete(s.handlers.RESTConfigs, name)
+	s.handlers.Unlock()
+
+	utils.LogInfo("Cluster removed", map[string]interface{}{
+		"cluster": name,
+	})
+	return nil
+}
+
+// TestCluster checks connectivity for a cluster configuration without registering it
+func (s *Service) TestCluster(config *models.ClusterConfig) (*TestResult, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: configuration is required", ErrInvalidClusterConfig)
+	}
+	if err := validateConnectionConfig(config); err != nil {
+		return nil, err
+	}
+	restConfig, err := BuildRESTConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	clients, err := s.clientBuilder(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	version, err := testConnection(clients)
+	if err != nil {
+		return nil, err
+	}
+	return &TestResult{Name: config.Name, Host: restConfig.Host, Version: version}, nil
+}
+
+// TestRegisteredCluster checks connectivity for a cluster that is already loaded
+func (s *Service) TestRegisteredCluster(name string) (*TestResult, error) {
+	s.handlers.RLock()
+	client := s.handlers.Clients[name]
+	config := s.handlers.RESTConfigs[name]
+	s.handlers.RUnlock()
+
+	if client == nil {
+		return nil, fmt.Errorf("%w: %s", ErrClusterNotFound, name)
+	}
+
+	version, err := testConnection(&Clients{Client: client, Config: config})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TestResult{Name: name, Version: version}
+	if config != nil {
+		result.Host = config.Host
+	}
+	return result, nil
+}
+
+// ListClusters returns the known clusters without their credentials.
+// The startup cluster is always listed first.
+func (s *Service) ListClusters() []models.ClusterInfo {
+	names := make(map[string]bool)
+
+	s.handlers.RLock()
+	for name, client := range s.handlers.Clients {
+		if client != nil {
+			names[name] = true
+		}
+	}
+	s.handlers.RUnlock()
+
+	s.mu.RLock()
+	for name := range s.registered {
+		names[name] = true
+	}
+	s.mu.RUnlock()
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i] == DefaultClusterName || sorted[j] == DefaultClusterName {
+			return sorted[i] == DefaultClusterName
+		}
+		return sorted[i] < sorted[j]
+	})
+
+	infos := make([]models.ClusterInfo, 0, len(sorted))
+	for _, name := range sorted {
+		infos = append(infos, s.clusterInfo(name))
+	}
+	return infos
+}
+
+// ClusterNames returns the names of the clusters that currently have clients loaded
+func (s *Service) ClusterNames() []string {
+	s.handlers.RLock()
+	defer s.handlers.RUnlock()
+
+	names := make([]string, 0, len(s.handlers.Clients))
+	for name, client := range s.handlers.Clients {
+		if client != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Service) clusterInfo(name string) models.ClusterInfo {
+	s.handlers.RLock()
+	client := s.handlers.Clients[name]
+	config := s.handlers.RESTConfigs[name]
+	metrics := s.handlers.Metrics[name]
+	s.handlers.RUnlock()
+
+	s.mu.RLock()
+	registered, isRegistered := s.registered[name]
+	loadErr := s.loadErrors[name]
+	s.mu.RUnlock()
+
+	info := models.ClusterInfo{
+		Name:      name,
+		Source:    "local",
+		Metrics:   metrics != nil,
+		Connected: client != nil,
+		Error:     loadErr,
+	}
+	if isRegistered {
+		info.Source = "registry"
+		info.Host = registered.Host
+	}
+	if config != nil {
+		info.Host = config.Host
+	}
+	return info
+}
+
+func (s *Service) clusterExists(name string) bool {
+	s.mu.RLock()
+	_, registered := s.registered[name]
+	s.mu.RUnlock()
+	if registered {
+		return true
+	}
+
+	s.handlers.RLock()
+	defer s.handlers.RUnlock()
+	_, loaded := s.handlers.Clients[name]
+	return loaded
+}
+
+func (s *Service) setLoadError(name string, err error) {
+	utils.LogWarn("Failed to load registered cluster", map[string]interface{}{
+		"cluster": name,
+		"error":   err.Error(),
+	})
+	s.mu.Lock()
+	s.loadErrors[name] = err.Error()
+	s.mu.Unlock()
+}
+
+// installClients publishes the clients in every handlers map so requests using
+// ?cluster=<name> can reach the cluster immediately, without a restart.
+func (s *Service) installClients(name string, clients *Clients) {
+	s.handlers.Lock()
+	if s.handlers.Clients == nil {
+		s.handlers.Clients = make(map[string]kubernetes.Interface)
+	}
+	if s.handlers.Dynamics == nil {
+		s.handlers.Dynamics = make(map[string]dynamic.Interface)
+	}
+	if s.handlers.Metrics == nil {
+		s.handlers.Metrics = make(map[string]*metricsv.Clientset)
+	}
+	if s.handlers.RESTConfigs == nil {
+		s.handlers.RESTConfigs = make(map[string]*rest.Config)
+	}
+	s.handlers.Clients[name] = clients.Client
+	s.handlers.Dynamics[name] = clients.Dynamic
+	s.handlers.RESTConfigs[name] = clients.Config
+	if clients.Metrics != nil {
+		s.handlers.Metrics[name] = clients.Metrics
+	} else {
+		delete(s.handlers.Metrics, name)
+	}
+	s.handlers.Unlock()
+
+	s.mu.Lock()
+	delete(s.loadErrors, name)
+	s.mu.Unlock()
+}
+
+// testConnection queries the API server version to confirm the credentials work
+func testConnection(clients *Clients) (string, error) {
+	if clients == nil || clients.Client == nil {
+		return "", fmt.Errorf("kubernetes client not available")
+	}
+	version, err := clients.Client.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrClusterUnreachable, err)
+	}
+	return version.GitVersion, nil
+}
+
+// ValidateClusterConfig checks that a cluster configuration is complete and safe to load
+func ValidateClusterConfig(config *models.ClusterConfig) error {
+	if config == nil {
+		return fmt.Errorf("%w: configuration is required", ErrInvalidClusterConfig)
+	}
+	if err := utils.ValidateK8sName(config.Name, "cluster name"); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidClusterConfig, err)
+	}
+	if config.Name == DefaultClusterName {
+		return ErrReservedClusterName
+	}
+	return validateConnectionConfig(config)
+}
+
+// validateConnectionConfig checks that either a kubeconfig or host+token was provided
+func validateConnectionConfig(config *models.ClusterConfig) error {
+	if config.Kubeconfig != "" {
+		return nil
+	}
+
+	if config.Host == "" {
+		return fmt.Errorf("%w: host or kubeconfig is required", ErrInvalidClusterConfig)
+	}
+	if !strings.HasPrefix(config.Host, "https://") && !strings.HasPrefix(config.Host, "http://") {
+		return fmt.Errorf("%w: host must start with https:// or http://", ErrInvalidClusterConfig)
+	}
+	if config.Token == "" {
+		return fmt.Errorf("%w: token is required when host is provided", ErrInvalidClusterConfig)
+	}
+	return nil
+}
+
+// BuildRESTConfig converts a cluster configuration into a REST config.
+// A kubeconfig takes precedence over host+token; only the selected (or current) context is used.
+func BuildRESTConfig(config *models.ClusterConfig) (*rest.Config, error) {
+	var restConfig *rest.Config
+	if config.Kubeconfig != "" {
+		var err error
+		restConfig, err = restConfigFromKubeconfig([]byte(config.Kubeconfig), config.Context)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		restConfig = &rest.Config{
+			Host:        config.Host,
+			BearerToken: config.Token,
+			TLSClientConfig: rest.TLSClientConfig{
+				Insecure: config.Insecure,
+			},
+		}
+		if config.CAData != "" && !config.Insecure {
+			restConfig.TLSClientConfig.CAData = []byte(config.CAData)
+		}
+	}
+
+	if restConfig.Timeout == 0 {
+		restConfig.Timeout = connectionTestTimeout
+	}
+	return restConfig, nil
+}
+
+// restConfigFromKubeconfig parses an uploaded kubeconfig. Exec plugins, auth providers
+// and file references are rejected because they would run commands or read files
+// inside the DKonsole pod.
+func restConfigFromKubeconfig(data []byte, contextName string) (*rest.Config, error) {
+	rawConfig, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse kubeconfig: %v", ErrInvalidClusterConfig, err)
+	}
+
+	if contextName == "" {
+		contextName = rawConfig.CurrentContext
+	}
+	kubeContext, ok := rawConfig.Contexts[contextName]
+	if contextName == "" || !ok || kubeContext == nil {
+		return nil, fmt.Errorf("%w: context %q not found in kubeconfig", ErrInvalidClusterConfig, contextName)
+	}
+
+	if cluster, ok := rawConfig.Clusters[kubeContext.Cluster]; ok && cluster != nil {
+		if cluster.CertificateAuthority != "" {
+			return nil, fmt.Errorf("%w: certificate-authority file references are not supported, use certificate-authority-data", ErrInvalidClusterConfig)
+		}
+	}
+	if authInfo, ok := rawConfig.AuthInfos[kubeContext.AuthInfo]; ok && authInfo != nil {
+		if authInfo.Exec != nil || authInfo.AuthProvider != nil {
+			return nil, fmt.Errorf("%w: exec and auth-provider credentials are not supported", ErrInvalidClusterConfig)
+		}
+		if authInfo.TokenFile != "" || authInfo.ClientCertificate != "" || authInfo.ClientKey != "" {
+			return nil, fmt.Errorf("%w: file-based credentials are not supported, embed them in the kubeconfig", ErrInvalidClusterConfig)
+		}
+	}
+
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: contextName}
+	restConfig, err := clientcmd.NewDefaultClientConfig(*rawConfig, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClusterConfig, err)
+	}
+	return restConfig, nil
+}
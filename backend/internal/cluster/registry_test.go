@@ -0,0 +1,404 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// memoryRepository is an in-memory Repository used by the registry tests
+type memoryRepository struct {
+	clusters map[string]models.ClusterConfig
+	saveErr  error
+}
+
+func newMemoryRepository(configs ...models.ClusterConfig) *memoryRepository {
+	repo := &memoryRepository{clusters: make(map[string]models.ClusterConfig)}
+	for _, c := range configs {
+		repo.clusters[c.Name] = c
+	}
+	return repo
+}
+
+func (m *memoryRepository) ListClusters(ctx context.Context) ([]models.ClusterConfig, error) {
+	out := make([]models.ClusterConfig, 0, len(m.clusters))
+	for _, c := range m.clusters {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (m *memoryRepository) SaveCluster(ctx context.Context, config *models.ClusterConfig) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.clusters[config.Name] = *config
+	return nil
+}
+
+func (m *memoryRepository) DeleteCluster(ctx context.Context, name string) error {
+	if _, ok := m.clusters[name]; !ok {
+		return ErrClusterNotFound
+	}
+	delete(m.clusters, name)
+	return nil
+}
+
+func newEmptyHandlers() *models.Handlers {
+	return &models.Handlers{
+		Clients:     map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+		Dynamics:    map[string]dynamic.Interface{},
+		Metrics:     map[string]*metricsv.Clientset{},
+		RESTConfigs: map[string]*rest.Config{},
+	}
+}
+
+// fakeBuilder returns a ClientBuilder backed by fake clients, recording the configs it was given
+func fakeBuilder(built *[]*rest.Config, serverErr error) ClientBuilder {
+	return func(config *rest.Config) (*Clients, error) {
+		*built = append(*built, config)
+		client := k8sfake.NewSimpleClientset()
+		client.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.30.1"}
+		if serverErr != nil {
+			client.PrependReactor("get", "version", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, nil, serverErr
+			})
+		}
+		return &Clients{
+			Client:  client,
+			Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+			Config:  config,
+		}, nil
+	}
+}
+
+func TestService_RegisterCluster_InstallsClientsInAllMaps(t *testing.T) {
+	handlers := newEmptyHandlers()
+	repo := newMemoryRepository()
+	var built []*rest.Config
+
+	service := NewService(handlers)
+	service.SetRepository(repo)
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	info, err := service.RegisterCluster(context.Background(), &models.ClusterConfig{
+		Name:   "prod-eu",
+		Host:   "https://prod-eu.example.com:6443",
+		Token:  "secret-token",
+		CAData: "-----BEGIN CERTIFICATE-----",
+	}, false)
+	if err != nil {
+		t.Fatalf("RegisterCluster returned error: %v", err)
+	}
+	if info.Source != "registry" || !info.Connected {
+		t.Fatalf("unexpected cluster info: %+v", info)
+	}
+	if _, ok := repo.clusters["prod-eu"]; !ok {
+		t.Fatalf("expected cluster to be persisted")
+	}
+	if handlers.Clients["prod-eu"] == nil || handlers.Dynamics["prod-eu"] == nil || handlers.RESTConfigs["prod-eu"] == nil {
+		t.Fatalf("expected clients to be installed for new cluster")
+	}
+	if len(built) != 1 || built[0].BearerToken != "secret-token" || string(built[0].CAData) != "-----BEGIN CERTIFICATE-----" {
+		t.Fatalf("unexpected REST config: %+v", built)
+	}
+
+	// The new cluster is immediately reachable through the ?cluster= lookup
+	if _, err := service.GetClient(newRequest("prod-eu")); err != nil {
+		t.Fatalf("GetClient for registered cluster returned error: %v", err)
+	}
+}
+
+func TestService_RegisterCluster_Errors(t *testing.T) {
+	var built []*rest.Config
+
+	tests := []struct {
+		name      string
+		config    models.ClusterConfig
+		serverErr error
+		wantErr   error
+	}{
+		{name: "reserved name", config: models.ClusterConfig{Name: "default", Host: "https://x", Token: "t"}, wantErr: ErrReservedClusterName},
+		{name: "invalid name", config: models.ClusterConfig{Name: "Bad_Name", Host: "https://x", Token: "t"}, wantErr: ErrInvalidClusterConfig},
+		{name: "missing host", config: models.ClusterConfig{Name: "c1", Token: "t"}, wantErr: ErrInvalidClusterConfig},
+		{name: "missing token", config: models.ClusterConfig{Name: "c1", Host: "https://x"}, wantErr: ErrInvalidClusterConfig},
+		{name: "bad scheme", config: models.ClusterConfig{Name: "c1", Host: "ftp://x", Token: "t"}, wantErr: ErrInvalidClusterConfig},
+		{name: "unreachable", config: models.ClusterConfig{Name: "c1", Host: "https://x", Token: "t"}, serverErr: errors.New("dial tcp: timeout"), wantErr: ErrClusterUnreachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewService(newEmptyHandlers())
+			service.SetRepository(newMemoryRepository())
+			service.clientBuilder = fakeBuilder(&built, tt.serverErr)
+
+			config := tt.config
+			_, err := service.RegisterCluster(context.Background(), &config, false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestService_RegisterCluster_Duplicate(t *testing.T) {
+	var built []*rest.Config
+	service := NewService(newEmptyHandlers())
+	service.SetRepository(newMemoryRepository())
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	config := models.ClusterConfig{Name: "c1", Host: "https://x", Token: "t"}
+	if _, err := service.RegisterCluster(context.Background(), &config, false); err != nil {
+		t.Fatalf("first RegisterCluster returned error: %v", err)
+	}
+	if _, err := service.RegisterCluster(context.Background(), &config, false); !errors.Is(err, ErrClusterExists) {
+		t.Fatalf("expected ErrClusterExists, got %v", err)
+	}
+}
+
+func TestService_RegisterCluster_SkipTest(t *testing.T) {
+	var built []*rest.Config
+	service := NewService(newEmptyHandlers())
+	service.SetRepository(newMemoryRepository())
+	service.clientBuilder = fakeBuilder(&built, errors.New("unreachable"))
+
+	config := models.ClusterConfig{Name: "offline", Host: "https://x", Token: "t"}
+	if _, err := service.RegisterCluster(context.Background(), &config, true); err != nil {
+		t.Fatalf("expected registration to succeed when skipping test, got %v", err)
+	}
+}
+
+func TestService_RegisterCluster_NoRepository(t *testing.T) {
+	service := NewService(newEmptyHandlers())
+	config := models.ClusterConfig{Name: "c1", Host: "https://x", Token: "t"}
+	if _, err := service.RegisterCluster(context.Background(), &config, false); !errors.Is(err, ErrRegistryUnavailable) {
+		t.Fatalf("expected ErrRegistryUnavailable, got %v", err)
+	}
+}
+
+func TestService_LoadClusters(t *testing.T) {
+	handlers := newEmptyHandlers()
+	repo := newMemoryRepository(
+		models.ClusterConfig{Name: "east", Host: "https://east", Token: "t1"},
+		models.ClusterConfig{Name: "west", Host: "https://west", Token: "t2"},
+		models.ClusterConfig{Name: "broken", Kubeconfig: "not: [valid"},
+	)
+	var built []*rest.Config
+
+	service := NewService(handlers)
+	service.SetRepository(repo)
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	if err := service.LoadClusters(context.Background()); err != nil {
+		t.Fatalf("LoadClusters returned error: %v", err)
+	}
+	if handlers.Clients["east"] == nil || handlers.Clients["west"] == nil {
+		t.Fatalf("expected east and west to be loaded")
+	}
+	if _, ok := handlers.Clients["broken"]; ok {
+		t.Fatalf("expected broken cluster not to be loaded")
+	}
+
+	infos := service.ListClusters()
+	if len(infos) != 4 {
+		t.Fatalf("expected 4 clusters (default + 3 registered), got %d", len(infos))
+	}
+	if infos[0].Name != "default" || infos[0].Source != "local" {
+		t.Fatalf("expected default cluster first, got %+v", infos[0])
+	}
+	for _, info := range infos {
+		if info.Name == "broken" && (info.Connected || info.Error == "") {
+			t.Fatalf("expected broken cluster to report load error, got %+v", info)
+		}
+	}
+}
+
+func TestService_RemoveCluster(t *testing.T) {
+	handlers := newEmptyHandlers()
+	repo := newMemoryRepository()
+	var built []*rest.Config
+
+	service := NewService(handlers)
+	service.SetRepository(repo)
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	config := models.ClusterConfig{Name: "temp", Host: "https://temp", Token: "t"}
+	if _, err := service.RegisterCluster(context.Background(), &config, false); err != nil {
+		t.Fatalf("RegisterCluster returned error: %v", err)
+	}
+
+	if err := service.RemoveCluster(context.Background(), "temp"); err != nil {
+		t.Fatalf("RemoveCluster returned error: %v", err)
+	}
+	if _, ok := handlers.Clients["temp"]; ok {
+		t.Fatalf("expected client to be removed")
+	}
+	if _, ok := handlers.RESTConfigs["temp"]; ok {
+		t.Fatalf("expected REST config to be removed")
+	}
+	if _, err := service.GetClient(newRequest("temp")); err == nil {
+		t.Fatalf("expected removed cluster to be unreachable")
+	}
+
+	if err := service.RemoveCluster(context.Background(), "default"); !errors.Is(err, ErrReservedClusterName) {
+		t.Fatalf("expected ErrReservedClusterName, got %v", err)
+	}
+	if err := service.RemoveCluster(context.Background(), "missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Fatalf("expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+func TestService_TestCluster(t *testing.T) {
+	var built []*rest.Config
+	service := NewService(newEmptyHandlers())
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	result, err := service.TestCluster(&models.ClusterConfig{Host: "https://x", Token: "t"})
+	if err != nil {
+		t.Fatalf("TestCluster returned error: %v", err)
+	}
+	if result.Version != "v1.30.1" || result.Host != "https://x" {
+		t.Fatalf("unexpected test result: %+v", result)
+	}
+
+	if _, err := service.TestCluster(&models.ClusterConfig{}); !errors.Is(err, ErrInvalidClusterConfig) {
+		t.Fatalf("expected ErrInvalidClusterConfig, got %v", err)
+	}
+	if _, err := service.TestRegisteredCluster("missing"); !errors.Is(err, ErrClusterNotFound) {
+		t.Fatalf("expected ErrClusterNotFound, got %v", err)
+	}
+}
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: main
+clusters:
+- name: main
+  cluster:
+    server: https://main.example.com:6443
+    certificate-authority-data: dGVzdA==
+- name: other
+  cluster:
+    server: https://other.example.com:6443
+contexts:
+- name: main
+  context:
+    cluster: main
+    user: admin
+- name: other
+  context:
+    cluster: other
+    user: admin
+users:
+- name: admin
+  user:
+    token: kube-token
+`
+
+const execKubeconfig = `apiVersion: v1
+kind: Config
+current-context: main
+clusters:
+- name: main
+  cluster:
+    server: https://main.example.com:6443
+contexts:
+- name: main
+  context:
+    cluster: main
+    user: exec-user
+users:
+- name: exec-user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: /bin/sh
+`
+
+func TestBuildRESTConfig_Kubeconfig(t *testing.T) {
+	config, err := BuildRESTConfig(&models.ClusterConfig{Name: "k", Kubeconfig: testKubeconfig})
+	if err != nil {
+		t.Fatalf("BuildRESTConfig returned error: %v", err)
+	}
+	if config.Host != "https://main.example.com:6443" || config.BearerToken != "kube-token" {
+		t.Fatalf("unexpected config from current context: host=%s", config.Host)
+	}
+
+	config, err = BuildRESTConfig(&models.ClusterConfig{Name: "k", Kubeconfig: testKubeconfig, Context: "other"})
+	if err != nil {
+		t.Fatalf("BuildRESTConfig with context returned error: %v", err)
+	}
+	if config.Host != "https://other.example.com:6443" {
+		t.Fatalf("expected other context host, got %s", config.Host)
+	}
+
+	if _, err := BuildRESTConfig(&models.ClusterConfig{Name: "k", Kubeconfig: testKubeconfig, Context: "missing"}); !errors.Is(err, ErrInvalidClusterConfig) {
+		t.Fatalf("expected ErrInvalidClusterConfig for missing context, got %v", err)
+	}
+	if _, err := BuildRESTConfig(&models.ClusterConfig{Name: "k", Kubeconfig: execKubeconfig}); !errors.Is(err, ErrInvalidClusterConfig) {
+		t.Fatalf("expected exec credentials to be rejected, got %v", err)
+	}
+}
+
+func TestBuildRESTConfig_HostToken(t *testing.T) {
+	config, err := BuildRESTConfig(&models.ClusterConfig{Name: "h", Host: "https://h", Token: "tok", Insecure: true, CAData: "ignored"})
+	if err != nil {
+		t.Fatalf("BuildRESTConfig returned error: %v", err)
+	}
+	if !config.Insecure || len(config.CAData) != 0 {
+		t.Fatalf("expected insecure config without CA data")
+	}
+	if config.Timeout == 0 {
+		t.Fatalf("expected a request timeout to be set")
+	}
+}
+
+func TestService_RefreshRepoClient_ConcurrentRequests(t *testing.T) {
+	handlers := newEmptyHandlers()
+	repo := &K8sRepository{namespace: "dkonsole", secretName: "dkonsole-clusters"}
+	service := NewService(handlers)
+	service.SetRepository(repo)
+	var built []*rest.Config
+	service.clientBuilder = fakeBuilder(&built, nil)
+
+	// Token reloads replace the default client while requests use the registry
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				handlers.Lock()
+				handlers.Clients[DefaultClusterName] = k8sfake.NewSimpleClientset()
+				handlers.Unlock()
+				if err := service.LoadClusters(context.Background()); err != nil {
+					t.Errorf("LoadClusters error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	handlers.RLock()
+	want := handlers.Clients[DefaultClusterName]
+	handlers.RUnlock()
+	service.refreshRepoClient()
+	if repo.kubeClient() != want {
+		t.Errorf("repository client was not refreshed to the current default client")
+	}
+}
@@ -0,0 +1,223 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// Repository defines the interface for cluster registry data access
+type Repository interface {
+	ListClusters(ctx context.Context) ([]models.ClusterConfig, error)
+	SaveCluster(ctx context.Context, config *models.ClusterConfig) error
+	DeleteCluster(ctx context.Context, name string) error
+}
+
+// K8sRepository implements Repository using a Kubernetes Secret.
+// Each registered cluster is stored as a JSON document under a key named after the cluster.
+type K8sRepository struct {
+	mu         sync.RWMutex // guards client
+	client     kubernetes.Interface
+	namespace  string
+	secretName string
+}
+
+// NewRepository creates a new K8sRepository instance
+func NewRepository(client kubernetes.Interface) *K8sRepository {
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		// Fallback to default namespace
+		namespace = "default"
+		utils.LogWarn("Failed to get current namespace, using default", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// #nosec G101 -- This is a secret name, not a credential
+	clustersSecretName := "dkonsole-clusters"
+
+	return &K8sRepository{
+		client:     client,
+		namespace:  namespace,
+		secretName: clustersSecretName,
+	}
+}
+
+// SetClient replaces the Kubernetes client, e.g. after the service account token changed
+func (r *K8sRepository) SetClient(client kubernetes.Interface) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client = client
+}
+
+func (r *K8sRepository) kubeClient() kubernetes.Interface {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.client
+}
+
+// ServiceAccountNamespaceFile is the path to the service account namespace file
+var ServiceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// getCurrentNamespace retrieves the current namespace from the pod's service account
+func getCurrentNamespace() (string, error) {
+	// Try reading from service account namespace file (standard in Kubernetes pods)
+	if data, err := os.ReadFile(ServiceAccountNamespaceFile); err == nil {
+		namespace := string(data)
+		if namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	// Fallback to environment variable
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace, nil
+	}
+
+	return "", fmt.Errorf("could not determine namespace: service account file not found and POD_NAMESPACE not set")
+}
+
+// ListClusters retrieves all registered clusters from the Secret, sorted by name
+func (r *K8sRepository) ListClusters(ctx context.Context) ([]models.ClusterConfig, error) {
+	client := r.kubeClient()
+	if client == nil {
+		return nil, nil
+	}
+
+	secret, err := client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// No clusters registered yet
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	clusters := make([]models.ClusterConfig, 0, len(secret.Data))
+	for key, data := range secret.Data {
+		var config models.ClusterConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			utils.LogWarn("Skipping invalid cluster entry in registry secret", map[string]interface{}{
+				"key":   key,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if config.Name == "" {
+			config.Name = key
+		}
+		clusters = append(clusters, config)
+	}
+
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].Name < clusters[j].Name
+	})
+
+	return clusters, nil
+}
+
+// SaveCluster creates or replaces a cluster entry in the Secret
+func (r *K8sRepository) SaveCluster(ctx context.Context, config *models.ClusterConfig) error {
+	client := r.kubeClient()
+	if client == nil {
+		return fmt.Errorf("kubernetes client not available")
+	}
+
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cluster config: %w", err)
+	}
+
+	// Try to get existing Secret
+	secret, err := client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			// Create new Secret
+			secret = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      r.secretName,
+					Namespace: r.namespace,
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					config.Name: configJSON,
+				},
+			}
+			_, err = client.CoreV1().Secrets(r.namespace).Create(ctx, secret, metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to create secret: %w", err)
+			}
+			utils.LogInfo("Created Secret for cluster registry", map[string]interface{}{
+				"secret_name": r.secretName,
+				"namespace":   r.namespace,
+				"cluster":     config.Name,
+			})
+			return nil
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	// Update existing Secret
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[config.Name] = configJSON
+
+	_, err = client.CoreV1().Secrets(r.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	utils.LogInfo("Updated cluster in registry Secret", map[string]interface{}{
+		"secret_name": r.secretName,
+		"namespace":   r.namespace,
+		"cluster":     config.Name,
+	})
+
+	return nil
+}
+
+// DeleteCluster removes a cluster entry from the Secret
+func (r *K8sRepository) DeleteCluster(ctx context.Context, name string) error {
+	client := r.kubeClient()
+	if client == nil {
+		return fmt.Errorf("kubernetes client not available")
+	}
+
+	secret, err := client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return ErrClusterNotFound
+		}
+		return fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	if _, exists := secret.Data[name]; !exists {
+		return ErrClusterNotFound
+	}
+	delete(secret.Data, name)
+
+	_, err = client.CoreV1().Secrets(r.namespace).Update(ctx, secret, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+
+	utils.LogInfo("Removed cluster from registry Secret", map[string]interface{}{
+		"secret_name": r.secretName,
+		"namespace":   r.namespace,
+		"cluster":     name,
+	})
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestNewRepository_DefaultsAndEnvNamespace(t *testing.T) {
+	defer os.Unsetenv("POD_NAMESPACE")
+	os.Setenv("POD_NAMESPACE", "test-ns")
+
+	repo := NewRepository(nil)
+	if repo.namespace == "" {
+		t.Fatalf("expected namespace to be set")
+	}
+	if repo.secretName != "dkonsole-clusters" {
+		t.Fatalf("expected default secret name, got %s", repo.secretName)
+	}
+}
+
+func TestK8sRepository_SaveListDelete(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	repo := &K8sRepository{client: client, namespace: "dkonsole", secretName: "dkonsole-clusters"}
+	ctx := context.Background()
+
+	clusters, err := repo.ListClusters(ctx)
+	if err != nil || len(clusters) != 0 {
+		t.Fatalf("expected empty registry, got %v (err=%v)", clusters, err)
+	}
+
+	// First save creates the Secret, second save updates it
+	if err := repo.SaveCluster(ctx, &models.ClusterConfig{Name: "west", Host: "https://west", Token: "t"}); err != nil {
+		t.Fatalf("SaveCluster create error: %v", err)
+	}
+	if err := repo.SaveCluster(ctx, &models.ClusterConfig{Name: "east", Host: "https://east", Token: "t"}); err != nil {
+		t.Fatalf("SaveCluster update error: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("dkonsole").Get(ctx, "dkonsole-clusters", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected secret to exist: %v", err)
+	}
+	if len(secret.Data) != 2 {
+		t.Fatalf("expected 2 entries in secret, got %d", len(secret.Data))
+	}
+
+	clusters, err = repo.ListClusters(ctx)
+	if err != nil {
+		t.Fatalf("ListClusters error: %v", err)
+	}
+	if len(clusters) != 2 || clusters[0].Name != "east" || clusters[1].Name != "west" {
+		t.Fatalf("expected sorted clusters, got %+v", clusters)
+	}
+
+	if err := repo.DeleteCluster(ctx, "east"); err != nil {
+		t.Fatalf("DeleteCluster error: %v", err)
+	}
+	if err := repo.DeleteCluster(ctx, "east"); !errors.Is(err, ErrClusterNotFound) {
+		t.Fatalf("expected ErrClusterNotFound, got %v", err)
+	}
+
+	clusters, _ = repo.ListClusters(ctx)
+	if len(clusters) != 1 || clusters[0].Name != "west" {
+		t.Fatalf("expected only west to remain, got %+v", clusters)
+	}
+}
+
+func TestK8sRepository_SkipsInvalidEntries(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	repo := &K8sRepository{client: client, namespace: "dkonsole", secretName: "dkonsole-clusters"}
+	ctx := context.Background()
+
+	if err := repo.SaveCluster(ctx, &models.ClusterConfig{Name: "ok", Host: "https://ok", Token: "t"}); err != nil {
+		t.Fatalf("SaveCluster error: %v", err)
+	}
+	secret, _ := client.CoreV1().Secrets("dkonsole").Get(ctx, "dkonsole-clusters", metav1.GetOptions{})
+	secret.Data["corrupt"] = []byte("{not-json")
+	if _, err := client.CoreV1().Secrets("dkonsole").Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("failed to corrupt secret: %v", err)
+	}
+
+	clusters, err := repo.ListClusters(ctx)
+	if err != nil {
+		t.Fatalf("ListClusters error: %v", err)
+	}
+	if len(clusters) != 1 || clusters[0].Name != "ok" {
+		t.Fatalf("expected invalid entry to be skipped, got %+v", clusters)
+	}
+}
+
+func TestK8sRepository_NilClient(t *testing.T) {
+	repo := &K8sRepository{namespace: "dkonsole", secretName: "dkonsole-clusters"}
+	ctx := context.Background()
+
+	if clusters, err := repo.ListClusters(ctx); err != nil || clusters != nil {
+		t.Fatalf("expected empty result without client, got %v (err=%v)", clusters, err)
+	}
+	if err := repo.SaveCluster(ctx, &models.ClusterConfig{Name: "x"}); err == nil {
+		t.Fatalf("expected error saving without client")
+	}
+	if err := repo.DeleteCluster(ctx, "x"); err == nil {
+		t.Fatalf("expected error deleting without client")
+	}
+}
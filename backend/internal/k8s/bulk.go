@@ -0,0 +1,133 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// bulkTimeout bounds a whole bulk request; it is longer than the usual request timeout
+// because up to maxBulkTargets items share it
+const bulkTimeout = 2 * time.Minute
+
+// BulkOperation applies one action (delete, restart, scale, label or annotate) to a list of resources,
+// or to the resources of a kind matching a label selector, and reports the outcome of every item.
+// Items are processed with bounded concurrency and each one is permission-checked and audited on its own.
+//
+// Example request:
+//
+//	{
+//	  "action": "delete",
+//	  "selector": {"kind": "Job", "namespace": "batch", "labelSelector": "status=failed"}
+//	}
+//
+// Example response:
+//
+//	{
+//	  "action": "delete", "total": 2, "succeeded": 1, "failed": 1,
+//	  "results": [
+//	    {"kind": "Job", "namespace": "batch", "name": "etl-1", "success": true, "status": 200},
+//	    {"kind": "Job", "namespace": "batch", "name": "etl-2", "success": false, "status": 404, "error": "..."}
+//	  ]
+//	}
+func (s *Service) BulkOperation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var req BulkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize)).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for _, target := range req.Targets {
+		if err := utils.ValidateK8sName(target.Name, "name"); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if target.Namespace != "" {
+			if err := utils.ValidateK8sName(target.Namespace, "namespace"); err != nil {
+				utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+	}
+	if req.Selector != nil && req.Selector.Namespace != "" {
+		if err := utils.ValidateK8sName(req.Selector.Namespace, "namespace"); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dynamicClient, err := s.clusterService.GetDynamicClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	bulkService := s.serviceFactory.CreateBulkService(dynamicClient, client)
+
+	// The request may outlive both the default operation timeout and the server write timeout
+	ctx, cancel := context.WithTimeout(r.Context(), bulkTimeout)
+	defer cancel()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(bulkTimeout + 10*time.Second))
+
+	result, err := bulkService.Run(ctx, req)
+	if err != nil {
+		writeBulkError(w, err)
+		return
+	}
+
+	for i := range result.Results {
+		item := &result.Results[i]
+		item.Status = bulkItemStatus(item.Err)
+		utils.AuditLog(r, string(req.Action), item.Kind, item.Name, item.Namespace, item.Success, item.Err, map[string]interface{}{
+			"bulk": true,
+		})
+	}
+
+	utils.JSONResponse(w, http.StatusOK, result)
+}
+
+// writeBulkError maps an error rejecting a whole bulk request to an HTTP response
+func writeBulkError(w http.ResponseWriter, err error) {
+	switch status := bulkItemStatus(err); status {
+	case http.StatusInternalServerError:
+		utils.HandleErrorJSON(w, err, "Failed to run bulk operation", status, nil)
+	default:
+		utils.ErrorResponse(w, status, err.Error())
+	}
+}
+
+// bulkItemStatus returns the HTTP status equivalent of the outcome of a bulk item
+func bulkItemStatus(err error) int {
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrInvalidBulkRequest), errors.Is(err, ErrNamespaceRequired),
+		errors.Is(err, ErrScaleUnsupported), errors.Is(err, ErrRestartUnsupported), errors.Is(err, ErrInvalidReplicas):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrAdminRequired):
+		return http.StatusForbidden
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	case apierrors.IsConflict(err):
+		return http.StatusConflict
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
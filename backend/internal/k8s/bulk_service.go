@@ -0,0 +1,305 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// BulkAction is the operation a bulk request applies to each of its targets
+type BulkAction string
+
+const (
+	BulkDelete   BulkAction = "delete"
+	BulkRestart  BulkAction = "restart"
+	BulkScale    BulkAction = "scale"
+	BulkLabel    BulkAction = "label"
+	BulkAnnotate BulkAction = "annotate"
+)
+
+const (
+	// maxBulkTargets bounds the number of resources a single bulk request may touch
+	maxBulkTargets = 500
+	// defaultBulkConcurrency is the number of items processed in parallel unless the request asks otherwise
+	defaultBulkConcurrency = 5
+	// maxBulkConcurrency caps the parallelism a request may ask for
+	maxBulkConcurrency = 20
+)
+
+// ErrInvalidBulkRequest reports a malformed bulk request
+var ErrInvalidBulkRequest = errors.New("invalid bulk request")
+
+// BulkTarget references a single resource of a bulk request
+type BulkTarget struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// BulkSelector selects the targets of a bulk request by label instead of listing them
+type BulkSelector struct {
+	Kind          string `json:"kind"`
+	APIVersion    string `json:"apiVersion,omitempty"`
+	Namespace     string `json:"namespace,omitempty"`
+	LabelSelector string `json:"labelSelector"` // empty matches every resource of the kind
+}
+
+// BulkRequest applies one action to a list of targets or to the resources matching a selector
+type BulkRequest struct {
+	Action   BulkAction    `json:"action"`
+	Targets  []BulkTarget  `json:"targets,omitempty"`
+	Selector *BulkSelector `json:"selector,omitempty"`
+
+	Force    bool               `json:"force,omitempty"`    // delete: no grace period, background propagation
+	Delta    int                `json:"delta,omitempty"`    // scale: relative change
+	Replicas *int32             `json:"replicas,omitempty"` // scale: absolute replica count
+	Values   map[string]*string `json:"values,omitempty"`   // label/annotate: keys to set; a null value removes the key
+
+	Concurrency int `json:"concurrency,omitempty"` // items processed in parallel (default 5, max 20)
+}
+
+// BulkItemResult reports the outcome of a bulk action on one target
+type BulkItemResult struct {
+	BulkTarget
+	Success bool         `json:"success"`
+	Status  int          `json:"status,omitempty"` // HTTP status equivalent of the outcome, set by the handler
+	Error   string       `json:"error,omitempty"`
+	Scale   *ScaleResult `json:"scale,omitempty"`
+
+	Err error `json:"-"`
+}
+
+// BulkResult reports the outcome of a bulk request, with one result per target in request order
+type BulkResult struct {
+	Action    BulkAction       `json:"action"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
+
+// BulkService applies delete, restart, scale, label and annotate actions to many resources at once
+type BulkService struct {
+	resourceRepo ResourceRepository
+	gvrResolver  GVRResolver
+	workloads    *WorkloadService
+}
+
+// NewBulkService creates a new BulkService
+func NewBulkService(resourceRepo ResourceRepository, gvrResolver GVRResolver, workloads *WorkloadService) *BulkService {
+	return &BulkService{
+		resourceRepo: resourceRepo,
+		gvrResolver:  gvrResolver,
+		workloads:    workloads,
+	}
+}
+
+// Run validates the request, resolves its targets and applies the action to each of them with bounded
+// concurrency. A failing item does not stop the others; its error is reported in its result.
+// Every item is permission-checked on its own: edit on its namespace, or admin for cluster-scoped resources.
+func (s *BulkService) Run(ctx context.Context, req BulkRequest) (*BulkResult, error) {
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+
+	targets := req.Targets
+	if req.Selector != nil {
+		var err error
+		if targets, err = s.selectTargets(ctx, *req.Selector); err != nil {
+			return nil, err
+		}
+	}
+
+	concurrency := req.Concurrency
+	if concurrency == 0 {
+		concurrency = defaultBulkConcurrency
+	}
+	if concurrency > maxBulkConcurrency {
+		concurrency = maxBulkConcurrency
+	}
+
+	results := make([]BulkItemResult, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = s.runItem(ctx, req, target)
+		}()
+	}
+	wg.Wait()
+
+	result := &BulkResult{Action: req.Action, Total: len(results), Results: results}
+	for _, item := range results {
+		if item.Success {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+// validate checks the request is self-consistent before anything is touched
+func (req BulkRequest) validate() error {
+	switch req.Action {
+	case BulkDelete, BulkRestart:
+	case BulkScale:
+		if req.Replicas != nil && req.Delta != 0 {
+			return fmt.Errorf("%w: use either delta or replicas, not both", ErrInvalidBulkRequest)
+		}
+		if req.Replicas == nil && req.Delta == 0 {
+			return fmt.Errorf("%w: scale requires a non-zero delta or replicas", ErrInvalidBulkRequest)
+		}
+		if (req.Replicas != nil && *req.Replicas < 0) || req.Delta < -math.MaxInt32 || req.Delta > math.MaxInt32 {
+			return fmt.Errorf("%w: %w", ErrInvalidBulkRequest, ErrInvalidReplicas)
+		}
+	case BulkLabel, BulkAnnotate:
+		if len(req.Values) == 0 {
+			return fmt.Errorf("%w: %s requires values", ErrInvalidBulkRequest, req.Action)
+		}
+		for key, value := range req.Values {
+			if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+				return fmt.Errorf("%w: invalid key %q: %s", ErrInvalidBulkRequest, key, strings.Join(errs, "; "))
+			}
+			if req.Action == BulkLabel && value != nil {
+				if errs := validation.IsValidLabelValue(*value); len(errs) > 0 {
+					return fmt.Errorf("%w: invalid value for label %q: %s", ErrInvalidBulkRequest, key, strings.Join(errs, "; "))
+				}
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unknown action %q", ErrInvalidBulkRequest, req.Action)
+	}
+
+	if (len(req.Targets) == 0) == (req.Selector == nil) {
+		return fmt.Errorf("%w: specify either targets or a selector", ErrInvalidBulkRequest)
+	}
+	if len(req.Targets) > maxBulkTargets {
+		return fmt.Errorf("%w: at most %d targets per request", ErrInvalidBulkRequest, maxBulkTargets)
+	}
+	if req.Concurrency < 0 {
+		return fmt.Errorf("%w: concurrency must not be negative", ErrInvalidBulkRequest)
+	}
+	return nil
+}
+
+// selectTargets lists the resources matching a selector. Listing requires the same permission
+// the action does, so the selector cannot be used to enumerate resources the user cannot change.
+func (s *BulkService) selectTargets(ctx context.Context, sel BulkSelector) ([]BulkTarget, error) {
+	if sel.Kind == "" {
+		return nil, fmt.Errorf("%w: selector kind is required", ErrInvalidBulkRequest)
+	}
+	if _, err := labels.Parse(sel.LabelSelector); err != nil {
+		return nil, fmt.Errorf("%w: invalid label selector: %v", ErrInvalidBulkRequest, err)
+	}
+
+	kind := models.NormalizeKind(sel.Kind)
+	gvr, meta, err := s.gvrResolver.ResolveGVR(ctx, kind, sel.APIVersion, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve kind %s: %w", sel.Kind, err)
+	}
+	if err := authorizeBulkTarget(ctx, meta, sel.Namespace); err != nil {
+		return nil, err
+	}
+
+	list, err := s.resourceRepo.List(ctx, gvr, sel.Namespace, meta.Namespaced, metav1.ListOptions{LabelSelector: sel.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) > maxBulkTargets {
+		return nil, fmt.Errorf("%w: selector matches %d resources, at most %d per request", ErrInvalidBulkRequest, len(list.Items), maxBulkTargets)
+	}
+
+	targets := make([]BulkTarget, 0, len(list.Items))
+	for _, item := range list.Items {
+		targets = append(targets, BulkTarget{Kind: kind, APIVersion: sel.APIVersion, Namespace: item.GetNamespace(), Name: item.GetName()})
+	}
+	return targets, nil
+}
+
+// runItem applies the action to one target and records its outcome
+func (s *BulkService) runItem(ctx context.Context, req BulkRequest, target BulkTarget) BulkItemResult {
+	result := BulkItemResult{BulkTarget: target}
+	scale, err := s.apply(ctx, req, target)
+	if err != nil {
+		result.Err, result.Error = err, err.Error()
+		return result
+	}
+	result.Success, result.Scale = true, scale
+	return result
+}
+
+func (s *BulkService) apply(ctx context.Context, req BulkRequest, target BulkTarget) (*ScaleResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if target.Kind == "" || target.Name == "" {
+		return nil, fmt.Errorf("%w: kind and name are required", ErrInvalidBulkRequest)
+	}
+
+	kind := models.NormalizeKind(target.Kind)
+	gvr, meta, err := s.gvrResolver.ResolveGVR(ctx, kind, target.APIVersion, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve kind %s: %w", target.Kind, err)
+	}
+	if err := authorizeBulkTarget(ctx, meta, target.Namespace); err != nil {
+		return nil, err
+	}
+	namespace := target.Namespace
+	if !meta.Namespaced {
+		namespace = ""
+	}
+
+	switch req.Action {
+	case BulkDelete:
+		return nil, s.resourceRepo.Delete(ctx, gvr, target.Name, namespace, meta.Namespaced, deleteOptions(req.Force))
+	case BulkRestart:
+		return nil, s.workloads.Restart(ctx, kind, target.APIVersion, namespace, target.Name)
+	case BulkScale:
+		return s.workloads.Scale(ctx, ScaleRequest{
+			Kind: kind, APIVersion: target.APIVersion, Namespace: namespace, Name: target.Name,
+			Delta: req.Delta, Replicas: req.Replicas,
+		})
+	default:
+		field := "labels"
+		if req.Action == BulkAnnotate {
+			field = "annotations"
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{field: req.Values},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal patch: %w", err)
+		}
+		_, err = s.resourceRepo.Patch(ctx, gvr, target.Name, namespace, meta.Namespaced, patch, types.MergePatchType, metav1.PatchOptions{FieldManager: "dkonsole"})
+		return nil, err
+	}
+}
+
+// authorizeBulkTarget requires edit permission on the namespace of a namespaced resource,
+// and admin for cluster-scoped ones
+func authorizeBulkTarget(ctx context.Context, meta models.ResourceMeta, namespace string) error {
+	if !meta.Namespaced {
+		return requireAdmin(ctx)
+	}
+	if namespace == "" {
+		return fmt.Errorf("%w", ErrNamespaceRequired)
+	}
+	return requireEditPermission(ctx, namespace)
+}
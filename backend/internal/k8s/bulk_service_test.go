@@ -0,0 +1,231 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+var (
+	jobsGVR        = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	deploymentsGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+)
+
+func bulkObject(apiVersion, kind, namespace, name string, labels map[string]interface{}) runtime.Object {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace, "labels": labels},
+	}}
+}
+
+// newBulkTestService returns a BulkService backed by a fake dynamic client holding the given objects
+func newBulkTestService(workloads *mockWorkloadRepository, objects ...runtime.Object) (*BulkService, *dynamicfake.FakeDynamicClient) {
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{jobsGVR: "JobList", deploymentsGVR: "DeploymentList"}, objects...)
+	return NewBulkService(NewK8sResourceRepository(client), staticGVRResolver{}, NewWorkloadService(workloads, staticGVRResolver{})), client
+}
+
+func TestBulkService_Delete(t *testing.T) {
+	svc, client := newBulkTestService(&mockWorkloadRepository{},
+		bulkObject("batch/v1", "Job", "batch", "etl-1", nil),
+		bulkObject("batch/v1", "Job", "other", "etl-3", nil),
+	)
+	ctx := ctxWithPermissions(map[string]string{"batch": "edit", "other": "view"})
+
+	result, err := svc.Run(ctx, BulkRequest{Action: BulkDelete, Targets: []BulkTarget{
+		{Kind: "Job", Namespace: "batch", Name: "etl-1"},
+		{Kind: "Job", Namespace: "batch", Name: "etl-2"},
+		{Kind: "Job", Namespace: "other", Name: "etl-3"},
+		{Kind: "Node", Name: "worker-1"},
+	}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Total != 4 || result.Succeeded != 1 || result.Failed != 3 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	if item := result.Results[0]; !item.Success || item.Name != "etl-1" {
+		t.Errorf("results[0] = %+v", item)
+	}
+	if item := result.Results[1]; item.Success || !apierrors.IsNotFound(item.Err) || item.Error == "" {
+		t.Errorf("results[1] = %+v, want not found", item)
+	}
+	if item := result.Results[2]; !errors.Is(item.Err, ErrForbidden) {
+		t.Errorf("results[2] = %+v, want forbidden", item)
+	}
+	if item := result.Results[3]; !errors.Is(item.Err, ErrAdminRequired) {
+		t.Errorf("results[3] = %+v, want admin required", item)
+	}
+
+	if _, err := client.Resource(jobsGVR).Namespace("batch").Get(context.Background(), "etl-1", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("etl-1 was not deleted: %v", err)
+	}
+	if _, err := client.Resource(jobsGVR).Namespace("other").Get(context.Background(), "etl-3", metav1.GetOptions{}); err != nil {
+		t.Errorf("etl-3 was deleted without edit permission: %v", err)
+	}
+}
+
+func TestBulkService_Selector(t *testing.T) {
+	svc, client := newBulkTestService(&mockWorkloadRepository{},
+		bulkObject("apps/v1", "Deployment", "shop", "web", map[string]interface{}{"tier": "front"}),
+		bulkObject("apps/v1", "Deployment", "shop", "api", map[string]interface{}{"tier": "back"}),
+		bulkObject("apps/v1", "Deployment", "other", "web", map[string]interface{}{"tier": "front"}),
+	)
+	ctx := ctxWithPermissions(map[string]string{"shop": "edit"})
+
+	team := "checkout"
+	result, err := svc.Run(ctx, BulkRequest{
+		Action:   BulkLabel,
+		Selector: &BulkSelector{Kind: "Deployment", Namespace: "shop", LabelSelector: "tier=front"},
+		Values:   map[string]*string{"team": &team, "tier": nil},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Total != 1 || result.Succeeded != 1 || result.Results[0].Name != "web" || result.Results[0].Namespace != "shop" {
+		t.Fatalf("result = %+v", result)
+	}
+
+	web, err := client.Resource(deploymentsGVR).Namespace("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if labels := web.GetLabels(); labels["team"] != "checkout" || labels["tier"] != "" {
+		t.Errorf("labels = %v, want team added and tier removed", labels)
+	}
+
+	// Selecting in a namespace without edit permission is rejected before listing
+	_, err = svc.Run(ctx, BulkRequest{Action: BulkDelete, Selector: &BulkSelector{Kind: "Deployment", Namespace: "other"}})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("Run() error = %v, want %v", err, ErrForbidden)
+	}
+	_, err = svc.Run(ctx, BulkRequest{Action: BulkDelete, Selector: &BulkSelector{Kind: "Deployment", Namespace: "shop", LabelSelector: "tier in (front"}})
+	if !errors.Is(err, ErrInvalidBulkRequest) {
+		t.Errorf("Run() error = %v, want %v", err, ErrInvalidBulkRequest)
+	}
+}
+
+func TestBulkService_Workloads(t *testing.T) {
+	repo := &mockWorkloadRepository{replicas: 2}
+	svc, _ := newBulkTestService(repo)
+	ctx := ctxWithPermissions(map[string]string{"shop": "edit"})
+
+	result, err := svc.Run(ctx, BulkRequest{Action: BulkScale, Delta: 1, Concurrency: 1, Targets: []BulkTarget{
+		{Kind: "Deployment", Namespace: "shop", Name: "web"},
+		{Kind: "DaemonSet", Namespace: "shop", Name: "agent"},
+	}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if scale := result.Results[0].Scale; scale == nil || scale.Previous != 2 || scale.Replicas != 3 {
+		t.Errorf("results[0] = %+v", result.Results[0])
+	}
+	if !errors.Is(result.Results[1].Err, ErrScaleUnsupported) {
+		t.Errorf("results[1] = %+v, want %v", result.Results[1], ErrScaleUnsupported)
+	}
+
+	result, err = svc.Run(ctx, BulkRequest{Action: BulkRestart, Targets: []BulkTarget{{Kind: "StatefulSet", Namespace: "shop", Name: "db"}}})
+	if err != nil || !result.Results[0].Success || repo.patchedGVR.Resource != "statefulsets" {
+		t.Errorf("restart: result = %+v, err = %v, patched %v", result, err, repo.patchedGVR)
+	}
+}
+
+// concurrencyRepo tracks the peak number of concurrent Delete calls
+type concurrencyRepo struct {
+	ResourceRepository
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (r *concurrencyRepo) Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, namespaced bool, options metav1.DeleteOptions) error {
+	r.mu.Lock()
+	r.running++
+	if r.running > r.peak {
+		r.peak = r.running
+	}
+	r.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	r.mu.Lock()
+	r.running--
+	r.mu.Unlock()
+	return nil
+}
+
+func TestBulkService_Concurrency(t *testing.T) {
+	targets := make([]BulkTarget, 20)
+	for i := range targets {
+		targets[i] = BulkTarget{Kind: "Job", Namespace: "batch", Name: "etl"}
+	}
+	ctx := ctxWithPermissions(map[string]string{"batch": "edit"})
+
+	for _, tt := range []struct {
+		concurrency int
+		wantPeak    int
+	}{{0, defaultBulkConcurrency}, {3, 3}, {100, maxBulkConcurrency}} {
+		repo := &concurrencyRepo{}
+		svc := NewBulkService(repo, staticGVRResolver{}, nil)
+
+		result, err := svc.Run(ctx, BulkRequest{Action: BulkDelete, Targets: targets, Concurrency: tt.concurrency})
+		if err != nil || result.Succeeded != len(targets) {
+			t.Fatalf("Run() = %+v, %v", result, err)
+		}
+		if repo.peak < 2 || repo.peak > tt.wantPeak {
+			t.Errorf("concurrency %d: peak %d, want at most %d", tt.concurrency, repo.peak, tt.wantPeak)
+		}
+	}
+}
+
+func TestBulkRequest_Validate(t *testing.T) {
+	target := []BulkTarget{{Kind: "Deployment", Namespace: "shop", Name: "web"}}
+	value, invalid := "v1", "not a label value!"
+	negative := int32(-1)
+
+	tests := []struct {
+		name    string
+		req     BulkRequest
+		wantErr bool
+	}{
+		{"delete", BulkRequest{Action: BulkDelete, Targets: target}, false},
+		{"unknown action", BulkRequest{Action: "drain", Targets: target}, true},
+		{"no targets", BulkRequest{Action: BulkDelete}, true},
+		{"targets and selector", BulkRequest{Action: BulkDelete, Targets: target, Selector: &BulkSelector{Kind: "Pod"}}, true},
+		{"too many targets", BulkRequest{Action: BulkDelete, Targets: make([]BulkTarget, maxBulkTargets+1)}, true},
+		{"negative concurrency", BulkRequest{Action: BulkDelete, Targets: target, Concurrency: -1}, true},
+		{"scale delta", BulkRequest{Action: BulkScale, Targets: target, Delta: -1}, false},
+		{"scale without amount", BulkRequest{Action: BulkScale, Targets: target}, true},
+		{"scale delta and replicas", BulkRequest{Action: BulkScale, Targets: target, Delta: 1, Replicas: &negative}, true},
+		{"scale negative replicas", BulkRequest{Action: BulkScale, Targets: target, Replicas: &negative}, true},
+		{"scale delta out of range", BulkRequest{Action: BulkScale, Targets: target, Delta: math.MinInt32}, true},
+		{"label", BulkRequest{Action: BulkLabel, Targets: target, Values: map[string]*string{"example.com/team": &value}}, false},
+		{"label without values", BulkRequest{Action: BulkLabel, Targets: target}, true},
+		{"label invalid key", BulkRequest{Action: BulkLabel, Targets: target, Values: map[string]*string{"bad key": &value}}, true},
+		{"label invalid value", BulkRequest{Action: BulkLabel, Targets: target, Values: map[string]*string{"team": &invalid}}, true},
+		{"annotation free-form value", BulkRequest{Action: BulkAnnotate, Targets: target, Values: map[string]*string{"note": &invalid}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidBulkRequest) {
+				t.Errorf("validate() error = %v, want %v", err, ErrInvalidBulkRequest)
+			}
+		})
+	}
+}
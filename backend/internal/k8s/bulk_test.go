@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/cluster"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestBulkOperationHandler(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{jobsGVR: "JobList"},
+		bulkObject("batch/v1", "Job", "batch", "etl-1", nil),
+	)
+	handlers := &models.Handlers{
+		Clients:  map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicClient},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+
+	post := func(body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		service.BulkOperation(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/bulk", bytes.NewBufferString(body)), "batch"))
+		return rr
+	}
+
+	rr := post(`{"action":"delete","targets":[{"kind":"Job","namespace":"batch","name":"etl-1"},{"kind":"Job","namespace":"batch","name":"etl-2"},{"kind":"Job","namespace":"prod","name":"etl-3"}]}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result BulkResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Succeeded != 1 || result.Failed != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusNotFound, http.StatusForbidden} {
+		if got := result.Results[i].Status; got != want {
+			t.Errorf("results[%d].status = %d, want %d", i, got, want)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"invalid body", `{`, http.StatusBadRequest},
+		{"unknown action", `{"action":"drain","targets":[{"kind":"Job","namespace":"batch","name":"etl-1"}]}`, http.StatusBadRequest},
+		{"invalid name", `{"action":"delete","targets":[{"kind":"Job","namespace":"batch","name":"Bad_Name"}]}`, http.StatusBadRequest},
+		{"selector without permission", `{"action":"restart","selector":{"kind":"Deployment","namespace":"prod"}}`, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := post(tt.body); rr.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	rr = httptest.NewRecorder()
+	service.BulkOperation(rr, httptest.NewRequest(http.MethodGet, "/api/bulk", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rr.Code)
+	}
+}
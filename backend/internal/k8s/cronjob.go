@@ -43,6 +43,9 @@ func (s *Service) TriggerCronJob(w http.ResponseWriter, r *http.Request) {
 
 	// Call service to trigger cronjob (business logic layer)
 	jobName, err := cronJobService.TriggerCronJob(ctx, req.Namespace, req.Name)
+	utils.AuditLog(r, "trigger", "CronJob", req.Name, req.Namespace, err == nil, err, map[string]interface{}{
+		"job": jobName,
+	})
 	if err != nil {
 		utils.HandleErrorJSON(w, err, "Failed to trigger cronjob", http.StatusInternalServerError, map[string]interface{}{
 			"namespace": req.Namespace,
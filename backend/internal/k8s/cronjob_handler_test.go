@@ -40,9 +40,9 @@ func (m *MockServiceFactory) CreateNamespaceService(client kubernetes.Interface)
 	return args.Get(0).(*NamespaceService)
 }
 
-func (m *MockServiceFactory) CreateDeploymentService(client kubernetes.Interface) *DeploymentService {
-	args := m.Called(client)
-	return args.Get(0).(*DeploymentService)
+func (m *MockServiceFactory) CreateWorkloadService(dynamicClient dynamic.Interface, client kubernetes.Interface) *WorkloadService {
+	args := m.Called(dynamicClient, client)
+	return args.Get(0).(*WorkloadService)
 }
 
 func (m *MockServiceFactory) CreateCronJobService(client kubernetes.Interface) *CronJobService {
@@ -60,6 +60,21 @@ func (m *MockServiceFactory) CreateWatchService() *WatchService {
 	return args.Get(0).(*WatchService)
 }
 
+func (m *MockServiceFactory) CreateRolloutService(client kubernetes.Interface) *RolloutService {
+	args := m.Called(client)
+	return args.Get(0).(*RolloutService)
+}
+
+func (m *MockServiceFactory) CreateBulkService(dynamicClient dynamic.Interface, client kubernetes.Interface) *BulkService {
+	args := m.Called(dynamicClient, client)
+	return args.Get(0).(*BulkService)
+}
+
+func (m *MockServiceFactory) CreateExportService(dynamicClient dynamic.Interface, client kubernetes.Interface) *ExportService {
+	args := m.Called(dynamicClient, client)
+	return args.Get(0).(*ExportService)
+}
+
 // MockCronJobRepository for testing handler integration
 type MockRefCronJobRepository struct {
 	mock.Mock
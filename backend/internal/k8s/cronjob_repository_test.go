@@ -54,11 +54,20 @@ func (f *stubFactory) CreateNamespaceService(kubernetes.Interface) *NamespaceSer
 func (f *stubFactory) CreateClusterStatsService(kubernetes.Interface) *ClusterStatsService {
 	return nil
 }
-func (f *stubFactory) CreateDeploymentService(kubernetes.Interface) *DeploymentService { return nil }
+func (f *stubFactory) CreateWorkloadService(dynamic.Interface, kubernetes.Interface) *WorkloadService {
+	return nil
+}
 func (f *stubFactory) CreateCronJobService(kubernetes.Interface) *CronJobService {
 	return f.cronService
 }
-func (f *stubFactory) CreateWatchService() *WatchService { return nil }
+func (f *stubFactory) CreateWatchService() *WatchService                         { return nil }
+func (f *stubFactory) CreateRolloutService(kubernetes.Interface) *RolloutService { return nil }
+func (f *stubFactory) CreateBulkService(dynamic.Interface, kubernetes.Interface) *BulkService {
+	return nil
+}
+func (f *stubFactory) CreateExportService(dynamic.Interface, kubernetes.Interface) *ExportService {
+	return nil
+}
 
 func TestK8sCronJobRepository(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(&batchv1.CronJob{
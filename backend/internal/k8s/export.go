@@ -0,0 +1,104 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// exportTimeout bounds a whole export; listing every kind of a large namespace and building the
+// archive can take longer than the usual request timeout
+const exportTimeout = 2 * time.Minute
+
+// ExportNamespace handles HTTP GET requests to download the resources of a namespace as clean,
+// re-appliable manifests: a multi-document YAML file, or a tar.gz or zip archive with one file per object.
+// Query parameters: namespace (required), kinds (comma-separated, default all), labelSelector, format (yaml, tar, zip).
+// Secret values are redacted unless the user has edit permission on the namespace.
+func (s *Service) ExportNamespace(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	namespace := query.Get("namespace")
+	if namespace == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing namespace")
+		return
+	}
+	if err := utils.ValidateK8sName(namespace, "namespace"); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format, err := ParseExportFormat(query.Get("format"))
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var kinds []string
+	for _, kind := range strings.Split(query.Get("kinds"), ",") {
+		if kind = strings.TrimSpace(kind); kind != "" {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dynamicClient, err := s.clusterService.GetDynamicClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	exportService := s.serviceFactory.CreateExportService(dynamicClient, client)
+
+	// The export may outlive both the default operation timeout and the server write timeout
+	ctx, cancel := context.WithTimeout(r.Context(), exportTimeout)
+	defer cancel()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout + 10*time.Second))
+
+	result, err := exportService.Export(ctx, ExportRequest{Namespace: namespace, Kinds: kinds, LabelSelector: query.Get("labelSelector")})
+	if err != nil {
+		utils.AuditLog(r, "export", "Namespace", namespace, namespace, false, err, nil)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			utils.ErrorResponse(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, ErrInvalidExportRequest), errors.Is(err, ErrNamespaceRequired):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		default:
+			utils.HandleErrorJSON(w, err, "Failed to export namespace", http.StatusInternalServerError, map[string]interface{}{
+				"namespace": namespace,
+			})
+		}
+		return
+	}
+
+	// Encode fully before writing so that an encoding error can still be reported
+	var buf bytes.Buffer
+	if err := WriteExport(&buf, result, format); err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to encode export", http.StatusInternalServerError, map[string]interface{}{
+			"namespace": namespace,
+		})
+		return
+	}
+
+	utils.AuditLog(r, "export", "Namespace", namespace, namespace, true, nil, map[string]interface{}{
+		"format":          string(format),
+		"objects":         len(result.Objects),
+		"secretsRedacted": result.Redacted,
+	})
+
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.FileName(namespace, time.Now())))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// ExportFormat is the encoding of a namespace export
+type ExportFormat string
+
+const (
+	ExportYAML ExportFormat = "yaml" // a single multi-document YAML file
+	ExportTar  ExportFormat = "tar"  // a gzip-compressed tarball with one file per object
+	ExportZip  ExportFormat = "zip"  // a zip archive with one file per object
+)
+
+// ContentType returns the MIME type of the format
+func (f ExportFormat) ContentType() string {
+	switch f {
+	case ExportTar:
+		return "application/gzip"
+	case ExportZip:
+		return "application/zip"
+	default:
+		return "application/yaml"
+	}
+}
+
+// FileName returns the download name of an export of the namespace in the format
+func (f ExportFormat) FileName(namespace string, at time.Time) string {
+	ext := map[ExportFormat]string{ExportYAML: "yaml", ExportTar: "tar.gz", ExportZip: "zip"}[f]
+	return fmt.Sprintf("%s-%s.%s", namespace, at.UTC().Format("20060102-150405"), ext)
+}
+
+// ParseExportFormat parses a format name; empty means YAML
+func ParseExportFormat(value string) (ExportFormat, error) {
+	switch format := ExportFormat(strings.ToLower(value)); format {
+	case "":
+		return ExportYAML, nil
+	case ExportYAML, ExportTar, ExportZip:
+		return format, nil
+	default:
+		return "", fmt.Errorf("%w: unknown format %q (use yaml, tar or zip)", ErrInvalidExportRequest, value)
+	}
+}
+
+// WriteExport encodes an export in the given format
+func WriteExport(w io.Writer, result *ExportResult, format ExportFormat) error {
+	switch format {
+	case ExportTar:
+		return writeExportTar(w, result)
+	case ExportZip:
+		return writeExportZip(w, result)
+	default:
+		return writeExportYAML(w, result)
+	}
+}
+
+func writeExportYAML(w io.Writer, result *ExportResult) error {
+	var buf bytes.Buffer
+	for _, line := range exportNotes(result) {
+		buf.WriteString("# " + line + "\n")
+	}
+	for _, exported := range result.Objects {
+		data, err := yaml.Marshal(exported.Object.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s/%s: %w", exported.Object.GetKind(), exported.Object.GetName(), err)
+		}
+		buf.WriteString("---\n")
+		buf.Write(data)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeExportTar(w io.Writer, result *ExportResult) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	err := eachExportFile(result, func(name string, data []byte) error {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: now}); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeExportZip(w io.Writer, result *ExportResult) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	err := eachExportFile(result, func(name string, data []byte) error {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		_, err = f.Write(data)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// eachExportFile calls fn with the path and content of every file of an archive export:
+// <namespace>/<resource>[.<group>]/<name>.yaml, plus EXPORT-NOTES.txt when something was redacted or skipped
+func eachExportFile(result *ExportResult, fn func(name string, data []byte) error) error {
+	if notes := exportNotes(result); len(notes) > 1 {
+		if err := fn(path.Join(result.Namespace, "EXPORT-NOTES.txt"), []byte(strings.Join(notes, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	for _, exported := range result.Objects {
+		data, err := yaml.Marshal(exported.Object.Object)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s/%s: %w", exported.Object.GetKind(), exported.Object.GetName(), err)
+		}
+		if err := fn(path.Join(result.Namespace, exported.Resource.GroupResource().String(), exported.Object.GetName()+".yaml"), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportNotes describes an export: its size, then any redaction and skipped resource types
+func exportNotes(result *ExportResult) []string {
+	notes := []string{fmt.Sprintf("Export of namespace %s: %d objects", result.Namespace, len(result.Objects))}
+	if result.Redacted {
+		notes = append(notes, fmt.Sprintf("Secret values are redacted (annotation %s); edit permission is required to export them", redactedAnnotation))
+	}
+	for _, skip := range result.Skipped {
+		notes = append(notes, fmt.Sprintf("Skipped %s: %s", skip.Resource, skip.Reason))
+	}
+	return notes
+}
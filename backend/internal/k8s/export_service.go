@@ -0,0 +1,300 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+)
+
+// redactedAnnotation marks a Secret whose values were blanked during an export
+const redactedAnnotation = "dkonsole.io/export-redacted"
+
+// ErrInvalidExportRequest reports a malformed export request
+var ErrInvalidExportRequest = errors.New("invalid export request")
+
+// Resources that are derived from other objects or only record runtime state; they are never exported
+// when walking a namespace, since re-applying them is meaningless or harmful
+var exportSkippedResources = map[schema.GroupResource]bool{
+	{Group: "", Resource: "events"}:                                        true,
+	{Group: "events.k8s.io", Resource: "events"}:                           true,
+	{Group: "", Resource: "endpoints"}:                                     true,
+	{Group: "discovery.k8s.io", Resource: "endpointslices"}:                true,
+	{Group: "apps", Resource: "controllerrevisions"}:                       true,
+	{Group: "coordination.k8s.io", Resource: "leases"}:                     true,
+	{Group: "metrics.k8s.io", Resource: "pods"}:                            true,
+	{Group: "authorization.k8s.io", Resource: "localsubjectaccessreviews"}: true,
+}
+
+// Kinds exported first so that objects referencing them apply cleanly; other kinds follow alphabetically
+var exportKindOrder = []string{
+	"ResourceQuota", "LimitRange", "ServiceAccount", "Secret", "ConfigMap", "PersistentVolumeClaim",
+	"Role", "RoleBinding", "Service",
+}
+
+// ExportRequest selects what a namespace export contains
+type ExportRequest struct {
+	Namespace     string
+	Kinds         []string // kinds to export; empty exports every listable namespaced kind
+	LabelSelector string
+}
+
+// ExportedObject is a cleaned object of an export
+type ExportedObject struct {
+	Resource schema.GroupVersionResource
+	Object   *unstructured.Unstructured
+	Redacted bool // secret values were blanked
+}
+
+// ExportSkip reports a resource type that could not be listed during an export
+type ExportSkip struct {
+	Resource string
+	Reason   string
+}
+
+// ExportResult holds the objects of a namespace export in apply order
+type ExportResult struct {
+	Namespace string
+	Objects   []ExportedObject
+	Skipped   []ExportSkip
+	Redacted  bool // some Secret values were redacted because the user lacks edit permission
+}
+
+// ExportService exports the resources of a namespace as clean, re-appliable manifests
+type ExportService struct {
+	resourceRepo ResourceRepository
+	gvrResolver  GVRResolver
+	discovery    discovery.DiscoveryInterface
+}
+
+// NewExportService creates a new ExportService
+func NewExportService(resourceRepo ResourceRepository, gvrResolver GVRResolver, discoveryClient discovery.DiscoveryInterface) *ExportService {
+	return &ExportService{
+		resourceRepo: resourceRepo,
+		gvrResolver:  gvrResolver,
+		discovery:    discoveryClient,
+	}
+}
+
+// Export lists the selected kinds (or every listable namespaced kind) in a namespace and strips
+// status and server-populated fields from each object. Objects managed by a controller, service
+// account tokens and other generated objects are left out.
+// Requires access to the namespace; Secret values are redacted unless the user has edit permission.
+func (s *ExportService) Export(ctx context.Context, req ExportRequest) (*ExportResult, error) {
+	if req.Namespace == "" {
+		return nil, fmt.Errorf("%w", ErrNamespaceRequired)
+	}
+	if _, err := labels.Parse(req.LabelSelector); err != nil {
+		return nil, fmt.Errorf("%w: invalid label selector: %v", ErrInvalidExportRequest, err)
+	}
+
+	hasAccess, err := permissions.HasNamespaceAccess(ctx, req.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if !hasAccess {
+		return nil, fmt.Errorf("%w: access denied to namespace: %s", ErrForbidden, req.Namespace)
+	}
+	canEdit, err := permissions.CanPerformAction(ctx, req.Namespace, "edit")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check permissions: %w", err)
+	}
+
+	resources, err := s.exportResources(ctx, req.Kinds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ExportResult{Namespace: req.Namespace}
+	for _, gvr := range resources {
+		list, err := s.resourceRepo.List(ctx, gvr, req.Namespace, true, metav1.ListOptions{LabelSelector: req.LabelSelector})
+		if err != nil {
+			// Kinds the cluster credentials cannot list, or that disappeared since discovery, are reported, not fatal
+			if apierrors.IsForbidden(err) || apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+				result.Skipped = append(result.Skipped, ExportSkip{Resource: gvr.GroupResource().String(), Reason: err.Error()})
+				continue
+			}
+			return nil, fmt.Errorf("failed to list %s: %w", gvr.GroupResource(), err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if !exportable(obj) {
+				continue
+			}
+			cleanExportedObject(obj)
+			exported := ExportedObject{Resource: gvr, Object: obj}
+			if obj.GetKind() == "Secret" && !canEdit {
+				redactSecret(obj)
+				exported.Redacted, result.Redacted = true, true
+			}
+			result.Objects = append(result.Objects, exported)
+		}
+	}
+
+	sortExportedObjects(result.Objects)
+	return result, nil
+}
+
+// exportResources resolves the requested kinds, or discovers every namespaced kind that can be listed and created
+func (s *ExportService) exportResources(ctx context.Context, kinds []string) ([]schema.GroupVersionResource, error) {
+	if len(kinds) > 0 {
+		seen := make(map[schema.GroupVersionResource]bool)
+		var resources []schema.GroupVersionResource
+		for _, kind := range kinds {
+			gvr, meta, err := s.gvrResolver.ResolveGVR(ctx, models.NormalizeKind(kind), "", "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve kind %s: %w", kind, err)
+			}
+			if !meta.Namespaced {
+				return nil, fmt.Errorf("%w: %s is not namespaced", ErrInvalidExportRequest, kind)
+			}
+			if !seen[gvr] {
+				seen[gvr] = true
+				resources = append(resources, gvr)
+			}
+		}
+		return resources, nil
+	}
+
+	lists, err := discovery.ServerPreferredNamespacedResources(s.discovery)
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list", "get", "create"}}, lists)
+
+	var resources []schema.GroupVersionResource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, ar := range list.APIResources {
+			gvr := gv.WithResource(ar.Name)
+			if strings.Contains(ar.Name, "/") || exportSkippedResources[gvr.GroupResource()] {
+				continue
+			}
+			resources = append(resources, gvr)
+		}
+	}
+	return resources, nil
+}
+
+// exportable reports whether an object belongs in an export: objects a controller manages
+// are recreated by their owner, and a few objects are generated for every namespace
+func exportable(obj *unstructured.Unstructured) bool {
+	if metav1.GetControllerOfNoCopy(obj) != nil {
+		return false
+	}
+	switch obj.GetKind() {
+	case "Secret":
+		secretType, _, _ := unstructured.NestedString(obj.Object, "type")
+		return secretType != "kubernetes.io/service-account-token"
+	case "ConfigMap":
+		return obj.GetName() != "kube-root-ca.crt"
+	case "ServiceAccount":
+		return obj.GetName() != "default"
+	}
+	return true
+}
+
+// cleanExportedObject strips status and the fields the API server or controllers populate,
+// leaving a manifest that can be applied to a fresh namespace or cluster
+func cleanExportedObject(obj *unstructured.Unstructured) {
+	removeServerMetadata(obj)
+	for _, field := range []string{"generation", "selfLink", "deletionTimestamp", "deletionGracePeriodSeconds", "ownerReferences"} {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+	unstructured.RemoveNestedField(obj.Object, "status")
+
+	annotations := obj.GetAnnotations()
+	for _, key := range []string{
+		"kubectl.kubernetes.io/last-applied-configuration",
+		"deployment.kubernetes.io/revision",
+		"pv.kubernetes.io/bind-completed",
+		"pv.kubernetes.io/bound-by-controller",
+		"volume.beta.kubernetes.io/storage-provisioner",
+		"volume.kubernetes.io/storage-provisioner",
+		"volume.kubernetes.io/selected-node",
+	} {
+		delete(annotations, key)
+	}
+	if len(annotations) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	} else {
+		obj.SetAnnotations(annotations)
+	}
+
+	switch obj.GetKind() {
+	case "Service":
+		// Cluster IPs are allocated by the cluster; headless services keep "None"
+		if clusterIP, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); clusterIP != "None" {
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIP")
+			unstructured.RemoveNestedField(obj.Object, "spec", "clusterIPs")
+		}
+		unstructured.RemoveNestedField(obj.Object, "spec", "healthCheckNodePort")
+	case "PersistentVolumeClaim":
+		unstructured.RemoveNestedField(obj.Object, "spec", "volumeName")
+	case "Job":
+		// Generated selectors carry the uid of the Job and are rejected on create
+		if manual, _, _ := unstructured.NestedBool(obj.Object, "spec", "manualSelector"); !manual {
+			unstructured.RemoveNestedField(obj.Object, "spec", "selector")
+			for _, key := range []string{"controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"} {
+				unstructured.RemoveNestedField(obj.Object, "spec", "template", "metadata", "labels", key)
+			}
+		}
+	}
+}
+
+// redactSecret blanks every value of a Secret, keeping its keys, and marks it as redacted
+func redactSecret(obj *unstructured.Unstructured) {
+	for _, field := range []string{"data", "stringData"} {
+		values, found, _ := unstructured.NestedMap(obj.Object, field)
+		if !found {
+			continue
+		}
+		for key := range values {
+			values[key] = ""
+		}
+		_ = unstructured.SetNestedMap(obj.Object, values, field)
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[redactedAnnotation] = "true"
+	obj.SetAnnotations(annotations)
+}
+
+// sortExportedObjects orders objects so that dependencies come first, then by kind and name
+func sortExportedObjects(objects []ExportedObject) {
+	rank := func(kind string) int {
+		for i, k := range exportKindOrder {
+			if k == kind {
+				return i
+			}
+		}
+		return len(exportKindOrder)
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		a, b := objects[i].Object, objects[j].Object
+		if ra, rb := rank(a.GetKind()), rank(b.GetKind()); ra != rb {
+			return ra < rb
+		}
+		if a.GetKind() != b.GetKind() {
+			return a.GetKind() < b.GetKind()
+		}
+		return a.GetName() < b.GetName()
+	})
+}
@@ -0,0 +1,200 @@
+package k8s
+
+import (
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func exportFixture(apiVersion, kind, name string, fields map[string]interface{}) runtime.Object {
+	obj := map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"namespace":         "shop",
+			"uid":               "0b7c",
+			"resourceVersion":   "42",
+			"creationTimestamp": "2024-01-01T00:00:00Z",
+			"managedFields":     []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"annotations":       map[string]interface{}{"kubectl.kubernetes.io/last-applied-configuration": "{}"},
+		},
+		"status": map[string]interface{}{"phase": "Active"},
+	}
+	for k, v := range fields {
+		obj[k] = v
+	}
+	return &unstructured.Unstructured{Object: obj}
+}
+
+// newExportTestService returns an ExportService over a cluster with a typical namespace
+func newExportTestService() *ExportService {
+	gvrs := map[schema.GroupVersionResource]string{
+		{Version: "v1", Resource: "configmaps"}:                    "ConfigMapList",
+		{Version: "v1", Resource: "secrets"}:                       "SecretList",
+		{Version: "v1", Resource: "services"}:                      "ServiceList",
+		{Version: "v1", Resource: "events"}:                        "EventList",
+		{Group: "apps", Version: "v1", Resource: "deployments"}:    "DeploymentList",
+		{Group: "apps", Version: "v1", Resource: "replicasets"}:    "ReplicaSetList",
+		{Group: "batch", Version: "v1", Resource: "jobs"}:          "JobList",
+		{Group: "example.com", Version: "v1", Resource: "widgets"}: "WidgetList",
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), gvrs,
+		exportFixture("v1", "ConfigMap", "app-config", map[string]interface{}{"data": map[string]interface{}{"mode": "prod"}}),
+		exportFixture("v1", "ConfigMap", "kube-root-ca.crt", nil),
+		exportFixture("v1", "Secret", "db-password", map[string]interface{}{"type": "Opaque", "data": map[string]interface{}{"password": "czNjcjN0"}}),
+		exportFixture("v1", "Secret", "builder-token", map[string]interface{}{"type": "kubernetes.io/service-account-token"}),
+		exportFixture("v1", "Service", "web", map[string]interface{}{"spec": map[string]interface{}{"clusterIP": "10.0.0.12", "clusterIPs": []interface{}{"10.0.0.12"}}}),
+		exportFixture("v1", "Service", "db", map[string]interface{}{"spec": map[string]interface{}{"clusterIP": "None"}}),
+		exportFixture("v1", "Event", "web.17a", nil),
+		exportFixture("apps/v1", "Deployment", "web", nil),
+		exportFixture("apps/v1", "ReplicaSet", "web-5d9f", map[string]interface{}{"metadata": map[string]interface{}{
+			"name": "web-5d9f", "namespace": "shop",
+			"ownerReferences": []interface{}{map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web", "uid": "1", "controller": true}},
+		}}),
+		exportFixture("batch/v1", "Job", "migrate", map[string]interface{}{"spec": map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"controller-uid": "9a1"}},
+			"template": map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"controller-uid": "9a1", "app": "migrate"}}},
+		}}),
+		exportFixture("example.com/v1", "Widget", "gizmo", nil),
+	)
+	dynamicClient.PrependReactor("list", "widgets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "example.com", Resource: "widgets"}, "", errors.New("RBAC denied"))
+	})
+
+	client := k8sfake.NewSimpleClientset()
+	verbs := metav1.Verbs{"get", "list", "create", "delete"}
+	client.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: verbs},
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: verbs},
+			{Name: "services", Kind: "Service", Namespaced: true, Verbs: verbs},
+			{Name: "services/status", Kind: "Service", Namespaced: true, Verbs: metav1.Verbs{"get", "patch"}},
+			{Name: "events", Kind: "Event", Namespaced: true, Verbs: verbs},
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: verbs},
+			{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: verbs},
+			{Name: "replicasets", Kind: "ReplicaSet", Namespaced: true, Verbs: verbs},
+		}},
+		{GroupVersion: "batch/v1", APIResources: []metav1.APIResource{
+			{Name: "jobs", Kind: "Job", Namespaced: true, Verbs: verbs},
+		}},
+		{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+			{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: verbs},
+		}},
+	}
+
+	return NewExportService(NewK8sResourceRepository(dynamicClient), NewK8sGVRResolver(), client.Discovery())
+}
+
+func exportedNames(result *ExportResult) []string {
+	var names []string
+	for _, exported := range result.Objects {
+		names = append(names, exported.Object.GetKind()+"/"+exported.Object.GetName())
+	}
+	return names
+}
+
+func TestExportService_Export(t *testing.T) {
+	svc := newExportTestService()
+
+	result, err := svc.Export(ctxWithPermissions(map[string]string{"shop": "edit"}), ExportRequest{Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	want := []string{"Secret/db-password", "ConfigMap/app-config", "Service/db", "Service/web", "Deployment/web", "Job/migrate"}
+	if got := exportedNames(result); len(got) != len(want) {
+		t.Fatalf("exported %v, want %v", got, want)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("exported %v, want %v", got, want)
+			}
+		}
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].Resource != "widgets.example.com" {
+		t.Errorf("skipped = %+v", result.Skipped)
+	}
+	if result.Redacted {
+		t.Error("secrets redacted for a user with edit permission")
+	}
+
+	for _, exported := range result.Objects {
+		obj := exported.Object
+		for _, field := range [][]string{{"status"}, {"metadata", "uid"}, {"metadata", "resourceVersion"}, {"metadata", "managedFields"}, {"metadata", "creationTimestamp"}, {"metadata", "annotations"}} {
+			if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, field...); found {
+				t.Errorf("%s/%s still has %v", obj.GetKind(), obj.GetName(), field)
+			}
+		}
+		switch obj.GetKind() + "/" + obj.GetName() {
+		case "Service/web":
+			if _, found, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); found {
+				t.Error("clusterIP of Service/web was kept")
+			}
+		case "Service/db":
+			if ip, _, _ := unstructured.NestedString(obj.Object, "spec", "clusterIP"); ip != "None" {
+				t.Error("headless clusterIP of Service/db was removed")
+			}
+		case "Job/migrate":
+			labels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+			if _, found, _ := unstructured.NestedMap(obj.Object, "spec", "selector"); found || labels["controller-uid"] != "" || labels["app"] != "migrate" {
+				t.Errorf("Job/migrate selector or labels not cleaned: %v", obj.Object["spec"])
+			}
+		case "Secret/db-password":
+			if password, _, _ := unstructured.NestedString(obj.Object, "data", "password"); password != "czNjcjN0" {
+				t.Errorf("secret data = %q", password)
+			}
+		}
+	}
+}
+
+func TestExportService_Export_Permissions(t *testing.T) {
+	svc := newExportTestService()
+
+	result, err := svc.Export(ctxWithPermissions(map[string]string{"shop": "view"}), ExportRequest{Namespace: "shop", Kinds: []string{"Secret"}})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if len(result.Objects) != 1 || !result.Redacted || !result.Objects[0].Redacted {
+		t.Fatalf("result = %+v", result)
+	}
+	secret := result.Objects[0].Object
+	if password, found, _ := unstructured.NestedString(secret.Object, "data", "password"); !found || password != "" {
+		t.Errorf("secret data not redacted: %v", secret.Object["data"])
+	}
+	if secret.GetAnnotations()[redactedAnnotation] != "true" {
+		t.Errorf("annotations = %v", secret.GetAnnotations())
+	}
+
+	if _, err := svc.Export(ctxWithPermissions(map[string]string{"other": "edit"}), ExportRequest{Namespace: "shop"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("Export() without access error = %v, want %v", err, ErrForbidden)
+	}
+	if _, err := svc.Export(ctxWithPermissions(map[string]string{"shop": "edit"}), ExportRequest{Namespace: "shop", Kinds: []string{"Node"}}); !errors.Is(err, ErrInvalidExportRequest) {
+		t.Errorf("Export() of a cluster-scoped kind error = %v, want %v", err, ErrInvalidExportRequest)
+	}
+}
+
+func TestExportService_Export_LabelSelector(t *testing.T) {
+	svc := newExportTestService()
+	ctx := ctxWithPermissions(map[string]string{"shop": "edit"})
+
+	if _, err := svc.Export(ctx, ExportRequest{Namespace: "shop", LabelSelector: "app in (web"}); !errors.Is(err, ErrInvalidExportRequest) {
+		t.Errorf("Export() error = %v, want %v", err, ErrInvalidExportRequest)
+	}
+	result, err := svc.Export(ctx, ExportRequest{Namespace: "shop", Kinds: []string{"ConfigMap"}, LabelSelector: "app=none"})
+	if err != nil || len(result.Objects) != 0 {
+		t.Errorf("Export() = %v, %v", exportedNames(result), err)
+	}
+}
@@ -0,0 +1,159 @@
+package k8s
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/cluster"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newExportHandlerService() *Service {
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+			{Version: "v1", Resource: "secrets"}:                    "SecretList",
+			{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+		},
+		exportFixture("v1", "ConfigMap", "app-config", nil),
+		exportFixture("v1", "Secret", "db-password", map[string]interface{}{"data": map[string]interface{}{"password": "czNjcjN0"}}),
+		exportFixture("apps/v1", "Deployment", "web", nil),
+	)
+	handlers := &models.Handlers{
+		Clients:  map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicClient},
+	}
+	return NewService(handlers, cluster.NewService(handlers))
+}
+
+func TestExportNamespaceHandler_YAML(t *testing.T) {
+	service := newExportHandlerService()
+
+	rr := httptest.NewRecorder()
+	service.ExportNamespace(rr, viewCtx(httptest.NewRequest(http.MethodGet, "/api/namespaces/export?namespace=shop&kinds=Deployment,Secret,ConfigMap", nil), "shop"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="shop-`) || !strings.HasSuffix(got, `.yaml"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+
+	body := rr.Body.String()
+	if docs := strings.Count(body, "---\n"); docs != 3 {
+		t.Errorf("expected 3 documents, got %d:\n%s", docs, body)
+	}
+	if strings.Contains(body, "czNjcjN0") || !strings.Contains(body, "Secret values are redacted") {
+		t.Errorf("secret not redacted for a viewer:\n%s", body)
+	}
+	if strings.Index(body, "kind: Secret") > strings.Index(body, "kind: Deployment") {
+		t.Errorf("Secret should come before Deployment:\n%s", body)
+	}
+}
+
+func TestExportNamespaceHandler_Archives(t *testing.T) {
+	service := newExportHandlerService()
+	want := []string{"shop/configmaps/app-config.yaml", "shop/deployments.apps/web.yaml", "shop/secrets/db-password.yaml"}
+
+	for _, format := range []string{"tar", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			service.ExportNamespace(rr, editCtx(httptest.NewRequest(http.MethodGet, "/api/namespaces/export?namespace=shop&kinds=Deployment,Secret,ConfigMap&format="+format, nil), "shop"))
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+			}
+
+			files := map[string]string{}
+			if format == "tar" {
+				gz, err := gzip.NewReader(rr.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				tr := tar.NewReader(gz)
+				for {
+					hdr, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					data, _ := io.ReadAll(tr)
+					files[hdr.Name] = string(data)
+				}
+			} else {
+				zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, f := range zr.File {
+					rc, _ := f.Open()
+					data, _ := io.ReadAll(rc)
+					rc.Close()
+					files[f.Name] = string(data)
+				}
+			}
+
+			var names []string
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(want, ",") {
+				t.Fatalf("files = %v, want %v", names, want)
+			}
+			if !strings.Contains(files["shop/secrets/db-password.yaml"], "czNjcjN0") {
+				t.Errorf("secret redacted for an editor: %s", files["shop/secrets/db-password.yaml"])
+			}
+		})
+	}
+}
+
+func TestExportNamespaceHandler_Errors(t *testing.T) {
+	service := newExportHandlerService()
+	tests := []struct {
+		name     string
+		method   string
+		query    string
+		wantCode int
+	}{
+		{"method", http.MethodPost, "namespace=shop", http.StatusMethodNotAllowed},
+		{"missing namespace", http.MethodGet, "", http.StatusBadRequest},
+		{"invalid format", http.MethodGet, "namespace=shop&format=rar", http.StatusBadRequest},
+		{"no access", http.MethodGet, "namespace=prod", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			service.ExportNamespace(rr, viewCtx(httptest.NewRequest(tt.method, "/api/namespaces/export?"+tt.query, nil), "shop"))
+			if rr.Code != tt.wantCode {
+				t.Errorf("expected status %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestExportFormat(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	if got := ExportTar.FileName("shop", at); got != "shop-20240501-123000.tar.gz" {
+		t.Errorf("FileName() = %q", got)
+	}
+	if format, err := ParseExportFormat("ZIP"); err != nil || format != ExportZip || format.ContentType() != "application/zip" {
+		t.Errorf("ParseExportFormat() = %q, %v", format, err)
+	}
+}
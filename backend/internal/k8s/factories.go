@@ -11,9 +11,12 @@ type Factory interface {
 	CreateImportService(dynamicClient dynamic.Interface, client kubernetes.Interface) *ImportService
 	CreateNamespaceService(client kubernetes.Interface) *NamespaceService
 	CreateClusterStatsService(client kubernetes.Interface) *ClusterStatsService
-	CreateDeploymentService(client kubernetes.Interface) *DeploymentService
+	CreateWorkloadService(dynamicClient dynamic.Interface, client kubernetes.Interface) *WorkloadService
 	CreateCronJobService(client kubernetes.Interface) *CronJobService
 	CreateWatchService() *WatchService
+	CreateRolloutService(client kubernetes.Interface) *RolloutService
+	CreateBulkService(dynamicClient dynamic.Interface, client kubernetes.Interface) *BulkService
+	CreateExportService(dynamicClient dynamic.Interface, client kubernetes.Interface) *ExportService
 }
 
 // ServiceFactory provides factory methods for creating business logic services
@@ -51,10 +54,11 @@ func (f *ServiceFactory) CreateClusterStatsService(client kubernetes.Interface)
 	return NewClusterStatsService(statsRepo)
 }
 
-// CreateDeploymentService creates a DeploymentService with the given client
-func (f *ServiceFactory) CreateDeploymentService(client kubernetes.Interface) *DeploymentService {
-	deploymentRepo := NewK8sDeploymentRepository(client)
-	return NewDeploymentService(deploymentRepo)
+// CreateWorkloadService creates a WorkloadService with the given clients
+func (f *ServiceFactory) CreateWorkloadService(dynamicClient dynamic.Interface, client kubernetes.Interface) *WorkloadService {
+	workloadRepo := NewK8sWorkloadRepository(dynamicClient, client)
+	gvrResolver := NewK8sGVRResolverWithDiscovery(client)
+	return NewWorkloadService(workloadRepo, gvrResolver)
 }
 
 // CreateCronJobService creates a CronJobService with the given client
@@ -68,3 +72,22 @@ func (f *ServiceFactory) CreateWatchService() *WatchService {
 	gvrResolver := NewK8sGVRResolver()
 	return NewWatchService(gvrResolver)
 }
+
+// CreateRolloutService creates a RolloutService with the given client
+func (f *ServiceFactory) CreateRolloutService(client kubernetes.Interface) *RolloutService {
+	return NewRolloutService(client)
+}
+
+// CreateBulkService creates a BulkService with the given clients
+func (f *ServiceFactory) CreateBulkService(dynamicClient dynamic.Interface, client kubernetes.Interface) *BulkService {
+	resourceRepo := NewK8sResourceRepository(dynamicClient)
+	gvrResolver := NewK8sGVRResolverWithDiscovery(client)
+	return NewBulkService(resourceRepo, gvrResolver, f.CreateWorkloadService(dynamicClient, client))
+}
+
+// CreateExportService creates an ExportService with the given clients
+func (f *ServiceFactory) CreateExportService(dynamicClient dynamic.Interface, client kubernetes.Interface) *ExportService {
+	resourceRepo := NewK8sResourceRepository(dynamicClient)
+	gvrResolver := NewK8sGVRResolverWithDiscovery(client)
+	return NewExportService(resourceRepo, gvrResolver, client.Discovery())
+}
@@ -25,8 +25,8 @@ func TestServiceFactory_CreateServices(t *testing.T) {
 	if svc := factory.CreateClusterStatsService(k8sClient); svc == nil {
 		t.Fatalf("CreateClusterStatsService returned nil")
 	}
-	if svc := factory.CreateDeploymentService(k8sClient); svc == nil {
-		t.Fatalf("CreateDeploymentService returned nil")
+	if svc := factory.CreateWorkloadService(dynamicClient, k8sClient); svc == nil {
+		t.Fatalf("CreateWorkloadService returned nil")
 	}
 	if svc := factory.CreateCronJobService(k8sClient); svc == nil {
 		t.Fatalf("CreateCronJobService returned nil")
@@ -34,4 +34,13 @@ func TestServiceFactory_CreateServices(t *testing.T) {
 	if svc := factory.CreateWatchService(); svc == nil {
 		t.Fatalf("CreateWatchService returned nil")
 	}
+	if svc := factory.CreateRolloutService(k8sClient); svc == nil {
+		t.Fatalf("CreateRolloutService returned nil")
+	}
+	if svc := factory.CreateBulkService(dynamicClient, k8sClient); svc == nil {
+		t.Fatalf("CreateBulkService returned nil")
+	}
+	if svc := factory.CreateExportService(dynamicClient, k8sClient); svc == nil {
+		t.Fatalf("CreateExportService returned nil")
+	}
 }
@@ -1,10 +1,13 @@
 package k8s
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -29,6 +32,7 @@ type Service struct {
 	handlers       *models.Handlers
 	clusterService ClusterService
 	serviceFactory Factory
+	resourceCache  ResourceCache // shared informer cache for lists and watches (optional)
 }
 
 // NewService creates a new Kubernetes service with the provided handlers and cluster service.
@@ -40,6 +44,11 @@ func NewService(h *models.Handlers, cs ClusterService) *Service {
 	}
 }
 
+// SetResourceCache serves resource lists and watches from a shared informer cache
+func (s *Service) SetResourceCache(cache ResourceCache) {
+	s.resourceCache = cache
+}
+
 // GetNamespaces handles HTTP GET requests to retrieve all Kubernetes namespaces.
 // Returns a JSON array of namespace objects.
 //
@@ -87,13 +96,20 @@ func (s *Service) GetNamespaces(w http.ResponseWriter, r *http.Request) {
 // Query parameters:
 //   - kind: The resource kind (e.g., "Pod", "Deployment", "Service")
 //   - namespace: The namespace to filter by, or "all" for all namespaces
+//   - labelSelector, fieldSelector: Passed to the Kubernetes List
+//   - sort: "name", "created" or "status"; order: "asc" (default) or "desc"
+//   - status: Comma-separated statuses to keep (pods also match container reasons like CrashLoopBackOff)
+//   - q: Text the resource name must contain
+//   - limit, continue: Page size and the token returned by the previous page
 //
-// Returns a JSON array of resource objects with metadata and status information.
+// Returns a JSON array of resource objects with metadata and status information,
+// or a models.PaginatedResources object when limit or continue is given.
 func (s *Service) GetResources(w http.ResponseWriter, r *http.Request) {
 	// Parse HTTP parameters
-	ns := r.URL.Query().Get("namespace")
-	kind := r.URL.Query().Get("kind")
-	labelSelector := r.URL.Query().Get("labelSelector")
+	query := r.URL.Query()
+	ns := query.Get("namespace")
+	kind := query.Get("kind")
+	labelSelector := query.Get("labelSelector")
 	allNamespaces := ns == "all"
 
 	if kind == "" {
@@ -101,6 +117,27 @@ func (s *Service) GetResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var limit int64
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+	order := strings.ToLower(query.Get("order"))
+	if order != "" && order != "asc" && order != "desc" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "order must be asc or desc")
+		return
+	}
+	var statuses []string
+	for _, status := range strings.Split(query.Get("status"), ",") {
+		if status = strings.TrimSpace(status); status != "" {
+			statuses = append(statuses, status)
+		}
+	}
+
 	// Normalize kind (handle aliases like HPA -> HorizontalPodAutoscaler)
 	normalizedKind := models.NormalizeKind(kind)
 
@@ -115,6 +152,9 @@ func (s *Service) GetResources(w http.ResponseWriter, r *http.Request) {
 
 	// Create service
 	resourceListService := NewResourceListService(s.clusterService, s.handlers.PrometheusURL)
+	if s.resourceCache != nil {
+		resourceListService.SetCache(s.resourceCache)
+	}
 
 	// Create context
 	ctx, cancel := utils.CreateRequestContext(r)
@@ -122,23 +162,39 @@ func (s *Service) GetResources(w http.ResponseWriter, r *http.Request) {
 
 	// Prepare request
 	req := ListResourcesRequest{
+		Cluster:       query.Get("cluster"),
 		Kind:          normalizedKind,
 		Namespace:     ns,
 		AllNamespaces: allNamespaces,
 		LabelSelector: labelSelector,
+		FieldSelector: query.Get("fieldSelector"),
+		Limit:         limit,
+		Continue:      query.Get("continue"),
+		SortBy:        strings.ToLower(query.Get("sort")),
+		SortDesc:      order == "desc",
+		Status:        statuses,
+		Search:        strings.TrimSpace(query.Get("q")),
 		Client:        client,
 		MetricsClient: metricsClient,
 	}
 
 	// Call service to get resources (business logic layer)
 	// The service will filter resources based on permissions
-	resources, err := resourceListService.ListResources(ctx, req)
+	page, err := resourceListService.ListResourcesPage(ctx, req)
 	if err != nil {
 		// Check if it's a permission error
 		if err.Error() == fmt.Sprintf("access denied to namespace: %s", req.Namespace) {
 			utils.ErrorResponse(w, http.StatusForbidden, err.Error())
 			return
 		}
+		if errors.Is(err, ErrInvalidListRequest) || apierrors.IsBadRequest(err) {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+			utils.ErrorResponse(w, http.StatusGone, "The continue token has expired, restart the list")
+			return
+		}
 		utils.HandleErrorJSON(w, err, "Failed to list resources", http.StatusInternalServerError, map[string]interface{}{
 			"namespace":     req.Namespace,
 			"kind":          req.Kind,
@@ -148,17 +204,23 @@ func (s *Service) GetResources(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Write JSON response (HTTP layer)
-	utils.JSONResponse(w, http.StatusOK, resources)
+	if req.Limit > 0 || req.Continue != "" {
+		utils.JSONResponse(w, http.StatusOK, page)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, page.Resources)
 }
 
-// ScaleResource handles HTTP POST requests to scale a Kubernetes Deployment.
+// ScaleResource handles HTTP POST requests to scale a workload through its scale subresource.
 // Query parameters:
-//   - kind: Must be "Deployment"
-//   - name: The deployment name
+//   - kind: Deployment, StatefulSet, ReplicaSet or a custom resource exposing the scale subresource
+//   - apiVersion: Optional group/version, to disambiguate custom resources
+//   - name: The workload name
 //   - namespace: The namespace (defaults to "default" if empty)
 //   - delta: The number of replicas to add or subtract (positive or negative integer)
+//   - replicas: An absolute replica count, used instead of delta
 //
-// Returns the new replica count on success.
+// Returns the previous and new replica counts on success.
 func (s *Service) ScaleResource(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
@@ -172,12 +234,14 @@ func (s *Service) ScaleResource(w http.ResponseWriter, r *http.Request) {
 	}
 
 	kind := r.URL.Query().Get("kind")
+	apiVersion := r.URL.Query().Get("apiVersion")
 	name := r.URL.Query().Get("name")
 	namespace := r.URL.Query().Get("namespace")
 	deltaStr := r.URL.Query().Get("delta")
+	replicasStr := r.URL.Query().Get("replicas")
 
-	if kind != "Deployment" {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Scaling supported only for Deployments")
+	if kind == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing kind")
 		return
 	}
 	if name == "" {
@@ -214,36 +278,80 @@ func (s *Service) ScaleResource(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	delta, err := strconv.Atoi(deltaStr)
-	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid delta: %v", err))
-		return
+	scaleReq := ScaleRequest{Kind: kind, APIVersion: apiVersion, Namespace: namespace, Name: name}
+	if replicasStr != "" {
+		if deltaStr != "" {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Use either delta or replicas, not both")
+			return
+		}
+		replicas, err := strconv.ParseInt(replicasStr, 10, 32)
+		if err != nil || replicas < 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid replicas: %s", replicasStr))
+			return
+		}
+		absolute := int32(replicas)
+		scaleReq.Replicas = &absolute
+	} else {
+		delta, err := strconv.ParseInt(deltaStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid delta: %v", err))
+			return
+		}
+		if delta == 0 {
+			utils.ErrorResponse(w, http.StatusBadRequest, "Delta cannot be zero")
+			return
+		}
+		scaleReq.Delta = int(delta)
 	}
-	if delta == 0 {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Delta cannot be zero")
+
+	dynamicClient, err := s.clusterService.GetDynamicClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	// Create service using factory (dependency injection)
-	deploymentService := s.serviceFactory.CreateDeploymentService(client)
+	workloadService := s.serviceFactory.CreateWorkloadService(dynamicClient, client)
 
 	// Create context
-	ctx, cancel := utils.CreateTimeoutContext()
+	ctx, cancel := utils.CreateRequestContext(r)
 	defer cancel()
 
-	// Call service to scale deployment (business logic layer)
-	newReplicas, err := deploymentService.ScaleDeployment(ctx, namespace, name, delta)
+	// Call service to scale the workload (business logic layer)
+	result, err := workloadService.Scale(ctx, scaleReq)
+	details := map[string]interface{}{"delta": scaleReq.Delta}
+	if scaleReq.Replicas != nil {
+		details = map[string]interface{}{"requestedReplicas": *scaleReq.Replicas}
+	}
+	if result != nil {
+		details["previous"] = result.Previous
+		details["replicas"] = result.Replicas
+	}
+	utils.AuditLog(r, "scale", kind, name, namespace, err == nil, err, details)
 	if err != nil {
-		utils.HandleErrorJSON(w, err, "Failed to scale deployment", http.StatusInternalServerError, map[string]interface{}{
-			"namespace": namespace,
-			"name":      name,
-			"delta":     delta,
-		})
+		writeWorkloadError(w, err, "Failed to scale "+strings.ToLower(kind), namespace, name)
 		return
 	}
 
 	// Write JSON response (HTTP layer)
-	utils.JSONResponse(w, http.StatusOK, map[string]int32{"replicas": newReplicas})
+	utils.JSONResponse(w, http.StatusOK, result)
+}
+
+// writeWorkloadError maps scale and restart errors to HTTP status codes
+func writeWorkloadError(w http.ResponseWriter, err error, message, namespace, name string) {
+	switch {
+	case errors.Is(err, ErrScaleUnsupported), errors.Is(err, ErrRestartUnsupported), errors.Is(err, ErrInvalidReplicas):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case apierrors.IsNotFound(err):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	case apierrors.IsConflict(err):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, message, http.StatusInternalServerError, map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+		})
+	}
 }
 
 // WatchResources is implemented in resource_operations.go
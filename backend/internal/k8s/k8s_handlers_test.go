@@ -10,9 +10,11 @@ import (
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 
@@ -33,65 +35,6 @@ func (s *stubNamespaceRepository) List(ctx context.Context) ([]corev1.Namespace,
 	return s.namespaces, nil
 }
 
-type stubDeploymentRepository struct {
-	replicas  int32
-	getErr    error
-	updateErr error
-}
-
-func (s *stubDeploymentRepository) GetScale(ctx context.Context, namespace, name string) (*autoscalingv1.Scale, error) {
-	if s.getErr != nil {
-		return nil, s.getErr
-	}
-	return &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: s.replicas}}, nil
-}
-
-func (s *stubDeploymentRepository) UpdateScale(ctx context.Context, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
-	if s.updateErr != nil {
-		return nil, s.updateErr
-	}
-	return scale, nil
-}
-
-func (s *stubDeploymentRepository) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
-	return &appsv1.Deployment{}, nil
-}
-
-func (s *stubDeploymentRepository) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	return deployment, nil
-}
-
-type stubRolloutDeploymentRepository struct {
-	getErr    error
-	updateErr error
-}
-
-func (s *stubRolloutDeploymentRepository) GetScale(ctx context.Context, namespace, name string) (*autoscalingv1.Scale, error) {
-	return &autoscalingv1.Scale{}, nil
-}
-
-func (s *stubRolloutDeploymentRepository) UpdateScale(ctx context.Context, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
-	return scale, nil
-}
-
-func (s *stubRolloutDeploymentRepository) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
-	if s.getErr != nil {
-		return nil, s.getErr
-	}
-	return &appsv1.Deployment{
-		Spec: appsv1.DeploymentSpec{
-			Template: corev1.PodTemplateSpec{},
-		},
-	}, nil
-}
-
-func (s *stubRolloutDeploymentRepository) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	if s.updateErr != nil {
-		return nil, s.updateErr
-	}
-	return deployment, nil
-}
-
 type stubClusterStatsRepository struct {
 	stats models.ClusterStats
 	err   error
@@ -383,13 +326,14 @@ func TestGetResourcesHandler_InternalError(t *testing.T) {
 func TestScaleResourceHandler_Success(t *testing.T) {
 	client := k8sfake.NewClientset()
 	handlers := &models.Handlers{
-		Clients: map[string]kubernetes.Interface{"default": client},
+		Clients:  map[string]kubernetes.Interface{"default": client},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())},
 	}
 	clusterService := cluster.NewService(handlers)
 	service := NewService(handlers, clusterService)
 
 	mockFactory := newMockServiceFactory()
-	mockFactory.deploymentSvc = NewDeploymentService(&stubDeploymentRepository{replicas: 3})
+	mockFactory.workloadSvc = NewWorkloadService(&mockWorkloadRepository{replicas: 3}, staticGVRResolver{})
 	service.serviceFactory = mockFactory
 
 	req := withUser(httptest.NewRequest(http.MethodPost, "/api/scale?kind=Deployment&name=demo&namespace=default&delta=2", nil), models.Claims{
@@ -460,7 +404,8 @@ func TestScaleResourceHandler_Forbidden(t *testing.T) {
 
 func TestScaleResourceHandler_ValidationErrors(t *testing.T) {
 	handlers := &models.Handlers{
-		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewClientset()},
+		Clients:  map[string]kubernetes.Interface{"default": k8sfake.NewClientset()},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())},
 	}
 	clusterService := cluster.NewService(handlers)
 	service := NewService(handlers, clusterService)
@@ -485,7 +430,7 @@ func TestScaleResourceHandler_ValidationErrors(t *testing.T) {
 			method:   http.MethodPost,
 			url:      "/api/scale?kind=Pod&name=demo&namespace=default&delta=1",
 			code:     http.StatusBadRequest,
-			contains: "Scaling supported only for Deployments",
+			contains: "Pod has no scale subresource",
 		},
 		{
 			name:     "missing name",
@@ -522,6 +467,13 @@ func TestScaleResourceHandler_ValidationErrors(t *testing.T) {
 			code:     http.StatusBadRequest,
 			contains: "Delta cannot be zero",
 		},
+		{
+			name:     "delta out of range",
+			method:   http.MethodPost,
+			url:      "/api/scale?kind=Deployment&name=demo&namespace=default&delta=3000000000",
+			code:     http.StatusBadRequest,
+			contains: "Invalid delta",
+		},
 		{
 			name:     "cluster not found",
 			method:   http.MethodPost,
@@ -551,15 +503,16 @@ func TestScaleResourceHandler_ValidationErrors(t *testing.T) {
 func TestScaleResourceHandler_ServiceError(t *testing.T) {
 	client := k8sfake.NewClientset()
 	handlers := &models.Handlers{
-		Clients: map[string]kubernetes.Interface{"default": client},
+		Clients:  map[string]kubernetes.Interface{"default": client},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())},
 	}
 	clusterService := cluster.NewService(handlers)
 	service := NewService(handlers, clusterService)
 
 	mockFactory := newMockServiceFactory()
-	mockFactory.deploymentSvc = NewDeploymentService(&stubDeploymentRepository{
-		getErr: errors.New("cannot fetch scale"),
-	})
+	mockFactory.workloadSvc = NewWorkloadService(&mockWorkloadRepository{
+		getScaleErr: errors.New("cannot fetch scale"),
+	}, staticGVRResolver{})
 	service.serviceFactory = mockFactory
 
 	req := withUser(httptest.NewRequest(http.MethodPost, "/api/scale?kind=Deployment&name=demo&namespace=default&delta=1", nil), models.Claims{
@@ -663,13 +616,14 @@ func TestGetClusterStatsHandler_ClusterNotFound(t *testing.T) {
 
 func TestRolloutDeploymentHandler(t *testing.T) {
 	handlers := &models.Handlers{
-		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewClientset()},
+		Clients:  map[string]kubernetes.Interface{"default": k8sfake.NewClientset()},
+		Dynamics: map[string]dynamic.Interface{"default": dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())},
 	}
 	clusterService := cluster.NewService(handlers)
 	service := NewService(handlers, clusterService)
 
 	mockFactory := newMockServiceFactory()
-	mockFactory.deploymentSvc = NewDeploymentService(&stubRolloutDeploymentRepository{})
+	mockFactory.workloadSvc = NewWorkloadService(&mockWorkloadRepository{}, staticGVRResolver{})
 	service.serviceFactory = mockFactory
 
 	admin := models.Claims{Username: "ops", Role: "admin"}
@@ -757,7 +711,7 @@ func TestRolloutDeploymentHandler(t *testing.T) {
 	})
 
 	t.Run("service error", func(t *testing.T) {
-		mockFactory.deploymentSvc = NewDeploymentService(&stubRolloutDeploymentRepository{getErr: errors.New("boom")})
+		mockFactory.workloadSvc = NewWorkloadService(&mockWorkloadRepository{patchErr: errors.New("boom")}, staticGVRResolver{})
 		service.serviceFactory = mockFactory
 
 		req := withUser(httptest.NewRequest(http.MethodPost, "/api/rollout", strings.NewReader(`{"namespace":"default","name":"demo"}`)), admin)
@@ -4,109 +4,120 @@ import (
 	"context"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func (s *ResourceListService) listJobs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listJobs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.BatchV1().Jobs(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		status := "Running"
-		if i.Status.Succeeded > 0 {
-			status = "Completed"
-		} else if i.Status.Failed > 0 {
-			status = "Failed"
-		}
-		details := map[string]interface{}{
-			"active":         i.Status.Active,
-			"succeeded":      i.Status.Succeeded,
-			"failed":         i.Status.Failed,
-			"startTime":      i.Status.StartTime,
-			"completionTime": i.Status.CompletionTime,
-			"parallelism":    i.Spec.Parallelism,
-			"completions":    i.Spec.Completions,
-			"backoffLimit":   i.Spec.BackoffLimit,
-			"activeDeadline": i.Spec.ActiveDeadlineSeconds,
-			"podSelector":    i.Spec.Selector,
-			"podTemplate":    i.Spec.Template.Spec,
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "Job",
-			Status:    status,
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
+	for idx := range list.Items {
+		resources = append(resources, jobToResource(&list.Items[idx]))
 	}
 
-	return resources, nil
+	return resources, list.ListMeta, nil
+}
+
+// jobToResource converts a Job into the Resource returned by ListResources
+func jobToResource(i *batchv1.Job) models.Resource {
+	status := "Running"
+	if i.Status.Succeeded > 0 {
+		status = "Completed"
+	} else if i.Status.Failed > 0 {
+		status = "Failed"
+	}
+	details := map[string]interface{}{
+		"active":         i.Status.Active,
+		"succeeded":      i.Status.Succeeded,
+		"failed":         i.Status.Failed,
+		"startTime":      i.Status.StartTime,
+		"completionTime": i.Status.CompletionTime,
+		"parallelism":    i.Spec.Parallelism,
+		"completions":    i.Spec.Completions,
+		"backoffLimit":   i.Spec.BackoffLimit,
+		"activeDeadline": i.Spec.ActiveDeadlineSeconds,
+		"podSelector":    i.Spec.Selector,
+		"podTemplate":    i.Spec.Template.Spec,
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "Job",
+		Status:    status,
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listCronJobs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listCronJobs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.BatchV1().CronJobs(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		var lastSchedule string
-		if i.Status.LastScheduleTime != nil {
-			lastSchedule = i.Status.LastScheduleTime.Format(time.RFC3339)
-		}
+	for idx := range list.Items {
+		resources = append(resources, cronJobToResource(&list.Items[idx]))
+	}
 
-		// Calculate status based on CronJob state
-		status := "Active"
-		if i.Spec.Suspend != nil && *i.Spec.Suspend {
-			status = "Suspended"
-		} else if len(i.Status.Active) > 0 {
-			status = "Running"
-		} else if i.Status.LastSuccessfulTime != nil {
-			// Check if there's a recent failure by comparing LastScheduleTime with LastSuccessfulTime
-			// If LastScheduleTime exists but LastSuccessfulTime is nil or older, it might have failed
-			if i.Status.LastScheduleTime != nil {
-				if i.Status.LastSuccessfulTime.Before(i.Status.LastScheduleTime) {
-					// Last schedule was not successful
-					status = "Failed"
-				} else {
-					status = "Succeeded"
-				}
+	return resources, list.ListMeta, nil
+}
+
+// cronJobToResource converts a CronJob into the Resource returned by ListResources
+func cronJobToResource(i *batchv1.CronJob) models.Resource {
+	var lastSchedule string
+	if i.Status.LastScheduleTime != nil {
+		lastSchedule = i.Status.LastScheduleTime.Format(time.RFC3339)
+	}
+
+	// Calculate status based on CronJob state
+	status := "Active"
+	if i.Spec.Suspend != nil && *i.Spec.Suspend {
+		status = "Suspended"
+	} else if len(i.Status.Active) > 0 {
+		status = "Running"
+	} else if i.Status.LastSuccessfulTime != nil {
+		// Check if there's a recent failure by comparing LastScheduleTime with LastSuccessfulTime
+		// If LastScheduleTime exists but LastSuccessfulTime is nil or older, it might have failed
+		if i.Status.LastScheduleTime != nil {
+			if i.Status.LastSuccessfulTime.Before(i.Status.LastScheduleTime) {
+				// Last schedule was not successful
+				status = "Failed"
 			} else {
 				status = "Succeeded"
 			}
-		} else if i.Status.LastScheduleTime != nil {
-			// Has been scheduled but no successful time recorded - likely failed
-			status = "Failed"
+		} else {
+			status = "Succeeded"
 		}
-
-		details := map[string]interface{}{
-			"schedule":         i.Spec.Schedule,
-			"suspend":          i.Spec.Suspend,
-			"concurrency":      i.Spec.ConcurrencyPolicy,
-			"startingDeadline": i.Spec.StartingDeadlineSeconds,
-			"lastSchedule":     lastSchedule,
-			"succeeded":        len(i.Status.Active) == 0 && i.Status.LastSuccessfulTime != nil,
-			"failed":           i.Status.LastScheduleTime != nil && (i.Status.LastSuccessfulTime == nil || i.Status.LastSuccessfulTime.Before(i.Status.LastScheduleTime)),
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "CronJob",
-			Status:    status,
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
+	} else if i.Status.LastScheduleTime != nil {
+		// Has been scheduled but no successful time recorded - likely failed
+		status = "Failed"
 	}
 
-	return resources, nil
+	details := map[string]interface{}{
+		"schedule":         i.Spec.Schedule,
+		"suspend":          i.Spec.Suspend,
+		"concurrency":      i.Spec.ConcurrencyPolicy,
+		"startingDeadline": i.Spec.StartingDeadlineSeconds,
+		"lastSchedule":     lastSchedule,
+		"succeeded":        len(i.Status.Active) == 0 && i.Status.LastSuccessfulTime != nil,
+		"failed":           i.Status.LastScheduleTime != nil && (i.Status.LastSuccessfulTime == nil || i.Status.LastSuccessfulTime.Before(i.Status.LastScheduleTime)),
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "CronJob",
+		Status:    status,
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
@@ -4,85 +4,101 @@ import (
 	"context"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func (s *ResourceListService) listConfigMaps(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listConfigMaps(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	resources := make([]models.Resource, 0, len(list.Items))
-	for _, item := range list.Items {
-		resources = append(resources, models.Resource{
-			UID:       string(item.UID),
-			Name:      item.Name,
-			Namespace: item.Namespace,
-			Kind:      "ConfigMap",
-			Created:   item.CreationTimestamp.Format(time.RFC3339),
-			Status:    "Active",
-			Details: map[string]interface{}{
-				"data":      item.Data,
-				"dataCount": len(item.Data),
-			},
-		})
+	for idx := range list.Items {
+		resources = append(resources, configMapToResource(&list.Items[idx]))
 	}
-	return resources, nil
+	return resources, list.ListMeta, nil
 }
 
-func (s *ResourceListService) listSecrets(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+// configMapToResource converts a ConfigMap into the Resource returned by ListResources
+func configMapToResource(item *corev1.ConfigMap) models.Resource {
+	return models.Resource{
+		UID:       string(item.UID),
+		Name:      item.Name,
+		Namespace: item.Namespace,
+		Kind:      "ConfigMap",
+		Created:   item.CreationTimestamp.Format(time.RFC3339),
+		Status:    "Active",
+		Details: map[string]interface{}{
+			"data":      item.Data,
+			"dataCount": len(item.Data),
+		},
+	}
+}
+
+func (s *ResourceListService) listSecrets(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().Secrets(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	resources := make([]models.Resource, 0, len(list.Items))
-	for _, item := range list.Items {
-		// Convert []byte values to base64 strings for JSON serialization
-		dataMap := make(map[string]string)
-		for k, v := range item.Data {
-			dataMap[k] = string(v)
-		}
-		resources = append(resources, models.Resource{
-			UID:       string(item.UID),
-			Name:      item.Name,
-			Namespace: item.Namespace,
-			Kind:      "Secret",
-			Created:   item.CreationTimestamp.Format(time.RFC3339),
-			Status:    string(item.Type),
-			Details: map[string]interface{}{
-				"Type":      string(item.Type),
-				"data":      dataMap,
-				"dataCount": len(item.Data),
-			},
-		})
+	for idx := range list.Items {
+		resources = append(resources, secretToResource(&list.Items[idx]))
+	}
+	return resources, list.ListMeta, nil
+}
+
+// secretToResource converts a Secret into the Resource returned by ListResources
+func secretToResource(item *corev1.Secret) models.Resource {
+	// Convert []byte values to base64 strings for JSON serialization
+	dataMap := make(map[string]string)
+	for k, v := range item.Data {
+		dataMap[k] = string(v)
+	}
+	return models.Resource{
+		UID:       string(item.UID),
+		Name:      item.Name,
+		Namespace: item.Namespace,
+		Kind:      "Secret",
+		Created:   item.CreationTimestamp.Format(time.RFC3339),
+		Status:    string(item.Type),
+		Details: map[string]interface{}{
+			"Type":      string(item.Type),
+			"data":      dataMap,
+			"dataCount": len(item.Data),
+		},
 	}
-	return resources, nil
 }
 
-func (s *ResourceListService) listServiceAccounts(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listServiceAccounts(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	resources := make([]models.Resource, 0, len(list.Items))
-	for _, item := range list.Items {
-		resources = append(resources, models.Resource{
-			UID:       string(item.UID),
-			Name:      item.Name,
-			Namespace: item.Namespace,
-			Kind:      "ServiceAccount",
-			Created:   item.CreationTimestamp.Format(time.RFC3339),
-			Status:    "Active",
-			Details: map[string]interface{}{
-				"Secrets": len(item.Secrets),
-			},
-		})
+	for idx := range list.Items {
+		resources = append(resources, serviceAccountToResource(&list.Items[idx]))
+	}
+	return resources, list.ListMeta, nil
+}
+
+// serviceAccountToResource converts a ServiceAccount into the Resource returned by ListResources
+func serviceAccountToResource(item *corev1.ServiceAccount) models.Resource {
+	return models.Resource{
+		UID:       string(item.UID),
+		Name:      item.Name,
+		Namespace: item.Namespace,
+		Kind:      "ServiceAccount",
+		Created:   item.CreationTimestamp.Format(time.RFC3339),
+		Status:    "Active",
+		Details: map[string]interface{}{
+			"Secrets": len(item.Secrets),
+		},
 	}
-	return resources, nil
 }
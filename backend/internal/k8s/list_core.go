@@ -15,13 +15,25 @@ import (
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func (s *ResourceListService) listNodes(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listNodes(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().Nodes().List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
-	// Build metrics map
+	// Only the selector applies to metrics; the page options belong to the node list
+	metricsMap := nodeMetrics(ctx, metricsClient, metav1.ListOptions{LabelSelector: opts.LabelSelector})
+
+	var resources []models.Resource
+	for idx := range list.Items {
+		resources = append(resources, nodeToResource(&list.Items[idx], metricsMap[list.Items[idx].Name]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// nodeMetrics returns the current usage of each node, or an empty map without metrics-server
+func nodeMetrics(ctx context.Context, metricsClient metricsv.Interface, opts metav1.ListOptions) map[string]models.NodeUsage {
 	metricsMap := make(map[string]models.NodeUsage)
 	if metricsClient != nil {
 		if nmList, mErr := metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, opts); mErr == nil {
@@ -35,68 +47,79 @@ func (s *ResourceListService) listNodes(ctx context.Context, client kubernetes.I
 			}
 		}
 	}
+	return metricsMap
+}
 
-	var resources []models.Resource
-	for _, i := range list.Items {
-		conditions := make(map[string]string)
-		for _, c := range i.Status.Conditions {
-			conditions[string(c.Type)] = string(c.Status)
-		}
+// nodeToResource converts a Node into the Resource returned by ListResources
+func nodeToResource(i *corev1.Node, metrics models.NodeUsage) models.Resource {
+	conditions := make(map[string]string)
+	for _, c := range i.Status.Conditions {
+		conditions[string(c.Type)] = string(c.Status)
+	}
 
-		var images []string
-		for _, img := range i.Status.Images {
-			if len(img.Names) > 0 {
-				images = append(images, img.Names[0])
-			}
+	var images []string
+	for _, img := range i.Status.Images {
+		if len(img.Names) > 0 {
+			images = append(images, img.Names[0])
 		}
+	}
 
-		details := map[string]interface{}{
-			"addresses":     i.Status.Addresses,
-			"nodeInfo":      i.Status.NodeInfo,
-			"capacity":      i.Status.Capacity,
-			"allocatable":   i.Status.Allocatable,
-			"conditions":    conditions,
-			"images":        images,
-			"taints":        i.Spec.Taints,
-			"podCIDR":       i.Spec.PodCIDR,
-			"podCIDRs":      i.Spec.PodCIDRs,
-			"unschedulable": i.Spec.Unschedulable,
-			"labels":        i.Labels,
-			"annotations":   i.Annotations,
-			"metrics":       metricsMap[i.Name],
-		}
+	details := map[string]interface{}{
+		"addresses":     i.Status.Addresses,
+		"nodeInfo":      i.Status.NodeInfo,
+		"capacity":      i.Status.Capacity,
+		"allocatable":   i.Status.Allocatable,
+		"conditions":    conditions,
+		"images":        images,
+		"taints":        i.Spec.Taints,
+		"podCIDR":       i.Spec.PodCIDR,
+		"podCIDRs":      i.Spec.PodCIDRs,
+		"unschedulable": i.Spec.Unschedulable,
+		"labels":        i.Labels,
+		"annotations":   i.Annotations,
+		"metrics":       metrics,
+	}
 
-		status := "Ready"
-		for _, c := range i.Status.Conditions {
-			if c.Type == corev1.NodeReady && c.Status != corev1.ConditionTrue {
-				status = "NotReady"
-			}
+	status := "Ready"
+	for _, c := range i.Status.Conditions {
+		if c.Type == corev1.NodeReady && c.Status != corev1.ConditionTrue {
+			status = "NotReady"
 		}
-		if i.Spec.Unschedulable {
-			status += ",SchedulingDisabled"
-		}
-
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: "",
-			Kind:      "Node",
-			Status:    status,
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
+	}
+	if i.Spec.Unschedulable {
+		status += ",SchedulingDisabled"
 	}
 
-	return resources, nil
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: "",
+		Kind:      "Node",
+		Status:    status,
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listPods(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listPods(ctx context.Context, client kubernetes.Interface, metricsClient metricsv.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().Pods(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
+	}
+
+	// Only the selector applies to metrics; the page options belong to the pod list
+	metricsMap := podMetrics(ctx, metricsClient, namespace, metav1.ListOptions{LabelSelector: opts.LabelSelector})
+
+	var resources []models.Resource
+	for idx := range list.Items {
+		resources = append(resources, podToResource(&list.Items[idx], metricsMap[list.Items[idx].Name]))
 	}
 
-	// Build metrics map
+	return resources, list.ListMeta, nil
+}
+
+// podMetrics returns the current usage of each pod in a namespace, or an empty map without metrics-server
+func podMetrics(ctx context.Context, metricsClient metricsv.Interface, namespace string, opts metav1.ListOptions) map[string]models.PodMetric {
 	metricsMap := make(map[string]models.PodMetric)
 	if metricsClient != nil {
 		if pmList, mErr := metricsClient.MetricsV1beta1().PodMetricses(namespace).List(ctx, opts); mErr == nil {
@@ -114,149 +137,148 @@ func (s *ResourceListService) listPods(ctx context.Context, client kubernetes.In
 			}
 		}
 	}
+	return metricsMap
+}
 
-	var resources []models.Resource
-	for _, i := range list.Items {
-		var containers []string
-
-		var initContainerNames []string
-		// Include InitContainers in the list of containers
-		for _, c := range i.Spec.InitContainers {
-			containers = append(containers, c.Name)
-			initContainerNames = append(initContainerNames, c.Name)
-		}
-		for _, c := range i.Spec.Containers {
-			containers = append(containers, c.Name)
-		}
+// podToResource converts a Pod into the Resource returned by ListResources
+func podToResource(i *corev1.Pod, metrics models.PodMetric) models.Resource {
+	var containers []string
 
-		restarts := int32(0)
-		readyCount := int32(0)
-		totalContainers := int32(len(i.Spec.Containers) + len(i.Spec.InitContainers))
-		var containerStatuses []map[string]interface{}
-
-		// processStatus is a helper to process container statuses
-		processStatus := func(statuses []corev1.ContainerStatus) {
-			for _, cs := range statuses {
-				restarts += cs.RestartCount
-				if cs.Ready {
-					readyCount++
-				}
+	var initContainerNames []string
+	// Include InitContainers in the list of containers
+	for _, c := range i.Spec.InitContainers {
+		containers = append(containers, c.Name)
+		initContainerNames = append(initContainerNames, c.Name)
+	}
+	for _, c := range i.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
 
-				containerStatus := map[string]interface{}{
-					"name":         cs.Name,
-					"ready":        cs.Ready,
-					"restartCount": cs.RestartCount,
-					"image":        cs.Image,
-				}
+	restarts := int32(0)
+	readyCount := int32(0)
+	totalContainers := int32(len(i.Spec.Containers) + len(i.Spec.InitContainers))
+	var containerStatuses []map[string]interface{}
+
+	// processStatus is a helper to process container statuses
+	processStatus := func(statuses []corev1.ContainerStatus) {
+		for _, cs := range statuses {
+			restarts += cs.RestartCount
+			if cs.Ready {
+				readyCount++
+			}
 
-				if cs.State.Waiting != nil {
-					containerStatus["state"] = "Waiting"
-					containerStatus["reason"] = cs.State.Waiting.Reason
-					containerStatus["message"] = cs.State.Waiting.Message
-				} else if cs.State.Running != nil {
-					containerStatus["state"] = "Running"
-					containerStatus["startedAt"] = cs.State.Running.StartedAt.Format(time.RFC3339)
-				} else if cs.State.Terminated != nil {
-					containerStatus["state"] = "Terminated"
-					containerStatus["reason"] = cs.State.Terminated.Reason
-					containerStatus["exitCode"] = cs.State.Terminated.ExitCode
-					if !cs.State.Terminated.StartedAt.IsZero() {
-						containerStatus["startedAt"] = cs.State.Terminated.StartedAt.Format(time.RFC3339)
-					}
-					if !cs.State.Terminated.FinishedAt.IsZero() {
-						containerStatus["finishedAt"] = cs.State.Terminated.FinishedAt.Format(time.RFC3339)
-					}
-				}
+			containerStatus := map[string]interface{}{
+				"name":         cs.Name,
+				"ready":        cs.Ready,
+				"restartCount": cs.RestartCount,
+				"image":        cs.Image,
+			}
 
-				containerStatuses = append(containerStatuses, containerStatus)
+			if cs.State.Waiting != nil {
+				containerStatus["state"] = "Waiting"
+				containerStatus["reason"] = cs.State.Waiting.Reason
+				containerStatus["message"] = cs.State.Waiting.Message
+			} else if cs.State.Running != nil {
+				containerStatus["state"] = "Running"
+				containerStatus["startedAt"] = cs.State.Running.StartedAt.Format(time.RFC3339)
+			} else if cs.State.Terminated != nil {
+				containerStatus["state"] = "Terminated"
+				containerStatus["reason"] = cs.State.Terminated.Reason
+				containerStatus["exitCode"] = cs.State.Terminated.ExitCode
+				if !cs.State.Terminated.StartedAt.IsZero() {
+					containerStatus["startedAt"] = cs.State.Terminated.StartedAt.Format(time.RFC3339)
+				}
+				if !cs.State.Terminated.FinishedAt.IsZero() {
+					containerStatus["finishedAt"] = cs.State.Terminated.FinishedAt.Format(time.RFC3339)
+				}
 			}
+
+			containerStatuses = append(containerStatuses, containerStatus)
 		}
+	}
 
-		// Process init container statuses first
-		processStatus(i.Status.InitContainerStatuses)
-		// Process regular container statuses
-		processStatus(i.Status.ContainerStatuses)
+	// Process init container statuses first
+	processStatus(i.Status.InitContainerStatuses)
+	// Process regular container statuses
+	processStatus(i.Status.ContainerStatuses)
 
-		var conditions []map[string]interface{}
-		for _, condition := range i.Status.Conditions {
-			info := map[string]interface{}{
-				"type":   string(condition.Type),
-				"status": string(condition.Status),
-			}
-			if condition.Reason != "" {
-				info["reason"] = condition.Reason
-			}
-			if condition.Message != "" {
-				info["message"] = condition.Message
-			}
-			if !condition.LastTransitionTime.IsZero() {
-				info["lastTransitionTime"] = condition.LastTransitionTime.Format(time.RFC3339)
-			}
-			if !condition.LastProbeTime.IsZero() {
-				info["lastProbeTime"] = condition.LastProbeTime.Format(time.RFC3339)
-			}
-			conditions = append(conditions, info)
+	var conditions []map[string]interface{}
+	for _, condition := range i.Status.Conditions {
+		info := map[string]interface{}{
+			"type":   string(condition.Type),
+			"status": string(condition.Status),
 		}
-
-		var containerProbes []map[string]interface{}
-		for _, c := range i.Spec.Containers {
-			containerProbes = append(containerProbes, map[string]interface{}{
-				"name":           c.Name,
-				"image":          c.Image,
-				"readinessProbe": probeToInfo(c.ReadinessProbe),
-				"livenessProbe":  probeToInfo(c.LivenessProbe),
-				"startupProbe":   probeToInfo(c.StartupProbe),
-			})
+		if condition.Reason != "" {
+			info["reason"] = condition.Reason
 		}
-
-		var initContainerProbes []map[string]interface{}
-		for _, c := range i.Spec.InitContainers {
-			initContainerProbes = append(initContainerProbes, map[string]interface{}{
-				"name":           c.Name,
-				"image":          c.Image,
-				"readinessProbe": probeToInfo(c.ReadinessProbe),
-				"livenessProbe":  probeToInfo(c.LivenessProbe),
-				"startupProbe":   probeToInfo(c.StartupProbe),
-			})
+		if condition.Message != "" {
+			info["message"] = condition.Message
 		}
-
-		startTime := ""
-		if i.Status.StartTime != nil && !i.Status.StartTime.IsZero() {
-			startTime = i.Status.StartTime.Format(time.RFC3339)
+		if !condition.LastTransitionTime.IsZero() {
+			info["lastTransitionTime"] = condition.LastTransitionTime.Format(time.RFC3339)
+		}
+		if !condition.LastProbeTime.IsZero() {
+			info["lastProbeTime"] = condition.LastProbeTime.Format(time.RFC3339)
 		}
+		conditions = append(conditions, info)
+	}
+
+	var containerProbes []map[string]interface{}
+	for _, c := range i.Spec.Containers {
+		containerProbes = append(containerProbes, map[string]interface{}{
+			"name":           c.Name,
+			"image":          c.Image,
+			"readinessProbe": probeToInfo(c.ReadinessProbe),
+			"livenessProbe":  probeToInfo(c.LivenessProbe),
+			"startupProbe":   probeToInfo(c.StartupProbe),
+		})
+	}
 
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "Pod",
-			Status:    string(i.Status.Phase),
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details: map[string]interface{}{
-				"node":                i.Spec.NodeName,
-				"ip":                  i.Status.PodIP,
-				"restarts":            restarts,
-				"ready":               fmt.Sprintf("%d/%d", readyCount, totalContainers),
-				"readyCount":          readyCount,
-				"totalContainers":     totalContainers,
-				"containers":          containers,
-				"initContainers":      initContainerNames,
-				"containerStatuses":   containerStatuses,
-				"containerProbes":     containerProbes,
-				"initContainerProbes": initContainerProbes,
-				"metrics":             metricsMap[i.Name],
-				"labels":              i.Labels,
-				"conditions":          conditions,
-				"statusReason":        i.Status.Reason,
-				"statusMessage":       i.Status.Message,
-				"qosClass":            string(i.Status.QOSClass),
-				"startTime":           startTime,
-				"serviceAccount":      i.Spec.ServiceAccountName,
-			},
+	var initContainerProbes []map[string]interface{}
+	for _, c := range i.Spec.InitContainers {
+		initContainerProbes = append(initContainerProbes, map[string]interface{}{
+			"name":           c.Name,
+			"image":          c.Image,
+			"readinessProbe": probeToInfo(c.ReadinessProbe),
+			"livenessProbe":  probeToInfo(c.LivenessProbe),
+			"startupProbe":   probeToInfo(c.StartupProbe),
 		})
 	}
 
-	return resources, nil
+	startTime := ""
+	if i.Status.StartTime != nil && !i.Status.StartTime.IsZero() {
+		startTime = i.Status.StartTime.Format(time.RFC3339)
+	}
+
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "Pod",
+		Status:    string(i.Status.Phase),
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details: map[string]interface{}{
+			"node":                i.Spec.NodeName,
+			"ip":                  i.Status.PodIP,
+			"restarts":            restarts,
+			"ready":               fmt.Sprintf("%d/%d", readyCount, totalContainers),
+			"readyCount":          readyCount,
+			"totalContainers":     totalContainers,
+			"containers":          containers,
+			"initContainers":      initContainerNames,
+			"containerStatuses":   containerStatuses,
+			"containerProbes":     containerProbes,
+			"initContainerProbes": initContainerProbes,
+			"metrics":             metrics,
+			"labels":              i.Labels,
+			"conditions":          conditions,
+			"statusReason":        i.Status.Reason,
+			"statusMessage":       i.Status.Message,
+			"qosClass":            string(i.Status.QOSClass),
+			"startTime":           startTime,
+			"serviceAccount":      i.Spec.ServiceAccountName,
+		},
+	}
 }
 
 func probeToInfo(probe *corev1.Probe) map[string]interface{} {
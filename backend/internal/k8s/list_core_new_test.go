@@ -68,7 +68,7 @@ func TestResourceListService_ListPods_WithMetrics(t *testing.T) {
 	svc := NewResourceListService(nil, "")
 
 	// Test List
-	list, err := svc.listPods(context.Background(), client, metricsClient, "default", metav1.ListOptions{})
+	list, _, err := svc.listPods(context.Background(), client, metricsClient, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listPods failed: %v", err)
 	}
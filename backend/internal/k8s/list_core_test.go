@@ -52,7 +52,7 @@ func TestListPods(t *testing.T) {
 	// Let's test via ListResources to be safe and integration-style,
 	// or call the method directly from this test since it is in package k8s.
 
-	resources, err := service.listPods(context.Background(), client, nil, "default", metav1.ListOptions{})
+	resources, _, err := service.listPods(context.Background(), client, nil, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listPods failed: %v", err)
 	}
@@ -100,7 +100,7 @@ func TestListServices(t *testing.T) {
 	client := k8sfake.NewClientset(svc1)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listServices(context.Background(), client, "default", metav1.ListOptions{})
+	resources, _, err := service.listServices(context.Background(), client, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listServices failed: %v", err)
 	}
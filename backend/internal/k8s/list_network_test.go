@@ -94,7 +94,7 @@ func TestResourceListService_ListIngresses(t *testing.T) {
 	}
 
 	// Execute
-	resources, err := svc.listIngresses(context.Background(), req.Client, req.Namespace, metav1.ListOptions{})
+	resources, _, err := svc.listIngresses(context.Background(), req.Client, req.Namespace, metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listIngresses failed: %v", err)
 	}
@@ -168,7 +168,7 @@ func TestResourceListService_ListServices_ExternalIPs(t *testing.T) {
 	}
 	_, _ = client.CoreV1().Services("default").Create(context.Background(), service, metav1.CreateOptions{})
 
-	resources, err := svc.listServices(context.Background(), client, "default", metav1.ListOptions{})
+	resources, _, err := svc.listServices(context.Background(), client, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listServices failed: %v", err)
 	}
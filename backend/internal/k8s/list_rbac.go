@@ -4,110 +4,131 @@ import (
 	"context"
 	"time"
 
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func (s *ResourceListService) listRoles(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listRoles(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.RbacV1().Roles(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		details := map[string]interface{}{
-			"rules": i.Rules,
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "Role",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, roleToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// roleToResource converts a Role into the Resource returned by ListResources
+func roleToResource(i *rbacv1.Role) models.Resource {
+	details := map[string]interface{}{
+		"rules": i.Rules,
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "Role",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listClusterRoles(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listClusterRoles(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.RbacV1().ClusterRoles().List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		details := map[string]interface{}{
-			"rules": i.Rules,
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: "",
-			Kind:      "ClusterRole",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, clusterRoleToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// clusterRoleToResource converts a ClusterRole into the Resource returned by ListResources
+func clusterRoleToResource(i *rbacv1.ClusterRole) models.Resource {
+	details := map[string]interface{}{
+		"rules": i.Rules,
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: "",
+		Kind:      "ClusterRole",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listRoleBindings(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listRoleBindings(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.RbacV1().RoleBindings(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		details := map[string]interface{}{
-			"subjects": i.Subjects,
-			"roleRef":  i.RoleRef,
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "RoleBinding",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, roleBindingToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// roleBindingToResource converts a RoleBinding into the Resource returned by ListResources
+func roleBindingToResource(i *rbacv1.RoleBinding) models.Resource {
+	details := map[string]interface{}{
+		"subjects": i.Subjects,
+		"roleRef":  i.RoleRef,
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "RoleBinding",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listClusterRoleBindings(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listClusterRoleBindings(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.RbacV1().ClusterRoleBindings().List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		details := map[string]interface{}{
-			"subjects": i.Subjects,
-			"roleRef":  i.RoleRef,
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: "",
-			Kind:      "ClusterRoleBinding",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, clusterRoleBindingToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// clusterRoleBindingToResource converts a ClusterRoleBinding into the Resource returned by ListResources
+func clusterRoleBindingToResource(i *rbacv1.ClusterRoleBinding) models.Resource {
+	details := map[string]interface{}{
+		"subjects": i.Subjects,
+		"roleRef":  i.RoleRef,
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: "",
+		Kind:      "ClusterRoleBinding",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
@@ -26,7 +26,7 @@ func TestListRoles(t *testing.T) {
 	client := k8sfake.NewClientset(role)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listRoles(context.Background(), client, "default", metav1.ListOptions{})
+	resources, _, err := service.listRoles(context.Background(), client, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listRoles failed: %v", err)
 	}
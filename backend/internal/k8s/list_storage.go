@@ -5,170 +5,196 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func (s *ResourceListService) listPVCs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listPVCs(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		requested := ""
-		if i.Spec.Resources.Requests != nil {
-			if storage, ok := i.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
-				requested = storage.String()
-			}
-		}
+	for idx := range list.Items {
+		resources = append(resources, pvcToResource(&list.Items[idx]))
+	}
 
-		allocated := ""
-		if i.Status.Capacity != nil {
-			if storage, ok := i.Status.Capacity[corev1.ResourceStorage]; ok {
-				allocated = storage.String()
-			}
-		}
+	return resources, list.ListMeta, nil
+}
 
-		// Note: Prometheus metrics for PVC usage would require queryPrometheusInstant
-		// For now, we skip Prometheus metrics in the refactored version
-		// This can be added later if needed by injecting a Prometheus client
+// pvcToResource converts a PersistentVolumeClaim into the Resource returned by ListResources
+func pvcToResource(i *corev1.PersistentVolumeClaim) models.Resource {
+	requested := ""
+	if i.Spec.Resources.Requests != nil {
+		if storage, ok := i.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			requested = storage.String()
+		}
+	}
 
-		details := map[string]interface{}{
-			"accessModes":      i.Spec.AccessModes,
-			"capacity":         allocated,
-			"requested":        requested,
-			"storageClassName": i.Spec.StorageClassName,
-			"volumeName":       i.Spec.VolumeName,
+	allocated := ""
+	if i.Status.Capacity != nil {
+		if storage, ok := i.Status.Capacity[corev1.ResourceStorage]; ok {
+			allocated = storage.String()
 		}
+	}
+
+	// Note: Prometheus metrics for PVC usage would require queryPrometheusInstant
+	// For now, we skip Prometheus metrics in the refactored version
+	// This can be added later if needed by injecting a Prometheus client
 
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "PersistentVolumeClaim",
-			Status:    string(i.Status.Phase),
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details:   details,
-		})
+	details := map[string]interface{}{
+		"accessModes":      i.Spec.AccessModes,
+		"capacity":         allocated,
+		"requested":        requested,
+		"storageClassName": i.Spec.StorageClassName,
+		"volumeName":       i.Spec.VolumeName,
 	}
 
-	return resources, nil
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "PersistentVolumeClaim",
+		Status:    string(i.Status.Phase),
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details:   details,
+	}
 }
 
-func (s *ResourceListService) listPVs(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) listPVs(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().PersistentVolumes().List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: "",
-			Kind:      "PersistentVolume",
-			Status:    string(i.Status.Phase),
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details: map[string]interface{}{
-				"accessModes":      i.Spec.AccessModes,
-				"capacity":         i.Spec.Capacity.Storage().String(),
-				"storageClassName": i.Spec.StorageClassName,
-				"reclaimPolicy":    string(i.Spec.PersistentVolumeReclaimPolicy),
-				"claimRef":         i.Spec.ClaimRef,
-			},
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, pvToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
 }
 
-func (s *ResourceListService) listStorageClasses(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, error) {
+// pvToResource converts a PersistentVolume into the Resource returned by ListResources
+func pvToResource(i *corev1.PersistentVolume) models.Resource {
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: "",
+		Kind:      "PersistentVolume",
+		Status:    string(i.Status.Phase),
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details: map[string]interface{}{
+			"accessModes":      i.Spec.AccessModes,
+			"capacity":         i.Spec.Capacity.Storage().String(),
+			"storageClassName": i.Spec.StorageClassName,
+			"reclaimPolicy":    string(i.Spec.PersistentVolumeReclaimPolicy),
+			"claimRef":         i.Spec.ClaimRef,
+		},
+	}
+}
+
+func (s *ResourceListService) listStorageClasses(ctx context.Context, client kubernetes.Interface, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.StorageV1().StorageClasses().List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		reclaim := ""
-		if i.ReclaimPolicy != nil {
-			reclaim = string(*i.ReclaimPolicy)
-		}
-		volumeBinding := ""
-		if i.VolumeBindingMode != nil {
-			volumeBinding = string(*i.VolumeBindingMode)
-		}
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: "",
-			Kind:      "StorageClass",
-			Status:    reclaim,
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details: map[string]interface{}{
-				"provisioner":          i.Provisioner,
-				"reclaimPolicy":        reclaim,
-				"volumeBindingMode":    volumeBinding,
-				"allowVolumeExpansion": i.AllowVolumeExpansion,
-				"parameters":           i.Parameters,
-				"mountOptions":         i.MountOptions,
-			},
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, storageClassToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
 }
 
-func (s *ResourceListService) listResourceQuotas(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+// storageClassToResource converts a StorageClass into the Resource returned by ListResources
+func storageClassToResource(i *storagev1.StorageClass) models.Resource {
+	reclaim := ""
+	if i.ReclaimPolicy != nil {
+		reclaim = string(*i.ReclaimPolicy)
+	}
+	volumeBinding := ""
+	if i.VolumeBindingMode != nil {
+		volumeBinding = string(*i.VolumeBindingMode)
+	}
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: "",
+		Kind:      "StorageClass",
+		Status:    reclaim,
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details: map[string]interface{}{
+			"provisioner":          i.Provisioner,
+			"reclaimPolicy":        reclaim,
+			"volumeBindingMode":    volumeBinding,
+			"allowVolumeExpansion": i.AllowVolumeExpansion,
+			"parameters":           i.Parameters,
+			"mountOptions":         i.MountOptions,
+		},
+	}
+}
+
+func (s *ResourceListService) listResourceQuotas(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().ResourceQuotas(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "ResourceQuota",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details: map[string]interface{}{
-				"hard": i.Status.Hard,
-				"used": i.Status.Used,
-			},
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, resourceQuotaToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
 }
 
-func (s *ResourceListService) listLimitRanges(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, error) {
+// resourceQuotaToResource converts a ResourceQuota into the Resource returned by ListResources
+func resourceQuotaToResource(i *corev1.ResourceQuota) models.Resource {
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "ResourceQuota",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details: map[string]interface{}{
+			"hard": i.Status.Hard,
+			"used": i.Status.Used,
+		},
+	}
+}
+
+func (s *ResourceListService) listLimitRanges(ctx context.Context, client kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	list, err := client.CoreV1().LimitRanges(namespace).List(ctx, opts)
 	if err != nil {
-		return nil, err
+		return nil, metav1.ListMeta{}, err
 	}
 
 	var resources []models.Resource
-	for _, i := range list.Items {
-		resources = append(resources, models.Resource{
-			Name:      i.Name,
-			Namespace: i.Namespace,
-			Kind:      "LimitRange",
-			Status:    "Active",
-			Created:   i.CreationTimestamp.Format(time.RFC3339),
-			UID:       string(i.UID),
-			Details: map[string]interface{}{
-				"limits": i.Spec.Limits,
-			},
-		})
-	}
-
-	return resources, nil
+	for idx := range list.Items {
+		resources = append(resources, limitRangeToResource(&list.Items[idx]))
+	}
+
+	return resources, list.ListMeta, nil
+}
+
+// limitRangeToResource converts a LimitRange into the Resource returned by ListResources
+func limitRangeToResource(i *corev1.LimitRange) models.Resource {
+	return models.Resource{
+		Name:      i.Name,
+		Namespace: i.Namespace,
+		Kind:      "LimitRange",
+		Status:    "Active",
+		Created:   i.CreationTimestamp.Format(time.RFC3339),
+		UID:       string(i.UID),
+		Details: map[string]interface{}{
+			"limits": i.Spec.Limits,
+		},
+	}
 }
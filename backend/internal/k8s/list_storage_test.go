@@ -27,7 +27,7 @@ func TestListPVCs(t *testing.T) {
 	client := k8sfake.NewClientset(pvc)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listPVCs(context.Background(), client, "default", metav1.ListOptions{})
+	resources, _, err := service.listPVCs(context.Background(), client, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listPVCs failed: %v", err)
 	}
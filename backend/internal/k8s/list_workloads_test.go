@@ -41,7 +41,7 @@ func TestListDeployments(t *testing.T) {
 	client := k8sfake.NewClientset(dep)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listDeployments(context.Background(), client, "default", metav1.ListOptions{})
+	resources, _, err := service.listDeployments(context.Background(), client, "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listDeployments failed: %v", err)
 	}
@@ -25,6 +25,8 @@ type mockResourceRepository struct {
 	patchNamespaced bool
 	patchData       []byte
 	patchType       types.PatchType
+	patchOptions    []metav1.PatchOptions // options of every Patch call, in order
+	patchResult     *unstructured.Unstructured
 
 	createObj        *unstructured.Unstructured
 	createNamespace  string
@@ -37,6 +39,9 @@ type mockResourceRepository struct {
 	deleteNamespaced bool
 	deleteOptions    metav1.DeleteOptions
 
+	listResult  *unstructured.UnstructuredList
+	listOptions metav1.ListOptions
+
 	getObj     *unstructured.Unstructured
 	getErr     error
 	getReturns *unstructured.Unstructured // Explicit return for Get
@@ -81,6 +86,7 @@ func (m *mockResourceRepository) Patch(ctx context.Context, gvr schema.GroupVers
 	m.patchNamespaced = namespaced
 	m.patchData = patchData
 	m.patchType = patchType
+	m.patchOptions = append(m.patchOptions, options)
 
 	if m.patchErr != nil {
 		return nil, m.patchErr
@@ -88,6 +94,9 @@ func (m *mockResourceRepository) Patch(ctx context.Context, gvr schema.GroupVers
 	if m.err != nil {
 		return nil, m.err
 	}
+	if m.patchResult != nil {
+		return m.patchResult.DeepCopy(), nil
+	}
 	return &unstructured.Unstructured{}, nil
 }
 
@@ -104,6 +113,18 @@ func (m *mockResourceRepository) Delete(ctx context.Context, gvr schema.GroupVer
 	return nil
 }
 
+func (m *mockResourceRepository) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	m.listOptions = options
+
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.listResult != nil {
+		return m.listResult.DeepCopy(), nil
+	}
+	return &unstructured.UnstructuredList{}, nil
+}
+
 type fakeResourceRepo = mockResourceRepository
 
 type mockGVRResolver struct {
@@ -183,7 +204,7 @@ type mockServiceFactory struct {
 	realFactory      *ServiceFactory
 	namespaceService *NamespaceService
 	clusterStatsSvc  *ClusterStatsService
-	deploymentSvc    *DeploymentService
+	workloadSvc      *WorkloadService
 	resourceSvc      *ResourceService
 	importSvc        *ImportService
 	watchSvc         *WatchService
@@ -221,11 +242,11 @@ func (f *mockServiceFactory) CreateClusterStatsService(client kubernetes.Interfa
 	return f.realFactory.CreateClusterStatsService(client)
 }
 
-func (f *mockServiceFactory) CreateDeploymentService(client kubernetes.Interface) *DeploymentService {
-	if f.deploymentSvc != nil {
-		return f.deploymentSvc
+func (f *mockServiceFactory) CreateWorkloadService(dynamicClient dynamic.Interface, client kubernetes.Interface) *WorkloadService {
+	if f.workloadSvc != nil {
+		return f.workloadSvc
 	}
-	return f.realFactory.CreateDeploymentService(client)
+	return f.realFactory.CreateWorkloadService(dynamicClient, client)
 }
 
 func (f *mockServiceFactory) CreateCronJobService(client kubernetes.Interface) *CronJobService {
@@ -238,3 +259,15 @@ func (f *mockServiceFactory) CreateWatchService() *WatchService {
 	}
 	return f.realFactory.CreateWatchService()
 }
+
+func (f *mockServiceFactory) CreateRolloutService(client kubernetes.Interface) *RolloutService {
+	return f.realFactory.CreateRolloutService(client)
+}
+
+func (f *mockServiceFactory) CreateBulkService(dynamicClient dynamic.Interface, client kubernetes.Interface) *BulkService {
+	return f.realFactory.CreateBulkService(dynamicClient, client)
+}
+
+func (f *mockServiceFactory) CreateExportService(dynamicClient dynamic.Interface, client kubernetes.Interface) *ExportService {
+	return f.realFactory.CreateExportService(dynamicClient, client)
+}
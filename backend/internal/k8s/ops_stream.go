@@ -98,7 +98,11 @@ func (s *Service) StreamResourceCreation(w http.ResponseWriter, r *http.Request)
 	}
 }
 
-// WatchResources handles WebSocket connections for watching Kubernetes resources
+// WatchResources handles WebSocket connections for watching Kubernetes resources.
+// Each message is a WatchResult: ADDED, MODIFIED and DELETED events carry the object as returned by
+// /api/resources plus its resourceVersion, BOOKMARK events only the resourceVersion, and a SYNC event
+// replaces the client state after the server had to relist. Clients reconnecting with
+// ?resourceVersion=<last seen> resume from there instead of receiving every object again.
 func (s *Service) WatchResources(w http.ResponseWriter, r *http.Request) {
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(w, r, nil)
@@ -122,6 +126,9 @@ func (s *Service) WatchResources(w http.ResponseWriter, r *http.Request) {
 
 	// Create WatchService
 	watchService := s.serviceFactory.CreateWatchService()
+	if s.resourceCache != nil {
+		watchService.SetCache(s.resourceCache)
+	}
 
 	// Create context that is canceled when connection closes
 	ctx, cancel := context.WithCancel(r.Context())
@@ -137,40 +144,18 @@ func (s *Service) WatchResources(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	// Start Watch
+	// Stream events (resumes after resourceVersion when the client reconnects with it)
 	req := WatchRequest{
-		Kind:          kind,
-		Namespace:     namespace,
-		AllNamespaces: allNamespaces,
+		Kind:            kind,
+		Namespace:       namespace,
+		AllNamespaces:   allNamespaces,
+		ResourceVersion: r.URL.Query().Get("resourceVersion"),
 	}
 
-	watcher, err := watchService.StartWatch(ctx, dynamicClient, req)
-	if err != nil {
+	err = watchService.Stream(ctx, dynamicClient, req, func(result *WatchResult) error {
+		return conn.WriteJSON(result)
+	})
+	if err != nil && ctx.Err() == nil {
 		_ = conn.WriteJSON(map[string]string{"type": "ERROR", "message": err.Error()})
-		return
-	}
-	defer watcher.Stop()
-
-	// Send events to client
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case event, ok := <-watcher.ResultChan():
-			if !ok {
-				return
-			}
-
-			// Transform event
-			result, err := watchService.TransformEvent(event)
-			if err != nil {
-				continue
-			}
-
-			// Send to client
-			if err := conn.WriteJSON(result); err != nil {
-				return
-			}
-		}
 	}
 }
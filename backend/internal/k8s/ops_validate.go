@@ -2,145 +2,200 @@ package k8s
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/yaml"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
-// DryRunResourceYAML performs a dry-run creation
-func (s *Service) DryRunResourceYAML(w http.ResponseWriter, r *http.Request) {
-	// Read YAML
+// The apply workflow: ValidateResourceYAML, then DryRunResourceYAML to preview the result and its diff
+// against the live object, then ServerSideApply. All three take a single-resource manifest as the body
+// and require the same permissions as UpdateResourceYAML: edit on the target namespace, or admin for
+// cluster-scoped resources.
+
+// readApplyRequest reads the manifest and the apply options (namespace, fieldManager, force) of a workflow request
+func readApplyRequest(w http.ResponseWriter, r *http.Request) (ApplyRequest, []byte, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
-		return
+		return ApplyRequest{}, nil, fmt.Errorf("failed to read request body: %w", err)
 	}
 	defer r.Body.Close()
 
-	yamlData := string(body)
-	jsonData, err := yaml.YAMLToJSON([]byte(yamlData))
+	query := r.URL.Query()
+	req := ApplyRequest{
+		YAMLContent:  string(body),
+		Namespace:    query.Get("namespace"),
+		FieldManager: query.Get("fieldManager"),
+	}
+	if value := query.Get("force"); value != "" {
+		force, err := strconv.ParseBool(value)
+		if err != nil {
+			return ApplyRequest{}, nil, fmt.Errorf("invalid force value %q", value)
+		}
+		req.Force = force
+	}
+	return req, body, nil
+}
+
+// resourceServiceFor creates a ResourceService for the cluster of the request
+func (s *Service) resourceServiceFor(r *http.Request) (*ResourceService, error) {
+	dynamicClient, err := s.clusterService.GetDynamicClient(r)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid YAML: %v", err))
-		return
+		return nil, err
+	}
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		return nil, err
 	}
+	return s.serviceFactory.CreateResourceService(dynamicClient, client), nil
+}
 
-	var obj unstructured.Unstructured
-	if err := json.Unmarshal(jsonData, &obj); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid JSON: %v", err))
-		return
+// applyErrorStatus maps apply workflow errors to HTTP status codes
+func applyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalidManifest), errors.Is(err, ErrNamespaceMismatch), errors.Is(err, ErrNamespaceRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrForbidden), errors.Is(err, ErrAdminRequired), apierrors.IsForbidden(err):
+		return http.StatusForbidden
+	case apierrors.IsConflict(err):
+		return http.StatusConflict
+	case apierrors.IsInvalid(err):
+		return http.StatusUnprocessableEntity
+	case apierrors.IsBadRequest(err):
+		return http.StatusBadRequest
+	case apierrors.IsNotFound(err):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
 	}
+}
 
-	ctx, cancel := utils.CreateTimeoutContext()
-	defer cancel()
+// writeApplyError writes an apply workflow error; conflicts carry a hint about force
+func writeApplyError(w http.ResponseWriter, err error, message string) {
+	status := applyErrorStatus(err)
+	switch status {
+	case http.StatusInternalServerError:
+		utils.HandleErrorJSON(w, err, message, status, nil)
+	case http.StatusConflict:
+		utils.ErrorResponse(w, status, fmt.Sprintf("%v (retry with force=true to take ownership of the conflicting fields)", err))
+	default:
+		utils.ErrorResponse(w, status, err.Error())
+	}
+}
 
-	// Get Dynamic Client
-	dynamicClient, err := s.clusterService.GetDynamicClient(r)
+// DryRunResourceYAML previews a server-side apply of a manifest without persisting it
+//
+// @Summary Dry-run de server-side apply
+// @Description Aplica el manifiesto con dryRun=All y devuelve el objeto resultante y el diff contra el objeto en vivo (sin status ni metadata del servidor)
+// @Tags resources
+// @Security Bearer
+// @Accept plain
+// @Produce json
+// @Param namespace query string false "Namespace por defecto si el manifiesto no lo indica"
+// @Param fieldManager query string false "Field manager (por defecto dkonsole-apply)"
+// @Param force query bool false "Forzar conflictos de ownership"
+// @Success 200 {object} map[string]interface{} "Objeto resultante y diff"
+// @Failure 400 {object} map[string]string "Manifiesto inválido"
+// @Failure 403 {object} map[string]string "Permiso de edición requerido"
+// @Failure 409 {object} map[string]string "Conflicto con otro field manager"
+// @Router /api/resource/dry-run [post]
+func (s *Service) DryRunResourceYAML(w http.ResponseWriter, r *http.Request) {
+	req, _, err := readApplyRequest(w, r)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	resolver := NewK8sGVRResolver()
-
-	gvk := obj.GroupVersionKind()
-	gvr, meta, err := resolver.ResolveGVR(ctx, gvk.Kind, gvk.GroupVersion().String(), "")
+	resourceService, err := s.resourceServiceFor(r)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	namespace := obj.GetNamespace()
-	if meta.Namespaced && namespace == "" {
-		namespace = "default"
-		obj.SetNamespace(namespace)
-	}
-
-	// Server-side DryRun
-	var result *unstructured.Unstructured
-	options := metav1.CreateOptions{
-		DryRun: []string{metav1.DryRunAll},
-	}
-
-	if meta.Namespaced {
-		result, err = dynamicClient.Resource(gvr).Namespace(namespace).Create(ctx, &obj, options)
-	} else {
-		result, err = dynamicClient.Resource(gvr).Create(ctx, &obj, options)
-	}
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
 
+	result, err := resourceService.DryRunApply(ctx, req)
 	if err != nil {
-		utils.HandleErrorJSON(w, err, "Dry run failed", http.StatusInternalServerError, map[string]interface{}{
-			"kind":      gvk.Kind,
-			"namespace": namespace,
-		})
+		writeApplyError(w, err, "Dry run failed")
 		return
 	}
 
 	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
 		"message": "Dry run successful",
+		"target":  result.Target,
+		"created": result.Created,
+		"diff":    result.Diff,
+		"unified": result.Unified,
 		"object":  result.Object,
 	})
 }
 
-// ValidateResourceYAML validates the YAML content
+// ValidateResourceYAML validates a manifest and the user's permission to apply it
+//
+// @Summary Validar manifiesto
+// @Description Verifica la sintaxis YAML, kind/apiVersion/name, que el tipo de recurso exista y el permiso de edición
+// @Tags resources
+// @Security Bearer
+// @Accept plain
+// @Produce json
+// @Param namespace query string false "Namespace por defecto si el manifiesto no lo indica"
+// @Success 200 {object} map[string]interface{} "Recurso destino"
+// @Failure 400 {object} map[string]string "Manifiesto inválido"
+// @Failure 403 {object} map[string]string "Permiso de edición requerido"
+// @Router /api/resource/validate [post]
 func (s *Service) ValidateResourceYAML(w http.ResponseWriter, r *http.Request) {
-	// Read YAML
-	body, err := io.ReadAll(r.Body)
+	req, _, err := readApplyRequest(w, r)
 	if err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
-	yamlData := string(body)
 
-	// Basic YAML validation
-	jsonData, err := yaml.YAMLToJSON([]byte(yamlData))
+	resourceService, err := s.resourceServiceFor(r)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid YAML syntax: %v", err))
-		return
-	}
-
-	// Unmarshal
-	var obj unstructured.Unstructured
-	if err := json.Unmarshal(jsonData, &obj); err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid resource structure: %v", err))
-		return
-	}
-
-	gvk := obj.GroupVersionKind()
-	if gvk.Kind == "" || gvk.Version == "" {
-		utils.ErrorResponse(w, http.StatusBadRequest, "Missing Kind or APIVersion")
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Check if we can resolve the resource type
-	ctx, cancel := utils.CreateTimeoutContext()
+	ctx, cancel := utils.CreateRequestContext(r)
 	defer cancel()
 
-	resolver := NewK8sGVRResolver()
-	_, _, err = resolver.ResolveGVR(ctx, gvk.Kind, gvk.GroupVersion().String(), "")
+	target, err := resourceService.ValidateManifest(ctx, req)
 	if err != nil {
-		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Unknown resource type: %v", err))
+		writeApplyError(w, err, "Validation failed")
 		return
 	}
 
 	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
 		"message": "YAML is valid",
-		"data": map[string]string{
-			"kind":    gvk.Kind,
-			"version": gvk.Version,
-			"name":    obj.GetName(),
-		},
+		"data":    target,
 	})
 }
 
-// ServerSideApply performs a server-side apply
+// ServerSideApply runs the apply workflow as a server-sent event stream: a "validated" event with the target,
+// a "dry-run" event with the diff against the live object, then "success" with the applied object.
+// Any failure ends the stream with an "error" event carrying the message and HTTP-style status.
+//
+// @Summary Server-side apply
+// @Description Valida, hace dry-run con diff y aplica el manifiesto con server-side apply (SSE)
+// @Tags resources
+// @Security Bearer
+// @Accept plain
+// @Produce text/event-stream
+// @Param namespace query string false "Namespace por defecto si el manifiesto no lo indica"
+// @Param fieldManager query string false "Field manager (por defecto dkonsole-apply)"
+// @Param force query bool false "Forzar conflictos de ownership"
+// @Success 200 {string} string "Eventos validated, dry-run, success o error"
+// @Router /api/resource/apply [post]
 func (s *Service) ServerSideApply(w http.ResponseWriter, r *http.Request) {
+	req, body, readErr := readApplyRequest(w, r)
+
 	// SSE setup
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
@@ -151,71 +206,62 @@ func (s *Service) ServerSideApply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	body, _ := io.ReadAll(r.Body)
-	yamlData := string(body)
-
-	fmt.Fprintf(w, "data: {\"message\": \"Starting Server-Side Apply...\"}\n\n")
-	flusher.Flush()
-
-	ctx, cancel := utils.CreateTimeoutContext()
-	defer cancel()
-
-	jsonData, err := yaml.YAMLToJSON([]byte(yamlData))
-	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: {\"message\": \"Invalid YAML: %v\"}\n\n", err)
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		if event != "" {
+			fmt.Fprintf(w, "event: %s\n", event)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", payload)
 		flusher.Flush()
-		return
 	}
-
-	var obj unstructured.Unstructured
-	if err := json.Unmarshal(jsonData, &obj); err != nil {
-		fmt.Fprintf(w, "event: error\ndata: {\"message\": \"JSON Unmarshal error: %v\"}\n\n", err)
-		flusher.Flush()
-		return
+	sendError := func(message string, err error) {
+		send("error", map[string]interface{}{
+			"message": fmt.Sprintf("%s: %v", message, err),
+			"status":  applyErrorStatus(err),
+		})
 	}
 
-	// Get Dynamic Client
-	dynamicClient, err := s.clusterService.GetDynamicClient(r)
-	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: {\"message\": \"Cluster error: %v\"}\n\n", err)
-		flusher.Flush()
+	send("", map[string]string{"message": "Starting Server-Side Apply..."})
+	if readErr != nil {
+		sendError("Invalid request", fmt.Errorf("%w: %v", ErrInvalidManifest, readErr))
 		return
 	}
 
-	resolver := NewK8sGVRResolver()
-	gvk := obj.GroupVersionKind()
-	gvr, meta, err := resolver.ResolveGVR(ctx, gvk.Kind, gvk.GroupVersion().String(), "")
+	resourceService, err := s.resourceServiceFor(r)
 	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: {\"message\": \"Resolution error: %v\"}\n\n", err)
-		flusher.Flush()
+		sendError("Cluster error", err)
 		return
 	}
 
-	namespace := obj.GetNamespace()
-	if meta.Namespaced && namespace == "" {
-		namespace = "default"
-		obj.SetNamespace(namespace)
-	}
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
 
-	force := true
-	options := metav1.PatchOptions{
-		FieldManager: "dkonsole-apply",
-		Force:        &force,
+	target, err := resourceService.ValidateManifest(ctx, req)
+	if err != nil {
+		sendError("Validation failed", err)
+		return
 	}
+	send("validated", target)
 
-	var result *unstructured.Unstructured
-	if meta.Namespaced {
-		result, err = dynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, obj.GetName(), types.ApplyPatchType, jsonData, options)
-	} else {
-		result, err = dynamicClient.Resource(gvr).Patch(ctx, obj.GetName(), types.ApplyPatchType, jsonData, options)
+	preview, err := resourceService.DryRunApply(ctx, req)
+	if err != nil {
+		sendError("Preview failed", err)
+		return
 	}
+	send("dry-run", map[string]interface{}{
+		"created": preview.Created,
+		"diff":    preview.Diff,
+		"unified": preview.Unified,
+	})
 
+	result, err := resourceService.Apply(ctx, req)
+	utils.AuditLogChange(r, "apply", target.Kind, target.Name, target.Namespace, body, err == nil, err, map[string]interface{}{
+		"fieldManager": req.fieldManager(),
+		"force":        req.Force,
+	})
 	if err != nil {
-		fmt.Fprintf(w, "event: error\ndata: {\"message\": \"Apply failed: %v\"}\n\n", err)
-		flusher.Flush()
-	} else {
-		resBytes, _ := json.Marshal(result)
-		fmt.Fprintf(w, "event: success\ndata: %s\n\n", resBytes)
-		flusher.Flush()
+		sendError("Apply failed", err)
+		return
 	}
+	send("success", result.Object)
 }
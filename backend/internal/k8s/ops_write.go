@@ -7,6 +7,10 @@ import (
 	"net/http"
 	"strings"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
@@ -73,6 +77,7 @@ func (s *Service) UpdateResourceYAML(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err = resourceService.UpdateResource(ctx, req)
+	utils.AuditLogChange(r, "update", kind, name, namespace, body, err == nil, err, nil)
 	if err != nil {
 		switch {
 		case errors.Is(err, ErrNamespaceMismatch), errors.Is(err, ErrNamespaceRequired):
@@ -98,6 +103,78 @@ func (s *Service) UpdateResourceYAML(w http.ResponseWriter, r *http.Request) {
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Resource updated successfully"})
 }
 
+// DiffResourceYAML previews an update: it takes the same parameters and body as UpdateResourceYAML and
+// returns the changed fields and a unified diff between the live resource and the submitted YAML.
+// Status, managedFields, resourceVersion and other server-maintained metadata are ignored.
+//
+// @Summary Diff entre el recurso en vivo y el YAML editado
+// @Description Devuelve los campos modificados y un diff unificado, sin aplicar cambios. Ignora status, managedFields y resourceVersion
+// @Tags resources
+// @Security Bearer
+// @Accept plain
+// @Produce json
+// @Param kind query string true "Tipo de recurso"
+// @Param name query string true "Nombre del recurso"
+// @Param namespace query string false "Namespace"
+// @Success 200 {object} k8s.ResourceDiff "Cambios y diff unificado"
+// @Failure 400 {object} map[string]string "YAML inválido o namespace incorrecto"
+// @Failure 403 {object} map[string]string "Permiso de edición requerido"
+// @Failure 404 {object} map[string]string "Recurso no encontrado"
+// @Router /api/resource/diff [post]
+func (s *Service) DiffResourceYAML(w http.ResponseWriter, r *http.Request) {
+	kind := r.URL.Query().Get("kind")
+	name := r.URL.Query().Get("name")
+	namespace := r.URL.Query().Get("namespace")
+
+	if kind == "" || name == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing required parameters: kind, name")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Failed to read request body: %v", err))
+		return
+	}
+	defer r.Body.Close()
+
+	resourceService, err := s.resourceServiceFor(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
+
+	diff, err := resourceService.DiffResource(ctx, UpdateResourceRequest{
+		YAMLContent: string(body),
+		Kind:        kind,
+		Name:        name,
+		Namespace:   namespace,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNamespaceMismatch), errors.Is(err, ErrNamespaceRequired), strings.HasPrefix(err.Error(), "invalid YAML"):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, ErrForbidden), errors.Is(err, ErrAdminRequired):
+			utils.ErrorResponse(w, http.StatusForbidden, err.Error())
+		case apierrors.IsNotFound(err):
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+		default:
+			utils.HandleErrorJSON(w, err, "Failed to diff resource", http.StatusInternalServerError, map[string]interface{}{
+				"kind":      kind,
+				"name":      name,
+				"namespace": namespace,
+			})
+		}
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, diff)
+}
+
 // CreateResourceYAML handles HTTP POST requests to create a Kubernetes resource from YAML.
 func (s *Service) CreateResourceYAML(w http.ResponseWriter, r *http.Request) {
 	// Read YAML from request body
@@ -135,6 +212,12 @@ func (s *Service) CreateResourceYAML(w http.ResponseWriter, r *http.Request) {
 
 	// Create the resource
 	result, err := resourceService.CreateResource(ctx, yamlData)
+	kind, name, namespace := manifestRef(body)
+	if created, ok := result.(map[string]interface{}); ok {
+		// Namespaced resources without a namespace are created in "default"
+		namespace, _, _ = unstructured.NestedString(created, "metadata", "namespace")
+	}
+	utils.AuditLogChange(r, "create", kind, name, namespace, body, err == nil, err, nil)
 	if err != nil {
 		utils.HandleErrorJSON(w, err, "Failed to create resource", http.StatusInternalServerError, nil)
 		return
@@ -186,6 +269,13 @@ func (s *Service) ImportResourceYAML(w http.ResponseWriter, r *http.Request) {
 
 	// Import resources
 	result, err := importService.ImportResources(ctx, req)
+	var applied []string
+	if result != nil {
+		applied = result.Applied
+	}
+	utils.AuditLogChange(r, "import", "", "", "", body, err == nil, err, map[string]interface{}{
+		"resources": applied,
+	})
 	if err != nil {
 		utils.HandleErrorJSON(w, err, "Failed to import resources", http.StatusInternalServerError, nil)
 		return
@@ -255,7 +345,11 @@ func (s *Service) DeleteResource(w http.ResponseWriter, r *http.Request) {
 		Force:     force,
 	}
 
-	if err := resourceService.DeleteResource(ctx, req); err != nil {
+	err = resourceService.DeleteResource(ctx, req)
+	utils.AuditLog(r, "delete", kind, name, namespace, err == nil, err, map[string]interface{}{
+		"force": force,
+	})
+	if err != nil {
 		utils.HandleErrorJSON(w, err, "Failed to delete resource", http.StatusInternalServerError, map[string]interface{}{
 			"kind":      kind,
 			"name":      name,
@@ -268,3 +362,19 @@ func (s *Service) DeleteResource(w http.ResponseWriter, r *http.Request) {
 	// Write success response
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Resource deleted successfully"})
 }
+
+// manifestRef returns the kind, name and namespace declared by a YAML manifest, for the audit log.
+// Fields that cannot be read are left empty.
+func manifestRef(body []byte) (kind, name, namespace string) {
+	var manifest struct {
+		Kind     string `json:"kind"`
+		Metadata struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal(body, &manifest); err != nil {
+		return "", "", ""
+	}
+	return manifest.Kind, manifest.Metadata.Name, manifest.Metadata.Namespace
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"fmt"
 
-	appsv1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -161,58 +166,80 @@ func (r *K8sClusterStatsRepository) GetPVCount(ctx context.Context) (int, error)
 	return len(pvs.Items), nil
 }
 
-// DeploymentRepository defines the interface for Deployment operations
-type DeploymentRepository interface {
-	GetScale(ctx context.Context, namespace, name string) (*autoscalingv1.Scale, error)
-	UpdateScale(ctx context.Context, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error)
-	GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error)
-	UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error)
+// WorkloadRepository defines scale and restart operations on workloads of any kind, addressed by GVR
+type WorkloadRepository interface {
+	GetScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*autoscalingv1.Scale, error)
+	UpdateScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error)
+	HasScaleSubresource(ctx context.Context, gvr schema.GroupVersionResource) (bool, error)
+	Patch(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, patch []byte) error
 }
 
-// K8sDeploymentRepository implements DeploymentRepository
-type K8sDeploymentRepository struct {
-	client kubernetes.Interface
+// K8sWorkloadRepository implements WorkloadRepository with the dynamic client, so it also covers custom resources
+type K8sWorkloadRepository struct {
+	dynamicClient dynamic.Interface
+	client        kubernetes.Interface
 }
 
-// NewK8sDeploymentRepository creates a new K8sDeploymentRepository
-func NewK8sDeploymentRepository(client kubernetes.Interface) *K8sDeploymentRepository {
-	return &K8sDeploymentRepository{client: client}
+// NewK8sWorkloadRepository creates a new K8sWorkloadRepository; client is used for discovery
+func NewK8sWorkloadRepository(dynamicClient dynamic.Interface, client kubernetes.Interface) *K8sWorkloadRepository {
+	return &K8sWorkloadRepository{dynamicClient: dynamicClient, client: client}
 }
 
-// GetScale gets the scale of a deployment
-func (r *K8sDeploymentRepository) GetScale(ctx context.Context, namespace, name string) (*autoscalingv1.Scale, error) {
-	scale, err := r.client.AppsV1().Deployments(namespace).GetScale(ctx, name, metav1.GetOptions{})
+// GetScale gets the scale subresource of a workload
+func (r *K8sWorkloadRepository) GetScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*autoscalingv1.Scale, error) {
+	obj, err := r.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{}, "scale")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deployment scale: %w", err)
+		return nil, fmt.Errorf("failed to get %s scale: %w", gvr.Resource, err)
+	}
+	scale := &autoscalingv1.Scale{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, scale); err != nil {
+		return nil, fmt.Errorf("failed to decode %s scale: %w", gvr.Resource, err)
 	}
 	return scale, nil
 }
 
-// UpdateScale updates the scale of a deployment
-func (r *K8sDeploymentRepository) UpdateScale(ctx context.Context, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
-	// Preserve ResourceVersion and other metadata for proper update
-	updateOptions := metav1.UpdateOptions{}
-	updatedScale, err := r.client.AppsV1().Deployments(namespace).UpdateScale(ctx, name, scale, updateOptions)
+// UpdateScale updates the scale subresource of a workload, preserving its ResourceVersion for conflict detection
+func (r *K8sWorkloadRepository) UpdateScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(scale)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update deployment scale: %w", err)
+		return nil, fmt.Errorf("failed to encode %s scale: %w", gvr.Resource, err)
 	}
-	return updatedScale, nil
-}
+	obj := &unstructured.Unstructured{Object: content}
+	obj.SetAPIVersion(autoscalingv1.SchemeGroupVersion.String())
+	obj.SetKind("Scale")
 
-// GetDeployment gets a deployment by name
-func (r *K8sDeploymentRepository) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
-	deployment, err := r.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	updated, err := r.dynamicClient.Resource(gvr).Namespace(namespace).Update(ctx, obj, metav1.UpdateOptions{}, "scale")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deployment: %w", err)
+		return nil, fmt.Errorf("failed to update %s scale: %w", gvr.Resource, err)
 	}
-	return deployment, nil
+	result := &autoscalingv1.Scale{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(updated.Object, result); err != nil {
+		return nil, fmt.Errorf("failed to decode %s scale: %w", gvr.Resource, err)
+	}
+	return result, nil
 }
 
-// UpdateDeployment updates a deployment
-func (r *K8sDeploymentRepository) UpdateDeployment(ctx context.Context, namespace string, deployment *appsv1.Deployment) (*appsv1.Deployment, error) {
-	updatedDeployment, err := r.client.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{})
+// HasScaleSubresource reports whether the API server exposes a scale subresource for a resource
+func (r *K8sWorkloadRepository) HasScaleSubresource(ctx context.Context, gvr schema.GroupVersionResource) (bool, error) {
+	resources, err := r.client.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to update deployment: %w", err)
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to discover %s: %w", gvr.GroupVersion(), err)
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == gvr.Resource+"/scale" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Patch applies a JSON merge patch to a workload
+func (r *K8sWorkloadRepository) Patch(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, patch []byte) error {
+	if _, err := r.dynamicClient.Resource(gvr).Namespace(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch %s: %w", gvr.Resource, err)
 	}
-	return updatedDeployment, nil
+	return nil
 }
@@ -5,11 +5,13 @@ import (
 	"testing"
 
 	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 )
@@ -58,72 +60,74 @@ func TestK8sClusterStatsRepository_Counts(t *testing.T) {
 	}
 }
 
-func TestK8sDeploymentRepository(t *testing.T) {
-	replicas := int32(1)
-	dep := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "deploy",
-			Namespace: "ns",
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-		},
-	}
-	client := k8sfake.NewSimpleClientset(dep)
-	scaleObj := &autoscalingv1.Scale{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      "deploy",
-			Namespace: "ns",
-		},
-		Spec: autoscalingv1.ScaleSpec{
-			Replicas: replicas,
-		},
-	}
-	client.Fake.PrependReactor("get", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
-		if sub, ok := action.(k8stesting.GetAction); ok && sub.GetSubresource() == "scale" {
-			return true, scaleObj, nil
+func TestK8sWorkloadRepository(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "workers"}
+	worker := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Worker",
+		"metadata":   map[string]interface{}{"name": "queue", "namespace": "ns"},
+	}}
+	scheme := runtime.NewScheme()
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{gvr: "WorkerList"}, worker)
+
+	scaleObj := map[string]interface{}{
+		"apiVersion": "autoscaling/v1",
+		"kind":       "Scale",
+		"metadata":   map[string]interface{}{"name": "queue", "namespace": "ns", "resourceVersion": "5"},
+		"spec":       map[string]interface{}{"replicas": int64(1)},
+	}
+	dynamicClient.PrependReactor("get", "workers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "scale" {
+			return true, &unstructured.Unstructured{Object: scaleObj}, nil
 		}
 		return false, nil, nil
 	})
-	client.Fake.PrependReactor("update", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
-		if sub, ok := action.(k8stesting.UpdateAction); ok && sub.GetSubresource() == "scale" {
-			obj := sub.GetObject().(*autoscalingv1.Scale)
-			scaleObj = obj
+	dynamicClient.PrependReactor("update", "workers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if update, ok := action.(k8stesting.UpdateAction); ok && action.GetSubresource() == "scale" {
+			obj := update.GetObject().(*unstructured.Unstructured)
+			scaleObj = obj.Object
 			return true, obj, nil
 		}
 		return false, nil, nil
 	})
-	repo := NewK8sDeploymentRepository(client)
+
+	client := k8sfake.NewSimpleClientset()
+	client.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "example.com/v1",
+		APIResources: []metav1.APIResource{{Name: "workers", Kind: "Worker", Namespaced: true}, {Name: "workers/scale", Kind: "Scale", Namespaced: true}},
+	}}
+	repo := NewK8sWorkloadRepository(dynamicClient, client)
 	ctx := context.Background()
 
-	scale, err := repo.GetScale(ctx, "ns", "deploy")
+	scale, err := repo.GetScale(ctx, gvr, "ns", "queue")
 	if err != nil {
 		t.Fatalf("GetScale error: %v", err)
 	}
-	if scale.Spec.Replicas != replicas {
-		t.Fatalf("expected replicas %d, got %d", replicas, scale.Spec.Replicas)
+	if scale.Spec.Replicas != 1 || scale.ResourceVersion != "5" {
+		t.Fatalf("unexpected scale %+v", scale)
 	}
 
 	scale.Spec.Replicas = 3
-	updatedScale, err := repo.UpdateScale(ctx, "ns", "deploy", scale)
+	updatedScale, err := repo.UpdateScale(ctx, gvr, "ns", "queue", scale)
 	if err != nil {
 		t.Fatalf("UpdateScale error: %v", err)
 	}
-	if updatedScale.Spec.Replicas != 3 {
-		t.Fatalf("scale not updated, got %d", updatedScale.Spec.Replicas)
+	if updatedScale.Spec.Replicas != 3 || scaleObj["kind"] != "Scale" {
+		t.Fatalf("scale not updated, got %+v", scaleObj)
 	}
 
-	deployment, err := repo.GetDeployment(ctx, "ns", "deploy")
-	if err != nil {
-		t.Fatalf("GetDeployment error: %v", err)
+	if ok, err := repo.HasScaleSubresource(ctx, gvr); err != nil || !ok {
+		t.Fatalf("HasScaleSubresource = %v, %v", ok, err)
+	}
+	if ok, err := repo.HasScaleSubresource(ctx, schema.GroupVersionResource{Group: "other.io", Version: "v1", Resource: "things"}); err != nil || ok {
+		t.Fatalf("HasScaleSubresource of an unknown group = %v, %v", ok, err)
 	}
-	deployment.Spec.Replicas = &replicas
 
-	updatedDep, err := repo.UpdateDeployment(ctx, "ns", deployment)
-	if err != nil {
-		t.Fatalf("UpdateDeployment error: %v", err)
+	if err := repo.Patch(ctx, gvr, "ns", "queue", []byte(`{"metadata":{"labels":{"restarted":"true"}}}`)); err != nil {
+		t.Fatalf("Patch error: %v", err)
 	}
-	if updatedDep.Spec.Replicas == nil || *updatedDep.Spec.Replicas != replicas {
-		t.Fatalf("deployment replicas not preserved")
+	patched, _ := dynamicClient.Resource(gvr).Namespace("ns").Get(ctx, "queue", metav1.GetOptions{})
+	if patched.GetLabels()["restarted"] != "true" {
+		t.Fatalf("patch not applied: %v", patched.GetLabels())
 	}
 }
@@ -0,0 +1,194 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// DefaultApplyFieldManager owns the fields of applies that do not name a field manager
+const DefaultApplyFieldManager = "dkonsole-apply"
+
+// ErrInvalidManifest reports a manifest that cannot be applied: bad YAML, missing kind,
+// apiVersion or name, or a resource type the cluster does not know
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// ApplyRequest represents a single-resource manifest for the apply workflow
+type ApplyRequest struct {
+	YAMLContent  string // YAML manifest of one resource
+	Namespace    string // Namespace from the query; must match the manifest namespace when both are set
+	FieldManager string // Field manager recorded for the applied fields (defaults to DefaultApplyFieldManager)
+	Force        bool   // Take over fields owned by other managers instead of failing with a conflict
+}
+
+// fieldManager returns the requested field manager or the default one
+func (req ApplyRequest) fieldManager() string {
+	if req.FieldManager == "" {
+		return DefaultApplyFieldManager
+	}
+	return req.FieldManager
+}
+
+// ManifestTarget identifies the resource a manifest applies to
+type ManifestTarget struct {
+	Kind       string `json:"kind"`
+	APIVersion string `json:"apiVersion"`
+	Resource   string `json:"resource"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+	Namespaced bool   `json:"namespaced"`
+}
+
+// ApplyResult is the outcome of a server-side dry-run or apply
+type ApplyResult struct {
+	Target  ManifestTarget         `json:"target"`
+	DryRun  bool                   `json:"dryRun"`
+	Created bool                   `json:"created"`           // the resource does not exist yet (dry-run only)
+	Diff    []DiffEntry            `json:"diff,omitempty"`    // changes to the live object (dry-run only)
+	Unified string                 `json:"unified,omitempty"` // the same changes as a unified YAML diff
+	Object  map[string]interface{} `json:"object"`
+}
+
+// applyManifest is a parsed, authorized manifest ready to be sent as an apply patch
+type applyManifest struct {
+	obj    *unstructured.Unstructured
+	gvr    schema.GroupVersionResource
+	meta   models.ResourceMeta
+	target ManifestTarget
+}
+
+// prepareApply parses a manifest, resolves its resource type and checks that the user may write it,
+// with the same namespace rules as UpdateResource.
+func (s *ResourceService) prepareApply(ctx context.Context, req ApplyRequest) (*applyManifest, error) {
+	jsonData, err := yaml.YAMLToJSON([]byte(req.YAMLContent))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid YAML: %v", ErrInvalidManifest, err)
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(jsonData, &obj.Object); err != nil || obj.Object == nil {
+		return nil, fmt.Errorf("%w: expected a single resource object", ErrInvalidManifest)
+	}
+	if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+		return nil, fmt.Errorf("%w: missing kind or apiVersion", ErrInvalidManifest)
+	}
+	if obj.GetName() == "" {
+		return nil, fmt.Errorf("%w: missing metadata.name", ErrInvalidManifest)
+	}
+
+	gvr, meta, err := s.gvrResolver.ResolveGVR(ctx, models.NormalizeKind(obj.GetKind()), obj.GetAPIVersion(), "")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown resource type: %v", ErrInvalidManifest, err)
+	}
+
+	if err := authorizeTarget(ctx, obj, meta, req.Namespace); err != nil {
+		return nil, err
+	}
+	removeServerMetadata(obj)
+
+	return &applyManifest{
+		obj:  obj,
+		gvr:  gvr,
+		meta: meta,
+		target: ManifestTarget{
+			Kind:       obj.GetKind(),
+			APIVersion: obj.GetAPIVersion(),
+			Resource:   gvr.Resource,
+			Name:       obj.GetName(),
+			Namespace:  obj.GetNamespace(),
+			Namespaced: meta.Namespaced,
+		},
+	}, nil
+}
+
+// ValidateManifest checks that a manifest parses, names a known resource type and may be written by the user.
+// It does not contact the API server beyond resource discovery.
+func (s *ResourceService) ValidateManifest(ctx context.Context, req ApplyRequest) (*ManifestTarget, error) {
+	manifest, err := s.prepareApply(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &manifest.target, nil
+}
+
+// DryRunApply sends the manifest as a server-side apply with dryRun=All, so admission and validation run
+// without persisting anything, and diffs the would-be object against the live one.
+// Status and server-maintained metadata are left out of the diff.
+func (s *ResourceService) DryRunApply(ctx context.Context, req ApplyRequest) (*ApplyResult, error) {
+	manifest, err := s.prepareApply(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := s.resourceRepo.Get(ctx, manifest.gvr, manifest.target.Name, manifest.target.Namespace, manifest.meta.Namespaced)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get live resource: %w", err)
+	}
+	var liveObject map[string]interface{}
+	if err == nil && live != nil {
+		liveObject = live.Object
+	}
+
+	result, err := s.serverSideApply(ctx, manifest, req, true)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := buildResourceDiff("live", "proposed", liveObject, result.Object)
+	if err != nil {
+		return nil, err
+	}
+	result.Created = liveObject == nil
+	result.Diff = diff.Changes
+	result.Unified = diff.Unified
+	return result, nil
+}
+
+// Apply writes the manifest with server-side apply under the request's field manager.
+// Without Force, fields owned by another manager make the apply fail with a conflict error.
+func (s *ResourceService) Apply(ctx context.Context, req ApplyRequest) (*ApplyResult, error) {
+	manifest, err := s.prepareApply(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.serverSideApply(ctx, manifest, req, false)
+}
+
+func (s *ResourceService) serverSideApply(ctx context.Context, manifest *applyManifest, req ApplyRequest, dryRun bool) (*ApplyResult, error) {
+	patchData, err := json.Marshal(manifest.obj.Object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	force := req.Force
+	patchOptions := metav1.PatchOptions{
+		FieldManager: req.fieldManager(),
+		Force:        &force,
+	}
+	if dryRun {
+		patchOptions.DryRun = []string{metav1.DryRunAll}
+	}
+
+	applied, err := s.resourceRepo.Patch(ctx, manifest.gvr, manifest.target.Name, manifest.target.Namespace, manifest.meta.Namespaced, patchData, types.ApplyPatchType, patchOptions)
+	if err != nil {
+		if dryRun {
+			return nil, fmt.Errorf("dry run failed: %w", err)
+		}
+		return nil, fmt.Errorf("failed to apply resource: %w", err)
+	}
+
+	result := &ApplyResult{Target: manifest.target, DryRun: dryRun}
+	if applied != nil {
+		result.Object = applied.Object
+	}
+	return result, nil
+}
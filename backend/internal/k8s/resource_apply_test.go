@@ -0,0 +1,175 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+const applyDeploymentYAML = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 3
+`
+
+func deploymentsResolver() *fakeGVRResolver {
+	return &fakeGVRResolver{
+		gvr:  schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		meta: models.ResourceMeta{Namespaced: true},
+	}
+}
+
+// appliedDeployment returns a deployment as the API server returns it, with status and server metadata
+func appliedDeployment(replicas int64, resourceVersion string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"namespace":       "shop",
+			"resourceVersion": resourceVersion,
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "dkonsole-apply"}},
+		},
+		"spec":   map[string]interface{}{"replicas": replicas},
+		"status": map[string]interface{}{"observedGeneration": int64(4)},
+	}}
+}
+
+func TestResourceService_DryRunApply_DiffsAgainstLive(t *testing.T) {
+	repo := &fakeResourceRepo{getObj: appliedDeployment(1, "10"), patchResult: appliedDeployment(3, "11")}
+	svc := NewResourceService(repo, deploymentsResolver())
+
+	result, err := svc.DryRunApply(ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: applyDeploymentYAML})
+	if err != nil {
+		t.Fatalf("DryRunApply() error = %v", err)
+	}
+
+	if result.Created || !result.DryRun || result.Target.Name != "web" || result.Target.Namespace != "shop" {
+		t.Errorf("result = %+v", result)
+	}
+	// resourceVersion, managedFields and status differ too but are not reported
+	if len(result.Diff) != 1 || result.Diff[0].Path != "/spec/replicas" || result.Diff[0].Old != int64(1) || result.Diff[0].New != int64(3) {
+		t.Errorf("diff = %+v", result.Diff)
+	}
+
+	opts := repo.patchOptions[0]
+	if len(opts.DryRun) != 1 || opts.DryRun[0] != metav1.DryRunAll {
+		t.Errorf("dry run options = %v", opts.DryRun)
+	}
+	if opts.FieldManager != DefaultApplyFieldManager || opts.Force == nil || *opts.Force {
+		t.Errorf("patch options = %+v", opts)
+	}
+}
+
+func TestResourceService_DryRunApply_NewResource(t *testing.T) {
+	repo := &fakeResourceRepo{
+		getErr:      apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web"),
+		patchResult: appliedDeployment(3, "1"),
+	}
+	svc := NewResourceService(repo, deploymentsResolver())
+
+	result, err := svc.DryRunApply(ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: applyDeploymentYAML})
+	if err != nil {
+		t.Fatalf("DryRunApply() error = %v", err)
+	}
+	if !result.Created || len(result.Diff) == 0 || result.Diff[0].Op != DiffAdd {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestResourceService_Apply_FieldManagerAndForce(t *testing.T) {
+	repo := &fakeResourceRepo{patchResult: appliedDeployment(3, "11")}
+	svc := NewResourceService(repo, deploymentsResolver())
+
+	result, err := svc.Apply(ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{
+		YAMLContent:  applyDeploymentYAML,
+		FieldManager: "ci-pipeline",
+		Force:        true,
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if result.DryRun || result.Object == nil {
+		t.Errorf("result = %+v", result)
+	}
+
+	opts := repo.patchOptions[0]
+	if len(opts.DryRun) != 0 || opts.FieldManager != "ci-pipeline" || opts.Force == nil || !*opts.Force {
+		t.Errorf("patch options = %+v", opts)
+	}
+	if repo.patchName != "web" || repo.patchNamespace != "shop" {
+		t.Errorf("patched %s/%s", repo.patchNamespace, repo.patchName)
+	}
+}
+
+func TestResourceService_Apply_Conflict(t *testing.T) {
+	repo := &fakeResourceRepo{patchErr: apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web", errors.New("field managed by kubectl"))}
+	svc := NewResourceService(repo, deploymentsResolver())
+
+	_, err := svc.Apply(ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: applyDeploymentYAML})
+	if !apierrors.IsConflict(err) {
+		t.Fatalf("Apply() error = %v, want a conflict", err)
+	}
+}
+
+func TestResourceService_Apply_Rejected(t *testing.T) {
+	clusterRole := "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: reader\n"
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		req       ApplyRequest
+		clustered bool
+		want      error
+	}{
+		{"view permission", ctxWithPermissions(map[string]string{"shop": "view"}), ApplyRequest{YAMLContent: applyDeploymentYAML}, false, ErrForbidden},
+		{"namespace mismatch", ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: applyDeploymentYAML, Namespace: "other"}, false, ErrNamespaceMismatch},
+		{"no namespace", ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n"}, false, ErrNamespaceRequired},
+		{"missing name", ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  namespace: shop\n"}, false, ErrInvalidManifest},
+		{"not an object", ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: "- a\n- b\n"}, false, ErrInvalidManifest},
+		{"cluster-scoped without admin", ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{YAMLContent: clusterRole}, true, ErrAdminRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := deploymentsResolver()
+			resolver.meta.Namespaced = !tt.clustered
+			repo := &fakeResourceRepo{}
+			svc := NewResourceService(repo, resolver)
+
+			if _, err := svc.Apply(tt.ctx, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("Apply() error = %v, want %v", err, tt.want)
+			}
+			if _, err := svc.DryRunApply(tt.ctx, tt.req); !errors.Is(err, tt.want) {
+				t.Errorf("DryRunApply() error = %v, want %v", err, tt.want)
+			}
+			if repo.patchCalls != 0 {
+				t.Errorf("patch called %d times", repo.patchCalls)
+			}
+		})
+	}
+}
+
+func TestResourceService_ValidateManifest(t *testing.T) {
+	svc := NewResourceService(&fakeResourceRepo{}, deploymentsResolver())
+
+	target, err := svc.ValidateManifest(ctxWithPermissions(map[string]string{"shop": "edit"}), ApplyRequest{
+		YAMLContent: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n",
+		Namespace:   "shop",
+	})
+	if err != nil {
+		t.Fatalf("ValidateManifest() error = %v", err)
+	}
+	want := ManifestTarget{Kind: "Deployment", APIVersion: "apps/v1", Resource: "deployments", Name: "web", Namespace: "shop", Namespaced: true}
+	if *target != want {
+		t.Errorf("target = %+v", *target)
+	}
+}
@@ -0,0 +1,78 @@
+package k8s
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// resourceConverters convert dynamic client objects of the kinds ListResources supports
+var resourceConverters = map[string]func(*unstructured.Unstructured) (models.Resource, error){
+	"Deployment":              converter(deploymentToResource),
+	"StatefulSet":             converter(statefulSetToResource),
+	"DaemonSet":               converter(daemonSetToResource),
+	"ReplicaSet":              converter(replicaSetToResource),
+	"HorizontalPodAutoscaler": converter(hpaToResource),
+	"Job":                     converter(jobToResource),
+	"CronJob":                 converter(cronJobToResource),
+	"Pod": converter(func(pod *corev1.Pod) models.Resource {
+		return podToResource(pod, models.PodMetric{})
+	}),
+	"Node": converter(func(node *corev1.Node) models.Resource {
+		return nodeToResource(node, models.NodeUsage{})
+	}),
+	"ConfigMap":             converter(configMapToResource),
+	"Secret":                converter(secretToResource),
+	"ServiceAccount":        converter(serviceAccountToResource),
+	"Service":               converter(serviceToResource),
+	"Ingress":               converter(ingressToResource),
+	"NetworkPolicy":         converter(networkPolicyToResource),
+	"Role":                  converter(roleToResource),
+	"ClusterRole":           converter(clusterRoleToResource),
+	"RoleBinding":           converter(roleBindingToResource),
+	"ClusterRoleBinding":    converter(clusterRoleBindingToResource),
+	"PersistentVolumeClaim": converter(pvcToResource),
+	"PersistentVolume":      converter(pvToResource),
+	"StorageClass":          converter(storageClassToResource),
+	"ResourceQuota":         converter(resourceQuotaToResource),
+	"LimitRange":            converter(limitRangeToResource),
+}
+
+// resourceFromUnstructured converts an object returned by the dynamic client into the same
+// Resource shape ListResources produces for its kind. Kinds ListResources does not know about
+// only get the common metadata. Metrics are not included.
+func resourceFromUnstructured(obj *unstructured.Unstructured) (models.Resource, error) {
+	return resourceConverterFor(models.NormalizeKind(obj.GetKind()))(obj)
+}
+
+// resourceConverterFor returns the converter for kind, or one that keeps the common metadata
+func resourceConverterFor(kind string) func(*unstructured.Unstructured) (models.Resource, error) {
+	if convert, ok := resourceConverters[kind]; ok {
+		return convert
+	}
+	return func(obj *unstructured.Unstructured) (models.Resource, error) {
+		return models.Resource{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+			Kind:      kind,
+			Created:   obj.GetCreationTimestamp().Format(time.RFC3339),
+			UID:       string(obj.GetUID()),
+		}, nil
+	}
+}
+
+// converter wraps a list converter so it decodes the object into its typed form first
+func converter[T any](toResource func(*T) models.Resource) func(*unstructured.Unstructured) (models.Resource, error) {
+	return func(obj *unstructured.Unstructured) (models.Resource, error) {
+		typed := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), typed); err != nil {
+			return models.Resource{}, fmt.Errorf("failed to convert %s %s: %w", obj.GetKind(), obj.GetName(), err)
+		}
+		return toResource(typed), nil
+	}
+}
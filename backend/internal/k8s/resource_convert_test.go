@@ -0,0 +1,73 @@
+package k8s
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestResourceFromUnstructured(t *testing.T) {
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "shop", "uid": "u-1"},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "web", "image": "nginx:1.27"}},
+				},
+			},
+		},
+		"status": map[string]interface{}{"readyReplicas": int64(2)},
+	}}
+
+	resource, err := resourceFromUnstructured(deployment)
+	if err != nil {
+		t.Fatalf("resourceFromUnstructured() error = %v", err)
+	}
+	if resource.Kind != "Deployment" || resource.Status != "2/3" || resource.UID != "u-1" {
+		t.Errorf("resource = %+v", resource)
+	}
+	details, ok := resource.Details.(models.DeploymentDetails)
+	if !ok || details.ImageTag != "1.27" || details.PodLabels["app"] != "web" {
+		t.Errorf("details = %+v", resource.Details)
+	}
+
+	// Kinds without a list converter keep the common fields
+	custom := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]interface{}{"name": "w", "namespace": "shop"},
+	}}
+	resource, err = resourceFromUnstructured(custom)
+	if err != nil || resource.Kind != "Widget" || resource.Name != "w" || resource.Details != nil {
+		t.Errorf("resourceFromUnstructured(custom) = %+v, %v", resource, err)
+	}
+
+	invalid := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Pod",
+		"metadata": map[string]interface{}{"name": "p"},
+		"spec":     "not an object",
+	}}
+	if _, err := resourceFromUnstructured(invalid); err == nil {
+		t.Error("expected conversion error")
+	}
+}
+
+func TestResourceConverterFor_UnknownKind(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetName("widget-1")
+	obj.SetNamespace("shop")
+
+	resource, err := resourceConverterFor("Widget")(obj)
+	if err != nil || resource.Kind != "Widget" || resource.Name != "widget-1" {
+		t.Errorf("resource = %+v, %v", resource, err)
+	}
+	if obj.GetKind() != "" {
+		t.Errorf("object was modified: %+v", obj.Object)
+	}
+}
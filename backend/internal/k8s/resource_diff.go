@@ -0,0 +1,374 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// Operations of a DiffEntry
+const (
+	DiffAdd     = "add"
+	DiffRemove  = "remove"
+	DiffReplace = "replace"
+)
+
+// DiffEntry is one changed field between two versions of an object
+type DiffEntry struct {
+	Path string      `json:"path"` // JSON pointer, e.g. /spec/template/spec/containers/0/image
+	Op   string      `json:"op"`   // add, remove or replace
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// diffIgnoredMetadata lists metadata fields the API server maintains; they change on every write
+var diffIgnoredMetadata = []string{"managedFields", "resourceVersion", "generation", "uid", "creationTimestamp", "selfLink"}
+
+// normalizeForDiff returns a copy of an object without status and server-maintained metadata.
+// A nil object (one that does not exist yet) normalizes to an empty object.
+func normalizeForDiff(obj map[string]interface{}) map[string]interface{} {
+	if obj == nil {
+		return map[string]interface{}{}
+	}
+	normalized := runtime.DeepCopyJSON(obj)
+	delete(normalized, "status")
+	if metadata, ok := normalized["metadata"].(map[string]interface{}); ok {
+		for _, field := range diffIgnoredMetadata {
+			delete(metadata, field)
+		}
+	}
+	return normalized
+}
+
+// diffObjects lists the fields that differ between two objects, ordered by path.
+// Maps are compared key by key and lists index by index; added or removed subtrees are reported once.
+func diffObjects(oldObj, newObj map[string]interface{}) []DiffEntry {
+	var entries []DiffEntry
+	diffValues("", oldObj, newObj, &entries)
+	return entries
+}
+
+func diffValues(path string, oldValue, newValue interface{}, entries *[]DiffEntry) {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(oldTyped)+len(newTyped))
+			for key := range oldTyped {
+				keys = append(keys, key)
+			}
+			for key := range newTyped {
+				if _, ok := oldTyped[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				childPath := path + "/" + escapePointerToken(key)
+				oldChild, inOld := oldTyped[key]
+				newChild, inNew := newTyped[key]
+				switch {
+				case !inOld:
+					*entries = append(*entries, DiffEntry{Path: childPath, Op: DiffAdd, New: newChild})
+				case !inNew:
+					*entries = append(*entries, DiffEntry{Path: childPath, Op: DiffRemove, Old: oldChild})
+				default:
+					diffValues(childPath, oldChild, newChild, entries)
+				}
+			}
+			return
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok {
+			for i := 0; i < len(oldTyped) || i < len(newTyped); i++ {
+				childPath := path + "/" + strconv.Itoa(i)
+				switch {
+				case i >= len(oldTyped):
+					*entries = append(*entries, DiffEntry{Path: childPath, Op: DiffAdd, New: newTyped[i]})
+				case i >= len(newTyped):
+					*entries = append(*entries, DiffEntry{Path: childPath, Op: DiffRemove, Old: oldTyped[i]})
+				default:
+					diffValues(childPath, oldTyped[i], newTyped[i], entries)
+				}
+			}
+			return
+		}
+	}
+
+	if !scalarEqual(oldValue, newValue) {
+		*entries = append(*entries, DiffEntry{Path: path, Op: DiffReplace, Old: oldValue, New: newValue})
+	}
+}
+
+// scalarEqual compares leaf values; numbers are equal across int64 and float64,
+// since objects decoded from YAML and from the API server represent them differently
+func scalarEqual(a, b interface{}) bool {
+	if fa, ok := toFloat(a); ok {
+		if fb, ok := toFloat(b); ok {
+			return fa == fb
+		}
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// escapePointerToken escapes a map key for use in a JSON pointer (RFC 6901)
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
+
+// ResourceDiff describes the changes between two versions of an object,
+// both as a list of changed fields and as a unified diff of their YAML
+type ResourceDiff struct {
+	Changes []DiffEntry `json:"changes"`
+	Unified string      `json:"unified"` // empty when nothing changed
+}
+
+// diffContextLines is the number of unchanged lines shown around each change in a unified diff
+const diffContextLines = 3
+
+// maxDiffEdits bounds the line diff; past it the changed region is shown as one replaced block
+const maxDiffEdits = 1000
+
+// buildResourceDiff diffs two normalized objects, labelling the unified diff with their names.
+// A nil object (one that does not exist) diffs as an empty one.
+func buildResourceDiff(fromName, toName string, from, to map[string]interface{}) (*ResourceDiff, error) {
+	oldObj, newObj := normalizeForDiff(from), normalizeForDiff(to)
+
+	changes := diffObjects(oldObj, newObj)
+	if changes == nil {
+		changes = []DiffEntry{}
+	}
+	result := &ResourceDiff{Changes: changes}
+	if len(changes) == 0 {
+		return result, nil
+	}
+
+	oldYAML, err := renderDiffYAML(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newYAML, err := renderDiffYAML(newObj)
+	if err != nil {
+		return nil, err
+	}
+	result.Unified = unifiedDiff(fromName, toName, oldYAML, newYAML)
+	return result, nil
+}
+
+// renderDiffYAML renders an object as YAML with sorted keys; an empty object renders as no lines
+func renderDiffYAML(obj map[string]interface{}) (string, error) {
+	if len(obj) == 0 {
+		return "", nil
+	}
+	jsonData, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal object: %w", err)
+	}
+	yamlData, err := yaml.JSONToYAML(jsonData)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert to YAML: %w", err)
+	}
+	return string(yamlData), nil
+}
+
+// lineOp is one line of a line diff: ' ' kept, '-' removed or '+' added
+type lineOp struct {
+	kind byte
+	text string
+}
+
+// unifiedDiff returns the unified diff of two texts, or "" when they are equal
+func unifiedDiff(fromName, toName, from, to string) string {
+	ops := diffLines(splitLines(from), splitLines(to))
+
+	// Group changes into hunks, merging those whose context overlaps
+	var hunks [][2]int
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		lo, hi := i-diffContextLines, i+diffContextLines+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+		if n := len(hunks); n > 0 && lo <= hunks[n-1][1] {
+			hunks[n-1][1] = hi
+		} else {
+			hunks = append(hunks, [2]int{lo, hi})
+		}
+	}
+	if len(hunks) == 0 {
+		return ""
+	}
+
+	// Line numbers before each op
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	for _, hunk := range hunks {
+		lo, hi := hunk[0], hunk[1]
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(oldLine[lo], oldLine[hi]-oldLine[lo]),
+			hunkRange(newLine[lo], newLine[hi]-newLine[lo]))
+		for _, op := range ops[lo:hi] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.text)
+			out.WriteByte('\n')
+		}
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,count of a hunk header; empty ranges start at the line before them
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	if count == 1 {
+		return strconv.Itoa(before + 1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLines computes a shortest line edit script with the Myers algorithm.
+// Past maxDiffEdits it stops and reports the lines between the common prefix and suffix as replaced.
+func diffLines(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	limit := n + m
+	if limit > maxDiffEdits {
+		limit = maxDiffEdits
+	}
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	// trace[d] holds v[-d..d] as it was before step d, for backtracking
+	var trace [][]int
+
+	for d := 0; d <= limit; d++ {
+		snapshot := make([]int, 2*d+1)
+		copy(snapshot, v[offset-d:offset+d+1])
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrackLines(a, b, trace)
+			}
+		}
+	}
+	return replaceLines(a, b)
+}
+
+// backtrackLines walks the Myers trace back from the end of both inputs
+func backtrackLines(a, b []string, trace [][]int) []lineOp {
+	var ops []lineOp
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && v[k-1+d] < v[k+1+d]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[prevK+d]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, lineOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			ops = append(ops, lineOp{'+', b[y-1]})
+		} else {
+			ops = append(ops, lineOp{'-', a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, lineOp{' ', a[x-1]})
+		x--
+		y--
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// replaceLines is the fallback edit script: common prefix and suffix kept, everything between replaced
+func replaceLines(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]lineOp, 0, len(a)+len(b)-prefix-suffix)
+	for _, line := range a[:prefix] {
+		ops = append(ops, lineOp{' ', line})
+	}
+	for _, line := range a[prefix : len(a)-suffix] {
+		ops = append(ops, lineOp{'-', line})
+	}
+	for _, line := range b[prefix : len(b)-suffix] {
+		ops = append(ops, lineOp{'+', line})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, lineOp{' ', line})
+	}
+	return ops
+}
@@ -0,0 +1,164 @@
+package k8s
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDiffObjects(t *testing.T) {
+	oldObj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{"app.kubernetes.io/name": "web", "tier": "front"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"ports":    []interface{}{int64(80)},
+		},
+	}
+	newObj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": map[string]interface{}{"app.kubernetes.io/name": "api"},
+		},
+		"spec": map[string]interface{}{
+			"replicas": float64(2), // same number, decoded from YAML
+			"ports":    []interface{}{int64(80), int64(443)},
+			"paused":   true,
+		},
+	}
+
+	got := diffObjects(oldObj, newObj)
+	want := []DiffEntry{
+		{Path: "/metadata/labels/app.kubernetes.io~1name", Op: DiffReplace, Old: "web", New: "api"},
+		{Path: "/metadata/labels/tier", Op: DiffRemove, Old: "front"},
+		{Path: "/spec/paused", Op: DiffAdd, New: true},
+		{Path: "/spec/ports/1", Op: DiffAdd, New: int64(443)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffObjects() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiffObjects_TypeChange(t *testing.T) {
+	got := diffObjects(
+		map[string]interface{}{"data": map[string]interface{}{"k": "v"}},
+		map[string]interface{}{"data": "inline"},
+	)
+	if len(got) != 1 || got[0].Path != "/data" || got[0].Op != DiffReplace {
+		t.Errorf("diffObjects() = %+v", got)
+	}
+}
+
+func TestNormalizeForDiff(t *testing.T) {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":            "web",
+			"resourceVersion": "42",
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+			"uid":             "abc",
+		},
+		"spec":   map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{"readyReplicas": int64(1)},
+	}
+
+	normalized := normalizeForDiff(obj)
+	want := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web"},
+		"spec":     map[string]interface{}{"replicas": int64(1)},
+	}
+	if !reflect.DeepEqual(normalized, want) {
+		t.Errorf("normalizeForDiff() = %v", normalized)
+	}
+	if _, ok := obj["status"]; !ok {
+		t.Error("normalizeForDiff() modified its input")
+	}
+	if got := normalizeForDiff(nil); got == nil || len(got) != 0 {
+		t.Errorf("normalizeForDiff(nil) = %v", got)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	from := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	to := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+
+	want := `--- live
++++ proposed
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,3 +9,4 @@
+ i
+ j
+ k
++l
+`
+	if got := unifiedDiff("live", "proposed", from, to); got != want {
+		t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+	}
+	if got := unifiedDiff("live", "proposed", from, from); got != "" {
+		t.Errorf("unifiedDiff() of equal texts = %q", got)
+	}
+}
+
+func TestUnifiedDiff_FromEmpty(t *testing.T) {
+	want := "--- live\n+++ proposed\n@@ -0,0 +1,2 @@\n+a\n+b\n"
+	if got := unifiedDiff("live", "proposed", "", "a\nb\n"); got != want {
+		t.Errorf("unifiedDiff() = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLines_FallbackKeepsCommonLines(t *testing.T) {
+	a := []string{"head"}
+	b := []string{"head"}
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, "old"+strconv.Itoa(i))
+		b = append(b, "new"+strconv.Itoa(i))
+	}
+	a = append(a, "tail")
+	b = append(b, "tail")
+
+	ops := diffLines(a, b)
+	if len(ops) != 2+2*maxDiffEdits || ops[0] != (lineOp{' ', "head"}) || ops[len(ops)-1] != (lineOp{' ', "tail"}) {
+		t.Fatalf("unexpected fallback script: %d ops", len(ops))
+	}
+	if ops[1].kind != '-' || ops[len(ops)-2].kind != '+' {
+		t.Errorf("expected removals then additions, got %c ... %c", ops[1].kind, ops[len(ops)-2].kind)
+	}
+}
+
+func TestBuildResourceDiff(t *testing.T) {
+	live := map[string]interface{}{
+		"kind":     "ConfigMap",
+		"metadata": map[string]interface{}{"name": "cfg", "resourceVersion": "7"},
+		"data":     map[string]interface{}{"mode": "slow"},
+	}
+	proposed := map[string]interface{}{
+		"kind":     "ConfigMap",
+		"metadata": map[string]interface{}{"name": "cfg"},
+		"data":     map[string]interface{}{"mode": "fast"},
+	}
+
+	diff, err := buildResourceDiff("live", "proposed", live, proposed)
+	if err != nil {
+		t.Fatalf("buildResourceDiff() error = %v", err)
+	}
+	if len(diff.Changes) != 1 || diff.Changes[0].Path != "/data/mode" {
+		t.Errorf("changes = %+v", diff.Changes)
+	}
+	if !strings.Contains(diff.Unified, "-  mode: slow\n+  mode: fast\n") {
+		t.Errorf("unified = %s", diff.Unified)
+	}
+
+	same, err := buildResourceDiff("live", "proposed", live, live)
+	if err != nil {
+		t.Fatalf("buildResourceDiff() error = %v", err)
+	}
+	if same.Changes == nil || len(same.Changes) != 0 || same.Unified != "" {
+		t.Errorf("identical objects diff = %+v", same)
+	}
+}
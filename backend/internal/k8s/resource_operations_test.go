@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -384,7 +385,9 @@ func TestWatchResources_Success(t *testing.T) {
 	mockFactory.watchSvc = watchSvc
 	service.serviceFactory = mockFactory
 
-	server := httptest.NewServer(http.HandlerFunc(service.WatchResources))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service.WatchResources(w, adminCtx(r))
+	}))
 	defer server.Close()
 
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/?kind=Pod&namespace=default"
@@ -427,7 +430,9 @@ func TestWatchResources_StartWatchError(t *testing.T) {
 	mockFactory.watchSvc = watchSvc
 	service.serviceFactory = mockFactory
 
-	server := httptest.NewServer(http.HandlerFunc(service.WatchResources))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service.WatchResources(w, adminCtx(r))
+	}))
 	defer server.Close()
 
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/?kind=Pod&namespace=default"
@@ -457,12 +462,10 @@ func TestDryRunResourceYAML_Success(t *testing.T) {
 	req := adminCtx(httptest.NewRequest(http.MethodPost, "/api/resource/dry-run", body))
 	rr := httptest.NewRecorder()
 
-	// Mock dynamic client behaviors (create returns object)
-	// We need to inject reactor for Create to return the object
+	// Mock dynamic client behaviors (the dry-run apply patch returns the object)
 	dyn := handlers.Dynamics["default"].(*dynamicfake.FakeDynamicClient)
-	dyn.PrependReactor("create", "pods", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
-		createAction := action.(k8stesting.CreateAction)
-		return true, createAction.GetObject(), nil
+	dyn.PrependReactor("patch", "pods", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
+		return true, &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "Pod", "metadata": map[string]interface{}{"name": "dry-pod", "namespace": "default"}}}, nil
 	})
 
 	service.DryRunResourceYAML(rr, req)
@@ -480,7 +483,7 @@ func TestValidateResourceYAML_Success(t *testing.T) {
 	clusterService := cluster.NewService(handlers)
 	service := NewService(handlers, clusterService)
 
-	body := bytes.NewBufferString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: val-pod\n")
+	body := bytes.NewBufferString("apiVersion: v1\nkind: Pod\nmetadata:\n  name: val-pod\n  namespace: default\n")
 	req := adminCtx(httptest.NewRequest(http.MethodPost, "/api/resource/validate", body))
 	rr := httptest.NewRecorder()
 
@@ -586,3 +589,133 @@ func TestImportResourceYAMLHandler_ImportError(t *testing.T) {
 		t.Fatalf("expected internal error to be hidden, got %s", rr.Body.String())
 	}
 }
+
+func newApplyTestService(repo *fakeResourceRepo) *Service {
+	handlers := newHandlersWithDynamic()
+	service := NewService(handlers, cluster.NewService(handlers))
+	mockFactory := newMockServiceFactory()
+	mockFactory.resourceSvc = NewResourceService(repo, deploymentsResolver())
+	service.serviceFactory = mockFactory
+	return service
+}
+
+func TestDryRunResourceYAML_Diff(t *testing.T) {
+	repo := &fakeResourceRepo{getObj: appliedDeployment(1, "10"), patchResult: appliedDeployment(3, "11")}
+	service := newApplyTestService(repo)
+
+	req := editCtx(httptest.NewRequest(http.MethodPost, "/api/resource/dry-run", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.DryRunResourceYAML(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"path":"/spec/replicas"`) {
+		t.Errorf("expected replicas diff, got %s", rr.Body.String())
+	}
+}
+
+func TestDryRunResourceYAML_Forbidden(t *testing.T) {
+	repo := &fakeResourceRepo{}
+	service := newApplyTestService(repo)
+
+	req := viewCtx(httptest.NewRequest(http.MethodPost, "/api/resource/dry-run", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.DryRunResourceYAML(rr, req)
+
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rr.Code)
+	}
+	if repo.patchCalls != 0 {
+		t.Errorf("patch called without permission")
+	}
+}
+
+func TestDryRunResourceYAML_Conflict(t *testing.T) {
+	repo := &fakeResourceRepo{patchErr: apierrors.NewConflict(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web", errors.New("conflict with kubectl"))}
+	service := newApplyTestService(repo)
+
+	req := editCtx(httptest.NewRequest(http.MethodPost, "/api/resource/dry-run", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.DryRunResourceYAML(rr, req)
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "force=true") {
+		t.Errorf("expected force hint, got %s", rr.Body.String())
+	}
+}
+
+func TestServerSideApply_Workflow(t *testing.T) {
+	repo := &fakeResourceRepo{getObj: appliedDeployment(1, "10"), patchResult: appliedDeployment(3, "11")}
+	service := newApplyTestService(repo)
+
+	req := editCtx(httptest.NewRequest(http.MethodPost, "/api/resource/apply?fieldManager=ci&force=true", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.ServerSideApply(rr, req)
+
+	resp := rr.Body.String()
+	validated := strings.Index(resp, "event: validated")
+	dryRun := strings.Index(resp, "event: dry-run")
+	success := strings.Index(resp, "event: success")
+	if validated < 0 || dryRun < validated || success < dryRun {
+		t.Fatalf("expected validated, dry-run and success events in order, got %s", resp)
+	}
+	if len(repo.patchOptions) != 2 || len(repo.patchOptions[0].DryRun) != 1 || len(repo.patchOptions[1].DryRun) != 0 {
+		t.Fatalf("expected a dry-run patch then an apply, got %+v", repo.patchOptions)
+	}
+	if repo.patchOptions[1].FieldManager != "ci" || !*repo.patchOptions[1].Force {
+		t.Errorf("apply options = %+v", repo.patchOptions[1])
+	}
+}
+
+func TestServerSideApply_Forbidden(t *testing.T) {
+	repo := &fakeResourceRepo{}
+	service := newApplyTestService(repo)
+
+	req := viewCtx(httptest.NewRequest(http.MethodPost, "/api/resource/apply", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.ServerSideApply(rr, req)
+
+	resp := rr.Body.String()
+	if !strings.Contains(resp, "event: error") || !strings.Contains(resp, `"status":403`) {
+		t.Fatalf("expected forbidden error event, got %s", resp)
+	}
+	if repo.patchCalls != 0 {
+		t.Errorf("patch called without permission")
+	}
+}
+
+func TestDiffResourceYAMLHandler(t *testing.T) {
+	repo := &fakeResourceRepo{getObj: appliedDeployment(1, "10")}
+	service := newApplyTestService(repo)
+
+	body := bytes.NewBufferString("apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\nspec:\n  replicas: 2\n")
+	req := editCtx(httptest.NewRequest(http.MethodPost, "/api/resource/diff?kind=Deployment&name=web&namespace=shop", body), "shop")
+	rr := httptest.NewRecorder()
+	service.DiffResourceYAML(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if !strings.Contains(rr.Body.String(), `"path":"/spec/replicas"`) || !strings.Contains(rr.Body.String(), `+  replicas: 2`) {
+		t.Errorf("unexpected diff: %s", rr.Body.String())
+	}
+	if repo.patchCalls != 0 {
+		t.Errorf("diff patched the resource")
+	}
+}
+
+func TestDiffResourceYAMLHandler_NotFound(t *testing.T) {
+	repo := &fakeResourceRepo{getErr: apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: "deployments"}, "web")}
+	service := newApplyTestService(repo)
+
+	req := editCtx(httptest.NewRequest(http.MethodPost, "/api/resource/diff?kind=Deployment&name=web&namespace=shop", bytes.NewBufferString(applyDeploymentYAML)), "shop")
+	rr := httptest.NewRecorder()
+	service.DiffResourceYAML(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
@@ -23,6 +23,7 @@ type ResourceRepository interface {
 	Create(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool, obj *unstructured.Unstructured, options metav1.CreateOptions) (*unstructured.Unstructured, error)
 	Patch(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, namespaced bool, patchData []byte, patchType types.PatchType, options metav1.PatchOptions) (*unstructured.Unstructured, error)
 	Delete(ctx context.Context, gvr schema.GroupVersionResource, name, namespace string, namespaced bool, options metav1.DeleteOptions) error
+	List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool, options metav1.ListOptions) (*unstructured.UnstructuredList, error)
 }
 
 // K8sResourceRepository implements ResourceRepository using dynamic client
@@ -84,6 +85,16 @@ func (r *K8sResourceRepository) Delete(ctx context.Context, gvr schema.GroupVers
 	return nil
 }
 
+// List lists resources matching the options
+func (r *K8sResourceRepository) List(ctx context.Context, gvr schema.GroupVersionResource, namespace string, namespaced bool, options metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	res := r.getResourceInterface(gvr, namespace, namespaced)
+	list, err := res.List(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return list, nil
+}
+
 // GVRResolver resolves GroupVersionResource from kind and apiVersion
 type GVRResolver interface {
 	ResolveGVR(ctx context.Context, kind, apiVersion string, namespacedParam string) (schema.GroupVersionResource, models.ResourceMeta, error)
@@ -69,14 +69,14 @@ func TestK8sResourceRepository_CRUD(t *testing.T) {
 
 func TestK8sResourceRepository_Errors(t *testing.T) {
 	scheme := runtime.NewScheme()
-	client := dynamicfake.NewSimpleDynamicClient(scheme)
+	gvr := schema.GroupVersionResource{Group: "group", Version: "v1", Resource: "res"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{gvr: "ResList"})
 	// Inject error reactor
 	client.PrependReactor("*", "*", func(action k8stesting.Action) (handled bool, ret runtime.Object, err error) {
 		return true, nil, errors.New("simulated error")
 	})
 	repo := NewK8sResourceRepository(client)
 	ctx := context.Background()
-	gvr := schema.GroupVersionResource{Group: "group", Version: "v1", Resource: "res"}
 
 	_, err := repo.Get(ctx, gvr, "name", "ns", true)
 	assert.Error(t, err)
@@ -89,6 +89,33 @@ func TestK8sResourceRepository_Errors(t *testing.T) {
 
 	err = repo.Delete(ctx, gvr, "name", "ns", true, metav1.DeleteOptions{})
 	assert.Error(t, err)
+
+	_, err = repo.List(ctx, gvr, "ns", true, metav1.ListOptions{})
+	assert.Error(t, err)
+}
+
+func TestK8sResourceRepository_List(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	job := func(name, namespace string, labels map[string]interface{}) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"metadata":   map[string]interface{}{"name": name, "namespace": namespace, "labels": labels},
+		}}
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "JobList"},
+		job("failed-1", "ns1", map[string]interface{}{"status": "failed"}),
+		job("ok-1", "ns1", map[string]interface{}{"status": "ok"}),
+		job("failed-2", "ns2", map[string]interface{}{"status": "failed"}),
+	)
+	repo := NewK8sResourceRepository(client)
+
+	list, err := repo.List(context.Background(), gvr, "ns1", true, metav1.ListOptions{LabelSelector: "status=failed"})
+	assert.NoError(t, err)
+	if assert.Len(t, list.Items, 1) {
+		assert.Equal(t, "failed-1", list.Items[0].GetName())
+	}
 }
 
 func TestResolveGVR(t *testing.T) {
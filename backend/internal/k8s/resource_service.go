@@ -8,6 +8,7 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/yaml"
 
@@ -51,16 +52,66 @@ type UpdateResourceRequest struct {
 // Returns an error if the YAML is invalid, the resource cannot be found, or the update fails.
 // It validates namespace permissions before updating.
 func (s *ResourceService) UpdateResource(ctx context.Context, req UpdateResourceRequest) error {
+	obj, gvr, meta, err := s.prepareUpdate(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	// Marshal for patch
+	patchData, err := json.Marshal(obj.Object)
+	if err != nil {
+		return fmt.Errorf("failed to marshal patch: %w", err)
+	}
+
+	// Apply patch
+	force := true
+	patchOptions := metav1.PatchOptions{
+		FieldManager: "dkonsole",
+		Force:        &force,
+	}
+
+	_, err = s.resourceRepo.Patch(ctx, gvr, obj.GetName(), obj.GetNamespace(), meta.Namespaced, patchData, types.ApplyPatchType, patchOptions)
+	if err != nil {
+		return fmt.Errorf("failed to update resource: %w", err)
+	}
+
+	return nil
+}
+
+// DiffResource compares the live resource with the YAML an update would submit, without writing anything.
+// It applies the same parsing and permission checks as UpdateResource; status and server-maintained
+// metadata (managedFields, resourceVersion, uid, ...) are left out of the comparison.
+func (s *ResourceService) DiffResource(ctx context.Context, req UpdateResourceRequest) (*ResourceDiff, error) {
+	obj, gvr, meta, err := s.prepareUpdate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := s.resourceRepo.Get(ctx, gvr, obj.GetName(), obj.GetNamespace(), meta.Namespaced)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource: %w", err)
+	}
+	var liveObject map[string]interface{}
+	if live != nil {
+		liveObject = live.Object
+	}
+
+	return buildResourceDiff("live", "proposed", liveObject, obj.Object)
+}
+
+// prepareUpdate parses the YAML of an update, resolves its GroupVersionResource and checks namespace permissions.
+// The returned object carries the effective namespace and name, without server-maintained metadata.
+func (s *ResourceService) prepareUpdate(ctx context.Context, req UpdateResourceRequest) (*unstructured.Unstructured, schema.GroupVersionResource, models.ResourceMeta, error) {
 	// Parse YAML to JSON
 	jsonData, err := yaml.YAMLToJSON([]byte(req.YAMLContent))
 	if err != nil {
-		return fmt.Errorf("invalid YAML: %w", err)
+		return nil, schema.GroupVersionResource{}, models.ResourceMeta{}, fmt.Errorf("invalid YAML: %w", err)
 	}
 
 	// Unmarshal to unstructured
-	var obj unstructured.Unstructured
+	obj := &unstructured.Unstructured{}
 	if err := json.Unmarshal(jsonData, &obj.Object); err != nil {
-		return fmt.Errorf("failed to parse resource: %w", err)
+		return nil, schema.GroupVersionResource{}, models.ResourceMeta{}, fmt.Errorf("failed to parse resource: %w", err)
 	}
 
 	normalizedKind := models.NormalizeKind(req.Kind)
@@ -71,70 +122,68 @@ func (s *ResourceService) UpdateResource(ctx context.Context, req UpdateResource
 	// Resolve GVR
 	gvr, meta, err := s.gvrResolver.ResolveGVR(ctx, normalizedKind, obj.GetAPIVersion(), "")
 	if err != nil {
-		return fmt.Errorf("failed to resolve GVR: %w", err)
+		return nil, schema.GroupVersionResource{}, models.ResourceMeta{}, fmt.Errorf("failed to resolve GVR: %w", err)
 	}
 
-	queryNamespace := req.Namespace
-	yamlNamespace := obj.GetNamespace()
-
 	// Enforce authorization on the effective namespace (derived from YAML and/or query param).
-	if meta.Namespaced {
-		if queryNamespace != "" && yamlNamespace != "" && queryNamespace != yamlNamespace {
-			return fmt.Errorf("%w: query namespace %q does not match yaml namespace %q", ErrNamespaceMismatch, queryNamespace, yamlNamespace)
-		}
+	if err := authorizeTarget(ctx, obj, meta, req.Namespace); err != nil {
+		return nil, schema.GroupVersionResource{}, models.ResourceMeta{}, err
+	}
 
-		effectiveNamespace := yamlNamespace
-		if effectiveNamespace == "" {
-			effectiveNamespace = queryNamespace
-		}
-		if effectiveNamespace == "" {
-			return fmt.Errorf("%w", ErrNamespaceRequired)
-		}
+	// Normalize name
+	if req.Name != "" {
+		obj.SetName(req.Name)
+	}
 
-		if err := requireEditPermission(ctx, effectiveNamespace); err != nil {
-			return err
-		}
+	// Cleanup metadata
+	removeServerMetadata(obj)
+
+	return obj, gvr, meta, nil
+}
+
+// authorizeTarget enforces authorization on the namespace a manifest is written to.
+// For namespaced resources the effective namespace comes from the YAML and/or the query parameter,
+// which must agree, and requires edit permission; cluster-scoped resources require admin.
+// The object's namespace is set to the effective one.
+func authorizeTarget(ctx context.Context, obj *unstructured.Unstructured, meta models.ResourceMeta, queryNamespace string) error {
+	yamlNamespace := obj.GetNamespace()
 
-		obj.SetNamespace(effectiveNamespace)
-	} else {
+	if !meta.Namespaced {
 		if err := requireAdmin(ctx); err != nil {
 			return err
 		}
 		obj.SetNamespace("")
+		return nil
 	}
 
-	// Normalize name
-	if req.Name != "" {
-		obj.SetName(req.Name)
+	if queryNamespace != "" && yamlNamespace != "" && queryNamespace != yamlNamespace {
+		return fmt.Errorf("%w: query namespace %q does not match yaml namespace %q", ErrNamespaceMismatch, queryNamespace, yamlNamespace)
 	}
 
-	// Cleanup metadata
-	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
-	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
-	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
-	unstructured.RemoveNestedField(obj.Object, "metadata", "uid")
-
-	// Marshal for patch
-	patchData, err := json.Marshal(obj.Object)
-	if err != nil {
-		return fmt.Errorf("failed to marshal patch: %w", err)
+	effectiveNamespace := yamlNamespace
+	if effectiveNamespace == "" {
+		effectiveNamespace = queryNamespace
 	}
-
-	// Apply patch
-	force := true
-	patchOptions := metav1.PatchOptions{
-		FieldManager: "dkonsole",
-		Force:        &force,
+	if effectiveNamespace == "" {
+		return fmt.Errorf("%w", ErrNamespaceRequired)
 	}
 
-	_, err = s.resourceRepo.Patch(ctx, gvr, obj.GetName(), obj.GetNamespace(), meta.Namespaced, patchData, types.ApplyPatchType, patchOptions)
-	if err != nil {
-		return fmt.Errorf("failed to update resource: %w", err)
+	if err := requireEditPermission(ctx, effectiveNamespace); err != nil {
+		return err
 	}
 
+	obj.SetNamespace(effectiveNamespace)
 	return nil
 }
 
+// removeServerMetadata drops metadata the API server owns, which an apply patch must not carry
+func removeServerMetadata(obj *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(obj.Object, "metadata", "uid")
+}
+
 func requireEditPermission(ctx context.Context, namespace string) error {
 	claims, err := permissions.GetUserFromContext(ctx)
 	if err != nil {
@@ -232,23 +281,27 @@ func (s *ResourceService) DeleteResource(ctx context.Context, req DeleteResource
 		}
 	}
 
+	err = s.resourceRepo.Delete(ctx, gvr, req.Name, req.Namespace, meta.Namespaced, deleteOptions(req.Force))
+	if err != nil {
+		return fmt.Errorf("failed to delete resource: %w", err)
+	}
+	return nil
+}
+
+// deleteOptions returns the options of a regular deletion (30s grace period, foreground propagation)
+// or, if force is set, of an immediate one (no grace period, background propagation)
+func deleteOptions(force bool) metav1.DeleteOptions {
 	var grace int64 = 30
 	propagation := metav1.DeletePropagationForeground
-	if req.Force {
+	if force {
 		grace = 0
 		propagation = metav1.DeletePropagationBackground
 	}
 
-	delOpts := metav1.DeleteOptions{
+	return metav1.DeleteOptions{
 		GracePeriodSeconds: &grace,
 		PropagationPolicy:  &propagation,
 	}
-
-	err = s.resourceRepo.Delete(ctx, gvr, req.Name, req.Namespace, meta.Namespaced, delOpts)
-	if err != nil {
-		return fmt.Errorf("failed to delete resource: %w", err)
-	}
-	return nil
 }
 
 // GetResourceRequest represents parameters for retrieving a Kubernetes resource.
@@ -293,3 +293,57 @@ func TestResourceService_CreateResource_DefaultNamespace(t *testing.T) {
 		t.Fatalf("expected returned object kind ConfigMap, got %v", result)
 	}
 }
+
+func TestResourceService_DiffResource(t *testing.T) {
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":            "demo",
+			"namespace":       "default",
+			"resourceVersion": "12",
+			"managedFields":   []interface{}{map[string]interface{}{"manager": "kubectl"}},
+		},
+		"spec":   map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}}
+	resolver := &fakeGVRResolver{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, meta: models.ResourceMeta{Namespaced: true}}
+	repo := &fakeResourceRepo{getObj: live}
+	svc := NewResourceService(repo, resolver)
+
+	// The editor submits the YAML it was given, with status and a stale resourceVersion
+	yamlContent := "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: demo\n  resourceVersion: \"9\"\nspec:\n  replicas: 3\nstatus:\n  replicas: 0\n"
+	diff, err := svc.DiffResource(ctxWithPermissions(map[string]string{"default": "edit"}), UpdateResourceRequest{
+		YAMLContent: yamlContent,
+		Kind:        "Deployment",
+		Name:        "demo",
+		Namespace:   "default",
+	})
+	if err != nil {
+		t.Fatalf("DiffResource() error = %v", err)
+	}
+
+	if len(diff.Changes) != 1 || diff.Changes[0].Path != "/spec/replicas" || diff.Changes[0].Op != DiffReplace {
+		t.Errorf("changes = %+v", diff.Changes)
+	}
+	if !strings.Contains(diff.Unified, "-  replicas: 1\n+  replicas: 3\n") || strings.Contains(diff.Unified, "status") {
+		t.Errorf("unified = %s", diff.Unified)
+	}
+	if repo.patchCalls != 0 {
+		t.Errorf("DiffResource() patched the resource")
+	}
+}
+
+func TestResourceService_DiffResource_Forbidden(t *testing.T) {
+	resolver := &fakeGVRResolver{gvr: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, meta: models.ResourceMeta{Namespaced: true}}
+	svc := NewResourceService(&fakeResourceRepo{}, resolver)
+
+	_, err := svc.DiffResource(ctxWithPermissions(map[string]string{"default": "view"}), UpdateResourceRequest{
+		YAMLContent: "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: demo\n",
+		Name:        "demo",
+		Namespace:   "default",
+	})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("DiffResource() error = %v, want ErrForbidden", err)
+	}
+}
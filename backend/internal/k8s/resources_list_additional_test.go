@@ -43,7 +43,7 @@ func TestResourceListService_listNodes(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(readyNode, notReadyNode)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listNodes(context.Background(), client, nil, metav1.ListOptions{})
+	resources, _, err := service.listNodes(context.Background(), client, nil, metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listNodes returned error: %v", err)
 	}
@@ -89,7 +89,7 @@ func TestResourceListService_listPods(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(pod)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listPods(context.Background(), client, nil, "ns", metav1.ListOptions{})
+	resources, _, err := service.listPods(context.Background(), client, nil, "ns", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listPods returned error: %v", err)
 	}
@@ -115,7 +115,7 @@ func TestResourceListService_listConfigMaps(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(cm)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listConfigMaps(context.Background(), client, "ns", metav1.ListOptions{})
+	resources, _, err := service.listConfigMaps(context.Background(), client, "ns", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listConfigMaps returned error: %v", err)
 	}
@@ -139,7 +139,7 @@ func TestResourceListService_listServices(t *testing.T) {
 	client := k8sfake.NewSimpleClientset(svcObj)
 	service := NewResourceListService(nil, "")
 
-	resources, err := service.listServices(context.Background(), client, "ns", metav1.ListOptions{})
+	resources, _, err := service.listServices(context.Background(), client, "ns", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("listServices returned error: %v", err)
 	}
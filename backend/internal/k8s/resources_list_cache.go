@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// ResourceCache serves lists and watches from shared informers instead of the API server.
+// The cache reads with the cluster's service account, so callers still apply user permissions.
+type ResourceCache interface {
+	// CachesKind reports whether a (normalized) kind should be served from the cache
+	CachesKind(kind string) bool
+	// List returns the cached objects of a namespace ("" for all) and the resourceVersion of the cache.
+	// The objects are shared with the cache and must not be modified.
+	List(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, string, error)
+	// Watch replays the cached objects as ADDED events and then streams their changes
+	Watch(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string) (watch.Interface, error)
+}
+
+// SetCache makes the service list supported kinds from a shared informer cache
+func (s *ResourceListService) SetCache(cache ResourceCache) {
+	s.cache = cache
+}
+
+// servesFromCache reports whether a request can be answered from the cache.
+// Field selectors are only understood by the API server.
+func (s *ResourceListService) servesFromCache(req ListResourcesRequest) bool {
+	if s.cache == nil || req.FieldSelector != "" || !s.cache.CachesKind(req.Kind) {
+		return false
+	}
+	_, ok := resourceConverters[req.Kind]
+	return ok
+}
+
+// listFromCache lists a kind from the cache for the given namespaces.
+// It reports false when the kind is not cached or the cache failed, so the caller lists from the API server.
+func (s *ResourceListService) listFromCache(ctx context.Context, cluster string, req ListResourcesRequest, namespaces []string) ([]models.Resource, bool) {
+	if !s.servesFromCache(req) {
+		return nil, false
+	}
+	convert := resourceConverters[req.Kind]
+	gvr, ok := models.ResolveGVR(req.Kind)
+	if !ok {
+		return nil, false
+	}
+	selector := labels.Everything()
+	if req.LabelSelector != "" {
+		parsed, err := labels.Parse(req.LabelSelector)
+		if err != nil {
+			// Let the API server report the invalid selector
+			return nil, false
+		}
+		selector = parsed
+	}
+	if isClusterScoped(req.Kind) {
+		namespaces = []string{""}
+	}
+
+	// Metrics are not cached; fetch them once for the whole request
+	metricsOpts := metav1.ListOptions{LabelSelector: req.LabelSelector}
+	switch req.Kind {
+	case "Pod":
+		metricsNamespace := ""
+		if len(namespaces) == 1 {
+			metricsNamespace = namespaces[0]
+		}
+		metricsMap := podMetrics(ctx, req.MetricsClient, metricsNamespace, metricsOpts)
+		convert = converter(func(pod *corev1.Pod) models.Resource {
+			return podToResource(pod, metricsMap[pod.Name])
+		})
+	case "Node":
+		metricsMap := nodeMetrics(ctx, req.MetricsClient, metricsOpts)
+		convert = converter(func(node *corev1.Node) models.Resource {
+			return nodeToResource(node, metricsMap[node.Name])
+		})
+	}
+
+	var resources []models.Resource
+	for _, ns := range namespaces {
+		objects, _, err := s.cache.List(ctx, cluster, gvr, ns, selector)
+		if err != nil {
+			utils.LogWarn("Resource cache unavailable, listing from the API server", map[string]interface{}{
+				"cluster":   cluster,
+				"kind":      req.Kind,
+				"namespace": ns,
+				"error":     err.Error(),
+			})
+			return nil, false
+		}
+		for _, obj := range objects {
+			resource, err := convert(obj)
+			if err != nil {
+				continue
+			}
+			resources = append(resources, resource)
+		}
+	}
+	return resources, true
+}
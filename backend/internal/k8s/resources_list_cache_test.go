@@ -0,0 +1,149 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeResourceCache serves fixed objects, recording the namespaces it was asked for
+type fakeResourceCache struct {
+	objects    []*unstructured.Unstructured
+	err        error
+	watcher    *watch.FakeWatcher
+	listed     []string
+	watchedGVR schema.GroupVersionResource
+	watchedNS  string
+}
+
+func (f *fakeResourceCache) CachesKind(kind string) bool {
+	return kind != "Secret"
+}
+
+func (f *fakeResourceCache) List(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string, selector labels.Selector) ([]*unstructured.Unstructured, string, error) {
+	f.listed = append(f.listed, namespace)
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	var objects []*unstructured.Unstructured
+	for _, obj := range f.objects {
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if selector != nil && !selector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, "300", nil
+}
+
+func (f *fakeResourceCache) Watch(ctx context.Context, cluster string, gvr schema.GroupVersionResource, namespace string) (watch.Interface, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.watchedGVR, f.watchedNS = gvr, namespace
+	return f.watcher, nil
+}
+
+// cachedPod returns a pod the way the informer stores it: without kind, like list items
+func cachedPod(name, namespace string, podLabels map[string]string) *unstructured.Unstructured {
+	pod := watchedPod(name, namespace, "100")
+	delete(pod.Object, "kind")
+	pod.SetLabels(podLabels)
+	return pod
+}
+
+func TestResourceListService_ListsFromCache(t *testing.T) {
+	cache := &fakeResourceCache{objects: []*unstructured.Unstructured{
+		cachedPod("web", "shop", map[string]string{"app": "web"}),
+		cachedPod("worker", "shop", map[string]string{"app": "worker"}),
+		cachedPod("api", "payments", map[string]string{"app": "web"}),
+	}}
+	service := NewResourceListService(nil, "")
+	service.SetCache(cache)
+
+	ctx := userContext("", map[string]string{"shop": "view"})
+	resources, err := service.ListResources(ctx, ListResourcesRequest{
+		Kind:          "Pod",
+		AllNamespaces: true,
+		LabelSelector: "app=web",
+		Client:        k8sfake.NewClientset(),
+	})
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(resources) != 1 || resources[0].Name != "web" || resources[0].Kind != "Pod" || resources[0].Status != "Running" {
+		t.Fatalf("resources = %+v", resources)
+	}
+	// Restricted users only read their own namespaces from the index
+	if len(cache.listed) != 1 || cache.listed[0] != "shop" {
+		t.Errorf("listed namespaces = %v", cache.listed)
+	}
+	if cache.objects[0].GetKind() != "" {
+		t.Errorf("cached object was modified: kind = %q", cache.objects[0].GetKind())
+	}
+}
+
+func TestResourceListService_CacheFallsBackToAPIServer(t *testing.T) {
+	client := k8sfake.NewClientset(newTestDeployment("from-api", "default", map[string]string{"app": "demo"}, metav1.Now()))
+	cache := &fakeResourceCache{err: errors.New("not synced")}
+	service := NewResourceListService(nil, "")
+	service.SetCache(cache)
+
+	resources, err := service.ListResources(userContext("admin", nil), ListResourcesRequest{Kind: "Deployment", Namespace: "default", Client: client})
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(resources) != 1 || resources[0].Name != "from-api" {
+		t.Errorf("resources = %+v", resources)
+	}
+}
+
+func TestResourceListService_UncachedKind(t *testing.T) {
+	cache := &fakeResourceCache{}
+	service := NewResourceListService(nil, "")
+	service.SetCache(cache)
+
+	_, err := service.ListResources(userContext("admin", nil), ListResourcesRequest{Kind: "Secret", Namespace: "default", Client: k8sfake.NewClientset()})
+	if err != nil {
+		t.Fatalf("ListResources() error = %v", err)
+	}
+	if len(cache.listed) != 0 {
+		t.Errorf("uncached kind was read from the cache: %v", cache.listed)
+	}
+}
+
+func TestWatchService_Stream_FromCache(t *testing.T) {
+	watcher := watch.NewFakeWithChanSize(2, false)
+	watcher.Add(cachedPod("web", "shop", nil))
+	watcher.Delete(cachedPod("old", "shop", nil))
+	cache := &fakeResourceCache{watcher: watcher, objects: []*unstructured.Unstructured{cachedPod("web", "shop", nil)}}
+
+	service := NewWatchService(nil)
+	service.SetCache(cache)
+
+	ctx := userContext("admin", nil)
+	results := collectStream(t, ctx, service, nil, WatchRequest{Kind: "Pod", Namespace: "shop", ResourceVersion: "90"}, 3)
+
+	if cache.watchedGVR.Resource != "pods" || cache.watchedNS != "shop" {
+		t.Errorf("watched %v in %q", cache.watchedGVR, cache.watchedNS)
+	}
+	// A resumed watch gets the cached state first, since the cache cannot replay from resourceVersion 90
+	if results[0].Type != WatchEventSync || results[0].ResourceVersion != "300" || len(results[0].Resources) != 1 {
+		t.Fatalf("sync = %+v", results[0])
+	}
+	if results[1].Type != "ADDED" || results[1].Resource.Kind != "Pod" || results[2].Type != "DELETED" || results[2].Name != "old" {
+		t.Errorf("events = %+v, %+v", results[1], results[2])
+	}
+	if cache.objects[0].GetKind() != "" {
+		t.Errorf("cached object was modified: kind = %q", cache.objects[0].GetKind())
+	}
+}
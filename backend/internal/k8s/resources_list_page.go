@@ -0,0 +1,195 @@
+package k8s
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// ErrInvalidListRequest reports list parameters the server cannot apply (bad sort field or continue token)
+var ErrInvalidListRequest = errors.New("invalid list request")
+
+// Sort fields accepted by ListResourcesRequest.SortBy
+const (
+	SortByName    = "name"
+	SortByCreated = "created"
+	SortByStatus  = "status"
+)
+
+// continueToken is the opaque Continue value handed to clients.
+// Pages passed through to the API server record the namespace and its continue token;
+// pages of a filtered or sorted list record an offset into it.
+type continueToken struct {
+	Namespace string `json:"ns,omitempty"`
+	Continue  string `json:"c,omitempty"`
+	Offset    int    `json:"o,omitempty"`
+}
+
+func encodeContinueToken(token continueToken) string {
+	data, _ := json.Marshal(token)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinueToken(value string) (continueToken, error) {
+	var token continueToken
+	if value == "" {
+		return token, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(data, &token) != nil || token.Offset < 0 {
+		return continueToken{}, fmt.Errorf("%w: malformed continue token", ErrInvalidListRequest)
+	}
+	return token, nil
+}
+
+// validateListQuery checks the sort and paging parameters of a request
+func validateListQuery(req ListResourcesRequest) error {
+	switch req.SortBy {
+	case "", SortByName, SortByCreated, SortByStatus:
+	default:
+		return fmt.Errorf("%w: unsupported sort field %q (use name, created or status)", ErrInvalidListRequest, req.SortBy)
+	}
+	if req.Limit < 0 {
+		return fmt.Errorf("%w: limit must not be negative", ErrInvalidListRequest)
+	}
+	return nil
+}
+
+// needsFullList reports whether the request sorts or filters on fields the API server cannot page by
+func (req ListResourcesRequest) needsFullList() bool {
+	return req.SortBy != "" || len(req.Status) > 0 || req.Search != ""
+}
+
+// filterResources keeps the resources matching the status and search filters of a request
+func filterResources(resources []models.Resource, req ListResourcesRequest) []models.Resource {
+	if len(req.Status) == 0 && req.Search == "" {
+		return resources
+	}
+	search := strings.ToLower(req.Search)
+
+	filtered := make([]models.Resource, 0, len(resources))
+	for _, resource := range resources {
+		if search != "" && !strings.Contains(strings.ToLower(resource.Name), search) {
+			continue
+		}
+		if len(req.Status) > 0 && !statusMatches(resource, req.Status) {
+			continue
+		}
+		filtered = append(filtered, resource)
+	}
+	return filtered
+}
+
+// statusMatches reports whether a resource has one of the wanted statuses.
+// Pods also match on their reason and their containers' waiting or terminated reasons,
+// so "CrashLoopBackOff" finds pods whose phase is still Running.
+func statusMatches(resource models.Resource, wanted []string) bool {
+	statuses := []string{resource.Status}
+	if details, ok := resource.Details.(map[string]interface{}); ok && resource.Kind == "Pod" {
+		if reason, ok := details["statusReason"].(string); ok {
+			statuses = append(statuses, reason)
+		}
+		if containers, ok := details["containerStatuses"].([]map[string]interface{}); ok {
+			for _, container := range containers {
+				if reason, ok := container["reason"].(string); ok {
+					statuses = append(statuses, reason)
+				}
+			}
+		}
+	}
+
+	for _, status := range statuses {
+		for _, want := range wanted {
+			if status != "" && strings.EqualFold(status, want) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sortResources sorts resources in place by name, creation time or status; ties are ordered by namespace and name
+func sortResources(resources []models.Resource, sortBy string, desc bool) {
+	if sortBy == "" {
+		return
+	}
+	byName := func(a, b models.Resource) int {
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Namespace, b.Namespace)
+	}
+	byNamespace := func(a, b models.Resource) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		var c int
+		switch sortBy {
+		case SortByName:
+			c = byName(a, b)
+		case SortByCreated:
+			c = compareCreated(a.Created, b.Created)
+		case SortByStatus:
+			c = strings.Compare(a.Status, b.Status)
+		}
+		if c == 0 {
+			c = byNamespace(a, b)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+// compareCreated compares RFC3339 timestamps, ordering unparsable values first
+func compareCreated(a, b string) int {
+	ta, errA := time.Parse(time.RFC3339, a)
+	tb, errB := time.Parse(time.RFC3339, b)
+	switch {
+	case errA != nil || errB != nil:
+		return strings.Compare(a, b)
+	case ta.Before(tb):
+		return -1
+	case ta.After(tb):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// offsetPage returns the page of resources starting at offset; a limit of 0 returns all of them
+func offsetPage(resources []models.Resource, limit int64, offset int) (*models.PaginatedResources, error) {
+	if limit <= 0 {
+		if offset != 0 {
+			return nil, fmt.Errorf("%w: continue token requires a limit", ErrInvalidListRequest)
+		}
+		return &models.PaginatedResources{Resources: resources}, nil
+	}
+	if offset > len(resources) {
+		// The list shrank since the previous page
+		offset = len(resources)
+	}
+
+	end := len(resources)
+	if int64(end-offset) > limit {
+		end = offset + int(limit)
+	}
+	page := &models.PaginatedResources{Resources: resources[offset:end]}
+	if end < len(resources) {
+		page.Continue = encodeContinueToken(continueToken{Offset: end})
+		page.Remaining = len(resources) - end
+	}
+	return page, nil
+}
@@ -0,0 +1,227 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/cluster"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func resourceNames(resources []models.Resource) []string {
+	names := make([]string, 0, len(resources))
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	return names
+}
+
+func equalNames(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pagingConfigMaps serves config maps through a reactor that honors limit and continue like the API server
+func pagingConfigMaps(perNamespace map[string]int) *k8sfake.Clientset {
+	client := k8sfake.NewClientset()
+	var requests []metav1.ListOptions
+	client.PrependReactor("list", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		list := action.(k8stesting.ListActionImpl)
+		opts := list.ListOptions
+		requests = append(requests, opts)
+
+		start, _ := strconv.Atoi(opts.Continue)
+		total := perNamespace[list.Namespace]
+		end := total
+		if opts.Limit > 0 && start+int(opts.Limit) < total {
+			end = start + int(opts.Limit)
+		}
+		result := &corev1.ConfigMapList{}
+		for i := start; i < end; i++ {
+			result.Items = append(result.Items, corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+				Name:      list.Namespace + "-" + strconv.Itoa(i),
+				Namespace: list.Namespace,
+			}})
+		}
+		if end < total {
+			remaining := int64(total - end)
+			result.Continue = strconv.Itoa(end)
+			result.RemainingItemCount = &remaining
+		}
+		return true, result, nil
+	})
+	return client
+}
+
+func TestResourceListService_PagesThroughNamespaces(t *testing.T) {
+	client := pagingConfigMaps(map[string]int{"a": 3, "b": 1, "c": 2})
+	service := NewResourceListService(nil, "")
+	ctx := userContext("", map[string]string{"c": "view", "a": "view", "b": "view"})
+	req := ListResourcesRequest{Kind: "ConfigMap", AllNamespaces: true, Limit: 2, Client: client}
+
+	var pages [][]string
+	for i := 0; i < 5; i++ {
+		page, err := service.ListResourcesPage(ctx, req)
+		if err != nil {
+			t.Fatalf("page %d: %v", i, err)
+		}
+		pages = append(pages, resourceNames(page.Resources))
+		if i == 0 && page.Remaining != 1 {
+			t.Errorf("first page remaining = %d, want 1", page.Remaining)
+		}
+		if page.Continue == "" {
+			break
+		}
+		req.Continue = page.Continue
+	}
+
+	want := [][]string{{"a-0", "a-1"}, {"a-2", "b-0"}, {"c-0", "c-1"}}
+	if len(pages) != len(want) {
+		t.Fatalf("pages = %v, want %v", pages, want)
+	}
+	for i := range want {
+		if !equalNames(pages[i], want[i]...) {
+			t.Errorf("page %d = %v, want %v", i, pages[i], want[i])
+		}
+	}
+}
+
+func TestResourceListService_SortsAndFiltersBeforePaging(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	pod := func(name string, age time.Duration, phase corev1.PodPhase, waiting string) *corev1.Pod {
+		p := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", CreationTimestamp: metav1.NewTime(base.Add(-age))},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if waiting != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "app", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}}}}
+		}
+		return p
+	}
+	client := k8sfake.NewClientset(
+		pod("web-1", 3*time.Hour, corev1.PodRunning, "CrashLoopBackOff"),
+		pod("web-2", time.Hour, corev1.PodRunning, ""),
+		pod("api-1", 2*time.Hour, corev1.PodRunning, "CrashLoopBackOff"),
+		pod("web-3", 4*time.Hour, corev1.PodPending, "CrashLoopBackOff"),
+	)
+	service := NewResourceListService(nil, "")
+	ctx := userContext("admin", nil)
+
+	req := ListResourcesRequest{
+		Kind: "Pod", Namespace: "shop", Client: client,
+		SortBy: SortByCreated, SortDesc: true,
+		Status: []string{"crashloopbackoff"}, Search: "WEB",
+		Limit: 1,
+	}
+	first, err := service.ListResourcesPage(ctx, req)
+	if err != nil {
+		t.Fatalf("ListResourcesPage() error = %v", err)
+	}
+	if !equalNames(resourceNames(first.Resources), "web-1") || first.Continue == "" || first.Remaining != 1 {
+		t.Fatalf("first page = %v, continue %q, remaining %d", resourceNames(first.Resources), first.Continue, first.Remaining)
+	}
+
+	req.Continue = first.Continue
+	second, err := service.ListResourcesPage(ctx, req)
+	if err != nil {
+		t.Fatalf("ListResourcesPage() error = %v", err)
+	}
+	if !equalNames(resourceNames(second.Resources), "web-3") || second.Continue != "" {
+		t.Errorf("second page = %v, continue %q", resourceNames(second.Resources), second.Continue)
+	}
+
+	// Phase filters still work on their own
+	all, err := service.ListResources(ctx, ListResourcesRequest{Kind: "Pod", Namespace: "shop", Client: client, Status: []string{"Pending"}, SortBy: SortByName})
+	if err != nil || !equalNames(resourceNames(all), "web-3") {
+		t.Errorf("pending pods = %v, %v", resourceNames(all), err)
+	}
+}
+
+func TestResourceListService_RejectsInvalidQuery(t *testing.T) {
+	service := NewResourceListService(nil, "")
+	ctx := userContext("admin", nil)
+	client := k8sfake.NewClientset()
+	passthroughToken := encodeContinueToken(continueToken{Namespace: "default", Continue: "abc"})
+
+	for name, req := range map[string]ListResourcesRequest{
+		"sort field":      {SortBy: "size"},
+		"malformed token": {Limit: 10, Continue: "not base64!"},
+		"token mismatch":  {Limit: 10, Continue: passthroughToken, SortBy: SortByName},
+		"offset no limit": {Continue: encodeContinueToken(continueToken{Offset: 5})},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req.Kind, req.Namespace, req.Client = "ConfigMap", "default", client
+			if _, err := service.ListResourcesPage(ctx, req); !errors.Is(err, ErrInvalidListRequest) {
+				t.Errorf("error = %v, want ErrInvalidListRequest", err)
+			}
+		})
+	}
+}
+
+func TestSortResources(t *testing.T) {
+	resources := []models.Resource{
+		{Name: "b", Namespace: "x", Status: "Running", Created: "2026-01-02T00:00:00Z"},
+		{Name: "a", Namespace: "y", Status: "Failed", Created: "2026-01-03T00:00:00+02:00"},
+		{Name: "a", Namespace: "x", Status: "Running", Created: "2026-01-01T00:00:00Z"},
+	}
+
+	sortResources(resources, SortByName, false)
+	if got := resources[0].Namespace + resources[1].Namespace + resources[2].Name; got != "xyb" {
+		t.Errorf("by name = %+v", resources)
+	}
+	sortResources(resources, SortByCreated, true)
+	if !equalNames(resourceNames(resources), "a", "b", "a") || resources[0].Namespace != "y" {
+		t.Errorf("by created desc = %+v", resources)
+	}
+	sortResources(resources, SortByStatus, false)
+	if resources[0].Status != "Failed" || resources[1].Name != "a" {
+		t.Errorf("by status = %+v", resources)
+	}
+}
+
+func TestGetResourcesHandler_Paginated(t *testing.T) {
+	client := pagingConfigMaps(map[string]int{"default": 3})
+	handlers := &models.Handlers{Clients: map[string]kubernetes.Interface{"default": client}}
+	service := NewService(handlers, cluster.NewService(handlers))
+
+	rr := httptest.NewRecorder()
+	service.GetResources(rr, adminCtx(httptest.NewRequest(http.MethodGet, "/api/resources?kind=ConfigMap&namespace=default&limit=2", nil)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rr.Code, rr.Body.String())
+	}
+	var page models.PaginatedResources
+	if err := json.NewDecoder(rr.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Resources) != 2 || page.Continue == "" || page.Remaining != 1 {
+		t.Errorf("page = %+v", page)
+	}
+
+	for _, query := range []string{"limit=0", "limit=abc", "sort=size", "order=up"} {
+		rr = httptest.NewRecorder()
+		service.GetResources(rr, adminCtx(httptest.NewRequest(http.MethodGet, "/api/resources?kind=ConfigMap&namespace=default&"+query, nil)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -15,7 +16,8 @@ import (
 // ResourceListService provides business logic for listing Kubernetes resources
 type ResourceListService struct {
 	clusterService ClusterService
-	prometheusURL  string // URL for Prometheus queries (optional)
+	prometheusURL  string        // URL for Prometheus queries (optional)
+	cache          ResourceCache // serves supported kinds from memory when set (optional)
 }
 
 // NewResourceListService creates a new ResourceListService
@@ -28,10 +30,18 @@ func NewResourceListService(clusterService ClusterService, prometheusURL string)
 
 // ListResourcesRequest represents parameters for listing resources
 type ListResourcesRequest struct {
+	Cluster       string // Cluster the client belongs to; defaults to the cluster in the context
 	Kind          string
 	Namespace     string
 	AllNamespaces bool
 	LabelSelector string
+	FieldSelector string   // passed to the Kubernetes List, e.g. "spec.nodeName=node-1"
+	Limit         int64    // page size; 0 returns all resources
+	Continue      string   // token from the previous page
+	SortBy        string   // "name", "created" or "status"; empty keeps the API order (namespace, name)
+	SortDesc      bool     // reverse the sort order
+	Status        []string // keep resources with one of these statuses (case-insensitive)
+	Search        string   // keep resources whose name contains this text (case-insensitive)
 	Client        kubernetes.Interface
 	MetricsClient metricsv.Interface
 }
@@ -50,33 +60,66 @@ func isClusterScoped(kind string) bool {
 // This is the business logic layer that handles the transformation of different resource types
 // It filters resources based on user permissions before returning them
 func (s *ResourceListService) ListResources(ctx context.Context, req ListResourcesRequest) ([]models.Resource, error) {
+	page, err := s.ListResourcesPage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return page.Resources, nil
+}
+
+// ListResourcesPage is ListResources with pagination: with req.Limit set it returns at most that many
+// resources and a Continue token for the next page.
+// Without sorting or status/search filters, limit and continue are passed through to the Kubernetes List.
+// Otherwise (and for cached kinds) the whole list is fetched, filtered and sorted, and pages are offsets into it.
+func (s *ResourceListService) ListResourcesPage(ctx context.Context, req ListResourcesRequest) (*models.PaginatedResources, error) {
+	cluster := req.Cluster
+	if cluster == "" {
+		cluster = permissions.ClusterFromContext(ctx)
+	}
+
 	// Validate namespace access using helper
 	// Now returns a slice of namespaces to query (optimization for restricted users)
-	targetNamespaces, err := s.validateNamespaceAccess(ctx, req.Namespace, req.AllNamespaces)
+	targetNamespaces, err := s.validateNamespaceAccess(ctx, cluster, req.Namespace, req.AllNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	if isClusterScoped(req.Kind) {
+		targetNamespaces = []string{""}
+	}
+	// Pages walk the namespaces in a stable order
+	targetNamespaces = append([]string(nil), targetNamespaces...)
+	sort.Strings(targetNamespaces)
+
+	if err := validateListQuery(req); err != nil {
+		return nil, err
+	}
+	token, err := decodeContinueToken(req.Continue)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create ListOptions
-	listOpts := metav1.ListOptions{}
-	if req.LabelSelector != "" {
-		listOpts.LabelSelector = req.LabelSelector
+	listOpts := metav1.ListOptions{
+		LabelSelector: req.LabelSelector,
+		FieldSelector: req.FieldSelector,
 	}
 
-	var allResources []models.Resource
+	if req.Limit > 0 && !req.needsFullList() && !s.servesFromCache(req) {
+		return s.listPage(ctx, cluster, req, targetNamespaces, listOpts, token)
+	}
+	if token.Namespace != "" || token.Continue != "" {
+		return nil, fmt.Errorf("%w: continue token does not match the request", ErrInvalidListRequest)
+	}
 
-	// Optimization: For cluster-scoped resources, we effectively query once.
-	// For namespaced resources, we iterate over the allowed namespaces.
-	if isClusterScoped(req.Kind) {
-		resources, err := s.fetchResourcesByKind(ctx, req, "", listOpts)
-		if err != nil {
-			return nil, err
-		}
-		allResources = resources
-	} else {
+	// Cached kinds are read from memory; otherwise each allowed namespace is listed
+	allResources, ok := s.listFromCache(ctx, cluster, req, targetNamespaces)
+	if !ok {
 		for _, ns := range targetNamespaces {
-			resources, err := s.fetchResourcesByKind(ctx, req, ns, listOpts)
+			resources, _, err := s.fetchResourcesByKind(ctx, req, ns, listOpts)
 			if err != nil {
+				if isClusterScoped(req.Kind) {
+					return nil, err
+				}
 				return nil, fmt.Errorf("failed to list %s resources in namespace %s: %w", req.Kind, ns, err)
 			}
 			allResources = append(allResources, resources...)
@@ -85,78 +128,136 @@ func (s *ResourceListService) ListResources(ctx context.Context, req ListResourc
 
 	// Filter resources based on user permissions (final check)
 	// This ensures that even if we query all namespaces, we only return resources the user has access to
-	filteredResources, err := permissions.FilterResources(ctx, allResources)
+	filteredResources, err := permissions.FilterClusterResources(ctx, cluster, filterResources(allResources, req))
 	if err != nil {
 		return nil, fmt.Errorf("failed to filter resources by permissions: %w", err)
 	}
+	sortResources(filteredResources, req.SortBy, req.SortDesc)
+
+	return offsetPage(filteredResources, req.Limit, token.Offset)
+}
+
+// listPage fetches one page through the Kubernetes List continue mechanism.
+// The token records the namespace the page ended in, so restricted users page through their namespaces in turn.
+func (s *ResourceListService) listPage(ctx context.Context, cluster string, req ListResourcesRequest, namespaces []string, listOpts metav1.ListOptions, token continueToken) (*models.PaginatedResources, error) {
+	if token.Offset != 0 {
+		return nil, fmt.Errorf("%w: continue token does not match the request", ErrInvalidListRequest)
+	}
+	start := 0
+	if token.Namespace != "" {
+		start = sort.SearchStrings(namespaces, token.Namespace)
+		if start == len(namespaces) || namespaces[start] != token.Namespace {
+			return nil, fmt.Errorf("%w: continue token namespace is not accessible", ErrInvalidListRequest)
+		}
+	}
 
-	return filteredResources, nil
+	page := &models.PaginatedResources{}
+	var resources []models.Resource
+	for i := start; i < len(namespaces); i++ {
+		ns := namespaces[i]
+		opts := listOpts
+		opts.Limit = req.Limit - int64(len(resources))
+		if i == start {
+			opts.Continue = token.Continue
+		}
+
+		items, meta, err := s.fetchResourcesByKind(ctx, req, ns, opts)
+		if err != nil {
+			if isClusterScoped(req.Kind) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("failed to list %s resources in namespace %s: %w", req.Kind, ns, err)
+		}
+		resources = append(resources, items...)
+
+		if meta.Continue != "" {
+			page.Continue = encodeContinueToken(continueToken{Namespace: ns, Continue: meta.Continue})
+			if meta.RemainingItemCount != nil {
+				page.Remaining = int(*meta.RemainingItemCount)
+			}
+			break
+		}
+		if int64(len(resources)) >= req.Limit {
+			if i+1 < len(namespaces) {
+				page.Continue = encodeContinueToken(continueToken{Namespace: namespaces[i+1]})
+			}
+			break
+		}
+	}
+
+	filtered, err := permissions.FilterClusterResources(ctx, cluster, resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter resources by permissions: %w", err)
+	}
+	page.Resources = filtered
+	return page, nil
 }
 
 // fetchResourcesByKind handles the specific listing logic for a single namespace scope
-func (s *ResourceListService) fetchResourcesByKind(ctx context.Context, req ListResourcesRequest, namespace string, listOpts metav1.ListOptions) ([]models.Resource, error) {
+func (s *ResourceListService) fetchResourcesByKind(ctx context.Context, req ListResourcesRequest, namespace string, listOpts metav1.ListOptions) ([]models.Resource, metav1.ListMeta, error) {
 	var resources []models.Resource
+	var listMeta metav1.ListMeta
 	var listErr error
 
 	// Delegate to specific transformer based on kind
 	switch req.Kind {
 	case "Deployment":
-		resources, listErr = s.listDeployments(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listDeployments(ctx, req.Client, namespace, listOpts)
 	case "Node":
-		resources, listErr = s.listNodes(ctx, req.Client, req.MetricsClient, listOpts)
+		resources, listMeta, listErr = s.listNodes(ctx, req.Client, req.MetricsClient, listOpts)
 	case "Pod":
-		resources, listErr = s.listPods(ctx, req.Client, req.MetricsClient, namespace, listOpts)
+		resources, listMeta, listErr = s.listPods(ctx, req.Client, req.MetricsClient, namespace, listOpts)
 	case "ConfigMap":
-		resources, listErr = s.listConfigMaps(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listConfigMaps(ctx, req.Client, namespace, listOpts)
 	case "Secret":
-		resources, listErr = s.listSecrets(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listSecrets(ctx, req.Client, namespace, listOpts)
 	case "Job":
-		resources, listErr = s.listJobs(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listJobs(ctx, req.Client, namespace, listOpts)
 	case "CronJob":
-		resources, listErr = s.listCronJobs(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listCronJobs(ctx, req.Client, namespace, listOpts)
 	case "StatefulSet":
-		resources, listErr = s.listStatefulSets(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listStatefulSets(ctx, req.Client, namespace, listOpts)
 	case "DaemonSet":
-		resources, listErr = s.listDaemonSets(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listDaemonSets(ctx, req.Client, namespace, listOpts)
 	case "HorizontalPodAutoscaler":
-		resources, listErr = s.listHPAs(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listHPAs(ctx, req.Client, namespace, listOpts)
 	case "Service":
-		resources, listErr = s.listServices(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listServices(ctx, req.Client, namespace, listOpts)
 	case "Ingress":
-		resources, listErr = s.listIngresses(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listIngresses(ctx, req.Client, namespace, listOpts)
 	case "ServiceAccount":
-		resources, listErr = s.listServiceAccounts(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listServiceAccounts(ctx, req.Client, namespace, listOpts)
 	case "Role":
-		resources, listErr = s.listRoles(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listRoles(ctx, req.Client, namespace, listOpts)
 	case "ClusterRole":
-		resources, listErr = s.listClusterRoles(ctx, req.Client, listOpts)
+		resources, listMeta, listErr = s.listClusterRoles(ctx, req.Client, listOpts)
 	case "RoleBinding":
-		resources, listErr = s.listRoleBindings(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listRoleBindings(ctx, req.Client, namespace, listOpts)
 	case "ClusterRoleBinding":
-		resources, listErr = s.listClusterRoleBindings(ctx, req.Client, listOpts)
+		resources, listMeta, listErr = s.listClusterRoleBindings(ctx, req.Client, listOpts)
 	case "NetworkPolicy":
-		resources, listErr = s.listNetworkPolicies(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listNetworkPolicies(ctx, req.Client, namespace, listOpts)
 	case "PersistentVolumeClaim":
-		resources, listErr = s.listPVCs(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listPVCs(ctx, req.Client, namespace, listOpts)
 	case "PersistentVolume":
-		resources, listErr = s.listPVs(ctx, req.Client, listOpts)
+		resources, listMeta, listErr = s.listPVs(ctx, req.Client, listOpts)
 	case "StorageClass":
-		resources, listErr = s.listStorageClasses(ctx, req.Client, listOpts)
+		resources, listMeta, listErr = s.listStorageClasses(ctx, req.Client, listOpts)
 	case "ResourceQuota":
-		resources, listErr = s.listResourceQuotas(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listResourceQuotas(ctx, req.Client, namespace, listOpts)
 	case "LimitRange":
-		resources, listErr = s.listLimitRanges(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listLimitRanges(ctx, req.Client, namespace, listOpts)
 
 	case "ReplicaSet":
-		resources, listErr = s.listReplicaSets(ctx, req.Client, namespace, listOpts)
+		resources, listMeta, listErr = s.listReplicaSets(ctx, req.Client, namespace, listOpts)
 	default:
 		// Return empty list for unknown kinds
 		resources = []models.Resource{}
 	}
 
 	if listErr != nil {
-		return nil, listErr
+		return nil, metav1.ListMeta{}, listErr
 	}
 
-	return resources, nil
+	return resources, listMeta, nil
 }
@@ -7,11 +7,11 @@ import (
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
 )
 
-// validateNamespaceAccess checks if the user has permission to access the requested namespace
+// validateNamespaceAccess checks if the user has permission to access the requested namespace in a cluster
 // It returns a list of effective namespaces to list and an error if access is denied
-func (s *ResourceListService) validateNamespaceAccess(ctx context.Context, ns string, allNamespaces bool) ([]string, error) {
-	// Get user's allowed namespaces
-	allowedNamespaces, err := permissions.GetAllowedNamespaces(ctx)
+func (s *ResourceListService) validateNamespaceAccess(ctx context.Context, cluster, ns string, allNamespaces bool) ([]string, error) {
+	// Get user's allowed namespaces in this cluster
+	allowedNamespaces, err := permissions.GetAllowedClusterNamespaces(ctx, cluster)
 	if err != nil {
 		// If we can't get permissions, deny access (fail secure)
 		return nil, fmt.Errorf("failed to get user permissions: %w", err)
@@ -34,7 +34,7 @@ func (s *ResourceListService) validateNamespaceAccess(ctx context.Context, ns st
 	// Case 2: Specific Namespace requested
 	if len(allowedNamespaces) > 0 {
 		// User has restricted permissions - check if requested namespace is allowed
-		hasAccess, err := permissions.HasNamespaceAccess(ctx, ns)
+		hasAccess, err := permissions.HasClusterNamespaceAccess(ctx, cluster, ns)
 		if err != nil {
 			return nil, fmt.Errorf("failed to check namespace access: %w", err)
 		}
@@ -0,0 +1,348 @@
+package k8s
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// Limits of a rollout status stream
+const (
+	defaultRolloutStatusTimeout = 5 * time.Minute
+	maxRolloutStatusTimeout     = 30 * time.Minute
+	rolloutHeartbeatInterval    = 15 * time.Second
+)
+
+// rolloutRequest identifies the workload of a rollout request
+type rolloutRequest struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Revision  int64  `json:"revision,omitempty"` // rollback target; 0 is the previous revision
+}
+
+// rolloutService validates a rollout request and the user's access to its namespace, then creates the service.
+// GET requests read kind, name and namespace from the query and need view access; POST requests read a
+// JSON body and need edit permission. It writes the error response and returns false when the request is rejected.
+func (s *Service) rolloutService(w http.ResponseWriter, r *http.Request, method string) (*RolloutService, rolloutRequest, bool) {
+	var req rolloutRequest
+	if r.Method != method {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return nil, req, false
+	}
+
+	if method == http.MethodGet {
+		req.Kind = r.URL.Query().Get("kind")
+		req.Name = r.URL.Query().Get("name")
+		req.Namespace = r.URL.Query().Get("namespace")
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize)).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return nil, req, false
+	}
+
+	if req.Kind == "" || req.Name == "" || req.Namespace == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Missing kind, name or namespace")
+		return nil, req, false
+	}
+	if _, err := rolloutKind(req.Kind); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, req, false
+	}
+	if err := utils.ValidateK8sName(req.Name, "name"); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, req, false
+	}
+	if err := utils.ValidateK8sName(req.Namespace, "namespace"); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, req, false
+	}
+	if req.Revision < 0 {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Revision must not be negative")
+		return nil, req, false
+	}
+
+	// Validate namespace access
+	var allowed bool
+	var err error
+	action := "edit"
+	if method == http.MethodGet {
+		action = "view"
+		allowed, err = permissions.HasNamespaceAccess(r.Context(), req.Namespace)
+	} else {
+		allowed, err = permissions.CanPerformAction(r.Context(), req.Namespace, action)
+	}
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to check permissions", http.StatusInternalServerError, map[string]interface{}{
+			"namespace": req.Namespace,
+			"action":    action,
+		})
+		return nil, req, false
+	}
+	if !allowed {
+		if action == "edit" {
+			utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Edit permission required for namespace: %s", req.Namespace))
+		} else {
+			utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Access denied to namespace: %s", req.Namespace))
+		}
+		return nil, req, false
+	}
+
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return nil, req, false
+	}
+	return s.serviceFactory.CreateRolloutService(client), req, true
+}
+
+// writeRolloutError maps rollout errors to HTTP status codes
+func writeRolloutError(w http.ResponseWriter, err error, message string, req rolloutRequest) {
+	switch {
+	case errors.Is(err, ErrUnsupportedRolloutKind):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrRevisionNotFound), apierrors.IsNotFound(err):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrRolloutPaused), apierrors.IsConflict(err):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, message, http.StatusInternalServerError, map[string]interface{}{
+			"kind":      req.Kind,
+			"namespace": req.Namespace,
+			"name":      req.Name,
+		})
+	}
+}
+
+// GetRolloutHistory returns the revisions of a Deployment, StatefulSet or DaemonSet
+//
+// @Summary Historial de rollout
+// @Description Revisiones con change-cause, imágenes y diff del pod template contra la revisión anterior
+// @Tags rollouts
+// @Security Bearer
+// @Produce json
+// @Param kind query string true "Deployment, StatefulSet o DaemonSet"
+// @Param name query string true "Nombre"
+// @Param namespace query string true "Namespace"
+// @Success 200 {object} k8s.RolloutHistory "Historial"
+// @Failure 403 {object} map[string]string "Sin acceso al namespace"
+// @Failure 404 {object} map[string]string "Recurso no encontrado"
+// @Router /api/rollout/history [get]
+func (s *Service) GetRolloutHistory(w http.ResponseWriter, r *http.Request) {
+	rolloutService, req, ok := s.rolloutService(w, r, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
+
+	history, err := rolloutService.History(ctx, req.Kind, req.Namespace, req.Name)
+	if err != nil {
+		writeRolloutError(w, err, "Failed to get rollout history", req)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, history)
+}
+
+// RollbackRollout restores the pod template of an earlier revision
+//
+// @Summary Rollback a una revisión
+// @Description Restaura el pod template de una revisión (0 = la anterior a la actual)
+// @Tags rollouts
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param body body object true "kind, name, namespace y revision"
+// @Success 200 {object} k8s.RollbackResult "Revisión restaurada"
+// @Failure 403 {object} map[string]string "Permiso de edición requerido"
+// @Failure 404 {object} map[string]string "Revisión no encontrada"
+// @Failure 409 {object} map[string]string "Deployment pausado"
+// @Router /api/rollout/rollback [post]
+func (s *Service) RollbackRollout(w http.ResponseWriter, r *http.Request) {
+	rolloutService, req, ok := s.rolloutService(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
+
+	result, err := rolloutService.Rollback(ctx, req.Kind, req.Namespace, req.Name, req.Revision)
+	details := map[string]interface{}{"requestedRevision": req.Revision}
+	if result != nil {
+		details["revision"] = result.Revision
+		details["skipped"] = result.Skipped
+	}
+	utils.AuditLog(r, "rollback", req.Kind, req.Name, req.Namespace, err == nil, err, details)
+	if err != nil {
+		writeRolloutError(w, err, "Failed to roll back", req)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, result)
+}
+
+// PauseRollout pauses the rollout of a Deployment
+//
+// @Summary Pausar rollout
+// @Tags rollouts
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param body body object true "kind, name y namespace"
+// @Success 200 {object} map[string]string "Rollout pausado"
+// @Failure 400 {object} map[string]string "Solo Deployments"
+// @Router /api/rollout/pause [post]
+func (s *Service) PauseRollout(w http.ResponseWriter, r *http.Request) {
+	s.setRolloutPaused(w, r, true)
+}
+
+// ResumeRollout resumes a paused Deployment rollout
+//
+// @Summary Reanudar rollout
+// @Tags rollouts
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param body body object true "kind, name y namespace"
+// @Success 200 {object} map[string]string "Rollout reanudado"
+// @Failure 400 {object} map[string]string "Solo Deployments"
+// @Router /api/rollout/resume [post]
+func (s *Service) ResumeRollout(w http.ResponseWriter, r *http.Request) {
+	s.setRolloutPaused(w, r, false)
+}
+
+func (s *Service) setRolloutPaused(w http.ResponseWriter, r *http.Request, paused bool) {
+	rolloutService, req, ok := s.rolloutService(w, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := utils.CreateRequestContext(r)
+	defer cancel()
+
+	verb, message := "resume", "Rollout resumed"
+	if paused {
+		verb, message = "pause", "Rollout paused"
+	}
+	err := rolloutService.SetPaused(ctx, req.Kind, req.Namespace, req.Name, paused)
+	utils.AuditLog(r, verb, req.Kind, req.Name, req.Namespace, err == nil, err, nil)
+	if err != nil {
+		writeRolloutError(w, err, "Failed to "+verb+" rollout", req)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": message, "paused": strconv.FormatBool(paused)})
+}
+
+// StreamRolloutStatus follows a rollout as a server-sent event stream, like kubectl rollout status.
+// It sends "status" events with the replica counts whenever they change and "event" events for Kubernetes
+// events about the workload, its ReplicaSets and its pods, then ends with "success", "failure" (progress
+// deadline exceeded), "timeout" or "error".
+//
+// @Summary Estado del rollout (SSE)
+// @Description Sigue el rollout hasta que termina, falla o vence el timeout, como kubectl rollout status
+// @Tags rollouts
+// @Security Bearer
+// @Produce text/event-stream
+// @Param kind query string true "Deployment, StatefulSet o DaemonSet"
+// @Param name query string true "Nombre"
+// @Param namespace query string true "Namespace"
+// @Param timeout query string false "Duración máxima (por defecto 5m, máximo 30m)"
+// @Success 200 {string} string "Eventos status, event, success, failure, timeout o error"
+// @Failure 403 {object} map[string]string "Sin acceso al namespace"
+// @Router /api/rollout/status [get]
+func (s *Service) StreamRolloutStatus(w http.ResponseWriter, r *http.Request) {
+	timeout := defaultRolloutStatusTimeout
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxRolloutStatusTimeout {
+			utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid timeout: use a duration up to %s", maxRolloutStatusTimeout))
+			return
+		}
+		timeout = parsed
+	}
+
+	rolloutService, req, ok := s.rolloutService(w, r, http.MethodGet)
+	if !ok {
+		return
+	}
+
+	// SSE setup
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + time.Minute))
+
+	var mu sync.Mutex
+	send := func(event string, data interface{}) {
+		payload, _ := json.Marshal(data)
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+		flusher.Flush()
+	}
+
+	// Comments keep proxies from closing a quiet stream
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(rolloutHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mu.Lock()
+				fmt.Fprint(w, ": keep-alive\n\n")
+				flusher.Flush()
+				mu.Unlock()
+			}
+		}
+	}()
+
+	status, err := rolloutService.FollowRollout(r.Context(), req.Kind, req.Namespace, req.Name, timeout, func(update RolloutUpdate) {
+		if update.Status != nil {
+			send("status", update.Status)
+		}
+		if update.Event != nil {
+			send("event", update.Event)
+		}
+	})
+	switch {
+	case errors.Is(err, ErrRolloutTimeout):
+		send("timeout", status)
+	case err != nil:
+		if r.Context().Err() != nil {
+			return // client disconnected
+		}
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, ErrUnsupportedRolloutKind):
+			status = http.StatusBadRequest
+		case apierrors.IsNotFound(err):
+			status = http.StatusNotFound
+		case apierrors.IsForbidden(err):
+			status = http.StatusForbidden
+		}
+		send("error", map[string]interface{}{"message": err.Error(), "status": status})
+	case status.Failed:
+		send("failure", status)
+	default:
+		send("success", status)
+	}
+}
@@ -0,0 +1,339 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// Annotations the Deployment controller and kubectl use to record rollout history
+const (
+	revisionAnnotation    = "deployment.kubernetes.io/revision"
+	changeCauseAnnotation = "kubernetes.io/change-cause"
+)
+
+var (
+	// ErrUnsupportedRolloutKind reports a kind without rollouts, or an action the kind does not support
+	ErrUnsupportedRolloutKind = errors.New("unsupported kind")
+	// ErrRevisionNotFound reports a rollback to a revision that is not in the history
+	ErrRevisionNotFound = errors.New("revision not found")
+	// ErrRolloutPaused reports a rollback of a paused Deployment, which the controller would not roll out
+	ErrRolloutPaused = errors.New("rollout is paused")
+)
+
+// RolloutRevision is one revision in the rollout history of a workload
+type RolloutRevision struct {
+	Revision    int64         `json:"revision"`
+	Name        string        `json:"name"` // ReplicaSet or ControllerRevision holding the revision
+	ChangeCause string        `json:"changeCause,omitempty"`
+	Images      []string      `json:"images"`
+	Created     string        `json:"created"`
+	Current     bool          `json:"current"`
+	Diff        *ResourceDiff `json:"diff,omitempty"` // pod template changes since the previous revision
+}
+
+// RolloutHistory is the rollout history of a Deployment, StatefulSet or DaemonSet, oldest revision first
+type RolloutHistory struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Paused    bool              `json:"paused"`
+	Revisions []RolloutRevision `json:"revisions"`
+}
+
+// RollbackResult reports the outcome of a rollback
+type RollbackResult struct {
+	Revision int64 `json:"revision"` // revision whose pod template was restored
+	Skipped  bool  `json:"skipped"`  // the revision is already the current one
+}
+
+// workloadRevision is a revision together with the data needed to roll back to it
+type workloadRevision struct {
+	RolloutRevision
+	template corev1.PodTemplateSpec
+	patch    []byte // ControllerRevision data, a strategic merge patch (StatefulSets and DaemonSets)
+}
+
+// RolloutService provides rollout history, rollback and pause/resume for workloads
+type RolloutService struct {
+	client kubernetes.Interface
+}
+
+// NewRolloutService creates a new RolloutService
+func NewRolloutService(client kubernetes.Interface) *RolloutService {
+	return &RolloutService{client: client}
+}
+
+// rolloutKind normalizes a kind and checks that it has a rollout history
+func rolloutKind(kind string) (string, error) {
+	normalized := models.NormalizeKind(kind)
+	switch normalized {
+	case "Deployment", "StatefulSet", "DaemonSet":
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("%w: %s has no rollout history (use Deployment, StatefulSet or DaemonSet)", ErrUnsupportedRolloutKind, kind)
+	}
+}
+
+// History returns the revisions of a workload, each with the pod template diff against the previous one.
+// Deployment revisions come from their ReplicaSets, StatefulSet and DaemonSet revisions from ControllerRevisions.
+func (s *RolloutService) History(ctx context.Context, kind, namespace, name string) (*RolloutHistory, error) {
+	kind, err := rolloutKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	revisions, paused, _, err := s.revisions(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &RolloutHistory{Kind: kind, Name: name, Namespace: namespace, Paused: paused, Revisions: []RolloutRevision{}}
+	var previous map[string]interface{}
+	for i, revision := range revisions {
+		template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&revision.template)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert pod template of revision %d: %w", revision.Revision, err)
+		}
+		if i > 0 {
+			diff, err := buildResourceDiff(fmt.Sprintf("revision %d", revisions[i-1].Revision), fmt.Sprintf("revision %d", revision.Revision), previous, template)
+			if err != nil {
+				return nil, err
+			}
+			revision.Diff = diff
+		}
+		previous = template
+		history.Revisions = append(history.Revisions, revision.RolloutRevision)
+	}
+	return history, nil
+}
+
+// Rollback restores the pod template of a revision; revision 0 means the one before the current revision.
+// The workload controller then rolls out the restored template as a new revision.
+func (s *RolloutService) Rollback(ctx context.Context, kind, namespace, name string, revision int64) (*RollbackResult, error) {
+	kind, err := rolloutKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	revisions, paused, deployment, err := s.revisions(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 {
+		return nil, fmt.Errorf("%w: %s %s/%s has no recorded revisions", ErrRevisionNotFound, kind, namespace, name)
+	}
+
+	current := revisions[len(revisions)-1]
+	var target *workloadRevision
+	if revision == 0 {
+		if len(revisions) < 2 {
+			return nil, fmt.Errorf("%w: no revision before %d", ErrRevisionNotFound, current.Revision)
+		}
+		target = &revisions[len(revisions)-2]
+	} else {
+		for i := range revisions {
+			if revisions[i].Revision == revision {
+				target = &revisions[i]
+			}
+		}
+		if target == nil {
+			return nil, fmt.Errorf("%w: %d", ErrRevisionNotFound, revision)
+		}
+	}
+	if target.Revision == current.Revision {
+		return &RollbackResult{Revision: target.Revision, Skipped: true}, nil
+	}
+
+	switch kind {
+	case "Deployment":
+		if paused {
+			return nil, fmt.Errorf("%w: resume the Deployment before rolling back", ErrRolloutPaused)
+		}
+		deployment.Spec.Template = target.template
+		if deployment.Annotations == nil {
+			deployment.Annotations = map[string]string{}
+		}
+		if cause := target.ChangeCause; cause != "" {
+			deployment.Annotations[changeCauseAnnotation] = cause
+		} else {
+			delete(deployment.Annotations, changeCauseAnnotation)
+		}
+		if _, err := s.client.AppsV1().Deployments(namespace).Update(ctx, deployment, metav1.UpdateOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to roll back deployment: %w", err)
+		}
+	case "StatefulSet":
+		if _, err := s.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, target.patch, metav1.PatchOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to roll back statefulset: %w", err)
+		}
+	case "DaemonSet":
+		if _, err := s.client.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.StrategicMergePatchType, target.patch, metav1.PatchOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to roll back daemonset: %w", err)
+		}
+	}
+	return &RollbackResult{Revision: target.Revision}, nil
+}
+
+// SetPaused pauses or resumes the rollout of a Deployment.
+// StatefulSets and DaemonSets have no paused state.
+func (s *RolloutService) SetPaused(ctx context.Context, kind, namespace, name string, paused bool) error {
+	kind, err := rolloutKind(kind)
+	if err != nil {
+		return err
+	}
+	if kind != "Deployment" {
+		return fmt.Errorf("%w: only Deployments can be paused and resumed", ErrUnsupportedRolloutKind)
+	}
+
+	patch, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"paused": paused}})
+	if _, err := s.client.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to update deployment: %w", err)
+	}
+	return nil
+}
+
+// revisions lists the revisions of a workload, oldest first, with the highest revision marked current.
+// For Deployments it also returns whether the rollout is paused and the Deployment itself.
+func (s *RolloutService) revisions(ctx context.Context, kind, namespace, name string) ([]workloadRevision, bool, *appsv1.Deployment, error) {
+	var revisions []workloadRevision
+	var paused bool
+	var deployment *appsv1.Deployment
+
+	switch kind {
+	case "Deployment":
+		d, err := s.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, false, nil, fmt.Errorf("failed to get deployment: %w", err)
+		}
+		deployment, paused = d, d.Spec.Paused
+		revisions, err = s.replicaSetRevisions(ctx, d)
+		if err != nil {
+			return nil, false, nil, err
+		}
+	case "StatefulSet":
+		sts, err := s.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, false, nil, fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		revisions, err = s.controllerRevisions(ctx, sts, sts.Spec.Selector)
+		if err != nil {
+			return nil, false, nil, err
+		}
+	case "DaemonSet":
+		ds, err := s.client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, false, nil, fmt.Errorf("failed to get daemonset: %w", err)
+		}
+		revisions, err = s.controllerRevisions(ctx, ds, ds.Spec.Selector)
+		if err != nil {
+			return nil, false, nil, err
+		}
+	}
+
+	sort.Slice(revisions, func(i, j int) bool { return revisions[i].Revision < revisions[j].Revision })
+	if n := len(revisions); n > 0 {
+		revisions[n-1].Current = true
+	}
+	return revisions, paused, deployment, nil
+}
+
+// replicaSetRevisions reads the revisions of a Deployment from the ReplicaSets it controls
+func (s *RolloutService) replicaSetRevisions(ctx context.Context, deployment *appsv1.Deployment) ([]workloadRevision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deployment selector: %w", err)
+	}
+	replicaSets, err := s.client.AppsV1().ReplicaSets(deployment.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+
+	var revisions []workloadRevision
+	for i := range replicaSets.Items {
+		rs := &replicaSets.Items[i]
+		if !metav1.IsControlledBy(rs, deployment) {
+			continue
+		}
+		revision, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+		// The controller adds the pod-template-hash label to each ReplicaSet's template
+		template := *rs.Spec.Template.DeepCopy()
+		delete(template.Labels, appsv1.DefaultDeploymentUniqueLabelKey)
+
+		revisions = append(revisions, workloadRevision{
+			RolloutRevision: RolloutRevision{
+				Revision:    revision,
+				Name:        rs.Name,
+				ChangeCause: rs.Annotations[changeCauseAnnotation],
+				Images:      templateImages(template),
+				Created:     rs.CreationTimestamp.Format(time.RFC3339),
+			},
+			template: template,
+		})
+	}
+	return revisions, nil
+}
+
+// controllerRevisions reads the revisions of a StatefulSet or DaemonSet from the ControllerRevisions it controls
+func (s *RolloutService) controllerRevisions(ctx context.Context, owner metav1.Object, labelSelector *metav1.LabelSelector) ([]workloadRevision, error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	history, err := s.client.AppsV1().ControllerRevisions(owner.GetNamespace()).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list controllerrevisions: %w", err)
+	}
+
+	var revisions []workloadRevision
+	for i := range history.Items {
+		cr := &history.Items[i]
+		if !metav1.IsControlledBy(cr, owner) {
+			continue
+		}
+		// The data is a patch restoring the pod template: {"spec":{"template":{..., "$patch":"replace"}}}
+		var data struct {
+			Spec struct {
+				Template corev1.PodTemplateSpec `json:"template"`
+			} `json:"spec"`
+		}
+		if err := json.Unmarshal(cr.Data.Raw, &data); err != nil {
+			continue
+		}
+
+		revisions = append(revisions, workloadRevision{
+			RolloutRevision: RolloutRevision{
+				Revision:    cr.Revision,
+				Name:        cr.Name,
+				ChangeCause: cr.Annotations[changeCauseAnnotation],
+				Images:      templateImages(data.Spec.Template),
+				Created:     cr.CreationTimestamp.Format(time.RFC3339),
+			},
+			template: data.Spec.Template,
+			patch:    cr.Data.Raw,
+		})
+	}
+	return revisions, nil
+}
+
+// templateImages lists the container images of a pod template
+func templateImages(template corev1.PodTemplateSpec) []string {
+	images := make([]string, 0, len(template.Spec.Containers))
+	for _, container := range template.Spec.Containers {
+		images = append(images, container.Image)
+	}
+	return images
+}
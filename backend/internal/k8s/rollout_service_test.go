@@ -0,0 +1,278 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func rolloutTemplate(image string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "web", Image: image}}},
+	}
+}
+
+func rolloutDeployment(paused bool) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop", UID: types.UID("deploy-uid")},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: rolloutTemplate("web:3"),
+			Paused:   paused,
+		},
+	}
+}
+
+// revisionReplicaSet returns a ReplicaSet of the web Deployment as the controller creates it
+func revisionReplicaSet(deployment *appsv1.Deployment, revision int, image, changeCause string) *appsv1.ReplicaSet {
+	template := rolloutTemplate(image)
+	template.Labels[appsv1.DefaultDeploymentUniqueLabelKey] = "hash" + strconv.Itoa(revision)
+	annotations := map[string]string{revisionAnnotation: strconv.Itoa(revision)}
+	if changeCause != "" {
+		annotations[changeCauseAnnotation] = changeCause
+	}
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-" + strconv.Itoa(revision),
+			Namespace:       "shop",
+			Labels:          template.Labels,
+			Annotations:     annotations,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+		},
+		Spec: appsv1.ReplicaSetSpec{Template: template},
+	}
+}
+
+func newDeploymentRolloutClient(paused bool) *k8sfake.Clientset {
+	deployment := rolloutDeployment(paused)
+	orphan := revisionReplicaSet(deployment, 9, "other:1", "")
+	orphan.OwnerReferences = nil
+	return k8sfake.NewSimpleClientset(
+		deployment,
+		revisionReplicaSet(deployment, 3, "web:3", "kubectl set image web=web:3"),
+		revisionReplicaSet(deployment, 1, "web:1", "initial"),
+		revisionReplicaSet(deployment, 2, "web:2", ""),
+		orphan,
+	)
+}
+
+func TestRolloutService_History_Deployment(t *testing.T) {
+	svc := NewRolloutService(newDeploymentRolloutClient(false))
+
+	history, err := svc.History(context.Background(), "Deployment", "shop", "web")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if history.Kind != "Deployment" || history.Paused {
+		t.Errorf("history = %+v", history)
+	}
+	if len(history.Revisions) != 3 {
+		t.Fatalf("got %d revisions, want 3 (the orphan ReplicaSet is not part of the history)", len(history.Revisions))
+	}
+
+	first, last := history.Revisions[0], history.Revisions[2]
+	if first.Revision != 1 || first.ChangeCause != "initial" || first.Diff != nil || first.Current {
+		t.Errorf("first revision = %+v", first)
+	}
+	if last.Revision != 3 || !last.Current || last.Name != "web-3" || !reflect.DeepEqual(last.Images, []string{"web:3"}) {
+		t.Errorf("last revision = %+v", last)
+	}
+
+	// The pod-template-hash label differs between ReplicaSets but is not a change
+	diff := last.Diff
+	if diff == nil || len(diff.Changes) != 1 || diff.Changes[0].Path != "/spec/containers/0/image" {
+		t.Fatalf("diff = %+v", diff)
+	}
+	if !strings.HasPrefix(diff.Unified, "--- revision 2\n+++ revision 3\n") || !strings.Contains(diff.Unified, "+  - image: web:3\n") {
+		t.Errorf("unified diff =\n%s", diff.Unified)
+	}
+}
+
+func TestRolloutService_Rollback_Deployment(t *testing.T) {
+	client := newDeploymentRolloutClient(false)
+	svc := NewRolloutService(client)
+
+	result, err := svc.Rollback(context.Background(), "Deployment", "shop", "web", 1)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if result.Revision != 1 || result.Skipped {
+		t.Errorf("result = %+v", result)
+	}
+
+	deployment, _ := client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "web:1" {
+		t.Errorf("image = %s, want web:1", image)
+	}
+	if _, ok := deployment.Spec.Template.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+		t.Error("pod-template-hash label copied into the deployment template")
+	}
+	if cause := deployment.Annotations[changeCauseAnnotation]; cause != "initial" {
+		t.Errorf("change-cause = %q", cause)
+	}
+}
+
+func TestRolloutService_Rollback_PreviousRevision(t *testing.T) {
+	client := newDeploymentRolloutClient(false)
+	svc := NewRolloutService(client)
+
+	result, err := svc.Rollback(context.Background(), "Deployment", "shop", "web", 0)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if result.Revision != 2 {
+		t.Errorf("rolled back to %d, want 2", result.Revision)
+	}
+	deployment, _ := client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "web:2" {
+		t.Errorf("image = %s, want web:2", image)
+	}
+	if _, ok := deployment.Annotations[changeCauseAnnotation]; ok {
+		t.Error("revision 2 has no change-cause, but one was kept")
+	}
+}
+
+func TestRolloutService_Rollback_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		paused   bool
+		kind     string
+		revision int64
+		want     error
+	}{
+		{"unknown revision", false, "Deployment", 7, ErrRevisionNotFound},
+		{"paused", true, "Deployment", 1, ErrRolloutPaused},
+		{"unsupported kind", false, "CronJob", 1, ErrUnsupportedRolloutKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newDeploymentRolloutClient(tt.paused)
+			svc := NewRolloutService(client)
+
+			if _, err := svc.Rollback(context.Background(), tt.kind, "shop", "web", tt.revision); !errors.Is(err, tt.want) {
+				t.Errorf("Rollback() error = %v, want %v", err, tt.want)
+			}
+			for _, action := range client.Actions() {
+				if action.GetVerb() == "update" || action.GetVerb() == "patch" {
+					t.Errorf("unexpected %s", action.GetVerb())
+				}
+			}
+		})
+	}
+}
+
+func TestRolloutService_Rollback_CurrentRevisionSkipped(t *testing.T) {
+	client := newDeploymentRolloutClient(false)
+	svc := NewRolloutService(client)
+
+	result, err := svc.Rollback(context.Background(), "Deployment", "shop", "web", 3)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	if !result.Skipped || result.Revision != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" {
+			t.Error("current revision should not be written again")
+		}
+	}
+}
+
+// controllerRevision returns a StatefulSet revision holding the patch the controller records
+func controllerRevision(t *testing.T, sts *appsv1.StatefulSet, revision int64, image string) *appsv1.ControllerRevision {
+	t.Helper()
+	template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db", Image: image}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	template["$patch"] = "replace"
+	data, err := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"template": template}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "db-" + strconv.FormatInt(revision, 10),
+			Namespace:       "shop",
+			Labels:          map[string]string{"app": "db"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(sts, appsv1.SchemeGroupVersion.WithKind("StatefulSet"))},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: revision,
+	}
+}
+
+func TestRolloutService_StatefulSet(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop", UID: types.UID("sts-uid")},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "db", Image: "postgres:16"}}},
+			},
+		},
+	}
+	client := k8sfake.NewSimpleClientset(sts, controllerRevision(t, sts, 1, "postgres:15"), controllerRevision(t, sts, 2, "postgres:16"))
+	svc := NewRolloutService(client)
+
+	history, err := svc.History(context.Background(), "StatefulSet", "shop", "db")
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history.Revisions) != 2 || !history.Revisions[1].Current || history.Revisions[1].Diff == nil {
+		t.Fatalf("history = %+v", history)
+	}
+	if images := history.Revisions[0].Images; !reflect.DeepEqual(images, []string{"postgres:15"}) {
+		t.Errorf("images = %v", images)
+	}
+
+	if _, err := svc.Rollback(context.Background(), "StatefulSet", "shop", "db", 0); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	updated, _ := client.AppsV1().StatefulSets("shop").Get(context.Background(), "db", metav1.GetOptions{})
+	if image := updated.Spec.Template.Spec.Containers[0].Image; image != "postgres:15" {
+		t.Errorf("image = %s, want postgres:15", image)
+	}
+
+	if err := svc.SetPaused(context.Background(), "StatefulSet", "shop", "db", true); !errors.Is(err, ErrUnsupportedRolloutKind) {
+		t.Errorf("SetPaused() error = %v, want %v", err, ErrUnsupportedRolloutKind)
+	}
+}
+
+func TestRolloutService_SetPaused(t *testing.T) {
+	client := newDeploymentRolloutClient(false)
+	svc := NewRolloutService(client)
+
+	if err := svc.SetPaused(context.Background(), "Deployment", "shop", "web", true); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	deployment, _ := client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if !deployment.Spec.Paused {
+		t.Error("deployment not paused")
+	}
+
+	if err := svc.SetPaused(context.Background(), "Deployment", "shop", "web", false); err != nil {
+		t.Fatalf("SetPaused() error = %v", err)
+	}
+	deployment, _ = client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if deployment.Spec.Paused {
+		t.Error("deployment still paused")
+	}
+}
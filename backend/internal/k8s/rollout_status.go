@@ -0,0 +1,421 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ErrRolloutTimeout reports a rollout that did not finish within the requested time
+var ErrRolloutTimeout = errors.New("timed out waiting for rollout")
+
+// progressDeadlineExceeded is the reason of the Progressing condition of a Deployment that stopped making progress
+const progressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+// RolloutStatus is the progress of a workload rollout, evaluated like kubectl rollout status
+type RolloutStatus struct {
+	Kind               string `json:"kind"`
+	Name               string `json:"name"`
+	Namespace          string `json:"namespace"`
+	Generation         int64  `json:"generation"`
+	ObservedGeneration int64  `json:"observedGeneration"`
+	Replicas           int32  `json:"replicas"` // desired replicas, or scheduled pods of a DaemonSet
+	Updated            int32  `json:"updated"`
+	Ready              int32  `json:"ready"`
+	Available          int32  `json:"available"`
+	Done               bool   `json:"done"`
+	Failed             bool   `json:"failed"` // the Deployment exceeded its progress deadline
+	Message            string `json:"message"`
+}
+
+// RolloutEvent is a Kubernetes event about the workload, its ReplicaSets or its pods
+type RolloutEvent struct {
+	Type    string `json:"type"` // Normal or Warning
+	Reason  string `json:"reason"`
+	Kind    string `json:"kind"` // kind of the object the event is about
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Count   int32  `json:"count"`
+	Time    string `json:"time"`
+}
+
+// RolloutUpdate is one update while following a rollout: a changed status or a new event
+type RolloutUpdate struct {
+	Status *RolloutStatus `json:"status,omitempty"`
+	Event  *RolloutEvent  `json:"event,omitempty"`
+}
+
+// FollowRollout watches a workload until its rollout completes, fails or the timeout expires, calling
+// onUpdate with each status change and each related event. It returns the last status; a rollout that
+// has not finished when the timeout expires returns ErrRolloutTimeout along with that status.
+func (s *RolloutService) FollowRollout(ctx context.Context, kind, namespace, name string, timeout time.Duration, onUpdate func(RolloutUpdate)) (*RolloutStatus, error) {
+	kind, err := rolloutKind(kind)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	obj, err := s.getWorkload(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	status, err := workloadRolloutStatus(obj)
+	if err != nil {
+		return nil, err
+	}
+	if status.Done || status.Failed {
+		onUpdate(RolloutUpdate{Status: status})
+		return status, nil
+	}
+	selector, err := workloadSelector(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	workloadWatch, err := s.watchWorkload(ctx, kind, namespace, name, obj.(metav1.Object).GetResourceVersion())
+	if err != nil {
+		return nil, err
+	}
+	defer func() { workloadWatch.Stop() }()
+	eventWatch, err := s.watchEvents(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { eventWatch.Stop() }()
+
+	onUpdate(RolloutUpdate{Status: status})
+	related := map[string]bool{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return status, fmt.Errorf("%w: %s", ErrRolloutTimeout, status.Message)
+			}
+			return status, ctx.Err()
+
+		case event, ok := <-workloadWatch.ResultChan():
+			if !ok || event.Type == watch.Error {
+				// The API server ends watches periodically; resume from the current object
+				workloadWatch.Stop()
+				if obj, err = s.getWorkload(ctx, kind, namespace, name); err != nil {
+					return status, err
+				}
+				if workloadWatch, err = s.watchWorkload(ctx, kind, namespace, name, obj.(metav1.Object).GetResourceVersion()); err != nil {
+					return status, err
+				}
+			} else if event.Type == watch.Deleted {
+				return status, apierrors.NewNotFound(schema.GroupResource{Group: "apps", Resource: rolloutResource(kind)}, name)
+			} else if meta, isObject := event.Object.(metav1.Object); isObject && meta.GetName() == name {
+				obj = event.Object
+			} else {
+				continue
+			}
+
+			next, err := workloadRolloutStatus(obj)
+			if err != nil {
+				return status, err
+			}
+			if *next != *status {
+				status = next
+				onUpdate(RolloutUpdate{Status: status})
+			}
+			if status.Done || status.Failed {
+				return status, nil
+			}
+
+		case event, ok := <-eventWatch.ResultChan():
+			if !ok || event.Type == watch.Error {
+				eventWatch.Stop()
+				if eventWatch, err = s.watchEvents(ctx, namespace); err != nil {
+					return status, err
+				}
+				continue
+			}
+			k8sEvent, isEvent := event.Object.(*corev1.Event)
+			if !isEvent || event.Type == watch.Deleted || !s.relatedObject(ctx, kind, obj, selector, k8sEvent.InvolvedObject, related) {
+				continue
+			}
+			onUpdate(RolloutUpdate{Event: rolloutEvent(k8sEvent)})
+		}
+	}
+}
+
+func rolloutResource(kind string) string {
+	switch kind {
+	case "StatefulSet":
+		return "statefulsets"
+	case "DaemonSet":
+		return "daemonsets"
+	default:
+		return "deployments"
+	}
+}
+
+func (s *RolloutService) getWorkload(ctx context.Context, kind, namespace, name string) (runtime.Object, error) {
+	switch kind {
+	case "StatefulSet":
+		sts, err := s.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		return sts, nil
+	case "DaemonSet":
+		ds, err := s.client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get daemonset: %w", err)
+		}
+		return ds, nil
+	default:
+		d, err := s.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get deployment: %w", err)
+		}
+		return d, nil
+	}
+}
+
+// watchWorkload watches a single workload from the given resource version
+func (s *RolloutService) watchWorkload(ctx context.Context, kind, namespace, name, resourceVersion string) (watch.Interface, error) {
+	opts := metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion: resourceVersion,
+	}
+	var w watch.Interface
+	var err error
+	switch kind {
+	case "StatefulSet":
+		w, err = s.client.AppsV1().StatefulSets(namespace).Watch(ctx, opts)
+	case "DaemonSet":
+		w, err = s.client.AppsV1().DaemonSets(namespace).Watch(ctx, opts)
+	default:
+		w, err = s.client.AppsV1().Deployments(namespace).Watch(ctx, opts)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", rolloutResource(kind), err)
+	}
+	return w, nil
+}
+
+// watchEvents watches the events of a namespace created from now on
+func (s *RolloutService) watchEvents(ctx context.Context, namespace string) (watch.Interface, error) {
+	// Listing one event gives the current resource version, so the watch skips older events
+	existing, err := s.client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	w, err := s.client.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{ResourceVersion: existing.ResourceVersion})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch events: %w", err)
+	}
+	return w, nil
+}
+
+// relatedObject reports whether an event is about the workload, one of its pods or, for Deployments, one of
+// its ReplicaSets. Pods and ReplicaSets are looked up once and the answer is cached in related.
+func (s *RolloutService) relatedObject(ctx context.Context, kind string, workload runtime.Object, selector labels.Selector, involved corev1.ObjectReference, related map[string]bool) bool {
+	owner := workload.(metav1.Object)
+	if involved.Kind == kind {
+		return involved.Name == owner.GetName()
+	}
+	key := involved.Kind + "/" + involved.Name
+	if answer, cached := related[key]; cached {
+		return answer
+	}
+
+	var answer bool
+	switch {
+	case involved.Kind == "Pod":
+		pod, err := s.client.CoreV1().Pods(owner.GetNamespace()).Get(ctx, involved.Name, metav1.GetOptions{})
+		answer = err == nil && selector.Matches(labels.Set(pod.Labels))
+	case involved.Kind == "ReplicaSet" && kind == "Deployment":
+		rs, err := s.client.AppsV1().ReplicaSets(owner.GetNamespace()).Get(ctx, involved.Name, metav1.GetOptions{})
+		answer = err == nil && metav1.IsControlledBy(rs, owner)
+	default:
+		return false
+	}
+	related[key] = answer
+	return answer
+}
+
+func rolloutEvent(event *corev1.Event) *RolloutEvent {
+	eventTime := event.LastTimestamp.Time
+	if eventTime.IsZero() {
+		eventTime = event.EventTime.Time
+	}
+	if eventTime.IsZero() {
+		eventTime = event.CreationTimestamp.Time
+	}
+	return &RolloutEvent{
+		Type:    event.Type,
+		Reason:  event.Reason,
+		Kind:    event.InvolvedObject.Kind,
+		Name:    event.InvolvedObject.Name,
+		Message: event.Message,
+		Count:   event.Count,
+		Time:    eventTime.Format(time.RFC3339),
+	}
+}
+
+func workloadSelector(obj runtime.Object) (labels.Selector, error) {
+	var labelSelector *metav1.LabelSelector
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		labelSelector = workload.Spec.Selector
+	case *appsv1.StatefulSet:
+		labelSelector = workload.Spec.Selector
+	case *appsv1.DaemonSet:
+		labelSelector = workload.Spec.Selector
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+	return selector, nil
+}
+
+func workloadRolloutStatus(obj runtime.Object) (*RolloutStatus, error) {
+	switch workload := obj.(type) {
+	case *appsv1.Deployment:
+		return deploymentRolloutStatus(workload), nil
+	case *appsv1.StatefulSet:
+		return statefulSetRolloutStatus(workload)
+	case *appsv1.DaemonSet:
+		return daemonSetRolloutStatus(workload)
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedRolloutKind, obj)
+	}
+}
+
+// deploymentRolloutStatus evaluates a Deployment rollout like kubectl rollout status
+func deploymentRolloutStatus(d *appsv1.Deployment) *RolloutStatus {
+	status := &RolloutStatus{
+		Kind:               "Deployment",
+		Name:               d.Name,
+		Namespace:          d.Namespace,
+		Generation:         d.Generation,
+		ObservedGeneration: d.Status.ObservedGeneration,
+		Replicas:           desiredReplicas(d.Spec.Replicas),
+		Updated:            d.Status.UpdatedReplicas,
+		Ready:              d.Status.ReadyReplicas,
+		Available:          d.Status.AvailableReplicas,
+	}
+
+	if d.Generation > d.Status.ObservedGeneration {
+		status.Message = "Waiting for deployment spec update to be observed"
+		return status
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Reason == progressDeadlineExceeded {
+			status.Failed = true
+			status.Message = fmt.Sprintf("deployment %q exceeded its progress deadline", d.Name)
+			return status
+		}
+	}
+	switch {
+	case status.Updated < status.Replicas:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated", d.Name, status.Updated, status.Replicas)
+	case d.Status.Replicas > status.Updated:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination", d.Name, d.Status.Replicas-status.Updated)
+	case status.Available < status.Updated:
+		status.Message = fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available", d.Name, status.Available, status.Updated)
+	default:
+		status.Done = true
+		status.Message = fmt.Sprintf("deployment %q successfully rolled out", d.Name)
+	}
+	return status
+}
+
+// statefulSetRolloutStatus evaluates a StatefulSet rollout like kubectl rollout status
+func statefulSetRolloutStatus(sts *appsv1.StatefulSet) (*RolloutStatus, error) {
+	if sts.Spec.UpdateStrategy.Type != "" && sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return nil, fmt.Errorf("%w: rollout status is only available for the %s strategy", ErrUnsupportedRolloutKind, appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+	status := &RolloutStatus{
+		Kind:               "StatefulSet",
+		Name:               sts.Name,
+		Namespace:          sts.Namespace,
+		Generation:         sts.Generation,
+		ObservedGeneration: sts.Status.ObservedGeneration,
+		Replicas:           desiredReplicas(sts.Spec.Replicas),
+		Updated:            sts.Status.UpdatedReplicas,
+		Ready:              sts.Status.ReadyReplicas,
+		Available:          sts.Status.AvailableReplicas,
+	}
+
+	if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
+		status.Message = "Waiting for statefulset spec update to be observed"
+		return status, nil
+	}
+	if status.Ready < status.Replicas {
+		status.Message = fmt.Sprintf("Waiting for %d pods to be ready", status.Replicas-status.Ready)
+		return status, nil
+	}
+	if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil && *rollingUpdate.Partition > 0 {
+		if status.Updated < status.Replicas-*rollingUpdate.Partition {
+			status.Message = fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated", status.Updated, status.Replicas-*rollingUpdate.Partition)
+			return status, nil
+		}
+		status.Done = true
+		status.Message = fmt.Sprintf("partitioned roll out complete: %d new pods have been updated", status.Updated)
+		return status, nil
+	}
+	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
+		status.Message = fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s", status.Updated, sts.Status.UpdateRevision)
+		return status, nil
+	}
+	status.Done = true
+	status.Message = fmt.Sprintf("statefulset rolling update complete %d pods at revision %s", sts.Status.CurrentReplicas, sts.Status.CurrentRevision)
+	return status, nil
+}
+
+// daemonSetRolloutStatus evaluates a DaemonSet rollout like kubectl rollout status
+func daemonSetRolloutStatus(ds *appsv1.DaemonSet) (*RolloutStatus, error) {
+	if ds.Spec.UpdateStrategy.Type != "" && ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return nil, fmt.Errorf("%w: rollout status is only available for the %s strategy", ErrUnsupportedRolloutKind, appsv1.RollingUpdateDaemonSetStrategyType)
+	}
+	status := &RolloutStatus{
+		Kind:               "DaemonSet",
+		Name:               ds.Name,
+		Namespace:          ds.Namespace,
+		Generation:         ds.Generation,
+		ObservedGeneration: ds.Status.ObservedGeneration,
+		Replicas:           ds.Status.DesiredNumberScheduled,
+		Updated:            ds.Status.UpdatedNumberScheduled,
+		Ready:              ds.Status.NumberReady,
+		Available:          ds.Status.NumberAvailable,
+	}
+
+	switch {
+	case ds.Generation > ds.Status.ObservedGeneration:
+		status.Message = "Waiting for daemon set spec update to be observed"
+	case status.Updated < status.Replicas:
+		status.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated", ds.Name, status.Updated, status.Replicas)
+	case status.Available < status.Replicas:
+		status.Message = fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available", ds.Name, status.Available, status.Replicas)
+	default:
+		status.Done = true
+		status.Message = fmt.Sprintf("daemon set %q successfully rolled out", ds.Name)
+	}
+	return status, nil
+}
+
+// desiredReplicas returns the replica count of a spec, which defaults to 1
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
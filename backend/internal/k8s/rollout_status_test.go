@@ -0,0 +1,220 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/cluster"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func int32Ptr(v int32) *int32 { return &v }
+
+// progressingDeployment returns the web Deployment halfway through a rollout to 2 replicas
+func progressingDeployment() *appsv1.Deployment {
+	d := rolloutDeployment(false)
+	d.Generation = 2
+	d.Spec.Replicas = int32Ptr(2)
+	d.Status = appsv1.DeploymentStatus{ObservedGeneration: 2, Replicas: 2, UpdatedReplicas: 1, ReadyReplicas: 1, AvailableReplicas: 1}
+	return d
+}
+
+func TestDeploymentRolloutStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(d *appsv1.Deployment)
+		wantDone   bool
+		wantFailed bool
+		wantMsg    string
+	}{
+		{"spec not observed", func(d *appsv1.Deployment) { d.Generation = 3 }, false, false, "spec update to be observed"},
+		{"updating", func(d *appsv1.Deployment) {}, false, false, "1 out of 2 new replicas have been updated"},
+		{"old replicas terminating", func(d *appsv1.Deployment) {
+			d.Status.UpdatedReplicas, d.Status.Replicas = 2, 3
+		}, false, false, "1 old replicas are pending termination"},
+		{"waiting for availability", func(d *appsv1.Deployment) { d.Status.UpdatedReplicas = 2 }, false, false, "1 of 2 updated replicas are available"},
+		{"complete", func(d *appsv1.Deployment) {
+			d.Status.UpdatedReplicas, d.Status.AvailableReplicas, d.Status.ReadyReplicas = 2, 2, 2
+		}, true, false, "successfully rolled out"},
+		{"progress deadline exceeded", func(d *appsv1.Deployment) {
+			d.Status.Conditions = []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: progressDeadlineExceeded}}
+		}, false, true, "exceeded its progress deadline"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := progressingDeployment()
+			tt.modify(d)
+			status := deploymentRolloutStatus(d)
+			if status.Done != tt.wantDone || status.Failed != tt.wantFailed || !strings.Contains(status.Message, tt.wantMsg) {
+				t.Errorf("status = %+v", status)
+			}
+		})
+	}
+}
+
+func TestStatefulSetRolloutStatus(t *testing.T) {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Generation: 1},
+		Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(3)},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 1, ReadyReplicas: 3, UpdatedReplicas: 1, CurrentReplicas: 2,
+			CurrentRevision: "db-1", UpdateRevision: "db-2",
+		},
+	}
+	if status, err := statefulSetRolloutStatus(sts); err != nil || status.Done || !strings.Contains(status.Message, "revision db-2") {
+		t.Errorf("rolling update: status = %+v, err = %v", status, err)
+	}
+
+	partitioned := sts.DeepCopy()
+	partitioned.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(2)}
+	if status, err := statefulSetRolloutStatus(partitioned); err != nil || !status.Done {
+		t.Errorf("partitioned: status = %+v, err = %v", status, err)
+	}
+
+	complete := sts.DeepCopy()
+	complete.Status.CurrentRevision, complete.Status.UpdatedReplicas = "db-2", 3
+	if status, err := statefulSetRolloutStatus(complete); err != nil || !status.Done {
+		t.Errorf("complete: status = %+v, err = %v", status, err)
+	}
+
+	onDelete := sts.DeepCopy()
+	onDelete.Spec.UpdateStrategy.Type = appsv1.OnDeleteStatefulSetStrategyType
+	if _, err := statefulSetRolloutStatus(onDelete); !errors.Is(err, ErrUnsupportedRolloutKind) {
+		t.Errorf("OnDelete strategy: err = %v", err)
+	}
+}
+
+func TestDaemonSetRolloutStatus(t *testing.T) {
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent", Generation: 4},
+		Status:     appsv1.DaemonSetStatus{ObservedGeneration: 4, DesiredNumberScheduled: 3, UpdatedNumberScheduled: 3, NumberAvailable: 2},
+	}
+	if status, _ := daemonSetRolloutStatus(ds); status.Done || !strings.Contains(status.Message, "2 of 3 updated pods are available") {
+		t.Errorf("status = %+v", status)
+	}
+	ds.Status.NumberAvailable = 3
+	if status, _ := daemonSetRolloutStatus(ds); !status.Done || status.Replicas != 3 {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestRolloutService_FollowRollout(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-abc", Namespace: "shop", Labels: map[string]string{"app": "web"}}}
+	client := k8sfake.NewSimpleClientset(progressingDeployment(), pod)
+	svc := NewRolloutService(client)
+
+	updates := make(chan RolloutUpdate, 10)
+	type outcome struct {
+		status *RolloutStatus
+		err    error
+	}
+	result := make(chan outcome, 1)
+	go func() {
+		status, err := svc.FollowRollout(context.Background(), "Deployment", "shop", "web", 5*time.Second, func(update RolloutUpdate) {
+			updates <- update
+		})
+		result <- outcome{status, err}
+	}()
+
+	next := func() RolloutUpdate {
+		t.Helper()
+		select {
+		case update := <-updates:
+			return update
+		case <-time.After(2 * time.Second):
+			t.Fatal("no update received")
+			return RolloutUpdate{}
+		}
+	}
+
+	if first := next(); first.Status == nil || first.Status.Updated != 1 || first.Status.Done {
+		t.Fatalf("first update = %+v", first)
+	}
+
+	// Only events about the Deployment's pods are forwarded
+	for _, event := range []*corev1.Event{
+		{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "shop"}, InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api-xyz"}, Reason: "Pulled"},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pull", Namespace: "shop"}, InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web-abc"}, Type: corev1.EventTypeWarning, Reason: "BackOff", Message: "Back-off pulling image"},
+	} {
+		if _, err := client.CoreV1().Events("shop").Create(context.Background(), event, metav1.CreateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if update := next(); update.Event == nil || update.Event.Name != "web-abc" || update.Event.Reason != "BackOff" {
+		t.Fatalf("event update = %+v", update)
+	}
+
+	complete := progressingDeployment()
+	complete.Status.UpdatedReplicas, complete.Status.AvailableReplicas, complete.Status.ReadyReplicas = 2, 2, 2
+	if _, err := client.AppsV1().Deployments("shop").UpdateStatus(context.Background(), complete, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if update := next(); update.Status == nil || !update.Status.Done {
+		t.Fatalf("final update = %+v", update)
+	}
+
+	select {
+	case out := <-result:
+		if out.err != nil || !out.status.Done {
+			t.Errorf("FollowRollout() = %+v, %v", out.status, out.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("FollowRollout() did not return after the rollout completed")
+	}
+}
+
+func TestRolloutService_FollowRollout_Timeout(t *testing.T) {
+	svc := NewRolloutService(k8sfake.NewSimpleClientset(progressingDeployment()))
+
+	status, err := svc.FollowRollout(context.Background(), "Deployment", "shop", "web", 50*time.Millisecond, func(RolloutUpdate) {})
+	if !errors.Is(err, ErrRolloutTimeout) {
+		t.Fatalf("FollowRollout() error = %v, want %v", err, ErrRolloutTimeout)
+	}
+	if status == nil || status.Done {
+		t.Errorf("status = %+v", status)
+	}
+}
+
+func TestStreamRolloutStatusHandler(t *testing.T) {
+	complete := progressingDeployment()
+	complete.Status.UpdatedReplicas, complete.Status.AvailableReplicas = 2, 2
+	handlers := &models.Handlers{Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset(complete)}}
+	service := NewService(handlers, cluster.NewService(handlers))
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		want     string
+	}{
+		{"complete", "kind=Deployment&name=web&namespace=shop", http.StatusOK, "event: success\n"},
+		{"missing", "kind=Deployment&name=api&namespace=shop", http.StatusOK, "event: error\n"},
+		{"invalid timeout", "kind=Deployment&name=web&namespace=shop&timeout=2h", http.StatusBadRequest, "Invalid timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			service.StreamRolloutStatus(rr, viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/status?"+tt.query, nil), "shop"))
+			if rr.Code != tt.wantCode || !strings.Contains(rr.Body.String(), tt.want) {
+				t.Errorf("got %d %q, want %d containing %q", rr.Code, rr.Body.String(), tt.wantCode, tt.want)
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	service.StreamRolloutStatus(rr, viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/status?kind=Deployment&name=web&namespace=shop", nil), "other"))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("no access: expected status 403, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,124 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/cluster"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newRolloutHandlerService() (*Service, kubernetes.Interface) {
+	client := newDeploymentRolloutClient(false)
+	handlers := &models.Handlers{Clients: map[string]kubernetes.Interface{"default": client}}
+	return NewService(handlers, cluster.NewService(handlers)), client
+}
+
+func TestGetRolloutHistoryHandler(t *testing.T) {
+	service, _ := newRolloutHandlerService()
+
+	req := viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/history?kind=Deployment&name=web&namespace=shop", nil), "shop")
+	rr := httptest.NewRecorder()
+	service.GetRolloutHistory(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var history RolloutHistory
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(history.Revisions) != 3 || history.Revisions[2].Revision != 3 {
+		t.Errorf("history = %+v", history)
+	}
+
+	tests := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"no access", viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/history?kind=Deployment&name=web&namespace=shop", nil), "other"), http.StatusForbidden},
+		{"unsupported kind", viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/history?kind=Pod&name=web&namespace=shop", nil), "shop"), http.StatusBadRequest},
+		{"not found", viewCtx(httptest.NewRequest(http.MethodGet, "/api/rollout/history?kind=Deployment&name=api&namespace=shop", nil), "shop"), http.StatusNotFound},
+		{"method not allowed", viewCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/history", nil), "shop"), http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			service.GetRolloutHistory(rr, tt.req)
+			if rr.Code != tt.want {
+				t.Errorf("expected status %d, got %d: %s", tt.want, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRollbackRolloutHandler(t *testing.T) {
+	service, client := newRolloutHandlerService()
+	body := `{"kind":"Deployment","name":"web","namespace":"shop","revision":1}`
+
+	rr := httptest.NewRecorder()
+	service.RollbackRollout(rr, viewCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/rollback", strings.NewReader(body)), "shop"))
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("view permission: expected status 403, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	service.RollbackRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/rollback", strings.NewReader(body)), "shop"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	deployment, _ := client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "web:1" {
+		t.Errorf("image = %s, want web:1", image)
+	}
+
+	rr = httptest.NewRecorder()
+	missing := `{"kind":"Deployment","name":"web","namespace":"shop","revision":42}`
+	service.RollbackRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/rollback", strings.NewReader(missing)), "shop"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown revision: expected status 404, got %d", rr.Code)
+	}
+}
+
+func TestPauseResumeRolloutHandlers(t *testing.T) {
+	service, client := newRolloutHandlerService()
+	body := `{"kind":"Deployment","name":"web","namespace":"shop"}`
+
+	rr := httptest.NewRecorder()
+	service.PauseRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/pause", strings.NewReader(body)), "shop"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("pause: expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// A paused Deployment cannot be rolled back
+	rr = httptest.NewRecorder()
+	service.RollbackRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/rollback", strings.NewReader(body)), "shop"))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("rollback while paused: expected status 409, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	service.ResumeRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/resume", strings.NewReader(body)), "shop"))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("resume: expected status 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	deployment, _ := client.AppsV1().Deployments("shop").Get(context.Background(), "web", metav1.GetOptions{})
+	if deployment.Spec.Paused {
+		t.Error("deployment still paused")
+	}
+
+	rr = httptest.NewRecorder()
+	statefulSet := `{"kind":"StatefulSet","name":"db","namespace":"shop"}`
+	service.PauseRollout(rr, editCtx(httptest.NewRequest(http.MethodPost, "/api/rollout/pause", strings.NewReader(statefulSet)), "shop"))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("pause statefulset: expected status 400, got %d", rr.Code)
+	}
+}
@@ -2,14 +2,28 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// WatchEventSync is sent with the full (filtered) list after a relist; clients replace their state with it
+	WatchEventSync = "SYNC"
+
+	defaultWatchResumeDelay = time.Second
+	watchRelistPageSize     = 500
 )
 
 // WatchService provides business logic for watching Kubernetes resources
@@ -18,36 +32,127 @@ type WatchService struct {
 	gvrResolver   GVRResolver
 	watchFunc     func(ctx context.Context, client dynamic.Interface, req WatchRequest) (watch.Interface, error)
 	transformFunc func(event watch.Event) (*WatchResult, error)
+	resumeDelay   time.Duration // wait before re-opening a watch the API server closed
+	cache         ResourceCache // serves supported kinds from shared informers when set (optional)
 }
 
 // NewWatchService creates a new WatchService
 func NewWatchService(gvrResolver GVRResolver) *WatchService {
 	return &WatchService{
 		gvrResolver: gvrResolver,
+		resumeDelay: defaultWatchResumeDelay,
 	}
 }
 
+// SetCache makes the service watch supported kinds through a shared informer cache
+func (s *WatchService) SetCache(cache ResourceCache) {
+	s.cache = cache
+}
+
 // WatchRequest represents parameters for watching resources
 type WatchRequest struct {
-	Kind          string
-	Namespace     string
-	AllNamespaces bool
+	Cluster         string // Cluster used for permission checks; defaults to the cluster in the context
+	Kind            string
+	Namespace       string
+	AllNamespaces   bool
+	ResourceVersion string // Resume after this resourceVersion; empty starts with the current state
 }
 
 // WatchResult represents a single watch event
 type WatchResult struct {
-	Type      string `json:"type"`
-	Name      string `json:"name"`
-	Namespace string `json:"namespace"`
+	Type            string            `json:"type"`
+	Name            string            `json:"name,omitempty"`
+	Namespace       string            `json:"namespace,omitempty"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Resource        *models.Resource  `json:"resource,omitempty"`  // ADDED, MODIFIED and DELETED events
+	Resources       []models.Resource `json:"resources,omitempty"` // SYNC events; absent when the list is empty
 }
 
+// errWatchExpired reports that the requested resourceVersion is too old and the client must relist
+var errWatchExpired = errors.New("watch resourceVersion expired")
+
 // StartWatch initializes a Kubernetes watch for the specified resource type
 // Returns a watcher interface that can be used to receive events
 func (s *WatchService) StartWatch(ctx context.Context, client dynamic.Interface, req WatchRequest) (watch.Interface, error) {
+	watcher, _, err := s.startWatch(ctx, client, req)
+	return watcher, err
+}
+
+// startWatch opens a watch from the cache when it serves the kind, falling back to the API server.
+// It reports whether the watch comes from the cache.
+func (s *WatchService) startWatch(ctx context.Context, client dynamic.Interface, req WatchRequest) (watch.Interface, bool, error) {
 	if s.watchFunc != nil {
-		return s.watchFunc(ctx, client, req)
+		watcher, err := s.watchFunc(ctx, client, req)
+		return watcher, false, err
+	}
+
+	if gvr, namespace, ok := s.cacheScope(req); ok {
+		watcher, err := s.cache.Watch(ctx, s.cluster(ctx, req), gvr, namespace)
+		if err == nil {
+			return watcher, true, nil
+		}
+		utils.LogWarn("Resource cache unavailable, watching the API server", map[string]interface{}{
+			"kind":  req.Kind,
+			"error": err.Error(),
+		})
+	}
+
+	watcher, err := s.watchAPIServer(ctx, client, req)
+	return watcher, false, err
+}
+
+// watchAPIServer opens a watch on the API server
+func (s *WatchService) watchAPIServer(ctx context.Context, client dynamic.Interface, req WatchRequest) (watch.Interface, error) {
+	res, err := s.resourceInterface(client, req)
+	if err != nil {
+		return nil, err
 	}
 
+	// Create watcher
+	watcher, err := res.Watch(ctx, metav1.ListOptions{
+		Watch:               true,
+		ResourceVersion:     req.ResourceVersion,
+		AllowWatchBookmarks: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start watch: %w", err)
+	}
+
+	return watcher, nil
+}
+
+// cacheScope returns the resource and namespace ("" for all) to watch or list from the cache,
+// or false when the kind is not cached
+func (s *WatchService) cacheScope(req WatchRequest) (schema.GroupVersionResource, string, bool) {
+	kind := models.NormalizeKind(req.Kind)
+	if s.cache == nil || !s.cache.CachesKind(kind) {
+		return schema.GroupVersionResource{}, "", false
+	}
+	meta, ok := models.ResourceMetaMap[kind]
+	if !ok {
+		return schema.GroupVersionResource{}, "", false
+	}
+	gvr, _ := models.ResolveGVR(kind)
+
+	if !meta.Namespaced || req.AllNamespaces {
+		return gvr, "", true
+	}
+	if req.Namespace == "" {
+		return gvr, "default", true
+	}
+	return gvr, req.Namespace, true
+}
+
+// cluster returns the cluster of a request, defaulting to the one in the context
+func (s *WatchService) cluster(ctx context.Context, req WatchRequest) string {
+	if req.Cluster != "" {
+		return req.Cluster
+	}
+	return permissions.ClusterFromContext(ctx)
+}
+
+// resourceInterface resolves the dynamic client scope for a watch request
+func (s *WatchService) resourceInterface(client dynamic.Interface, req WatchRequest) (dynamic.ResourceInterface, error) {
 	// Normalize kind (handle aliases like HPA -> HorizontalPodAutoscaler)
 	normalizedKind := models.NormalizeKind(req.Kind)
 
@@ -70,42 +175,278 @@ func (s *WatchService) StartWatch(ctx context.Context, client dynamic.Interface,
 	}
 
 	// Get the appropriate ResourceInterface
-	var res dynamic.ResourceInterface
-	if meta.Namespaced {
-		if req.AllNamespaces {
-			res = client.Resource(gvr)
-		} else {
-			res = client.Resource(gvr).Namespace(namespace)
-		}
-	} else {
-		res = client.Resource(gvr)
-	}
-
-	// Create watcher
-	watcher, err := res.Watch(ctx, metav1.ListOptions{
-		Watch: true,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to start watch: %w", err)
+	if meta.Namespaced && !req.AllNamespaces {
+		return client.Resource(gvr).Namespace(namespace), nil
 	}
-
-	return watcher, nil
+	return client.Resource(gvr), nil
 }
 
 // TransformEvent transforms a Kubernetes watch event to a WatchResult DTO
+// carrying the object in the same shape ListResources returns
 func (s *WatchService) TransformEvent(event watch.Event) (*WatchResult, error) {
+	return s.transformEvent("", event)
+}
+
+// transformEvent converts an event, treating objects without a kind as kind
+func (s *WatchService) transformEvent(kind string, event watch.Event) (*WatchResult, error) {
 	if s.transformFunc != nil {
 		return s.transformFunc(event)
 	}
 
+	if event.Type == watch.Error {
+		return nil, watchEventError(event)
+	}
+
 	obj, ok := event.Object.(*unstructured.Unstructured)
 	if !ok {
 		return nil, fmt.Errorf("event object is not unstructured")
 	}
 
-	return &WatchResult{
-		Type:      string(event.Type),
-		Name:      obj.GetName(),
-		Namespace: obj.GetNamespace(),
-	}, nil
+	result := &WatchResult{
+		Type:            string(event.Type),
+		ResourceVersion: obj.GetResourceVersion(),
+	}
+	// Bookmarks only carry the resourceVersion to resume from
+	if event.Type == watch.Bookmark {
+		return result, nil
+	}
+
+	// Objects from the API server carry their kind; objects from the cache are shared and not modified
+	convert := resourceFromUnstructured
+	if obj.GetKind() == "" {
+		convert = resourceConverterFor(models.NormalizeKind(kind))
+	}
+	resource, err := convert(obj)
+	if err != nil {
+		return nil, err
+	}
+	result.Name = obj.GetName()
+	result.Namespace = obj.GetNamespace()
+	result.Resource = &resource
+	return result, nil
+}
+
+// Stream watches the requested resources and calls send for every event the user is allowed to see.
+// A watch the API server closes is resumed from the last resourceVersion; when that version has
+// expired (410 Gone) the resources are listed again and sent as a single SYNC event.
+// Watches served by the cache start with an ADDED event per object; a client resuming from a
+// resourceVersion also gets a SYNC, since the cache cannot replay the changes it missed.
+// Stream returns when ctx is done, send fails or the watch cannot be (re)started.
+func (s *WatchService) Stream(ctx context.Context, client dynamic.Interface, req WatchRequest, send func(*WatchResult) error) error {
+	if req.Cluster == "" {
+		req.Cluster = permissions.ClusterFromContext(ctx)
+	}
+	if err := s.checkAccess(ctx, req); err != nil {
+		return err
+	}
+
+	for {
+		watcher, cached, err := s.startWatch(ctx, client, req)
+		if err == nil {
+			if cached && req.ResourceVersion != "" {
+				// Listed after the watch started, so objects deleted in between still get their DELETED event
+				req.ResourceVersion, err = s.relist(ctx, client, req, send)
+			}
+			if err == nil {
+				err = s.forward(ctx, watcher, &req, send)
+			}
+			watcher.Stop()
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		switch {
+		case err == nil:
+			// The API server closed the watch (timeout); resume where it stopped
+			if !sleepContext(ctx, s.resumeInterval()) {
+				return nil
+			}
+		case errors.Is(err, errWatchExpired) || apierrors.IsResourceExpired(err) || apierrors.IsGone(err):
+			if req.ResourceVersion, err = s.relist(ctx, client, req, send); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+	}
+}
+
+// forward sends the events of a single watch, tracking the last resourceVersion in req.
+// It returns nil when the watch channel is closed.
+func (s *WatchService) forward(ctx context.Context, watcher watch.Interface, req *WatchRequest, send func(*WatchResult) error) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return nil
+			}
+
+			result, err := s.transformEvent(req.Kind, event)
+			if err != nil {
+				if event.Type == watch.Error {
+					return err
+				}
+				continue
+			}
+			if result.ResourceVersion != "" {
+				req.ResourceVersion = result.ResourceVersion
+			}
+			// Bookmarks are forwarded so clients can resume from them, other events only if visible
+			if result.Type != string(watch.Bookmark) && !s.allowed(ctx, req.Cluster, result.Namespace) {
+				continue
+			}
+
+			if err := send(result); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// relist lists the requested resources, sends them as a SYNC event and returns the list resourceVersion
+func (s *WatchService) relist(ctx context.Context, client dynamic.Interface, req WatchRequest, send func(*WatchResult) error) (string, error) {
+	var sync *WatchResult
+	var err error
+	if gvr, namespace, ok := s.cacheScope(req); ok {
+		sync, err = s.listCached(ctx, req, gvr, namespace)
+	}
+	if sync == nil {
+		sync, err = s.listAPIServer(ctx, client, req)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := send(sync); err != nil {
+		return "", err
+	}
+	return sync.ResourceVersion, nil
+}
+
+// listCached builds the SYNC event from the cache
+func (s *WatchService) listCached(ctx context.Context, req WatchRequest, gvr schema.GroupVersionResource, namespace string) (*WatchResult, error) {
+	objects, resourceVersion, err := s.cache.List(ctx, s.cluster(ctx, req), gvr, namespace, nil)
+	if err != nil {
+		utils.LogWarn("Resource cache unavailable, listing from the API server", map[string]interface{}{
+			"kind":  req.Kind,
+			"error": err.Error(),
+		})
+		return nil, err
+	}
+
+	convert := resourceConverterFor(models.NormalizeKind(req.Kind))
+	sync := &WatchResult{Type: WatchEventSync, ResourceVersion: resourceVersion}
+	for _, obj := range objects {
+		if !s.allowed(ctx, req.Cluster, obj.GetNamespace()) {
+			continue
+		}
+		resource, err := convert(obj)
+		if err != nil {
+			continue
+		}
+		sync.Resources = append(sync.Resources, resource)
+	}
+	return sync, nil
+}
+
+// listAPIServer builds the SYNC event from a paginated list on the API server
+func (s *WatchService) listAPIServer(ctx context.Context, client dynamic.Interface, req WatchRequest) (*WatchResult, error) {
+	res, err := s.resourceInterface(client, req)
+	if err != nil {
+		return nil, err
+	}
+
+	convert := resourceConverterFor(models.NormalizeKind(req.Kind))
+	sync := &WatchResult{Type: WatchEventSync}
+	opts := metav1.ListOptions{Limit: watchRelistPageSize}
+	for {
+		list, err := res.List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to relist %s: %w", req.Kind, err)
+		}
+		// The first page fixes the snapshot the following pages belong to
+		if sync.ResourceVersion == "" {
+			sync.ResourceVersion = list.GetResourceVersion()
+		}
+		for i := range list.Items {
+			item := &list.Items[i]
+			if !s.allowed(ctx, req.Cluster, item.GetNamespace()) {
+				continue
+			}
+			resource, err := convert(item)
+			if err != nil {
+				continue
+			}
+			sync.Resources = append(sync.Resources, resource)
+		}
+		if list.GetContinue() == "" {
+			break
+		}
+		opts.Continue = list.GetContinue()
+	}
+	return sync, nil
+}
+
+// checkAccess rejects watches on a single namespace the user cannot see
+func (s *WatchService) checkAccess(ctx context.Context, req WatchRequest) error {
+	meta, ok := models.ResourceMetaMap[models.NormalizeKind(req.Kind)]
+	if !ok || !meta.Namespaced || req.AllNamespaces {
+		return nil
+	}
+
+	namespace := req.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	hasAccess, err := permissions.HasClusterNamespaceAccess(ctx, req.Cluster, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to check namespace access: %w", err)
+	}
+	if !hasAccess {
+		return fmt.Errorf("access denied to namespace: %s", namespace)
+	}
+	return nil
+}
+
+// allowed reports whether the user may see objects of a namespace; cluster-scoped objects are visible to all users
+func (s *WatchService) allowed(ctx context.Context, cluster, namespace string) bool {
+	if namespace == "" {
+		return true
+	}
+	hasAccess, err := permissions.HasClusterNamespaceAccess(ctx, cluster, namespace)
+	return err == nil && hasAccess
+}
+
+func (s *WatchService) resumeInterval() time.Duration {
+	if s.resumeDelay > 0 {
+		return s.resumeDelay
+	}
+	return defaultWatchResumeDelay
+}
+
+// watchEventError converts an ERROR watch event into an error
+func watchEventError(event watch.Event) error {
+	status, ok := event.Object.(*metav1.Status)
+	if !ok {
+		return fmt.Errorf("watch error: %v", event.Object)
+	}
+	if status.Code == 410 || status.Reason == metav1.StatusReasonExpired || status.Reason == metav1.StatusReasonGone {
+		return errWatchExpired
+	}
+	return apierrors.FromObject(status)
+}
+
+// sleepContext waits for d and reports false if ctx was canceled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
@@ -3,15 +3,22 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	dynamicfake "k8s.io/client-go/dynamic/fake"
 	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
 func TestWatchService_TransformEvent(t *testing.T) {
@@ -181,3 +188,171 @@ func TestWatchService_StartWatch(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "simulated watch error")
 }
+
+func watchedPod(name, namespace, resourceVersion string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       namespace,
+			"resourceVersion": resourceVersion,
+		},
+		"spec": map[string]interface{}{
+			"nodeName":   "node-1",
+			"containers": []interface{}{map[string]interface{}{"name": "app", "image": "nginx"}},
+		},
+		"status": map[string]interface{}{"phase": "Running"},
+	}}
+}
+
+func userContext(role string, perms map[string]string) context.Context {
+	claims := &auth.AuthClaims{Claims: models.Claims{Username: "alice", Role: role, Permissions: perms}}
+	return context.WithValue(context.Background(), auth.UserContextKey(), claims)
+}
+
+func TestWatchService_TransformEvent_FullResource(t *testing.T) {
+	service := NewWatchService(nil)
+
+	result, err := service.TransformEvent(watch.Event{Type: watch.Modified, Object: watchedPod("web", "shop", "42")})
+	if err != nil {
+		t.Fatalf("TransformEvent() error = %v", err)
+	}
+	if result.ResourceVersion != "42" || result.Resource == nil {
+		t.Fatalf("TransformEvent() = %+v", result)
+	}
+	if result.Resource.Kind != "Pod" || result.Resource.Status != "Running" {
+		t.Errorf("resource = %+v", result.Resource)
+	}
+	details := result.Resource.Details.(map[string]interface{})
+	if details["node"] != "node-1" || details["ready"] != "0/1" {
+		t.Errorf("details = %+v", details)
+	}
+
+	bookmark := &unstructured.Unstructured{}
+	bookmark.SetResourceVersion("50")
+	result, err = service.TransformEvent(watch.Event{Type: watch.Bookmark, Object: bookmark})
+	if err != nil || result.Type != "BOOKMARK" || result.ResourceVersion != "50" || result.Resource != nil {
+		t.Errorf("bookmark = %+v, %v", result, err)
+	}
+
+	_, err = service.TransformEvent(watch.Event{Type: watch.Error, Object: &metav1.Status{Code: 410, Reason: metav1.StatusReasonExpired}})
+	if err != errWatchExpired {
+		t.Errorf("410 error = %v, want errWatchExpired", err)
+	}
+}
+
+// scriptedWatches returns the given watchers in order and records the resourceVersion each was started from
+type scriptedWatches struct {
+	mu       sync.Mutex
+	watchers []*watch.FakeWatcher
+	versions []string
+}
+
+func (s *scriptedWatches) watch(ctx context.Context, client dynamic.Interface, req WatchRequest) (watch.Interface, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions = append(s.versions, req.ResourceVersion)
+	if len(s.watchers) == 0 {
+		return watch.NewFake(), nil
+	}
+	w := s.watchers[0]
+	s.watchers = s.watchers[1:]
+	return w, nil
+}
+
+func (s *scriptedWatches) startedFrom() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.versions...)
+}
+
+func collectStream(t *testing.T, ctx context.Context, service *WatchService, client dynamic.Interface, req WatchRequest, want int) []*WatchResult {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var results []*WatchResult
+	err := service.Stream(ctx, client, req, func(result *WatchResult) error {
+		results = append(results, result)
+		if len(results) == want {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if len(results) != want {
+		t.Fatalf("got %d events, want %d", len(results), want)
+	}
+	return results
+}
+
+func TestWatchService_Stream_ResumesAndFilters(t *testing.T) {
+	first, second := watch.NewFakeWithChanSize(3, false), watch.NewFakeWithChanSize(1, false)
+	first.Add(watchedPod("hidden", "secret-ns", "10"))
+	first.Add(watchedPod("web", "shop", "11"))
+	first.Stop() // the API server closed the watch
+	second.Modify(watchedPod("web", "shop", "12"))
+
+	watches := &scriptedWatches{watchers: []*watch.FakeWatcher{first, second}}
+	service := &WatchService{watchFunc: watches.watch, resumeDelay: time.Millisecond}
+
+	ctx := userContext("", map[string]string{"shop": "view"})
+	results := collectStream(t, ctx, service, nil, WatchRequest{Kind: "Pod", AllNamespaces: true, ResourceVersion: "5"}, 2)
+
+	if results[0].Type != "ADDED" || results[0].Name != "web" || results[1].Type != "MODIFIED" {
+		t.Errorf("events = %+v, %+v", results[0], results[1])
+	}
+	// Events of other namespaces are not sent, but still advance the resourceVersion
+	if got := watches.startedFrom(); len(got) < 2 || got[0] != "5" || got[1] != "11" {
+		t.Errorf("watches started from %v", got)
+	}
+}
+
+func TestWatchService_Stream_RelistsOnGone(t *testing.T) {
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{podsGVR: "PodList"})
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		list := &unstructured.UnstructuredList{}
+		list.SetAPIVersion("v1")
+		list.SetKind("PodList")
+		list.SetResourceVersion("200")
+		list.Items = []unstructured.Unstructured{*watchedPod("web", "default", "150"), *watchedPod("api", "default", "160")}
+		return true, list, nil
+	})
+
+	expired := watch.NewFakeWithChanSize(1, false)
+	expired.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired})
+	resumed := watch.NewFakeWithChanSize(1, false)
+	resumed.Delete(watchedPod("api", "default", "201"))
+
+	watches := &scriptedWatches{watchers: []*watch.FakeWatcher{expired, resumed}}
+	service := &WatchService{watchFunc: watches.watch}
+
+	ctx := userContext("admin", nil)
+	results := collectStream(t, ctx, service, client, WatchRequest{Kind: "Pod", Namespace: "default", ResourceVersion: "1"}, 2)
+
+	sync := results[0]
+	if sync.Type != WatchEventSync || sync.ResourceVersion != "200" || len(sync.Resources) != 2 {
+		t.Fatalf("sync = %+v", sync)
+	}
+	if results[1].Type != "DELETED" || results[1].Name != "api" {
+		t.Errorf("event after relist = %+v", results[1])
+	}
+	if got := watches.startedFrom(); len(got) != 2 || got[1] != "200" {
+		t.Errorf("watches started from %v, want resume from the list resourceVersion", got)
+	}
+}
+
+func TestWatchService_Stream_DeniesNamespace(t *testing.T) {
+	watches := &scriptedWatches{}
+	service := &WatchService{watchFunc: watches.watch}
+
+	ctx := userContext("", map[string]string{"shop": "view"})
+	err := service.Stream(ctx, nil, WatchRequest{Kind: "Pod", Namespace: "payments"}, func(*WatchResult) error { return nil })
+	if err == nil || len(watches.startedFrom()) != 0 {
+		t.Errorf("Stream() error = %v, watches = %v", err, watches.startedFrom())
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
-// RolloutDeployment triggers a rollout/restart of a deployment
+// RolloutDeployment triggers a rollout/restart of a Deployment, StatefulSet or DaemonSet.
+// The body's kind defaults to Deployment; apiVersion is optional.
 // Refactored to use layered architecture: Handler -> Service -> Repository
 func (s *Service) RolloutDeployment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -25,8 +27,10 @@ func (s *Service) RolloutDeployment(w http.ResponseWriter, r *http.Request) {
 
 	// Parse HTTP request body
 	var req struct {
-		Namespace string `json:"namespace"`
-		Name      string `json:"name"`
+		Kind       string `json:"kind"`
+		APIVersion string `json:"apiVersion"`
+		Namespace  string `json:"namespace"`
+		Name       string `json:"name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -37,6 +41,9 @@ func (s *Service) RolloutDeployment(w http.ResponseWriter, r *http.Request) {
 		utils.ErrorResponse(w, http.StatusBadRequest, "Missing name or namespace")
 		return
 	}
+	if req.Kind == "" {
+		req.Kind = "Deployment"
+	}
 
 	// Validate namespace access
 	ctx := r.Context()
@@ -63,23 +70,27 @@ func (s *Service) RolloutDeployment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	dynamicClient, err := s.clusterService.GetDynamicClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	// Create service using factory (dependency injection)
-	deploymentService := s.serviceFactory.CreateDeploymentService(client)
+	workloadService := s.serviceFactory.CreateWorkloadService(dynamicClient, client)
 
 	// Create context with timeout
-	ctx, cancel := utils.CreateTimeoutContext()
+	ctx, cancel := utils.CreateRequestContext(r)
 	defer cancel()
 
-	// Call service to rollout deployment (business logic layer)
-	err = deploymentService.RolloutDeployment(ctx, req.Namespace, req.Name)
+	// Call service to restart the workload (business logic layer)
+	err = workloadService.Restart(ctx, req.Kind, req.APIVersion, req.Namespace, req.Name)
+	utils.AuditLog(r, "rollout", req.Kind, req.Name, req.Namespace, err == nil, err, nil)
 	if err != nil {
-		utils.HandleErrorJSON(w, err, "Failed to rollout deployment", http.StatusInternalServerError, map[string]interface{}{
-			"namespace": req.Namespace,
-			"name":      req.Name,
-		})
+		writeWorkloadError(w, err, "Failed to rollout "+strings.ToLower(req.Kind), req.Namespace, req.Name)
 		return
 	}
 
 	// Write JSON response (HTTP layer)
-	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "Deployment rollout triggered successfully"})
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": req.Kind + " rollout triggered successfully"})
 }
@@ -0,0 +1,211 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// mockWorkloadRepository is a mock implementation of WorkloadRepository for testing
+type mockWorkloadRepository struct {
+	replicas       int32
+	scalable       bool // answer of HasScaleSubresource
+	getScaleErr    error
+	updateScaleErr error
+	patchErr       error
+
+	scaledGVR    schema.GroupVersionResource
+	updatedScale *autoscalingv1.Scale
+	patchedGVR   schema.GroupVersionResource
+	patch        []byte
+}
+
+func (m *mockWorkloadRepository) GetScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (*autoscalingv1.Scale, error) {
+	if m.getScaleErr != nil {
+		return nil, m.getScaleErr
+	}
+	return &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: "7"},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: m.replicas},
+	}, nil
+}
+
+func (m *mockWorkloadRepository) UpdateScale(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, scale *autoscalingv1.Scale) (*autoscalingv1.Scale, error) {
+	if m.updateScaleErr != nil {
+		return nil, m.updateScaleErr
+	}
+	m.scaledGVR, m.updatedScale = gvr, scale
+	return scale, nil
+}
+
+func (m *mockWorkloadRepository) HasScaleSubresource(ctx context.Context, gvr schema.GroupVersionResource) (bool, error) {
+	return m.scalable, nil
+}
+
+func (m *mockWorkloadRepository) Patch(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string, patch []byte) error {
+	if m.patchErr != nil {
+		return m.patchErr
+	}
+	m.patchedGVR, m.patch = gvr, patch
+	return nil
+}
+
+// staticGVRResolver resolves kinds from the static resource map, like the real resolver without discovery
+type staticGVRResolver struct {
+	custom map[string]schema.GroupVersionResource
+}
+
+func (r staticGVRResolver) ResolveGVR(ctx context.Context, kind, apiVersion, namespaced string) (schema.GroupVersionResource, models.ResourceMeta, error) {
+	if gvr, ok := r.custom[kind]; ok {
+		return gvr, models.ResourceMeta{Namespaced: true}, nil
+	}
+	return NewK8sGVRResolver().ResolveGVR(ctx, kind, apiVersion, namespaced)
+}
+
+func TestWorkloadService_Scale(t *testing.T) {
+	int32Value := func(v int32) *int32 { return &v }
+	tests := []struct {
+		name            string
+		kind            string
+		delta           int
+		replicas        *int32
+		currentReplicas int32
+		getScaleErr     error
+		updateScaleErr  error
+		wantReplicas    int32
+		wantResource    string
+		wantErr         string
+	}{
+		{name: "scale up successfully", kind: "Deployment", delta: 2, currentReplicas: 3, wantReplicas: 5, wantResource: "deployments"},
+		{name: "scale down successfully", kind: "Deployment", delta: -2, currentReplicas: 5, wantReplicas: 3, wantResource: "deployments"},
+		{name: "scale to zero when delta is negative enough", kind: "Deployment", delta: -5, currentReplicas: 3, wantReplicas: 0, wantResource: "deployments"},
+		{name: "scale from zero", kind: "Deployment", delta: 3, currentReplicas: 0, wantReplicas: 3, wantResource: "deployments"},
+		{name: "absolute replicas", kind: "StatefulSet", replicas: int32Value(4), currentReplicas: 1, wantReplicas: 4, wantResource: "statefulsets"},
+		{name: "absolute zero", kind: "ReplicaSet", replicas: int32Value(0), currentReplicas: 2, wantReplicas: 0, wantResource: "replicasets"},
+		{name: "delta overflowing int32", kind: "Deployment", delta: 2, currentReplicas: math.MaxInt32 - 1, wantErr: "replicas must be between 0 and 2147483647"},
+		{name: "delta out of range", kind: "Deployment", delta: math.MinInt32, currentReplicas: 3, wantErr: "replicas must be between 0 and 2147483647"},
+		{name: "get scale error", kind: "Deployment", delta: 2, getScaleErr: errors.New("deployment not found"), wantErr: "failed to get current scale"},
+		{name: "update scale error", kind: "Deployment", delta: 2, updateScaleErr: errors.New("update failed"), wantErr: "failed to update scale"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockWorkloadRepository{replicas: tt.currentReplicas, getScaleErr: tt.getScaleErr, updateScaleErr: tt.updateScaleErr}
+			service := NewWorkloadService(repo, staticGVRResolver{})
+
+			result, err := service.Scale(context.Background(), ScaleRequest{
+				Kind: tt.kind, Namespace: "default", Name: "web", Delta: tt.delta, Replicas: tt.replicas,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Errorf("Scale() error = %v, want error starting with %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Scale() error = %v", err)
+			}
+			if result.Replicas != tt.wantReplicas || result.Previous != tt.currentReplicas {
+				t.Errorf("Scale() = %+v, want %d replicas (previous %d)", result, tt.wantReplicas, tt.currentReplicas)
+			}
+			if repo.scaledGVR.Resource != tt.wantResource {
+				t.Errorf("scaled %s, want %s", repo.scaledGVR.Resource, tt.wantResource)
+			}
+			if repo.updatedScale.ResourceVersion != "7" {
+				t.Error("ResourceVersion of the scale was not preserved")
+			}
+		})
+	}
+}
+
+func TestWorkloadService_Scale_Support(t *testing.T) {
+	crd := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "workers"}
+	resolver := staticGVRResolver{custom: map[string]schema.GroupVersionResource{"Worker": crd}}
+
+	tests := []struct {
+		name     string
+		kind     string
+		scalable bool
+		wantErr  error
+	}{
+		{"custom resource with scale subresource", "Worker", true, nil},
+		{"custom resource without scale subresource", "Worker", false, ErrScaleUnsupported},
+		{"daemonset", "DaemonSet", true, ErrScaleUnsupported},
+		{"pod", "Pod", false, ErrScaleUnsupported},
+		{"cluster-scoped", "Node", true, ErrScaleUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockWorkloadRepository{replicas: 1, scalable: tt.scalable}
+			service := NewWorkloadService(repo, resolver)
+
+			_, err := service.Scale(context.Background(), ScaleRequest{Kind: tt.kind, Namespace: "default", Name: "web", Delta: 1})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Scale() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && repo.scaledGVR != crd {
+				t.Errorf("scaled %v, want %v", repo.scaledGVR, crd)
+			}
+		})
+	}
+
+	negative := int32(-1)
+	if _, err := NewWorkloadService(&mockWorkloadRepository{}, resolver).Scale(context.Background(), ScaleRequest{Kind: "Deployment", Replicas: &negative}); !errors.Is(err, ErrInvalidReplicas) {
+		t.Errorf("Scale() error = %v, want %v", err, ErrInvalidReplicas)
+	}
+}
+
+func TestWorkloadService_Restart(t *testing.T) {
+	for _, kind := range []string{"Deployment", "StatefulSet", "DaemonSet"} {
+		t.Run(kind, func(t *testing.T) {
+			repo := &mockWorkloadRepository{}
+			service := NewWorkloadService(repo, staticGVRResolver{})
+
+			if err := service.Restart(context.Background(), kind, "", "default", "web"); err != nil {
+				t.Fatalf("Restart() error = %v", err)
+			}
+			if repo.patchedGVR.Group != "apps" {
+				t.Errorf("patched %v", repo.patchedGVR)
+			}
+
+			var patch struct {
+				Spec struct {
+					Template struct {
+						Metadata struct {
+							Annotations map[string]string `json:"annotations"`
+						} `json:"metadata"`
+					} `json:"template"`
+				} `json:"spec"`
+			}
+			if err := json.Unmarshal(repo.patch, &patch); err != nil {
+				t.Fatalf("invalid patch: %v", err)
+			}
+			if patch.Spec.Template.Metadata.Annotations[restartAnnotation] == "" {
+				t.Errorf("patch %s does not set %s", repo.patch, restartAnnotation)
+			}
+		})
+	}
+}
+
+func TestWorkloadService_Restart_Errors(t *testing.T) {
+	service := NewWorkloadService(&mockWorkloadRepository{}, staticGVRResolver{})
+	for _, kind := range []string{"ReplicaSet", "Pod"} {
+		if err := service.Restart(context.Background(), kind, "", "default", "web"); !errors.Is(err, ErrRestartUnsupported) {
+			t.Errorf("Restart(%s) error = %v, want %v", kind, err, ErrRestartUnsupported)
+		}
+	}
+
+	service = NewWorkloadService(&mockWorkloadRepository{patchErr: errors.New("update failed")}, staticGVRResolver{})
+	if err := service.Restart(context.Background(), "Deployment", "", "default", "web"); err == nil || !strings.HasPrefix(err.Error(), "failed to restart Deployment") {
+		t.Errorf("Restart() error = %v", err)
+	}
+}
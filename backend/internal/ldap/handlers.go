@@ -159,6 +159,10 @@ func (s *Service) UpdateGroupsHandler(w http.ResponseWriter, r *http.Request) {
 				utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid permission: %s. Must be 'view' or 'edit'", perm.Permission))
 				return
 			}
+			if err := validatePermissionScope(perm); err != nil {
+				utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
 			validPermissions = append(validPermissions, perm)
 		}
 		// Update group with filtered permissions
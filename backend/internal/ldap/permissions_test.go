@@ -98,3 +98,42 @@ func TestCalculatePermissions(t *testing.T) {
 		}
 	})
 }
+
+func TestCalculatePermissions_ClusterScoped(t *testing.T) {
+	groupsConfig := &models.LDAPGroupsConfig{
+		Groups: []models.LDAPGroup{
+			{
+				Name: "payments",
+				Permissions: []models.LDAPGroupPermission{
+					{Cluster: "prod", Namespace: "payments", Permission: "edit"},
+					{Cluster: "*", Namespace: "payments", Permission: "view"},
+					{Namespace: "payments", Permission: "view"},
+				},
+			},
+			{
+				Name: "payments-ops",
+				Permissions: []models.LDAPGroupPermission{
+					{Cluster: "prod", Namespace: "payments", Permission: "view"},
+					{Cluster: "default", Namespace: "payments", Permission: "edit"},
+				},
+			},
+		},
+	}
+
+	service := &Service{}
+	perms := service.calculatePermissions([]string{"payments", "payments-ops"}, nil, groupsConfig)
+
+	expected := map[string]string{
+		"prod/payments": "edit",
+		"*/payments":    "view",
+		"payments":      "edit", // default cluster keeps the bare namespace key
+	}
+	if len(perms) != len(expected) {
+		t.Fatalf("expected %d permissions, got %v", len(expected), perms)
+	}
+	for key, level := range expected {
+		if perms[key] != level {
+			t.Errorf("expected %s=%s, got %q", key, level, perms[key])
+		}
+	}
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/go-ldap/ldap/v3"
 
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
@@ -82,3 +84,9 @@ func sanitizeLDAPFilter(input string) string {
 func isValidLDAPURL(url string) bool {
 	return len(url) > 0 && (url[:7] == "ldap://" || url[:8] == "ldaps://")
 }
+
+// validatePermissionScope validates the cluster and namespace of a group permission.
+// Both accept "*" as a wildcard; an empty cluster means the default cluster.
+func validatePermissionScope(perm models.LDAPGroupPermission) error {
+	return permissions.ValidatePermissionScope(perm.Cluster, perm.Namespace)
+}
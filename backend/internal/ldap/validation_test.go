@@ -1,6 +1,10 @@
 package ldap
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
 
 func TestValidateLDAPUsername(t *testing.T) {
 	tests := []struct {
@@ -96,3 +100,27 @@ func TestIsValidLDAPDN(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePermissionScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		perm    models.LDAPGroupPermission
+		wantErr bool
+	}{
+		{name: "default cluster", perm: models.LDAPGroupPermission{Namespace: "payments"}},
+		{name: "named cluster", perm: models.LDAPGroupPermission{Cluster: "prod-eu", Namespace: "payments"}},
+		{name: "wildcards", perm: models.LDAPGroupPermission{Cluster: "*", Namespace: "*"}},
+		{name: "invalid cluster", perm: models.LDAPGroupPermission{Cluster: "Prod/EU", Namespace: "payments"}, wantErr: true},
+		{name: "invalid namespace", perm: models.LDAPGroupPermission{Namespace: "pay/ments"}, wantErr: true},
+		{name: "partial wildcard not supported", perm: models.LDAPGroupPermission{Namespace: "team-*"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePermissionScope(tt.perm)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validatePermissionScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -8,8 +8,8 @@ import (
 )
 
 // userContextKeyStr is the context key for user information.
-// This must match auth.userContextKey to avoid import cycle.
-const userContextKeyStr = "user"
+// It is shared with the auth package through utils to avoid an import cycle.
+const userContextKeyStr = utils.UserContextKey
 
 // AuditMiddleware logs request details with improved information
 func AuditMiddleware(next http.HandlerFunc) http.HandlerFunc {
@@ -38,8 +38,8 @@ func AuditMiddleware(next http.HandlerFunc) http.HandlerFunc {
 				if u, ok := claims["username"].(string); ok {
 					user = u
 				}
-			} else if claims, ok := userVal.(interface{ Username() string }); ok {
-				user = claims.Username()
+			} else if claims, ok := userVal.(utils.AuditIdentity); ok {
+				user = claims.AuditUser()
 			} else {
 				// Try to extract via reflection or type assertion
 				// This handles both old Claims and new AuthClaims
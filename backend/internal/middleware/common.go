@@ -22,6 +22,11 @@ func (r *StatusRecorder) Flush() {
 	}
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController, e.g. to extend write deadlines of streams
+func (r *StatusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 // getClientIP extracts the real client IP from request.
 // Security: By default this implementation relies on RemoteAddr to avoid IP spoofing via headers.
 // If running behind a trusted proxy/Kubernetes Ingress, X-Forwarded-For should be trusted ONLY if
@@ -32,6 +32,15 @@ func TestStatusRecorder_Flush(t *testing.T) {
 	}
 }
 
+func TestStatusRecorder_Unwrap(t *testing.T) {
+	inner := httptest.NewRecorder()
+	recorder := &StatusRecorder{ResponseWriter: inner}
+
+	if recorder.Unwrap() != inner {
+		t.Error("StatusRecorder.Unwrap() did not return the underlying writer")
+	}
+}
+
 func TestChain(t *testing.T) {
 	// Create a recorder to verify order
 	var order []string
@@ -2,9 +2,15 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 )
 
-// CSRFMiddleware checks Origin/Referer for state-changing requests
+// apiTokenAuthPrefix starts the Authorization header of personal API tokens.
+// Browsers never attach it on their own, so such requests cannot be forged cross-site.
+const apiTokenAuthPrefix = "Bearer dkp_"
+
+// CSRFMiddleware checks Origin/Referer for state-changing requests.
+// Requests authenticated with a personal API token (scripts, CI) are exempt.
 func CSRFMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Skip for safe methods
@@ -13,6 +19,11 @@ func CSRFMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(r.Header.Get("Authorization"), apiTokenAuthPrefix) {
+			next(w, r)
+			return
+		}
+
 		// Check Origin or Referer
 		origin := r.Header.Get("Origin")
 		referer := r.Header.Get("Referer")
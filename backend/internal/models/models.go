@@ -22,6 +22,7 @@ type Claims struct {
 	Role             string            `json:"role"`
 	IDP              string            `json:"idp,omitempty"`         // Identity Provider: "core" or "ldap"
 	Permissions      map[string]string `json:"permissions,omitempty"` // namespace -> permission (view/edit)
+	Groups           []string          `json:"groups,omitempty"`      // Grupos del IDP, usados para impersonar al usuario en Kubernetes
 	RegisteredClaims interface{}       `json:"-"`                     // Se manejará con jwt.RegisteredClaims en el paquete auth
 }
 
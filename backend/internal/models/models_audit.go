@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// AuditRecord representa una acción auditada (quién cambió qué, dónde y con qué resultado)
+type AuditRecord struct {
+	ID        string                 `json:"id"` // Ordenable por tiempo; se usa como token de paginación
+	Time      time.Time              `json:"time"`
+	User      string                 `json:"user"`
+	IP        string                 `json:"ip,omitempty"`
+	Cluster   string                 `json:"cluster,omitempty"`
+	Verb      string                 `json:"verb"` // Acción: "create", "update", "delete", "rollout", ...
+	Kind      string                 `json:"kind,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Status    string                 `json:"status"` // "success" o "failure"
+	Error     string                 `json:"error,omitempty"`
+	DiffHash  string                 `json:"diffHash,omitempty"` // SHA-256 del contenido enviado (YAML o patch)
+	Details   map[string]interface{} `json:"details,omitempty"`
+}
+
+// PaginatedAuditRecords representa una página de registros de auditoría, del más reciente al más antiguo
+type PaginatedAuditRecords struct {
+	Records   []AuditRecord `json:"records"`
+	Continue  string        `json:"continue,omitempty"`  // Token para la siguiente página
+	Remaining int           `json:"remaining,omitempty"` // Cantidad de registros restantes que cumplen el filtro
+}
@@ -4,10 +4,23 @@ import "k8s.io/apimachinery/pkg/runtime/schema"
 
 // ClusterConfig representa la configuración de un cluster de Kubernetes
 type ClusterConfig struct {
-	Name     string `json:"name"`
-	Host     string `json:"host"`
-	Token    string `json:"token"`
-	Insecure bool   `json:"insecure"`
+	Name       string `json:"name"`
+	Host       string `json:"host"`
+	Token      string `json:"token"`
+	Insecure   bool   `json:"insecure"`
+	CAData     string `json:"caData,omitempty"`     // Certificado CA en formato PEM
+	Kubeconfig string `json:"kubeconfig,omitempty"` // Kubeconfig completo (alternativa a host+token)
+	Context    string `json:"context,omitempty"`    // Contexto del kubeconfig a utilizar (opcional)
+}
+
+// ClusterInfo representa un cluster registrado sin exponer credenciales
+type ClusterInfo struct {
+	Name      string `json:"name"`
+	Host      string `json:"host,omitempty"`
+	Source    string `json:"source"`          // "local" (cluster de arranque) o "registry"
+	Metrics   bool   `json:"metrics"`         // Indica si hay cliente de métricas disponible
+	Connected bool   `json:"connected"`       // Indica si los clientes están cargados en memoria
+	Error     string `json:"error,omitempty"` // Último error al cargar el cluster
 }
 
 // Namespace representa un namespace de Kubernetes
@@ -14,17 +14,11 @@ type LDAPConfig struct {
 	CACert             string   `json:"caCert,omitempty"`             // CA certificate in PEM format for TLS verification
 }
 
-// LDAPGroupPermission representa los permisos de un grupo LDAP para un namespace
-type LDAPGroupPermission struct {
-	Namespace  string `json:"namespace"`
-	Permission string `json:"permission"` // "view", "edit"
-}
+// LDAPGroupPermission representa los permisos de un grupo LDAP para un namespace de un cluster
+type LDAPGroupPermission = GroupPermission
 
 // LDAPGroup representa un grupo LDAP con sus permisos
-type LDAPGroup struct {
-	Name        string                `json:"name"`
-	Permissions []LDAPGroupPermission `json:"permissions"`
-}
+type LDAPGroup = GroupMapping
 
 // LDAPGroupsConfig representa la configuración de grupos LDAP
 type LDAPGroupsConfig struct {
@@ -0,0 +1,20 @@
+package models
+
+// OIDCConfig representa la configuración del proveedor OpenID Connect
+type OIDCConfig struct {
+	Enabled       bool     `json:"enabled"`
+	IssuerURL     string   `json:"issuerURL"`               // URL del issuer (p. ej. https://keycloak/realms/main)
+	ClientID      string   `json:"clientID"`                // Client ID registrado en el proveedor
+	ClientSecret  string   `json:"clientSecret,omitempty"`  // Opcional: los clientes públicos solo usan PKCE
+	RedirectURL   string   `json:"redirectURL"`             // URL de callback, p. ej. https://dkonsole/api/auth/oidc/callback
+	Scopes        []string `json:"scopes,omitempty"`        // Scopes adicionales a "openid" (por defecto profile, email, groups)
+	UsernameClaim string   `json:"usernameClaim,omitempty"` // Claim usado como nombre de usuario (por defecto preferred_username)
+	GroupsClaim   string   `json:"groupsClaim,omitempty"`   // Claim con los grupos del usuario (por defecto groups)
+	RequiredGroup string   `json:"requiredGroup,omitempty"` // Grupo requerido para acceso (opcional)
+	AdminGroups   []string `json:"adminGroups,omitempty"`   // Grupos OIDC que tienen acceso de admin al cluster
+}
+
+// OIDCGroupsConfig representa la configuración de grupos OIDC
+type OIDCGroupsConfig struct {
+	Groups []GroupMapping `json:"groups"`
+}
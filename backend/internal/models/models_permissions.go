@@ -0,0 +1,72 @@
+package models
+
+import "strings"
+
+// DefaultCluster es el nombre del cluster sobre el que DKonsole arranca
+const DefaultCluster = "default"
+
+// PermissionWildcard coincide con cualquier cluster o namespace en una clave de permisos
+const PermissionWildcard = "*"
+
+// PermissionKey construye la clave de Claims.Permissions para un par cluster/namespace.
+// Las claves del cluster por defecto son solo el namespace, igual que antes de soportar
+// múltiples clusters, para que los tokens y grupos existentes sigan funcionando.
+func PermissionKey(cluster, namespace string) string {
+	if cluster == "" || cluster == DefaultCluster {
+		return namespace
+	}
+	return cluster + "/" + namespace
+}
+
+// ParsePermissionKey separa una clave de Claims.Permissions en cluster y namespace.
+// Una clave sin "/" pertenece al cluster por defecto.
+func ParsePermissionKey(key string) (cluster, namespace string) {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return DefaultCluster, key
+}
+
+// permissionLevels define la jerarquía de permisos: edit > view
+var permissionLevels = map[string]int{
+	"view": 1,
+	"edit": 2,
+}
+
+// PermissionLevel devuelve el rango de un nivel de permiso ("view" < "edit"), o 0 si no es válido
+func PermissionLevel(level string) int {
+	return permissionLevels[level]
+}
+
+// GrantedPermission devuelve el permiso más alto que perms concede para un namespace de un cluster,
+// combinando concesiones exactas con comodines de cluster y namespace. Una concesión cubre el par
+// cuando cada parte es "*" o igual, así "prod/*" cubre "prod/payments" pero no al revés.
+func GrantedPermission(perms map[string]string, cluster, namespace string) (string, bool) {
+	best := ""
+	found := false
+	for key, level := range perms {
+		grantCluster, grantNamespace := ParsePermissionKey(key)
+		if (grantCluster != PermissionWildcard && grantCluster != cluster) ||
+			(grantNamespace != PermissionWildcard && grantNamespace != namespace) {
+			continue
+		}
+		if !found || PermissionLevel(level) > PermissionLevel(best) {
+			best = level
+			found = true
+		}
+	}
+	return best, found
+}
+
+// GroupPermission representa los permisos de un grupo del IDP (LDAP u OIDC) para un namespace de un cluster
+type GroupPermission struct {
+	Cluster    string `json:"cluster,omitempty"` // Nombre del cluster, "*" para todos (vacío = cluster por defecto)
+	Namespace  string `json:"namespace"`         // Nombre del namespace o "*" para todos
+	Permission string `json:"permission"`        // "view", "edit"
+}
+
+// GroupMapping asocia un grupo del IDP con sus permisos
+type GroupMapping struct {
+	Name        string            `json:"name"`
+	Permissions []GroupPermission `json:"permissions"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ExecRecording representa la grabación de una sesión de terminal (exec) en formato asciicast v2
+type ExecRecording struct {
+	ID        string     `json:"id"` // Ordenable por tiempo
+	User      string     `json:"user"`
+	IP        string     `json:"ip,omitempty"`
+	Cluster   string     `json:"cluster,omitempty"`
+	Namespace string     `json:"namespace"`
+	Pod       string     `json:"pod"`
+	Container string     `json:"container,omitempty"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`   // Vacío mientras la sesión sigue abierta
+	Size      int64      `json:"size"`                // Tamaño de la grabación en bytes
+	Truncated bool       `json:"truncated,omitempty"` // La sesión superó el tamaño máximo y el resto no se grabó
+}
+
+// ExecRecordingList representa las grabaciones de sesiones exec, de la más reciente a la más antigua
+type ExecRecordingList struct {
+	Enabled    bool            `json:"enabled"` // Si la grabación de sesiones está activada
+	Recordings []ExecRecording `json:"recordings"`
+}
@@ -264,3 +264,52 @@ func TestClaims(t *testing.T) {
 		t.Errorf("Claims.Role = %q, want %q", claims.Role, "admin")
 	}
 }
+
+func TestPermissionKey(t *testing.T) {
+	tests := []struct {
+		cluster   string
+		namespace string
+		key       string
+		// parsed cluster (default cluster keys carry no prefix)
+		parsedCluster string
+	}{
+		{cluster: "", namespace: "payments", key: "payments", parsedCluster: "default"},
+		{cluster: "default", namespace: "payments", key: "payments", parsedCluster: "default"},
+		{cluster: "prod", namespace: "payments", key: "prod/payments", parsedCluster: "prod"},
+		{cluster: "*", namespace: "*", key: "*/*", parsedCluster: "*"},
+	}
+
+	for _, tt := range tests {
+		if got := PermissionKey(tt.cluster, tt.namespace); got != tt.key {
+			t.Errorf("PermissionKey(%q, %q) = %q, want %q", tt.cluster, tt.namespace, got, tt.key)
+		}
+		cluster, namespace := ParsePermissionKey(tt.key)
+		if cluster != tt.parsedCluster || namespace != tt.namespace {
+			t.Errorf("ParsePermissionKey(%q) = (%q, %q), want (%q, %q)", tt.key, cluster, namespace, tt.parsedCluster, tt.namespace)
+		}
+	}
+}
+
+func TestGrantedPermission(t *testing.T) {
+	perms := map[string]string{"payments": "view", "prod/*": "edit", "*/billing": "view"}
+	tests := []struct {
+		cluster, namespace string
+		want               string
+		found              bool
+	}{
+		{"default", "payments", "view", true},
+		{"prod", "payments", "edit", true},
+		{"staging", "billing", "view", true},
+		{"staging", "payments", "", false},
+	}
+	for _, tt := range tests {
+		got, found := GrantedPermission(perms, tt.cluster, tt.namespace)
+		if got != tt.want || found != tt.found {
+			t.Errorf("GrantedPermission(%q, %q) = (%q, %v), want (%q, %v)", tt.cluster, tt.namespace, got, found, tt.want, tt.found)
+		}
+	}
+
+	if PermissionLevel("edit") <= PermissionLevel("view") || PermissionLevel("view") == 0 || PermissionLevel("admin") != 0 {
+		t.Error("PermissionLevel() must rank edit above view and reject unknown levels")
+	}
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// LocalUser representa un usuario local (IDP "core") guardado en el Secret de autenticación
+type LocalUser struct {
+	Username     string            `json:"username"`
+	PasswordHash string            `json:"passwordHash,omitempty"` // Hash Argon2id; nunca se devuelve por la API
+	Role         string            `json:"role"`                   // "admin" o "user"
+	Permissions  map[string]string `json:"permissions,omitempty"`  // clave de permiso (namespace o cluster/namespace) -> "view"/"edit"
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
@@ -0,0 +1,23 @@
+package oidc
+
+import (
+	"k8s.io/client-go/kubernetes"
+)
+
+// ServiceFactory creates and configures OIDC services
+type ServiceFactory struct {
+	k8sClient kubernetes.Interface
+}
+
+// NewServiceFactory creates a new ServiceFactory
+func NewServiceFactory(k8sClient kubernetes.Interface) *ServiceFactory {
+	return &ServiceFactory{
+		k8sClient: k8sClient,
+	}
+}
+
+// NewService creates a new OIDC service
+func (f *ServiceFactory) NewService() *Service {
+	repo := NewRepository(f.k8sClient)
+	return NewService(repo)
+}
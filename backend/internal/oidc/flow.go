@@ -0,0 +1,319 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// loginTTL is how long a user has to complete the login at the identity provider
+	loginTTL = 10 * time.Minute
+	// maxPendingLogins bounds the in-flight login state kept in memory
+	maxPendingLogins = 1000
+
+	defaultUsernameClaim = "preferred_username"
+	defaultGroupsClaim   = "groups"
+)
+
+// defaultScopes are requested in addition to "openid" when none are configured
+var defaultScopes = []string{"profile", "email", "groups"}
+
+// pendingLogin is the server-side half of an authorization request
+type pendingLogin struct {
+	nonce        string
+	codeVerifier string
+	expires      time.Time
+}
+
+// tokenResponse is the subset of the token endpoint response DKonsole uses
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// BeginLogin starts an authorization-code + PKCE login.
+// It returns the URL to redirect the browser to and the state that must come back on the callback.
+func (s *Service) BeginLogin(ctx context.Context) (string, string, error) {
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get OIDC config: %w", err)
+	}
+	if config == nil || !config.Enabled {
+		return "", "", ErrDisabled
+	}
+
+	p, err := s.getProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := url.Parse(p.doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", config.ClientID)
+	query.Set("redirect_uri", config.RedirectURL)
+	query.Set("scope", strings.Join(requestedScopes(config), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge(verifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	if err := s.storePending(state, pendingLogin{nonce: nonce, codeVerifier: verifier, expires: s.now().Add(loginTTL)}); err != nil {
+		return "", "", err
+	}
+
+	return authURL.String(), state, nil
+}
+
+// CompleteLogin finishes a login started with BeginLogin: it exchanges the authorization code,
+// verifies the ID token and maps the user's groups to DKonsole permissions.
+// The returned claims use IDP "oidc"; admins get role "admin" and nil permissions.
+func (s *Service) CompleteLogin(ctx context.Context, state, code string) (*models.Claims, error) {
+	login, ok := s.takePending(state)
+	if !ok {
+		return nil, ErrInvalidState
+	}
+	if code == "" {
+		return nil, fmt.Errorf("missing authorization code")
+	}
+
+	config, err := s.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OIDC config: %w", err)
+	}
+	if config == nil || !config.Enabled {
+		return nil, ErrDisabled
+	}
+
+	p, err := s.getProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := s.exchangeCode(ctx, p, config, code, login.codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := s.verifyIDToken(ctx, p, rawIDToken, config.ClientID, login.nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	username := usernameFromClaims(claims, config.UsernameClaim)
+	if username == "" {
+		return nil, fmt.Errorf("ID token has no usable username claim")
+	}
+	groups := groupsFromClaims(claims, config.GroupsClaim)
+
+	if config.RequiredGroup != "" && !containsGroup(groups, config.RequiredGroup) {
+		utils.LogWarn("OIDC user is not in the required group", map[string]interface{}{
+			"username":      username,
+			"requiredGroup": config.RequiredGroup,
+		})
+		return nil, ErrAccessDenied
+	}
+
+	s.refreshRepoClient()
+	groupsConfig, err := s.repo.GetGroups(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get OIDC groups config: %w", err)
+	}
+
+	perms := s.calculatePermissions(groups, config, groupsConfig)
+	role := "user"
+	if perms == nil {
+		role = "admin"
+	}
+
+	utils.LogInfo("OIDC login verified", map[string]interface{}{
+		"username": username,
+		"role":     role,
+		"groups":   groups,
+	})
+
+	return &models.Claims{
+		Username:    username,
+		Role:        role,
+		IDP:         "oidc",
+		Permissions: perms,
+		Groups:      groups,
+	}, nil
+}
+
+// exchangeCode redeems the authorization code at the token endpoint and returns the raw ID token
+func (s *Service) exchangeCode(ctx context.Context, p *provider, config *models.OIDCConfig, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", config.RedirectURL)
+	form.Set("client_id", config.ClientID)
+	form.Set("code_verifier", verifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(config.ClientID), url.QueryEscape(config.ClientSecret))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(&token); err != nil {
+		return "", fmt.Errorf("invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request rejected (status %d): %s %s", resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return token.IDToken, nil
+}
+
+// storePending records an in-flight login, dropping expired ones first
+func (s *Service) storePending(state string, login pendingLogin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, pending := range s.pending {
+		if now.After(pending.expires) {
+			delete(s.pending, key)
+		}
+	}
+	if len(s.pending) >= maxPendingLogins {
+		return fmt.Errorf("too many pending OIDC logins, try again later")
+	}
+
+	s.pending[state] = login
+	return nil
+}
+
+// takePending removes and returns an in-flight login; each state can only be used once
+func (s *Service) takePending(state string) (pendingLogin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	login, ok := s.pending[state]
+	if !ok {
+		return pendingLogin{}, false
+	}
+	delete(s.pending, state)
+	if s.now().After(login.expires) {
+		return pendingLogin{}, false
+	}
+	return login, true
+}
+
+func requestedScopes(config *models.OIDCConfig) []string {
+	scopes := []string{"openid"}
+	configured := config.Scopes
+	if len(configured) == 0 {
+		configured = defaultScopes
+	}
+	for _, scope := range configured {
+		if scope != "" && scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// usernameFromClaims reads the configured username claim, falling back to "sub"
+func usernameFromClaims(claims jwt.MapClaims, claim string) string {
+	if claim == "" {
+		claim = defaultUsernameClaim
+	}
+	if username, ok := claims[claim].(string); ok && username != "" {
+		return username
+	}
+	sub, _ := claims["sub"].(string)
+	return sub
+}
+
+// groupsFromClaims reads the configured groups claim (a list or a single string)
+func groupsFromClaims(claims jwt.MapClaims, claim string) []string {
+	if claim == "" {
+		claim = defaultGroupsClaim
+	}
+
+	var groups []string
+	switch value := claims[claim].(type) {
+	case []interface{}:
+		for _, item := range value {
+			if group, ok := item.(string); ok && group != "" {
+				groups = append(groups, group)
+			}
+		}
+	case string:
+		if value != "" {
+			groups = append(groups, value)
+		}
+	}
+	return groups
+}
+
+func containsGroup(groups []string, group string) bool {
+	for _, g := range groups {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// randomString returns 32 random bytes encoded for use in URLs (state, nonce, PKCE verifier)
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// codeChallenge derives the S256 PKCE challenge from a verifier
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,369 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// memoryRepository is an in-memory Repository for tests
+type memoryRepository struct {
+	config *models.OIDCConfig
+	groups *models.OIDCGroupsConfig
+}
+
+func (m *memoryRepository) GetConfig(ctx context.Context) (*models.OIDCConfig, error) {
+	if m.config == nil {
+		return &models.OIDCConfig{}, nil
+	}
+	copied := *m.config
+	return &copied, nil
+}
+
+func (m *memoryRepository) UpdateConfig(ctx context.Context, config *models.OIDCConfig) error {
+	copied := *config
+	m.config = &copied
+	return nil
+}
+
+func (m *memoryRepository) GetGroups(ctx context.Context) (*models.OIDCGroupsConfig, error) {
+	if m.groups == nil {
+		return &models.OIDCGroupsConfig{Groups: []models.GroupMapping{}}, nil
+	}
+	return m.groups, nil
+}
+
+func (m *memoryRepository) UpdateGroups(ctx context.Context, groups *models.OIDCGroupsConfig) error {
+	m.groups = groups
+	return nil
+}
+
+// authorization is what the mock issuer remembers about an issued code
+type authorization struct {
+	challenge string
+	nonce     string
+}
+
+// mockIssuer is a minimal OpenID provider: discovery, JWKS and a PKCE-checking token endpoint
+type mockIssuer struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	mu         sync.Mutex
+	codes      map[string]authorization
+	claims     jwt.MapClaims // extra claims for the next ID token
+	signingKey *rsa.PrivateKey
+	jwksHits   int
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	m := &mockIssuer{t: t, key: key, kid: "key-1", codes: make(map[string]authorization)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		m.jwksHits++
+		pub := m.key.PublicKey
+		kid := m.kid
+		m.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.handleToken)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize simulates the user logging in at the provider and returns the issued code
+func (m *mockIssuer) authorize(authURL string) string {
+	m.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatalf("invalid auth URL: %v", err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		m.t.Fatalf("expected PKCE S256 challenge, got %q", u.RawQuery)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	code := "code-" + q.Get("state")[:8]
+	m.codes[code] = authorization{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code
+}
+
+func (m *mockIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+	m.mu.Lock()
+	auth, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	extra := m.claims
+	signingKey := m.signingKey
+	kid := m.kid
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.challenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":                m.server.URL,
+		"sub":                "user-123",
+		"aud":                r.Form.Get("client_id"),
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              auth.nonce,
+		"preferred_username": "alice",
+		"groups":             []string{"devs"},
+	}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	if signingKey == nil {
+		signingKey = m.key
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	idToken, err := token.SignedString(signingKey)
+	if err != nil {
+		m.t.Errorf("failed to sign ID token: %v", err)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "at", "token_type": "Bearer"})
+}
+
+func newTestService(issuer *mockIssuer) (*Service, *memoryRepository) {
+	repo := &memoryRepository{
+		config: &models.OIDCConfig{
+			Enabled:     true,
+			IssuerURL:   issuer.server.URL,
+			ClientID:    "dkonsole",
+			RedirectURL: "https://dkonsole.example.com/api/auth/oidc/callback",
+			AdminGroups: []string{"platform-admins"},
+		},
+		groups: &models.OIDCGroupsConfig{Groups: []models.GroupMapping{
+			{Name: "devs", Permissions: []models.GroupPermission{
+				{Namespace: "payments", Permission: "edit"},
+				{Cluster: "prod", Namespace: "payments", Permission: "view"},
+			}},
+		}},
+	}
+	return NewService(repo), repo
+}
+
+func login(t *testing.T, service *Service, issuer *mockIssuer) (*models.Claims, error) {
+	t.Helper()
+	authURL, state, err := service.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin returned error: %v", err)
+	}
+	code := issuer.authorize(authURL)
+	return service.CompleteLogin(context.Background(), state, code)
+}
+
+func TestLoginFlow_MockIssuer(t *testing.T) {
+	issuer := newMockIssuer(t)
+	service, _ := newTestService(issuer)
+
+	authURL, state, err := service.BeginLogin(context.Background())
+	if err != nil {
+		t.Fatalf("BeginLogin returned error: %v", err)
+	}
+	if !strings.HasPrefix(authURL, issuer.server.URL+"/authorize?") {
+		t.Fatalf("unexpected authorization URL: %s", authURL)
+	}
+	q, _ := url.ParseQuery(strings.SplitN(authURL, "?", 2)[1])
+	if q.Get("state") != state || q.Get("client_id") != "dkonsole" || q.Get("scope") != "openid profile email groups" {
+		t.Fatalf("unexpected authorization parameters: %v", q)
+	}
+
+	claims, err := service.CompleteLogin(context.Background(), state, issuer.authorize(authURL))
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+	if claims.Username != "alice" || claims.Role != "user" || claims.IDP != "oidc" {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if claims.Permissions["payments"] != "edit" || claims.Permissions["prod/payments"] != "view" {
+		t.Fatalf("unexpected permissions: %v", claims.Permissions)
+	}
+	if len(claims.Groups) != 1 || claims.Groups[0] != "devs" {
+		t.Fatalf("unexpected groups: %v", claims.Groups)
+	}
+
+	// The state is single use
+	if _, err := service.CompleteLogin(context.Background(), state, "code"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState on reuse, got %v", err)
+	}
+}
+
+func TestLoginFlow_AdminAndRequiredGroup(t *testing.T) {
+	issuer := newMockIssuer(t)
+	service, repo := newTestService(issuer)
+
+	issuer.claims = jwt.MapClaims{"groups": []string{"platform-admins"}, "preferred_username": ""}
+	claims, err := login(t, service, issuer)
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+	if claims.Role != "admin" || claims.Permissions != nil {
+		t.Fatalf("expected admin with nil permissions, got %+v", claims)
+	}
+	if claims.Username != "user-123" {
+		t.Fatalf("expected fallback to sub claim, got %q", claims.Username)
+	}
+
+	repo.config.RequiredGroup = "dkonsole-users"
+	issuer.claims = nil
+	if _, err := login(t, service, issuer); !errors.Is(err, ErrAccessDenied) {
+		t.Fatalf("expected ErrAccessDenied, got %v", err)
+	}
+
+	// Custom claims
+	repo.config.RequiredGroup = ""
+	repo.config.UsernameClaim = "email"
+	repo.config.GroupsClaim = "roles"
+	issuer.claims = jwt.MapClaims{"email": "alice@example.com", "roles": "devs"}
+	claims, err = login(t, service, issuer)
+	if err != nil {
+		t.Fatalf("CompleteLogin returned error: %v", err)
+	}
+	if claims.Username != "alice@example.com" || claims.Permissions["payments"] != "edit" {
+		t.Fatalf("unexpected claims with custom claim names: %+v", claims)
+	}
+}
+
+func TestLoginFlow_RejectsInvalidTokens(t *testing.T) {
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		setup func(m *mockIssuer)
+	}{
+		{name: "wrong audience", setup: func(m *mockIssuer) { m.claims = jwt.MapClaims{"aud": "someone-else"} }},
+		{name: "wrong nonce", setup: func(m *mockIssuer) { m.claims = jwt.MapClaims{"nonce": "replayed"} }},
+		{name: "wrong issuer", setup: func(m *mockIssuer) { m.claims = jwt.MapClaims{"iss": "https://evil.example.com"} }},
+		{name: "expired", setup: func(m *mockIssuer) { m.claims = jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()} }},
+		{name: "bad signature", setup: func(m *mockIssuer) { m.signingKey = otherKey }},
+		{name: "untrusted azp", setup: func(m *mockIssuer) {
+			m.claims = jwt.MapClaims{"aud": []string{"dkonsole", "other"}, "azp": "other"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := newMockIssuer(t)
+			service, _ := newTestService(issuer)
+			tt.setup(issuer)
+
+			if _, err := login(t, service, issuer); err == nil {
+				t.Fatalf("expected login to fail")
+			}
+		})
+	}
+}
+
+func TestLoginFlow_PKCEAndStateErrors(t *testing.T) {
+	issuer := newMockIssuer(t)
+	service, repo := newTestService(issuer)
+
+	// A code redeemed with another login's verifier is rejected by the provider
+	authURL1, _, _ := service.BeginLogin(context.Background())
+	_, state2, _ := service.BeginLogin(context.Background())
+	if _, err := service.CompleteLogin(context.Background(), state2, issuer.authorize(authURL1)); err == nil {
+		t.Fatalf("expected PKCE mismatch to fail")
+	}
+
+	if _, err := service.CompleteLogin(context.Background(), "unknown", "code"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState, got %v", err)
+	}
+
+	// Expired login state
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	_, state, _ := service.BeginLogin(context.Background())
+	service.now = func() time.Time { return now.Add(loginTTL + time.Second) }
+	if _, err := service.CompleteLogin(context.Background(), state, "code"); !errors.Is(err, ErrInvalidState) {
+		t.Fatalf("expected ErrInvalidState for expired login, got %v", err)
+	}
+
+	repo.config.Enabled = false
+	if _, _, err := service.BeginLogin(context.Background()); !errors.Is(err, ErrDisabled) {
+		t.Fatalf("expected ErrDisabled, got %v", err)
+	}
+}
+
+func TestLoginFlow_KeyRotation(t *testing.T) {
+	issuer := newMockIssuer(t)
+	service, _ := newTestService(issuer)
+
+	if _, err := login(t, service, issuer); err != nil {
+		t.Fatalf("first login failed: %v", err)
+	}
+
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	issuer.mu.Lock()
+	issuer.key = newKey
+	issuer.kid = "key-2"
+	issuer.mu.Unlock()
+
+	// Unknown keys are only refetched once the refresh interval has passed
+	now := time.Now()
+	service.now = func() time.Time { return now.Add(keyRefreshInterval + time.Second) }
+	if _, err := login(t, service, issuer); err != nil {
+		t.Fatalf("login after key rotation failed: %v", err)
+	}
+	if issuer.jwksHits != 2 {
+		t.Fatalf("expected JWKS to be refetched once, got %d fetches", issuer.jwksHits)
+	}
+}
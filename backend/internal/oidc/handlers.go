@@ -0,0 +1,209 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// UpdateConfigRequest represents a request to update the OIDC configuration.
+// An empty clientSecret keeps the stored one.
+type UpdateConfigRequest struct {
+	Config models.OIDCConfig `json:"config"`
+}
+
+// UpdateGroupsRequest represents a request to update the OIDC group permissions
+type UpdateGroupsRequest struct {
+	Groups models.OIDCGroupsConfig `json:"groups"`
+}
+
+// GetOIDCStatusHandler returns whether OIDC login is enabled (public endpoint for login page)
+//
+// @Summary Estado de OIDC
+// @Description Indica si el login OIDC está habilitado
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} map[string]bool "Estado de OIDC"
+// @Router /api/oidc/status [get]
+func (s *Service) GetOIDCStatusHandler(w http.ResponseWriter, r *http.Request) {
+	config, err := s.GetConfig(r.Context())
+	if err != nil {
+		// If error, assume OIDC is not enabled
+		utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+			"enabled": false,
+		})
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"enabled": config != nil && config.Enabled,
+	})
+}
+
+// GetConfigHandler returns the current OIDC configuration without the client secret
+//
+// @Summary Obtener configuración OIDC
+// @Description Retorna la configuración OIDC (sin el client secret)
+// @Tags oidc
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Configuración OIDC"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/oidc/config [get]
+func (s *Service) GetConfigHandler(w http.ResponseWriter, r *http.Request) {
+	config, err := s.GetConfig(r.Context())
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to get OIDC config", http.StatusInternalServerError, nil)
+		return
+	}
+
+	hasSecret := config.ClientSecret != ""
+	config.ClientSecret = ""
+
+	utils.JSONResponse(w, http.StatusOK, map[string]interface{}{
+		"config":          config,
+		"hasClientSecret": hasSecret,
+	})
+}
+
+// UpdateConfigHandler updates the OIDC configuration
+//
+// @Summary Actualizar configuración OIDC
+// @Description Guarda la configuración del proveedor OIDC. Un clientSecret vacío conserva el actual.
+// @Tags oidc
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body UpdateConfigRequest true "Configuración OIDC"
+// @Success 200 {object} map[string]string "Configuración actualizada"
+// @Failure 400 {object} map[string]string "Configuración inválida"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/oidc/config [put]
+func (s *Service) UpdateConfigHandler(w http.ResponseWriter, r *http.Request) {
+	s.refreshRepoClient()
+	var req UpdateConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validateConfig(&req.Config); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	if req.Config.ClientSecret == "" {
+		current, err := s.GetConfig(ctx)
+		if err != nil {
+			utils.HandleErrorJSON(w, err, "Failed to get OIDC config", http.StatusInternalServerError, nil)
+			return
+		}
+		req.Config.ClientSecret = current.ClientSecret
+	}
+
+	if err := s.repo.UpdateConfig(ctx, &req.Config); err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to update OIDC config", http.StatusInternalServerError, map[string]interface{}{
+			"enabled": req.Config.Enabled,
+		})
+		return
+	}
+	s.resetProvider()
+
+	utils.LogInfo("OIDC config updated", map[string]interface{}{
+		"enabled":   req.Config.Enabled,
+		"issuerURL": req.Config.IssuerURL,
+		"clientID":  req.Config.ClientID,
+	})
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "OIDC configuration updated successfully",
+	})
+}
+
+// GetGroupsHandler returns the current OIDC group permissions
+//
+// @Summary Obtener grupos OIDC
+// @Description Retorna los permisos por namespace asignados a cada grupo OIDC
+// @Tags oidc
+// @Security Bearer
+// @Produce json
+// @Success 200 {object} models.OIDCGroupsConfig "Grupos OIDC"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/oidc/groups [get]
+func (s *Service) GetGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	s.refreshRepoClient()
+	groups, err := s.repo.GetGroups(r.Context())
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to get OIDC groups", http.StatusInternalServerError, nil)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, groups)
+}
+
+// UpdateGroupsHandler updates the OIDC group permissions
+//
+// @Summary Actualizar grupos OIDC
+// @Description Guarda los permisos por namespace asignados a cada grupo OIDC
+// @Tags oidc
+// @Security Bearer
+// @Accept json
+// @Produce json
+// @Param request body UpdateGroupsRequest true "Grupos OIDC"
+// @Success 200 {object} map[string]string "Grupos actualizados"
+// @Failure 400 {object} map[string]string "Grupos inválidos"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/oidc/groups [put]
+func (s *Service) UpdateGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	s.refreshRepoClient()
+	var req UpdateGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+
+	for i := range req.Groups.Groups {
+		group := &req.Groups.Groups[i]
+		if group.Name == "" {
+			utils.ErrorResponse(w, http.StatusBadRequest, "group name cannot be empty")
+			return
+		}
+
+		// Skip incomplete permissions (no namespace selected yet), reject invalid ones
+		validPermissions := make([]models.GroupPermission, 0, len(group.Permissions))
+		for _, perm := range group.Permissions {
+			if perm.Namespace == "" {
+				continue
+			}
+			if err := permissions.ValidateGroupPermission(perm); err != nil {
+				utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			validPermissions = append(validPermissions, perm)
+		}
+		group.Permissions = validPermissions
+	}
+	if req.Groups.Groups == nil {
+		req.Groups.Groups = []models.GroupMapping{}
+	}
+
+	if err := s.repo.UpdateGroups(r.Context(), &req.Groups); err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to update OIDC groups", http.StatusInternalServerError, map[string]interface{}{
+			"groups_count": len(req.Groups.Groups),
+		})
+		return
+	}
+
+	utils.LogInfo("OIDC groups updated", map[string]interface{}{
+		"groups_count": len(req.Groups.Groups),
+	})
+
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "OIDC groups updated successfully",
+	})
+}
@@ -0,0 +1,111 @@
+package oidc
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestGetConfigHandler_RedactsClientSecret(t *testing.T) {
+	repo := &memoryRepository{config: &models.OIDCConfig{Enabled: true, ClientID: "dkonsole", ClientSecret: "s3cret"}}
+	service := NewService(repo)
+
+	rr := httptest.NewRecorder()
+	service.GetConfigHandler(rr, httptest.NewRequest(http.MethodGet, "/api/oidc/config", nil))
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if bytes.Contains(rr.Body.Bytes(), []byte("s3cret")) {
+		t.Fatalf("response must not contain the client secret: %s", rr.Body.String())
+	}
+	var resp struct {
+		Config          models.OIDCConfig `json:"config"`
+		HasClientSecret bool              `json:"hasClientSecret"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || !resp.HasClientSecret || resp.Config.ClientID != "dkonsole" {
+		t.Fatalf("unexpected response: %s (err=%v)", rr.Body.String(), err)
+	}
+	if repo.config.ClientSecret != "s3cret" {
+		t.Fatalf("stored secret must not be modified")
+	}
+}
+
+func TestUpdateConfigHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantSecret string
+	}{
+		{name: "invalid json", body: "{", wantCode: http.StatusBadRequest, wantSecret: "old"},
+		{name: "missing client id", body: `{"config":{"enabled":true,"issuerURL":"https://idp","redirectURL":"https://app/cb"}}`, wantCode: http.StatusBadRequest, wantSecret: "old"},
+		{name: "plain http issuer", body: `{"config":{"enabled":true,"clientID":"c","issuerURL":"http://idp.example.com","redirectURL":"https://app/cb"}}`, wantCode: http.StatusBadRequest, wantSecret: "old"},
+		{name: "keeps existing secret", body: `{"config":{"enabled":true,"clientID":"c","issuerURL":"https://idp","redirectURL":"https://app/cb"}}`, wantCode: http.StatusOK, wantSecret: "old"},
+		{name: "replaces secret", body: `{"config":{"enabled":true,"clientID":"c","clientSecret":"new","issuerURL":"http://localhost:5556/dex","redirectURL":"http://127.0.0.1:8080/cb"}}`, wantCode: http.StatusOK, wantSecret: "new"},
+		{name: "disabled config may be incomplete", body: `{"config":{"enabled":false}}`, wantCode: http.StatusOK, wantSecret: "old"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memoryRepository{config: &models.OIDCConfig{ClientSecret: "old"}}
+			service := NewService(repo)
+
+			rr := httptest.NewRecorder()
+			service.UpdateConfigHandler(rr, httptest.NewRequest(http.MethodPut, "/api/oidc/config", bytes.NewBufferString(tt.body)))
+
+			if rr.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, rr.Code, rr.Body.String())
+			}
+			if repo.config.ClientSecret != tt.wantSecret {
+				t.Fatalf("expected stored secret %q, got %q", tt.wantSecret, repo.config.ClientSecret)
+			}
+		})
+	}
+}
+
+func TestUpdateGroupsHandler(t *testing.T) {
+	repo := &memoryRepository{}
+	service := NewService(repo)
+
+	body := `{"groups":{"groups":[{"name":"devs","permissions":[
+		{"namespace":"payments","permission":"edit"},
+		{"namespace":"","permission":"view"},
+		{"cluster":"*","namespace":"shared","permission":"view"}]}]}}`
+	rr := httptest.NewRecorder()
+	service.UpdateGroupsHandler(rr, httptest.NewRequest(http.MethodPut, "/api/oidc/groups", bytes.NewBufferString(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if len(repo.groups.Groups) != 1 || len(repo.groups.Groups[0].Permissions) != 2 {
+		t.Fatalf("expected incomplete permission to be dropped, got %+v", repo.groups)
+	}
+
+	for _, invalid := range []string{
+		`{"groups":{"groups":[{"name":"","permissions":[]}]}}`,
+		`{"groups":{"groups":[{"name":"devs","permissions":[{"namespace":"payments","permission":"admin"}]}]}}`,
+		`{"groups":{"groups":[{"name":"devs","permissions":[{"cluster":"Bad_Name","namespace":"payments","permission":"view"}]}]}}`,
+	} {
+		rr := httptest.NewRecorder()
+		service.UpdateGroupsHandler(rr, httptest.NewRequest(http.MethodPut, "/api/oidc/groups", bytes.NewBufferString(invalid)))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", invalid, rr.Code)
+		}
+	}
+}
+
+func TestGetOIDCStatusHandler(t *testing.T) {
+	service := NewService(&memoryRepository{config: &models.OIDCConfig{Enabled: true}})
+
+	rr := httptest.NewRecorder()
+	service.GetOIDCStatusHandler(rr, httptest.NewRequest(http.MethodGet, "/api/oidc/status", nil))
+
+	var resp map[string]bool
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil || !resp["enabled"] {
+		t.Fatalf("expected enabled status, got %s", rr.Body.String())
+	}
+}
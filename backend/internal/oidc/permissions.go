@@ -0,0 +1,21 @@
+package oidc
+
+import (
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// calculatePermissions maps the user's group claims to DKonsole permissions,
+// the same way LDAP groups are mapped. Admin group members get nil (full access).
+func (s *Service) calculatePermissions(groups []string, config *models.OIDCConfig, groupsConfig *models.OIDCGroupsConfig) map[string]string {
+	if config != nil && permissions.IsAdminGroupMember(groups, config.AdminGroups) {
+		utils.LogInfo("OIDC user is admin group member, returning nil permissions", nil)
+		return nil
+	}
+
+	if groupsConfig == nil {
+		return map[string]string{}
+	}
+	return permissions.GroupPermissions(groups, groupsConfig.Groups)
+}
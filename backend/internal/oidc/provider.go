@@ -0,0 +1,281 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// providerCacheTTL is how long discovery data and signing keys are reused
+	providerCacheTTL = time.Hour
+	// keyRefreshInterval limits JWKS refetches triggered by unknown key IDs
+	keyRefreshInterval = time.Minute
+	// maxResponseBytes bounds responses read from the identity provider
+	maxResponseBytes = 1 << 20
+)
+
+// signingMethods are the ID token algorithms accepted from the provider
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// discoveryDocument is the subset of the OpenID provider metadata DKonsole uses
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// provider caches the discovery document and signing keys of one issuer
+type provider struct {
+	issuerURL   string
+	doc         discoveryDocument
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	keysFetched time.Time
+}
+
+// jsonWebKey is a single entry of a JWKS document
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// getProvider returns the cached provider for the issuer, fetching discovery data when needed
+func (s *Service) getProvider(ctx context.Context, issuerURL string) (*provider, error) {
+	issuerURL = strings.TrimSuffix(issuerURL, "/")
+
+	s.mu.Lock()
+	cached := s.provider
+	s.mu.Unlock()
+	if cached != nil && cached.issuerURL == issuerURL && s.now().Sub(cached.fetchedAt) < providerCacheTTL {
+		return cached, nil
+	}
+
+	var doc discoveryDocument
+	if err := s.getJSON(ctx, issuerURL+"/.well-known/openid-configuration", &doc); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	if strings.TrimSuffix(doc.Issuer, "/") != issuerURL {
+		return nil, fmt.Errorf("issuer mismatch: discovery document reports %q", doc.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document is missing required endpoints")
+	}
+
+	keys, err := s.fetchKeys(ctx, doc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	p := &provider{issuerURL: issuerURL, doc: doc, keys: keys, fetchedAt: now, keysFetched: now}
+
+	s.mu.Lock()
+	s.provider = p
+	s.mu.Unlock()
+	return p, nil
+}
+
+// fetchKeys downloads and parses the provider's JWKS
+func (s *Service) fetchKeys(ctx context.Context, jwksURI string) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := s.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			utils.LogWarn("Skipping unsupported OIDC signing key", map[string]interface{}{
+				"kid":   jwk.Kid,
+				"error": err.Error(),
+			})
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable OIDC signing keys found")
+	}
+	return keys, nil
+}
+
+// publicKey converts an RSA or EC JWK into a Go public key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid modulus: %w", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, fmt.Errorf("invalid exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid x coordinate: %w", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid y coordinate: %w", err)
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("point is not on curve %s", k.Crv)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// lookupKey returns the signing key for a key ID, refetching the JWKS once when the key is unknown
+// (the provider may have rotated its keys since they were cached).
+func (s *Service) lookupKey(ctx context.Context, p *provider, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	key, ok := findKey(p.keys, kid)
+	canRefresh := s.now().Sub(p.keysFetched) >= keyRefreshInterval
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+	if !canRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	keys, err := s.fetchKeys(ctx, p.doc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.keys = keys
+	p.keysFetched = s.now()
+	if key, ok := findKey(keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// findKey picks a key by ID; tokens without a key ID are accepted when the provider has a single key
+func findKey(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// verifyIDToken validates the ID token signature, issuer, audience, expiry and nonce
+func (s *Service) verifyIDToken(ctx context.Context, p *provider, rawToken, clientID, nonce string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.doc.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(s.now),
+		jwt.WithLeeway(30*time.Second),
+	)
+
+	_, err := parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return s.lookupKey(ctx, p, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	// With several audiences, the authorized party must be DKonsole
+	if aud, ok := claims["aud"].([]interface{}); ok && len(aud) > 1 {
+		if azp, _ := claims["azp"].(string); azp != clientID {
+			return nil, fmt.Errorf("invalid ID token: authorized party mismatch")
+		}
+	}
+
+	return claims, nil
+}
+
+// getJSON fetches a URL from the provider and decodes the JSON response
+func (s *Service) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("invalid JSON from %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	configKey = "oidc-config"
+	groupsKey = "oidc-groups"
+)
+
+// Repository defines the interface for OIDC configuration data access
+type Repository interface {
+	GetConfig(ctx context.Context) (*models.OIDCConfig, error)
+	UpdateConfig(ctx context.Context, config *models.OIDCConfig) error
+	GetGroups(ctx context.Context) (*models.OIDCGroupsConfig, error)
+	UpdateGroups(ctx context.Context, groups *models.OIDCGroupsConfig) error
+}
+
+// K8sRepository implements Repository using a Kubernetes Secret
+type K8sRepository struct {
+	client     kubernetes.Interface
+	namespace  string
+	secretName string
+}
+
+// NewRepository creates a new K8sRepository instance
+func NewRepository(client kubernetes.Interface) *K8sRepository {
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		// Fallback to default namespace
+		namespace = "default"
+		utils.LogWarn("Failed to get current namespace, using default", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return &K8sRepository{
+		client:    client,
+		namespace: namespace,
+		// #nosec G101 -- This is a secret name, not a credential
+		secretName: "oidc-config",
+	}
+}
+
+// ServiceAccountNamespaceFile is the path to the service account namespace file
+var ServiceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// getCurrentNamespace retrieves the current namespace from the pod's service account
+func getCurrentNamespace() (string, error) {
+	// Try reading from service account namespace file (standard in Kubernetes pods)
+	if data, err := os.ReadFile(ServiceAccountNamespaceFile); err == nil {
+		namespace := string(data)
+		if namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	// Fallback to environment variable
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace, nil
+	}
+
+	return "", fmt.Errorf("could not determine namespace: service account file not found and POD_NAMESPACE not set")
+}
+
+// GetConfig retrieves the OIDC configuration from the Secret
+func (r *K8sRepository) GetConfig(ctx context.Context) (*models.OIDCConfig, error) {
+	var config models.OIDCConfig
+	found, err := r.readKey(ctx, configKey, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC config: %w", err)
+	}
+	if !found {
+		// Not configured yet - OIDC is disabled
+		return &models.OIDCConfig{Enabled: false}, nil
+	}
+	return &config, nil
+}
+
+// UpdateConfig stores the OIDC configuration in the Secret
+func (r *K8sRepository) UpdateConfig(ctx context.Context, config *models.OIDCConfig) error {
+	if err := r.writeKey(ctx, configKey, config); err != nil {
+		return err
+	}
+
+	utils.LogInfo("Updated OIDC config in Secret", map[string]interface{}{
+		"secret_name": r.secretName,
+		"namespace":   r.namespace,
+		"enabled":     config.Enabled,
+	})
+	return nil
+}
+
+// GetGroups retrieves the OIDC group permissions from the Secret
+func (r *K8sRepository) GetGroups(ctx context.Context) (*models.OIDCGroupsConfig, error) {
+	groups := models.OIDCGroupsConfig{Groups: []models.GroupMapping{}}
+	if _, err := r.readKey(ctx, groupsKey, &groups); err != nil {
+		return nil, fmt.Errorf("failed to read OIDC groups: %w", err)
+	}
+	return &groups, nil
+}
+
+// UpdateGroups stores the OIDC group permissions in the Secret
+func (r *K8sRepository) UpdateGroups(ctx context.Context, groups *models.OIDCGroupsConfig) error {
+	if err := r.writeKey(ctx, groupsKey, groups); err != nil {
+		return err
+	}
+
+	utils.LogInfo("Updated OIDC groups in Secret", map[string]interface{}{
+		"secret_name":  r.secretName,
+		"namespace":    r.namespace,
+		"groups_count": len(groups.Groups),
+	})
+	return nil
+}
+
+// readKey decodes a JSON entry of the Secret into out.
+// It reports false when there is no client, the Secret does not exist or the key is empty.
+func (r *K8sRepository) readKey(ctx context.Context, key string, out interface{}) (bool, error) {
+	if r.client == nil {
+		return false, nil
+	}
+
+	secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	data, exists := secret.Data[key]
+	if !exists || len(data) == 0 {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// writeKey stores value as JSON under key, creating the Secret if needed
+func (r *K8sRepository) writeKey(ctx context.Context, key string, value interface{}) error {
+	if r.client == nil {
+		return fmt.Errorf("kubernetes client not available")
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", key, err)
+	}
+
+	secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.secretName,
+				Namespace: r.namespace,
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{key: data},
+		}
+		if _, err := r.client.CoreV1().Secrets(r.namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create secret: %w", err)
+		}
+		utils.LogInfo("Created Secret for OIDC config", map[string]interface{}{
+			"secret_name": r.secretName,
+			"namespace":   r.namespace,
+		})
+		return nil
+	}
+
+	if secret.Data == nil {
+		secret.Data = make(map[string][]byte)
+	}
+	secret.Data[key] = data
+
+	if _, err := r.client.CoreV1().Secrets(r.namespace).Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update secret: %w", err)
+	}
+	return nil
+}
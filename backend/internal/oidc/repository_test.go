@@ -0,0 +1,60 @@
+package oidc
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestK8sRepository_ConfigAndGroups(t *testing.T) {
+	client := k8sfake.NewSimpleClientset()
+	repo := &K8sRepository{client: client, namespace: "dkonsole", secretName: "oidc-config"}
+	ctx := context.Background()
+
+	config, err := repo.GetConfig(ctx)
+	if err != nil || config.Enabled {
+		t.Fatalf("expected disabled config before setup, got %+v (err=%v)", config, err)
+	}
+	groups, err := repo.GetGroups(ctx)
+	if err != nil || len(groups.Groups) != 0 {
+		t.Fatalf("expected no groups before setup, got %+v (err=%v)", groups, err)
+	}
+
+	// First write creates the Secret, the second updates it
+	if err := repo.UpdateConfig(ctx, &models.OIDCConfig{Enabled: true, ClientID: "dkonsole", ClientSecret: "s"}); err != nil {
+		t.Fatalf("UpdateConfig error: %v", err)
+	}
+	if err := repo.UpdateGroups(ctx, &models.OIDCGroupsConfig{Groups: []models.GroupMapping{{Name: "devs"}}}); err != nil {
+		t.Fatalf("UpdateGroups error: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("dkonsole").Get(ctx, "oidc-config", metav1.GetOptions{})
+	if err != nil || len(secret.Data) != 2 {
+		t.Fatalf("expected secret with config and groups, got %v (err=%v)", secret, err)
+	}
+
+	config, _ = repo.GetConfig(ctx)
+	if !config.Enabled || config.ClientID != "dkonsole" || config.ClientSecret != "s" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+	groups, _ = repo.GetGroups(ctx)
+	if len(groups.Groups) != 1 || groups.Groups[0].Name != "devs" {
+		t.Fatalf("unexpected groups: %+v", groups)
+	}
+}
+
+func TestK8sRepository_NilClient(t *testing.T) {
+	repo := &K8sRepository{namespace: "dkonsole", secretName: "oidc-config"}
+	ctx := context.Background()
+
+	if config, err := repo.GetConfig(ctx); err != nil || config.Enabled {
+		t.Fatalf("expected disabled config without client, got %+v (err=%v)", config, err)
+	}
+	if err := repo.UpdateConfig(ctx, &models.OIDCConfig{}); err == nil {
+		t.Fatalf("expected error saving without client")
+	}
+}
@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// httpTimeout bounds every request made to the identity provider
+const httpTimeout = 10 * time.Second
+
+var (
+	// ErrDisabled is returned when OIDC login is attempted while the provider is disabled
+	ErrDisabled = errors.New("OIDC login is not enabled")
+	// ErrInvalidState is returned when the callback state is unknown, reused or expired
+	ErrInvalidState = errors.New("invalid or expired OIDC login state")
+	// ErrAccessDenied is returned when the user is authenticated but not allowed in DKonsole
+	ErrAccessDenied = auth.ErrOIDCAccessDenied
+)
+
+// Service provides business logic for OpenID Connect login
+type Service struct {
+	repo          Repository
+	handlersModel *models.Handlers
+	httpClient    *http.Client
+	now           func() time.Time
+
+	mu       sync.Mutex
+	pending  map[string]pendingLogin // in-flight logins by state
+	provider *provider               // cached discovery document and signing keys
+}
+
+// NewService creates a new OIDC service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo:       repo,
+		httpClient: &http.Client{Timeout: httpTimeout},
+		now:        time.Now,
+		pending:    make(map[string]pendingLogin),
+	}
+}
+
+// SetHandlersModel wires the global handlers model for refreshing the K8s client.
+func (s *Service) SetHandlersModel(handlersModel *models.Handlers) {
+	s.handlersModel = handlersModel
+}
+
+// SetHTTPClient replaces the client used to talk to the identity provider
+func (s *Service) SetHTTPClient(client *http.Client) {
+	s.httpClient = client
+}
+
+func (s *Service) refreshRepoClient() {
+	repo, ok := s.repo.(*K8sRepository)
+	if !ok || s.handlersModel == nil {
+		return
+	}
+
+	s.handlersModel.RLock()
+	client := s.handlersModel.Clients["default"]
+	s.handlersModel.RUnlock()
+
+	if client != nil {
+		repo.client = client
+	}
+}
+
+// GetConfig returns the OIDC configuration (for internal use)
+func (s *Service) GetConfig(ctx context.Context) (*models.OIDCConfig, error) {
+	s.refreshRepoClient()
+	return s.repo.GetConfig(ctx)
+}
+
+// resetProvider drops cached discovery data, e.g. after the issuer changed
+func (s *Service) resetProvider() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = nil
+}
@@ -0,0 +1,57 @@
+package oidc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// validateConfig checks an OIDC configuration before it is saved.
+// A disabled configuration may be incomplete.
+func validateConfig(config *models.OIDCConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+	if config.ClientID == "" {
+		return fmt.Errorf("clientID is required")
+	}
+	if err := validateProviderURL(config.IssuerURL, "issuerURL"); err != nil {
+		return err
+	}
+	if err := validateProviderURL(config.RedirectURL, "redirectURL"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateProviderURL requires an absolute https URL; plain http is only accepted for loopback hosts
+func validateProviderURL(raw, field string) error {
+	if raw == "" {
+		return fmt.Errorf("%s is required", field)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%s must be an absolute URL", field)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if isLoopback(u.Hostname()) {
+			return nil
+		}
+		return fmt.Errorf("%s must use https", field)
+	default:
+		return fmt.Errorf("%s must use https", field)
+	}
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -39,6 +39,7 @@ func TestIsAdmin(t *testing.T) {
 				&models.Claims{
 					Username: "ldapuser",
 					Role:     "user",
+					IDP:      "ldap",
 					Permissions: map[string]string{
 						"ns1": "view",
 					},
@@ -54,6 +55,40 @@ func TestIsAdmin(t *testing.T) {
 			want:    true,
 			wantErr: false,
 		},
+		{
+			name: "LDAP admin group matches regardless of case",
+			ctx: context.WithValue(
+				context.Background(),
+				auth.UserContextKey(),
+				&models.Claims{Username: "ldapuser", Role: "user", IDP: "ldap"},
+			),
+			ldapAdminChecker: &mockLDAPAdminChecker{
+				userGroups: []string{"CN-Admins"},
+				config: &models.LDAPConfig{
+					Enabled:     true,
+					AdminGroups: []string{"cn-admins"},
+				},
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "OIDC user sharing the name of an LDAP admin is not admin",
+			ctx: context.WithValue(
+				context.Background(),
+				auth.UserContextKey(),
+				&models.Claims{Username: "ldapuser", Role: "user", IDP: "oidc"},
+			),
+			ldapAdminChecker: &mockLDAPAdminChecker{
+				userGroups: []string{"admins"},
+				config: &models.LDAPConfig{
+					Enabled:     true,
+					AdminGroups: []string{"admins"},
+				},
+			},
+			want:    false,
+			wantErr: false,
+		},
 		{
 			name: "user not in LDAP admin group is not admin",
 			ctx: context.WithValue(
@@ -62,6 +97,7 @@ func TestIsAdmin(t *testing.T) {
 				&models.Claims{
 					Username: "ldapuser",
 					Role:     "user",
+					IDP:      "ldap",
 					Permissions: map[string]string{
 						"ns1": "view",
 					},
@@ -102,6 +138,7 @@ func TestIsAdmin(t *testing.T) {
 				&models.Claims{
 					Username: "ldapuser",
 					Role:     "user",
+					IDP:      "ldap",
 					Permissions: map[string]string{
 						"ns1": "view",
 					},
@@ -117,6 +154,27 @@ func TestIsAdmin(t *testing.T) {
 			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "scoped API token of an LDAP admin is not admin",
+			ctx: context.WithValue(
+				context.Background(),
+				auth.UserContextKey(),
+				&auth.AuthClaims{
+					Claims:         models.Claims{Username: "ldapuser", Role: "user", IDP: "ldap"},
+					APITokenID:     "abc",
+					APITokenScoped: true,
+				},
+			),
+			ldapAdminChecker: &mockLDAPAdminChecker{
+				userGroups: []string{"admins"},
+				config: &models.LDAPConfig{
+					Enabled:     true,
+					AdminGroups: []string{"admins"},
+				},
+			},
+			want:    false,
+			wantErr: false,
+		},
 		{
 			name:             "no user in context",
 			ctx:              context.Background(),
@@ -172,6 +230,7 @@ func TestRequireAdmin(t *testing.T) {
 				&models.Claims{
 					Username: "ldapuser",
 					Role:     "user",
+					IDP:      "ldap",
 					Permissions: map[string]string{
 						"ns1": "view",
 					},
@@ -2,13 +2,16 @@ package permissions
 
 import (
 	"context"
+	"sort"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
 // FilterAllowedNamespaces filters a list of namespaces to only include those the user has access to
+// in the cluster the context is scoped to
 func FilterAllowedNamespaces(ctx context.Context, namespaces []string) ([]string, error) {
+	cluster := ClusterFromContext(ctx)
 	claims, err := GetUserFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -29,7 +32,7 @@ func FilterAllowedNamespaces(ctx context.Context, namespaces []string) ([]string
 	// Filter namespaces based on permissions
 	allowed := make([]string, 0)
 	for _, ns := range namespaces {
-		if _, hasAccess := claims.Permissions[ns]; hasAccess {
+		if _, hasAccess := models.GrantedPermission(claims.Permissions, cluster, ns); hasAccess {
 			allowed = append(allowed, ns)
 		}
 	}
@@ -38,7 +41,14 @@ func FilterAllowedNamespaces(ctx context.Context, namespaces []string) ([]string
 }
 
 // GetAllowedNamespaces returns the list of namespaces the user has access to
+// in the cluster the context is scoped to
 func GetAllowedNamespaces(ctx context.Context) ([]string, error) {
+	return GetAllowedClusterNamespaces(ctx, ClusterFromContext(ctx))
+}
+
+// GetAllowedClusterNamespaces returns the namespaces the user has explicit access to in a cluster.
+// An empty list means "all namespaces" for admins and for users with a namespace wildcard grant.
+func GetAllowedClusterNamespaces(ctx context.Context, cluster string) ([]string, error) {
 	claims, err := GetUserFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -56,17 +66,35 @@ func GetAllowedNamespaces(ctx context.Context) ([]string, error) {
 		return []string{}, nil // Empty list means no access
 	}
 
-	// Return list of allowed namespaces
+	// A namespace wildcard grant behaves like unrestricted access in this cluster
+	if hasAllNamespacesGrant(claims.Permissions, cluster) {
+		return []string{}, nil
+	}
+
+	// Return list of allowed namespaces in this cluster (deduplicated across exact and wildcard cluster grants)
+	seen := make(map[string]bool)
 	allowed := make([]string, 0, len(claims.Permissions))
-	for ns := range claims.Permissions {
+	for key := range claims.Permissions {
+		grantCluster, ns := models.ParsePermissionKey(key)
+		if !matchesScope(grantCluster, cluster) || seen[ns] {
+			continue
+		}
+		seen[ns] = true
 		allowed = append(allowed, ns)
 	}
+	sort.Strings(allowed)
 
 	return allowed, nil
 }
 
 // FilterResources filters a list of resources to only include those in namespaces the user has access to
+// in the cluster the context is scoped to
 func FilterResources(ctx context.Context, resources []models.Resource) ([]models.Resource, error) {
+	return FilterClusterResources(ctx, ClusterFromContext(ctx), resources)
+}
+
+// FilterClusterResources filters resources listed from a specific cluster by namespace permissions
+func FilterClusterResources(ctx context.Context, cluster string, resources []models.Resource) ([]models.Resource, error) {
 	claims, err := GetUserFromContext(ctx)
 	if err != nil {
 		return nil, err
@@ -88,10 +116,11 @@ func FilterResources(ctx context.Context, resources []models.Resource) ([]models
 		}
 
 		// Check if user has access to this namespace
-		if _, hasAccess := claims.Permissions[resource.Namespace]; hasAccess {
+		if _, hasAccess := models.GrantedPermission(claims.Permissions, cluster, resource.Namespace); hasAccess {
 			filtered = append(filtered, resource)
 		} else {
 			utils.LogWarn("Filtered out resource due to lack of namespace access", map[string]interface{}{
+				"cluster":   cluster,
 				"namespace": resource.Namespace,
 				"kind":      resource.Kind,
 				"name":      resource.Name,
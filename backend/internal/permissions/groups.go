@@ -0,0 +1,75 @@
+package permissions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// IsAdminGroupMember reports whether any of the user's groups is an admin group.
+// Group names are compared case-insensitively, like in GroupPermissions.
+func IsAdminGroupMember(groups, adminGroups []string) bool {
+	for _, adminGroup := range adminGroups {
+		if inGroups(groups, adminGroup) {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupPermissions maps an identity provider's groups to a permissions map.
+// Group names are compared case-insensitively and the highest level wins for each key.
+// Keys follow models.PermissionKey, so grants for the default cluster keep the bare namespace.
+func GroupPermissions(groups []string, mappings []models.GroupMapping) map[string]string {
+	permissions := make(map[string]string)
+
+	for _, mapping := range mappings {
+		if !inGroups(groups, mapping.Name) {
+			continue
+		}
+		for _, perm := range mapping.Permissions {
+			key := models.PermissionKey(perm.Cluster, perm.Namespace)
+			if models.PermissionLevel(perm.Permission) > models.PermissionLevel(permissions[key]) {
+				permissions[key] = perm.Permission
+			}
+		}
+	}
+
+	return permissions
+}
+
+// inGroups reports whether name is one of groups, ignoring case
+func inGroups(groups []string, name string) bool {
+	for _, group := range groups {
+		if strings.EqualFold(group, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateGroupPermission validates the level, cluster and namespace of a group permission.
+// Cluster and namespace accept "*" as a wildcard; an empty cluster means the default cluster.
+func ValidateGroupPermission(perm models.GroupPermission) error {
+	if models.PermissionLevel(perm.Permission) == 0 {
+		return fmt.Errorf("invalid permission: %s. Must be 'view' or 'edit'", perm.Permission)
+	}
+	return ValidatePermissionScope(perm.Cluster, perm.Namespace)
+}
+
+// ValidatePermissionScope validates the cluster and namespace of a permission key
+func ValidatePermissionScope(cluster, namespace string) error {
+	if cluster != "" && cluster != models.PermissionWildcard {
+		if err := utils.ValidateK8sName(cluster, "cluster"); err != nil {
+			return err
+		}
+	}
+	if namespace != models.PermissionWildcard {
+		if err := utils.ValidateNamespace(namespace); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package permissions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestGroupPermissions(t *testing.T) {
+	mappings := []models.GroupMapping{
+		{Name: "Devs", Permissions: []models.GroupPermission{
+			{Namespace: "payments", Permission: "view"},
+			{Cluster: "prod", Namespace: "*", Permission: "view"},
+		}},
+		{Name: "payments-owners", Permissions: []models.GroupPermission{
+			{Namespace: "payments", Permission: "edit"},
+			{Namespace: "billing", Permission: "bogus"},
+		}},
+		{Name: "unrelated", Permissions: []models.GroupPermission{
+			{Namespace: "secret", Permission: "edit"},
+		}},
+	}
+
+	got := GroupPermissions([]string{"devs", "payments-owners"}, mappings)
+	want := map[string]string{"payments": "edit", "prod/*": "view"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupPermissions() = %v, want %v", got, want)
+	}
+
+	if got := GroupPermissions(nil, mappings); len(got) != 0 {
+		t.Errorf("expected no permissions without groups, got %v", got)
+	}
+}
+
+func TestGroupMatching_IgnoresCase(t *testing.T) {
+	groups := []string{"Platform-Admins", "DEVS"}
+
+	if !IsAdminGroupMember(groups, []string{"platform-admins"}) {
+		t.Error("IsAdminGroupMember() = false for an admin group in a different case")
+	}
+	if !IsAdminGroupMember([]string{"platform-admins"}, []string{"Platform-Admins"}) {
+		t.Error("IsAdminGroupMember() = false for a configured admin group in a different case")
+	}
+	if IsAdminGroupMember(groups, []string{"platform"}) {
+		t.Error("IsAdminGroupMember() = true for a group that is not an admin group")
+	}
+
+	// The same group grants namespace permissions and admin alike
+	mappings := []models.GroupMapping{{Name: "Platform-ADMINS", Permissions: []models.GroupPermission{
+		{Namespace: "payments", Permission: "edit"},
+	}}}
+	if got := GroupPermissions([]string{"platform-admins"}, mappings); got["payments"] != "edit" {
+		t.Errorf("GroupPermissions() = %v, want edit on payments", got)
+	}
+}
+
+func TestValidateGroupPermission(t *testing.T) {
+	tests := []struct {
+		perm    models.GroupPermission
+		wantErr bool
+	}{
+		{perm: models.GroupPermission{Namespace: "payments", Permission: "view"}},
+		{perm: models.GroupPermission{Cluster: "*", Namespace: "*", Permission: "edit"}},
+		{perm: models.GroupPermission{Cluster: "prod", Namespace: "payments", Permission: "admin"}, wantErr: true},
+		{perm: models.GroupPermission{Cluster: "Prod_EU", Namespace: "payments", Permission: "view"}, wantErr: true},
+		{perm: models.GroupPermission{Namespace: "team-*", Permission: "view"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateGroupPermission(tt.perm); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateGroupPermission(%+v) error = %v, wantErr %v", tt.perm, err, tt.wantErr)
+		}
+	}
+}
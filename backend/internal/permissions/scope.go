@@ -0,0 +1,62 @@
+package permissions
+
+import (
+	"context"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+type clusterContextKey struct{}
+
+// WithCluster returns a context scoped to the given cluster.
+// Permission checks that do not take an explicit cluster use this value.
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	if cluster == "" {
+		cluster = models.DefaultCluster
+	}
+	return context.WithValue(ctx, clusterContextKey{}, cluster)
+}
+
+// ClusterFromContext returns the cluster the request is scoped to ("default" if none)
+func ClusterFromContext(ctx context.Context) string {
+	if cluster, ok := ctx.Value(clusterContextKey{}).(string); ok && cluster != "" {
+		return cluster
+	}
+	return models.DefaultCluster
+}
+
+// matchesScope reports whether a grant pattern (exact name or "*") covers value
+func matchesScope(pattern, value string) bool {
+	return pattern == models.PermissionWildcard || pattern == value
+}
+
+// hasAllNamespacesGrant reports whether the user has a namespace wildcard grant in the cluster
+func hasAllNamespacesGrant(perms map[string]string, cluster string) bool {
+	for key := range perms {
+		grantCluster, grantNamespace := models.ParsePermissionKey(key)
+		if matchesScope(grantCluster, cluster) && grantNamespace == models.PermissionWildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// HasClusterAccess checks if the user has any permission in the given cluster
+func HasClusterAccess(ctx context.Context, cluster string) (bool, error) {
+	claims, err := GetUserFromContext(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if claims.Role == "admin" {
+		return true, nil
+	}
+
+	for key := range claims.Permissions {
+		grantCluster, _ := models.ParsePermissionKey(key)
+		if matchesScope(grantCluster, cluster) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
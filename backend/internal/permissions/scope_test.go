@@ -0,0 +1,176 @@
+package permissions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func userContext(perms map[string]string) context.Context {
+	return context.WithValue(context.Background(), auth.UserContextKey(), &models.Claims{
+		Username:    "testuser",
+		Role:        "user",
+		Permissions: perms,
+	})
+}
+
+func TestClusterFromContext(t *testing.T) {
+	if got := ClusterFromContext(context.Background()); got != "default" {
+		t.Errorf("expected default cluster, got %q", got)
+	}
+	if got := ClusterFromContext(WithCluster(context.Background(), "")); got != "default" {
+		t.Errorf("expected empty cluster to map to default, got %q", got)
+	}
+	if got := ClusterFromContext(WithCluster(context.Background(), "prod")); got != "prod" {
+		t.Errorf("expected prod, got %q", got)
+	}
+}
+
+func TestGetClusterPermissionLevel(t *testing.T) {
+	ctx := userContext(map[string]string{
+		"payments":        "view", // default cluster only
+		"prod/payments":   "edit",
+		"*/shared":        "view",
+		"staging/*":       "edit",
+		"prod/monitoring": "view",
+		"*/monitoring":    "edit",
+	})
+
+	tests := []struct {
+		name      string
+		cluster   string
+		namespace string
+		want      string
+		wantErr   bool
+	}{
+		{name: "bare key applies to default cluster", cluster: "default", namespace: "payments", want: "view"},
+		{name: "cluster-specific grant", cluster: "prod", namespace: "payments", want: "edit"},
+		{name: "bare key does not leak to other clusters", cluster: "dev", namespace: "payments", wantErr: true},
+		{name: "cluster wildcard", cluster: "dev", namespace: "shared", want: "view"},
+		{name: "namespace wildcard", cluster: "staging", namespace: "anything", want: "edit"},
+		{name: "highest matching grant wins", cluster: "prod", namespace: "monitoring", want: "edit"},
+		{name: "no matching grant", cluster: "prod", namespace: "other", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetClusterPermissionLevel(ctx, tt.cluster, tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetClusterPermissionLevel() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetClusterPermissionLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanPerformAction_UsesContextCluster(t *testing.T) {
+	ctx := userContext(map[string]string{
+		"payments":      "view",
+		"prod/payments": "edit",
+	})
+
+	canEdit, err := CanPerformAction(ctx, "payments", "edit")
+	if err != nil || canEdit {
+		t.Fatalf("expected view-only on default cluster, got canEdit=%v err=%v", canEdit, err)
+	}
+
+	canEdit, err = CanPerformAction(WithCluster(ctx, "prod"), "payments", "edit")
+	if err != nil || !canEdit {
+		t.Fatalf("expected edit on prod cluster, got canEdit=%v err=%v", canEdit, err)
+	}
+
+	if _, err := CanPerformAction(WithCluster(ctx, "dev"), "payments", "view"); err == nil {
+		t.Fatalf("expected no access on dev cluster")
+	}
+}
+
+func TestGetAllowedClusterNamespaces(t *testing.T) {
+	ctx := userContext(map[string]string{
+		"payments":      "view",
+		"prod/payments": "edit",
+		"*/shared":      "view",
+		"prod/billing":  "view",
+		"staging/*":     "view",
+	})
+
+	got, err := GetAllowedClusterNamespaces(ctx, "prod")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"billing", "payments", "shared"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prod namespaces = %v, want %v", got, want)
+	}
+
+	got, _ = GetAllowedClusterNamespaces(ctx, "default")
+	if want := []string{"payments", "shared"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default namespaces = %v, want %v", got, want)
+	}
+
+	// Namespace wildcard means all namespaces (empty list)
+	got, _ = GetAllowedClusterNamespaces(ctx, "staging")
+	if len(got) != 0 {
+		t.Errorf("staging namespaces = %v, want empty (all)", got)
+	}
+
+	// Context-scoped variant
+	got, _ = GetAllowedNamespaces(WithCluster(ctx, "dev"))
+	if want := []string{"shared"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dev namespaces = %v, want %v", got, want)
+	}
+}
+
+func TestFilterClusterResources(t *testing.T) {
+	ctx := userContext(map[string]string{
+		"prod/payments": "view",
+	})
+	resources := []models.Resource{
+		{Name: "api", Namespace: "payments", Kind: "Pod"},
+		{Name: "web", Namespace: "frontend", Kind: "Pod"},
+		{Name: "node-1", Kind: "Node"},
+	}
+
+	filtered, err := FilterClusterResources(ctx, "prod", resources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(filtered) != 2 || filtered[0].Name != "api" || filtered[1].Name != "node-1" {
+		t.Errorf("unexpected prod result: %+v", filtered)
+	}
+
+	filtered, _ = FilterResources(ctx, resources)
+	if len(filtered) != 1 || filtered[0].Name != "node-1" {
+		t.Errorf("expected only cluster-scoped resources on default cluster, got %+v", filtered)
+	}
+}
+
+func TestHasClusterAccess(t *testing.T) {
+	ctx := userContext(map[string]string{
+		"prod/payments": "view",
+		"payments":      "view",
+	})
+
+	for cluster, want := range map[string]bool{"prod": true, "default": true, "dev": false} {
+		got, err := HasClusterAccess(ctx, cluster)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got != want {
+			t.Errorf("HasClusterAccess(%q) = %v, want %v", cluster, got, want)
+		}
+	}
+
+	wildcard := userContext(map[string]string{"*/shared": "view"})
+	if ok, _ := HasClusterAccess(wildcard, "anything"); !ok {
+		t.Errorf("expected cluster wildcard to grant access")
+	}
+
+	admin := context.WithValue(context.Background(), auth.UserContextKey(), &models.Claims{Role: "admin"})
+	if ok, _ := HasClusterAccess(admin, "anything"); !ok {
+		t.Errorf("expected admin to have access to every cluster")
+	}
+}
@@ -3,13 +3,23 @@ package permissions
 import (
 	"context"
 	"fmt"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
 // HasNamespaceAccess checks if the user has access to a specific namespace
+// in the cluster the context is scoped to (see WithCluster)
 // Returns true if:
 // - User is admin (role == "admin" means full access, including LDAP admins)
 // - User has any permission (view/edit) for the namespace
 func HasNamespaceAccess(ctx context.Context, namespace string) (bool, error) {
+	return HasClusterNamespaceAccess(ctx, ClusterFromContext(ctx), namespace)
+}
+
+// HasClusterNamespaceAccess checks if the user has access to a namespace in a specific cluster.
+// Grants may use "*" for the cluster, the namespace, or both.
+func HasClusterNamespaceAccess(ctx context.Context, cluster, namespace string) (bool, error) {
 	claims, err := GetUserFromContext(ctx)
 	if err != nil {
 		return false, err
@@ -27,14 +37,21 @@ func HasNamespaceAccess(ctx context.Context, namespace string) (bool, error) {
 		return false, nil
 	}
 
-	_, hasAccess := claims.Permissions[namespace]
+	_, hasAccess := models.GrantedPermission(claims.Permissions, cluster, namespace)
 	return hasAccess, nil
 }
 
 // GetPermissionLevel returns the permission level for a namespace
+// in the cluster the context is scoped to
 // Returns "edit", "view", or "" if no access
 // Admin users return "edit" (full access)
 func GetPermissionLevel(ctx context.Context, namespace string) (string, error) {
+	return GetClusterPermissionLevel(ctx, ClusterFromContext(ctx), namespace)
+}
+
+// GetClusterPermissionLevel returns the permission level for a namespace in a specific cluster.
+// When several grants match (exact and wildcard), the highest level wins.
+func GetClusterPermissionLevel(ctx context.Context, cluster, namespace string) (string, error) {
 	claims, err := GetUserFromContext(ctx)
 	if err != nil {
 		return "", err
@@ -53,7 +70,7 @@ func GetPermissionLevel(ctx context.Context, namespace string) (string, error) {
 	}
 
 	// Get permission for namespace
-	permission, exists := claims.Permissions[namespace]
+	permission, exists := models.GrantedPermission(claims.Permissions, cluster, namespace)
 	if !exists {
 		return "", fmt.Errorf("no access to namespace: %s", namespace)
 	}
@@ -62,27 +79,27 @@ func GetPermissionLevel(ctx context.Context, namespace string) (string, error) {
 }
 
 // CanPerformAction checks if the user can perform a specific action on a namespace
+// in the cluster the context is scoped to
 // Actions: "view", "edit"
 // Returns true if user has the required permission level or higher
 func CanPerformAction(ctx context.Context, namespace, action string) (bool, error) {
-	permission, err := GetPermissionLevel(ctx, namespace)
+	return CanPerformClusterAction(ctx, ClusterFromContext(ctx), namespace, action)
+}
+
+// CanPerformClusterAction checks if the user can perform an action on a namespace in a specific cluster
+func CanPerformClusterAction(ctx context.Context, cluster, namespace, action string) (bool, error) {
+	permission, err := GetClusterPermissionLevel(ctx, cluster, namespace)
 	if err != nil {
 		return false, err
 	}
 
-	// Permission hierarchy: edit > view
-	permissionLevels := map[string]int{
-		"view": 1,
-		"edit": 2,
-	}
-
-	requiredLevel, ok := permissionLevels[action]
-	if !ok {
+	requiredLevel := models.PermissionLevel(action)
+	if requiredLevel == 0 {
 		return false, fmt.Errorf("invalid action: %s. Must be 'view' or 'edit'", action)
 	}
 
-	userLevel, ok := permissionLevels[permission]
-	if !ok {
+	userLevel := models.PermissionLevel(permission)
+	if userLevel == 0 {
 		return false, fmt.Errorf("invalid permission level: %s", permission)
 	}
 
@@ -126,28 +143,25 @@ func IsAdmin(ctx context.Context, ldapAdminChecker LDAPAdminChecker) (bool, erro
 		return false, err
 	}
 
+	// A scoped API token only grants the namespaces in its scope, even when its owner is an admin
+	if authClaims, ok := ctx.Value(auth.UserContextKey()).(*auth.AuthClaims); ok && authClaims.APITokenScoped {
+		return false, nil
+	}
+
 	// Core admin has full access
 	if claims.Role == "admin" {
 		return true, nil
 	}
 
-	// Check if user belongs to LDAP admin groups
-	if ldapAdminChecker != nil {
+	// Check if an LDAP user belongs to LDAP admin groups; users of other IDPs may share a
+	// username with an LDAP account and must not inherit its groups
+	if claims.IDP == "ldap" && ldapAdminChecker != nil {
 		config, err := ldapAdminChecker.GetConfig(ctx)
 		if err == nil && config != nil && config.Enabled && len(config.AdminGroups) > 0 {
 			// Get user's groups
 			userGroups, err := ldapAdminChecker.GetUserGroups(ctx, claims.Username)
-			if err == nil {
-				// Check if user belongs to any admin group
-				for _, adminGroup := range config.AdminGroups {
-					for _, userGroup := range userGroups {
-						if userGroup == adminGroup {
-							// Using fmt.Printf here as we don't have utils usage in this file currently
-							// Ideally we should import/use a logger if available or pass it
-							return true, nil
-						}
-					}
-				}
+			if err == nil && IsAdminGroupMember(userGroups, config.AdminGroups) {
+				return true, nil
 			}
 		}
 	}
@@ -2,6 +2,7 @@ package pod
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,7 +16,9 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 
 	"github.com/flaucha/DKonsole/backend/internal/middleware"
+	"github.com/flaucha/DKonsole/backend/internal/models"
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
@@ -68,8 +71,49 @@ func (s *Service) ExecIntoPod(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	// 4. Streaming (IO)
-	s.streamPodConnection(r.Context(), conn, executor, *params)
+	// 4. Recording; when it is configured, sessions that cannot be recorded are refused
+	recorder, err := s.recordings.Start(r.Context(), models.ExecRecording{
+		User:      utils.RequestUser(r),
+		IP:        utils.GetClientIP(r),
+		Cluster:   execCluster(params.Cluster),
+		Namespace: params.Namespace,
+		Pod:       params.PodName,
+		Container: params.Container,
+	})
+	if err != nil {
+		utils.LogError(err, "Failed to start exec session recording", map[string]interface{}{
+			"pod":       fmt.Sprintf("%s/%s", params.Namespace, params.PodName),
+			"container": params.Container,
+		})
+		conn.WriteMessage(websocket.TextMessage, []byte("Exec error: the session cannot be recorded"))
+		return
+	}
+	defer func() {
+		if err := recorder.Close(); err != nil {
+			utils.LogError(err, "Failed to complete exec session recording", map[string]interface{}{
+				"id": recorder.ID(),
+			})
+		}
+	}()
+
+	// 5. Streaming (IO)
+	s.streamPodConnection(r.Context(), conn, executor, *params, recorder)
+
+	// A session closed because its recording stopped is audited as a failed exec
+	if err := recorder.Err(); err != nil {
+		utils.AuditLog(r, "exec", "Pod", params.PodName, params.Namespace, false, err, map[string]interface{}{
+			"container": params.Container,
+			"recording": recorder.ID(),
+		})
+	}
+}
+
+// execCluster names the cluster of an exec session the way audit records do
+func execCluster(cluster string) string {
+	if cluster == "" {
+		return "default"
+	}
+	return cluster
 }
 
 func (s *Service) validateExecPermissions(ctx context.Context, namespace string) error {
@@ -126,7 +170,10 @@ func checkWebSocketOrigin(r *http.Request) bool {
 	return middleware.IsRequestOriginAllowed(r)
 }
 
-func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn, executor remotecommand.Executor, params utils.PodParams) {
+// streamPodConnection pipes the WebSocket to the pod's terminal until either side closes.
+// Input and output are also passed to recorder, which may be nil; the session is closed as soon
+// as the recording stops, so nothing is exchanged unrecorded.
+func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn, executor remotecommand.Executor, params utils.PodParams, recorder *recording.Recorder) {
 	// Configure WebSocket connection
 	const (
 		pongWait   = 60 * time.Second
@@ -147,6 +194,21 @@ func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn,
 	streamCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	var stopOnce sync.Once
+	stopUnrecorded := func(err error) {
+		stopOnce.Do(func() {
+			message := "Exec session closed: the session can no longer be recorded"
+			if errors.Is(err, recording.ErrMaxSize) {
+				message = "Exec session closed: the session recording reached its maximum size"
+			}
+			writeMu.Lock()
+			conn.SetWriteDeadline(time.Now().Add(writeWait))
+			conn.WriteMessage(websocket.TextMessage, []byte(message))
+			writeMu.Unlock()
+			cancel()
+		})
+	}
+
 	// Start ping loop
 	go func() {
 		ticker := time.NewTicker(pingPeriod)
@@ -180,6 +242,10 @@ func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn,
 			if len(message) == 0 || (len(message) == 1 && message[0] == 0) {
 				continue
 			}
+			if err := recorder.Input(message); err != nil {
+				stopUnrecorded(err)
+				return
+			}
 			stdinWriter.Write(message)
 		}
 	}()
@@ -192,6 +258,10 @@ func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn,
 		for {
 			n, err := stdoutReader.Read(buf)
 			if n > 0 {
+				if err := recorder.Output(buf[:n]); err != nil {
+					stopUnrecorded(err)
+					return
+				}
 				writeMu.Lock()
 				conn.SetWriteDeadline(time.Now().Add(writeWait))
 				writeErr := conn.WriteMessage(websocket.BinaryMessage, buf[:n])
@@ -213,7 +283,7 @@ func (s *Service) streamPodConnection(ctx context.Context, conn *websocket.Conn,
 		Tty:    true,
 	})
 
-	if err != nil {
+	if err != nil && recorder.Err() == nil {
 		utils.LogError(err, "Exec stream error", map[string]interface{}{
 			"pod":       fmt.Sprintf("%s/%s", params.Namespace, params.PodName),
 			"container": params.Container,
@@ -20,6 +20,7 @@ import (
 
 	"github.com/flaucha/DKonsole/backend/internal/auth"
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 )
 
 // MockClusterService
@@ -236,6 +237,106 @@ func TestService_ExecIntoPod_Mocked(t *testing.T) {
 		// Wait a bit to ensure filtering logic runs
 		time.Sleep(50 * time.Millisecond)
 	})
+	t.Run("ExecIntoPod Recording", func(t *testing.T) {
+		mockClusterService := new(MockClusterService)
+		mockExecService := new(MockExecService)
+		store := recording.NewMemoryStore(0)
+		service := &Service{
+			clusterService: mockClusterService,
+			execFactory:    func() execCreator { return mockExecService },
+			handlers:       &models.Handlers{},
+			recordings:     recording.NewService(store, 0, 0),
+		}
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), auth.UserContextKey(), &auth.AuthClaims{
+				Claims: models.Claims{Username: "alice", Role: "admin"},
+			})
+			service.ExecIntoPod(w, r.WithContext(ctx))
+		}))
+		defer s.Close()
+
+		mockClusterService.On("GetClient", mock.Anything).Return(nil, nil)
+		mockClusterService.On("GetRESTConfig", mock.Anything).Return(&rest.Config{}, nil)
+		mockExecutor := new(MockExecutor)
+		mockExecutor.On("StreamWithContext", mock.Anything, mock.Anything).Return(nil)
+		mockExecService.On("CreateExecutor", mock.Anything, mock.Anything, mock.Anything).Return(mockExecutor, "ws://mock", nil)
+
+		ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"?namespace=default&pod=pod-1&container=c1", nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer ws.Close()
+		assert.NoError(t, ws.WriteMessage(websocket.BinaryMessage, []byte("ls\r")))
+		_, msg, err := ws.ReadMessage()
+		assert.NoError(t, err)
+		assert.Contains(t, string(msg), "mock output")
+
+		// The recording is completed once the session ends
+		var recordings []models.ExecRecording
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			recordings, _ = store.List(context.Background(), recording.Query{})
+			if len(recordings) == 1 && recordings[0].EndedAt != nil {
+				break
+			}
+		}
+		if assert.Len(t, recordings, 1) {
+			meta := recordings[0]
+			assert.NotNil(t, meta.EndedAt)
+			assert.Equal(t, "alice", meta.User)
+			assert.Equal(t, "default", meta.Cluster)
+			assert.Equal(t, "pod-1", meta.Pod)
+			assert.Equal(t, "c1", meta.Container)
+
+			data, _, err := store.Open(context.Background(), meta.ID)
+			if assert.NoError(t, err) {
+				content, _ := io.ReadAll(data)
+				data.Close()
+				assert.Contains(t, string(content), `"o","mock output"`)
+				assert.Contains(t, string(content), `"i","ls\r"`)
+			}
+		}
+	})
+	t.Run("ExecIntoPod Recording Truncated", func(t *testing.T) {
+		store := recording.NewMemoryStore(0)
+		wsURL := newRecordingExecServer(t, recording.NewService(store, 0, 1))
+
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer ws.Close()
+
+		// Output that cannot be recorded is not forwarded; the session is closed instead
+		_, msg, err := ws.ReadMessage()
+		assert.NoError(t, err)
+		assert.Contains(t, string(msg), "maximum size")
+		assert.NotContains(t, string(msg), "mock output")
+
+		var recordings []models.ExecRecording
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+			recordings, _ = store.List(context.Background(), recording.Query{})
+			if len(recordings) == 1 && recordings[0].EndedAt != nil {
+				break
+			}
+		}
+		if assert.Len(t, recordings, 1) {
+			assert.True(t, recordings[0].Truncated)
+		}
+	})
+	t.Run("ExecIntoPod Recording Unavailable", func(t *testing.T) {
+		t.Setenv("EXEC_RECORDING_STORE", "s3")
+		wsURL := newRecordingExecServer(t, recording.NewServiceFromEnv())
+
+		ws, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer ws.Close()
+
+		_, msg, err := ws.ReadMessage()
+		assert.NoError(t, err)
+		assert.Contains(t, string(msg), "cannot be recorded")
+	})
 	// Test Allowed Origins via Env Var
 	t.Run("ExecIntoPod Allowed Origins", func(t *testing.T) {
 		t.Setenv("ALLOWED_ORIGINS", "https://trusted.com,https://another.com")
@@ -270,6 +371,35 @@ func TestService_ExecIntoPod_Mocked(t *testing.T) {
 	})
 }
 
+// newRecordingExecServer serves ExecIntoPod for an admin with the given recording service and
+// returns the WebSocket URL of an exec session
+func newRecordingExecServer(t *testing.T, recordings *recording.Service) string {
+	t.Helper()
+	mockClusterService := new(MockClusterService)
+	mockExecService := new(MockExecService)
+	service := &Service{
+		clusterService: mockClusterService,
+		execFactory:    func() execCreator { return mockExecService },
+		handlers:       &models.Handlers{},
+		recordings:     recordings,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), auth.UserContextKey(), &auth.AuthClaims{
+			Claims: models.Claims{Username: "alice", Role: "admin"},
+		})
+		service.ExecIntoPod(w, r.WithContext(ctx))
+	}))
+	t.Cleanup(s.Close)
+
+	mockClusterService.On("GetClient", mock.Anything).Return(nil, nil)
+	mockClusterService.On("GetRESTConfig", mock.Anything).Return(&rest.Config{}, nil)
+	mockExecutor := new(MockExecutor)
+	mockExecutor.On("StreamWithContext", mock.Anything, mock.Anything).Return(nil)
+	mockExecService.On("CreateExecutor", mock.Anything, mock.Anything, mock.Anything).Return(mockExecutor, "ws://mock", nil)
+
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "?namespace=default&pod=pod-1&container=c1"
+}
+
 func TestSetWebSocketCORSHeaders(t *testing.T) {
 	req := httptest.NewRequest("GET", "/", nil)
 	req.Header.Set("Origin", "http://example.com")
@@ -1,21 +1,42 @@
 package pod
 
 import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
+// logDownloadTimeout bounds the time spent reading and sending the logs of one download
+const logDownloadTimeout = 5 * time.Minute
+
 // StreamPodLogs handles HTTP GET requests to stream logs from a Kubernetes pod.
 // Query parameters:
 //   - namespace: The namespace containing the pod
 //   - pod: The pod name
 //   - container: Optional container name (if pod has multiple containers)
+//   - previous, timestamps, sinceSeconds, sinceTime, tailLines, limitBytes: Optional log
+//     options, see parseLogOptions
+//   - include, exclude, ignoreCase, minLevel, field: Optional server-side filters, see parseLogFilter
+//   - format: "text" (default) or "json" for newline-delimited LogLine objects carrying the
+//     detected level and the spans matching include
+//   - parse: "json" to also split JSON lines into timestamp, level, message and fields;
+//     implies format=json, and other lines are passed through unchanged
 //
 // Returns a streaming text/plain response with pod logs. The connection remains open
-// and logs are streamed in real-time as they are generated.
+// and logs are streamed in real-time as they are generated. Logs of the previous
+// container instance are complete, so they are returned without following.
 func (s *Service) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
 	// Parse and validate HTTP parameters
 	params, err := utils.ParsePodParams(r)
@@ -23,6 +44,33 @@ func (s *Service) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
 		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	streamReq := StreamLogsRequest{
+		Namespace: params.Namespace,
+		PodName:   params.PodName,
+		Container: params.Container,
+	}
+	if err := parseLogOptions(r, &streamReq); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	streamReq.Follow = !streamReq.Previous
+	filter, err := parseLogFilter(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "json" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid format (use text or json)")
+		return
+	}
+	if filter.parse {
+		if format == "text" {
+			utils.ErrorResponse(w, http.StatusBadRequest, "parse=json requires format=json")
+			return
+		}
+		format = "json"
+	}
 
 	// Validate namespace access
 	ctx := r.Context()
@@ -56,28 +104,20 @@ func (s *Service) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := utils.CreateTimeoutContext()
 	defer cancel()
 
-	// Prepare request
-	streamReq := StreamLogsRequest{
-		Namespace: params.Namespace,
-		PodName:   params.PodName,
-		Container: params.Container,
-		Follow:    true,
-	}
-
 	// Call service to get log stream (business logic layer)
 	stream, err := logService.StreamLogs(ctx, streamReq)
 	if err != nil {
-		utils.HandleErrorJSON(w, err, "Failed to open log stream", http.StatusInternalServerError, map[string]interface{}{
-			"namespace": params.Namespace,
-			"pod":       params.PodName,
-			"container": params.Container,
-		})
+		writeLogStreamError(w, err, streamReq)
 		return
 	}
 	defer stream.Close()
 
 	// Set HTTP headers for streaming
-	w.Header().Set("Content-Type", "text/plain")
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/plain")
+	}
 	w.Header().Set("Transfer-Encoding", "chunked")
 	w.Header().Set("Connection", "keep-alive")
 
@@ -88,6 +128,24 @@ func (s *Service) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Filtered and structured output is produced line by line
+	if filter.Active() || format == "json" {
+		_ = logService.FilterLogStream(stream, filter, func(line LogLine) error {
+			var out []byte
+			if format == "json" {
+				out, _ = json.Marshal(line)
+			} else {
+				out = []byte(line.Line)
+			}
+			if _, err := w.Write(append(out, '\n')); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		})
+		return
+	}
+
 	// Stream logs (HTTP layer - streaming loop remains here for efficiency)
 	buf := make([]byte, 1024)
 	for {
@@ -103,3 +161,346 @@ func (s *Service) StreamPodLogs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// DownloadPodLogs handles HTTP GET requests to download the logs of a pod container as a
+// gzip-compressed attachment.
+// It takes the pod and log options of StreamPodLogs; the logs are never followed, so the
+// download contains the logs available when the request was made.
+func (s *Service) DownloadPodLogs(w http.ResponseWriter, r *http.Request) {
+	params, err := utils.ParsePodParams(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	downloadReq := StreamLogsRequest{
+		Namespace: params.Namespace,
+		PodName:   params.PodName,
+		Container: params.Container,
+	}
+	if err := parseLogOptions(r, &downloadReq); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Validate namespace access
+	hasAccess, err := permissions.HasNamespaceAccess(r.Context(), params.Namespace)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to check permissions", http.StatusInternalServerError, map[string]interface{}{
+			"namespace": params.Namespace,
+			"action":    "view",
+		})
+		return
+	}
+	if !hasAccess {
+		utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Access denied to namespace: %s", params.Namespace))
+		return
+	}
+
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Large logs take longer to transfer than the server write timeout allows
+	ctx, cancel := context.WithTimeout(r.Context(), logDownloadTimeout)
+	defer cancel()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(logDownloadTimeout))
+
+	stream, err := NewLogService(s.logRepoFactory(client)).StreamLogs(ctx, downloadReq)
+	if err != nil {
+		writeLogStreamError(w, err, downloadReq)
+		return
+	}
+	defer stream.Close()
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", logDownloadFileName(downloadReq, time.Now())))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are sent; a failure now can only cut the download short
+	gz := gzip.NewWriter(w)
+	if _, err := io.Copy(gz, stream); err != nil {
+		utils.LogError(err, "Failed to download pod logs", map[string]interface{}{
+			"namespace": params.Namespace,
+			"pod":       params.PodName,
+			"container": params.Container,
+		})
+		return
+	}
+	_ = gz.Close()
+}
+
+// logDownloadFileName names a log download after the pod, container and time, e.g. web-1-app-previous-20240501-123000.log.gz
+func logDownloadFileName(req StreamLogsRequest, at time.Time) string {
+	name := req.PodName
+	if req.Container != "" {
+		name += "-" + req.Container
+	}
+	if req.Previous {
+		name += "-previous"
+	}
+	return fmt.Sprintf("%s-%s.log.gz", name, at.UTC().Format("20060102-150405"))
+}
+
+// parseLogOptions reads the optional log options of a logs request:
+//   - previous: "true" for the logs of the previous, terminated container instance
+//   - timestamps: "true" to prefix each line with its RFC3339 timestamp
+//   - sinceSeconds: only logs newer than this many seconds
+//   - sinceTime: only logs after this RFC3339 time; cannot be combined with sinceSeconds
+//   - tailLines: only this many lines from the end
+//   - limitBytes: stop after this many bytes
+func parseLogOptions(r *http.Request, req *StreamLogsRequest) error {
+	query := r.URL.Query()
+
+	for param, target := range map[string]*bool{"previous": &req.Previous, "timestamps": &req.Timestamps} {
+		if value := query.Get(param); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", param, value)
+			}
+			*target = b
+		}
+	}
+	for param, target := range map[string]**int64{"sinceSeconds": &req.SinceSeconds, "tailLines": &req.TailLines, "limitBytes": &req.LimitBytes} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %s", param, value)
+			}
+			*target = &n
+		}
+	}
+	if value := query.Get("sinceTime"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid sinceTime (use RFC3339): %s", value)
+		}
+		req.SinceTime = &t
+	}
+
+	_, err := req.podLogOptions()
+	return err
+}
+
+// parseLogFilter reads the optional filters of a logs request:
+//   - include: only lines matching this regular expression (RE2 syntax)
+//   - exclude: drop lines matching this regular expression
+//   - ignoreCase: "true" to match include and exclude case-insensitively
+//   - minLevel: only lines of this severity or above (trace, debug, info, warn, error, fatal)
+//   - field: key=value, e.g. trace_id=abc; only JSON lines with this field value. May be repeated,
+//     and a dotted key such as http.status looks into nested objects
+//   - parse: "json" to split JSON lines into their parts
+func parseLogFilter(r *http.Request) (*LogFilter, error) {
+	query := r.URL.Query()
+	opts := LogFilterOptions{
+		Include:  query.Get("include"),
+		Exclude:  query.Get("exclude"),
+		MinLevel: query.Get("minLevel"),
+	}
+	switch parse := query.Get("parse"); parse {
+	case "":
+	case "json":
+		opts.Parse = true
+	default:
+		return nil, fmt.Errorf("invalid parse mode: %s (use json)", parse)
+	}
+	for _, value := range query["field"] {
+		filter, err := ParseLogFieldFilter(value)
+		if err != nil {
+			return nil, err
+		}
+		opts.Fields = append(opts.Fields, filter)
+	}
+	if value := query.Get("ignoreCase"); value != "" {
+		ignoreCase, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ignoreCase: %s", value)
+		}
+		opts.IgnoreCase = ignoreCase
+	}
+	return NewLogFilter(opts)
+}
+
+// writeLogStreamError responds to a log stream that could not be opened, keeping the
+// status of errors caused by the request, such as a missing pod or no previous container
+func writeLogStreamError(w http.ResponseWriter, err error, req StreamLogsRequest) {
+	switch {
+	case errors.Is(err, ErrInvalidLogOptions), apierrors.IsBadRequest(err):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case apierrors.IsNotFound(err):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, "Failed to open log stream", http.StatusInternalServerError, map[string]interface{}{
+			"namespace": req.Namespace,
+			"pod":       req.PodName,
+			"container": req.Container,
+		})
+	}
+}
+
+// StreamAggregatedLogs handles HTTP GET requests to follow the logs of every pod of a workload
+// or label selector in one stream, like stern.
+// Query parameters:
+//   - namespace: The namespace containing the pods
+//   - selector: A label selector, or
+//   - kind and name: A Deployment, StatefulSet, DaemonSet, ReplicaSet or Job whose pods are followed
+//   - container: Optional container name; by default every container is followed
+//   - tailLines, sinceSeconds: Optional history to include per container
+//   - format: "text" (default) or "json" for newline-delimited LogFrame objects
+//
+// In text format each line is prefixed with [pod/container] and its timestamp. Pods that start
+// matching are picked up and deleted pods are dropped while the stream is open.
+func (s *Service) StreamAggregatedLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	req := AggregateLogsRequest{
+		Namespace:     query.Get("namespace"),
+		LabelSelector: query.Get("selector"),
+		Container:     query.Get("container"),
+	}
+	kind, name := query.Get("kind"), query.Get("name")
+
+	if err := utils.ValidateNamespace(req.Namespace); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if (req.LabelSelector == "") == (kind == "" && name == "") {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Specify either selector or kind and name")
+		return
+	}
+	if req.LabelSelector != "" {
+		if _, err := labels.Parse(req.LabelSelector); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid selector: %v", err))
+			return
+		}
+	} else if err := utils.ValidateK8sName(name, "name"); err != nil || kind == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid or missing kind and name")
+		return
+	}
+	if req.Container != "" {
+		if err := utils.ValidateContainerName(req.Container); err != nil {
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	for param, target := range map[string]**int64{"tailLines": &req.TailLines, "sinceSeconds": &req.SinceSeconds} {
+		if value := query.Get(param); value != "" {
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 {
+				utils.ErrorResponse(w, http.StatusBadRequest, fmt.Sprintf("Invalid %s: %s", param, value))
+				return
+			}
+			*target = &n
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "text" && format != "json" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "Invalid format (use text or json)")
+		return
+	}
+
+	// Validate namespace access
+	hasAccess, err := permissions.HasNamespaceAccess(r.Context(), req.Namespace)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to check permissions", http.StatusInternalServerError, map[string]interface{}{
+			"namespace": req.Namespace,
+			"action":    "view",
+		})
+		return
+	}
+	if !hasAccess {
+		utils.ErrorResponse(w, http.StatusForbidden, fmt.Sprintf("Access denied to namespace: %s", req.Namespace))
+		return
+	}
+
+	client, err := s.clusterService.GetClient(r)
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	podRepo := s.podRepoFactory(client)
+
+	if req.LabelSelector == "" {
+		lookupCtx, cancel := utils.CreateRequestContext(r)
+		req.LabelSelector, err = podRepo.GetWorkloadSelector(lookupCtx, req.Namespace, kind, name)
+		cancel()
+		switch {
+		case errors.Is(err, ErrUnsupportedWorkload):
+			utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		case apierrors.IsNotFound(err):
+			utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+			return
+		case err != nil:
+			utils.HandleErrorJSON(w, err, "Failed to resolve workload pods", http.StatusInternalServerError, map[string]interface{}{
+				"namespace": req.Namespace,
+				"kind":      kind,
+				"name":      name,
+			})
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.ErrorResponse(w, http.StatusInternalServerError, "Streaming not supported")
+		return
+	}
+
+	// The stream lives as long as the client stays connected, beyond the server write timeout
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	} else {
+		w.Header().Set("Content-Type", "text/plain")
+	}
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	write := func(frame LogFrame) {
+		var out []byte
+		if format == "json" {
+			out, _ = json.Marshal(frame)
+			out = append(out, '\n')
+		} else {
+			out = []byte(formatLogFrame(frame))
+		}
+		if _, err := w.Write(out); err != nil {
+			cancel()
+			return
+		}
+		flusher.Flush()
+	}
+
+	aggregator := NewLogAggregator(s.logRepoFactory(client), podRepo)
+	if err := aggregator.Stream(ctx, req, write); err != nil {
+		write(LogFrame{Type: LogFrameError, Timestamp: time.Now().UTC(), Message: err.Error()})
+	}
+}
+
+// formatLogFrame renders a frame as a line of the text stream
+func formatLogFrame(frame LogFrame) string {
+	switch frame.Type {
+	case LogFrameLine:
+		return fmt.Sprintf("[%s/%s] %s %s\n", frame.Pod, frame.Container, frame.Timestamp.Format(time.RFC3339Nano), frame.Message)
+	case LogFrameAdded:
+		return fmt.Sprintf("[%s] + pod added\n", frame.Pod)
+	case LogFrameRemoved:
+		return fmt.Sprintf("[%s] - pod removed\n", frame.Pod)
+	default:
+		source := frame.Pod
+		if frame.Container != "" {
+			source += "/" + frame.Container
+		}
+		if source == "" {
+			source = "stream"
+		}
+		return fmt.Sprintf("[%s] ! %s\n", source, frame.Message)
+	}
+}
@@ -0,0 +1,348 @@
+package pod
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const (
+	// maxAggregatedContainers bounds the number of containers one aggregated stream follows
+	maxAggregatedContainers = 64
+	// maxLogLineSize is the longest log line read; longer lines end the container's stream
+	maxLogLineSize = 1 << 20
+	// watchRetryDelay is the minimum time between two pod watches of one stream
+	watchRetryDelay = time.Second
+)
+
+// Types of the frames of an aggregated log stream
+const (
+	LogFrameLine    = "line"    // a log line of a container
+	LogFrameAdded   = "added"   // a matching pod appeared
+	LogFrameRemoved = "removed" // a matching pod was deleted or no longer matches
+	LogFrameError   = "error"   // a container could not be followed
+)
+
+// LogFrame is one item of an aggregated log stream
+type LogFrame struct {
+	Type      string    `json:"type"`
+	Pod       string    `json:"pod"`
+	Container string    `json:"container,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message,omitempty"`
+}
+
+// AggregateLogsRequest represents the parameters for following the logs of every pod matching a selector.
+type AggregateLogsRequest struct {
+	Namespace     string
+	LabelSelector string
+	Container     string // only follow containers with this name; empty follows every container
+	TailLines     *int64 // lines of history per container when the stream starts
+	SinceSeconds  *int64 // history window per container when the stream starts
+}
+
+// LogAggregator follows the logs of many pods at once, like stern: lines from every matching
+// pod and container are interleaved, and pods are picked up or dropped as they come and go.
+type LogAggregator struct {
+	logRepo LogRepository
+	podRepo PodWatchRepository
+}
+
+// NewLogAggregator creates a new LogAggregator with the provided repositories.
+func NewLogAggregator(logRepo LogRepository, podRepo PodWatchRepository) *LogAggregator {
+	return &LogAggregator{logRepo: logRepo, podRepo: podRepo}
+}
+
+// Stream follows the containers of every pod matching the request and passes each frame to emit,
+// which is never called concurrently. It returns nil when ctx is done, or an error if the pods
+// cannot be listed or watched.
+func (a *LogAggregator) Stream(ctx context.Context, req AggregateLogsRequest, emit func(LogFrame)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	agg := &aggregation{
+		ctx:     ctx,
+		logRepo: a.logRepo,
+		req:     req,
+		emit:    emit,
+		pods:    make(map[string]*podFollow),
+	}
+	defer func() {
+		cancel()
+		agg.wg.Wait()
+	}()
+
+	resourceVersion, err := a.resync(ctx, agg)
+	if err != nil {
+		return err
+	}
+
+	for {
+		w, err := a.podRepo.WatchPods(ctx, req.Namespace, req.LabelSelector, resourceVersion)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		started := time.Now()
+		var expired bool
+		resourceVersion, expired = agg.consume(w, resourceVersion)
+		w.Stop()
+		// Avoid hammering the API server when watches keep ending right away
+		if time.Since(started) < watchRetryDelay {
+			select {
+			case <-ctx.Done():
+			case <-time.After(watchRetryDelay):
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		// A watch that fell too far behind must start over from a fresh list
+		if expired {
+			if resourceVersion, err = a.resync(ctx, agg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resync lists the matching pods, follows new ones and drops the ones that are gone
+func (a *LogAggregator) resync(ctx context.Context, agg *aggregation) (string, error) {
+	pods, err := a.podRepo.ListPods(ctx, agg.req.Namespace, agg.req.LabelSelector)
+	if err != nil {
+		return "", err
+	}
+	listed := make(map[string]bool, len(pods.Items))
+	for i := range pods.Items {
+		listed[pods.Items[i].Name] = true
+		agg.update(&pods.Items[i])
+	}
+	for _, name := range agg.podNames() {
+		if !listed[name] {
+			agg.remove(name)
+		}
+	}
+	return pods.ResourceVersion, nil
+}
+
+// aggregation is the state of one aggregated stream
+type aggregation struct {
+	ctx     context.Context
+	logRepo LogRepository
+	req     AggregateLogsRequest
+
+	emitMu sync.Mutex
+	emit   func(LogFrame)
+
+	mu        sync.Mutex
+	pods      map[string]*podFollow
+	followed  int
+	limitSeen bool
+	wg        sync.WaitGroup
+}
+
+// podFollow tracks the followed containers of one pod
+type podFollow struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	pod        *corev1.Pod // latest version seen
+	containers map[string]*containerFollow
+}
+
+// containerFollow tracks the log stream of one container
+type containerFollow struct {
+	active   bool
+	restarts int32     // restart count when the stream was opened
+	last     time.Time // timestamp of the last line read
+}
+
+func (g *aggregation) send(frame LogFrame) {
+	if frame.Timestamp.IsZero() {
+		frame.Timestamp = time.Now().UTC()
+	}
+	g.emitMu.Lock()
+	defer g.emitMu.Unlock()
+	if g.ctx.Err() == nil {
+		g.emit(frame)
+	}
+}
+
+// consume applies watch events until the watch ends, returning the last resource version seen
+// and whether the watch failed and must be restarted from a fresh list
+func (g *aggregation) consume(w watch.Interface, resourceVersion string) (string, bool) {
+	for {
+		select {
+		case <-g.ctx.Done():
+			return resourceVersion, false
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return resourceVersion, false
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					resourceVersion = pod.ResourceVersion
+					g.update(pod)
+				}
+			case watch.Deleted:
+				if pod, ok := event.Object.(*corev1.Pod); ok {
+					resourceVersion = pod.ResourceVersion
+					g.remove(pod.Name)
+				}
+			case watch.Error:
+				// Typically 410 Gone: the resource version is too old to resume from
+				return resourceVersion, true
+			}
+		}
+	}
+}
+
+func (g *aggregation) podNames() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	names := make([]string, 0, len(g.pods))
+	for name := range g.pods {
+		names = append(names, name)
+	}
+	return names
+}
+
+// update records the latest version of a pod and opens streams for containers that started or restarted
+func (g *aggregation) update(pod *corev1.Pod) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pf, ok := g.pods[pod.Name]
+	if !ok {
+		ctx, cancel := context.WithCancel(g.ctx)
+		pf = &podFollow{ctx: ctx, cancel: cancel, containers: make(map[string]*containerFollow)}
+		g.pods[pod.Name] = pf
+		g.send(LogFrame{Type: LogFrameAdded, Pod: pod.Name})
+	}
+	pf.pod = pod
+	g.attachLocked(pf)
+}
+
+// attachLocked opens a stream for every container of the pod that has logs to read and is not followed yet
+func (g *aggregation) attachLocked(pf *podFollow) {
+	statuses := make(map[string]corev1.ContainerStatus, len(pf.pod.Status.ContainerStatuses))
+	for _, status := range pf.pod.Status.ContainerStatuses {
+		statuses[status.Name] = status
+	}
+
+	for _, container := range pf.pod.Spec.Containers {
+		if g.req.Container != "" && container.Name != g.req.Container {
+			continue
+		}
+		status, ok := statuses[container.Name]
+		if !ok || (status.State.Running == nil && status.State.Terminated == nil) {
+			continue // not started yet; a later update attaches it
+		}
+
+		cf, known := pf.containers[container.Name]
+		switch {
+		case !known:
+			if g.followed >= maxAggregatedContainers {
+				if !g.limitSeen {
+					g.limitSeen = true
+					g.send(LogFrame{Type: LogFrameError, Pod: pf.pod.Name, Container: container.Name,
+						Message: fmt.Sprintf("not followed: the stream follows at most %d containers", maxAggregatedContainers)})
+				}
+				continue
+			}
+			g.followed++
+			cf = &containerFollow{}
+			pf.containers[container.Name] = cf
+		case cf.active || status.RestartCount == cf.restarts || status.State.Running == nil:
+			continue // already followed, or ended and not restarted since
+		}
+
+		cf.active, cf.restarts = true, status.RestartCount
+		g.wg.Add(1)
+		go g.follow(pf, container.Name, cf, !known)
+	}
+}
+
+// remove stops following a pod
+func (g *aggregation) remove(name string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	pf, ok := g.pods[name]
+	if !ok {
+		return
+	}
+	pf.cancel()
+	g.followed -= len(pf.containers)
+	delete(g.pods, name)
+	g.send(LogFrame{Type: LogFrameRemoved, Pod: name})
+}
+
+// follow streams the logs of one container until it exits or the pod is dropped
+func (g *aggregation) follow(pf *podFollow, container string, cf *containerFollow, first bool) {
+	defer g.wg.Done()
+
+	g.mu.Lock()
+	podName, since := pf.pod.Name, cf.last
+	g.mu.Unlock()
+
+	opts := &corev1.PodLogOptions{Container: container, Follow: true, Timestamps: true}
+	if first {
+		opts.TailLines, opts.SinceSeconds = g.req.TailLines, g.req.SinceSeconds
+	} else if !since.IsZero() {
+		opts.SinceTime = &metav1.Time{Time: since}
+	}
+
+	stream, err := g.logRepo.GetLogStream(pf.ctx, g.req.Namespace, podName, opts)
+	if err == nil {
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+		for scanner.Scan() {
+			timestamp, message := splitLogTimestamp(scanner.Text())
+			// SinceTime has second precision; skip lines already sent before a restart
+			if !timestamp.IsZero() {
+				if !since.IsZero() && !timestamp.After(since) {
+					continue
+				}
+				g.mu.Lock()
+				cf.last = timestamp
+				g.mu.Unlock()
+			}
+			g.send(LogFrame{Type: LogFrameLine, Pod: podName, Container: container, Timestamp: timestamp, Message: message})
+		}
+		err = scanner.Err()
+		stream.Close()
+	}
+	if err != nil && pf.ctx.Err() == nil {
+		g.send(LogFrame{Type: LogFrameError, Pod: podName, Container: container, Message: err.Error()})
+	}
+
+	// The container exited; if it already restarted, follow the new instance
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	cf.active = false
+	if pf.ctx.Err() == nil {
+		g.attachLocked(pf)
+	}
+}
+
+// splitLogTimestamp separates the RFC3339 timestamp the kubelet prefixes to each line when
+// timestamps are requested; lines without one are returned unchanged
+func splitLogTimestamp(line string) (time.Time, string) {
+	prefix, rest, found := strings.Cut(line, " ")
+	if !found {
+		prefix, rest = line, ""
+	}
+	timestamp, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, line
+	}
+	return timestamp.UTC(), rest
+}
@@ -0,0 +1,193 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// scriptedLogRepo serves a scripted log stream per pod/container and records the options of every call
+type scriptedLogRepo struct {
+	mu      sync.Mutex
+	streams map[string][]string // pod/container -> content of successive streams
+	calls   map[string][]*corev1.PodLogOptions
+}
+
+func (r *scriptedLogRepo) GetLogStream(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := podName + "/" + opts.Container
+	if r.calls == nil {
+		r.calls = make(map[string][]*corev1.PodLogOptions)
+	}
+	r.calls[key] = append(r.calls[key], opts)
+	if len(r.streams[key]) == 0 {
+		return nil, errors.New("container not found")
+	}
+	content := r.streams[key][0]
+	r.streams[key] = r.streams[key][1:]
+	return io.NopCloser(strings.NewReader(content)), nil
+}
+
+func (r *scriptedLogRepo) callsFor(key string) []*corev1.PodLogOptions {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls[key]
+}
+
+// fakePodWatchRepo lists a fixed set of pods and hands out a fake watch
+type fakePodWatchRepo struct {
+	pods    []corev1.Pod
+	watcher *watch.FakeWatcher
+}
+
+func (r *fakePodWatchRepo) ListPods(ctx context.Context, namespace, labelSelector string) (*corev1.PodList, error) {
+	return &corev1.PodList{ListMeta: metav1.ListMeta{ResourceVersion: "10"}, Items: r.pods}, nil
+}
+
+func (r *fakePodWatchRepo) WatchPods(ctx context.Context, namespace, labelSelector, resourceVersion string) (watch.Interface, error) {
+	return r.watcher, nil
+}
+
+func (r *fakePodWatchRepo) GetWorkloadSelector(ctx context.Context, namespace, kind, name string) (string, error) {
+	return "app=web", nil
+}
+
+func runningPod(name string, restarts int32, containers ...string) corev1.Pod {
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"}}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: c})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name: c, RestartCount: restarts, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+// collectFrames runs an aggregated stream in the background and returns a function waiting for the next frame
+func collectFrames(t *testing.T, aggregator *LogAggregator, req AggregateLogsRequest) (func() LogFrame, context.CancelFunc, chan error) {
+	t.Helper()
+	frames := make(chan LogFrame, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- aggregator.Stream(ctx, req, func(frame LogFrame) { frames <- frame })
+	}()
+	next := func() LogFrame {
+		t.Helper()
+		select {
+		case frame := <-frames:
+			return frame
+		case <-time.After(2 * time.Second):
+			t.Fatal("no frame received")
+			return LogFrame{}
+		}
+	}
+	return next, cancel, done
+}
+
+func TestLogAggregator_Stream(t *testing.T) {
+	logs := &scriptedLogRepo{streams: map[string][]string{
+		"web-1/app":   {"2024-01-01T00:00:01Z started\n2024-01-01T00:00:02Z ready\n"},
+		"web-1/proxy": {"2024-01-01T00:00:01Z proxy up\n"},
+		"web-2/app":   {"2024-01-01T00:00:03Z started\n"},
+	}}
+	pods := &fakePodWatchRepo{pods: []corev1.Pod{runningPod("web-1", 0, "app", "proxy")}, watcher: watch.NewFakeWithChanSize(10, false)}
+	tail := int64(50)
+
+	next, cancel, done := collectFrames(t, NewLogAggregator(logs, pods), AggregateLogsRequest{
+		Namespace: "shop", LabelSelector: "app=web", Container: "app", TailLines: &tail,
+	})
+
+	if frame := next(); frame.Type != LogFrameAdded || frame.Pod != "web-1" {
+		t.Fatalf("first frame = %+v", frame)
+	}
+	for _, want := range []string{"started", "ready"} {
+		frame := next()
+		if frame.Type != LogFrameLine || frame.Pod != "web-1" || frame.Container != "app" || frame.Message != want {
+			t.Fatalf("frame = %+v, want line %q", frame, want)
+		}
+		if frame.Timestamp.IsZero() {
+			t.Errorf("timestamp not parsed: %+v", frame)
+		}
+	}
+	opts := logs.callsFor("web-1/app")[0]
+	if !opts.Follow || !opts.Timestamps || opts.TailLines == nil || *opts.TailLines != 50 {
+		t.Errorf("log options = %+v", opts)
+	}
+	if len(logs.callsFor("web-1/proxy")) != 0 {
+		t.Error("container filter ignored")
+	}
+
+	// A pod starting to match is picked up, a deleted one is dropped
+	pending := runningPod("web-2", 0, "app")
+	pending.Status.ContainerStatuses[0].State = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}}
+	pods.watcher.Add(&pending)
+	if frame := next(); frame.Type != LogFrameAdded || frame.Pod != "web-2" {
+		t.Fatalf("frame = %+v, want web-2 added", frame)
+	}
+	started := runningPod("web-2", 0, "app")
+	pods.watcher.Modify(&started)
+	if frame := next(); frame.Pod != "web-2" || frame.Message != "started" {
+		t.Fatalf("frame = %+v, want web-2 line", frame)
+	}
+	pods.watcher.Delete(&started)
+	if frame := next(); frame.Type != LogFrameRemoved || frame.Pod != "web-2" {
+		t.Fatalf("frame = %+v, want web-2 removed", frame)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Stream() error = %v", err)
+	}
+}
+
+func TestLogAggregator_Stream_Restart(t *testing.T) {
+	logs := &scriptedLogRepo{streams: map[string][]string{
+		"web-1/app": {
+			"2024-01-01T00:00:01Z first\n",
+			// SinceTime has second precision, so the new stream repeats the last line
+			"2024-01-01T00:00:01Z first\n2024-01-01T00:00:05Z second\n",
+		},
+	}}
+	pods := &fakePodWatchRepo{pods: []corev1.Pod{runningPod("web-1", 0, "app")}, watcher: watch.NewFakeWithChanSize(10, false)}
+
+	next, cancel, done := collectFrames(t, NewLogAggregator(logs, pods), AggregateLogsRequest{Namespace: "shop", LabelSelector: "app=web"})
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	next() // added
+	if frame := next(); frame.Message != "first" {
+		t.Fatalf("frame = %+v", frame)
+	}
+
+	restarted := runningPod("web-1", 1, "app")
+	pods.watcher.Modify(&restarted)
+	if frame := next(); frame.Message != "second" {
+		t.Fatalf("frame = %+v, want the line after the restart only", frame)
+	}
+	calls := logs.callsFor("web-1/app")
+	if len(calls) != 2 || calls[1].SinceTime == nil || !calls[1].SinceTime.Time.Equal(time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)) {
+		t.Errorf("reattach options = %+v", calls[len(calls)-1])
+	}
+}
+
+func TestSplitLogTimestamp(t *testing.T) {
+	ts, msg := splitLogTimestamp("2024-01-01T00:00:01.123456789Z GET /healthz 200")
+	if msg != "GET /healthz 200" || ts.Nanosecond() != 123456789 {
+		t.Errorf("splitLogTimestamp() = %v, %q", ts, msg)
+	}
+	if ts, msg := splitLogTimestamp("plain line"); !ts.IsZero() || msg != "plain line" {
+		t.Errorf("splitLogTimestamp() = %v, %q", ts, msg)
+	}
+}
@@ -0,0 +1,286 @@
+package pod
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// maxLogPatternLength bounds the length of include and exclude patterns
+	maxLogPatternLength = 1024
+	// maxHighlights bounds the number of highlighted spans reported for one line
+	maxHighlights = 64
+	// levelSearchPrefix is how much of a text line is searched for a severity keyword
+	levelSearchPrefix = 256
+	// levelSearchFields is how many leading words of a text line may hold a severity keyword
+	levelSearchFields = 6
+)
+
+// LogLevel is the severity of a log line, ordered from least to most severe
+type LogLevel int
+
+// Severity levels detected in log lines
+const (
+	LogLevelUnknown LogLevel = iota
+	LogLevelTrace
+	LogLevelDebug
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+	LogLevelFatal
+)
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelTrace: "trace",
+	LogLevelDebug: "debug",
+	LogLevelInfo:  "info",
+	LogLevelWarn:  "warn",
+	LogLevelError: "error",
+	LogLevelFatal: "fatal",
+}
+
+// logLevelAliases maps the spellings used by common logging libraries to a level
+var logLevelAliases = map[string]LogLevel{
+	"trace":    LogLevelTrace,
+	"debug":    LogLevelDebug,
+	"dbg":      LogLevelDebug,
+	"info":     LogLevelInfo,
+	"notice":   LogLevelInfo,
+	"warn":     LogLevelWarn,
+	"warning":  LogLevelWarn,
+	"error":    LogLevelError,
+	"err":      LogLevelError,
+	"fatal":    LogLevelFatal,
+	"panic":    LogLevelFatal,
+	"crit":     LogLevelFatal,
+	"critical": LogLevelFatal,
+	"alert":    LogLevelFatal,
+	"emerg":    LogLevelFatal,
+}
+
+var (
+	// logfmtLevelPattern matches level=warn style fields
+	logfmtLevelPattern = regexp.MustCompile(`(?i)\b(?:level|lvl|severity)=["']?([a-z]+)`)
+	// klogLevelPattern matches the Kubernetes klog header, e.g. "E0501 12:00:00.000000"
+	klogLevelPattern = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}`)
+	klogLevels       = map[string]LogLevel{"I": LogLevelInfo, "W": LogLevelWarn, "E": LogLevelError, "F": LogLevelFatal}
+)
+
+// String returns the name of the level, or an empty string for LogLevelUnknown
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+// ParseLogLevel parses a level name such as "warn", "WARNING" or "err"
+func ParseLogLevel(name string) (LogLevel, error) {
+	if level, ok := logLevelAliases[strings.ToLower(name)]; ok {
+		return level, nil
+	}
+	return LogLevelUnknown, fmt.Errorf("%w: unknown level %q (use trace, debug, info, warn, error or fatal)", ErrInvalidLogOptions, name)
+}
+
+// DetectLogLevel finds the severity of a log line. It understands JSON lines with a level,
+// severity or lvl field, logfmt level= fields, klog headers and upper-case keywords such as
+// ERROR or [WARN] among the first words of the line.
+func DetectLogLevel(line string) LogLevel {
+	if fields, ok := parseJSONLogLine(line); ok {
+		return jsonLogLevel(fields)
+	}
+	return textLogLevel(line)
+}
+
+// textLogLevel finds the severity of an unstructured log line
+func textLogLevel(line string) LogLevel {
+	trimmed := strings.TrimSpace(line)
+	if len(trimmed) > levelSearchPrefix {
+		trimmed = trimmed[:levelSearchPrefix]
+	}
+	if m := klogLevelPattern.FindStringSubmatch(trimmed); m != nil {
+		return klogLevels[m[1]]
+	}
+	if m := logfmtLevelPattern.FindStringSubmatch(trimmed); m != nil {
+		if level, err := ParseLogLevel(m[1]); err == nil {
+			return level
+		}
+	}
+	for i, field := range strings.Fields(trimmed) {
+		if i == levelSearchFields {
+			break
+		}
+		// Only upper-case keywords, so prose such as "retrying after error" is not a level
+		word := strings.Trim(field, "[]()<>:|,")
+		if word != strings.ToUpper(word) {
+			continue
+		}
+		if level, err := ParseLogLevel(word); err == nil {
+			return level
+		}
+	}
+	return LogLevelUnknown
+}
+
+// jsonLogLevel reads the level of a structured log line, either a name or a pino/bunyan number
+func jsonLogLevel(fields map[string]interface{}) LogLevel {
+	for _, key := range logLevelKeys {
+		switch value := fields[key].(type) {
+		case string:
+			if level, err := ParseLogLevel(value); err == nil {
+				return level
+			}
+		case json.Number:
+			switch value, err := value.Float64(); {
+			case err != nil:
+				continue
+			case value <= 10:
+				return LogLevelTrace
+			case value <= 20:
+				return LogLevelDebug
+			case value <= 30:
+				return LogLevelInfo
+			case value <= 40:
+				return LogLevelWarn
+			case value <= 50:
+				return LogLevelError
+			default:
+				return LogLevelFatal
+			}
+		}
+	}
+	return LogLevelUnknown
+}
+
+// LogSpan is a highlighted part of a log line, as byte offsets into the line
+type LogSpan struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// LogLine is one line of a filtered log stream. In parse mode, a JSON line is also split into
+// its timestamp, message and remaining fields, and Structured is set.
+type LogLine struct {
+	Line       string                 `json:"line"`
+	Level      string                 `json:"level,omitempty"`
+	Highlights []LogSpan              `json:"highlights,omitempty"` // matches of the include pattern
+	Structured bool                   `json:"structured,omitempty"`
+	Timestamp  *time.Time             `json:"timestamp,omitempty"`
+	Message    string                 `json:"message,omitempty"`
+	Fields     map[string]interface{} `json:"fields,omitempty"`
+}
+
+// LogFilterOptions represents the parameters for filtering a log stream.
+type LogFilterOptions struct {
+	Include    string // only keep lines matching this regular expression
+	Exclude    string // drop lines matching this regular expression
+	IgnoreCase bool   // match Include and Exclude case-insensitively
+	MinLevel   string // only keep lines of this severity or above, e.g. "warn"
+
+	Parse  bool             // split JSON lines into timestamp, level, message and fields
+	Fields []LogFieldFilter // only keep JSON lines having all these field values
+}
+
+// LogFilter selects the lines of one log stream. It keeps the level of the last line seen, so
+// it must not be shared between streams or used concurrently.
+type LogFilter struct {
+	include   *regexp.Regexp
+	exclude   *regexp.Regexp
+	minLevel  LogLevel
+	parse     bool
+	fields    []LogFieldFilter
+	lastLevel LogLevel
+}
+
+// NewLogFilter compiles the filter options. Errors wrap ErrInvalidLogOptions.
+func NewLogFilter(opts LogFilterOptions) (*LogFilter, error) {
+	if len(opts.Fields) > maxLogFieldFilters {
+		return nil, fmt.Errorf("%w: at most %d field filters", ErrInvalidLogOptions, maxLogFieldFilters)
+	}
+	f := &LogFilter{parse: opts.Parse, fields: opts.Fields}
+	var err error
+	if f.include, err = compileLogPattern("include", opts.Include, opts.IgnoreCase); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compileLogPattern("exclude", opts.Exclude, opts.IgnoreCase); err != nil {
+		return nil, err
+	}
+	if opts.MinLevel != "" {
+		if f.minLevel, err = ParseLogLevel(opts.MinLevel); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func compileLogPattern(param, pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	if len(pattern) > maxLogPatternLength {
+		return nil, fmt.Errorf("%w: %s pattern longer than %d characters", ErrInvalidLogOptions, param, maxLogPatternLength)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s pattern: %v", ErrInvalidLogOptions, param, err)
+	}
+	return re, nil
+}
+
+// Active reports whether the filter can drop lines
+func (f *LogFilter) Active() bool {
+	return f.include != nil || f.exclude != nil || f.minLevel != LogLevelUnknown || len(f.fields) > 0
+}
+
+// Apply reports whether a line passes the filter and returns it with its level and the spans
+// matching the include pattern. Lines without a detectable level, such as the lines of a stack
+// trace, take the level of the line before them. Field filters only match JSON lines; in parse
+// mode other lines are passed through unchanged.
+func (f *LogFilter) Apply(line string) (LogLine, bool) {
+	// Skip the timestamp the API server prefixes when timestamps are requested
+	timestamp, message := splitLogTimestamp(line)
+	fields, structured := parseJSONLogLine(message)
+	var level LogLevel
+	if structured {
+		level = jsonLogLevel(fields)
+	} else {
+		level = textLogLevel(message)
+	}
+	if level == LogLevelUnknown {
+		level = f.lastLevel
+	} else {
+		f.lastLevel = level
+	}
+
+	if f.minLevel != LogLevelUnknown && level < f.minLevel {
+		return LogLine{}, false
+	}
+	for _, filter := range f.fields {
+		if !structured || !filter.matches(fields) {
+			return LogLine{}, false
+		}
+	}
+	if f.exclude != nil && f.exclude.MatchString(line) {
+		return LogLine{}, false
+	}
+
+	result := LogLine{Line: line, Level: level.String()}
+	if f.include != nil {
+		matches := f.include.FindAllStringIndex(line, maxHighlights)
+		if matches == nil {
+			return LogLine{}, false
+		}
+		for _, m := range matches {
+			if m[1] > m[0] {
+				result.Highlights = append(result.Highlights, LogSpan{Start: m[0], End: m[1]})
+			}
+		}
+	}
+	if f.parse && structured {
+		normalizeStructuredLog(&result, fields, timestamp)
+	}
+	return result, true
+}
@@ -0,0 +1,87 @@
+package pod
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDetectLogLevel(t *testing.T) {
+	tests := []struct {
+		line string
+		want LogLevel
+	}{
+		{`{"level":"warn","msg":"disk almost full"}`, LogLevelWarn},
+		{`{"severity":"ERROR","message":"boom"}`, LogLevelError},
+		{`{"level":30,"msg":"pino info"}`, LogLevelInfo},
+		{`{"level":60,"msg":"pino fatal"}`, LogLevelFatal},
+		{`{"msg":"no level"}`, LogLevelUnknown},
+		{`time="2024-05-01T12:00:00Z" level=debug msg="cache miss"`, LogLevelDebug},
+		{`E0501 12:00:00.000000       1 controller.go:42] sync failed`, LogLevelError},
+		{`W0501 12:00:00.000000       1 reflector.go:10] watch closed`, LogLevelWarn},
+		{`2024-05-01 12:00:00,123 ERROR [main] connection refused`, LogLevelError},
+		{`[WARN] retrying`, LogLevelWarn},
+		{`INFO: server started`, LogLevelInfo},
+		{`panic: runtime error: index out of range`, LogLevelUnknown},
+		{`retrying after error in upstream`, LogLevelUnknown},
+		{`GET /healthz 200`, LogLevelUnknown},
+	}
+	for _, tt := range tests {
+		if got := DetectLogLevel(tt.line); got != tt.want {
+			t.Errorf("DetectLogLevel(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLogFilter_Apply(t *testing.T) {
+	filter, err := NewLogFilter(LogFilterOptions{Include: "time(out)?", Exclude: "healthz", IgnoreCase: true, MinLevel: "warn"})
+	if err != nil {
+		t.Fatalf("NewLogFilter() error = %v", err)
+	}
+	if !filter.Active() {
+		t.Error("Active() = false")
+	}
+
+	tests := []struct {
+		line   string
+		want   bool
+		result LogLine
+	}{
+		{"INFO request timeout", false, LogLine{}},
+		{"2024-05-01T12:00:00Z ERROR Timeout after 5s, time budget exceeded", true, LogLine{
+			Line:       "2024-05-01T12:00:00Z ERROR Timeout after 5s, time budget exceeded",
+			Level:      "error",
+			Highlights: []LogSpan{{27, 34}, {45, 49}},
+		}},
+		// A stack trace line has no level of its own and inherits the error level
+		{"    at timeout (server.js:10)", true, LogLine{Line: "    at timeout (server.js:10)", Level: "error", Highlights: []LogSpan{{7, 14}}}},
+		{"ERROR healthz timeout", false, LogLine{}},
+		{"ERROR connection refused", false, LogLine{}},
+	}
+	for _, tt := range tests {
+		got, ok := filter.Apply(tt.line)
+		if ok != tt.want || !reflect.DeepEqual(got, tt.result) {
+			t.Errorf("Apply(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.result, tt.want)
+		}
+	}
+
+	passthrough, _ := NewLogFilter(LogFilterOptions{})
+	if passthrough.Active() {
+		t.Error("empty filter is active")
+	}
+	if got, ok := passthrough.Apply("WARN slow query"); !ok || got.Level != "warn" || got.Highlights != nil {
+		t.Errorf("Apply() = %+v, %v", got, ok)
+	}
+}
+
+func TestNewLogFilter_Invalid(t *testing.T) {
+	for _, opts := range []LogFilterOptions{
+		{Include: "("},
+		{Exclude: "[a-"},
+		{MinLevel: "loud"},
+	} {
+		if _, err := NewLogFilter(opts); !errors.Is(err, ErrInvalidLogOptions) {
+			t.Errorf("NewLogFilter(%+v) error = %v, want %v", opts, err, ErrInvalidLogOptions)
+		}
+	}
+}
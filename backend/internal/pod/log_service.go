@@ -1,13 +1,20 @@
 package pod
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ErrInvalidLogOptions reports log options the Kubernetes API would reject
+var ErrInvalidLogOptions = errors.New("invalid log options")
+
 // LogService provides business logic for pod log operations.
 type LogService struct {
 	logRepo LogRepository
@@ -24,18 +31,52 @@ type StreamLogsRequest struct {
 	PodName   string // Pod name
 	Container string // Optional container name (for multi-container pods)
 	Follow    bool   // If true, stream logs continuously as they are generated
+
+	Previous     bool       // Return the logs of the previous, terminated instance of the container
+	Timestamps   bool       // Prefix every line with its RFC3339 timestamp
+	SinceSeconds *int64     // Only return logs newer than this many seconds
+	SinceTime    *time.Time // Only return logs after this time; exclusive with SinceSeconds
+	TailLines    *int64     // Only return this many lines from the end of the logs
+	LimitBytes   *int64     // Stop after this many bytes of log data
+}
+
+// podLogOptions converts the request into Kubernetes log options, rejecting invalid combinations.
+func (req StreamLogsRequest) podLogOptions() (*corev1.PodLogOptions, error) {
+	switch {
+	case req.SinceSeconds != nil && req.SinceTime != nil:
+		return nil, fmt.Errorf("%w: sinceSeconds and sinceTime cannot be combined", ErrInvalidLogOptions)
+	case req.SinceSeconds != nil && *req.SinceSeconds < 1:
+		return nil, fmt.Errorf("%w: sinceSeconds must be greater than 0", ErrInvalidLogOptions)
+	case req.TailLines != nil && *req.TailLines < 0:
+		return nil, fmt.Errorf("%w: tailLines must not be negative", ErrInvalidLogOptions)
+	case req.LimitBytes != nil && *req.LimitBytes < 1:
+		return nil, fmt.Errorf("%w: limitBytes must be greater than 0", ErrInvalidLogOptions)
+	}
+
+	opts := &corev1.PodLogOptions{
+		Container:    req.Container,
+		Follow:       req.Follow,
+		Previous:     req.Previous,
+		Timestamps:   req.Timestamps,
+		SinceSeconds: req.SinceSeconds,
+		TailLines:    req.TailLines,
+		LimitBytes:   req.LimitBytes,
+	}
+	if req.SinceTime != nil {
+		opts.SinceTime = &metav1.Time{Time: *req.SinceTime}
+	}
+	return opts, nil
 }
 
 // StreamLogs opens a log stream for a specific pod container.
 // Returns an io.ReadCloser that can be used to read log data.
 // The caller is responsible for closing the stream.
-// Returns an error if the log stream cannot be opened.
+// Returns an error wrapping ErrInvalidLogOptions if the options are inconsistent,
+// or an error if the log stream cannot be opened.
 func (s *LogService) StreamLogs(ctx context.Context, req StreamLogsRequest) (io.ReadCloser, error) {
-	opts := &corev1.PodLogOptions{
-		Follow: req.Follow,
-	}
-	if req.Container != "" {
-		opts.Container = req.Container
+	opts, err := req.podLogOptions()
+	if err != nil {
+		return nil, err
 	}
 
 	stream, err := s.logRepo.GetLogStream(ctx, req.Namespace, req.PodName, opts)
@@ -45,3 +86,21 @@ func (s *LogService) StreamLogs(ctx context.Context, req StreamLogsRequest) (io.
 
 	return stream, nil
 }
+
+// FilterLogStream reads a log stream line by line and passes every line accepted by filter to
+// emit. It stops at the end of the stream, when emit returns an error, or when a line is longer
+// than the maximum line size, returning the error that stopped it.
+func (s *LogService) FilterLogStream(stream io.Reader, filter *LogFilter, emit func(LogLine) error) error {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), maxLogLineSize)
+	for scanner.Scan() {
+		line, ok := filter.Apply(scanner.Text())
+		if !ok {
+			continue
+		}
+		if err := emit(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"strings"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -174,3 +175,58 @@ func TestLogService_StreamLogs(t *testing.T) {
 		})
 	}
 }
+
+func TestLogService_StreamLogs_Options(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tail, limit, seconds, zero := int64(100), int64(4096), int64(60), int64(0)
+
+	var got *corev1.PodLogOptions
+	service := NewLogService(&mockLogRepository{
+		getLogStreamFunc: func(ctx context.Context, namespace, podName string, opts *corev1.PodLogOptions) (io.ReadCloser, error) {
+			got = opts
+			return io.NopCloser(strings.NewReader("")), nil
+		},
+	})
+
+	stream, err := service.StreamLogs(context.Background(), StreamLogsRequest{
+		Namespace: "default", PodName: "web-1", Container: "app",
+		Previous: true, Timestamps: true, SinceTime: &since, TailLines: &tail, LimitBytes: &limit,
+	})
+	if err != nil {
+		t.Fatalf("StreamLogs() error = %v", err)
+	}
+	stream.Close()
+	if got.Container != "app" || !got.Previous || !got.Timestamps || got.Follow ||
+		got.SinceTime == nil || !got.SinceTime.Time.Equal(since) || got.SinceSeconds != nil ||
+		*got.TailLines != 100 || *got.LimitBytes != 4096 {
+		t.Errorf("PodLogOptions = %+v", got)
+	}
+
+	invalid := []StreamLogsRequest{
+		{SinceSeconds: &seconds, SinceTime: &since},
+		{SinceSeconds: &zero},
+		{LimitBytes: &zero},
+	}
+	for _, req := range invalid {
+		if _, err := service.StreamLogs(context.Background(), req); !errors.Is(err, ErrInvalidLogOptions) {
+			t.Errorf("StreamLogs(%+v) error = %v, want %v", req, err, ErrInvalidLogOptions)
+		}
+	}
+}
+
+func TestLogService_FilterLogStream(t *testing.T) {
+	filter, _ := NewLogFilter(LogFilterOptions{MinLevel: "error"})
+	service := NewLogService(&mockLogRepository{})
+
+	var got []string
+	err := service.FilterLogStream(strings.NewReader("INFO up\nERROR down\nERROR again\n"), filter, func(line LogLine) error {
+		got = append(got, line.Line)
+		if len(got) == 2 {
+			return io.ErrClosedPipe
+		}
+		return nil
+	})
+	if !errors.Is(err, io.ErrClosedPipe) || strings.Join(got, "|") != "ERROR down|ERROR again" {
+		t.Errorf("FilterLogStream() = %v, %v", got, err)
+	}
+}
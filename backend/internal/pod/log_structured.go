@@ -0,0 +1,146 @@
+package pod
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLogFieldFilters bounds the number of field filters of one log stream
+const maxLogFieldFilters = 16
+
+// Keys holding the timestamp, level and message of a structured log line, in order of preference
+var (
+	logTimestampKeys = []string{"time", "timestamp", "ts", "@timestamp"}
+	logLevelKeys     = []string{"level", "severity", "lvl", "log.level"}
+	logMessageKeys   = []string{"msg", "message", "@message"}
+)
+
+// LogFieldFilter keeps structured log lines whose field Key has the value Value. Key may be a
+// dotted path into nested objects, e.g. "http.status".
+type LogFieldFilter struct {
+	Key   string
+	Value string
+}
+
+// ParseLogFieldFilter parses a filter written as key=value, e.g. trace_id=abc
+func ParseLogFieldFilter(s string) (LogFieldFilter, error) {
+	key, value, found := strings.Cut(s, "=")
+	if !found || strings.TrimSpace(key) == "" {
+		return LogFieldFilter{}, fmt.Errorf("%w: field filter %q must be key=value", ErrInvalidLogOptions, s)
+	}
+	return LogFieldFilter{Key: strings.TrimSpace(key), Value: value}, nil
+}
+
+// matches reports whether a structured log line has the filtered value
+func (f LogFieldFilter) matches(fields map[string]interface{}) bool {
+	value, ok := lookupLogField(fields, f.Key)
+	return ok && logFieldString(value) == f.Value
+}
+
+// parseJSONLogLine decodes a log line holding a JSON object. Numbers are kept as json.Number so
+// large identifiers are not rounded.
+func parseJSONLogLine(line string) (map[string]interface{}, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(trimmed))
+	decoder.UseNumber()
+	var fields map[string]interface{}
+	if err := decoder.Decode(&fields); err != nil || decoder.More() {
+		return nil, false
+	}
+	return fields, true
+}
+
+// lookupLogField finds a field by its exact key, or else by following a dotted path
+func lookupLogField(fields map[string]interface{}, key string) (interface{}, bool) {
+	if value, ok := fields[key]; ok {
+		return value, true
+	}
+	head, rest, found := strings.Cut(key, ".")
+	if !found {
+		return nil, false
+	}
+	nested, ok := fields[head].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupLogField(nested, rest)
+}
+
+// logFieldString renders a field value the way it is written in a field filter
+func logFieldString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	default:
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		_ = encoder.Encode(v)
+		return strings.TrimSuffix(buf.String(), "\n")
+	}
+}
+
+// normalizeStructuredLog moves the timestamp and message of a structured line into the frame and
+// leaves the remaining fields. The level is taken out as well, since the frame already carries it.
+// When the line has no timestamp of its own, fallback is used, typically the API server's one.
+func normalizeStructuredLog(line *LogLine, fields map[string]interface{}, fallback time.Time) {
+	for _, key := range logTimestampKeys {
+		if timestamp, ok := logFieldTime(fields[key]); ok {
+			line.Timestamp = &timestamp
+			delete(fields, key)
+			break
+		}
+	}
+	if line.Timestamp == nil && !fallback.IsZero() {
+		line.Timestamp = &fallback
+	}
+	for _, key := range logMessageKeys {
+		if message, ok := fields[key].(string); ok {
+			line.Message = message
+			delete(fields, key)
+			break
+		}
+	}
+	for _, key := range logLevelKeys {
+		if _, ok := fields[key]; ok {
+			delete(fields, key)
+			break
+		}
+	}
+	line.Structured = true
+	if len(fields) > 0 {
+		line.Fields = fields
+	}
+}
+
+// logFieldTime reads an RFC3339 timestamp, or a Unix time in seconds (zap) or milliseconds (pino)
+func logFieldTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		timestamp, err := time.Parse(time.RFC3339Nano, v)
+		return timestamp.UTC(), err == nil
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil || f <= 0 {
+			return time.Time{}, false
+		}
+		if f >= 1e12 {
+			return time.UnixMilli(int64(f)).UTC(), true
+		}
+		return time.Unix(0, int64(f*float64(time.Second))).UTC(), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,105 @@
+package pod
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLogFilter_Apply_Parse(t *testing.T) {
+	filter, err := NewLogFilter(LogFilterOptions{Parse: true})
+	if err != nil {
+		t.Fatalf("NewLogFilter() error = %v", err)
+	}
+
+	line, ok := filter.Apply(`{"time":"2024-05-01T12:00:00.5Z","level":"error","msg":"payment failed","trace_id":"abc","order":{"id":9007199254740993}}`)
+	if !ok || !line.Structured || line.Level != "error" || line.Message != "payment failed" {
+		t.Fatalf("Apply() = %+v, %v", line, ok)
+	}
+	if want := time.Date(2024, 5, 1, 12, 0, 0, 5e8, time.UTC); line.Timestamp == nil || !line.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, want %v", line.Timestamp, want)
+	}
+	fields, _ := json.Marshal(line.Fields)
+	if string(fields) != `{"order":{"id":9007199254740993},"trace_id":"abc"}` {
+		t.Errorf("Fields = %s", fields)
+	}
+
+	// pino: numeric level and epoch milliseconds; the message key is "message"
+	line, _ = filter.Apply(`{"level":40,"time":1714564800000,"message":"slow"}`)
+	if line.Level != "warn" || line.Message != "slow" || line.Timestamp == nil || line.Timestamp.Unix() != 1714564800 || line.Fields != nil {
+		t.Errorf("Apply(pino) = %+v", line)
+	}
+
+	// Without a timestamp of its own, the one prefixed by the API server is used
+	line, _ = filter.Apply(`2024-05-01T12:00:00Z {"msg":"hi"}`)
+	if line.Timestamp == nil || !line.Timestamp.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) || line.Message != "hi" {
+		t.Errorf("Apply(prefixed) = %+v", line)
+	}
+
+	// Non-JSON lines pass through unchanged
+	line, ok = filter.Apply("plain text {not json}")
+	if !ok || line.Structured || line.Line != "plain text {not json}" || line.Message != "" || line.Fields != nil {
+		t.Errorf("Apply(text) = %+v, %v", line, ok)
+	}
+}
+
+func TestLogFilter_Apply_Fields(t *testing.T) {
+	traceID, _ := ParseLogFieldFilter("trace_id=abc")
+	status, _ := ParseLogFieldFilter("http.status=500")
+	filter, err := NewLogFilter(LogFilterOptions{Fields: []LogFieldFilter{traceID, status}})
+	if err != nil {
+		t.Fatalf("NewLogFilter() error = %v", err)
+	}
+	if !filter.Active() {
+		t.Error("Active() = false")
+	}
+
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{`{"trace_id":"abc","http":{"status":500}}`, true},
+		{`{"trace_id":"abc","http.status":500}`, true},
+		{`{"trace_id":"abc","http":{"status":200}}`, false},
+		{`{"trace_id":"xyz","http":{"status":500}}`, false},
+		{`trace_id=abc http.status=500`, false},
+	}
+	for _, tt := range tests {
+		line, ok := filter.Apply(tt.line)
+		if ok != tt.want {
+			t.Errorf("Apply(%q) = %v, want %v", tt.line, ok, tt.want)
+		}
+		// Without parse mode the line is not split
+		if ok && line.Structured {
+			t.Errorf("Apply(%q) split the line without parse mode", tt.line)
+		}
+	}
+}
+
+func TestParseLogFieldFilter(t *testing.T) {
+	if f, err := ParseLogFieldFilter("query=a=b"); err != nil || f.Key != "query" || f.Value != "a=b" {
+		t.Errorf("ParseLogFieldFilter() = %+v, %v", f, err)
+	}
+	for _, s := range []string{"trace_id", "=abc"} {
+		if _, err := ParseLogFieldFilter(s); !errors.Is(err, ErrInvalidLogOptions) {
+			t.Errorf("ParseLogFieldFilter(%q) error = %v, want %v", s, err, ErrInvalidLogOptions)
+		}
+	}
+	if _, err := NewLogFilter(LogFilterOptions{Fields: make([]LogFieldFilter, maxLogFieldFilters+1)}); !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("NewLogFilter() error = %v, want %v", err, ErrInvalidLogOptions)
+	}
+}
+
+func TestLogFieldString(t *testing.T) {
+	fields, _ := parseJSONLogLine(`{"ok":true,"n":1.50,"none":null,"tags":["a","<b>"]}`)
+	want := map[string]string{"ok": "true", "n": "1.50", "none": "null", "tags": `["a","<b>"]`}
+	for key, w := range want {
+		if got := logFieldString(fields[key]); got != w {
+			t.Errorf("logFieldString(%s) = %q, want %q", key, got, w)
+		}
+	}
+	if _, ok := parseJSONLogLine(`{"a":1} {"b":2}`); ok {
+		t.Error("two objects on one line parsed as one")
+	}
+}
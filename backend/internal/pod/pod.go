@@ -8,6 +8,7 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 )
 
 // execCreator abstracts exec creation to allow testing with mocks.
@@ -26,7 +27,9 @@ type Service struct {
 	handlers       *models.Handlers
 	clusterService ClusterService
 	logRepoFactory func(kubernetes.Interface) LogRepository
+	podRepoFactory func(kubernetes.Interface) PodWatchRepository
 	execFactory    func() execCreator
+	recordings     *recording.Service // records exec sessions when set and enabled
 }
 
 // NewService creates a new pod service with the provided handlers and cluster service.
@@ -37,9 +40,17 @@ func NewService(h *models.Handlers, cs ClusterService) *Service {
 		logRepoFactory: func(client kubernetes.Interface) LogRepository {
 			return NewK8sLogRepository(client)
 		},
+		podRepoFactory: func(client kubernetes.Interface) PodWatchRepository {
+			return NewK8sPodWatchRepository(client)
+		},
 		execFactory: func() execCreator {
 			return NewExecService()
 		},
 		// PodService will be created on-demand in handlers that need it. Factories are overridable in tests.
 	}
 }
+
+// SetRecordingService records every exec session with the given service
+func (s *Service) SetRecordingService(recordings *recording.Service) {
+	s.recordings = recordings
+}
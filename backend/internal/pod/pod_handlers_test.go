@@ -1,6 +1,7 @@
 package pod
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -13,8 +14,10 @@ import (
 
 	"github.com/gorilla/websocket"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
@@ -107,6 +110,11 @@ func TestDefaultFactories(t *testing.T) {
 	if exec == nil {
 		t.Error("execFactory returned nil")
 	}
+
+	// Trigger podRepoFactory
+	if podRepo := service.podRepoFactory(client); podRepo == nil {
+		t.Error("podRepoFactory returned nil")
+	}
 }
 
 func TestStreamPodLogs_InvalidParams(t *testing.T) {
@@ -400,3 +408,292 @@ func TestStreamPodLogs_AuthError(t *testing.T) {
 		t.Fatalf("status = %d, want 500 when auth missing", rr.Code)
 	}
 }
+
+func TestStreamAggregatedLogs_InvalidParams(t *testing.T) {
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+	}{
+		{"missing namespace", "selector=app%3Dweb", http.StatusBadRequest},
+		{"missing selector", "namespace=ns1", http.StatusBadRequest},
+		{"selector and workload", "namespace=ns1&selector=app%3Dweb&kind=Deployment&name=web", http.StatusBadRequest},
+		{"invalid selector", "namespace=ns1&selector=app%3D%3D%3Dweb", http.StatusBadRequest},
+		{"missing kind", "namespace=ns1&name=web", http.StatusBadRequest},
+		{"invalid tailLines", "namespace=ns1&selector=app%3Dweb&tailLines=-1", http.StatusBadRequest},
+		{"invalid format", "namespace=ns1&selector=app%3Dweb&format=xml", http.StatusBadRequest},
+		{"unsupported workload", "namespace=ns1&kind=CronJob&name=nightly", http.StatusBadRequest},
+		{"missing workload", "namespace=ns1&kind=Deployment&name=web", http.StatusNotFound},
+		{"forbidden", "namespace=ns2&selector=app%3Dweb", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs/aggregate?"+tt.query, nil), models.Claims{
+				Username:    "user",
+				Permissions: map[string]string{"ns1": "view"},
+			})
+			rr := httptest.NewRecorder()
+			service.StreamAggregatedLogs(rr, req)
+			if rr.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamAggregatedLogs_Success(t *testing.T) {
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+	service.logRepoFactory = func(client kubernetes.Interface) LogRepository {
+		return &scriptedLogRepo{streams: map[string][]string{"web-1/app": {"2024-01-01T00:00:01Z hello logs\n"}}}
+	}
+	service.podRepoFactory = func(client kubernetes.Interface) PodWatchRepository {
+		return &fakePodWatchRepo{pods: []corev1.Pod{runningPod("web-1", 0, "app")}, watcher: watch.NewFakeWithChanSize(1, false)}
+	}
+
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/pods/logs/aggregate?namespace=ns1&kind=Deployment&name=web&format="+format, nil)
+			req = authContext(req, models.Claims{
+				Username:    "user",
+				Permissions: map[string]string{"ns1": "view"},
+			})
+			// The stream only ends when the client goes away
+			ctx, cancel := context.WithTimeout(req.Context(), 300*time.Millisecond)
+			defer cancel()
+			rr := httptest.NewRecorder()
+
+			service.StreamAggregatedLogs(rr, req.WithContext(ctx))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", rr.Code)
+			}
+			body := rr.Body.String()
+			if format == "text" {
+				want := "[web-1] + pod added\n[web-1/app] 2024-01-01T00:00:01Z hello logs\n"
+				if body != want {
+					t.Errorf("body = %q, want %q", body, want)
+				}
+				return
+			}
+			lines := strings.Split(strings.TrimSpace(body), "\n")
+			var frame LogFrame
+			if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &frame) != nil || frame.Type != LogFrameLine || frame.Message != "hello logs" {
+				t.Errorf("unexpected body: %q", body)
+			}
+		})
+	}
+}
+
+func TestFormatLogFrame(t *testing.T) {
+	tests := []struct {
+		frame LogFrame
+		want  string
+	}{
+		{LogFrame{Type: LogFrameRemoved, Pod: "web-1"}, "[web-1] - pod removed\n"},
+		{LogFrame{Type: LogFrameError, Pod: "web-1", Container: "app", Message: "boom"}, "[web-1/app] ! boom\n"},
+		{LogFrame{Type: LogFrameError, Message: "watch failed"}, "[stream] ! watch failed\n"},
+	}
+	for _, tt := range tests {
+		if got := formatLogFrame(tt.frame); got != tt.want {
+			t.Errorf("formatLogFrame(%+v) = %q, want %q", tt.frame, got, tt.want)
+		}
+	}
+}
+
+func TestStreamPodLogs_Options(t *testing.T) {
+	logRepo := &mockLogRepo{stream: io.NopCloser(strings.NewReader("crashed"))}
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+	service.logRepoFactory = func(client kubernetes.Interface) LogRepository {
+		return logRepo
+	}
+	claims := models.Claims{Username: "user", Permissions: map[string]string{"ns1": "view"}}
+
+	req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&previous=true&tailLines=20&timestamps=true", nil), claims)
+	rr := httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	opts := logRepo.lastOpts
+	if !opts.Previous || opts.Follow || !opts.Timestamps || opts.TailLines == nil || *opts.TailLines != 20 {
+		t.Errorf("PodLogOptions = %+v", opts)
+	}
+
+	for _, query := range []string{"previous=maybe", "tailLines=ten", "sinceTime=yesterday", "sinceSeconds=0", "sinceSeconds=60&sinceTime=2024-05-01T12:00:00Z"} {
+		req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&"+query, nil), claims)
+		rr := httptest.NewRecorder()
+		service.StreamPodLogs(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+
+	logRepo.err = apierrors.NewBadRequest(`previous terminated container "app" in pod "pod1" not found`)
+	req = authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&previous=true", nil), claims)
+	rr = httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400 for a missing previous container", rr.Code)
+	}
+}
+
+func TestDownloadPodLogs(t *testing.T) {
+	logRepo := &mockLogRepo{stream: io.NopCloser(strings.NewReader("line 1\nline 2\n"))}
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+	service.logRepoFactory = func(client kubernetes.Interface) LogRepository {
+		return logRepo
+	}
+	claims := models.Claims{Username: "user", Permissions: map[string]string{"ns1": "view"}}
+
+	req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs/download?namespace=ns1&pod=pod1&container=app&previous=true&limitBytes=1024", nil), claims)
+	rr := httptest.NewRecorder()
+	service.DownloadPodLogs(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/gzip" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="pod1-app-previous-`) || !strings.HasSuffix(got, `.log.gz"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	gz, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("body is not gzip: %v", err)
+	}
+	if data, _ := io.ReadAll(gz); string(data) != "line 1\nline 2\n" {
+		t.Errorf("logs = %q", data)
+	}
+	if opts := logRepo.lastOpts; opts.Follow || !opts.Previous || opts.LimitBytes == nil || *opts.LimitBytes != 1024 {
+		t.Errorf("PodLogOptions = %+v", opts)
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		claims   models.Claims
+		err      error
+		wantCode int
+	}{
+		{"missing pod", "namespace=ns1", claims, nil, http.StatusBadRequest},
+		{"invalid option", "namespace=ns1&pod=pod1&limitBytes=-1", claims, nil, http.StatusBadRequest},
+		{"forbidden", "namespace=ns2&pod=pod1", claims, nil, http.StatusForbidden},
+		{"pod not found", "namespace=ns1&pod=pod1", claims, apierrors.NewNotFound(corev1.Resource("pods"), "pod1"), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logRepo.err = tt.err
+			rr := httptest.NewRecorder()
+			service.DownloadPodLogs(rr, authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs/download?"+tt.query, nil), tt.claims))
+			if rr.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", rr.Code, tt.wantCode, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamPodLogs_Filtered(t *testing.T) {
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+	service.logRepoFactory = func(client kubernetes.Interface) LogRepository {
+		return &mockLogRepo{stream: io.NopCloser(strings.NewReader("INFO started\nWARN slow Query\nERROR query failed\n"))}
+	}
+	claims := models.Claims{Username: "user", Permissions: map[string]string{"ns1": "view"}}
+
+	req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&include=query&ignoreCase=true&minLevel=warn&format=json", nil), claims)
+	rr := httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	want := `{"line":"WARN slow Query","level":"warn","highlights":[{"start":10,"end":15}]}` + "\n" +
+		`{"line":"ERROR query failed","level":"error","highlights":[{"start":6,"end":11}]}` + "\n"
+	if body := rr.Body.String(); body != want {
+		t.Errorf("body = %q, want %q", body, want)
+	}
+
+	req = authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&exclude=started", nil), claims)
+	rr = httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if body := rr.Body.String(); body != "WARN slow Query\nERROR query failed\n" {
+		t.Errorf("body = %q", body)
+	}
+
+	for _, query := range []string{"include=(", "minLevel=loud", "ignoreCase=maybe", "format=xml"} {
+		req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&"+query, nil), claims)
+		rr := httptest.NewRecorder()
+		service.StreamPodLogs(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+}
+
+func TestStreamPodLogs_Parse(t *testing.T) {
+	handlers := &models.Handlers{
+		Clients: map[string]kubernetes.Interface{"default": k8sfake.NewSimpleClientset()},
+	}
+	service := NewService(handlers, cluster.NewService(handlers))
+	service.logRepoFactory = func(client kubernetes.Interface) LogRepository {
+		return &mockLogRepo{stream: io.NopCloser(strings.NewReader(
+			`{"level":"info","msg":"ok","trace_id":"abc"}` + "\n" +
+				`{"level":"info","msg":"other","trace_id":"xyz"}` + "\n" +
+				"starting up\n"))}
+	}
+	claims := models.Claims{Username: "user", Permissions: map[string]string{"ns1": "view"}}
+
+	req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&parse=json", nil), claims)
+	rr := httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 frames, got %q", rr.Body.String())
+	}
+	var first, last LogLine
+	_ = json.Unmarshal([]byte(lines[0]), &first)
+	_ = json.Unmarshal([]byte(lines[2]), &last)
+	if !first.Structured || first.Message != "ok" || first.Level != "info" || first.Fields["trace_id"] != "abc" {
+		t.Errorf("first frame = %+v", first)
+	}
+	if last.Structured || last.Line != "starting up" {
+		t.Errorf("last frame = %+v", last)
+	}
+
+	req = authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&parse=json&field=trace_id%3Dabc", nil), claims)
+	rr = httptest.NewRecorder()
+	service.StreamPodLogs(rr, req)
+	if n := strings.Count(rr.Body.String(), "\n"); n != 1 || !strings.Contains(rr.Body.String(), `"message":"ok"`) {
+		t.Errorf("field filter body = %q", rr.Body.String())
+	}
+
+	for _, query := range []string{"parse=xml", "parse=json&format=text", "field=trace_id"} {
+		req := authContext(httptest.NewRequest(http.MethodGet, "/api/pods/logs?namespace=ns1&pod=pod1&"+query, nil), claims)
+		rr := httptest.NewRecorder()
+		service.StreamPodLogs(rr, req)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rr.Code)
+		}
+	}
+}
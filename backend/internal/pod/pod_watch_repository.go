@@ -0,0 +1,105 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrUnsupportedWorkload reports a workload kind whose pods cannot be selected
+var ErrUnsupportedWorkload = errors.New("unsupported workload kind")
+
+// PodWatchRepository defines the interface for finding and following the pods behind a label selector
+type PodWatchRepository interface {
+	ListPods(ctx context.Context, namespace, labelSelector string) (*corev1.PodList, error)
+	WatchPods(ctx context.Context, namespace, labelSelector, resourceVersion string) (watch.Interface, error)
+	GetWorkloadSelector(ctx context.Context, namespace, kind, name string) (string, error)
+}
+
+// K8sPodWatchRepository implements PodWatchRepository using Kubernetes client-go
+type K8sPodWatchRepository struct {
+	client kubernetes.Interface
+}
+
+// NewK8sPodWatchRepository creates a new K8sPodWatchRepository
+func NewK8sPodWatchRepository(client kubernetes.Interface) *K8sPodWatchRepository {
+	return &K8sPodWatchRepository{client: client}
+}
+
+// ListPods lists the pods matching a label selector
+func (r *K8sPodWatchRepository) ListPods(ctx context.Context, namespace, labelSelector string) (*corev1.PodList, error) {
+	pods, err := r.client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods, nil
+}
+
+// WatchPods watches the pods matching a label selector, starting after resourceVersion
+func (r *K8sPodWatchRepository) WatchPods(ctx context.Context, namespace, labelSelector, resourceVersion string) (watch.Interface, error) {
+	w, err := r.client.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{
+		LabelSelector:   labelSelector,
+		ResourceVersion: resourceVersion,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch pods: %w", err)
+	}
+	return w, nil
+}
+
+// GetWorkloadSelector returns the pod selector of a Deployment, StatefulSet, DaemonSet, ReplicaSet or Job
+func (r *K8sPodWatchRepository) GetWorkloadSelector(ctx context.Context, namespace, kind, name string) (string, error) {
+	var selector *metav1.LabelSelector
+	switch strings.TrimSuffix(strings.ToLower(kind), "s") {
+	case "deployment":
+		d, err := r.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get deployment: %w", err)
+		}
+		selector = d.Spec.Selector
+	case "statefulset":
+		sts, err := r.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get statefulset: %w", err)
+		}
+		selector = sts.Spec.Selector
+	case "daemonset":
+		ds, err := r.client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get daemonset: %w", err)
+		}
+		selector = ds.Spec.Selector
+	case "replicaset":
+		rs, err := r.client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get replicaset: %w", err)
+		}
+		selector = rs.Spec.Selector
+	case "job":
+		job, err := r.client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to get job: %w", err)
+		}
+		selector = job.Spec.Selector
+	default:
+		return "", fmt.Errorf("%w: %s (use Deployment, StatefulSet, DaemonSet, ReplicaSet or Job)", ErrUnsupportedWorkload, kind)
+	}
+
+	if selector == nil {
+		return "", fmt.Errorf("%s %s has no pod selector", kind, name)
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector on %s %s: %w", kind, name, err)
+	}
+	if s.Empty() {
+		return "", fmt.Errorf("%s %s selects every pod", kind, name)
+	}
+	return s.String(), nil
+}
@@ -0,0 +1,58 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestK8sPodWatchRepository(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	client := k8sfake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}, Spec: appsv1.DeploymentSpec{Selector: selector}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "migrate", Namespace: "shop"}, Spec: batchv1.JobSpec{Selector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "job-name", Operator: metav1.LabelSelectorOpIn, Values: []string{"migrate"}}},
+		}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop", Labels: map[string]string{"app": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "shop", Labels: map[string]string{"app": "api"}}},
+	)
+	repo := NewK8sPodWatchRepository(client)
+	ctx := context.Background()
+
+	tests := []struct {
+		kind, name string
+		want       string
+	}{
+		{"Deployment", "web", "app=web"},
+		{"deployments", "web", "app=web"},
+		{"Job", "migrate", "job-name in (migrate)"},
+	}
+	for _, tt := range tests {
+		if got, err := repo.GetWorkloadSelector(ctx, "shop", tt.kind, tt.name); err != nil || got != tt.want {
+			t.Errorf("GetWorkloadSelector(%s) = %q, %v, want %q", tt.kind, got, err, tt.want)
+		}
+	}
+	if _, err := repo.GetWorkloadSelector(ctx, "shop", "CronJob", "nightly"); !errors.Is(err, ErrUnsupportedWorkload) {
+		t.Errorf("GetWorkloadSelector(CronJob) error = %v, want %v", err, ErrUnsupportedWorkload)
+	}
+	if _, err := repo.GetWorkloadSelector(ctx, "shop", "StatefulSet", "db"); !apierrors.IsNotFound(err) {
+		t.Errorf("GetWorkloadSelector(missing) error = %v, want not found", err)
+	}
+
+	pods, err := repo.ListPods(ctx, "shop", "app=web")
+	if err != nil || len(pods.Items) != 1 || pods.Items[0].Name != "web-1" {
+		t.Errorf("ListPods() = %v, %v", pods, err)
+	}
+	w, err := repo.WatchPods(ctx, "shop", "app=web", pods.ResourceVersion)
+	if err != nil {
+		t.Fatalf("WatchPods() error = %v", err)
+	}
+	w.Stop()
+}
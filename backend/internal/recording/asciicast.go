@@ -0,0 +1,196 @@
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// Terminal size written to the asciicast header. The exec terminal is not resized by the
+	// client, so the recording uses the size the shell starts with.
+	defaultWidth  = 80
+	defaultHeight = 24
+
+	// ContentType is the media type of asciicast recordings
+	ContentType = "application/x-asciicast"
+
+	eventOutput = "o"
+	eventInput  = "i"
+)
+
+// asciicastHeader is the first line of an asciicast v2 recording
+type asciicastHeader struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recorder writes one exec session as an asciicast v2 recording: a JSON header line, then one
+// [elapsed seconds, "o" or "i", data] line per chunk of terminal output or input. It is safe for
+// concurrent use, and all its methods do nothing on a nil Recorder, which stands for a session
+// that is not recorded.
+//
+// Once the recording reaches its maximum size or the store fails, recording stops and Input and
+// Output return the reason: the caller must then end the session rather than let it continue
+// unrecorded.
+type Recorder struct {
+	mu      sync.Mutex
+	writer  Writer
+	meta    models.ExecRecording
+	maxSize int64
+	pending map[string][]byte // trailing bytes of an incomplete UTF-8 character, per event type
+	err     error             // why recording stopped; nothing more is written once set
+	closed  bool
+}
+
+// newRecorder writes the asciicast header and returns a recorder for the session.
+// maxSize bounds the size of the recording in bytes; 0 means unlimited.
+func newRecorder(writer Writer, meta models.ExecRecording, maxSize int64) (*Recorder, error) {
+	r := &Recorder{writer: writer, meta: meta, maxSize: maxSize, pending: make(map[string][]byte)}
+
+	title := fmt.Sprintf("%s@%s %s/%s", meta.User, meta.Cluster, meta.Namespace, meta.Pod)
+	if meta.Container != "" {
+		title += "/" + meta.Container
+	}
+	header, err := json.Marshal(asciicastHeader{
+		Version:   2,
+		Width:     defaultWidth,
+		Height:    defaultHeight,
+		Timestamp: meta.StartedAt.Unix(),
+		Title:     title,
+		Env:       map[string]string{"TERM": "xterm-256color", "SHELL": "/bin/sh"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode recording header: %w", err)
+	}
+	if err := r.writeLine(header); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ID returns the ID of the recording
+func (r *Recorder) ID() string {
+	if r == nil {
+		return ""
+	}
+	return r.meta.ID
+}
+
+// Output records terminal output; it returns an error once recording has stopped
+func (r *Recorder) Output(p []byte) error {
+	return r.record(eventOutput, p)
+}
+
+// Input records what the user typed; it returns an error once recording has stopped
+func (r *Recorder) Input(p []byte) error {
+	return r.record(eventInput, p)
+}
+
+// Err returns why recording stopped before the end of the session, or nil
+func (r *Recorder) Err() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close records the end of the session and completes the recording
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	// Flush what is left of incomplete characters; the JSON encoder replaces the invalid bytes
+	for _, code := range []string{eventOutput, eventInput} {
+		if len(r.pending[code]) > 0 {
+			r.writeEvent(code, r.pending[code])
+		}
+	}
+	endedAt := time.Now().UTC()
+	r.meta.EndedAt = &endedAt
+	return r.writer.Finish(r.meta)
+}
+
+func (r *Recorder) record(code string, p []byte) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil || r.closed || len(p) == 0 {
+		return r.err
+	}
+
+	// A chunk may end in the middle of a multi-byte character; keep those bytes for the next one
+	data := append(r.pending[code], p...)
+	data, r.pending[code] = splitIncompleteUTF8(data)
+	if len(data) > 0 {
+		r.writeEvent(code, data)
+	}
+	return r.err
+}
+
+// writeEvent appends an event line; the caller holds r.mu
+func (r *Recorder) writeEvent(code string, data []byte) {
+	if r.err != nil {
+		return
+	}
+	elapsed := math.Round(time.Since(r.meta.StartedAt).Seconds()*1e6) / 1e6
+	line, err := json.Marshal([]interface{}{elapsed, code, string(data)})
+	if err != nil {
+		return
+	}
+	if r.maxSize > 0 && r.meta.Size+int64(len(line))+1 > r.maxSize {
+		r.err = ErrMaxSize
+		r.meta.Truncated = true
+		utils.LogWarn("Session recording reached its maximum size, closing the session", map[string]interface{}{
+			"id":      r.meta.ID,
+			"maxSize": r.maxSize,
+		})
+		return
+	}
+	if err := r.writeLine(line); err != nil {
+		r.err = fmt.Errorf("failed to write session recording: %w", err)
+		utils.LogError(err, "Failed to write session recording, closing the session", map[string]interface{}{
+			"id": r.meta.ID,
+		})
+	}
+}
+
+func (r *Recorder) writeLine(line []byte) error {
+	n, err := r.writer.Write(append(line, '\n'))
+	r.meta.Size += int64(n)
+	return err
+}
+
+// splitIncompleteUTF8 separates a trailing incomplete UTF-8 character from the rest of p
+func splitIncompleteUTF8(p []byte) ([]byte, []byte) {
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax+1; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				return p[:i], append([]byte(nil), p[i:]...)
+			}
+			break
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,176 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// readRecording returns the header and events of a recording
+func readRecording(t *testing.T, store Store, id string) (asciicastHeader, [][]interface{}, *models.ExecRecording) {
+	t.Helper()
+	data, meta, err := store.Open(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer data.Close()
+	raw, _ := io.ReadAll(data)
+
+	lines := strings.Split(strings.TrimSuffix(string(raw), "\n"), "\n")
+	var header asciicastHeader
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil {
+		t.Fatalf("invalid header %q: %v", lines[0], err)
+	}
+	var events [][]interface{}
+	for _, line := range lines[1:] {
+		var event []interface{}
+		if err := json.Unmarshal([]byte(line), &event); err != nil || len(event) != 3 {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		events = append(events, event)
+	}
+	if meta.Size != int64(len(raw)) {
+		t.Errorf("Size = %d, want %d", meta.Size, len(raw))
+	}
+	return header, events, meta
+}
+
+func TestRecorder(t *testing.T) {
+	store := NewMemoryStore(0)
+	service := NewService(store, 0, 0)
+
+	recorder, err := service.Start(context.Background(), models.ExecRecording{
+		User: "alice", Cluster: "default", Namespace: "shop", Pod: "web-1", Container: "app",
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	recorder.Input([]byte("ls\r"))
+	recorder.Output([]byte("caf\xc3")) // "é" split across two chunks
+	recorder.Output([]byte("\xa9\r\n"))
+	recorder.Output(nil)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	recorder.Output([]byte("after close"))
+	if err := recorder.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+
+	header, events, meta := readRecording(t, store, recorder.ID())
+	if header.Version != 2 || header.Width != defaultWidth || header.Title != "alice@default shop/web-1/app" || header.Timestamp != meta.StartedAt.Unix() {
+		t.Errorf("header = %+v", header)
+	}
+	want := [][2]string{{"i", "ls\r"}, {"o", "caf"}, {"o", "é\r\n"}}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v", events)
+	}
+	for i, w := range want {
+		if events[i][1] != w[0] || events[i][2] != w[1] {
+			t.Errorf("event %d = %v, want %v", i, events[i], w)
+		}
+		if elapsed, ok := events[i][0].(float64); !ok || elapsed < 0 || elapsed > 5 {
+			t.Errorf("event %d elapsed = %v", i, events[i][0])
+		}
+	}
+	if meta.EndedAt == nil || meta.User != "alice" || meta.Truncated {
+		t.Errorf("meta = %+v", meta)
+	}
+}
+
+func TestRecorder_MaxSize(t *testing.T) {
+	store := NewMemoryStore(0)
+	service := NewService(store, 0, 400)
+
+	recorder, err := service.Start(context.Background(), models.ExecRecording{User: "alice", Namespace: "shop", Pod: "web-1"})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	var outputErr error
+	for i := 0; i < 20 && outputErr == nil; i++ {
+		outputErr = recorder.Output([]byte(strings.Repeat("x", 20)))
+	}
+	if !errors.Is(outputErr, ErrMaxSize) || !errors.Is(recorder.Err(), ErrMaxSize) {
+		t.Errorf("Output() error = %v, Err() = %v, want ErrMaxSize", outputErr, recorder.Err())
+	}
+	if err := recorder.Input([]byte("ls")); !errors.Is(err, ErrMaxSize) {
+		t.Errorf("Input() after truncation error = %v, want ErrMaxSize", err)
+	}
+	recorder.Close()
+
+	_, events, meta := readRecording(t, store, recorder.ID())
+	if !meta.Truncated || meta.Size > 400 || len(events) == 0 || len(events) == 20 {
+		t.Errorf("meta = %+v with %d events", meta, len(events))
+	}
+}
+
+// failingWriter accepts the asciicast header and fails every later write
+type failingWriter struct {
+	writes int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes > 1 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func (w *failingWriter) Finish(meta models.ExecRecording) error {
+	return nil
+}
+
+func TestRecorder_WriteError(t *testing.T) {
+	recorder, err := newRecorder(&failingWriter{}, models.ExecRecording{ID: "abc", StartedAt: time.Now()}, 0)
+	if err != nil {
+		t.Fatalf("newRecorder() error = %v", err)
+	}
+	if err := recorder.Output([]byte("out")); err == nil {
+		t.Fatal("Output() error = nil after a write failure")
+	}
+	if err := recorder.Input([]byte("ls")); err == nil || recorder.Err() == nil {
+		t.Errorf("Input() error = %v, Err() = %v after a write failure", err, recorder.Err())
+	}
+}
+
+func TestRecorder_Nil(t *testing.T) {
+	var recorder *Recorder
+	if err := recorder.Input([]byte("ls")); err != nil {
+		t.Errorf("nil recorder Input() error = %v", err)
+	}
+	recorder.Output([]byte("out"))
+	if err := recorder.Close(); err != nil || recorder.ID() != "" || recorder.Err() != nil {
+		t.Errorf("nil recorder: %v", err)
+	}
+
+	// A disabled service hands out nil recorders
+	recorder, err := NewService(nil, time.Hour, 0).Start(context.Background(), models.ExecRecording{})
+	if recorder != nil || err != nil {
+		t.Errorf("Start() = %v, %v on a disabled service", recorder, err)
+	}
+}
+
+func TestSplitIncompleteUTF8(t *testing.T) {
+	tests := []struct {
+		in, complete, rest string
+	}{
+		{"abc", "abc", ""},
+		{"ab\xe2\x82", "ab", "\xe2\x82"},
+		{"ab\xe2\x82\xac", "ab\xe2\x82\xac", ""},
+		{"\xf0\x9f\x98", "", "\xf0\x9f\x98"},
+		{"ab\xff", "ab\xff", ""},
+	}
+	for _, tt := range tests {
+		complete, rest := splitIncompleteUTF8([]byte(tt.in))
+		if string(complete) != tt.complete || string(rest) != tt.rest {
+			t.Errorf("splitIncompleteUTF8(%q) = %q, %q", tt.in, complete, rest)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package recording
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// StoreNone, StoreMemory and StoreFile are the values of EXEC_RECORDING_STORE
+	StoreNone   = "none"
+	StoreMemory = "memory"
+	StoreFile   = "file"
+
+	defaultDir       = "/var/lib/dkonsole/recordings"
+	defaultRetention = 30 * 24 * time.Hour
+	defaultMaxSize   = 50 << 20 // 50MB
+)
+
+// Config selects the recording store and its retention policy
+type Config struct {
+	Store      string        // StoreNone (default, recording disabled), StoreMemory or StoreFile
+	Dir        string        // directory of the file store
+	MemorySize int           // recordings kept by the memory store
+	Retention  time.Duration // recordings older than this are deleted; 0 keeps them forever
+	MaxSize    int64         // maximum size of one recording in bytes; 0 means unlimited
+}
+
+// ConfigFromEnv reads the recording settings from the environment:
+// EXEC_RECORDING_STORE (none, memory or file), EXEC_RECORDING_DIR, EXEC_RECORDING_MEMORY_SIZE,
+// EXEC_RECORDING_RETENTION (a Go duration such as 720h; 0 keeps recordings forever) and
+// EXEC_RECORDING_MAX_SIZE_MB.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Store:      strings.ToLower(strings.TrimSpace(os.Getenv("EXEC_RECORDING_STORE"))),
+		Dir:        os.Getenv("EXEC_RECORDING_DIR"),
+		MemorySize: envInt("EXEC_RECORDING_MEMORY_SIZE", defaultMemorySize),
+		Retention:  defaultRetention,
+		MaxSize:    int64(envInt("EXEC_RECORDING_MAX_SIZE_MB", defaultMaxSize>>20)) << 20,
+	}
+	if cfg.Store == "" {
+		cfg.Store = StoreNone
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = defaultDir
+	}
+	if retention := strings.TrimSpace(os.Getenv("EXEC_RECORDING_RETENTION")); retention != "" {
+		if d, err := time.ParseDuration(retention); err == nil && d >= 0 {
+			cfg.Retention = d
+		} else {
+			utils.LogWarn("Invalid EXEC_RECORDING_RETENTION, using default", map[string]interface{}{
+				"value":   retention,
+				"default": defaultRetention.String(),
+			})
+		}
+	}
+	return cfg
+}
+
+// NewStore creates the store selected by cfg; it returns nil when recording is disabled
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.Store {
+	case "", StoreNone:
+		return nil, nil
+	case StoreMemory:
+		return NewMemoryStore(cfg.MemorySize), nil
+	case StoreFile:
+		store, err := NewFileStore(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown recording store %q (use %s, %s or %s)", cfg.Store, StoreNone, StoreMemory, StoreFile)
+	}
+}
+
+func envInt(name string, fallback int) int {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		utils.LogWarn("Invalid recording setting, using default", map[string]interface{}{
+			"name":    name,
+			"value":   value,
+			"default": fallback,
+		})
+		return fallback
+	}
+	return n
+}
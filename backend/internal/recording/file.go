@@ -0,0 +1,180 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	castExtension = ".cast"
+	metaExtension = ".json"
+)
+
+// FileStore keeps each recording in a directory as <id>.cast, the asciicast data, next to
+// <id>.json, its metadata. Mount a persistent volume at the directory to keep recordings
+// across restarts.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates the recording directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("recording directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Create opens the data file of a new recording and writes its metadata
+func (s *FileStore) Create(ctx context.Context, meta models.ExecRecording) (Writer, error) {
+	if !validRecordingID(meta.ID) {
+		return nil, fmt.Errorf("invalid recording ID %q", meta.ID)
+	}
+	file, err := os.OpenFile(s.path(meta.ID, castExtension), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording file: %w", err)
+	}
+	if err := s.writeMeta(meta); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return &fileWriter{store: s, file: file}, nil
+}
+
+// List reads the metadata files and returns the recordings matching q, newest first
+func (s *FileStore) List(ctx context.Context, q Query) ([]models.ExecRecording, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording directory: %w", err)
+	}
+
+	var recordings []models.ExecRecording
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		id, ok := strings.CutSuffix(entry.Name(), metaExtension)
+		if !ok || !validRecordingID(id) {
+			continue
+		}
+		meta, err := s.readMeta(id)
+		if err != nil {
+			utils.LogWarn("Skipping unreadable session recording", map[string]interface{}{
+				"id":    id,
+				"error": err.Error(),
+			})
+			continue
+		}
+		recordings = append(recordings, *meta)
+	}
+	return filterRecordings(recordings, q), nil
+}
+
+// Open opens the data file of a recording
+func (s *FileStore) Open(ctx context.Context, id string) (io.ReadCloser, *models.ExecRecording, error) {
+	if !validRecordingID(id) {
+		return nil, nil, ErrNotFound
+	}
+	meta, err := s.readMeta(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := os.Open(s.path(id, castExtension))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	return file, meta, nil
+}
+
+// Delete removes the data and metadata files of a recording
+func (s *FileStore) Delete(ctx context.Context, id string) error {
+	if !validRecordingID(id) {
+		return nil
+	}
+	for _, ext := range []string{castExtension, metaExtension} {
+		if err := os.Remove(s.path(id, ext)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete recording: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) path(id, ext string) string {
+	return filepath.Join(s.dir, id+ext)
+}
+
+// readMeta reads the metadata of a recording; the size of a recording still in progress is
+// taken from its data file
+func (s *FileStore) readMeta(id string) (*models.ExecRecording, error) {
+	data, err := os.ReadFile(s.path(id, metaExtension))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to read recording metadata: %w", err)
+	}
+	var meta models.ExecRecording
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode recording metadata: %w", err)
+	}
+	if meta.EndedAt == nil {
+		if info, err := os.Stat(s.path(id, castExtension)); err == nil {
+			meta.Size = info.Size()
+		}
+	}
+	return &meta, nil
+}
+
+// writeMeta replaces the metadata file atomically, so readers never see a partial file
+func (s *FileStore) writeMeta(meta models.ExecRecording) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode recording metadata: %w", err)
+	}
+	tmp := s.path(meta.ID, metaExtension+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write recording metadata: %w", err)
+	}
+	if err := os.Rename(tmp, s.path(meta.ID, metaExtension)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write recording metadata: %w", err)
+	}
+	return nil
+}
+
+// fileWriter appends to the data file of a recording
+type fileWriter struct {
+	store *FileStore
+	file  *os.File
+}
+
+func (w *fileWriter) Write(p []byte) (int, error) {
+	return w.file.Write(p)
+}
+
+func (w *fileWriter) Finish(meta models.ExecRecording) error {
+	closeErr := w.file.Close()
+	if err := w.store.writeMeta(meta); err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close recording file: %w", closeErr)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "recordings")
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	first := models.ExecRecording{ID: newRecordingID(started), User: "alice", Namespace: "shop", Pod: "web-1", StartedAt: started}
+	second := models.ExecRecording{ID: newRecordingID(started.Add(time.Minute)), User: "bob", Namespace: "shop", Pod: "web-2", StartedAt: started.Add(time.Minute)}
+
+	writer, err := store.Create(ctx, first)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	writer.Write([]byte("header\n"))
+	if _, err := store.Create(ctx, first); err == nil {
+		t.Error("Create() with an existing ID should fail")
+	}
+	open, err := store.Create(ctx, second)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	open.Write([]byte("in progress\n"))
+
+	ended := started.Add(30 * time.Second)
+	first.EndedAt, first.Size = &ended, 7
+	if err := writer.Finish(first); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+
+	recordings, err := store.List(ctx, Query{Namespace: "shop"})
+	if err != nil || len(recordings) != 2 {
+		t.Fatalf("List() = %+v, %v", recordings, err)
+	}
+	if recordings[0].ID != second.ID || recordings[0].Size != 12 || recordings[0].EndedAt != nil {
+		t.Errorf("in-progress recording = %+v", recordings[0])
+	}
+	if recordings[1].EndedAt == nil || !recordings[1].EndedAt.Equal(ended) {
+		t.Errorf("finished recording = %+v", recordings[1])
+	}
+	if recordings, _ := store.List(ctx, Query{User: "bob"}); len(recordings) != 1 || recordings[0].User != "bob" {
+		t.Errorf("List(user) = %+v", recordings)
+	}
+
+	data, meta, err := store.Open(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	content, _ := io.ReadAll(data)
+	data.Close()
+	if string(content) != "header\n" || meta.User != "alice" {
+		t.Errorf("Open() = %q, %+v", content, meta)
+	}
+
+	for _, id := range []string{"../../etc/passwd", "missing", "ffffffffffffffffffffffff"} {
+		if _, _, err := store.Open(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Open(%q) error = %v, want %v", id, err, ErrNotFound)
+		}
+	}
+
+	if err := store.Delete(ctx, first.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, first.ID+castExtension)); !os.IsNotExist(err) {
+		t.Errorf("recording file not deleted: %v", err)
+	}
+	if recordings, _ := store.List(ctx, Query{}); len(recordings) != 1 {
+		t.Errorf("List() after Delete = %+v", recordings)
+	}
+	open.Finish(second)
+}
@@ -0,0 +1,140 @@
+package recording
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// downloadTimeout bounds the time spent sending one recording
+const downloadTimeout = 5 * time.Minute
+
+// ListRecordingsHandler returns the exec session recordings, newest first
+//
+// @Summary Grabaciones de sesiones exec
+// @Description Lista las sesiones de terminal grabadas (usuario, cluster, pod, contenedor, inicio, fin y tamaño) con filtros
+// @Tags recordings
+// @Security Bearer
+// @Produce json
+// @Param user query string false "Usuario"
+// @Param cluster query string false "Cluster"
+// @Param namespace query string false "Namespace"
+// @Param pod query string false "Pod"
+// @Param since query string false "Iniciadas desde (RFC3339)"
+// @Param until query string false "Iniciadas hasta (RFC3339)"
+// @Success 200 {object} models.ExecRecordingList "Grabaciones"
+// @Failure 400 {object} map[string]string "Filtro inválido"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Router /api/admin/recordings [get]
+func (s *Service) ListRecordingsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q, err := parseQuery(r.URL.Query())
+	if err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	recordings, err := s.List(r.Context(), q)
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to list session recordings", http.StatusInternalServerError, nil)
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, models.ExecRecordingList{Enabled: s.Enabled(), Recordings: recordings})
+}
+
+// DownloadRecordingHandler returns one exec session recording as an asciicast v2 file,
+// playable with asciinema or asciinema-player
+//
+// @Summary Descargar grabación de sesión exec
+// @Description Descarga una sesión de terminal grabada en formato asciicast v2
+// @Tags recordings
+// @Security Bearer
+// @Produce application/x-asciicast
+// @Param id query string true "ID de la grabación"
+// @Success 200 {file} file "Grabación asciicast"
+// @Failure 403 {object} map[string]string "Se requiere admin"
+// @Failure 404 {object} map[string]string "Grabación no encontrada"
+// @Router /api/admin/recordings/download [get]
+func (s *Service) DownloadRecordingHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		utils.ErrorResponse(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	data, meta, err := s.Open(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		utils.ErrorResponse(w, http.StatusNotFound, "Recording not found")
+		return
+	}
+	if err != nil {
+		utils.HandleErrorJSON(w, err, "Failed to open session recording", http.StatusInternalServerError, map[string]interface{}{
+			"id": id,
+		})
+		return
+	}
+	defer data.Close()
+
+	// Watching who did what in a session is itself worth auditing
+	utils.AuditLog(r, "download", "ExecRecording", meta.ID, meta.Namespace, true, nil, map[string]interface{}{
+		"sessionUser": meta.User,
+		"pod":         meta.Pod,
+		"container":   meta.Container,
+	})
+
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(downloadTimeout))
+	fileName := fmt.Sprintf("%s-%s-%s.cast", meta.Namespace, meta.Pod, meta.StartedAt.UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(w, data); err != nil {
+		utils.LogError(err, "Failed to send session recording", map[string]interface{}{
+			"id": id,
+		})
+	}
+}
+
+// parseQuery reads the recording filters from the query string.
+// The "cluster" parameter filters recordings; it does not select the cluster to talk to here.
+func parseQuery(values url.Values) (Query, error) {
+	q := Query{
+		User:      values.Get("user"),
+		Cluster:   values.Get("cluster"),
+		Namespace: values.Get("namespace"),
+		Pod:       values.Get("pod"),
+	}
+	var err error
+	if q.Since, err = parseTime(values.Get("since"), "since"); err != nil {
+		return q, err
+	}
+	if q.Until, err = parseTime(values.Get("until"), "until"); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func parseTime(value, param string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+	}
+	return t, nil
+}
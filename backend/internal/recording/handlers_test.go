@@ -0,0 +1,104 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestHandlerService(t *testing.T) (*Service, string) {
+	t.Helper()
+	service := NewService(NewMemoryStore(0), 0, 0)
+	var id string
+	for _, meta := range []models.ExecRecording{
+		{User: "alice", Cluster: "default", Namespace: "shop", Pod: "web-1"},
+		{User: "bob", Cluster: "default", Namespace: "billing", Pod: "api-1"},
+	} {
+		recorder, err := service.Start(context.Background(), meta)
+		if err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		recorder.Output([]byte("$ "))
+		recorder.Close()
+		if meta.User == "alice" {
+			id = recorder.ID()
+		}
+	}
+	return service, id
+}
+
+func TestListRecordingsHandler(t *testing.T) {
+	service, id := newTestHandlerService(t)
+
+	rr := httptest.NewRecorder()
+	service.ListRecordingsHandler(rr, httptest.NewRequest(http.MethodGet, "/api/admin/recordings?namespace=shop", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	var list models.ExecRecordingList
+	if err := json.NewDecoder(rr.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !list.Enabled || len(list.Recordings) != 1 || list.Recordings[0].ID != id || list.Recordings[0].EndedAt == nil {
+		t.Errorf("list = %+v", list)
+	}
+
+	for _, tt := range []struct {
+		method, query string
+		want          int
+	}{
+		{http.MethodGet, "since=yesterday", http.StatusBadRequest},
+		{http.MethodPost, "", http.StatusMethodNotAllowed},
+	} {
+		rr := httptest.NewRecorder()
+		service.ListRecordingsHandler(rr, httptest.NewRequest(tt.method, "/api/admin/recordings?"+tt.query, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s %s: status = %d, want %d", tt.method, tt.query, rr.Code, tt.want)
+		}
+	}
+
+	// A disabled service lists nothing but says so
+	rr = httptest.NewRecorder()
+	NewService(nil, 0, 0).ListRecordingsHandler(rr, httptest.NewRequest(http.MethodGet, "/api/admin/recordings", nil))
+	if body := rr.Body.String(); !strings.Contains(body, `"enabled":false`) || !strings.Contains(body, `"recordings":[]`) {
+		t.Errorf("disabled list = %s", body)
+	}
+}
+
+func TestDownloadRecordingHandler(t *testing.T) {
+	service, id := newTestHandlerService(t)
+
+	rr := httptest.NewRecorder()
+	service.DownloadRecordingHandler(rr, httptest.NewRequest(http.MethodGet, "/api/admin/recordings/download?id="+id, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if got := rr.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := rr.Header().Get("Content-Disposition"); !strings.HasPrefix(got, `attachment; filename="shop-web-1-`) || !strings.HasSuffix(got, `.cast"`) {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	if lines := strings.Split(strings.TrimSpace(rr.Body.String()), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[0], `{"version":2,`) {
+		t.Errorf("body = %q", rr.Body.String())
+	}
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"", http.StatusBadRequest},
+		{"id=ffffffffffffffffffffffff", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		service.DownloadRecordingHandler(rr, httptest.NewRequest(http.MethodGet, "/api/admin/recordings/download?"+tt.query, nil))
+		if rr.Code != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.query, rr.Code, tt.want)
+		}
+	}
+}
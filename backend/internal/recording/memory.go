@@ -0,0 +1,107 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+// defaultMemorySize is the number of recordings kept by the in-memory store
+const defaultMemorySize = 50
+
+// MemoryStore keeps the most recent recordings in memory.
+// Recordings are lost on restart and not shared between replicas.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	size       int
+	recordings map[string]*memoryRecording
+}
+
+type memoryRecording struct {
+	meta models.ExecRecording
+	data bytes.Buffer
+}
+
+// NewMemoryStore creates a store holding up to size recordings
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = defaultMemorySize
+	}
+	return &MemoryStore{size: size, recordings: make(map[string]*memoryRecording)}
+}
+
+// Create starts a recording, dropping the oldest one when the store is full
+func (s *MemoryStore) Create(ctx context.Context, meta models.ExecRecording) (Writer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.recordings) >= s.size {
+		oldest := ""
+		for id := range s.recordings {
+			if oldest == "" || id < oldest {
+				oldest = id
+			}
+		}
+		delete(s.recordings, oldest)
+	}
+	rec := &memoryRecording{meta: meta}
+	s.recordings[meta.ID] = rec
+	return &memoryWriter{store: s, rec: rec}, nil
+}
+
+// List returns the recordings matching q, newest first
+func (s *MemoryStore) List(ctx context.Context, q Query) ([]models.ExecRecording, error) {
+	s.mu.RLock()
+	recordings := make([]models.ExecRecording, 0, len(s.recordings))
+	for _, rec := range s.recordings {
+		recordings = append(recordings, rec.meta)
+	}
+	s.mu.RUnlock()
+
+	return filterRecordings(recordings, q), nil
+}
+
+// Open returns a copy of the data recorded so far
+func (s *MemoryStore) Open(ctx context.Context, id string) (io.ReadCloser, *models.ExecRecording, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, ok := s.recordings[id]
+	if !ok {
+		return nil, nil, ErrNotFound
+	}
+	meta := rec.meta
+	data := bytes.Clone(rec.data.Bytes())
+	return io.NopCloser(bytes.NewReader(data)), &meta, nil
+}
+
+// Delete removes a recording
+func (s *MemoryStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.recordings, id)
+	return nil
+}
+
+// memoryWriter appends to a recording of a MemoryStore
+type memoryWriter struct {
+	store *MemoryStore
+	rec   *memoryRecording
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.rec.meta.Size += int64(len(p))
+	return w.rec.data.Write(p)
+}
+
+func (w *memoryWriter) Finish(meta models.ExecRecording) error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.rec.meta = meta
+	return nil
+}
@@ -0,0 +1,41 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestMemoryStore_DropsOldest(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(2)
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		meta := models.ExecRecording{ID: newRecordingID(start.Add(time.Duration(i) * time.Minute)), Pod: "web-1", StartedAt: start}
+		writer, err := store.Create(ctx, meta)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		writer.Write([]byte("data\n"))
+		ids = append(ids, meta.ID)
+	}
+
+	recordings, _ := store.List(ctx, Query{})
+	if len(recordings) != 2 || recordings[0].ID != ids[2] || recordings[1].ID != ids[1] || recordings[0].Size != 5 {
+		t.Errorf("List() = %+v", recordings)
+	}
+	if _, _, err := store.Open(ctx, ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Open(oldest) error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestNewMemoryStore_DefaultSize(t *testing.T) {
+	if store := NewMemoryStore(0); store.size != defaultMemorySize {
+		t.Errorf("size = %d, want %d", store.size, defaultMemorySize)
+	}
+}
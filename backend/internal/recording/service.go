@@ -0,0 +1,155 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// pruneInterval is how often recordings past the retention period are deleted
+const pruneInterval = time.Hour
+
+// Service records exec sessions in a Store, applies the retention policy and serves the
+// recordings to admins. A Service without a store has recording disabled.
+type Service struct {
+	store     Store
+	err       error // set when recording is configured but the store could not be created
+	retention time.Duration
+	maxSize   int64
+}
+
+// NewService creates a recording service backed by store; a nil store disables recording.
+// Recordings older than retention are deleted by Run (0 keeps them forever), and recordings
+// stop growing at maxSize bytes (0 means unlimited).
+func NewService(store Store, retention time.Duration, maxSize int64) *Service {
+	return &Service{store: store, retention: retention, maxSize: maxSize}
+}
+
+// NewServiceFromEnv creates a recording service with the store selected by the environment
+// (see ConfigFromEnv). If the store cannot be created, the service fails closed: every exec
+// session is refused instead of running unrecorded.
+func NewServiceFromEnv() *Service {
+	cfg := ConfigFromEnv()
+	store, err := NewStore(cfg)
+	if err != nil {
+		utils.LogError(err, "Failed to initialize the session recording store, exec sessions are refused", map[string]interface{}{
+			"store": cfg.Store,
+		})
+		return &Service{err: fmt.Errorf("%w: %v", ErrUnavailable, err), retention: cfg.Retention, maxSize: cfg.MaxSize}
+	}
+	if store != nil {
+		utils.LogInfo("Exec session recording enabled", map[string]interface{}{
+			"store":     cfg.Store,
+			"retention": cfg.Retention.String(),
+		})
+	}
+	return NewService(store, cfg.Retention, cfg.MaxSize)
+}
+
+// Enabled reports whether exec sessions are recorded
+func (s *Service) Enabled() bool {
+	return s != nil && s.store != nil
+}
+
+// Start begins the recording of a session described by meta; ID and StartedAt are filled in.
+// It returns a nil Recorder when recording is disabled, and ErrUnavailable when recording is
+// configured but its store could not be created.
+func (s *Service) Start(ctx context.Context, meta models.ExecRecording) (*Recorder, error) {
+	if s != nil && s.err != nil {
+		return nil, s.err
+	}
+	if !s.Enabled() {
+		return nil, nil
+	}
+	meta.StartedAt = time.Now().UTC()
+	meta.ID = newRecordingID(meta.StartedAt)
+	meta.Size, meta.EndedAt, meta.Truncated = 0, nil, false
+
+	writer, err := s.store.Create(ctx, meta)
+	if err != nil {
+		return nil, err
+	}
+	recorder, err := newRecorder(writer, meta, s.maxSize)
+	if err != nil {
+		_ = writer.Finish(meta)
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// List returns the recordings matching q, newest first
+func (s *Service) List(ctx context.Context, q Query) ([]models.ExecRecording, error) {
+	if s != nil && s.err != nil {
+		return nil, s.err
+	}
+	if !s.Enabled() {
+		return []models.ExecRecording{}, nil
+	}
+	return s.store.List(ctx, q)
+}
+
+// Open returns the data and the metadata of a recording
+func (s *Service) Open(ctx context.Context, id string) (io.ReadCloser, *models.ExecRecording, error) {
+	if !s.Enabled() {
+		return nil, nil, ErrNotFound
+	}
+	return s.store.Open(ctx, id)
+}
+
+// Prune deletes the recordings of sessions that ended, or started if they never ended, before
+// the retention period, and returns how many were deleted
+func (s *Service) Prune(ctx context.Context, now time.Time) (int, error) {
+	if !s.Enabled() || s.retention <= 0 {
+		return 0, nil
+	}
+	cutoff := now.Add(-s.retention)
+	recordings, err := s.store.List(ctx, Query{})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, meta := range recordings {
+		last := meta.StartedAt
+		if meta.EndedAt != nil {
+			last = *meta.EndedAt
+		}
+		if !last.Before(cutoff) {
+			continue
+		}
+		if err := s.store.Delete(ctx, meta.ID); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Run applies the retention policy periodically until ctx is cancelled
+func (s *Service) Run(ctx context.Context) {
+	if !s.Enabled() || s.retention <= 0 {
+		return
+	}
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.Prune(ctx, time.Now())
+		if err != nil {
+			utils.LogError(err, "Failed to apply the session recording retention policy", nil)
+		} else if deleted > 0 {
+			utils.LogInfo("Deleted expired session recordings", map[string]interface{}{
+				"count":     deleted,
+				"retention": s.retention.String(),
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
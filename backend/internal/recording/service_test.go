@@ -0,0 +1,88 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestService_Prune(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	service := NewService(store, 24*time.Hour, 0)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+
+	add := func(started time.Time, ended *time.Time) string {
+		meta := models.ExecRecording{ID: newRecordingID(started), Pod: "web-1", StartedAt: started}
+		writer, _ := store.Create(ctx, meta)
+		if ended != nil {
+			meta.EndedAt = ended
+			writer.Finish(meta)
+		}
+		return meta.ID
+	}
+	oldEnd := now.Add(-48 * time.Hour)
+	recentEnd := now.Add(-time.Hour)
+	expired := add(now.Add(-49*time.Hour), &oldEnd)
+	endedRecently := add(now.Add(-30*time.Hour), &recentEnd) // long session, ended within the period
+	abandoned := add(now.Add(-72*time.Hour), nil)            // never completed, e.g. the server crashed
+	running := add(now.Add(-time.Minute), nil)
+
+	deleted, err := service.Prune(ctx, now)
+	if err != nil || deleted != 2 {
+		t.Fatalf("Prune() = %d, %v, want 2", deleted, err)
+	}
+	kept := map[string]bool{}
+	recordings, _ := service.List(ctx, Query{})
+	for _, meta := range recordings {
+		kept[meta.ID] = true
+	}
+	if kept[expired] || kept[abandoned] || !kept[endedRecently] || !kept[running] {
+		t.Errorf("kept = %v", kept)
+	}
+
+	// Without a retention period nothing is deleted
+	if deleted, _ := NewService(store, 0, 0).Prune(ctx, now.Add(1000*time.Hour)); deleted != 0 {
+		t.Errorf("Prune() without retention deleted %d", deleted)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("EXEC_RECORDING_STORE", "File")
+	t.Setenv("EXEC_RECORDING_DIR", t.TempDir())
+	t.Setenv("EXEC_RECORDING_RETENTION", "168h")
+	t.Setenv("EXEC_RECORDING_MAX_SIZE_MB", "5")
+
+	cfg := ConfigFromEnv()
+	if cfg.Store != StoreFile || cfg.Retention != 168*time.Hour || cfg.MaxSize != 5<<20 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+	if service := NewServiceFromEnv(); !service.Enabled() {
+		t.Error("file store not enabled")
+	}
+
+	t.Setenv("EXEC_RECORDING_RETENTION", "30 days")
+	if cfg := ConfigFromEnv(); cfg.Retention != defaultRetention {
+		t.Errorf("invalid retention not defaulted: %v", cfg.Retention)
+	}
+
+	t.Setenv("EXEC_RECORDING_STORE", "")
+	if service := NewServiceFromEnv(); service.Enabled() {
+		t.Error("recording enabled without a store")
+	}
+	t.Setenv("EXEC_RECORDING_STORE", "s3")
+	if _, err := NewStore(ConfigFromEnv()); err == nil {
+		t.Error("NewStore() accepted an unknown store")
+	}
+	// A configured store that cannot be created refuses every session instead of disabling recording
+	service := NewServiceFromEnv()
+	if _, err := service.Start(context.Background(), models.ExecRecording{}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Start() error = %v with an unknown store, want ErrUnavailable", err)
+	}
+	if _, err := service.List(context.Background(), Query{}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("List() error = %v with an unknown store, want ErrUnavailable", err)
+	}
+}
@@ -0,0 +1,96 @@
+package recording
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+var (
+	// ErrNotFound reports a recording that does not exist or was removed by the retention policy
+	ErrNotFound = errors.New("recording not found")
+	// ErrUnavailable reports that recording is configured but its store could not be created
+	ErrUnavailable = errors.New("session recording is unavailable")
+	// ErrMaxSize reports a recording that reached its maximum size
+	ErrMaxSize = errors.New("session recording reached its maximum size")
+)
+
+// recordingIDPattern matches the IDs created by newRecordingID
+var recordingIDPattern = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+// Store persists exec session recordings
+type Store interface {
+	// Create starts a recording described by meta and returns the writer receiving its data
+	Create(ctx context.Context, meta models.ExecRecording) (Writer, error)
+	// List returns the recordings matching q, newest first
+	List(ctx context.Context, q Query) ([]models.ExecRecording, error)
+	// Open returns the data and the metadata of a recording, or ErrNotFound
+	Open(ctx context.Context, id string) (io.ReadCloser, *models.ExecRecording, error)
+	// Delete removes a recording; removing a missing recording is not an error
+	Delete(ctx context.Context, id string) error
+}
+
+// Writer receives the asciicast data of one recording
+type Writer interface {
+	io.Writer
+	// Finish stores the final metadata of the recording (end time, size) and releases the writer
+	Finish(meta models.ExecRecording) error
+}
+
+// Query filters recordings. Empty fields match everything.
+type Query struct {
+	User      string
+	Cluster   string
+	Namespace string
+	Pod       string
+	Since     time.Time // started at or after
+	Until     time.Time // started at or before
+}
+
+// Matches reports whether a recording passes the filters
+func (q Query) Matches(meta models.ExecRecording) bool {
+	switch {
+	case q.User != "" && meta.User != q.User,
+		q.Cluster != "" && meta.Cluster != q.Cluster,
+		q.Namespace != "" && meta.Namespace != q.Namespace,
+		q.Pod != "" && meta.Pod != q.Pod,
+		!q.Since.IsZero() && meta.StartedAt.Before(q.Since),
+		!q.Until.IsZero() && meta.StartedAt.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// newRecordingID returns an ID that sorts by time: the hex Unix nanoseconds plus random bytes
+func newRecordingID(t time.Time) string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Sprintf("%016x00000000", t.UnixNano())
+	}
+	return fmt.Sprintf("%016x%s", t.UnixNano(), hex.EncodeToString(suffix))
+}
+
+// validRecordingID reports whether id can name a recording; it keeps IDs from escaping the store
+func validRecordingID(id string) bool {
+	return recordingIDPattern.MatchString(id)
+}
+
+// filterRecordings keeps the recordings matching q and sorts them newest first
+func filterRecordings(recordings []models.ExecRecording, q Query) []models.ExecRecording {
+	matching := make([]models.ExecRecording, 0, len(recordings))
+	for _, meta := range recordings {
+		if q.Matches(meta) {
+			matching = append(matching, meta)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].ID > matching[j].ID })
+	return matching
+}
@@ -4,7 +4,9 @@ import (
 	"net/http"
 
 	"github.com/flaucha/DKonsole/backend/internal/api"
+	"github.com/flaucha/DKonsole/backend/internal/audit"
 	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/cache"
 	"github.com/flaucha/DKonsole/backend/internal/cluster"
 	"github.com/flaucha/DKonsole/backend/internal/helm"
 	"github.com/flaucha/DKonsole/backend/internal/k8s"
@@ -12,10 +14,13 @@ import (
 	"github.com/flaucha/DKonsole/backend/internal/logo"
 	"github.com/flaucha/DKonsole/backend/internal/middleware"
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/oidc"
 	"github.com/flaucha/DKonsole/backend/internal/permissions"
 	"github.com/flaucha/DKonsole/backend/internal/pod"
 	"github.com/flaucha/DKonsole/backend/internal/prometheus"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 	"github.com/flaucha/DKonsole/backend/internal/settings"
+	"github.com/flaucha/DKonsole/backend/internal/users"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 )
 
@@ -23,8 +28,13 @@ import (
 type Dependencies struct {
 	AuthService       *auth.Service
 	LDAPService       *ldap.Service
+	OIDCService       *oidc.Service
 	ClusterService    *cluster.Service
+	UsersService      *users.Service
+	AuditService      *audit.Service
+	RecordingService  *recording.Service
 	K8sService        *k8s.Service
+	ResourceCache     *cache.Manager
 	APIService        *api.Service
 	HelmService       *helm.Service
 	PodService        *pod.Service
@@ -43,7 +53,7 @@ func NewRouter(deps Dependencies) *http.ServeMux {
 	ldapService := deps.LDAPService
 
 	secure := func(h http.HandlerFunc) http.HandlerFunc {
-		return middleware.SecurityHeadersMiddleware(enableCors(middleware.RateLimitMiddleware(middleware.CSRFMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(h))))))
+		return middleware.SecurityHeadersMiddleware(enableCors(middleware.RateLimitMiddleware(middleware.CSRFMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(clusterScope(h)))))))
 	}
 
 	secureHandler := func(h http.Handler) http.Handler {
@@ -51,7 +61,7 @@ func NewRouter(deps Dependencies) *http.ServeMux {
 			h.ServeHTTP(w, r)
 		}
 		return middleware.SecurityHeadersHandler(http.HandlerFunc(
-			enableCors(middleware.RateLimitMiddleware(middleware.CSRFMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(handlerFunc)))))),
+			enableCors(middleware.RateLimitMiddleware(middleware.CSRFMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(clusterScope(handlerFunc))))))),
 		)
 	}
 
@@ -60,24 +70,10 @@ func NewRouter(deps Dependencies) *http.ServeMux {
 	}
 
 	secureWS := func(h http.HandlerFunc) http.HandlerFunc {
-		return middleware.SecurityHeadersMiddleware(middleware.RateLimitMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(h))))
+		return middleware.SecurityHeadersMiddleware(middleware.RateLimitMiddleware(middleware.AuditMiddleware(authService.AuthMiddleware(clusterScope(h)))))
 	}
 
-	adminOnly := func(h http.HandlerFunc) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			ctx := r.Context()
-			isAdmin, err := permissions.IsAdmin(ctx, ldapService)
-			if err != nil {
-				utils.HandleErrorJSON(w, err, "Failed to check admin status", http.StatusInternalServerError, nil)
-				return
-			}
-			if !isAdmin {
-				utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
-				return
-			}
-			h(w, r)
-		}
-	}
+	adminOnly := requireAdmin(ldapService)
 
 	config := RouterConfig{
 		Deps:          deps,
@@ -91,6 +87,10 @@ func NewRouter(deps Dependencies) *http.ServeMux {
 
 	registerRoutes(config)
 	registerHealthRoutes(config)
+	registerClusterRoutes(config)
+	registerUserRoutes(config)
+	registerAuditRoutes(config)
+	registerCacheRoutes(config)
 	registerK8sRoutes(config)
 	registerAPIRoutes(config)
 	registerHelmRoutes(config)
@@ -100,3 +100,30 @@ func NewRouter(deps Dependencies) *http.ServeMux {
 
 	return mux
 }
+
+// clusterScope scopes permission checks to the cluster selected with the ?cluster= query parameter
+func clusterScope(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := permissions.WithCluster(r.Context(), r.URL.Query().Get("cluster"))
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// requireAdmin returns the middleware of admin-only routes: core admins, and LDAP admin group
+// members as reported by ldapAdminChecker
+func requireAdmin(ldapAdminChecker permissions.LDAPAdminChecker) func(http.HandlerFunc) http.HandlerFunc {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			isAdmin, err := permissions.IsAdmin(r.Context(), ldapAdminChecker)
+			if err != nil {
+				utils.HandleErrorJSON(w, err, "Failed to check admin status", http.StatusInternalServerError, nil)
+				return
+			}
+			if !isAdmin {
+				utils.ErrorResponse(w, http.StatusForbidden, "Admin access required")
+				return
+			}
+			h(w, r)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -12,10 +13,15 @@ import (
 	"github.com/flaucha/DKonsole/backend/internal/models"
 )
 
-func addAuthHeader(t *testing.T, req *http.Request, claims models.Claims) *http.Request {
+func addAuthHeader(t *testing.T, req *http.Request, authService *auth.Service, claims models.Claims) *http.Request {
+	// Tokens must belong to an active server-side session
+	session, _, err := authService.Sessions().Create(context.Background(), claims, auth.SessionMeta{})
+	if err != nil {
+		t.Fatalf("failed to create session: %v", err)
+	}
 	authClaims := &auth.AuthClaims{
 		Claims:           claims,
-		RegisteredClaims: jwt.RegisteredClaims{},
+		RegisteredClaims: jwt.RegisteredClaims{ID: session.ID},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, authClaims)
 	tokenString, err := token.SignedString([]byte(strings.Repeat("a", 32))) // Matches newTestRouter secret
@@ -28,11 +34,11 @@ func addAuthHeader(t *testing.T, req *http.Request, claims models.Claims) *http.
 }
 
 func TestRouter_HelmRoutes_Coverage(t *testing.T) {
-	router, _ := newTestRouter(t)
+	router, _, authService := newTestRouter(t)
 
 	// GET /api/helm/releases
 	req := httptest.NewRequest(http.MethodGet, "/api/helm/releases", nil)
-	req = addAuthHeader(t, req, models.Claims{Username: "admin", Role: "admin"})
+	req = addAuthHeader(t, req, authService, models.Claims{Username: "admin", Role: "admin"})
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	// Even if it returns error (due to empty context/fake client), it covers the route registration
@@ -43,7 +49,7 @@ func TestRouter_HelmRoutes_Coverage(t *testing.T) {
 
 	// POST /api/helm/releases/install
 	req = httptest.NewRequest(http.MethodPost, "/api/helm/releases/install", nil)
-	req = addAuthHeader(t, req, models.Claims{Username: "admin", Role: "admin"})
+	req = addAuthHeader(t, req, authService, models.Claims{Username: "admin", Role: "admin"})
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if rr.Code == http.StatusNotFound {
@@ -52,7 +58,7 @@ func TestRouter_HelmRoutes_Coverage(t *testing.T) {
 
 	// Method Not Allowed checks (indirectly covers the "else" branches in handlers)
 	req = httptest.NewRequest(http.MethodPut, "/api/helm/releases/install", nil)
-	req = addAuthHeader(t, req, models.Claims{Username: "admin", Role: "admin"})
+	req = addAuthHeader(t, req, authService, models.Claims{Username: "admin", Role: "admin"})
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -60,7 +66,7 @@ func TestRouter_HelmRoutes_Coverage(t *testing.T) {
 	}
 
 	req = httptest.NewRequest(http.MethodPut, "/api/helm/releases", nil) // Only GET, DELETE, POST allowed
-	req = addAuthHeader(t, req, models.Claims{Username: "admin", Role: "admin"})
+	req = addAuthHeader(t, req, authService, models.Claims{Username: "admin", Role: "admin"})
 	rr = httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if rr.Code != http.StatusMethodNotAllowed {
@@ -69,7 +75,7 @@ func TestRouter_HelmRoutes_Coverage(t *testing.T) {
 }
 
 func TestRouter_OtherRoutes_Coverage(t *testing.T) {
-	router, _ := newTestRouter(t)
+	router, _, authService := newTestRouter(t)
 
 	// Logo
 	endpoints := []struct {
@@ -112,10 +118,73 @@ func TestRouter_OtherRoutes_Coverage(t *testing.T) {
 
 	// Method Not Allowed for Logo (PUT not allowed)
 	req := httptest.NewRequest(http.MethodPut, "/api/logo", nil)
-	req = addAuthHeader(t, req, models.Claims{Username: "admin", Role: "admin"})
+	req = addAuthHeader(t, req, authService, models.Claims{Username: "admin", Role: "admin"})
 	rr := httptest.NewRecorder()
 	router.ServeHTTP(rr, req)
 	if rr.Code != http.StatusMethodNotAllowed {
 		t.Errorf("PUT /api/logo should be 405, got %d", rr.Code)
 	}
 }
+
+func TestRouter_AccountRoutesRejectAPITokens(t *testing.T) {
+	router, _, authService := newTestRouter(t)
+	tokens := authService.APITokens()
+	if tokens == nil {
+		t.Fatal("API tokens not available")
+	}
+	_, token, err := tokens.Create(context.Background(), models.Claims{Username: "admin", Role: "admin", IDP: "core"}, "ci", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodPost, "/api/auth/change-password"},
+		{http.MethodGet, "/api/auth/sessions"},
+		{http.MethodDelete, "/api/auth/sessions"},
+		{http.MethodGet, "/api/admin/sessions"},
+		{http.MethodDelete, "/api/admin/sessions"},
+		{http.MethodGet, "/api/auth/tokens"},
+		{http.MethodPost, "/api/auth/tokens"},
+		{http.MethodDelete, "/api/auth/tokens"},
+		{http.MethodGet, "/api/admin/tokens"},
+		{http.MethodDelete, "/api/admin/tokens"},
+		{http.MethodGet, "/api/auth/totp"},
+		{http.MethodPost, "/api/auth/totp/enroll"},
+		{http.MethodPost, "/api/auth/totp/confirm"},
+		{http.MethodPost, "/api/auth/totp/disable"},
+		{http.MethodPost, "/api/auth/totp/recovery-codes"},
+		{http.MethodDelete, "/api/admin/totp"},
+		{http.MethodPost, "/api/clusters"},
+		{http.MethodDelete, "/api/clusters"},
+		{http.MethodPost, "/api/clusters/test"},
+		{http.MethodGet, "/api/users"},
+		{http.MethodPost, "/api/users"},
+		{http.MethodGet, "/api/ldap/config"},
+		{http.MethodPut, "/api/ldap/credentials"},
+		{http.MethodPost, "/api/ldap/test"},
+		{http.MethodGet, "/api/oidc/config"},
+		{http.MethodPut, "/api/oidc/groups"},
+	}
+	for _, route := range routes {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Origin", "http://example.com") // Satisfy CSRF check
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), "API tokens") {
+			t.Errorf("%s %s with API token = %d %s, want 403", route.method, route.path, rr.Code, rr.Body.String())
+		}
+	}
+
+	// The same token still works on the routes it is meant for
+	req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Errorf("GET /api/me with API token = %d, want 200", rr.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/flaucha/DKonsole/backend/internal/auth"
 	"github.com/flaucha/DKonsole/backend/internal/health"
 	"github.com/flaucha/DKonsole/backend/internal/middleware"
 
@@ -36,7 +37,69 @@ func registerRoutes(c RouterConfig) {
 	c.Mux.HandleFunc("/api/login", middleware.SecurityHeadersMiddleware(enableCors(middleware.LoginRateLimitMiddleware(middleware.AuditMiddleware(c.Deps.AuthService.LoginHandler)))))
 	c.Mux.HandleFunc("/api/logout", c.Secure(c.Deps.AuthService.LogoutHandler))
 	c.Mux.HandleFunc("/api/me", c.Secure(c.Deps.AuthService.MeHandler))
-	c.Mux.HandleFunc("/api/auth/change-password", c.Secure(c.Deps.AuthService.ChangePasswordHandler))
+	c.Mux.HandleFunc("/api/auth/change-password", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.ChangePasswordHandler)))
+
+	// Sessions: the refresh endpoint authenticates with the refresh cookie instead of the access token
+	c.Mux.HandleFunc("/api/auth/refresh", middleware.SecurityHeadersMiddleware(enableCors(middleware.RateLimitMiddleware(middleware.CSRFMiddleware(middleware.AuditMiddleware(c.Deps.AuthService.RefreshHandler))))))
+	c.Mux.HandleFunc("/api/auth/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(auth.InteractiveOnly(c.Deps.AuthService.ListSessionsHandler))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(auth.InteractiveOnly(c.Deps.AuthService.RevokeSessionHandler))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	c.Mux.HandleFunc("/api/admin/sessions", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.AuthService.AdminListSessionsHandler)))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.AuthService.AdminRevokeSessionsHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Personal API tokens for scripts and CI, sent as "Authorization: Bearer dkp_..."
+	c.Mux.HandleFunc("/api/auth/tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(auth.InteractiveOnly(c.Deps.AuthService.ListAPITokensHandler))(w, r)
+		} else if r.Method == http.MethodPost {
+			c.Secure(auth.InteractiveOnly(c.Deps.AuthService.CreateAPITokenHandler))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(auth.InteractiveOnly(c.Deps.AuthService.RevokeAPITokenHandler))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	c.Mux.HandleFunc("/api/admin/tokens", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.AuthService.AdminListAPITokensHandler)))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.AuthService.AdminRevokeAPITokensHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// Two-factor: the second login step shares the login rate limit
+	c.Mux.HandleFunc("/api/login/mfa", middleware.SecurityHeadersMiddleware(enableCors(middleware.LoginRateLimitMiddleware(middleware.AuditMiddleware(c.Deps.AuthService.LoginMFAHandler)))))
+	c.Mux.HandleFunc("/api/auth/totp", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.TOTPStatusHandler)))
+	c.Mux.HandleFunc("/api/auth/totp/enroll", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.TOTPEnrollHandler)))
+	c.Mux.HandleFunc("/api/auth/totp/confirm", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.TOTPConfirmHandler)))
+	c.Mux.HandleFunc("/api/auth/totp/disable", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.TOTPDisableHandler)))
+	c.Mux.HandleFunc("/api/auth/totp/recovery-codes", c.Secure(auth.InteractiveOnly(c.Deps.AuthService.TOTPRecoveryCodesHandler)))
+	c.Mux.HandleFunc("/api/admin/totp", c.Secure(c.AdminOnly(auth.InteractiveOnly(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			c.Deps.AuthService.AdminResetTOTPHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))
+
+	// OIDC login is a browser redirect flow: the callback gets the stricter login rate limit
+	c.Mux.HandleFunc("/api/auth/oidc/login", c.Public(c.Deps.AuthService.OIDCLoginHandler))
+	c.Mux.HandleFunc("/api/auth/oidc/callback", middleware.SecurityHeadersMiddleware(middleware.LoginRateLimitMiddleware(middleware.AuditMiddleware(c.Deps.AuthService.OIDCCallbackHandler))))
 
 	// Swagger documentation - protected with authentication
 	c.Mux.Handle("/swagger/", c.SecureHandler(httpSwagger.WrapHandler))
@@ -103,8 +166,62 @@ func registerHealthRoutes(c RouterConfig) {
 	}))
 }
 
+func registerClusterRoutes(c RouterConfig) {
+	// Listing is available to every authenticated user (no credentials are returned);
+	// registering and removing clusters requires admin.
+	c.Mux.HandleFunc("/api/clusters", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.Deps.ClusterService.ListClustersHandler)(w, r)
+		} else if r.Method == http.MethodPost {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.ClusterService.RegisterClusterHandler)))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.ClusterService.DeleteClusterHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	c.Mux.HandleFunc("/api/clusters/test", c.Secure(c.AdminOnly(auth.InteractiveOnly(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			c.Deps.ClusterService.TestClusterHandler(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))))
+}
+
+func registerUserRoutes(c RouterConfig) {
+	// Local user management is admin-only and needs an interactive session, not an API token
+	c.Mux.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.UsersService.ListUsersHandler)))(w, r)
+		} else if r.Method == http.MethodPost {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.UsersService.CreateUserHandler)))(w, r)
+		} else if r.Method == http.MethodPut {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.UsersService.UpdateUserHandler)))(w, r)
+		} else if r.Method == http.MethodDelete {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.UsersService.DeleteUserHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func registerAuditRoutes(c RouterConfig) {
+	// The audit log tells who did what to which resource; only admins may read it
+	c.Mux.HandleFunc("/api/audit", c.Secure(c.AdminOnly(c.Deps.AuditService.ListAuditHandler)))
+	// Exec session recordings show everything typed in a pod's terminal; admins only as well
+	c.Mux.HandleFunc("/api/admin/recordings", c.Secure(c.AdminOnly(c.Deps.RecordingService.ListRecordingsHandler)))
+	c.Mux.HandleFunc("/api/admin/recordings/download", c.Secure(c.AdminOnly(c.Deps.RecordingService.DownloadRecordingHandler)))
+}
+
+func registerCacheRoutes(c RouterConfig) {
+	// Informer counts and memory use of the shared resource cache
+	c.Mux.HandleFunc("/api/admin/cache", c.Secure(c.AdminOnly(c.Deps.ResourceCache.StatsHandler)))
+}
+
 func registerK8sRoutes(c RouterConfig) {
 	c.Mux.HandleFunc("/api/namespaces", c.Secure(c.Deps.K8sService.GetNamespaces))
+	c.Mux.HandleFunc("/api/namespaces/export", c.Secure(c.Deps.K8sService.ExportNamespace))
 	c.Mux.HandleFunc("/api/resources", c.Secure(c.Deps.K8sService.GetResources))
 	c.Mux.HandleFunc("/api/resources/watch", c.Secure(c.Deps.K8sService.WatchResources))
 	c.Mux.HandleFunc("/api/resource/yaml", c.Secure(func(w http.ResponseWriter, r *http.Request) {
@@ -116,6 +233,13 @@ func registerK8sRoutes(c RouterConfig) {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	}))
+	c.Mux.HandleFunc("/api/resource/diff", c.Secure(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			c.Deps.K8sService.DiffResourceYAML(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
 	c.Mux.HandleFunc("/api/resource/import", c.Secure(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			c.Deps.K8sService.ImportResourceYAML(w, r)
@@ -124,8 +248,38 @@ func registerK8sRoutes(c RouterConfig) {
 		}
 	}))
 
+	// Apply workflow: validate, server-side dry-run with a diff against the live object, then apply
+	c.Mux.HandleFunc("/api/resource/validate", c.Secure(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			c.Deps.K8sService.ValidateResourceYAML(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	c.Mux.HandleFunc("/api/resource/dry-run", c.Secure(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			c.Deps.K8sService.DryRunResourceYAML(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+	c.Mux.HandleFunc("/api/resource/apply", c.Secure(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			c.Deps.K8sService.ServerSideApply(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}))
+
 	c.Mux.HandleFunc("/api/scale", c.Secure(c.Deps.K8sService.ScaleResource))
 	c.Mux.HandleFunc("/api/deployments/rollout", c.Secure(c.Deps.K8sService.RolloutDeployment))
+	c.Mux.HandleFunc("/api/workloads/restart", c.Secure(c.Deps.K8sService.RolloutDeployment))
+	c.Mux.HandleFunc("/api/rollout/history", c.Secure(c.Deps.K8sService.GetRolloutHistory))
+	c.Mux.HandleFunc("/api/rollout/rollback", c.Secure(c.Deps.K8sService.RollbackRollout))
+	c.Mux.HandleFunc("/api/rollout/pause", c.Secure(c.Deps.K8sService.PauseRollout))
+	c.Mux.HandleFunc("/api/rollout/resume", c.Secure(c.Deps.K8sService.ResumeRollout))
+	c.Mux.HandleFunc("/api/rollout/status", c.Secure(c.Deps.K8sService.StreamRolloutStatus))
+	c.Mux.HandleFunc("/api/bulk", c.Secure(c.Deps.K8sService.BulkOperation))
 	c.Mux.HandleFunc("/api/overview", c.Secure(c.Deps.K8sService.GetClusterStats))
 	c.Mux.HandleFunc("/api/resource", c.Secure(c.Deps.K8sService.DeleteResource))
 	c.Mux.HandleFunc("/api/cronjobs/trigger", c.Secure(c.Deps.K8sService.TriggerCronJob))
@@ -163,6 +317,8 @@ func registerHelmRoutes(c RouterConfig) {
 
 func registerPodRoutes(c RouterConfig) {
 	c.Mux.HandleFunc("/api/pods/logs", c.Secure(middleware.WebSocketLimitMiddleware(c.Deps.PodService.StreamPodLogs)))
+	c.Mux.HandleFunc("/api/pods/logs/download", c.Secure(c.Deps.PodService.DownloadPodLogs))
+	c.Mux.HandleFunc("/api/pods/logs/aggregate", c.Secure(middleware.WebSocketLimitMiddleware(c.Deps.PodService.StreamAggregatedLogs)))
 	c.Mux.HandleFunc("/api/pods/events", c.Secure(c.Deps.PodService.GetPodEvents))
 	c.Mux.HandleFunc("/api/pods/exec", c.SecureWS(middleware.WebSocketLimitMiddleware(c.Deps.PodService.ExecIntoPod)))
 }
@@ -208,36 +364,57 @@ func registerOtherRoutes(c RouterConfig) {
 	c.Mux.HandleFunc("/api/ldap/status", c.Public(c.Deps.LDAPService.GetLDAPStatusHandler))
 	c.Mux.HandleFunc("/api/ldap/config", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.GetConfigHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.GetConfigHandler)))(w, r)
 		} else if r.Method == http.MethodPut {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.UpdateConfigHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.UpdateConfigHandler)))(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 	c.Mux.HandleFunc("/api/ldap/groups", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.GetGroupsHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.GetGroupsHandler)))(w, r)
 		} else if r.Method == http.MethodPut {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.UpdateGroupsHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.UpdateGroupsHandler)))(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
 	c.Mux.HandleFunc("/api/ldap/credentials", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodGet {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.GetCredentialsHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.GetCredentialsHandler)))(w, r)
 		} else if r.Method == http.MethodPut {
-			c.Secure(c.AdminOnly(c.Deps.LDAPService.UpdateCredentialsHandler))(w, r)
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.LDAPService.UpdateCredentialsHandler)))(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	})
-	c.Mux.HandleFunc("/api/ldap/test", c.Secure(c.AdminOnly(func(w http.ResponseWriter, r *http.Request) {
+	c.Mux.HandleFunc("/api/ldap/test", c.Secure(c.AdminOnly(auth.InteractiveOnly(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == http.MethodPost {
 			c.Deps.LDAPService.TestConnectionHandler(w, r)
 		} else {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
-	})))
+	}))))
+
+	// OIDC handlers
+	c.Mux.HandleFunc("/api/oidc/status", c.Public(c.Deps.OIDCService.GetOIDCStatusHandler))
+	c.Mux.HandleFunc("/api/oidc/config", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.OIDCService.GetConfigHandler)))(w, r)
+		} else if r.Method == http.MethodPut {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.OIDCService.UpdateConfigHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	c.Mux.HandleFunc("/api/oidc/groups", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.OIDCService.GetGroupsHandler)))(w, r)
+		} else if r.Method == http.MethodPut {
+			c.Secure(c.AdminOnly(auth.InteractiveOnly(c.Deps.OIDCService.UpdateGroupsHandler)))(w, r)
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
 }
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -19,19 +20,24 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/flaucha/DKonsole/backend/internal/api"
+	"github.com/flaucha/DKonsole/backend/internal/audit"
 	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/cache"
 	"github.com/flaucha/DKonsole/backend/internal/cluster"
 	"github.com/flaucha/DKonsole/backend/internal/helm"
 	"github.com/flaucha/DKonsole/backend/internal/k8s"
 	"github.com/flaucha/DKonsole/backend/internal/ldap"
 	"github.com/flaucha/DKonsole/backend/internal/logo"
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/oidc"
 	"github.com/flaucha/DKonsole/backend/internal/pod"
 	"github.com/flaucha/DKonsole/backend/internal/prometheus"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 	"github.com/flaucha/DKonsole/backend/internal/settings"
+	"github.com/flaucha/DKonsole/backend/internal/users"
 )
 
-func newTestRouter(t *testing.T) (*http.ServeMux, *models.Handlers) {
+func newTestRouter(t *testing.T) (*http.ServeMux, *models.Handlers, *auth.Service) {
 	t.Helper()
 
 	// Ensure the test namespace matches the namespace resolution logic used by auth.NewService.
@@ -75,6 +81,8 @@ func newTestRouter(t *testing.T) (*http.ServeMux, *models.Handlers) {
 		t.Fatalf("failed to init auth service: %v", err)
 	}
 	ldapService := ldap.NewServiceFactory(clientset, "test-secret").NewService()
+	oidcService := oidc.NewServiceFactory(clientset).NewService()
+	usersService := users.NewServiceFactory(clientset, "test-secret").NewService()
 	settingsService := settings.NewServiceFactory(clientset, handlersModel, "test-secret", promService).NewService()
 	logoService := logo.NewService(clientset, namespace)
 
@@ -83,8 +91,13 @@ func newTestRouter(t *testing.T) (*http.ServeMux, *models.Handlers) {
 	router := NewRouter(Dependencies{
 		AuthService:       authService,
 		LDAPService:       ldapService,
+		OIDCService:       oidcService,
 		ClusterService:    clusterService,
+		UsersService:      usersService,
+		AuditService:      audit.NewService(audit.NewMemoryStore(0)),
+		RecordingService:  recording.NewService(recording.NewMemoryStore(0), 0, 0),
 		K8sService:        k8sService,
+		ResourceCache:     cache.NewManager(cache.Config{}, nil),
 		APIService:        apiService,
 		HelmService:       helmService,
 		PodService:        podService,
@@ -95,12 +108,12 @@ func newTestRouter(t *testing.T) (*http.ServeMux, *models.Handlers) {
 		StaticDir:         filepath.Join(tmpDir, "static"),
 	})
 
-	return router, handlersModel
+	return router, handlersModel, authService
 }
 
 func TestRouter_HealthAndCORS(t *testing.T) {
 	t.Setenv("ALLOWED_ORIGINS", "https://example.com")
-	router, _ := newTestRouter(t)
+	router, _, _ := newTestRouter(t)
 
 	// Liveness endpoint
 	rr := httptest.NewRecorder()
@@ -129,7 +142,7 @@ func TestRouter_HealthAndCORS(t *testing.T) {
 }
 
 func TestRouter_ReadyzVariants(t *testing.T) {
-	router, handlers := newTestRouter(t)
+	router, handlers, _ := newTestRouter(t)
 
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
@@ -290,3 +303,55 @@ func TestEnableCors(t *testing.T) {
 		}
 	})
 }
+
+// ldapAdminCheckerStub reports every user as a member of the LDAP admin group
+type ldapAdminCheckerStub struct{}
+
+func (ldapAdminCheckerStub) GetUserGroups(ctx context.Context, username string) ([]string, error) {
+	return []string{"dkonsole-admins"}, nil
+}
+
+func (ldapAdminCheckerStub) GetConfig(ctx context.Context) (*models.LDAPConfig, error) {
+	return &models.LDAPConfig{Enabled: true, AdminGroups: []string{"dkonsole-admins"}}, nil
+}
+
+func TestRequireAdmin_APITokens(t *testing.T) {
+	ctx := context.Background()
+	tokens := auth.NewAPITokenManager(auth.NewMemoryAPITokenStore())
+	owner := models.Claims{Username: "alice", Role: "user", IDP: "ldap", Permissions: map[string]string{"payments": "edit"}}
+	_, scoped, err := tokens.Create(ctx, owner, "ci", 0, map[string]string{"payments": "view"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	_, full, err := tokens.Create(ctx, owner, "full", 0, nil)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	handler := requireAdmin(ldapAdminCheckerStub{})(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+	}{
+		{"scoped token of an LDAP admin", scoped, http.StatusForbidden},
+		{"unscoped token of an LDAP admin", full, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tokens.Authenticate(ctx, tt.token, "")
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/api/audit", nil)
+			req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey(), claims))
+			rr := httptest.NewRecorder()
+			handler(rr, req)
+			if rr.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package users
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+// maxUserRequestSize limits the size of user payloads
+const maxUserRequestSize = 64 << 10 // 64KB
+
+// UserRequest represents a request to create or update a local user
+type UserRequest struct {
+	Username    string            `json:"username"`
+	Password    string            `json:"password,omitempty"`
+	Role        string            `json:"role"`
+	Permissions map[string]string `json:"permissions,omitempty"`
+}
+
+// ListUsersHandler returns the local users without password hashes
+// @Summary Listar usuarios locales
+// @Description Devuelve los usuarios locales (IDP core) con su rol y permisos
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} models.LocalUser
+// @Router /api/users [get]
+func (s *Service) ListUsersHandler(w http.ResponseWriter, r *http.Request) {
+	users, err := s.ListUsers(r.Context())
+	if err != nil {
+		writeUserError(w, err, "Failed to list users", "")
+		return
+	}
+	utils.JSONResponse(w, http.StatusOK, users)
+}
+
+// CreateUserHandler creates a local user
+// @Summary Crear usuario local
+// @Description Crea un usuario local con contraseña, rol y permisos por namespace
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body UserRequest true "Datos del usuario"
+// @Success 201 {object} models.LocalUser
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /api/users [post]
+func (s *Service) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	var req UserRequest
+	if err := decodeUserRequest(w, r, &req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := s.CreateUser(r.Context(), req)
+	if err != nil {
+		utils.AuditLog(r, "create", "User", req.Username, "", false, err, nil)
+		writeUserError(w, err, "Failed to create user", req.Username)
+		return
+	}
+
+	utils.AuditLog(r, "create", "User", user.Username, "", true, nil, map[string]interface{}{
+		"role": user.Role,
+	})
+	utils.JSONResponse(w, http.StatusCreated, user)
+}
+
+// UpdateUserHandler updates the role, permissions or password of a local user
+// @Summary Actualizar usuario local
+// @Description Actualiza rol, permisos y opcionalmente la contraseña (vacía = sin cambios)
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param username query string true "Nombre del usuario"
+// @Param request body UserRequest true "Datos del usuario"
+// @Success 200 {object} models.LocalUser
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/users [put]
+func (s *Service) UpdateUserHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "username is required")
+		return
+	}
+
+	var req UserRequest
+	if err := decodeUserRequest(w, r, &req); err != nil {
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := s.UpdateUser(r.Context(), username, req)
+	if err != nil {
+		utils.AuditLog(r, "update", "User", username, "", false, err, nil)
+		writeUserError(w, err, "Failed to update user", username)
+		return
+	}
+
+	utils.AuditLog(r, "update", "User", username, "", true, nil, map[string]interface{}{
+		"role":             user.Role,
+		"password_changed": req.Password != "",
+	})
+	utils.JSONResponse(w, http.StatusOK, user)
+}
+
+// DeleteUserHandler deletes a local user and revokes its sessions
+// @Summary Eliminar usuario local
+// @Description Elimina un usuario local y revoca sus sesiones
+// @Tags users
+// @Produce json
+// @Security Bearer
+// @Param username query string true "Nombre del usuario"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/users [delete]
+func (s *Service) DeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if username == "" {
+		utils.ErrorResponse(w, http.StatusBadRequest, "username is required")
+		return
+	}
+
+	if err := s.DeleteUser(r.Context(), username); err != nil {
+		utils.AuditLog(r, "delete", "User", username, "", false, err, nil)
+		writeUserError(w, err, "Failed to delete user", username)
+		return
+	}
+
+	utils.AuditLog(r, "delete", "User", username, "", true, nil, nil)
+	utils.JSONResponse(w, http.StatusOK, map[string]string{
+		"message": "User deleted successfully",
+	})
+}
+
+func decodeUserRequest(w http.ResponseWriter, r *http.Request, req *UserRequest) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUserRequestSize)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return errors.New("invalid request body")
+	}
+	return nil
+}
+
+// writeUserError maps user service errors to HTTP status codes
+func writeUserError(w http.ResponseWriter, err error, userMsg, username string) {
+	switch {
+	case errors.Is(err, ErrInvalidUser):
+		utils.ErrorResponse(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrUserExists):
+		utils.ErrorResponse(w, http.StatusConflict, err.Error())
+	case errors.Is(err, ErrUserNotFound):
+		utils.ErrorResponse(w, http.StatusNotFound, err.Error())
+	default:
+		utils.HandleErrorJSON(w, err, userMsg, http.StatusInternalServerError, map[string]interface{}{
+			"username": username,
+		})
+	}
+}
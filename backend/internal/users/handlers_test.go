@@ -0,0 +1,85 @@
+package users
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func TestUserHandlers_CRUD(t *testing.T) {
+	s, _ := newTestService()
+
+	body, _ := json.Marshal(UserRequest{Username: "alice", Password: "alicepass", Permissions: map[string]string{"payments": "view"}})
+	rr := httptest.NewRecorder()
+	s.CreateUserHandler(rr, httptest.NewRequest(http.MethodPost, "/api/users", bytes.NewReader(body)))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("CreateUserHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+	if strings.Contains(rr.Body.String(), "passwordHash") {
+		t.Error("response must not contain the password hash")
+	}
+
+	rr = httptest.NewRecorder()
+	s.CreateUserHandler(rr, httptest.NewRequest(http.MethodPost, "/api/users", bytes.NewReader(body)))
+	if rr.Code != http.StatusConflict {
+		t.Errorf("duplicate status = %d, want 409", rr.Code)
+	}
+
+	body, _ = json.Marshal(UserRequest{Role: "user", Permissions: map[string]string{"payments": "edit"}})
+	rr = httptest.NewRecorder()
+	s.UpdateUserHandler(rr, httptest.NewRequest(http.MethodPut, "/api/users?username=alice", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("UpdateUserHandler() status = %d, body = %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	s.ListUsersHandler(rr, httptest.NewRequest(http.MethodGet, "/api/users", nil))
+	var users []models.LocalUser
+	if err := json.NewDecoder(rr.Body).Decode(&users); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if len(users) != 1 || users[0].Permissions["payments"] != "edit" || users[0].PasswordHash != "" {
+		t.Errorf("unexpected users: %+v", users)
+	}
+
+	rr = httptest.NewRecorder()
+	s.DeleteUserHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/users?username=alice", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("DeleteUserHandler() status = %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	s.DeleteUserHandler(rr, httptest.NewRequest(http.MethodDelete, "/api/users?username=alice", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rr.Code)
+	}
+}
+
+func TestUserHandlers_BadRequests(t *testing.T) {
+	s, _ := newTestService()
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		body    string
+	}{
+		{"create invalid body", s.CreateUserHandler, http.MethodPost, "/api/users", "{"},
+		{"create invalid user", s.CreateUserHandler, http.MethodPost, "/api/users", `{"username":"bob","password":"short"}`},
+		{"update missing username", s.UpdateUserHandler, http.MethodPut, "/api/users", `{}`},
+		{"delete missing username", s.DeleteUserHandler, http.MethodDelete, "/api/users", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tt.handler(rr, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", rr.Code)
+			}
+		})
+	}
+}
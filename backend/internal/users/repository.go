@@ -0,0 +1,165 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+const (
+	// usersKey is the auth Secret entry holding the local users
+	usersKey = "local-users"
+	// adminUsernameKey is the auth Secret entry holding the setup admin's username
+	adminUsernameKey = "admin-username"
+)
+
+// Repository defines the interface for local user data access
+type Repository interface {
+	GetUsers(ctx context.Context) ([]models.LocalUser, error)
+	// UpdateUsers applies fn to the stored users and saves the result atomically
+	UpdateUsers(ctx context.Context, fn func(users []models.LocalUser) ([]models.LocalUser, error)) error
+	// GetAdminUsername returns the setup admin's username, which local users cannot take
+	GetAdminUsername(ctx context.Context) (string, error)
+}
+
+// K8sRepository implements Repository using the auth Kubernetes Secret,
+// next to the admin credentials managed by the auth package
+type K8sRepository struct {
+	client     kubernetes.Interface
+	namespace  string
+	secretName string
+}
+
+// NewRepository creates a new K8sRepository instance
+func NewRepository(client kubernetes.Interface, secretName string) *K8sRepository {
+	namespace, err := getCurrentNamespace()
+	if err != nil {
+		// Fallback to default namespace
+		namespace = "default"
+		utils.LogWarn("Failed to get current namespace, using default", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	return &K8sRepository{
+		client:     client,
+		namespace:  namespace,
+		secretName: secretName,
+	}
+}
+
+// ServiceAccountNamespaceFile is the path to the service account namespace file
+var ServiceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// getCurrentNamespace retrieves the current namespace from the pod's service account
+func getCurrentNamespace() (string, error) {
+	// Try reading from service account namespace file (standard in Kubernetes pods)
+	if data, err := os.ReadFile(ServiceAccountNamespaceFile); err == nil {
+		namespace := string(data)
+		if namespace != "" {
+			return namespace, nil
+		}
+	}
+
+	// Fallback to environment variable
+	if namespace := os.Getenv("POD_NAMESPACE"); namespace != "" {
+		return namespace, nil
+	}
+
+	return "", fmt.Errorf("could not determine namespace: service account file not found and POD_NAMESPACE not set")
+}
+
+// GetUsers returns the local users stored in the auth Secret.
+// It returns an empty list when there is no client, the Secret does not exist or has no users yet.
+func (r *K8sRepository) GetUsers(ctx context.Context) ([]models.LocalUser, error) {
+	users := []models.LocalUser{}
+	if r.client == nil {
+		return users, nil
+	}
+
+	secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return users, nil
+		}
+		return nil, fmt.Errorf("failed to get secret: %w", err)
+	}
+
+	data := secret.Data[usersKey]
+	if len(data) == 0 {
+		return users, nil
+	}
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", usersKey, err)
+	}
+	return users, nil
+}
+
+// GetAdminUsername returns the setup admin's username ("" when unknown)
+func (r *K8sRepository) GetAdminUsername(ctx context.Context) (string, error) {
+	if r.client == nil {
+		return "", nil
+	}
+
+	secret, err := r.client.CoreV1().Secrets(r.namespace).Get(ctx, r.secretName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get secret: %w", err)
+	}
+	return string(secret.Data[adminUsernameKey]), nil
+}
+
+// UpdateUsers reads the users, applies fn and writes them back, retrying on conflicts.
+// The auth Secret must already exist (it is created by the initial setup); it is never created
+// here, since its absence is what puts DKonsole in setup mode.
+func (r *K8sRepository) UpdateUsers(ctx context.Context, fn func(users []models.LocalUser) ([]models.LocalUser, error)) error {
+	if r.client == nil {
+		return fmt.Errorf("kubernetes client not available")
+	}
+
+	secrets := r.client.CoreV1().Secrets(r.namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := secrets.Get(ctx, r.secretName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("auth secret %s not found, complete the initial setup first", r.secretName)
+			}
+			return fmt.Errorf("failed to get secret: %w", err)
+		}
+
+		users := []models.LocalUser{}
+		if data := secret.Data[usersKey]; len(data) > 0 {
+			if err := json.Unmarshal(data, &users); err != nil {
+				return fmt.Errorf("failed to unmarshal %s: %w", usersKey, err)
+			}
+		}
+
+		users, err = fn(users)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(users)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", usersKey, err)
+		}
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		secret.Data[usersKey] = data
+
+		_, err = secrets.Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
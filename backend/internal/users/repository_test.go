@@ -0,0 +1,98 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+func newTestRepository(objects ...*corev1.Secret) (*K8sRepository, *k8sfake.Clientset) {
+	client := k8sfake.NewSimpleClientset()
+	for _, obj := range objects {
+		_, _ = client.CoreV1().Secrets(obj.Namespace).Create(context.Background(), obj, metav1.CreateOptions{})
+	}
+	return &K8sRepository{client: client, namespace: "dkonsole", secretName: "dkonsole-auth"}, client
+}
+
+func authSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "dkonsole-auth", Namespace: "dkonsole"},
+		Data: map[string][]byte{
+			"admin-username": []byte("admin"),
+			"jwt-secret":     []byte("secret"),
+		},
+	}
+}
+
+func TestNewRepository_EnvNamespace(t *testing.T) {
+	defer os.Unsetenv("POD_NAMESPACE")
+	os.Setenv("POD_NAMESPACE", "test-ns")
+
+	repo := NewRepository(nil, "dkonsole-auth")
+	if repo.namespace != "test-ns" || repo.secretName != "dkonsole-auth" {
+		t.Fatalf("unexpected repository: %+v", repo)
+	}
+}
+
+func TestK8sRepository_UpdateAndGetUsers(t *testing.T) {
+	repo, client := newTestRepository(authSecret())
+	ctx := context.Background()
+
+	users, err := repo.GetUsers(ctx)
+	if err != nil || len(users) != 0 {
+		t.Fatalf("expected no users, got %v (err=%v)", users, err)
+	}
+
+	err = repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) {
+		return append(users, models.LocalUser{Username: "alice", Role: "user"}), nil
+	})
+	if err != nil {
+		t.Fatalf("UpdateUsers() error = %v", err)
+	}
+
+	users, err = repo.GetUsers(ctx)
+	if err != nil || len(users) != 1 || users[0].Username != "alice" {
+		t.Fatalf("GetUsers() = %v, %v", users, err)
+	}
+
+	// The admin credentials in the same Secret are left untouched
+	secret, _ := client.CoreV1().Secrets("dkonsole").Get(ctx, "dkonsole-auth", metav1.GetOptions{})
+	if string(secret.Data["jwt-secret"]) != "secret" {
+		t.Error("UpdateUsers() must keep the other Secret entries")
+	}
+	if admin, err := repo.GetAdminUsername(ctx); err != nil || admin != "admin" {
+		t.Errorf("GetAdminUsername() = %q, %v", admin, err)
+	}
+}
+
+func TestK8sRepository_UpdateUsersErrors(t *testing.T) {
+	ctx := context.Background()
+
+	// The Secret is never created outside of the initial setup
+	repo, _ := newTestRepository()
+	err := repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) { return users, nil })
+	if err == nil {
+		t.Fatal("expected error when the auth secret does not exist")
+	}
+
+	repo, _ = newTestRepository(authSecret())
+	want := errors.New("boom")
+	if err := repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) { return nil, want }); !errors.Is(err, want) {
+		t.Errorf("UpdateUsers() error = %v, want %v", err, want)
+	}
+
+	repo = &K8sRepository{}
+	if users, err := repo.GetUsers(ctx); err != nil || len(users) != 0 {
+		t.Errorf("GetUsers() without client = %v, %v", users, err)
+	}
+	if err := repo.UpdateUsers(ctx, nil); err == nil {
+		t.Error("expected error without client")
+	}
+}
@@ -0,0 +1,368 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/utils"
+)
+
+var (
+	// ErrUserNotFound is returned when the requested local user does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrUserExists is returned when creating a user whose username is already taken
+	ErrUserExists = errors.New("user already exists")
+	// ErrInvalidUser is returned when the submitted user data is incomplete or invalid
+	ErrInvalidUser = errors.New("invalid user")
+)
+
+// localIDP is the identity provider of local users in session and token claims
+const localIDP = "core"
+
+// SessionRevoker revokes the sessions of a user of an identity provider (implemented by
+// *auth.SessionManager), or its API tokens (implemented by *auth.APITokenManager)
+type SessionRevoker interface {
+	RevokeIDPUser(ctx context.Context, idp, username string) (int, error)
+}
+
+// dummyPasswordHash is verified when the username is unknown, so that a failed login takes
+// as long whether or not the user exists
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, err := auth.HashPassword("dkonsole-unknown-user")
+	if err != nil {
+		return ""
+	}
+	return hash
+})
+
+// TOTPResetter removes the two-factor enrollment of a user (implemented by *auth.Service)
+type TOTPResetter interface {
+	ResetTOTP(ctx context.Context, username string) error
+}
+
+// Service provides business logic for local user management
+type Service struct {
+	repo          Repository
+	handlersModel *models.Handlers
+	revoker       SessionRevoker
+	apiTokens     SessionRevoker
+	totp          TOTPResetter
+	now           func() time.Time
+}
+
+// NewService creates a new local users service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+		now:  time.Now,
+	}
+}
+
+// SetHandlersModel wires the global handlers model for refreshing the K8s client.
+func (s *Service) SetHandlersModel(handlersModel *models.Handlers) {
+	s.handlersModel = handlersModel
+}
+
+// SetSessionRevoker sets the session revoker used to log out deleted users
+// and users whose password was reset
+func (s *Service) SetSessionRevoker(revoker SessionRevoker) {
+	s.revoker = revoker
+}
+
+// SetAPITokenRevoker sets the revoker of the API tokens of deleted users,
+// so a new user with the same name does not inherit them
+func (s *Service) SetAPITokenRevoker(revoker SessionRevoker) {
+	s.apiTokens = revoker
+}
+
+// SetTOTPResetter sets the hook that drops the two-factor enrollment of deleted users,
+// so a new user with the same name does not inherit it
+func (s *Service) SetTOTPResetter(totp TOTPResetter) {
+	s.totp = totp
+}
+
+func (s *Service) refreshRepoClient() {
+	repo, ok := s.repo.(*K8sRepository)
+	if !ok || s.handlersModel == nil {
+		return
+	}
+
+	s.handlersModel.RLock()
+	client := s.handlersModel.Clients["default"]
+	s.handlersModel.RUnlock()
+
+	if client != nil {
+		repo.client = client
+	}
+}
+
+// ListUsers returns the local users sorted by username, without password hashes
+func (s *Service) ListUsers(ctx context.Context) ([]models.LocalUser, error) {
+	s.refreshRepoClient()
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.LocalUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, sanitize(user))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+	return result, nil
+}
+
+// CreateUser validates and stores a new local user with an Argon2 password hash
+func (s *Service) CreateUser(ctx context.Context, req UserRequest) (*models.LocalUser, error) {
+	s.refreshRepoClient()
+	if err := s.validateUsername(ctx, req.Username); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
+	}
+	role, perms, err := normalizeAccess(req.Role, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := s.now()
+	user := models.LocalUser{
+		Username:     req.Username,
+		PasswordHash: hash,
+		Role:         role,
+		Permissions:  perms,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	err = s.repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) {
+		if findUser(users, req.Username) >= 0 {
+			return nil, ErrUserExists
+		}
+		return append(users, user), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created := sanitize(user)
+	return &created, nil
+}
+
+// UpdateUser changes the role, permissions and optionally the password of a local user.
+// An empty password keeps the current one. A password reset or a role or permission change
+// revokes the user's sessions, as they carry the previous credentials and access.
+func (s *Service) UpdateUser(ctx context.Context, username string, req UserRequest) (*models.LocalUser, error) {
+	s.refreshRepoClient()
+	if req.Username != "" && req.Username != username {
+		return nil, fmt.Errorf("%w: username cannot be changed", ErrInvalidUser)
+	}
+	var hash string
+	if req.Password != "" {
+		if err := validatePassword(req.Password); err != nil {
+			return nil, err
+		}
+		var err error
+		if hash, err = auth.HashPassword(req.Password); err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+	}
+	role, perms, err := normalizeAccess(req.Role, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated models.LocalUser
+	var accessChanged bool
+	err = s.repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) {
+		i := findUser(users, username)
+		if i < 0 {
+			return nil, ErrUserNotFound
+		}
+		accessChanged = users[i].Role != role || !maps.Equal(users[i].Permissions, perms)
+		users[i].Role = role
+		users[i].Permissions = perms
+		if hash != "" {
+			users[i].PasswordHash = hash
+		}
+		users[i].UpdatedAt = s.now()
+		updated = users[i]
+		return users, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if hash != "" || accessChanged {
+		s.revokeSessions(ctx, username)
+	}
+	result := sanitize(updated)
+	return &result, nil
+}
+
+// DeleteUser removes a local user, revokes its sessions and API tokens and drops its two-factor enrollment
+func (s *Service) DeleteUser(ctx context.Context, username string) error {
+	s.refreshRepoClient()
+	err := s.repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) {
+		i := findUser(users, username)
+		if i < 0 {
+			return nil, ErrUserNotFound
+		}
+		return append(users[:i], users[i+1:]...), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revokeSessions(ctx, username)
+	if s.apiTokens != nil {
+		if _, err := s.apiTokens.RevokeIDPUser(ctx, localIDP, username); err != nil {
+			utils.LogWarn("Failed to revoke API tokens of deleted user", map[string]interface{}{
+				"username": username,
+				"error":    err.Error(),
+			})
+		}
+	}
+	if s.totp != nil {
+		if err := s.totp.ResetTOTP(ctx, username); err != nil {
+			utils.LogWarn("Failed to reset two-factor enrollment of deleted user", map[string]interface{}{
+				"username": username,
+				"error":    err.Error(),
+			})
+		}
+	}
+	return nil
+}
+
+// AuthenticateLocalUser verifies a local user's password and returns its claims.
+// It returns nil claims (and no error) when the user does not exist or the password is wrong.
+func (s *Service) AuthenticateLocalUser(ctx context.Context, username, password string) (*models.Claims, error) {
+	user, err := s.getUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		_, _ = auth.VerifyPassword(password, dummyPasswordHash())
+		return nil, nil
+	}
+
+	match, err := auth.VerifyPassword(password, user.PasswordHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !match {
+		return nil, nil
+	}
+	return userClaims(user), nil
+}
+
+// ChangeLocalUserPassword replaces a local user's password after verifying the current one.
+// It returns false (and no error) when the user does not exist or the current password is wrong.
+// Revoking the user's other sessions is left to the caller, which knows the current one.
+func (s *Service) ChangeLocalUserPassword(ctx context.Context, username, currentPassword, newPassword string) (bool, error) {
+	if err := validatePassword(newPassword); err != nil {
+		return false, err
+	}
+	claims, err := s.AuthenticateLocalUser(ctx, username, currentPassword)
+	if err != nil || claims == nil {
+		return false, err
+	}
+	hash, err := auth.HashPassword(newPassword)
+	if err != nil {
+		return false, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	err = s.repo.UpdateUsers(ctx, func(users []models.LocalUser) ([]models.LocalUser, error) {
+		i := findUser(users, username)
+		if i < 0 {
+			return nil, ErrUserNotFound
+		}
+		users[i].PasswordHash = hash
+		users[i].UpdatedAt = s.now()
+		return users, nil
+	})
+	if errors.Is(err, ErrUserNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// LocalUserClaims returns the current claims of a local user, used to re-authorize its sessions.
+// It returns nil claims (and no error) when the user no longer exists.
+func (s *Service) LocalUserClaims(ctx context.Context, username string) (*models.Claims, error) {
+	user, err := s.getUser(ctx, username)
+	if err != nil || user == nil {
+		return nil, err
+	}
+	return userClaims(user), nil
+}
+
+func (s *Service) getUser(ctx context.Context, username string) (*models.LocalUser, error) {
+	s.refreshRepoClient()
+	users, err := s.repo.GetUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if i := findUser(users, username); i >= 0 {
+		return &users[i], nil
+	}
+	return nil, nil
+}
+
+func (s *Service) revokeSessions(ctx context.Context, username string) {
+	if s.revoker == nil {
+		return
+	}
+	if _, err := s.revoker.RevokeIDPUser(ctx, localIDP, username); err != nil {
+		utils.LogWarn("Failed to revoke user sessions", map[string]interface{}{
+			"username": username,
+			"error":    err.Error(),
+		})
+	}
+}
+
+// userClaims builds the token claims of a local user; admins get full access and no permission map
+func userClaims(user *models.LocalUser) *models.Claims {
+	perms := make(map[string]string)
+	if user.Role != "admin" {
+		for key, level := range user.Permissions {
+			perms[key] = level
+		}
+	}
+	return &models.Claims{
+		Username:    user.Username,
+		Role:        user.Role,
+		IDP:         localIDP,
+		Permissions: perms,
+	}
+}
+
+func findUser(users []models.LocalUser, username string) int {
+	for i := range users {
+		if users[i].Username == username {
+			return i
+		}
+	}
+	return -1
+}
+
+func sanitize(user models.LocalUser) models.LocalUser {
+	user.PasswordHash = ""
+	return user
+}
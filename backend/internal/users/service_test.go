@@ -0,0 +1,232 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/models"
+)
+
+type mockRevoker struct {
+	revoked []string
+}
+
+// RevokeIDPUser records the username, prefixed with the identity provider unless it is "core"
+func (m *mockRevoker) RevokeIDPUser(ctx context.Context, idp, username string) (int, error) {
+	if idp != "core" {
+		username = idp + ":" + username
+	}
+	m.revoked = append(m.revoked, username)
+	return 1, nil
+}
+
+type mockTOTPResetter struct {
+	reset []string
+}
+
+func (m *mockTOTPResetter) ResetTOTP(ctx context.Context, username string) error {
+	m.reset = append(m.reset, username)
+	return nil
+}
+
+func newTestService() (*Service, *mockRevoker) {
+	repo, _ := newTestRepository(authSecret())
+	revoker := &mockRevoker{}
+	s := NewService(repo)
+	s.SetSessionRevoker(revoker)
+	return s, revoker
+}
+
+func TestService_CreateUser(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+
+	user, err := s.CreateUser(ctx, UserRequest{
+		Username:    "alice",
+		Password:    "alicepass",
+		Permissions: map[string]string{"payments": "edit", "prod/*": "view", "default/web": "view"},
+	})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.PasswordHash != "" {
+		t.Error("CreateUser() must not return the password hash")
+	}
+	if user.Role != "user" {
+		t.Errorf("role = %q, want default user", user.Role)
+	}
+	// Default cluster keys are normalized to the bare namespace
+	if user.Permissions["web"] != "view" || user.Permissions["prod/*"] != "view" || len(user.Permissions) != 3 {
+		t.Errorf("unexpected permissions: %v", user.Permissions)
+	}
+
+	stored, _ := s.repo.GetUsers(ctx)
+	if match, _ := auth.VerifyPassword("alicepass", stored[0].PasswordHash); !match {
+		t.Error("stored hash does not verify the password")
+	}
+
+	if _, err := s.CreateUser(ctx, UserRequest{Username: "alice", Password: "alicepass"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("duplicate user error = %v, want ErrUserExists", err)
+	}
+	if _, err := s.CreateUser(ctx, UserRequest{Username: "admin", Password: "adminpass"}); !errors.Is(err, ErrUserExists) {
+		t.Errorf("setup admin username error = %v, want ErrUserExists", err)
+	}
+}
+
+func TestService_CreateUserValidation(t *testing.T) {
+	s, _ := newTestService()
+	tests := []struct {
+		name string
+		req  UserRequest
+	}{
+		{"invalid username", UserRequest{Username: "bad user", Password: "password1"}},
+		{"empty username", UserRequest{Username: "", Password: "password1"}},
+		{"short password", UserRequest{Username: "bob", Password: "short"}},
+		{"invalid role", UserRequest{Username: "bob", Password: "password1", Role: "root"}},
+		{"invalid level", UserRequest{Username: "bob", Password: "password1", Permissions: map[string]string{"ns": "owner"}}},
+		{"invalid namespace", UserRequest{Username: "bob", Password: "password1", Permissions: map[string]string{"Bad_NS": "view"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.CreateUser(context.Background(), tt.req); !errors.Is(err, ErrInvalidUser) {
+				t.Errorf("CreateUser() error = %v, want ErrInvalidUser", err)
+			}
+		})
+	}
+}
+
+func TestService_UpdateAndDeleteUser(t *testing.T) {
+	s, revoker := newTestService()
+	resetter := &mockTOTPResetter{}
+	s.SetTOTPResetter(resetter)
+	tokens := &mockRevoker{}
+	s.SetAPITokenRevoker(tokens)
+	ctx := context.Background()
+	if _, err := s.CreateUser(ctx, UserRequest{Username: "alice", Password: "alicepass", Permissions: map[string]string{"payments": "view"}}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// An update that leaves the access unchanged keeps the sessions
+	if _, err := s.UpdateUser(ctx, "alice", UserRequest{Permissions: map[string]string{"payments": "view"}}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if len(revoker.revoked) != 0 {
+		t.Errorf("sessions revoked without access or password change: %v", revoker.revoked)
+	}
+
+	// A permission change revokes the existing sessions
+	if _, err := s.UpdateUser(ctx, "alice", UserRequest{Permissions: map[string]string{"payments": "edit"}}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if len(revoker.revoked) != 1 || revoker.revoked[0] != "alice" {
+		t.Errorf("revoked after permission change = %v, want [alice]", revoker.revoked)
+	}
+
+	// So does a role change, which keeps the password
+	user, err := s.UpdateUser(ctx, "alice", UserRequest{Role: "admin", Permissions: map[string]string{"payments": "edit"}})
+	if err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if user.Role != "admin" || len(user.Permissions) != 0 {
+		t.Errorf("admins must not keep namespace permissions: %+v", user)
+	}
+	if len(revoker.revoked) != 2 {
+		t.Errorf("revoked after role change = %v, want two revocations", revoker.revoked)
+	}
+	if claims, _ := s.AuthenticateLocalUser(ctx, "alice", "alicepass"); claims == nil || claims.Role != "admin" {
+		t.Errorf("AuthenticateLocalUser() after role change = %+v", claims)
+	}
+
+	// A password reset revokes the existing sessions
+	if _, err := s.UpdateUser(ctx, "alice", UserRequest{Role: "admin", Password: "newpassword"}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if len(revoker.revoked) != 3 {
+		t.Errorf("revoked after password reset = %v, want three revocations", revoker.revoked)
+	}
+	if claims, _ := s.AuthenticateLocalUser(ctx, "alice", "alicepass"); claims != nil {
+		t.Error("old password must not work after a reset")
+	}
+
+	if _, err := s.UpdateUser(ctx, "alice", UserRequest{Username: "mallory"}); !errors.Is(err, ErrInvalidUser) {
+		t.Errorf("rename error = %v, want ErrInvalidUser", err)
+	}
+	if _, err := s.UpdateUser(ctx, "bob", UserRequest{}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user error = %v, want ErrUserNotFound", err)
+	}
+
+	if err := s.DeleteUser(ctx, "alice"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if len(revoker.revoked) != 4 {
+		t.Errorf("DeleteUser() must revoke sessions, revoked = %v", revoker.revoked)
+	}
+	if len(resetter.reset) != 1 || resetter.reset[0] != "alice" {
+		t.Errorf("DeleteUser() must reset two-factor, reset = %v", resetter.reset)
+	}
+	if len(tokens.revoked) != 1 || tokens.revoked[0] != "alice" {
+		t.Errorf("DeleteUser() must revoke API tokens, revoked = %v", tokens.revoked)
+	}
+	if err := s.DeleteUser(ctx, "alice"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("DeleteUser() twice error = %v, want ErrUserNotFound", err)
+	}
+	if claims, err := s.LocalUserClaims(ctx, "alice"); claims != nil || err != nil {
+		t.Errorf("LocalUserClaims() for deleted user = %+v, %v", claims, err)
+	}
+}
+
+func TestService_AuthenticateLocalUser(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+	if _, err := s.CreateUser(ctx, UserRequest{Username: "alice", Password: "alicepass", Permissions: map[string]string{"payments": "edit"}}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	claims, err := s.AuthenticateLocalUser(ctx, "alice", "alicepass")
+	if err != nil || claims == nil {
+		t.Fatalf("AuthenticateLocalUser() = %v, %v", claims, err)
+	}
+	want := models.Claims{Username: "alice", Role: "user", IDP: "core"}
+	if claims.Username != want.Username || claims.Role != want.Role || claims.IDP != want.IDP || claims.Permissions["payments"] != "edit" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	for _, tc := range [][2]string{{"alice", "wrong"}, {"bob", "alicepass"}} {
+		if claims, err := s.AuthenticateLocalUser(ctx, tc[0], tc[1]); claims != nil || err != nil {
+			t.Errorf("AuthenticateLocalUser(%q, %q) = %+v, %v; want nil, nil", tc[0], tc[1], claims, err)
+		}
+	}
+	// Unknown usernames are checked against a real hash so they cost as much as a wrong password
+	if match, err := auth.VerifyPassword("alicepass", dummyPasswordHash()); match || err != nil {
+		t.Errorf("dummy hash verification = %v, %v; want a valid hash that does not match", match, err)
+	}
+}
+
+func TestService_ChangeLocalUserPassword(t *testing.T) {
+	s, _ := newTestService()
+	ctx := context.Background()
+	if _, err := s.CreateUser(ctx, UserRequest{Username: "alice", Password: "alicepass"}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	if _, err := s.ChangeLocalUserPassword(ctx, "alice", "alicepass", "short"); !errors.Is(err, ErrInvalidUser) {
+		t.Errorf("ChangeLocalUserPassword() with a short password error = %v, want ErrInvalidUser", err)
+	}
+	for _, tc := range [][2]string{{"alice", "wrong"}, {"bob", "alicepass"}} {
+		if ok, err := s.ChangeLocalUserPassword(ctx, tc[0], tc[1], "newalicepass"); ok || err != nil {
+			t.Errorf("ChangeLocalUserPassword(%q, %q) = %v, %v; want false, nil", tc[0], tc[1], ok, err)
+		}
+	}
+
+	if ok, err := s.ChangeLocalUserPassword(ctx, "alice", "alicepass", "newalicepass"); !ok || err != nil {
+		t.Fatalf("ChangeLocalUserPassword() = %v, %v", ok, err)
+	}
+	if claims, _ := s.AuthenticateLocalUser(ctx, "alice", "alicepass"); claims != nil {
+		t.Error("old password should no longer work")
+	}
+	if claims, _ := s.AuthenticateLocalUser(ctx, "alice", "newalicepass"); claims == nil {
+		t.Error("new password should work")
+	}
+}
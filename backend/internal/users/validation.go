@@ -0,0 +1,69 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/permissions"
+)
+
+// minPasswordLength is the minimum length of a local user password
+const minPasswordLength = 8
+
+// usernamePattern allows plain names and e-mail style usernames
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]{0,62}$`)
+
+// validateUsername checks the username format and that it does not shadow the setup admin
+func (s *Service) validateUsername(ctx context.Context, username string) error {
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: username must be 1-63 characters (letters, digits, '.', '_', '@', '-')", ErrInvalidUser)
+	}
+
+	adminUser, err := s.repo.GetAdminUsername(ctx)
+	if err != nil {
+		return err
+	}
+	if username == adminUser {
+		return ErrUserExists
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength {
+		return fmt.Errorf("%w: password must be at least %d characters", ErrInvalidUser, minPasswordLength)
+	}
+	return nil
+}
+
+// normalizeAccess validates the role and permissions of a user.
+// Permission keys are namespaces of the default cluster or "cluster/namespace" (both accept "*").
+// Admins have full access, so their permissions are dropped.
+func normalizeAccess(role string, perms map[string]string) (string, map[string]string, error) {
+	if role == "" {
+		role = "user"
+	}
+	if role != "admin" && role != "user" {
+		return "", nil, fmt.Errorf("%w: role must be 'admin' or 'user'", ErrInvalidUser)
+	}
+	if role == "admin" {
+		return role, nil, nil
+	}
+
+	normalized := make(map[string]string, len(perms))
+	for key, level := range perms {
+		cluster, namespace := models.ParsePermissionKey(key)
+		err := permissions.ValidateGroupPermission(models.GroupPermission{
+			Cluster:    cluster,
+			Namespace:  namespace,
+			Permission: level,
+		})
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: permission %q: %v", ErrInvalidUser, key, err)
+		}
+		normalized[models.PermissionKey(cluster, namespace)] = level
+	}
+	return role, normalized, nil
+}
@@ -3,6 +3,8 @@ package utils
 import (
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -41,10 +43,14 @@ func init() {
 
 // AuditLogEntry represents a structured audit log entry
 type AuditLogEntry struct {
+	Time      time.Time              `json:"time"`
 	User      string                 `json:"user"`
 	IP        string                 `json:"ip"`
+	Cluster   string                 `json:"cluster,omitempty"`
 	Action    string                 `json:"action"`
 	Resource  string                 `json:"resource,omitempty"`
+	Kind      string                 `json:"kind,omitempty"`
+	Name      string                 `json:"name,omitempty"`
 	Namespace string                 `json:"namespace,omitempty"`
 	Method    string                 `json:"method,omitempty"`
 	Path      string                 `json:"path,omitempty"`
@@ -52,9 +58,43 @@ type AuditLogEntry struct {
 	Duration  string                 `json:"duration,omitempty"`
 	Success   bool                   `json:"success"`
 	Error     string                 `json:"error,omitempty"`
+	DiffHash  string                 `json:"diffHash,omitempty"`
 	Details   map[string]interface{} `json:"details,omitempty"`
 }
 
+// AuditSink receives the action-level audit entries (AuditLog, AuditLogChange), e.g. to persist them.
+// Per-request entries of the audit middleware only go to the log.
+type AuditSink func(entry AuditLogEntry)
+
+var (
+	auditSinkMu sync.RWMutex
+	auditSinks  []AuditSink
+)
+
+// AddAuditSink registers a sink for action-level audit entries.
+// Sinks are called synchronously and must not block.
+func AddAuditSink(sink AuditSink) {
+	auditSinkMu.Lock()
+	defer auditSinkMu.Unlock()
+	auditSinks = append(auditSinks, sink)
+}
+
+// ResetAuditSinks removes all registered sinks (used by tests)
+func ResetAuditSinks() {
+	auditSinkMu.Lock()
+	defer auditSinkMu.Unlock()
+	auditSinks = nil
+}
+
+func dispatchAudit(entry AuditLogEntry) {
+	auditSinkMu.RLock()
+	sinks := auditSinks
+	auditSinkMu.RUnlock()
+	for _, sink := range sinks {
+		sink(entry)
+	}
+}
+
 // auditLogInternal writes a structured audit log entry (internal function)
 func auditLogInternal(entry AuditLogEntry) {
 	fields := logrus.Fields{
@@ -65,6 +105,9 @@ func auditLogInternal(entry AuditLogEntry) {
 		"success": entry.Success,
 	}
 
+	if entry.Cluster != "" {
+		fields["cluster"] = entry.Cluster
+	}
 	if entry.Resource != "" {
 		fields["resource"] = entry.Resource
 	}
@@ -86,6 +129,9 @@ func auditLogInternal(entry AuditLogEntry) {
 	if entry.Error != "" {
 		fields["error"] = entry.Error
 	}
+	if entry.DiffHash != "" {
+		fields["diff_hash"] = entry.DiffHash
+	}
 	if len(entry.Details) > 0 {
 		for k, v := range entry.Details {
 			fields[k] = v
@@ -2,6 +2,8 @@ package utils
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -165,9 +167,42 @@ func GetClientIP(r *http.Request) string {
 	return ip
 }
 
-// userContextKeyStr is the context key for user information.
-// This must match auth.userContextKey to avoid import cycle.
-const userContextKeyStr = "user"
+// ContextKey is the type of the request context keys shared between packages
+type ContextKey string
+
+// UserContextKey is the context key under which the auth middleware stores the user claims.
+// It lives here so packages that cannot import auth (middleware, utils) read the same key.
+const UserContextKey ContextKey = "user"
+
+// AuditIdentity is implemented by the user claims stored under UserContextKey
+type AuditIdentity interface {
+	AuditUser() string
+}
+
+// RequestUser returns the name of the authenticated user of a request, or "anonymous"
+func RequestUser(r *http.Request) string {
+	switch claims := r.Context().Value(UserContextKey).(type) {
+	case AuditIdentity:
+		if user := claims.AuditUser(); user != "" {
+			return user
+		}
+	case map[string]interface{}:
+		if user, ok := claims["username"].(string); ok && user != "" {
+			return user
+		}
+	}
+	return "anonymous"
+}
+
+// DiffHash returns the hex SHA-256 of a submitted manifest or patch, so audit records
+// can tell which content was applied without storing it
+func DiffHash(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
 
 // AuditLogLegacy logs detailed audit information for critical actions
 // This is a convenience function that builds an AuditLogEntry from parameters
@@ -176,38 +211,44 @@ const userContextKeyStr = "user"
 // Use LogAuditEntry with a complete AuditLogEntry structure instead for full audit information.
 // This function is kept for backward compatibility with existing code.
 func AuditLogLegacy(r *http.Request, action, resourceKind, resourceName, namespace string, success bool, err error, details map[string]interface{}) {
-	user := "anonymous"
-	// Try to get user from context - this will need to be adapted based on how Claims is structured
-	// For now, we'll use a simple approach
-	// Use the same context key as auth package (must match auth.userContextKey = "user")
-	if userVal := r.Context().Value(userContextKeyStr); userVal != nil {
-		// This will need to be updated when we move auth to its own package
-		if claims, ok := userVal.(interface{ Username() string }); ok {
-			user = claims.Username()
-		} else if claimsMap, ok := userVal.(map[string]interface{}); ok {
-			if u, ok := claimsMap["username"].(string); ok {
-				user = u
-			}
-		}
-	}
+	auditLogRequest(r, action, resourceKind, resourceName, namespace, "", success, err, details)
+}
 
-	// Get real client IP (handles proxies)
-	clientIP := GetClientIP(r)
+// AuditLog is a convenience wrapper that maintains backward compatibility
+// It calls AuditLogLegacy internally
+func AuditLog(r *http.Request, action, resourceKind, resourceName, namespace string, success bool, err error, details map[string]interface{}) {
+	AuditLogLegacy(r, action, resourceKind, resourceName, namespace, success, err, details)
+}
+
+// AuditLogChange records a change to a resource together with the hash of the submitted content
+// (see DiffHash)
+func AuditLogChange(r *http.Request, action, resourceKind, resourceName, namespace string, content []byte, success bool, err error, details map[string]interface{}) {
+	auditLogRequest(r, action, resourceKind, resourceName, namespace, DiffHash(content), success, err, details)
+}
 
+func auditLogRequest(r *http.Request, action, resourceKind, resourceName, namespace, diffHash string, success bool, err error, details map[string]interface{}) {
 	resource := resourceKind
 	if resourceName != "" {
 		resource = fmt.Sprintf("%s/%s", resourceKind, resourceName)
 	}
 
 	entry := AuditLogEntry{
-		User:      user,
-		IP:        clientIP,
+		Time:      time.Now().UTC(),
+		User:      RequestUser(r),
+		IP:        GetClientIP(r), // Real client IP (handles proxies)
+		Cluster:   r.URL.Query().Get("cluster"),
 		Action:    action,
 		Resource:  resource,
+		Kind:      resourceKind,
+		Name:      resourceName,
 		Namespace: namespace,
 		Success:   success,
+		DiffHash:  diffHash,
 		Details:   details,
 	}
+	if entry.Cluster == "" {
+		entry.Cluster = "default"
+	}
 
 	if err != nil {
 		entry.Error = err.Error()
@@ -215,12 +256,7 @@ func AuditLogLegacy(r *http.Request, action, resourceKind, resourceName, namespa
 
 	// Call the structured auditLogInternal function from logger.go
 	auditLogInternal(entry)
-}
-
-// AuditLog is a convenience wrapper that maintains backward compatibility
-// It calls AuditLogLegacy internally
-func AuditLog(r *http.Request, action, resourceKind, resourceName, namespace string, success bool, err error, details map[string]interface{}) {
-	AuditLogLegacy(r, action, resourceKind, resourceName, namespace, success, err, details)
+	dispatchAudit(entry)
 }
 
 // JSONResponse writes a JSON response with the given status code and data
@@ -2,8 +2,10 @@ package utils
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -499,3 +501,48 @@ func containsMiddle(s, substr string) bool {
 	}
 	return false
 }
+
+type testIdentity struct{ name string }
+
+func (i testIdentity) AuditUser() string { return i.name }
+
+func TestAuditLogChange_DispatchesToSinks(t *testing.T) {
+	Logger.SetOutput(io.Discard)
+	defer Logger.SetOutput(os.Stdout)
+	defer ResetAuditSinks()
+
+	var got []AuditLogEntry
+	AddAuditSink(func(entry AuditLogEntry) { got = append(got, entry) })
+
+	req := httptest.NewRequest("PUT", "/api/resource/yaml?cluster=prod", nil)
+	req = req.WithContext(context.WithValue(req.Context(), UserContextKey, testIdentity{name: "alice"}))
+
+	AuditLogChange(req, "update", "Deployment", "web", "shop", []byte("kind: Deployment"), false, errors.New("boom"), nil)
+	AuditLog(httptest.NewRequest("DELETE", "/api/resource", nil), "delete", "Pod", "p", "default", true, nil, nil)
+
+	if len(got) != 2 {
+		t.Fatalf("sink received %d entries, want 2", len(got))
+	}
+	entry := got[0]
+	if entry.User != "alice" || entry.Cluster != "prod" || entry.Kind != "Deployment" || entry.Name != "web" || entry.Namespace != "shop" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Success || entry.Error != "boom" {
+		t.Errorf("failure not recorded: %+v", entry)
+	}
+	if entry.DiffHash != DiffHash([]byte("kind: Deployment")) || len(entry.DiffHash) != 64 {
+		t.Errorf("DiffHash = %q", entry.DiffHash)
+	}
+	if entry.Time.IsZero() {
+		t.Error("entry time not set")
+	}
+	if got[1].User != "anonymous" || got[1].Cluster != "default" || got[1].DiffHash != "" {
+		t.Errorf("second entry = %+v", got[1])
+	}
+}
+
+func TestDiffHash_Empty(t *testing.T) {
+	if DiffHash(nil) != "" {
+		t.Error("DiffHash(nil) should be empty")
+	}
+}
@@ -37,17 +37,22 @@ import (
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 
 	"github.com/flaucha/DKonsole/backend/internal/api"
+	"github.com/flaucha/DKonsole/backend/internal/audit"
 	"github.com/flaucha/DKonsole/backend/internal/auth"
+	"github.com/flaucha/DKonsole/backend/internal/cache"
 	"github.com/flaucha/DKonsole/backend/internal/cluster"
 	"github.com/flaucha/DKonsole/backend/internal/helm"
 	"github.com/flaucha/DKonsole/backend/internal/k8s"
 	"github.com/flaucha/DKonsole/backend/internal/ldap"
 	"github.com/flaucha/DKonsole/backend/internal/logo"
 	"github.com/flaucha/DKonsole/backend/internal/models"
+	"github.com/flaucha/DKonsole/backend/internal/oidc"
 	"github.com/flaucha/DKonsole/backend/internal/pod"
 	"github.com/flaucha/DKonsole/backend/internal/prometheus"
+	"github.com/flaucha/DKonsole/backend/internal/recording"
 	"github.com/flaucha/DKonsole/backend/internal/server"
 	"github.com/flaucha/DKonsole/backend/internal/settings"
+	"github.com/flaucha/DKonsole/backend/internal/users"
 	"github.com/flaucha/DKonsole/backend/internal/utils"
 
 	_ "github.com/flaucha/DKonsole/backend/docs" // docs is generated by Swag CLI
@@ -131,6 +136,33 @@ func main() {
 		handlersModel.Metrics["default"] = metricsClient
 	}
 
+	// Audit records of user actions, queryable through /api/audit (store selected by AUDIT_STORE)
+	var auditClient kubernetes.Interface
+	if clientset != nil {
+		auditClient = clientset
+	}
+	auditService := audit.NewServiceFromEnv(auditClient)
+	utils.AddAuditSink(auditService.Record)
+	go auditService.Run(context.Background())
+
+	// Cluster registry: additional clusters are stored in a Secret and loaded at startup
+	clusterService := cluster.NewService(handlersModel)
+	clusterService.SetRepository(cluster.NewRepository(clientset))
+	if impersonation := cluster.ImpersonationConfigFromEnv(); impersonation.Enabled {
+		clusterService.SetImpersonation(impersonation)
+		utils.LogInfo("Kubernetes impersonation enabled: external users run with their own RBAC", map[string]interface{}{
+			"userPrefix":  impersonation.UserPrefix,
+			"groupPrefix": impersonation.GroupPrefix,
+		})
+	}
+	if clientset != nil {
+		if err := clusterService.LoadClusters(context.Background()); err != nil {
+			utils.LogWarn("Failed to load registered clusters", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	authService, err := auth.NewService(clientset, secretName)
 	if err != nil {
 		utils.LogError(err, "Failed to initialize auth service", nil)
@@ -174,8 +206,16 @@ func main() {
 			handlersModel.Metrics["default"] = newMetricsClient
 		}
 		handlersModel.Unlock()
+		auditService.SetClient(newClientset)
 
 		utils.LogInfo("Global K8s clients updated successfully", nil)
+
+		// Registered clusters may not have been loaded yet (e.g. first start in setup mode)
+		if err := clusterService.LoadClusters(context.Background()); err != nil {
+			utils.LogWarn("Failed to load registered clusters after reload", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
 	}
 
 	// Initialize LDAP service (non-blocking - will work even if LDAP is not configured)
@@ -187,11 +227,59 @@ func main() {
 	// We set it up here so it's available, but it won't block startup if LDAP is not configured
 	authService.SetLDAPAuthenticator(ldapService)
 
-	clusterService := cluster.NewService(handlersModel)
+	// Initialize OIDC service (disabled until configured through /api/oidc/config)
+	oidcService := oidc.NewServiceFactory(clientset).NewService()
+	oidcService.SetHandlersModel(handlersModel)
+	authService.SetOIDCAuthenticator(oidcService)
+
+	// Local users live in the auth Secret next to the setup admin and log in through the "core" IDP
+	usersService := users.NewServiceFactory(clientset, secretName).NewService()
+	usersService.SetHandlersModel(handlersModel)
+	if sessions := authService.Sessions(); sessions != nil {
+		usersService.SetSessionRevoker(sessions)
+	}
+	if apiTokens := authService.APITokens(); apiTokens != nil {
+		usersService.SetAPITokenRevoker(apiTokens)
+	}
+	usersService.SetTOTPResetter(authService)
+	authService.SetLocalUserAuthenticator(usersService)
+
 	k8sService := k8s.NewService(handlersModel, clusterService)
+
+	// Shared informer cache (RESOURCE_CACHE_ENABLED): lists and watches are served from memory
+	cacheConfig := cache.ConfigFromEnv()
+	if cacheConfig.Enabled && clusterService.ImpersonationEnabled() {
+		// The cache reads with the service account, which would bypass the users' own RBAC
+		utils.LogWarn("Resource cache disabled: not supported with Kubernetes impersonation", nil)
+		cacheConfig.Enabled = false
+	}
+	resourceCache := cache.NewManager(cacheConfig, func(cluster string) (dynamic.Interface, bool) {
+		handlersModel.RLock()
+		defer handlersModel.RUnlock()
+		client, ok := handlersModel.Dynamics[cluster]
+		if typed, isTyped := client.(*dynamic.DynamicClient); isTyped && typed == nil {
+			// Setup mode stores a nil client for the default cluster
+			return nil, false
+		}
+		return client, ok
+	})
+	if cacheConfig.Enabled {
+		k8sService.SetResourceCache(resourceCache)
+		go resourceCache.Run(context.Background())
+		utils.LogInfo("Resource cache enabled", map[string]interface{}{
+			"kinds":       cacheConfig.Kinds,
+			"idleTimeout": cacheConfig.IdleTimeout.String(),
+		})
+	}
+
 	apiService := api.NewService(clusterService)
 	helmService := helm.NewService(handlersModel, clusterService)
 	podService := pod.NewService(handlersModel, clusterService)
+
+	// Exec sessions are recorded as asciicast files when EXEC_RECORDING_STORE is set
+	recordingService := recording.NewServiceFromEnv()
+	podService.SetRecordingService(recordingService)
+	go recordingService.Run(context.Background())
 	prometheusService := prometheus.NewHTTPHandler(handlersModel.PrometheusURL, clusterService)
 
 	// Get namespace for logo ConfigMap (default to "dkonsole")
@@ -213,8 +301,13 @@ func main() {
 	router := server.NewRouter(server.Dependencies{
 		AuthService:       authService,
 		LDAPService:       ldapService,
+		OIDCService:       oidcService,
 		ClusterService:    clusterService,
+		UsersService:      usersService,
+		AuditService:      auditService,
+		RecordingService:  recordingService,
 		K8sService:        k8sService,
+		ResourceCache:     resourceCache,
 		APIService:        apiService,
 		HelmService:       helmService,
 		PodService:        podService,
@@ -1,7 +1,7 @@
 import React, { useState, useEffect } from 'react';
 import { useAuth } from '../context/AuthContext';
 import { useNavigate } from 'react-router-dom';
-import { Lock, User, Shield, Users } from 'lucide-react';
+import { Lock, User, Shield, Users, KeyRound } from 'lucide-react';
 import logoFullDark from '../assets/logo-full-dark.png';
 import logoFullLight from '../assets/logo-full-light.png';
 
@@ -9,9 +9,11 @@ const Login = () => {
     const [username, setUsername] = useState('');
     const [password, setPassword] = useState('');
     const [error, setError] = useState('');
+    const [mfaToken, setMfaToken] = useState('');
+    const [code, setCode] = useState('');
     const [ldapEnabled, setLdapEnabled] = useState(false);
     const [activeTab, setActiveTab] = useState('ldap'); // 'core' or 'ldap' - default to LDAP
-    const { login } = useAuth();
+    const { login, loginMFA } = useAuth();
     const navigate = useNavigate();
 
     // Get current theme and determine default logo immediately
@@ -74,13 +76,37 @@ const Login = () => {
         try {
             // Determine IDP based on active tab
             const idp = activeTab === 'ldap' ? 'ldap' : 'core';
-            await login(username, password, idp);
+            const result = await login(username, password, idp);
+            if (result && result.mfaRequired) {
+                // Password accepted: ask for the authenticator or recovery code
+                setMfaToken(result.mfaToken);
+                return;
+            }
             navigate('/');
         } catch {
             setError('Invalid username or password');
         }
     };
 
+    const handleMFASubmit = async (e) => {
+        e.preventDefault();
+        setError('');
+        try {
+            await loginMFA(mfaToken, code);
+            navigate('/');
+        } catch {
+            setError('Invalid or expired code');
+            setCode('');
+        }
+    };
+
+    const cancelMFA = () => {
+        setMfaToken('');
+        setCode('');
+        setPassword('');
+        setError('');
+    };
+
     // Theme classes - now relying on CSS variables via data-theme
     // bg-gray-900 adapts: Dark (Dark), Light (White), Cream (Cream)
     // text-white adapts: Dark (White), Light (Dark), Cream (Brown)
@@ -106,8 +132,47 @@ const Login = () => {
                     </div>
                 )}
 
+                {/* Second step for accounts with two-factor authentication */}
+                {mfaToken && (
+                    <form onSubmit={handleMFASubmit} className="space-y-6">
+                        <div>
+                            <label className="block text-sm font-medium text-gray-400 mb-2">Authentication code</label>
+                            <div className="relative">
+                                <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                                    <KeyRound size={18} className="text-gray-500" />
+                                </div>
+                                <input
+                                    type="text"
+                                    inputMode="numeric"
+                                    autoComplete="one-time-code"
+                                    autoFocus
+                                    value={code}
+                                    onChange={(e) => setCode(e.target.value)}
+                                    className="block w-full pl-10 pr-3 py-2 border rounded-md leading-5 placeholder-gray-500 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500 sm:text-sm bg-gray-900 text-gray-300 border-gray-700"
+                                    placeholder="6-digit code or recovery code"
+                                    required
+                                />
+                            </div>
+                        </div>
+
+                        <button
+                            type="submit"
+                            className="w-full flex justify-center py-2 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 transition-colors"
+                        >
+                            Verify
+                        </button>
+                        <button
+                            type="button"
+                            onClick={cancelMFA}
+                            className="w-full text-sm text-gray-400 hover:text-gray-300"
+                        >
+                            Back to sign in
+                        </button>
+                    </form>
+                )}
+
                 {/* Tabs for IDP selection when LDAP is enabled */}
-                {ldapEnabled && (
+                {!mfaToken && ldapEnabled && (
                     <div className="flex space-x-1 border-b border-gray-700 mb-6">
                         <button
                             type="button"
@@ -126,48 +191,50 @@ const Login = () => {
                     </div>
                 )}
 
-                <form onSubmit={handleSubmit} className="space-y-6">
-                    <div>
-                        <label className="block text-sm font-medium text-gray-400 mb-2">Username</label>
-                        <div className="relative">
-                            <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
-                                <User size={18} className="text-gray-500" />
+                {!mfaToken && (
+                    <form onSubmit={handleSubmit} className="space-y-6">
+                        <div>
+                            <label className="block text-sm font-medium text-gray-400 mb-2">Username</label>
+                            <div className="relative">
+                                <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                                    <User size={18} className="text-gray-500" />
+                                </div>
+                                <input
+                                    type="text"
+                                    value={username}
+                                    onChange={(e) => setUsername(e.target.value)}
+                                    className="block w-full pl-10 pr-3 py-2 border rounded-md leading-5 placeholder-gray-500 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500 sm:text-sm bg-gray-900 text-gray-300 border-gray-700"
+                                    placeholder="Enter username"
+                                    required
+                                />
                             </div>
-                            <input
-                                type="text"
-                                value={username}
-                                onChange={(e) => setUsername(e.target.value)}
-                                className="block w-full pl-10 pr-3 py-2 border rounded-md leading-5 placeholder-gray-500 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500 sm:text-sm bg-gray-900 text-gray-300 border-gray-700"
-                                placeholder="Enter username"
-                                required
-                            />
                         </div>
-                    </div>
 
-                    <div>
-                        <label className="block text-sm font-medium text-gray-400 mb-2">Password</label>
-                        <div className="relative">
-                            <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
-                                <Lock size={18} className="text-gray-500" />
+                        <div>
+                            <label className="block text-sm font-medium text-gray-400 mb-2">Password</label>
+                            <div className="relative">
+                                <div className="absolute inset-y-0 left-0 pl-3 flex items-center pointer-events-none">
+                                    <Lock size={18} className="text-gray-500" />
+                                </div>
+                                <input
+                                    type="password"
+                                    value={password}
+                                    onChange={(e) => setPassword(e.target.value)}
+                                    className="block w-full pl-10 pr-3 py-2 border rounded-md leading-5 placeholder-gray-500 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500 sm:text-sm bg-gray-900 text-gray-300 border-gray-700"
+                                    placeholder="Enter password"
+                                    required
+                                />
                             </div>
-                            <input
-                                type="password"
-                                value={password}
-                                onChange={(e) => setPassword(e.target.value)}
-                                className="block w-full pl-10 pr-3 py-2 border rounded-md leading-5 placeholder-gray-500 focus:outline-none focus:border-blue-500 focus:ring-1 focus:ring-blue-500 sm:text-sm bg-gray-900 text-gray-300 border-gray-700"
-                                placeholder="Enter password"
-                                required
-                            />
                         </div>
-                    </div>
 
-                    <button
-                        type="submit"
-                        className="w-full flex justify-center py-2 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 transition-colors"
-                    >
-                        Sign In
-                    </button>
-                </form>
+                        <button
+                            type="submit"
+                            className="w-full flex justify-center py-2 px-4 border border-transparent rounded-md shadow-sm text-sm font-medium text-white bg-blue-600 hover:bg-blue-700 focus:outline-none focus:ring-2 focus:ring-offset-2 focus:ring-blue-500 transition-colors"
+                        >
+                            Sign In
+                        </button>
+                    </form>
+                )}
             </div>
         </div>
     );
@@ -1,4 +1,4 @@
-import { render, screen, fireEvent } from '@testing-library/react';
+import { render, screen, fireEvent, waitFor } from '@testing-library/react';
 import { describe, it, expect, vi } from 'vitest';
 import Login from './Login';
 import { BrowserRouter } from 'react-router-dom';
@@ -66,4 +66,26 @@ describe('Login Component', () => {
         // Adjusted expectation to allow extra arguments (like idp='ldap' default)
         expect(loginMock).toHaveBeenCalledWith('testuser', 'password123', expect.anything());
     });
+
+    it('asks for a two-factor code when required', async () => {
+        const loginMock = vi.fn().mockResolvedValue({ mfaRequired: true, mfaToken: 'challenge' });
+        const loginMFAMock = vi.fn().mockResolvedValue(true);
+        render(
+            <BrowserRouter>
+                <AuthContext.Provider value={{ login: loginMock, loginMFA: loginMFAMock, setupStatus: { completed: true } }}>
+                    <Login />
+                </AuthContext.Provider>
+            </BrowserRouter>
+        );
+
+        fireEvent.change(screen.getByPlaceholderText(/enter username/i), { target: { value: 'admin' } });
+        fireEvent.change(screen.getByPlaceholderText(/enter password/i), { target: { value: 'password123' } });
+        fireEvent.click(screen.getByRole('button', { name: /sign in/i }));
+
+        const codeInput = await screen.findByPlaceholderText(/6-digit code/i);
+        fireEvent.change(codeInput, { target: { value: '123456' } });
+        fireEvent.click(screen.getByRole('button', { name: /verify/i }));
+
+        await waitFor(() => expect(loginMFAMock).toHaveBeenCalledWith('challenge', '123456'));
+    });
 });
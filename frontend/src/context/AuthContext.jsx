@@ -38,9 +38,23 @@ export const AuthProvider = ({ children }) => {
         }
     };
 
+    // Access tokens are short-lived: exchange the refresh cookie for a new one
+    const refreshSession = async () => {
+        try {
+            const res = await fetch('/api/auth/refresh', { method: 'POST' });
+            return res.ok;
+        } catch (error) {
+            logger.error('Session refresh failed:', error);
+            return false;
+        }
+    };
+
     const checkSession = async () => {
         try {
-            const res = await fetch('/api/me');
+            let res = await fetch('/api/me');
+            if (res.status === 401 && await refreshSession()) {
+                res = await fetch('/api/me');
+            }
             if (res.ok) {
                 const data = await res.json();
                 setUser(data);
@@ -52,6 +66,24 @@ export const AuthProvider = ({ children }) => {
         }
     };
 
+    // After a successful login, fetch full user data including permissions
+    const loadUser = async (fallback) => {
+        try {
+            const meRes = await fetch('/api/me');
+            if (meRes.ok) {
+                const meData = await meRes.json();
+                setUser(meData);
+            } else {
+                // Fallback to basic user data if /api/me fails
+                setUser(fallback);
+            }
+        } catch (meError) {
+            logger.error('Failed to fetch user data after login:', meError);
+            // Fallback to basic user data
+            setUser(fallback);
+        }
+    };
+
     const login = async (username, password, idp = '') => {
         try {
             const res = await fetch('/api/login', {
@@ -62,22 +94,12 @@ export const AuthProvider = ({ children }) => {
 
             if (res.ok) {
                 const data = await res.json();
-                // Token is handled by HttpOnly cookie
-                // After successful login, fetch full user data including permissions
-                try {
-                    const meRes = await fetch('/api/me');
-                    if (meRes.ok) {
-                        const meData = await meRes.json();
-                        setUser(meData);
-                    } else {
-                        // Fallback to basic user data if /api/me fails
-                        setUser({ username, role: data.role });
-                    }
-                } catch (meError) {
-                    logger.error('Failed to fetch user data after login:', meError);
-                    // Fallback to basic user data
-                    setUser({ username, role: data.role });
+                // Two-factor accounts get a challenge instead of a session
+                if (data.mfaRequired) {
+                    return { mfaRequired: true, mfaToken: data.mfaToken };
                 }
+                // Token is handled by HttpOnly cookie
+                await loadUser({ username, role: data.role });
                 return true;
             } else {
                 throw new Error('Invalid credentials');
@@ -88,9 +110,35 @@ export const AuthProvider = ({ children }) => {
         }
     };
 
+    // Second login step: TOTP or recovery code for the challenge returned by login
+    const loginMFA = async (mfaToken, code) => {
+        try {
+            const res = await fetch('/api/login/mfa', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ mfaToken, code }),
+            });
+
+            if (res.ok) {
+                const data = await res.json();
+                await loadUser({ role: data.role });
+                return true;
+            } else {
+                throw new Error('Invalid code');
+            }
+        } catch (error) {
+            logger.error('Two-factor login failed:', error);
+            throw error;
+        }
+    };
+
     const logout = async () => {
         try {
-            await fetch('/api/logout', { method: 'POST' });
+            const res = await fetch('/api/logout', { method: 'POST' });
+            if (res.status === 401 && await refreshSession()) {
+                // Revoke the session server-side even if the access token had expired
+                await fetch('/api/logout', { method: 'POST' });
+            }
         } catch (error) {
             logger.error('Logout failed:', error);
         }
@@ -99,7 +147,10 @@ export const AuthProvider = ({ children }) => {
 
     const authFetch = async (url, options = {}) => {
         // No need to manually attach token, cookies handle it
-        const res = await fetch(url, options);
+        let res = await fetch(url, options);
+        if (res.status === 401 && await refreshSession()) {
+            res = await fetch(url, options);
+        }
         if (res.status === 401) {
             setUser(null);
             throw new Error('Session expired');
@@ -108,7 +159,7 @@ export const AuthProvider = ({ children }) => {
     };
 
     return (
-        <AuthContext.Provider value={{ user, login, logout, loading, authFetch, setupRequired, checkSetupStatus }}>
+        <AuthContext.Provider value={{ user, login, loginMFA, logout, loading, authFetch, setupRequired, checkSetupStatus }}>
             {children}
         </AuthContext.Provider>
     );